@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/admin"
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/history"
+	"github.com/kqns91/kube-watcher/pkg/pipeline"
+	"github.com/kqns91/kube-watcher/pkg/stats"
+	"github.com/kqns91/kube-watcher/pkg/version"
+)
+
+// defaultAdminAddress is used when debug endpoints are enabled without the metrics endpoint
+const defaultAdminAddress = ":9090"
+
+// adminAddress returns the listen address of the admin server, which serves
+// the metrics, debug and admin API endpoints
+func adminAddress(c *config.Config) string {
+	if c.Metrics.Address != "" {
+		return c.Metrics.Address
+	}
+	return defaultAdminAddress
+}
+
+// registerPprof registers the net/http/pprof handlers under /debug/pprof/
+func registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// adminStatus describes the running instance for the admin API
+func adminStatus(s pipeline.State, startedAt time.Time) admin.Status {
+	c := s.Config
+	var lastReload *admin.ReloadStatus
+	if r := s.LastReload; r != nil {
+		lastReload = &admin.ReloadStatus{At: r.At}
+		if r.Err != nil {
+			lastReload.Error = r.Err.Error()
+		}
+	}
+	resources := make([]string, 0, len(c.Resources))
+	for _, r := range c.Resources {
+		resources = append(resources, r.Kind)
+	}
+	return admin.Status{
+		Version:     version.Get(),
+		StartedAt:   startedAt,
+		Uptime:      time.Since(startedAt).Round(time.Second).String(),
+		Cluster:     s.Cluster,
+		Namespace:   c.Namespace,
+		Namespaces:  c.WatchedNamespaces(),
+		Resources:   resources,
+		ConfigFiles: c.Files,
+		DryRun:      c.DryRun,
+		LastReload:  lastReload,
+	}
+}
+
+// adminComponents reports the state of the pipeline components for the admin API
+func adminComponents(s pipeline.State) admin.Components {
+	components := admin.Components{Breakers: make([]admin.BreakerStatus, 0, len(s.Breakers))}
+	if d := s.Deduplicator; d != nil {
+		m := d.Metrics()
+		components.Dedup = &admin.DedupStats{
+			Hits:        m.Hits,
+			Misses:      m.Misses,
+			Evictions:   m.Evictions,
+			Expirations: m.Expirations,
+			Size:        m.Size,
+			MaxSize:     m.MaxSize,
+		}
+	}
+	if b := s.Batcher; b != nil || len(s.RouteBatchers) > 0 {
+		components.Batcher = &admin.BatcherStats{}
+		if b != nil {
+			components.Batcher.Pending = b.Pending()
+		}
+		for name, rb := range s.RouteBatchers {
+			if components.Batcher.Routes == nil {
+				components.Batcher.Routes = make(map[string]int)
+			}
+			components.Batcher.Routes[name] = rb.Pending()
+		}
+	}
+	for _, cb := range s.Breakers {
+		components.Breakers = append(components.Breakers, admin.BreakerStatus{
+			Notifier: cb.Name(),
+			Open:     cb.IsOpen(),
+			Dropped:  cb.Dropped(),
+		})
+	}
+	return components
+}
+
+// unackedEvents converts the unacknowledged events for the /stats endpoint
+func unackedEvents(tracked []history.Tracked) []stats.UnackedEvent {
+	events := make([]stats.UnackedEvent, 0, len(tracked))
+	for _, t := range tracked {
+		events = append(events, stats.UnackedEvent{
+			ID:          t.ID,
+			ResourceKey: stats.ResourceKey{Kind: t.Kind, Namespace: t.Namespace, Name: t.Name},
+			EventType:   t.EventType,
+			Reason:      t.Reason,
+			NotifiedAt:  t.NotifiedAt,
+		})
+	}
+	return events
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+)
+
+func TestRegisterPprof(t *testing.T) {
+	mux := http.NewServeMux()
+	registerPprof(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/cmdline"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestAdminAddress(t *testing.T) {
+	if got := adminAddress(&config.Config{}); got != defaultAdminAddress {
+		t.Errorf("adminAddress() = %s, want %s", got, defaultAdminAddress)
+	}
+	cfg := &config.Config{Metrics: config.MetricsConfig{Address: ":8080"}}
+	if got := adminAddress(cfg); got != ":8080" {
+		t.Errorf("adminAddress() = %s, want :8080", got)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig implements the config subcommand and returns the exit code
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "dump" {
+		fmt.Fprintln(stderr, "Usage: kube-watcher config dump [-config path]")
+		return 2
+	}
+	return runConfigDump(args[1:], stdout, stderr)
+}
+
+// runConfigDump prints the effective configuration after includes, environment
+// variable overrides and defaults are applied, with secrets redacted
+func runConfigDump(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config dump", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "config/config.yaml", "Path to configuration file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+	redacted, err := cfg.Redacted()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to redact config: %v\n", err)
+		return 1
+	}
+
+	for _, file := range redacted.Files {
+		fmt.Fprintf(stdout, "# Loaded from %s\n", file)
+	}
+	enc := yaml.NewEncoder(stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(redacted); err != nil {
+		fmt.Fprintf(stderr, "Failed to encode config: %v\n", err)
+		return 1
+	}
+	return 0
+}
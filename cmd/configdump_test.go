@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConfigDump(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+namespace: production
+resources:
+  - kind: Pod
+notifier:
+  slack:
+    webhookUrl: "https://hooks.slack.com/services/T000/B000/SECRET"
+    template: "{{ .Name }}"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv("KW_CLUSTER_NAME", "tokyo")
+
+	var stdout, stderr bytes.Buffer
+	if code := runConfig([]string{"dump", "-config", configPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runConfig() = %d, stderr: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	if strings.Contains(out, "SECRET") {
+		t.Errorf("Secret was not redacted:\n%s", out)
+	}
+	// 環境変数とデフォルト値が反映された設定が出力される
+	for _, want := range []string{"webhookUrl: <redacted>", "clusterName: tokyo", "logLevel: info", "# Loaded from " + configPath} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunConfig_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runConfig(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("runConfig() = %d, want 2", code)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/reload"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+	"k8s.io/client-go/dynamic"
+)
+
+// newCRDWatcher creates a watcher for the KubeWatcher resource given as
+// [namespace/]name. Without a namespace, the namespace kube-watcher runs in is used.
+func newCRDWatcher(ref string) (*reload.CRDWatcher, error) {
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found {
+		namespace, name = watcher.CurrentNamespace(), ref
+	}
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid KubeWatcher resource %q, expected [namespace/]name", ref)
+	}
+
+	restConfig, err := watcher.RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	return reload.NewCRDWatcher(client, namespace, name), nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/eventstream"
+)
+
+// grpcPingTimeout is how long a keepalive ping may go unanswered before the
+// connection is closed
+const grpcPingTimeout = 20 * time.Second
+
+// newGRPCServer returns a server for the gRPC streaming API. gRPC clients
+// connect over TLS when a certificate is configured, and otherwise without
+// TLS using HTTP/2 prior knowledge (h2c). Idle connections are pinged, so
+// that streams of clients that went away are closed.
+func newGRPCServer(cfg config.GRPCConfig, stream *eventstream.Server) (*http.Server, error) {
+	server := &http.Server{
+		Addr:              cfg.Address,
+		Handler:           stream,
+		Protocols:         new(http.Protocols),
+		ReadHeaderTimeout: 5 * time.Second,
+		HTTP2: &http.HTTP2Config{
+			SendPingTimeout: time.Duration(cfg.KeepaliveSeconds) * time.Second,
+			PingTimeout:     grpcPingTimeout,
+		},
+	}
+	if cfg.TLS.CertFile == "" {
+		server.Protocols.SetUnencryptedHTTP2(true)
+		return server, nil
+	}
+
+	tlsConfig, err := newGRPCTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	server.Protocols.SetHTTP2(true)
+	server.TLSConfig = tlsConfig
+	return server, nil
+}
+
+// newGRPCTLSConfig builds the server TLS configuration. The certificate is
+// reloaded on each handshake so that rotated files are used.
+func newGRPCTLSConfig(cfg config.GRPCTLSConfig) (*tls.Config, error) {
+	if _, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile); err != nil {
+		return nil, fmt.Errorf("failed to load gRPC server certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load gRPC server certificate: %w", err)
+			}
+			return &cert, nil
+		},
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gRPC client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in gRPC client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/eventstream"
+)
+
+// writeServerCert writes a self-signed server certificate and key for localhost
+func writeServerCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestNewGRPCServer(t *testing.T) {
+	stream := eventstream.NewServer(eventstream.Options{})
+	defer stream.Close()
+
+	// 証明書がなければh2cで待ち受け、アイドル接続にpingを送る
+	server, err := newGRPCServer(config.GRPCConfig{Address: ":0", KeepaliveSeconds: 60}, stream)
+	if err != nil {
+		t.Fatalf("newGRPCServer() error = %v", err)
+	}
+	if server.TLSConfig != nil || !server.Protocols.UnencryptedHTTP2() {
+		t.Error("Expected h2c without a certificate")
+	}
+	if server.HTTP2.SendPingTimeout != time.Minute || server.HTTP2.PingTimeout != grpcPingTimeout {
+		t.Errorf("Unexpected keepalive %+v", server.HTTP2)
+	}
+
+	certFile, keyFile := writeServerCert(t, t.TempDir())
+	server, err = newGRPCServer(config.GRPCConfig{
+		Address: ":0",
+		TLS:     config.GRPCTLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile},
+	}, stream)
+	if err != nil {
+		t.Fatalf("newGRPCServer() error = %v", err)
+	}
+	if server.TLSConfig == nil || server.Protocols.UnencryptedHTTP2() || !server.Protocols.HTTP2() {
+		t.Error("Expected HTTP/2 over TLS with a certificate")
+	}
+	if server.TLSConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Error("Expected client certificates to be required with a client CA")
+	}
+	if cert, err := server.TLSConfig.GetCertificate(nil); err != nil || cert == nil {
+		t.Errorf("GetCertificate() error = %v", err)
+	}
+
+	if _, err := newGRPCServer(config.GRPCConfig{TLS: config.GRPCTLSConfig{CertFile: "/nonexistent/tls.crt", KeyFile: "/nonexistent/tls.key"}}, stream); err == nil {
+		t.Error("Expected error for a missing certificate")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+// logLevelVar holds the level of the default logger so that reloads can change it
+var logLevelVar = new(slog.LevelVar)
+
+// setupLogging installs the default logger writing to w in the given format
+// ("text" or "json") at the given level. The standard log package is routed
+// through it as well.
+func setupLogging(w io.Writer, format, level string) {
+	logLevelVar.Set(logLevel(level))
+	opts := &slog.HandlerOptions{Level: logLevelVar}
+
+	var handler slog.Handler
+	if format == "json" {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// logLevel converts a config log level to a slog level
+func logLevel(name string) slog.Level {
+	switch name {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// fatal logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
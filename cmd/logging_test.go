@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestSetupLogging(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var buf bytes.Buffer
+	setupLogging(&buf, "json", "warn")
+
+	event := &watcher.Event{Kind: "Pod", Namespace: "prod", Name: "api-0", EventType: "DELETED"}
+	slog.Info("Notification sent", event.LogAttrs("destination", "slack")...)
+	if buf.Len() != 0 {
+		t.Errorf("Expected info to be suppressed at warn level, got %s", buf.String())
+	}
+
+	slog.Warn("Notification failed", event.LogAttrs("destination", "slack")...)
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]string{"kind": "Pod", "namespace": "prod", "name": "api-0", "eventType": "DELETED", "destination": "slack"} {
+		if line[key] != want {
+			t.Errorf("%s = %v, want %s", key, line[key], want)
+		}
+	}
+
+	// 再読み込みでレベルを変更できる
+	buf.Reset()
+	setupLogging(&buf, "text", "debug")
+	slog.Debug("Event filtered out", event.LogAttrs()...)
+	if !bytes.Contains(buf.Bytes(), []byte("level=DEBUG")) || !bytes.Contains(buf.Bytes(), []byte("kind=Pod")) {
+		t.Errorf("Unexpected text output: %s", buf.String())
+	}
+}
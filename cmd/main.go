@@ -3,218 +3,309 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/kqns91/kube-watcher/pkg/admin"
 	"github.com/kqns91/kube-watcher/pkg/batcher"
 	"github.com/kqns91/kube-watcher/pkg/config"
-	"github.com/kqns91/kube-watcher/pkg/dedup"
-	"github.com/kqns91/kube-watcher/pkg/filter"
-	"github.com/kqns91/kube-watcher/pkg/formatter"
-	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/eventstream"
+	"github.com/kqns91/kube-watcher/pkg/history"
+	"github.com/kqns91/kube-watcher/pkg/metrics"
+	"github.com/kqns91/kube-watcher/pkg/pipeline"
 	"github.com/kqns91/kube-watcher/pkg/reload"
+	"github.com/kqns91/kube-watcher/pkg/stats"
+	"github.com/kqns91/kube-watcher/pkg/version"
 	"github.com/kqns91/kube-watcher/pkg/watcher"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "template-test" {
+		os.Exit(runTemplateTest(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfig(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "version" {
+		os.Exit(runVersion(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	configPath := flag.String("config", "config/config.yaml", "Path to configuration file")
+	crdRef := flag.String("crd", "", "Read the configuration from this KubeWatcher resource ([namespace/]name) instead of the file")
+	var overrides config.Overrides
+	flag.StringVar(&overrides.Namespace, "namespace", "", "Namespace to monitor (overrides the config)")
+	flag.StringVar(&overrides.WebhookURL, "webhook-url", "", "Slack webhook URL (overrides the config)")
+	flag.StringVar(&overrides.LogLevel, "log-level", "", "Log level: debug, info, warn or error (overrides the config)")
+	flag.StringVar(&overrides.LogFormat, "log-format", "", "Log format: text or json (overrides the config)")
+	flag.BoolVar(&overrides.DryRun, "dry-run", false, "Print notifications to stdout instead of sending them")
+	enablePprof := flag.Bool("pprof", false, "Serve net/http/pprof profiles under /debug/pprof/ on the admin server")
+	showVersion := flag.Bool("version", false, "Print the version and exit")
 	flag.Parse()
 
-	// Load configuration
-	cfg, err := config.LoadConfig(*configPath)
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+	if *showVersion {
+		os.Exit(runVersion(nil, os.Stdout, os.Stderr))
 	}
 
-	log.Printf("Starting kube-watcher for namespace: %s", cfg.Namespace)
-
-	// Components that can be reloaded
+	// Load configuration (flags > KW_* environment variables > file or KubeWatcher resource)
 	var (
-		fmt           *formatter.Formatter
-		eventFilter   *filter.Filter
-		deduplicator  *dedup.Deduplicator
-		eventBatcher  *batcher.Batcher
-		slackNotifier *notifier.SlackNotifier
-		mu            sync.RWMutex // Protects the components above
+		cfg        *config.Config
+		crdWatcher *reload.CRDWatcher
+		err        error
 	)
-
-	// Initialize components
-	initComponents := func(c *config.Config) error {
-		mu.Lock()
-		defer mu.Unlock()
-
-		// Initialize formatter
-		newFmt, err := formatter.NewFormatter(c.Notifier.Slack.Template)
+	if *crdRef != "" {
+		crdWatcher, err = newCRDWatcher(*crdRef)
 		if err != nil {
-			return err
+			fatal("Failed to create KubeWatcher client", "error", err)
 		}
-		fmt = newFmt
+		crdWatcher.SetOverrides(overrides)
+		cfg, err = crdWatcher.Load(context.Background())
+	} else {
+		cfg, err = config.LoadConfigWithOverrides(*configPath, overrides)
+	}
+	if err != nil {
+		fatal("Failed to load config", "error", err)
+	}
 
-		// Initialize notifier
-		slackNotifier = notifier.NewSlackNotifier(c.Notifier.Slack.WebhookURL)
+	setupLogging(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	slog.Info("Starting kube-watcher", "version", version.Get().String(), "namespaces", cfg.WatchedNamespaces())
+
+	// Tracing is configured on startup only
+	tracer := newTracer(cfg)
+	if tracer != nil {
+		slog.Info("Tracing enabled", "endpoint", cfg.Tracing.Endpoint, "sampleRatio", cfg.Tracing.SampleRatio)
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracer.Shutdown(ctx); err != nil {
+				slog.Warn("Failed to export remaining spans", "error", err)
+			}
+		}()
+	}
 
-		// Initialize filter
-		eventFilter = filter.NewFilter(c)
+	// The admin server serves the metrics, debug, stats and admin API endpoints
+	startedAt := time.Now()
+	slackInteractions := cfg.Acks.Enabled && cfg.Acks.SlackSigningSecret.IsSet()
+	adminEnabled := cfg.Metrics.Enabled || *enablePprof || cfg.Admin.Enabled || slackInteractions
 
-		// Initialize or update deduplicator
-		if c.Deduplication.Enabled {
-			if deduplicator != nil {
-				deduplicator.Stop()
-			}
-			ttl := time.Duration(c.Deduplication.TTLSeconds) * time.Second
-			deduplicator = dedup.NewDeduplicator(ttl, c.Deduplication.MaxCacheSize)
-			log.Printf("Deduplication enabled: TTL=%v, MaxCacheSize=%d", ttl, c.Deduplication.MaxCacheSize)
-		} else if deduplicator != nil {
-			deduplicator.Stop()
-			deduplicator = nil
-			log.Println("Deduplication disabled")
-		}
+	// Recent pipeline activity served on the admin server's /stats endpoint
+	var aggregator *stats.Aggregator
+	if adminEnabled {
+		aggregator = stats.NewAggregator(stats.DefaultWindow)
+	}
 
-		// Initialize or update batcher
-		if c.Batching.Enabled {
-			if eventBatcher != nil {
-				eventBatcher.Stop()
-			}
+	// Per-event counters and anomaly gauges exported on the metrics endpoint
+	var eventMetrics *metrics.EventMetrics
+	if cfg.Metrics.Enabled && cfg.Metrics.Events {
+		eventMetrics = metrics.NewEventMetrics()
+	}
 
-			// Create batch handler
-			batchHandler := func(batch *batcher.Batch) {
-				// Convert batcher.Batch to formatter.EventBatch
-				formatterBatch := &formatter.EventBatch{
-					Events:    batch.Events,
-					StartTime: batch.StartTime,
-					EndTime:   batch.EndTime,
-				}
+	// Processed events, kept on disk when history is enabled and otherwise in
+	// memory, served by the admin API
+	var (
+		eventHistory *history.Store
+		recentEvents *history.Recent
+	)
+	if cfg.History.Enabled {
+		eventHistory, err = history.Open(cfg.History.Path, time.Duration(cfg.History.RetentionHours)*time.Hour)
+		if err != nil {
+			fatal("Failed to open event history", "error", err)
+		}
+		defer eventHistory.Close()
+		slog.Info("Event history enabled", "path", cfg.History.Path, "retentionHours", cfg.History.RetentionHours)
+	} else if cfg.Admin.Enabled {
+		recentEvents = history.NewRecent(cfg.Admin.RecentEvents)
+	}
 
-				// Format batch message
-				mu.RLock()
-				currentFormatter := fmt
-				currentNotifier := slackNotifier
-				currentConfig := c
-				mu.RUnlock()
-
-				mode := formatter.BatchMode(currentConfig.Batching.Mode)
-				slackMessage := currentFormatter.FormatBatchSlackMessage(
-					formatterBatch,
-					mode,
-					currentConfig.Batching.Smart.MaxEventsPerGroup,
-					currentConfig.Batching.Smart.AlwaysShowDetails,
-				)
-
-				// Send batch notification
-				if err := currentNotifier.SendMessage(slackMessage); err != nil {
-					log.Printf("Failed to send batch notification: %v", err)
-					return
+	// Acknowledgements of the notified critical events, kept next to the history
+	var acks *history.Acks
+	if cfg.Acks.Enabled {
+		acks, err = history.OpenAcks(cfg.History.Path, time.Duration(cfg.History.RetentionHours)*time.Hour)
+		if err != nil {
+			fatal("Failed to open acknowledgements", "error", err)
+		}
+		defer acks.Close()
+		aggregator.SetUnacknowledged(func() []stats.UnackedEvent {
+			return unackedEvents(acks.List(true))
+		})
+		slog.Info("Acknowledgement tracking enabled", "unacknowledged", len(acks.List(true)))
+	}
+
+	// gRPC streaming API serving the events that are notified, created once the pipeline exists
+	var eventStream *eventstream.Server
+
+	// The event pipeline. The observers above record the events through its hooks.
+	p, err := pipeline.New(cfg, pipeline.Options{
+		Tracer: tracer,
+		Acks:   acks,
+		Hooks: pipeline.Hooks{
+			OnAccepted: func(event *watcher.Event) {
+				aggregator.RecordEvent(event)
+				eventMetrics.Record(event)
+				recentEvents.Add(event)
+				if eventHistory != nil {
+					if err := eventHistory.Add(event); err != nil {
+						slog.Error("Failed to record event history", event.LogAttrs("error", err)...)
+					}
 				}
+			},
+			OnDedup: func(_ *watcher.Event, duplicate bool) {
+				aggregator.RecordDedup(duplicate)
+			},
+			OnNotify: func(event *watcher.Event) {
+				eventStream.Publish(event)
+			},
+			OnBatch: func(batch *batcher.Batch) {
+				aggregator.RecordBatch(len(batch.Events))
+			},
+			OnConfig: func(c *config.Config) {
+				setupLogging(os.Stderr, c.LogFormat, c.LogLevel)
+			},
+		},
+	})
+	if err != nil {
+		fatal("Failed to initialize components", "error", err)
+	}
 
-				log.Printf("Batch notification sent: %d events", len(batch.Events))
-			}
+	if cfg.Notifier.StartupMessage {
+		p.Broadcast(startupMessage(strings.Join(cfg.WatchedNamespaces(), ", "), p.State().Cluster))
+	}
 
-			// Create batcher config
-			batchConfig := batcher.Config{
-				Enabled:       c.Batching.Enabled,
-				WindowSeconds: c.Batching.WindowSeconds,
-				Mode:          batcher.BatchMode(c.Batching.Mode),
-				Smart: batcher.SmartConfig{
-					MaxEventsPerGroup: c.Batching.Smart.MaxEventsPerGroup,
-					MaxTotalEvents:    c.Batching.Smart.MaxTotalEvents,
-					AlwaysShowDetails: c.Batching.Smart.AlwaysShowDetails,
-				},
-			}
+	// Setup the admin server for the metrics and debug endpoints
+	adminMux := http.NewServeMux()
+	if cfg.Metrics.Enabled {
+		registry := metrics.NewRegistry()
+		p.RegisterMetrics(registry)
 
-			eventBatcher = batcher.NewBatcher(batchConfig, batchHandler)
-			log.Printf("Batching enabled: Window=%ds, Mode=%s", c.Batching.WindowSeconds, c.Batching.Mode)
-		} else if eventBatcher != nil {
-			eventBatcher.Stop()
-			eventBatcher = nil
-			log.Println("Batching disabled")
+		if eventMetrics != nil {
+			eventMetrics.Register(registry)
 		}
 
-		return nil
+		adminMux.Handle(cfg.Metrics.Path, registry.Handler())
+		slog.Info("Metrics endpoint enabled", "address", cfg.Metrics.Address, "path", cfg.Metrics.Path)
 	}
-
-	// Initialize components with initial config
-	if err := initComponents(cfg); err != nil {
-		log.Fatalf("Failed to initialize components: %v", err)
+	if *enablePprof {
+		registerPprof(adminMux)
+		slog.Info("pprof endpoints enabled", "address", adminAddress(cfg), "path", "/debug/pprof/")
 	}
-	if deduplicator != nil {
-		defer deduplicator.Stop()
+	if aggregator != nil {
+		adminMux.Handle("/stats", aggregator.Handler())
+		slog.Info("Stats endpoint enabled", "address", adminAddress(cfg), "path", "/stats")
 	}
-	if eventBatcher != nil {
-		defer eventBatcher.Stop()
+	if slackInteractions {
+		adminMux.Handle(admin.SlackInteractionsPath, admin.NewSlackHandler(admin.SlackOptions{
+			SigningSecret: func() (string, error) {
+				return pipeline.SecretSource(p.State().Config.Acks.SlackSigningSecret).Resolve()
+			},
+			Acks: acks,
+		}))
+		slog.Info("Slack interactions endpoint enabled", "address", adminAddress(cfg), "path", admin.SlackInteractionsPath)
 	}
-
-	// Create event handler
-	eventHandler := func(event *watcher.Event) {
-		// Lock components for reading
-		mu.RLock()
-		currentFilter := eventFilter
-		currentDedup := deduplicator
-		currentBatcher := eventBatcher
-		currentFormatter := fmt
-		currentNotifier := slackNotifier
-		mu.RUnlock()
-
-		// Apply filters
-		if !currentFilter.ShouldProcess(event) {
-			log.Printf("Event filtered out: %s %s/%s (%s)", event.Kind, event.Namespace, event.Name, event.EventType)
-			return
+	if adminEnabled {
+		adminServer := &http.Server{
+			Addr:              adminAddress(cfg),
+			Handler:           adminMux,
+			ReadHeaderTimeout: 5 * time.Second,
 		}
-
-		// Apply deduplication if enabled
-		if currentDedup != nil {
-			key := dedup.EventKey{
-				Kind:      event.Kind,
-				Namespace: event.Namespace,
-				Name:      event.Name,
-				EventType: event.EventType,
-			}
-			if !currentDedup.ShouldProcess(key, event) {
-				log.Printf("Event deduplicated: %s %s/%s (%s)", event.Kind, event.Namespace, event.Name, event.EventType)
-				return
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("Admin server error", "error", err)
 			}
-		}
+		}()
+		defer adminServer.Close()
+	}
 
-		// If batching is enabled, add to batcher
-		if currentBatcher != nil {
-			currentBatcher.Add(event)
-			log.Printf("Event added to batch: %s %s/%s (%s)", event.Kind, event.Namespace, event.Name, event.EventType)
-			return
+	// gRPC streaming API serving the events that are notified
+	if cfg.GRPC.Enabled {
+		eventStream = eventstream.NewServer(eventstream.Options{
+			Token: func() (string, error) {
+				source := pipeline.SecretSource(p.State().Config.GRPC.Token)
+				if !source.IsSet() {
+					return "", nil
+				}
+				return source.Resolve()
+			},
+			BufferSize: cfg.GRPC.BufferSize,
+		})
+		grpcServer, err := newGRPCServer(cfg.GRPC, eventStream)
+		if err != nil {
+			fatal("Failed to create gRPC server", "error", err)
 		}
-
-		// Otherwise, send immediately
-		// Format message as Slack attachment
-		slackMessage := currentFormatter.FormatSlackMessage(event)
-
-		// Send notification
-		if err := currentNotifier.SendMessage(slackMessage); err != nil {
-			log.Printf("Failed to send notification: %v", err)
-			return
+		go func() {
+			var err error
+			if grpcServer.TLSConfig != nil {
+				err = grpcServer.ListenAndServeTLS("", "")
+			} else {
+				err = grpcServer.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				slog.Error("gRPC server error", "error", err)
+			}
+		}()
+		defer grpcServer.Close()
+		if !cfg.GRPC.Token.IsSet() {
+			slog.Warn("gRPC streaming API is enabled without a token")
 		}
-
-		log.Printf("Notification sent: %s %s/%s (%s)", event.Kind, event.Namespace, event.Name, event.EventType)
-	}
-
-	// Initialize watcher
-	w, err := watcher.NewWatcher(cfg, eventHandler)
-	if err != nil {
-		log.Fatalf("Failed to create watcher: %v", err)
+		slog.Info("gRPC streaming API enabled", "address", cfg.GRPC.Address, "method", eventstream.SubscribePath, "tls", grpcServer.TLSConfig != nil)
 	}
 
 	// Setup config hot-reload
-	configWatcher, err := reload.NewConfigWatcher(*configPath)
-	if err != nil {
-		log.Printf("Failed to create config watcher: %v (hot-reload disabled)", err)
+	var (
+		reloadNow  func() // Triggered by SIGHUP
+		stopReload func()
+	)
+	if crdWatcher != nil {
+		crdWatcher.AddCallback(p.Reload)
+		crdWatcher.AddErrorCallback(p.ReloadFailed)
+		crdWatcher.Start()
+		reloadNow = crdWatcher.Reload
+		stopReload = crdWatcher.Stop
+	} else if configWatcher, err := reload.NewConfigWatcher(*configPath); err != nil {
+		slog.Warn("Failed to create config watcher, hot-reload disabled", "error", err)
 	} else {
-		configWatcher.AddCallback(func(newCfg *config.Config) error {
-			log.Printf("Applying new configuration for namespace: %s", newCfg.Namespace)
-			return initComponents(newCfg)
-		})
+		configWatcher.SetOverrides(overrides)
+		configWatcher.SetDebounce(time.Duration(cfg.Reload.DebounceMs) * time.Millisecond)
+		configWatcher.AddCallback(p.Reload)
+		configWatcher.AddErrorCallback(p.ReloadFailed)
 		configWatcher.Start()
-		defer configWatcher.Stop()
+		reloadNow = configWatcher.Reload
+		stopReload = configWatcher.Stop
+	}
+
+	// The admin API is registered once reloadNow is known
+	if cfg.Admin.Enabled {
+		token := func() (string, error) {
+			source := pipeline.SecretSource(p.State().Config.Admin.Token)
+			if !source.IsSet() {
+				return "", nil
+			}
+			return source.Resolve()
+		}
+		var events history.Lister = recentEvents
+		if eventHistory != nil {
+			events = eventHistory
+		}
+		adminMux.Handle(admin.Prefix, admin.NewHandler(admin.Options{
+			Token: token,
+			Status: func() admin.Status {
+				return adminStatus(p.State(), startedAt)
+			},
+			Components: func() admin.Components {
+				return adminComponents(p.State())
+			},
+			Silences: p.Silences(),
+			Events:   events,
+			Acks:     acks,
+			Reload:   reloadNow,
+		}))
+		if !cfg.Admin.Token.IsSet() {
+			slog.Warn("Admin API is enabled without a token")
+		}
+		slog.Info("Admin API enabled", "address", adminAddress(cfg), "path", admin.Prefix)
 	}
 
 	// Setup signal handling
@@ -222,19 +313,53 @@ func main() {
 	defer cancel()
 
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		<-sigCh
-		log.Println("Received shutdown signal, stopping...")
-		cancel()
+		for sig := range sigCh {
+			if sig == syscall.SIGHUP {
+				if reloadNow == nil {
+					slog.Warn("Received SIGHUP, but hot-reload is disabled")
+					continue
+				}
+				slog.Info("Received SIGHUP")
+				reloadNow()
+				continue
+			}
+			slog.Info("Received shutdown signal, stopping...")
+			cancel()
+			return
+		}
 	}()
 
-	// Start watching
-	log.Println("Starting watchers...")
-	if err := w.Start(ctx); err != nil {
-		log.Fatalf("Watcher error: %v", err)
+	// Start watching. Start returns after the informers and their running event
+	// handlers have stopped and the pipeline is drained.
+	slog.Info("Starting watchers...")
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- p.Start(ctx)
+	}()
+
+	var watchErr error
+	select {
+	case watchErr = <-stopped:
+	case <-ctx.Done():
+		if stopReload != nil {
+			stopReload()
+		}
+		// End the event streams before the pending notifications are drained
+		eventStream.Close()
+		timeout := time.Duration(p.State().Config.Shutdown.TimeoutSeconds) * time.Second
+		slog.Info("Draining pending notifications", "timeout", timeout)
+		select {
+		case watchErr = <-stopped:
+		case <-time.After(timeout):
+			slog.Warn("Shutdown timeout exceeded, pending notifications may be lost", "timeout", timeout)
+		}
+	}
+	if watchErr != nil {
+		fatal("Watcher error", "error", watchErr)
 	}
 
-	log.Println("kube-watcher stopped")
+	slog.Info("kube-watcher stopped")
 }
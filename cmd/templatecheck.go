@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/pipeline"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// runTemplateTest implements the template-test subcommand.
+// It renders all configured templates against sample events and returns the exit code.
+func runTemplateTest(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("template-test", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "config/config.yaml", "Path to configuration file")
+	eventPath := fs.String("event", "", "Path to a JSON file with a sample event or a list of events")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	f, err := pipeline.NewFormatter(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to parse templates: %v\n", err)
+		return 1
+	}
+
+	events := sampleEvents()
+	if *eventPath != "" {
+		events, err = loadSampleEvents(*eventPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to load sample events: %v\n", err)
+			return 1
+		}
+	}
+	for _, event := range events {
+		if event.Cluster == "" {
+			event.Cluster = cfg.ClusterName
+		}
+	}
+
+	for _, event := range events {
+		fmt.Fprintf(stdout, "== %s %s/%s (%s)\n", event.Kind, event.Namespace, event.Name, event.EventType)
+		rendered, err := f.RenderTemplates(event)
+		if err != nil {
+			fmt.Fprintf(stderr, "Template error: %v\n", err)
+			return 1
+		}
+		for _, r := range rendered {
+			fmt.Fprintf(stdout, "--- %s\n%s\n", r.Name, r.Output)
+		}
+	}
+
+	fmt.Fprintf(stdout, "OK: all templates rendered for %d sample events\n", len(events))
+	return 0
+}
+
+// loadSampleEvents reads a single event or a list of events from a JSON file
+func loadSampleEvents(path string) ([]*watcher.Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*watcher.Event
+	if err := json.Unmarshal(data, &events); err == nil {
+		return events, nil
+	}
+
+	var event watcher.Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	return []*watcher.Event{&event}, nil
+}
+
+// sampleEvents returns built-in events covering the common resource kinds
+func sampleEvents() []*watcher.Event {
+	now := time.Now()
+	return []*watcher.Event{
+		{
+			Kind:       "Pod",
+			Namespace:  "default",
+			Name:       "web-7d4b9c-x2k8p",
+			EventType:  "ADDED",
+			Timestamp:  now,
+			Labels:     map[string]string{"app": "web"},
+			Status:     "Running",
+			Containers: []watcher.ContainerInfo{{Name: "web", Image: "nginx:1.27"}},
+		},
+		{
+			Kind:       "Deployment",
+			Namespace:  "default",
+			Name:       "web",
+			EventType:  "UPDATED",
+			Timestamp:  now,
+			Labels:     map[string]string{"app": "web"},
+			Replicas:   &watcher.ReplicaInfo{Desired: 5, Ready: 3, Current: 5},
+			Containers: []watcher.ContainerInfo{{Name: "web", Image: "nginx:1.27"}},
+			Changes:    []watcher.FieldChange{{Field: "replicas", Old: "3", New: "5"}},
+		},
+		{
+			Kind:        "Service",
+			Namespace:   "default",
+			Name:        "web",
+			EventType:   "DELETED",
+			Timestamp:   now,
+			Labels:      map[string]string{"app": "web"},
+			ServiceType: "ClusterIP",
+		},
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplateTestConfig(t *testing.T, template string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `namespace: default
+resources:
+  - kind: Pod
+notifier:
+  slack:
+    webhookUrl: "https://hooks.slack.com/test"
+    template: '` + template + `'
+    templates:
+      DELETED: "{{ .Kind }} {{ .Name }} was removed"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	return path
+}
+
+func TestRunTemplateTest(t *testing.T) {
+	configPath := writeTemplateTestConfig(t, "{{ .Kind }} {{ .Namespace }}/{{ .Name }} {{ .Status }}")
+
+	var stdout, stderr bytes.Buffer
+	if code := runTemplateTest([]string{"--config", configPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	if !strings.Contains(out, "--- templates.DELETED\nPod web-7d4b9c-x2k8p was removed") {
+		t.Errorf("Expected per-event-type template output, got:\n%s", out)
+	}
+	if !strings.Contains(out, "OK: all templates rendered") {
+		t.Errorf("Expected success summary, got:\n%s", out)
+	}
+}
+
+func TestRunTemplateTest_UndefinedField(t *testing.T) {
+	configPath := writeTemplateTestConfig(t, "{{ .Kind }} {{ .Namespce }}")
+
+	var stdout, stderr bytes.Buffer
+	if code := runTemplateTest([]string{"--config", configPath}, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Namespce") {
+		t.Errorf("Expected error to mention the undefined field, got %q", stderr.String())
+	}
+}
+
+func TestRunTemplateTest_SampleEventFile(t *testing.T) {
+	configPath := writeTemplateTestConfig(t, "{{ .Kind }}/{{ .Name }} {{ .Reason }}")
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	sample := `{"kind": "Pod", "namespace": "prod", "name": "api-0", "eventType": "UPDATED", "reason": "OOMKilled"}`
+	if err := os.WriteFile(eventPath, []byte(sample), 0644); err != nil {
+		t.Fatalf("Failed to write sample event: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runTemplateTest([]string{"--config", configPath, "--event", eventPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Pod/api-0 OOMKilled") {
+		t.Errorf("Expected sample event to be rendered, got:\n%s", stdout.String())
+	}
+}
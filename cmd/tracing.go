@@ -0,0 +1,43 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/tracing"
+	"github.com/kqns91/kube-watcher/pkg/version"
+)
+
+// newTracer creates the tracer, or returns nil when tracing is disabled
+func newTracer(c *config.Config) *tracing.Tracer {
+	t := c.Tracing
+	if !t.Enabled {
+		return nil
+	}
+	return tracing.NewTracer(tracing.Config{
+		Endpoint:       t.Endpoint,
+		ServiceName:    t.ServiceName,
+		ServiceVersion: version.Get().Version,
+		Resource:       tracingResource(c),
+		Headers:        t.Headers,
+		Sampler:        tracing.ParentBased(tracing.TraceIDRatioBased(t.SampleRatio)),
+	})
+}
+
+// tracingResource returns the configured resource attributes, with the
+// cluster name unless configured otherwise
+func tracingResource(c *config.Config) []tracing.Attribute {
+	var attrs []tracing.Attribute
+	if _, ok := c.Tracing.ResourceAttributes["k8s.cluster.name"]; !ok && c.ClusterName != "" {
+		attrs = append(attrs, tracing.String("k8s.cluster.name", c.ClusterName))
+	}
+	keys := make([]string, 0, len(c.Tracing.ResourceAttributes))
+	for k := range c.Tracing.ResourceAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, tracing.String(k, c.Tracing.ResourceAttributes[k]))
+	}
+	return attrs
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/kqns91/kube-watcher/pkg/version"
+)
+
+// runVersion implements the version subcommand and returns the exit code
+func runVersion(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "Print the build metadata as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	info := version.Get()
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			fmt.Fprintf(stderr, "Failed to encode version: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stdout, "kube-watcher %s\n", info)
+	return 0
+}
+
+// startupMessage is posted to the Slack destinations when notifier.startupMessage is set
+func startupMessage(namespace, cluster string) string {
+	watching := "namespace *" + namespace + "*"
+	if namespace == "" {
+		watching = "cluster-scoped resources"
+	}
+	text := ":rocket: kube-watcher " + version.Get().String() + " started watching " + watching
+	if cluster != "" {
+		text += " in cluster *" + cluster + "*"
+	}
+	return text
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/version"
+)
+
+func TestRunVersion(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runVersion(nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("runVersion() = %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "kube-watcher "+version.Version) {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := runVersion([]string{"-json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runVersion(-json) = %d, stderr: %s", code, stderr.String())
+	}
+	var info version.Info
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil || info.Version != version.Version {
+		t.Errorf("Unexpected JSON output %q (err=%v)", stdout.String(), err)
+	}
+}
+
+func TestStartupMessage(t *testing.T) {
+	msg := startupMessage("prod", "tokyo")
+	if !strings.Contains(msg, "namespace *prod*") || !strings.Contains(msg, "cluster *tokyo*") {
+		t.Errorf("Unexpected startup message: %q", msg)
+	}
+	if strings.Contains(startupMessage("prod", ""), "cluster") {
+		t.Error("Expected no cluster without a cluster name")
+	}
+}
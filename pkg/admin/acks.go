@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/kqns91/kube-watcher/pkg/history"
+)
+
+// AckRequest is the optional body of POST /api/v1/acks/{id}
+type AckRequest struct {
+	By      string `json:"by,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+func registerAcks(mux *http.ServeMux, acks *history.Acks) {
+	// ?unacknowledged=true lists only the events still awaiting an acknowledgement
+	mux.HandleFunc("GET "+Prefix+"acks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, acks.List(r.URL.Query().Get("unacknowledged") == "true"))
+	})
+
+	mux.HandleFunc("POST "+Prefix+"acks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var req AckRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		tracked, err := acks.Acknowledge(r.PathValue("id"), history.Ack{By: req.By, Comment: req.Comment})
+		if errors.Is(err, history.ErrNotTracked) {
+			writeError(w, http.StatusNotFound, "event not tracked")
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to record acknowledgement", "id", r.PathValue("id"), "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to record acknowledgement")
+			return
+		}
+		slog.Info("Event acknowledged", "id", tracked.ID, "by", tracked.Ack.By)
+		writeJSON(w, http.StatusOK, tracked)
+	})
+}
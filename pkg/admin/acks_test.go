@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/history"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func newTestAcks(t *testing.T, ids ...string) *history.Acks {
+	t.Helper()
+	acks, err := history.OpenAcks(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("OpenAcks() error = %v", err)
+	}
+	t.Cleanup(func() { acks.Close() })
+	for _, id := range ids {
+		if err := acks.Track(&watcher.Event{ID: id, Kind: "Pod", Namespace: "prod", Name: "api", EventType: "DELETED"}); err != nil {
+			t.Fatalf("Track() error = %v", err)
+		}
+	}
+	return acks
+}
+
+func TestHandler_Acks(t *testing.T) {
+	acks := newTestAcks(t, "a", "b")
+	h := NewHandler(Options{Acks: acks})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acks/a", strings.NewReader(`{"by":"alice","comment":"rolled back"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var tracked history.Tracked
+	if err := json.Unmarshal(rec.Body.Bytes(), &tracked); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if tracked.Ack == nil || tracked.Ack.By != "alice" || tracked.Ack.Comment != "rolled back" {
+		t.Errorf("Unexpected acknowledgement %+v", tracked.Ack)
+	}
+
+	// ボディは省略できる
+	if rec := serve(h, http.MethodPost, "/api/v1/acks/b", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without a body, got %d", rec.Code)
+	}
+	if rec := serve(h, http.MethodPost, "/api/v1/acks/unknown", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an untracked event, got %d", rec.Code)
+	}
+
+	acks.Track(&watcher.Event{ID: "c", Kind: "Pod", Name: "web", EventType: "DELETED"})
+	rec = serve(h, http.MethodGet, "/api/v1/acks?unacknowledged=true", "")
+	var list []history.Tracked
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != "c" {
+		t.Errorf("Expected only c to be unacknowledged, got %+v", list)
+	}
+}
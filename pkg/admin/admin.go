@@ -0,0 +1,204 @@
+// Package admin provides the HTTP API for inspecting and controlling a running kube-watcher.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/history"
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/silence"
+	"github.com/kqns91/kube-watcher/pkg/version"
+)
+
+// Prefix is the path prefix of all API endpoints
+const Prefix = "/api/v1/"
+
+// Status describes the running instance
+type Status struct {
+	Version     version.Info  `json:"version"`
+	StartedAt   time.Time     `json:"startedAt"`
+	Uptime      string        `json:"uptime"`
+	Cluster     string        `json:"cluster,omitempty"`
+	Namespace   string        `json:"namespace,omitempty"`  // Top-level namespace of the configuration
+	Namespaces  []string      `json:"namespaces,omitempty"` // Namespaces watched by the namespaced resources
+	Resources   []string      `json:"resources"`
+	ConfigFiles []string      `json:"configFiles,omitempty"`
+	DryRun      bool          `json:"dryRun,omitempty"`
+	LastReload  *ReloadStatus `json:"lastReload,omitempty"`
+}
+
+// ReloadStatus is the outcome of the last configuration reload
+type ReloadStatus struct {
+	At    time.Time `json:"at"`
+	Error string    `json:"error,omitempty"` // Empty when the configuration was applied
+}
+
+// Components holds the state of the pipeline components
+type Components struct {
+	Dedup    *DedupStats     `json:"dedup,omitempty"` // Nil when deduplication is disabled
+	Batcher  *BatcherStats   `json:"batcher,omitempty"`
+	Breakers []BreakerStatus `json:"breakers"`
+}
+
+// DedupStats holds the deduplication cache counters
+type DedupStats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Size        int    `json:"size"`
+	MaxSize     int    `json:"maxSize"`
+}
+
+// BatcherStats holds the state of the batcher
+type BatcherStats struct {
+	Pending int            `json:"pending"`          // Events waiting for the window to close
+	Routes  map[string]int `json:"routes,omitempty"` // Route name -> events pending in the route's own window
+}
+
+// BreakerStatus is the state of a notifier's circuit breaker
+type BreakerStatus struct {
+	Notifier string `json:"notifier"`
+	Open     bool   `json:"open"`
+	Dropped  int64  `json:"dropped"`
+}
+
+// Options configures the API. Endpoints whose function is nil respond with 404.
+type Options struct {
+	// Token returns the bearer token required on every request, or "" to allow
+	// unauthenticated access. It is called per request so that rotated tokens apply.
+	Token      func() (string, error)
+	Status     func() Status
+	Components func() Components
+	Silences   *silence.Manager
+	Events     history.Lister
+	Acks       *history.Acks
+	// Reload requests a configuration reload. The outcome is reported in Status.
+	Reload func()
+}
+
+// NewHandler returns the API handler, to be mounted at Prefix
+func NewHandler(opts Options) http.Handler {
+	mux := http.NewServeMux()
+	if opts.Status != nil {
+		mux.HandleFunc("GET "+Prefix+"status", func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, http.StatusOK, opts.Status())
+		})
+	}
+	if opts.Components != nil {
+		mux.HandleFunc("GET "+Prefix+"components", func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, http.StatusOK, opts.Components())
+		})
+	}
+	if opts.Silences != nil {
+		registerSilences(mux, opts.Silences)
+	}
+	if opts.Events != nil {
+		mux.HandleFunc("GET "+Prefix+"events", func(w http.ResponseWriter, r *http.Request) {
+			query, err := parseEventQuery(r)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			events, err := opts.Events.List(query)
+			if err != nil {
+				slog.Error("Failed to list events", "error", err)
+				writeError(w, http.StatusInternalServerError, "failed to list events")
+				return
+			}
+			payloads := make([]*notifier.EventPayload, 0, len(events))
+			for _, e := range events {
+				payloads = append(payloads, notifier.NewEventPayload(e))
+			}
+			writeJSON(w, http.StatusOK, payloads)
+		})
+	}
+	if opts.Acks != nil {
+		registerAcks(mux, opts.Acks)
+	}
+	if opts.Reload != nil {
+		mux.HandleFunc("POST "+Prefix+"reload", func(w http.ResponseWriter, _ *http.Request) {
+			opts.Reload()
+			writeJSON(w, http.StatusAccepted, map[string]string{"status": "reload requested"})
+		})
+	}
+
+	return authenticate(opts.Token, mux)
+}
+
+// authenticate requires "Authorization: Bearer <token>" when a token is configured
+func authenticate(token func() (string, error), next http.Handler) http.Handler {
+	if token == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want, err := token()
+		if err != nil {
+			slog.Error("Failed to read admin API token", "error", err)
+			writeError(w, http.StatusInternalServerError, "token unavailable")
+			return
+		}
+		if want != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="kube-watcher"`)
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Number of events returned by /api/v1/events without a limit, and at most,
+// which bounds the history read for a single request
+const (
+	defaultEventLimit = 100
+	maxEventLimit     = 1000
+)
+
+// parseEventQuery reads the kind, namespace, since, until and limit query parameters.
+// Times are RFC 3339, e.g. 2024-01-01T14:00:00+09:00.
+func parseEventQuery(r *http.Request) (history.Query, error) {
+	q := r.URL.Query()
+	query := history.Query{Kind: q.Get("kind"), Namespace: q.Get("namespace"), Limit: defaultEventLimit}
+	for _, p := range []struct {
+		name string
+		dest *time.Time
+	}{{"since", &query.Since}, {"until", &query.Until}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return query, fmt.Errorf("%s must be an RFC 3339 time (got %s)", p.name, v)
+			}
+			*p.dest = t
+		}
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxEventLimit {
+			return query, fmt.Errorf("limit must be an integer between 1 and %d", maxEventLimit)
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/history"
+	"github.com/kqns91/kube-watcher/pkg/silence"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func serve(h http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_Status(t *testing.T) {
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHandler(Options{
+		Status: func() Status {
+			return Status{StartedAt: started, Namespace: "prod", Resources: []string{"Pod"}}
+		},
+	})
+
+	rec := serve(h, http.MethodGet, "/api/v1/status", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var status Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if status.Namespace != "prod" || !status.StartedAt.Equal(started) {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	// 提供されていないエンドポイントは404
+	if rec := serve(h, http.MethodGet, "/api/v1/silences", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for silences, got %d", rec.Code)
+	}
+}
+
+func TestHandler_Token(t *testing.T) {
+	h := NewHandler(Options{
+		Token:  func() (string, error) { return "secret", nil },
+		Status: func() Status { return Status{} },
+	})
+
+	if rec := serve(h, http.MethodGet, "/api/v1/status", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+	if rec := serve(h, http.MethodGet, "/api/v1/status", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with a wrong token, got %d", rec.Code)
+	}
+	if rec := serve(h, http.MethodGet, "/api/v1/status", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the token, got %d", rec.Code)
+	}
+
+	// トークンを読み込めない場合はリクエストを拒否する
+	h = NewHandler(Options{
+		Token:  func() (string, error) { return "", errors.New("file not found") },
+		Status: func() Status { return Status{} },
+	})
+	if rec := serve(h, http.MethodGet, "/api/v1/status", "secret"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the token is unavailable, got %d", rec.Code)
+	}
+}
+
+func TestHandler_Reload(t *testing.T) {
+	reloaded := 0
+	h := NewHandler(Options{Reload: func() { reloaded++ }})
+
+	if rec := serve(h, http.MethodPost, "/api/v1/reload", ""); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected 202, got %d", rec.Code)
+	}
+	if rec := serve(h, http.MethodGet, "/api/v1/reload", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", rec.Code)
+	}
+	if reloaded != 1 {
+		t.Errorf("Expected 1 reload, got %d", reloaded)
+	}
+}
+
+func TestHandler_Events(t *testing.T) {
+	events := history.NewRecent(10)
+	events.Add(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "a", EventType: "ADDED"})
+	events.Add(&watcher.Event{Kind: "Deployment", Namespace: "prod", Name: "b", EventType: "UPDATED"})
+	events.Add(&watcher.Event{Kind: "Pod", Namespace: "dev", Name: "c", EventType: "DELETED"})
+	h := NewHandler(Options{Events: events})
+
+	rec := serve(h, http.MethodGet, "/api/v1/events?kind=Pod&limit=1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var payloads []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payloads); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(payloads) != 1 || payloads[0]["name"] != "c" {
+		t.Errorf("Expected the newest Pod event, got %v", payloads)
+	}
+
+	for _, limit := range []string{"x", "0", "1001"} {
+		if rec := serve(h, http.MethodGet, "/api/v1/events?limit="+limit, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for limit %s, got %d", limit, rec.Code)
+		}
+	}
+}
+
+func TestParseEventQuery_DefaultLimit(t *testing.T) {
+	// 件数を指定しなくても履歴全体は読まない
+	query, err := parseEventQuery(httptest.NewRequest(http.MethodGet, "/api/v1/events?kind=Pod", nil))
+	if err != nil {
+		t.Fatalf("parseEventQuery() error = %v", err)
+	}
+	if query.Limit != defaultEventLimit {
+		t.Errorf("Limit = %d, want %d", query.Limit, defaultEventLimit)
+	}
+}
+
+func TestHandler_Silences(t *testing.T) {
+	m := silence.NewManager(nil)
+	defer m.Stop()
+	h := NewHandler(Options{Silences: m})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/silences", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(`{"matcher":{"namespaces":["staging"]},"comment":"maintenance","durationSeconds":3600}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created silence.Silence
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.ID == "" || created.EndsAt.Sub(created.StartsAt) != time.Hour {
+		t.Errorf("Unexpected silence %+v", created)
+	}
+
+	// 条件のないサイレンスや未知のフィールドは拒否する
+	for _, body := range []string{`{"durationSeconds":60}`, `{"matcher":{"kinds":["Pod"]},"duration":60}`} {
+		if rec := post(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+
+	rec = serve(h, http.MethodGet, "/api/v1/silences", "")
+	var list []silence.Silence
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("Unexpected silences %+v", list)
+	}
+
+	if rec := serve(h, http.MethodDelete, "/api/v1/silences/"+created.ID, ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if rec := serve(h, http.MethodDelete, "/api/v1/silences/"+created.ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a removed silence, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/silence"
+)
+
+// SilenceRequest is the body of POST /api/v1/silences. The silence ends after
+// DurationSeconds, or at EndsAt when given.
+type SilenceRequest struct {
+	Matcher         config.MatcherConfig `json:"matcher"`
+	Comment         string               `json:"comment,omitempty"`
+	CreatedBy       string               `json:"createdBy,omitempty"`
+	StartsAt        time.Time            `json:"startsAt,omitempty"`
+	EndsAt          time.Time            `json:"endsAt,omitempty"`
+	DurationSeconds int                  `json:"durationSeconds,omitempty"`
+}
+
+// maxRequestBytes bounds request bodies
+const maxRequestBytes = 1 << 20
+
+func registerSilences(mux *http.ServeMux, silences *silence.Manager) {
+	mux.HandleFunc("GET "+Prefix+"silences", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, silences.List())
+	})
+
+	mux.HandleFunc("POST "+Prefix+"silences", func(w http.ResponseWriter, r *http.Request) {
+		var req SilenceRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		s := silence.Silence{
+			Matcher:   req.Matcher,
+			Comment:   req.Comment,
+			CreatedBy: req.CreatedBy,
+			StartsAt:  req.StartsAt,
+			EndsAt:    req.EndsAt,
+		}
+		if req.DurationSeconds > 0 {
+			if s.StartsAt.IsZero() {
+				s.StartsAt = time.Now()
+			}
+			s.EndsAt = s.StartsAt.Add(time.Duration(req.DurationSeconds) * time.Second)
+		}
+
+		created, err := silences.Add(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, created)
+	})
+
+	mux.HandleFunc("DELETE "+Prefix+"silences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		removed, ok := silences.Remove(r.PathValue("id"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "silence not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, removed)
+	})
+}
@@ -0,0 +1,167 @@
+package admin
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/history"
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+)
+
+// SlackInteractionsPath is where the Slack app's interactivity request URL should point
+const SlackInteractionsPath = "/slack/interactions"
+
+// maxSlackClockSkew bounds the age of signed Slack requests, to reject replays
+const maxSlackClockSkew = 5 * time.Minute
+
+// SlackOptions configures the Slack interactivity endpoint
+type SlackOptions struct {
+	// SigningSecret returns the Slack app's signing secret. It is called per
+	// request so that rotated secrets apply.
+	SigningSecret func() (string, error)
+	Acks          *history.Acks
+	HTTPClient    *http.Client // Posts the replies to the response URL; defaults to a client with a 10s timeout
+}
+
+// slackInteraction is the part of a Slack block_actions payload used here
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// NewSlackHandler returns the handler of Slack interactions, to be mounted at
+// SlackInteractionsPath. Requests are authenticated with Slack's request
+// signature instead of the API token. Clicks on the Acknowledge button record
+// an acknowledgement by the Slack user.
+func NewSlackHandler(opts SlackOptions) http.Handler {
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		secret, err := opts.SigningSecret()
+		if err != nil {
+			slog.Error("Failed to read Slack signing secret", "error", err)
+			writeError(w, http.StatusInternalServerError, "signing secret unavailable")
+			return
+		}
+		if err := verifySlackSignature(secret, r.Header, body, time.Now()); err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid form")
+			return
+		}
+		var interaction slackInteraction
+		if err := json.Unmarshal([]byte(form.Get("payload")), &interaction); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid payload")
+			return
+		}
+
+		for _, action := range interaction.Actions {
+			if interaction.Type != "block_actions" || action.ActionID != notifier.ActionAcknowledge {
+				continue
+			}
+			by := interaction.User.Username
+			if by == "" {
+				by = interaction.User.ID
+			}
+			tracked, err := opts.Acks.Acknowledge(action.Value, history.Ack{By: by})
+			var reply string
+			switch {
+			case errors.Is(err, history.ErrNotTracked):
+				reply = ":warning: This event is no longer tracked"
+			case err != nil:
+				slog.Error("Failed to record acknowledgement", "id", action.Value, "error", err)
+				reply = ":warning: Failed to record the acknowledgement"
+			default:
+				slog.Info("Event acknowledged", "id", tracked.ID, "by", tracked.Ack.By, "via", "slack")
+				reply = fmt.Sprintf(":white_check_mark: %s/%s acknowledged by <@%s>", tracked.Kind, tracked.Name, interaction.User.ID)
+				if tracked.Ack.By != by {
+					reply = fmt.Sprintf(":white_check_mark: %s/%s was already acknowledged by %s", tracked.Kind, tracked.Name, tracked.Ack.By)
+				}
+			}
+			if interaction.ResponseURL != "" {
+				// Slack expects the response within 3 seconds, so the reply is posted separately
+				go postSlackReply(httpClient, interaction.ResponseURL, reply)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// verifySlackSignature checks the X-Slack-Signature header: an HMAC-SHA256 of
+// "v0:<timestamp>:<body>" with the signing secret
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	if secret == "" {
+		return errors.New("signing secret not configured")
+	}
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing request timestamp")
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > maxSlackClockSkew || skew < -maxSlackClockSkew {
+		return errors.New("request timestamp out of range")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(want)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// postSlackReply posts a message to the channel of the interaction, keeping the original message
+func postSlackReply(httpClient *http.Client, responseURL, text string) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"response_type":    "in_channel",
+		"replace_original": false,
+		"text":             text,
+	})
+	resp, err := httpClient.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to reply to Slack interaction", "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.Error("Failed to reply to Slack interaction", "status", resp.StatusCode)
+	}
+}
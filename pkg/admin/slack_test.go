@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slackRequest creates an interaction request signed like Slack does
+func slackRequest(secret, payload string, at time.Time) *http.Request {
+	body := url.Values{"payload": {payload}}.Encode()
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	req := httptest.NewRequest(http.MethodPost, SlackInteractionsPath, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestSlackHandler_Acknowledge(t *testing.T) {
+	acks := newTestAcks(t, "abc")
+	replies := make(chan string, 1)
+	responseServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		replies <- string(body)
+	}))
+	defer responseServer.Close()
+
+	h := NewSlackHandler(SlackOptions{
+		SigningSecret: func() (string, error) { return "secret", nil },
+		Acks:          acks,
+	})
+	payload := `{"type":"block_actions","user":{"id":"U1","username":"alice"},` +
+		`"actions":[{"action_id":"kube_watcher_ack","value":"abc"}],"response_url":"` + responseServer.URL + `"}`
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, slackRequest("secret", payload, time.Now()))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := acks.List(false); got[0].Ack == nil || got[0].Ack.By != "alice" {
+		t.Errorf("Expected the event to be acknowledged by alice, got %+v", got[0].Ack)
+	}
+	select {
+	case reply := <-replies:
+		var msg struct {
+			Text            string `json:"text"`
+			ReplaceOriginal bool   `json:"replace_original"`
+		}
+		if err := json.Unmarshal([]byte(reply), &msg); err != nil {
+			t.Fatalf("Failed to decode reply: %v", err)
+		}
+		if msg.Text != ":white_check_mark: Pod/api acknowledged by <@U1>" || msg.ReplaceOriginal {
+			t.Errorf("Unexpected reply %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected a reply to the response URL")
+	}
+}
+
+func TestSlackHandler_Signature(t *testing.T) {
+	acks := newTestAcks(t, "abc")
+	h := NewSlackHandler(SlackOptions{
+		SigningSecret: func() (string, error) { return "secret", nil },
+		Acks:          acks,
+	})
+	payload := `{"type":"block_actions","user":{"id":"U1"},"actions":[{"action_id":"kube_watcher_ack","value":"abc"}]}`
+
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"異なるシークレット", slackRequest("other", payload, time.Now())},
+		{"古いタイムスタンプ", slackRequest("secret", payload, time.Now().Add(-10*time.Minute))},
+		{"署名なし", httptest.NewRequest(http.MethodPost, SlackInteractionsPath, strings.NewReader("payload={}"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, tt.req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status 401, got %d", rec.Code)
+			}
+		})
+	}
+	if got := acks.List(true); len(got) != 1 {
+		t.Error("Expected the event to stay unacknowledged")
+	}
+}
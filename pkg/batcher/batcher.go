@@ -2,6 +2,7 @@ package batcher
 
 import (
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -21,6 +22,17 @@ const (
 	BatchModeSmart BatchMode = "smart"
 )
 
+// WindowType represents how batch windows are scheduled
+type WindowType string
+
+// Window type constants
+const (
+	// WindowSliding starts a window when the first event arrives
+	WindowSliding WindowType = "sliding"
+	// WindowFixed flushes on fixed clock-aligned intervals regardless of event arrival
+	WindowFixed WindowType = "fixed"
+)
+
 // SmartConfig represents smart batching configuration
 type SmartConfig struct {
 	MaxEventsPerGroup int      // Maximum events to show details per group
@@ -32,8 +44,10 @@ type SmartConfig struct {
 type Config struct {
 	Enabled       bool
 	WindowSeconds int
+	WindowType    WindowType
 	Mode          BatchMode
 	Smart         SmartConfig
+	Store         Store // Optional persistence for pending events
 }
 
 // Batch represents a collection of events to be sent together
@@ -41,6 +55,8 @@ type Batch struct {
 	Events    []*watcher.Event
 	StartTime time.Time
 	EndTime   time.Time
+	// UpdateCounts holds how many UPDATED events were collapsed into an event (only when more than one)
+	UpdateCounts map[*watcher.Event]int
 }
 
 // EventGroup represents events grouped by resource type and event type
@@ -52,24 +68,53 @@ type EventGroup struct {
 
 // Batcher collects events and sends them in batches
 type Batcher struct {
-	config    Config
-	events    []*watcher.Event
-	mu        sync.Mutex
-	timer     *time.Timer
-	callback  func(*Batch)
-	startTime time.Time
-	stopCh    chan struct{}
+	config       Config
+	events       []*watcher.Event
+	updateIndex  map[string]int // Resource key -> index of its UPDATED event in events
+	updateCounts map[*watcher.Event]int
+	mu           sync.Mutex
+	timer        *time.Timer
+	callback     func(*Batch)
+	sending      sync.WaitGroup // Callbacks in progress
+	startTime    time.Time
+	stopCh       chan struct{}
 }
 
 // NewBatcher creates a new Batcher instance
 func NewBatcher(config Config, callback func(*Batch)) *Batcher {
-	return &Batcher{
-		config:    config,
-		events:    make([]*watcher.Event, 0),
-		callback:  callback,
-		startTime: time.Now(),
-		stopCh:    make(chan struct{}),
+	b := &Batcher{
+		config:       config,
+		events:       make([]*watcher.Event, 0),
+		updateIndex:  make(map[string]int),
+		updateCounts: make(map[*watcher.Event]int),
+		callback:     callback,
+		startTime:    time.Now(),
+		stopCh:       make(chan struct{}),
+	}
+
+	// Restore events spooled before the last shutdown and send them right away
+	if config.Store != nil {
+		pending, err := config.Store.Load()
+		if err != nil {
+			slog.Error("Failed to load pending batch events", "error", err)
+		} else if len(pending) > 0 {
+			slog.Info("Restored pending batch events", "events", len(pending))
+			for _, event := range pending {
+				b.collect(event)
+			}
+			b.startTime = pending[0].Timestamp
+			go b.flush()
+		}
+	}
+
+	// Fixed windows are flushed by a clock-aligned loop instead of per-batch timers
+	if config.WindowType == WindowFixed && config.WindowSeconds > 0 {
+		window := time.Duration(config.WindowSeconds) * time.Second
+		b.startTime = time.Now().Truncate(window)
+		go b.fixedWindowLoop(window)
 	}
+
+	return b
 }
 
 // Add adds an event to the current batch
@@ -77,11 +122,16 @@ func (b *Batcher) Add(event *watcher.Event) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	// Add event to the batch
-	b.events = append(b.events, event)
+	// The event is stored as added; restoring replays the collapsing of updates
+	if b.config.Store != nil {
+		if err := b.config.Store.Append(event); err != nil {
+			slog.Error("Failed to persist pending batch event", "error", err)
+		}
+	}
+	b.collect(event)
 
-	// Start timer if this is the first event
-	if len(b.events) == 1 {
+	// Start timer if this is the first event (sliding windows only)
+	if len(b.events) == 1 && b.config.WindowType != WindowFixed {
 		b.startTime = time.Now()
 		b.timer = time.AfterFunc(time.Duration(b.config.WindowSeconds)*time.Second, func() {
 			b.flush()
@@ -89,6 +139,50 @@ func (b *Batcher) Add(event *watcher.Event) {
 	}
 }
 
+// collect adds an event to the pending events. Must be called with b.mu held.
+func (b *Batcher) collect(event *watcher.Event) {
+	// Collapse repeated updates of the same resource into its latest state
+	if event.EventType == "UPDATED" {
+		key := fmt.Sprintf("%s/%s/%s", event.Kind, event.Namespace, event.Name)
+		if i, exists := b.updateIndex[key]; exists {
+			prev := b.events[i]
+			count := b.updateCounts[prev]
+			if count == 0 {
+				count = 1
+			}
+			delete(b.updateCounts, prev)
+			event.Changes = watcher.MergeChanges(prev.Changes, event.Changes)
+			b.events[i] = event
+			b.updateCounts[event] = count + 1
+			return
+		}
+		b.updateIndex[key] = len(b.events)
+	}
+
+	b.events = append(b.events, event)
+}
+
+// fixedWindowLoop flushes the batch at every window boundary on the clock
+func (b *Batcher) fixedWindowLoop(window time.Duration) {
+	for {
+		next := time.Now().Truncate(window).Add(window)
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-b.stopCh:
+			timer.Stop()
+			return
+		case <-timer.C:
+			b.flush()
+
+			// The next batch covers the window that just started
+			b.mu.Lock()
+			b.startTime = next
+			b.mu.Unlock()
+		}
+	}
+}
+
 // flush sends the current batch and resets
 func (b *Batcher) flush() {
 	b.mu.Lock()
@@ -100,28 +194,57 @@ func (b *Batcher) flush() {
 
 	// Create batch
 	batch := &Batch{
-		Events:    b.events,
-		StartTime: b.startTime,
-		EndTime:   time.Now(),
+		Events:       b.events,
+		StartTime:    b.startTime,
+		EndTime:      time.Now(),
+		UpdateCounts: b.updateCounts,
 	}
 
 	// Reset state
 	b.events = make([]*watcher.Event, 0)
+	b.updateIndex = make(map[string]int)
+	b.updateCounts = make(map[*watcher.Event]int)
 	if b.timer != nil {
 		b.timer.Stop()
 		b.timer = nil
 	}
 
+	// The stored events of the batch are kept until the callback has
+	// delivered it; failed deliveries are dead-lettered by the callback
+	var remove func() error
+	if b.config.Store != nil {
+		var err error
+		if remove, err = b.config.Store.Detach(); err != nil {
+			slog.Error("Failed to detach pending batch events", "error", err)
+		}
+	}
+
 	// Send batch via callback (unlock before calling to avoid deadlock)
+	b.sending.Add(1)
 	b.mu.Unlock()
 	b.callback(batch)
+	if remove != nil {
+		if err := remove(); err != nil {
+			slog.Error("Failed to remove delivered batch events", "error", err)
+		}
+	}
+	b.sending.Done()
 	b.mu.Lock()
 }
 
-// Stop stops the batcher and flushes remaining events
+// Pending returns the number of events waiting to be flushed
+func (b *Batcher) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.events)
+}
+
+// Stop stops the batcher, flushes remaining events and waits until all
+// batches, including those flushed by the window timer, have been sent
 func (b *Batcher) Stop() {
 	close(b.stopCh)
 	b.flush()
+	b.sending.Wait()
 }
 
 // GroupEvents groups events by Kind and EventType
@@ -1,6 +1,7 @@
 package batcher
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -206,3 +207,136 @@ func TestBatcher_ShouldShowDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestBatcher_FixedWindow(t *testing.T) {
+	batches := make(chan *Batch, 4)
+
+	config := Config{
+		Enabled:       true,
+		WindowSeconds: 1,
+		WindowType:    WindowFixed,
+		Mode:          BatchModeSmart,
+	}
+
+	b := NewBatcher(config, func(batch *Batch) {
+		batches <- batch
+	})
+	defer b.Stop()
+
+	b.Add(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "test-pod", EventType: "ADDED", Timestamp: time.Now()})
+
+	select {
+	case batch := <-batches:
+		if len(batch.Events) != 1 {
+			t.Errorf("Expected 1 event in batch, got %d", len(batch.Events))
+		}
+		// Fixed windows end on a second boundary
+		if batch.EndTime.Sub(batch.EndTime.Truncate(time.Second)) > 200*time.Millisecond {
+			t.Errorf("Expected batch to flush at a window boundary, got %v", batch.EndTime)
+		}
+	case <-time.After(2500 * time.Millisecond):
+		t.Fatal("Fixed window batch was not flushed")
+	}
+}
+
+func TestBatcher_FixedWindow_NoEmptyBatches(t *testing.T) {
+	called := make(chan struct{}, 1)
+
+	config := Config{
+		Enabled:       true,
+		WindowSeconds: 1,
+		WindowType:    WindowFixed,
+	}
+
+	b := NewBatcher(config, func(batch *Batch) {
+		called <- struct{}{}
+	})
+	defer b.Stop()
+
+	select {
+	case <-called:
+		t.Error("Callback should not be called for an empty window")
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
+
+func TestBatcher_CollapsesRepeatedUpdates(t *testing.T) {
+	batches := make(chan *Batch, 1)
+
+	b := NewBatcher(Config{Enabled: true, WindowSeconds: 60}, func(batch *Batch) {
+		batches <- batch
+	})
+
+	b.Add(&watcher.Event{Kind: "Deployment", Namespace: "default", Name: "app", EventType: "ADDED", Status: "v0"})
+	for _, status := range []string{"v1", "v2", "v3"} {
+		b.Add(&watcher.Event{Kind: "Deployment", Namespace: "default", Name: "app", EventType: "UPDATED", Status: status})
+	}
+	b.Add(&watcher.Event{Kind: "Deployment", Namespace: "default", Name: "other", EventType: "UPDATED", Status: "v1"})
+
+	b.Stop()
+	batch := <-batches
+
+	if len(batch.Events) != 3 {
+		t.Fatalf("Expected 3 events after collapsing, got %d", len(batch.Events))
+	}
+
+	collapsed := batch.Events[1]
+	if collapsed.Name != "app" || collapsed.Status != "v3" {
+		t.Errorf("Expected collapsed event to hold the final state, got %s/%s", collapsed.Name, collapsed.Status)
+	}
+	if batch.UpdateCounts[collapsed] != 3 {
+		t.Errorf("Expected update count 3, got %d", batch.UpdateCounts[collapsed])
+	}
+	if _, exists := batch.UpdateCounts[batch.Events[2]]; exists {
+		t.Error("Single update should not have an update count")
+	}
+}
+
+func TestBatcher_StopWaitsForSending(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var (
+		mu    sync.Mutex
+		calls int
+		sent  int
+	)
+	callback := func(batch *Batch) {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		mu.Unlock()
+		if first {
+			close(started)
+			<-release
+		}
+		mu.Lock()
+		sent++
+		mu.Unlock()
+	}
+
+	b := NewBatcher(Config{Enabled: true, WindowSeconds: 1}, callback)
+	b.Add(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "first", EventType: "ADDED"})
+	<-started // ウィンドウ終了によるフラッシュが送信中
+
+	b.Add(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "second", EventType: "ADDED"})
+	stopped := make(chan struct{})
+	go func() {
+		b.Stop()
+		close(stopped)
+	}()
+
+	// 送信中のバッチが完了するまでStopは戻らない
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while a batch was being sent")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	<-stopped
+	mu.Lock()
+	defer mu.Unlock()
+	if sent != 2 {
+		t.Errorf("Expected 2 batches after Stop, got %d", sent)
+	}
+}
@@ -0,0 +1,153 @@
+package batcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Store persists pending batch events so they survive restarts. Events are
+// kept until the batch they were flushed in has been delivered, so that a
+// crash during delivery sends them again on startup.
+type Store interface {
+	// Append records an event added to the pending batch
+	Append(event *watcher.Event) error
+	// Load returns the stored events in the order they were appended. It is
+	// called once on startup, before events are appended.
+	Load() ([]*watcher.Event, error)
+	// Detach sets the stored events aside for the batch being flushed, so that
+	// events appended afterwards are stored separately. The returned function
+	// removes them once the batch has been delivered.
+	Detach() (remove func() error, err error)
+}
+
+// FileStore appends pending events to a file as JSON lines. Detached events
+// are moved to files with the suffix .sending-<n> until they are removed.
+type FileStore struct {
+	path string
+
+	mu       sync.Mutex
+	file     *os.File // Opened on the first append after a detach
+	restored []string // Detached files left by the previous run, removed by the next detach
+	seq      int
+}
+
+// NewFileStore creates a new FileStore writing to the given path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Append writes the event as a line to the spool file
+func (s *FileStore) Append(event *watcher.Event) error {
+	// Object references are kept, so that restored events are still recorded on their objects
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spooled event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open spool file: %w", err)
+		}
+		s.file = f
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	return nil
+}
+
+// Detach moves the spool file aside. The moved file and those left by the
+// previous run are removed by the returned function.
+func (s *FileStore) Detach() (func() error, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+	detached := s.restored
+	s.restored = nil
+
+	s.seq++
+	// Zero-padded, so that the files sort in the order they were detached
+	sending := fmt.Sprintf("%s.sending-%019d-%06d", s.path, time.Now().UnixNano(), s.seq)
+	if err := os.Rename(s.path, sending); err == nil {
+		detached = append(detached, sending)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		s.restored = detached
+		return nil, fmt.Errorf("failed to detach spool file: %w", err)
+	}
+
+	return func() error {
+		for _, path := range detached {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove spool file: %w", err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// Load reads the events of the files detached but not removed by the
+// previous run, followed by those of the spool file. Missing files yield no events.
+func (s *FileStore) Load() ([]*watcher.Event, error) {
+	sending, err := filepath.Glob(s.path + ".sending-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spool files: %w", err)
+	}
+	sort.Strings(sending)
+
+	var events []*watcher.Event
+	for _, path := range append(sending, s.path) {
+		loaded, err := loadSpoolFile(path)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, loaded...)
+	}
+
+	s.mu.Lock()
+	s.restored = sending
+	s.mu.Unlock()
+	return events, nil
+}
+
+// loadSpoolFile reads the events of a spool file, one JSON object per line
+func loadSpoolFile(path string) ([]*watcher.Event, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool file: %w", err)
+	}
+
+	// Every event ends with a newline; a last line without one was cut short
+	// by a crash while appending
+	lines := bytes.Split(data, []byte("\n"))
+	var events []*watcher.Event
+	for _, line := range lines[:len(lines)-1] {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var event watcher.Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse spool file %s: %w", path, err)
+		}
+		events = append(events, &event)
+	}
+	return events, nil
+}
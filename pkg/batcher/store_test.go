@@ -0,0 +1,185 @@
+package batcher
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFileStore_AppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.json")
+	store := NewFileStore(path)
+
+	events := []*watcher.Event{
+		{Kind: "Pod", Namespace: "default", Name: "pod1", EventType: "ADDED", Timestamp: time.Now()},
+		{Kind: "Deployment", Namespace: "default", Name: "app", EventType: "UPDATED", Timestamp: time.Now(),
+			Replicas: &watcher.ReplicaInfo{Desired: 3, Ready: 2, Current: 3}},
+	}
+	for _, e := range events {
+		if err := store.Append(e); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	loaded, err := NewFileStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(loaded) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(loaded))
+	}
+	if loaded[1].Name != "app" || loaded[1].Replicas == nil || loaded[1].Replicas.Desired != 3 {
+		t.Errorf("Loaded event does not match saved event: %+v", loaded[1])
+	}
+}
+
+func TestFileStore_Detach(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.json")
+	store := NewFileStore(path)
+
+	_ = store.Append(&watcher.Event{Kind: "Pod", Name: "sending"})
+	remove, err := store.Detach()
+	if err != nil {
+		t.Fatalf("Detach() error = %v", err)
+	}
+	// 送信中に追加されたイベントは別に保存される
+	_ = store.Append(&watcher.Event{Kind: "Pod", Name: "next"})
+
+	// 送信完了前にクラッシュした場合は、両方のイベントが復元される
+	loaded, err := NewFileStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Name != "sending" || loaded[1].Name != "next" {
+		t.Fatalf("Expected both events in order, got %+v", loaded)
+	}
+
+	if err := remove(); err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	loaded, _ = NewFileStore(path).Load()
+	if len(loaded) != 1 || loaded[0].Name != "next" {
+		t.Errorf("Expected only the event appended after detaching, got %+v", loaded)
+	}
+}
+
+func TestFileStore_LoadRestoredFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.json")
+	prev := NewFileStore(path)
+	_ = prev.Append(&watcher.Event{Kind: "Pod", Name: "first"})
+	_, _ = prev.Detach()
+	_ = prev.Append(&watcher.Event{Kind: "Pod", Name: "second"})
+
+	// 前回の実行で送信中だったファイルは、次のDetachで引き継がれ送信後に削除される
+	store := NewFileStore(path)
+	loaded, err := store.Load()
+	if err != nil || len(loaded) != 2 {
+		t.Fatalf("Load() = %d events, error = %v", len(loaded), err)
+	}
+	remove, err := store.Detach()
+	if err != nil {
+		t.Fatalf("Detach() error = %v", err)
+	}
+	_ = remove()
+
+	files, _ := filepath.Glob(path + "*")
+	if len(files) != 0 {
+		t.Errorf("Expected all spool files to be removed, got %v", files)
+	}
+}
+
+func TestFileStore_LoadPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.json")
+	// 追記中のクラッシュで途切れた最後の行は無視される
+	os.WriteFile(path, []byte(`{"kind":"Pod","name":"pod1"}`+"\n"+`{"kind":"Pod","na`), 0o600)
+
+	loaded, err := NewFileStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Name != "pod1" {
+		t.Errorf("Expected the complete event only, got %+v", loaded)
+	}
+
+	os.WriteFile(path, []byte("not json\n"), 0o600)
+	if _, err := NewFileStore(path).Load(); err == nil {
+		t.Error("Expected error for a corrupt spool file")
+	}
+}
+
+func TestBatcher_RestoresSpooledEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.json")
+	store := NewFileStore(path)
+
+	// 再起動前に未送信だったイベントを保存
+	_ = store.Append(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "pending", EventType: "DELETED", Timestamp: time.Now()})
+	store = NewFileStore(path)
+
+	batches := make(chan *Batch, 1)
+	b := NewBatcher(Config{Enabled: true, WindowSeconds: 60, Store: store}, func(batch *Batch) {
+		batches <- batch
+	})
+
+	select {
+	case batch := <-batches:
+		if len(batch.Events) != 1 || batch.Events[0].Name != "pending" {
+			t.Errorf("Expected restored pending event, got %+v", batch.Events)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Spooled events were not flushed on startup")
+	}
+
+	// 送信後はスプールが空になる
+	b.Stop()
+	loaded, _ := NewFileStore(path).Load()
+	if len(loaded) != 0 {
+		t.Errorf("Expected spool to be cleared after flush, got %d events", len(loaded))
+	}
+}
+
+func TestBatcher_PersistsPendingEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.json")
+	store := NewFileStore(path)
+
+	delivering := make(chan struct{})
+	release := make(chan struct{})
+	b := NewBatcher(Config{Enabled: true, WindowSeconds: 60, Store: store}, func(batch *Batch) {
+		close(delivering)
+		<-release
+	})
+	b.Add(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "pod1", EventType: "ADDED", Timestamp: time.Now()})
+	for i := 0; i < 3; i++ {
+		b.Add(&watcher.Event{Kind: "Deployment", Namespace: "default", Name: "app", EventType: "UPDATED", Timestamp: time.Now(),
+			Changes: []watcher.FieldChange{{Field: "replicas", Old: strconv.Itoa(i), New: strconv.Itoa(i + 1)}}})
+	}
+
+	// 再起動後は更新の集約が再現される
+	data, _ := os.ReadFile(path)
+	copied := filepath.Join(t.TempDir(), "spool.json")
+	os.WriteFile(copied, data, 0o600)
+	batches := make(chan *Batch, 1)
+	restored := NewBatcher(Config{Enabled: true, WindowSeconds: 60, Store: NewFileStore(copied)}, func(batch *Batch) {
+		batches <- batch
+	})
+	restored.Stop()
+	if batch := <-batches; len(batch.Events) != 2 || batch.UpdateCounts[batch.Events[1]] != 3 {
+		t.Errorf("Expected 2 events with 3 collapsed updates, got %d events", len(batch.Events))
+	}
+
+	// 送信が完了するまでスプールは残る
+	go b.Stop()
+	<-delivering
+	loaded, err := NewFileStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 4 {
+		t.Errorf("Expected 4 spooled events during delivery, got %d", len(loaded))
+	}
+	close(release)
+}
@@ -2,25 +2,246 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Namespace      string              `yaml:"namespace"`
-	Resources      []ResourceConfig    `yaml:"resources"`
-	Filters        []FilterConfig      `yaml:"filters"`
-	Notifier       NotifierConfig      `yaml:"notifier"`
-	Deduplication  DeduplicationConfig `yaml:"deduplication,omitempty"`
-	Batching       BatchingConfig      `yaml:"batching,omitempty"`
+	Include       []string            `yaml:"include,omitempty"`     // Files, globs or directories merged into this file
+	ClusterName   string              `yaml:"clusterName,omitempty"` // Defaults to the kubeconfig context's cluster
+	Namespace     string              `yaml:"namespace"`
+	Resources     []ResourceConfig    `yaml:"resources"`
+	Enrichment    []EnrichmentRule    `yaml:"enrichment,omitempty"` // Applied before filtering, routing and formatting
+	Filters       []FilterConfig      `yaml:"filters"`
+	Notifier      NotifierConfig      `yaml:"notifier"`
+	Deduplication DeduplicationConfig `yaml:"deduplication,omitempty"`
+	Batching      BatchingConfig      `yaml:"batching,omitempty"`
+	Correlation   CorrelationConfig   `yaml:"correlation,omitempty"`
+	Storm         StormConfig         `yaml:"storm,omitempty"`
+	NodeDrains    NodeDrainsConfig    `yaml:"nodeDrains,omitempty"`
+	Metrics       MetricsConfig       `yaml:"metrics,omitempty"`
+	Reload        ReloadConfig        `yaml:"reload,omitempty"`
+	Tracing       TracingConfig       `yaml:"tracing,omitempty"`
+	OpsAlerts     OpsAlertsConfig     `yaml:"opsAlerts,omitempty"`
+	Watchdog      WatchdogConfig      `yaml:"watchdog,omitempty"`
+	Heartbeat     HeartbeatConfig     `yaml:"heartbeat,omitempty"`
+	PodLogs       PodLogsConfig       `yaml:"podLogs,omitempty"`
+	RelatedEvents RelatedEventsConfig `yaml:"relatedEvents,omitempty"`
+	Manifests     ManifestsConfig     `yaml:"manifests,omitempty"`
+	ManifestDiff  ManifestDiffConfig  `yaml:"manifestDiff,omitempty"`
+	CronJobs      CronJobsConfig      `yaml:"cronJobs,omitempty"`
+	Volumes       VolumesConfig       `yaml:"volumes,omitempty"`
+	CrashLoops    CrashLoopsConfig    `yaml:"crashLoops,omitempty"`
+	Offline       OfflineConfig       `yaml:"offlineChanges,omitempty"`
+	Shutdown      ShutdownConfig      `yaml:"shutdown,omitempty"`
+	Admin         AdminConfig         `yaml:"admin,omitempty"`
+	History       HistoryConfig       `yaml:"history,omitempty"`
+	Acks          AcksConfig          `yaml:"acknowledgements,omitempty"`
+	GRPC          GRPCConfig          `yaml:"grpc,omitempty"`
+	K8sEvents     K8sEventsConfig     `yaml:"kubernetesEvents,omitempty"`
+	Links         []LinkConfig        `yaml:"links,omitempty"`
+	Routes        []RouteConfig       `yaml:"routes,omitempty"`
+	Escalations   []EscalationRule    `yaml:"escalations,omitempty"`
+	Severity      SeverityConfig      `yaml:"severity,omitempty"`
+	Silences      []SilenceConfig     `yaml:"silences,omitempty"`
+	Maintenance   []MaintenanceWindow `yaml:"maintenanceWindows,omitempty"`
+	LogLevel      string              `yaml:"logLevel,omitempty"`  // "debug" | "info" (default) | "warn" | "error"
+	LogFormat     string              `yaml:"logFormat,omitempty"` // "text" (default) | "json"
+	DryRun        bool                `yaml:"dryRun,omitempty"`    // Print notifications to stdout instead of sending them
+
+	// Events are only processed when their namespace matches one of the
+	// include patterns, if any, and none of the exclude patterns. Cluster-scoped
+	// resources are not affected.
+	IncludeNamespacesRegex []string `yaml:"includeNamespacesRegex,omitempty"`
+	ExcludeNamespacesRegex []string `yaml:"excludeNamespacesRegex,omitempty"`
+
+	Files []string `yaml:"-"` // Files the configuration was loaded from, including includes
+}
+
+// RouteConfig sends events matching the conditions to the named destinations.
+// Routes are evaluated in order and the first match wins unless continue is set.
+// A route without conditions matches every event.
+type RouteConfig struct {
+	Name          string `yaml:"name,omitempty"`
+	MatcherConfig `yaml:",inline"`
+	Destinations  []string `yaml:"destinations"`
+	Continue      bool     `yaml:"continue,omitempty"` // Keep evaluating later routes after a match
+
+	// Formatting overrides of notifier.slack for the Slack destinations of the route
+	Template  string            `yaml:"template,omitempty"`
+	Templates map[string]string `yaml:"templates,omitempty"` // Per-event-type template overrides
+	Format    string            `yaml:"format,omitempty"`    // "attachments" | "blocks" | "text"
+
+	// Batching of the events sent through the route, in its own batching
+	// window instead of the global one
+	Batching *RouteBatchingConfig `yaml:"batching,omitempty"`
+}
+
+// RouteBatchingConfig contains the batching settings of a route. Unset
+// settings follow the global batching settings.
+type RouteBatchingConfig struct {
+	Enabled       *bool  `yaml:"enabled,omitempty"`       // false: the events of the route are sent unbatched
+	WindowSeconds int    `yaml:"windowSeconds,omitempty"` // Default: batching.windowSeconds, or 60
+	Mode          string `yaml:"mode,omitempty"`          // Default: batching.mode, or smart
+}
+
+// HasFormatting reports whether the route overrides the Slack formatting
+func (r RouteConfig) HasFormatting() bool {
+	return r.Template != "" || len(r.Templates) > 0 || r.Format != ""
+}
+
+// OwnBatching reports whether the events of the route are batched in a
+// window of their own
+func (r RouteConfig) OwnBatching() bool {
+	return r.Batching != nil && (r.Batching.Enabled == nil || *r.Batching.Enabled)
+}
+
+// Unbatched reports whether the events of the route are sent without batching
+func (r RouteConfig) Unbatched() bool {
+	return r.Batching != nil && r.Batching.Enabled != nil && !*r.Batching.Enabled
+}
+
+// EscalationRule re-sends a notified event matching the conditions when it is
+// neither acknowledged nor resolved within the delay. The event is resolved by
+// a later event about the same resource that does not match the conditions.
+type EscalationRule struct {
+	Name          string `yaml:"name"`
+	MatcherConfig `yaml:",inline"`
+	AfterMinutes  int      `yaml:"afterMinutes"`
+	Destinations  []string `yaml:"destinations,omitempty"` // Default: the destinations of the original notification
+}
+
+// SeverityConfig classifies events into the internal severity levels, which
+// are mapped to the severities and priorities of paging integrations so that
+// one severity model drives all of them
+type SeverityConfig struct {
+	Enabled bool                  `yaml:"enabled"`
+	Default string                `yaml:"default,omitempty"` // Severity of events matching no rule (default: warning for WARNING events, info otherwise)
+	Rules   []SeverityRule        `yaml:"rules,omitempty"`   // Evaluated in order; the first match wins
+	Mapping SeverityMappingConfig `yaml:"mapping,omitempty"`
+
+	// Behaviors override the global pipeline behavior for the events of each severity
+	Behaviors map[string]SeverityBehavior `yaml:"behaviors,omitempty"`
+}
+
+// SeverityBehavior controls how the events of a severity pass the pipeline.
+// Unset fields follow the global settings; behaviors that are disabled
+// globally cannot be enabled per severity.
+type SeverityBehavior struct {
+	Immediate   bool  `yaml:"immediate,omitempty"`   // Sent right away: not batched, nor held back for node drains, storms or stories
+	Deduplicate *bool `yaml:"deduplicate,omitempty"` // false: never deduplicated
+	Batch       *bool `yaml:"batch,omitempty"`       // false: sent without batching
+	RateLimit   *bool `yaml:"rateLimit,omitempty"`   // false: sent to Slack even over the rate limit
+}
+
+// Deduplicated reports whether the events are deduplicated when deduplication is enabled
+func (b SeverityBehavior) Deduplicated() bool {
+	return b.Deduplicate == nil || *b.Deduplicate
+}
+
+// Batched reports whether the events are batched when batching is enabled
+func (b SeverityBehavior) Batched() bool {
+	return !b.Immediate && (b.Batch == nil || *b.Batch)
+}
+
+// RateLimited reports whether the events count against the Slack rate limits
+func (b SeverityBehavior) RateLimited() bool {
+	return b.RateLimit == nil || *b.RateLimit
+}
+
+// SeverityRule assigns a severity to the events matching the conditions
+type SeverityRule struct {
+	Severity      string `yaml:"severity"` // "critical" | "warning" | "info"
+	MatcherConfig `yaml:",inline"`
+}
+
+// SeverityMappingConfig maps each severity level to the values of the paging
+// integrations. Levels not listed keep their defaults.
+type SeverityMappingConfig struct {
+	PagerDuty    map[string]string `yaml:"pagerDuty,omitempty"`    // Events API v2 severity: critical | error | warning | info
+	Opsgenie     map[string]string `yaml:"opsgenie,omitempty"`     // Alert priority: P1 to P5
+	Alertmanager map[string]string `yaml:"alertmanager,omitempty"` // Value of the severity label of alerts
+}
+
+// SeverityLevels are the internal severity levels, from most to least severe
+var SeverityLevels = []string{"critical", "warning", "info"}
+
+// defaultSeverityMapping are the values of the paging integrations for each severity level
+var defaultSeverityMapping = SeverityMappingConfig{
+	PagerDuty:    map[string]string{"critical": "critical", "warning": "warning", "info": "info"},
+	Opsgenie:     map[string]string{"critical": "P1", "warning": "P3", "info": "P5"},
+	Alertmanager: map[string]string{"critical": "critical", "warning": "warning", "info": "info"},
 }
 
-// ResourceConfig defines which Kubernetes resources to watch
+// ResourceConfig defines which Kubernetes resources to watch.
+// Either kind or preset is set; presets are expanded to their kinds by Validate.
 type ResourceConfig struct {
-	Kind string `yaml:"kind"`
+	Kind      string `yaml:"kind,omitempty"`
+	Preset    string `yaml:"preset,omitempty"`    // "workloads" | "networking" | "config"
+	Namespace string `yaml:"namespace,omitempty"` // Overrides the top-level namespace; ignored for cluster-scoped kinds
+}
+
+// ClusterScopedKinds are the supported kinds that are not namespaced. They are
+// watched in the whole cluster, which requires a ClusterRole.
+var ClusterScopedKinds = map[string]bool{"Node": true, "PersistentVolume": true, "Namespace": true}
+
+// ResourcePresets maps preset names to the kinds they watch
+var ResourcePresets = map[string][]string{
+	"workloads":  {"Pod", "Deployment", "ReplicaSet", "StatefulSet", "DaemonSet"},
+	"networking": {"Service", "Ingress"},
+	"config":     {"ConfigMap", "Secret"},
+}
+
+// expandResources replaces presets with their kinds and drops kinds listed
+// again for the same namespace. A kind may be watched in several namespaces;
+// resources without a namespace are watched in namespace, and cluster-scoped
+// kinds once for the whole cluster.
+func expandResources(resources []ResourceConfig, namespace string) ([]ResourceConfig, error) {
+	var expanded []ResourceConfig
+	seen := make(map[string]bool)
+	add := func(kind, resourceNamespace string) {
+		watched := resourceNamespace
+		switch {
+		case ClusterScopedKinds[kind]:
+			watched = ""
+		case watched == "":
+			watched = namespace
+		}
+		if key := kind + "/" + watched; !seen[key] {
+			seen[key] = true
+			expanded = append(expanded, ResourceConfig{Kind: kind, Namespace: resourceNamespace})
+		}
+	}
+
+	for i, r := range resources {
+		switch {
+		case r.Kind != "" && r.Preset != "":
+			return nil, fmt.Errorf("resources[%d]: kind and preset are mutually exclusive", i)
+		case r.Preset != "":
+			kinds, ok := ResourcePresets[r.Preset]
+			if !ok {
+				return nil, fmt.Errorf("resources[%d].preset must be one of: workloads, networking, config (got %s)", i, r.Preset)
+			}
+			for _, kind := range kinds {
+				add(kind, r.Namespace)
+			}
+		case r.Kind != "":
+			add(r.Kind, r.Namespace)
+		default:
+			return nil, fmt.Errorf("resources[%d] requires kind or preset", i)
+		}
+	}
+	return expanded, nil
 }
 
 // FilterConfig defines conditions for filtering events
@@ -33,27 +254,461 @@ type FilterConfig struct {
 
 // NotifierConfig defines notification settings
 type NotifierConfig struct {
-	Slack SlackConfig `yaml:"slack"`
+	Slack          SlackConfig          `yaml:"slack"`
+	SNS            SNSConfig            `yaml:"sns,omitempty"`
+	SQS            SQSConfig            `yaml:"sqs,omitempty"`
+	NATS           NATSConfig           `yaml:"nats,omitempty"`
+	Stdout         StdoutConfig         `yaml:"stdout,omitempty"`
+	File           FileConfig           `yaml:"file,omitempty"`
+	Webhook        WebhookConfig        `yaml:"webhook,omitempty"`
+	Alertmanager   AlertmanagerConfig   `yaml:"alertmanager,omitempty"`
+	Plugins        []PluginConfig       `yaml:"plugins,omitempty"` // Custom notifiers run as processes or HTTP sidecars
+	Retry          RetryConfig          `yaml:"retry,omitempty"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuitBreaker,omitempty"`
+	DeadLetter     DeadLetterConfig     `yaml:"deadLetter,omitempty"`
+	HTTP           HTTPConfig           `yaml:"http,omitempty"`
+	HealthCheck    HealthCheckConfig    `yaml:"healthCheck,omitempty"`
+	StartupMessage bool                 `yaml:"startupMessage,omitempty"` // Post the version to Slack on startup
+}
+
+// HealthCheckConfig contains settings for checking the notifiers on startup and reload
+type HealthCheckConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	TestMessage bool   `yaml:"testMessage,omitempty"` // Post a test message to Slack instead of checking
+	OnFailure   string `yaml:"onFailure,omitempty"`   // "fail" (default) | "alert"
+}
+
+// DestinationNames returns the names routes can send to: "slack" for the default
+// Slack notifier, the names of additional Slack destinations, and the names of
+// the other enabled notifiers
+func (n NotifierConfig) DestinationNames() []string {
+	var names []string
+	if n.Slack.WebhookURL != "" || n.Slack.BotToken != "" {
+		names = append(names, "slack")
+	}
+	for _, d := range n.Slack.Destinations {
+		names = append(names, d.Name)
+	}
+	if n.SNS.Enabled {
+		names = append(names, "sns")
+	}
+	if n.SQS.Enabled {
+		names = append(names, "sqs")
+	}
+	if n.Stdout.Enabled {
+		names = append(names, "stdout")
+	}
+	if n.NATS.Enabled {
+		names = append(names, "nats")
+	}
+	if n.File.Enabled {
+		names = append(names, "file")
+	}
+	if n.Webhook.Enabled {
+		names = append(names, "webhook")
+	}
+	if n.Alertmanager.Enabled {
+		names = append(names, "alertmanager")
+	}
+	for _, p := range n.Plugins {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// hasEventNotifiers reports whether any notifier other than Slack is enabled
+func (n NotifierConfig) hasEventNotifiers() bool {
+	return n.SNS.Enabled || n.SQS.Enabled || n.NATS.Enabled || n.Stdout.Enabled || n.File.Enabled || n.Webhook.Enabled ||
+		n.Alertmanager.Enabled || len(n.Plugins) > 0
+}
+
+// StdoutConfig enables writing events as JSON lines to standard output
+type StdoutConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// WebhookConfig contains settings for posting JSON event payloads to an HTTP endpoint
+type WebhookConfig struct {
+	Enabled       bool              `yaml:"enabled"`
+	URL           string            `yaml:"url" secret:"userinfo"`
+	Headers       map[string]string `yaml:"headers,omitempty" secret:"true"` // Values may carry credentials
+	BearerToken   SecretConfig      `yaml:"bearerToken,omitempty"`
+	BasicAuth     BasicAuthConfig   `yaml:"basicAuth,omitempty"`
+	Signing       SigningConfig     `yaml:"signing,omitempty"`
+	TimeoutConfig `yaml:",inline"`
+}
+
+// Plugin types
+const (
+	PluginExec = "exec" // Run a command with the payload on stdin
+	PluginHTTP = "http" // POST the payload to a sidecar
+)
+
+// PluginConfig contains settings for a custom notifier implemented outside
+// kube-watcher. The name is the destination name used by routes.
+type PluginConfig struct {
+	Name          string            `yaml:"name"`
+	Type          string            `yaml:"type"`                                  // "exec" | "http"
+	Command       []string          `yaml:"command,omitempty"`                     // exec: program and arguments
+	Env           map[string]string `yaml:"env,omitempty" secret:"true"`           // exec: additional environment variables
+	URL           string            `yaml:"url,omitempty" secret:"userinfo"`       // http: endpoint receiving the payloads
+	HealthURL     string            `yaml:"healthUrl,omitempty" secret:"userinfo"` // http: checked with GET by the notifier health check
+	Headers       map[string]string `yaml:"headers,omitempty" secret:"true"`       // http: values may carry credentials
+	BearerToken   SecretConfig      `yaml:"bearerToken,omitempty"`                 // http
+	TimeoutConfig `yaml:",inline"`  // timeoutSeconds also bounds the runtime of exec plugins
+}
+
+// AlertmanagerConfig contains settings for posting events as alerts to Prometheus Alertmanager
+type AlertmanagerConfig struct {
+	Enabled             bool              `yaml:"enabled"`
+	URL                 string            `yaml:"url" secret:"userinfo"`         // Base URL, e.g. http://alertmanager.monitoring:9093
+	AlertName           string            `yaml:"alertName,omitempty"`           // Default: KubernetesResourceChange
+	Labels              map[string]string `yaml:"labels,omitempty"`              // Static labels (e.g. severity)
+	ResolveAfterMinutes int               `yaml:"resolveAfterMinutes,omitempty"` // Sets endsAt; Alertmanager's resolve_timeout applies when 0
+	GeneratorURL        string            `yaml:"generatorUrl,omitempty"`
+	BearerToken         SecretConfig      `yaml:"bearerToken,omitempty"`
+	BasicAuth           BasicAuthConfig   `yaml:"basicAuth,omitempty"`
+	TimeoutConfig       `yaml:",inline"`
+}
+
+// SigningConfig contains HMAC-SHA256 payload signing settings
+type SigningConfig struct {
+	Secret          SecretConfig `yaml:"secret,omitempty"`          // Signing is enabled when set
+	SignatureHeader string       `yaml:"signatureHeader,omitempty"` // Default: X-Signature-256
+	TimestampHeader string       `yaml:"timestampHeader,omitempty"` // Sign "<timestamp>.<body>" and send the timestamp
+}
+
+// BasicAuthConfig contains HTTP basic auth credentials
+type BasicAuthConfig struct {
+	Username string       `yaml:"username,omitempty"`
+	Password SecretConfig `yaml:"password,omitempty"`
+}
+
+// SecretConfig is a credential given inline, read from an environment variable,
+// or read from a file such as a mounted Kubernetes Secret
+type SecretConfig struct {
+	Value string `yaml:"value,omitempty" secret:"true"`
+	Env   string `yaml:"env,omitempty"`
+	File  string `yaml:"file,omitempty"`
+}
+
+// IsSet reports whether any source is configured
+func (s SecretConfig) IsSet() bool {
+	return s.Value != "" || s.Env != "" || s.File != ""
+}
+
+// HTTPConfig contains outbound HTTP settings for the HTTP-based notifiers
+type HTTPConfig struct {
+	ProxyURL string    `yaml:"proxyUrl,omitempty" secret:"userinfo"` // HTTP_PROXY/HTTPS_PROXY/NO_PROXY are used when empty
+	NoProxy  string    `yaml:"noProxy,omitempty"`                    // Comma-separated hosts that bypass proxyUrl
+	TLS      TLSConfig `yaml:"tls,omitempty"`
+}
+
+// TLSConfig contains client TLS settings, e.g. for mutual TLS
+type TLSConfig struct {
+	CAFile   string `yaml:"caFile,omitempty"`   // PEM bundle trusted in addition to the system roots
+	CertFile string `yaml:"certFile,omitempty"` // Client certificate
+	KeyFile  string `yaml:"keyFile,omitempty"`  // Client certificate key
+}
+
+// RetryConfig contains retry settings for failed deliveries.
+// Network errors, timeouts, 429 and 5xx responses are retried with exponential backoff.
+type RetryConfig struct {
+	MaxAttempts       int `yaml:"maxAttempts,omitempty"`       // Total attempts including the first (1 disables retries)
+	InitialBackoffMs  int `yaml:"initialBackoffMs,omitempty"`  // Backoff before the first retry
+	MaxBackoffSeconds int `yaml:"maxBackoffSeconds,omitempty"` // Upper bound of a single backoff
+	MaxElapsedSeconds int `yaml:"maxElapsedSeconds,omitempty"` // Give up after this long
+}
+
+// CircuitBreakerConfig contains settings for suspending deliveries to a failing destination
+type CircuitBreakerConfig struct {
+	Enabled          bool `yaml:"enabled"`
+	FailureThreshold int  `yaml:"failureThreshold,omitempty"` // Consecutive failed deliveries before opening
+	CoolDownSeconds  int  `yaml:"coolDownSeconds,omitempty"`  // How long deliveries are dropped before a trial
+}
+
+// DeadLetterConfig contains settings for recording deliveries that failed after all retries
+type DeadLetterConfig struct {
+	Path        string `yaml:"path,omitempty"`        // JSON lines file with the full payload
+	MaxSizeMB   int    `yaml:"maxSizeMB,omitempty"`   // Rotate when the file exceeds this size
+	MaxBackups  *int   `yaml:"maxBackups,omitempty"`  // Rotated files to keep; 0 truncates the file instead
+	Destination string `yaml:"destination,omitempty"` // Secondary notifier: sns, nats, stdout, file or webhook
+}
+
+// FileConfig contains settings for appending events to a local file
+type FileConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Path       string `yaml:"path"`
+	Format     string `yaml:"format,omitempty"`   // json (default) or text
+	Template   string `yaml:"template,omitempty"` // Go template used when format is text
+	MaxSizeMB  int    `yaml:"maxSizeMB,omitempty"`
+	MaxBackups *int   `yaml:"maxBackups,omitempty"` // Rotated files to keep; 0 truncates the file instead
+}
+
+// NATSConfig contains NATS publisher settings
+type NATSConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	URL            string `yaml:"url" secret:"userinfo"` // nats://host:4222 or tls://host:4222
+	Subject        string `yaml:"subject,omitempty"`     // e.g. k8s.events.{namespace}.{kind}
+	Token          string `yaml:"token,omitempty" secret:"true"`
+	User           string `yaml:"user,omitempty"`
+	Password       string `yaml:"password,omitempty" secret:"true"`
+	JetStream      bool   `yaml:"jetStream,omitempty"` // Wait for JetStream publish acks
+	TimeoutSeconds int    `yaml:"timeoutSeconds,omitempty"`
+
+	TLS TLSConfig `yaml:"tls,omitempty"` // Used with tls:// URLs or when the server requires TLS
+}
+
+// SNSConfig contains Amazon SNS publisher settings.
+// Without static keys, AWS_* environment variables or IRSA are used.
+type SNSConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	TopicARN        string `yaml:"topicArn"`
+	Region          string `yaml:"region,omitempty"`   // Defaults to the topic ARN's region
+	Endpoint        string `yaml:"endpoint,omitempty"` // Custom endpoint (e.g. LocalStack)
+	RoleARN         string `yaml:"roleArn,omitempty"`  // Role assumed via STS
+	AccessKeyID     string `yaml:"accessKeyId,omitempty"`
+	SecretAccessKey string `yaml:"secretAccessKey,omitempty" secret:"true"`
+	SessionToken    string `yaml:"sessionToken,omitempty" secret:"true"`
+	TimeoutConfig   `yaml:",inline"`
+}
+
+// SQSConfig contains Amazon SQS sender settings.
+// Credentials are resolved as for SNS.
+type SQSConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	QueueURL        string `yaml:"queueUrl"`
+	Region          string `yaml:"region,omitempty"`   // Defaults to the queue URL's region
+	Endpoint        string `yaml:"endpoint,omitempty"` // Custom endpoint (e.g. LocalStack)
+	RoleARN         string `yaml:"roleArn,omitempty"`  // Role assumed via STS
+	AccessKeyID     string `yaml:"accessKeyId,omitempty"`
+	SecretAccessKey string `yaml:"secretAccessKey,omitempty" secret:"true"`
+	SessionToken    string `yaml:"sessionToken,omitempty" secret:"true"`
+	TimeoutConfig   `yaml:",inline"`
+}
+
+// TimeoutConfig contains HTTP timeouts of a notifier
+type TimeoutConfig struct {
+	TimeoutSeconds        int `yaml:"timeoutSeconds,omitempty"`        // Per request attempt, default 10
+	ConnectTimeoutSeconds int `yaml:"connectTimeoutSeconds,omitempty"` // TCP connect, default 5
+}
+
+// setDefaults fills unset timeouts from fallback and rejects negative values
+func (t *TimeoutConfig) setDefaults(field string, fallback TimeoutConfig) error {
+	if t.TimeoutSeconds == 0 {
+		t.TimeoutSeconds = fallback.TimeoutSeconds
+	}
+	if t.ConnectTimeoutSeconds == 0 {
+		t.ConnectTimeoutSeconds = fallback.ConnectTimeoutSeconds
+	}
+	if t.TimeoutSeconds < 0 || t.ConnectTimeoutSeconds < 0 {
+		return fmt.Errorf("%s.timeoutSeconds and connectTimeoutSeconds must not be negative", field)
+	}
+	return nil
 }
 
 // SlackConfig contains Slack webhook configuration
 type SlackConfig struct {
-	WebhookURL string `yaml:"webhookUrl"`
-	Template   string `yaml:"template"`
+	WebhookURL    string                   `yaml:"webhookUrl" secret:"true"`
+	BotToken      string                   `yaml:"botToken,omitempty" secret:"true"` // Post with chat.postMessage instead of a webhook
+	Channel       string                   `yaml:"channel,omitempty"`                // Channel ID or name (bot-token mode)
+	Threading     ThreadingConfig          `yaml:"threading,omitempty"`
+	Destinations  []SlackDestinationConfig `yaml:"destinations,omitempty"` // Additional channels for routes
+	Template      string                   `yaml:"template"`
+	Templates     map[string]string        `yaml:"templates,omitempty"`    // Per-event-type template overrides (e.g. DELETED)
+	Format        string                   `yaml:"format,omitempty"`       // "attachments" | "blocks" | "text"
+	Locale        string                   `yaml:"locale,omitempty"`       // "ja" | "en"
+	Labels        map[string]string        `yaml:"labels,omitempty"`       // Per-key label overrides on top of the locale
+	MaxDiffLines  *int                     `yaml:"maxDiffLines,omitempty"` // Max field changes listed per event; 0 lists none
+	Limits        SlackLimitsConfig        `yaml:"limits,omitempty"`
+	Mentions      []MentionConfig          `yaml:"mentions,omitempty"`
+	Coalesce      CoalesceConfig           `yaml:"coalesce,omitempty"`
+	RateLimit     RateLimitConfig          `yaml:"rateLimit,omitempty"`
+	TimeoutConfig `yaml:",inline"`
+}
+
+// SlackDestinationConfig is an additional Slack channel that routes can send to.
+// It uses the formatting settings of the default Slack notifier.
+type SlackDestinationConfig struct {
+	Name       string `yaml:"name"`
+	WebhookURL string `yaml:"webhookUrl,omitempty" secret:"true"`
+	BotToken   string `yaml:"botToken,omitempty" secret:"true"`
+	Channel    string `yaml:"channel,omitempty"`
+	// Not inherited from the default Slack notifier
+	RateLimit RateLimitConfig `yaml:"rateLimit,omitempty"`
+	// Timeouts default to those of the default Slack notifier
+	TimeoutConfig `yaml:",inline"`
+}
+
+// ThreadingConfig contains settings for replying to earlier messages about the
+// same resource in a thread (bot-token mode only)
+type ThreadingConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	TTLMinutes int    `yaml:"ttlMinutes,omitempty"` // Start a new thread after this long without events
+	GroupKey   string `yaml:"groupKey,omitempty"`   // Template of the thread key, e.g. "{{ .Namespace }}/{{ .OwnerName }}" (default: one thread per resource)
+}
+
+// CoalesceConfig contains settings for sending byte-identical messages to a
+// Slack destination only once within a short interval
+type CoalesceConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	IntervalSeconds int  `yaml:"intervalSeconds,omitempty"` // Repeats within this interval are counted instead of sent
+}
+
+// RateLimitConfig limits the messages sent to a Slack channel. Messages over
+// the limit are summarized in one message per minute.
+type RateLimitConfig struct {
+	MessagesPerMinute int `yaml:"messagesPerMinute,omitempty"` // 0 disables the limit
+	Burst             int `yaml:"burst,omitempty"`             // Messages sent at once before limiting (default: messagesPerMinute)
+}
+
+// validate checks the limit of the Slack destination at path
+func (r RateLimitConfig) validate(path string) error {
+	if r.MessagesPerMinute < 0 {
+		return fmt.Errorf("%s.rateLimit.messagesPerMinute must not be negative", path)
+	}
+	if r.Burst < 0 {
+		return fmt.Errorf("%s.rateLimit.burst must not be negative", path)
+	}
+	return nil
+}
+
+// SlackLimitsConfig contains size limits for Slack messages
+type SlackLimitsConfig struct {
+	MaxFields       int `yaml:"maxFields,omitempty"`       // Max fields per attachment
+	MaxContainers   int `yaml:"maxContainers,omitempty"`   // Max containers listed per event
+	MaxMessageBytes int `yaml:"maxMessageBytes,omitempty"` // Max JSON bytes per Slack request
 }
 
 // DeduplicationConfig contains event deduplication settings
 type DeduplicationConfig struct {
-	Enabled      bool   `yaml:"enabled"`
-	TTLSeconds   int    `yaml:"ttlSeconds"`
-	MaxCacheSize int    `yaml:"maxCacheSize"`
+	Enabled      bool                  `yaml:"enabled"`
+	TTLSeconds   int                   `yaml:"ttlSeconds"`
+	MaxCacheSize int                   `yaml:"maxCacheSize"`
+	Overrides    []DedupOverrideConfig `yaml:"overrides,omitempty"`
+	NeverDedupe  MatcherConfig         `yaml:"neverDedupe,omitempty"` // Events matching this are never deduplicated
+}
+
+// DedupOverrideConfig overrides deduplication settings for a resource kind
+type DedupOverrideConfig struct {
+	Kind       string `yaml:"kind"`
+	TTLSeconds int    `yaml:"ttlSeconds"`
+}
+
+// MatcherConfig defines conditions for matching events.
+// All specified conditions must match; an empty matcher matches nothing.
+// The JSON tags are used by the admin API.
+type MatcherConfig struct {
+	Clusters   []string          `yaml:"clusters,omitempty" json:"clusters,omitempty"`
+	Namespaces []string          `yaml:"namespaces,omitempty" json:"namespaces,omitempty"`
+	Kinds      []string          `yaml:"kinds,omitempty" json:"kinds,omitempty"`
+	Names      []string          `yaml:"names,omitempty" json:"names,omitempty"` // Resource names
+	EventTypes []string          `yaml:"eventTypes,omitempty" json:"eventTypes,omitempty"`
+	Severities []string          `yaml:"severities,omitempty" json:"severities,omitempty"` // Requires severity.enabled
+	Labels     map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`         // All labels must match
+	Expression string            `yaml:"expression,omitempty" json:"expression,omitempty"` // CEL expression
+}
+
+// IsEmpty reports whether the matcher has no conditions
+func (m MatcherConfig) IsEmpty() bool {
+	return len(m.Clusters) == 0 && len(m.Namespaces) == 0 && len(m.Kinds) == 0 && len(m.Names) == 0 && len(m.EventTypes) == 0 &&
+		len(m.Severities) == 0 && len(m.Labels) == 0 && m.Expression == ""
+}
+
+// validateSeverities checks the severities of the matcher at path, which
+// only match events classified by severity
+func (m MatcherConfig) validateSeverities(path string, enabled bool) error {
+	if len(m.Severities) > 0 && !enabled {
+		return fmt.Errorf("%s.severities requires severity.enabled", path)
+	}
+	for _, level := range m.Severities {
+		if !validSeverity(level) {
+			return fmt.Errorf("%s.severities must be one of: critical, warning, info (got %s)", path, level)
+		}
+	}
+	return nil
+}
+
+// SilenceConfig suppresses notifications for events matching the conditions
+// until endsAt. Silences that already ended are ignored.
+type SilenceConfig struct {
+	MatcherConfig `yaml:",inline"`
+	Comment       string    `yaml:"comment,omitempty"`
+	StartsAt      time.Time `yaml:"startsAt,omitempty"` // RFC 3339 (default: immediately)
+	EndsAt        time.Time `yaml:"endsAt"`             // RFC 3339, e.g. 2024-01-01T06:00:00+09:00
+}
+
+// Maintenance window actions
+const (
+	MaintenanceSuppress = "suppress" // Drop matching events
+	MaintenanceDigest   = "digest"   // Send matching events as one batch when the window ends
+)
+
+// MaintenanceWindow is a recurring window during which events matching the
+// conditions are suppressed or rolled into a digest. Without conditions all
+// events match.
+type MaintenanceWindow struct {
+	Name            string `yaml:"name"`
+	Schedule        string `yaml:"schedule"`           // Cron expression of the window start, e.g. "0 2 * * 6"
+	DurationMinutes int    `yaml:"durationMinutes"`    // Length of the window
+	Timezone        string `yaml:"timezone,omitempty"` // IANA time zone of the schedule (default: local time)
+	Action          string `yaml:"action,omitempty"`   // "suppress" (default) | "digest"
+	MatcherConfig   `yaml:",inline"`
+}
+
+// EnrichmentRule adds or rewrites fields of events matching the conditions.
+// Without conditions the rule applies to all events. Fields are "cluster",
+// "reason", "message", "status" and "labels.<key>"; sources may also be
+// "kind", "namespace", "name" and "eventType".
+type EnrichmentRule struct {
+	Name          string            `yaml:"name,omitempty"`
+	Set           map[string]string `yaml:"set,omitempty"` // Field -> CEL expression returning a string
+	Mappings      []FieldMapping    `yaml:"mappings,omitempty"`
+	MatcherConfig `yaml:",inline"`
+}
+
+// FieldMapping translates the value of a field through a lookup table
+type FieldMapping struct {
+	Source  string            `yaml:"source"`
+	Target  string            `yaml:"target,omitempty"` // Defaults to source
+	Values  map[string]string `yaml:"values"`
+	Default string            `yaml:"default,omitempty"` // For values not in the table; unset leaves the target unchanged
+}
+
+// enrichmentTargets are the event fields enrichment rules may write; sources may also read the identity fields
+var (
+	enrichmentTargets = map[string]bool{"cluster": true, "reason": true, "message": true, "status": true}
+	enrichmentSources = map[string]bool{"kind": true, "namespace": true, "name": true, "eventType": true}
+)
+
+// IsEnrichmentTarget reports whether enrichment rules may write the field
+func IsEnrichmentTarget(field string) bool {
+	if key, ok := strings.CutPrefix(field, "labels."); ok {
+		return key != ""
+	}
+	return enrichmentTargets[field]
+}
+
+// IsEnrichmentSource reports whether enrichment mappings may read the field
+func IsEnrichmentSource(field string) bool {
+	return IsEnrichmentTarget(field) || enrichmentSources[field]
+}
+
+// MentionConfig injects a mention into messages for events matching the conditions
+type MentionConfig struct {
+	Mention       string `yaml:"mention"` // "here", "channel", "subteam:<ID>", "user:<ID>" or raw Slack syntax
+	MatcherConfig `yaml:",inline"`
 }
 
 // BatchingConfig contains event batching settings
 type BatchingConfig struct {
 	Enabled       bool                `yaml:"enabled"`
 	WindowSeconds int                 `yaml:"windowSeconds"`
-	Mode          string              `yaml:"mode"` // "detailed" | "summary" | "smart"
+	WindowType    string              `yaml:"windowType"`   // "sliding" | "fixed"
+	Mode          string              `yaml:"mode"`         // "detailed" | "summary" | "smart"
+	GroupBy       string              `yaml:"groupBy"`      // "" | "namespace"
+	SpoolPath     string              `yaml:"spoolPath"`    // File to persist pending events across restarts (optional)
+	SummaryStats  bool                `yaml:"summaryStats"` // Prepend a statistics header to batch messages
 	Smart         SmartBatchingConfig `yaml:"smart"`
 }
 
@@ -64,50 +719,629 @@ type SmartBatchingConfig struct {
 	AlwaysShowDetails []string `yaml:"alwaysShowDetails"`
 }
 
-// LoadConfig loads configuration from a YAML file
+// CorrelationConfig links the events of a rollout (Deployment update, ReplicaSet
+// creation, Pod restarts) through owner references and sends them as one story
+type CorrelationConfig struct {
+	Enabled          bool `yaml:"enabled"`
+	WindowSeconds    int  `yaml:"windowSeconds"`    // A story ends when no related event arrives for this long (default: 30)
+	MaxWindowSeconds int  `yaml:"maxWindowSeconds"` // A story is sent at the latest after this long (default: 300)
+}
+
+// StormConfig detects event storms: when the events of a kind in a namespace
+// exceed a multiple of their usual rate, they are sent as periodic summaries
+// until the rate drops again
+type StormConfig struct {
+	Enabled                bool    `yaml:"enabled"`
+	WindowSeconds          int     `yaml:"windowSeconds,omitempty"`          // The rate is measured over this window (default 60)
+	Factor                 float64 `yaml:"factor,omitempty"`                 // Storm when the rate exceeds the baseline times this (default 5)
+	MinEvents              int     `yaml:"minEvents,omitempty"`              // ... and reaches this many events per window (default 30)
+	SummaryIntervalSeconds int     `yaml:"summaryIntervalSeconds,omitempty"` // Summaries are sent this often during a storm (default 60)
+}
+
+// NodeDrainsConfig consolidates the cordon of a Node and the Pod evictions from
+// it into one notification. A drain is also detected without a cordon when
+// many Pods are evicted from a Node at once.
+type NodeDrainsConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	WindowSeconds int  `yaml:"windowSeconds,omitempty"` // The drain is sent once no eviction arrived for this long (default 60)
+	MinEvictions  int  `yaml:"minEvictions,omitempty"`  // Evictions from a Node within the window that reveal a drain (default 5)
+}
+
+// LinkConfig defines a URL template attached to notifications (e.g. Grafana, ArgoCD)
+type LinkConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"` // Go template rendered with event fields
+}
+
+// MetricsConfig contains Prometheus metrics endpoint settings
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Address string `yaml:"address"` // Listen address (default ":9090")
+	Path    string `yaml:"path"`    // HTTP path (default "/metrics")
+	Events  bool   `yaml:"events"`  // Export per-event counters and anomaly gauges (applied on startup only)
+}
+
+// TracingConfig contains OpenTelemetry tracing settings. They are applied on startup only.
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Endpoint    string            `yaml:"endpoint"`                        // OTLP/HTTP base URL, e.g. http://otel-collector:4318
+	ServiceName string            `yaml:"serviceName,omitempty"`           // Default: kube-watcher
+	Headers     map[string]string `yaml:"headers,omitempty" secret:"true"` // Sent with every export
+	SampleRatio float64           `yaml:"sampleRatio,omitempty"`           // Fraction of events traced (default 1)
+
+	// ResourceAttributes are reported with the spans in addition to the detected
+	// ones (service.name, service.version, host.name, k8s.cluster.name)
+	ResourceAttributes map[string]string `yaml:"resourceAttributes,omitempty"`
+}
+
+// OpsAlertsConfig contains settings for alerts about kube-watcher's own failures
+type OpsAlertsConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	Destination        string `yaml:"destination,omitempty"`        // Slack destination to post to (default: all)
+	MinIntervalSeconds int    `yaml:"minIntervalSeconds,omitempty"` // Minimum time between alerts of the same kind (default 300, applied on startup only)
+}
+
+// WatchdogConfig contains settings for alerting on informers that stopped
+// delivering events. Applied on startup only.
+type WatchdogConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	StallSeconds int  `yaml:"stallSeconds,omitempty"` // Alert after this long without events, including resyncs (default 300)
+}
+
+// HeartbeatConfig contains settings for a scheduled message confirming that
+// the notification path works end to end
+type HeartbeatConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Schedule    string `yaml:"schedule,omitempty"`    // Cron expression (default "0 9 * * *", daily at 09:00)
+	Timezone    string `yaml:"timezone,omitempty"`    // IANA time zone of the schedule (default: local time)
+	Destination string `yaml:"destination,omitempty"` // Slack destination to post to (default: all)
+}
+
+// PodLogsConfig contains settings for attaching the tail of the log of a
+// failing container (CrashLoopBackOff or OOMKilled) to Pod notifications.
+// Requires the get permission on pods/log.
+type PodLogsConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	TailLines int  `yaml:"tailLines,omitempty"` // Number of lines to attach (default 20)
+	MaxBytes  int  `yaml:"maxBytes,omitempty"`  // The snippet is truncated to its last this many bytes (default 1500)
+}
+
+// RelatedEventsConfig contains settings for attaching the recent Warning
+// Kubernetes Events of a resource to its notifications, as shown by kubectl
+// describe. Requires the list permission on events.
+type RelatedEventsConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	Kinds         []string `yaml:"kinds,omitempty"`         // Resource kinds to attach Events to (default: Pod, Deployment)
+	MaxEvents     int      `yaml:"maxEvents,omitempty"`     // Number of reasons to attach, most recent first (default 3)
+	MaxAgeMinutes int      `yaml:"maxAgeMinutes,omitempty"` // Events last seen earlier are ignored (default 60)
+}
+
+// ManifestsConfig contains settings for attaching the YAML of the changed
+// object to its notifications, so that reviewers can see the new state without
+// cluster access. Managed fields and the last applied configuration are
+// removed, and the values of Secrets are redacted.
+type ManifestsConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Kinds    []string `yaml:"kinds,omitempty"`    // Resource kinds to attach manifests to (default: all)
+	MaxBytes int      `yaml:"maxBytes,omitempty"` // The manifest is truncated to this many bytes (default 2000, or 100000 when uploaded)
+	Upload   bool     `yaml:"upload,omitempty"`   // Upload the manifest as a file in the message's thread (requires botToken)
+}
+
+// ManifestDiffConfig contains settings for attaching a unified diff of the
+// old and new manifest to update notifications. Only the labels, annotations
+// and the spec or data are compared, and the values of Secrets are redacted.
+// Applied on startup only.
+type ManifestDiffConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	Kinds        []string `yaml:"kinds,omitempty"`        // Resource kinds to diff (default: Deployment, ConfigMap)
+	MaxBytes     int      `yaml:"maxBytes,omitempty"`     // The diff is truncated to this many bytes (default 2000)
+	ContextLines int      `yaml:"contextLines,omitempty"` // Unchanged lines shown around changes (default 3)
+}
+
+// CronJobsConfig contains settings for the warnings about CronJobs that missed
+// a scheduled run or whose Jobs run for too long. Requires the CronJob kind to
+// be watched. Applied on startup only.
+type CronJobsConfig struct {
+	Enabled                    bool `yaml:"enabled"`
+	MissedScheduleGraceSeconds int  `yaml:"missedScheduleGraceSeconds,omitempty"` // A run is missed when no Job started this long after its time (default 300)
+	MaxRunMinutes              int  `yaml:"maxRunMinutes,omitempty"`              // Warn about Jobs running for longer (default 60)
+}
+
+// VolumesConfig contains settings for the warnings about PersistentVolumeClaims
+// that stay Pending or fail to resize. Requires the PersistentVolumeClaim kind
+// to be watched. Applied on startup only.
+type VolumesConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	PendingMinutes int  `yaml:"pendingMinutes,omitempty"` // Warn about claims Pending for longer (default 10)
+}
+
+// CrashLoopsConfig contains settings for the crash loop reports, which
+// aggregate the crash-looping Pods of each workload into one periodic warning
+// instead of a notification per Pod. Requires the Pod kind to be watched.
+// Applied on startup only.
+type CrashLoopsConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	IntervalMinutes int  `yaml:"intervalMinutes,omitempty"` // A workload is reported at most this often (default 10)
+}
+
+// OfflineConfig contains settings for the report of the changes made while
+// kube-watcher was not running. The cached objects are saved to a snapshot
+// file periodically and on shutdown; on startup, the synced cache is compared
+// with the snapshot of the previous run. Applied on startup only.
+type OfflineConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	Path            string `yaml:"path"`                      // Snapshot file (needs a writable volume)
+	IntervalMinutes int    `yaml:"intervalMinutes,omitempty"` // The snapshot is refreshed this often (default 5)
+}
+
+// AdminConfig contains admin API settings. The API is served on the admin server
+// (metrics.address) under /api/v1/.
+type AdminConfig struct {
+	Enabled      bool         `yaml:"enabled"`                // Applied on startup only
+	Token        SecretConfig `yaml:"token,omitempty"`        // Bearer token required by every request (optional)
+	RecentEvents int          `yaml:"recentEvents,omitempty"` // Events kept for /api/v1/events (default 100, applied on startup only)
+}
+
+// HistoryConfig contains settings for the on-disk event history, queried through
+// the admin API. They are applied on startup only.
+type HistoryConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	Path           string `yaml:"path"`                     // Directory of the history files (needs a writable volume)
+	RetentionHours int    `yaml:"retentionHours,omitempty"` // Default 168 (7 days)
+}
+
+// AcksConfig tracks whether notified critical events have been acknowledged.
+// The state is kept in the history directory, so history must be enabled.
+// Enabling it is applied on startup only.
+type AcksConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	Critical           MatcherConfig `yaml:"critical,omitempty"`           // Events that need an acknowledgement (default: all notified events)
+	SlackSigningSecret SecretConfig  `yaml:"slackSigningSecret,omitempty"` // Verifies Slack interactions; enables the Acknowledge button
+}
+
+// K8sEventsConfig enables recording a Kubernetes Event (reason KubeWatcherNotified)
+// on the involved object whenever a notification about it is sent. It needs
+// permission to create and patch events, and is ignored in dry-run mode.
+type K8sEventsConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// GRPCConfig contains settings for the gRPC streaming API. They are applied on startup only.
+type GRPCConfig struct {
+	Enabled    bool         `yaml:"enabled"`
+	Address    string       `yaml:"address,omitempty"`    // Listen address (default ":9091")
+	Token      SecretConfig `yaml:"token,omitempty"`      // Bearer token required in the authorization metadata (optional)
+	BufferSize int          `yaml:"bufferSize,omitempty"` // Events buffered per subscriber before dropping (default 256)
+
+	TLS              GRPCTLSConfig `yaml:"tls,omitempty"`              // Serve over TLS instead of h2c when certFile is set
+	KeepaliveSeconds int           `yaml:"keepaliveSeconds,omitempty"` // Ping connections idle for this long, closing them without an answer (default 60)
+}
+
+// GRPCTLSConfig contains the server TLS settings of the gRPC streaming API.
+// The certificate is re-read on each handshake, so that rotated files are used.
+type GRPCTLSConfig struct {
+	CertFile     string `yaml:"certFile,omitempty"`
+	KeyFile      string `yaml:"keyFile,omitempty"`
+	ClientCAFile string `yaml:"clientCAFile,omitempty"` // Require client certificates signed by these CAs (mutual TLS)
+}
+
+// ShutdownConfig contains graceful shutdown settings
+type ShutdownConfig struct {
+	// Maximum time to flush batches and finish sending notifications after SIGTERM (default 25).
+	// Keep it below the pod's terminationGracePeriodSeconds.
+	TimeoutSeconds int `yaml:"timeoutSeconds,omitempty"`
+}
+
+// ReloadConfig contains config hot-reload settings
+type ReloadConfig struct {
+	DebounceMs        int    `yaml:"debounceMs,omitempty"`        // Wait until the file stops changing (default 500, applied on startup only)
+	Notify            bool   `yaml:"notify,omitempty"`            // Post to Slack whether a reload was applied or rejected
+	NotifyDestination string `yaml:"notifyDestination,omitempty"` // Slack destination to post to (default: all)
+}
+
+// Overrides are settings given on the command line. They take precedence over
+// environment variables, which take precedence over the configuration file.
+type Overrides struct {
+	Namespace  string
+	WebhookURL string // notifier.slack.webhookUrl
+	LogLevel   string
+	LogFormat  string
+	DryRun     bool
+}
+
+// isSet reports whether any override is given
+func (o Overrides) isSet() bool {
+	return o != Overrides{}
+}
+
+// apply sets the given overrides on c
+func (o Overrides) apply(c *Config) {
+	if o.Namespace != "" {
+		c.Namespace = o.Namespace
+	}
+	if o.WebhookURL != "" {
+		c.Notifier.Slack.WebhookURL = o.WebhookURL
+	}
+	if o.LogLevel != "" {
+		c.LogLevel = o.LogLevel
+	}
+	if o.LogFormat != "" {
+		c.LogFormat = o.LogFormat
+	}
+	if o.DryRun {
+		c.DryRun = true
+	}
+}
+
+// LoadConfig loads configuration from a YAML file and the files it includes,
+// and applies environment variable overrides (see EnvPrefix). The file may be
+// missing when the whole configuration is given through environment variables.
 func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	return LoadConfigWithOverrides(path, Overrides{})
+}
+
+// LoadConfigWithOverrides loads configuration like LoadConfig and then applies
+// command-line overrides before validation
+func LoadConfigWithOverrides(path string, overrides Overrides) (*Config, error) {
+	var files []string
+	doc, err := loadDocument(path, &files, make(map[string]bool))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		if _, statErr := os.Stat(path); !os.IsNotExist(statErr) || !(hasEnvOverrides() || overrides.isSet()) {
+			return nil, err
+		}
+		doc = nil
 	}
 
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config files: %w", err)
+	}
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	config.Files = files
+
+	return finishConfig(&config, overrides)
+}
+
+// LoadConfigFromSpec builds the configuration from the spec of a KubeWatcher
+// custom resource. Environment variable and command-line overrides apply as for
+// files, but include is not supported.
+func LoadConfigFromSpec(spec map[string]interface{}, overrides Overrides) (*Config, error) {
+	if _, ok := spec["include"]; ok {
+		return nil, fmt.Errorf("include is not supported in a KubeWatcher resource")
+	}
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert spec: %w", err)
+	}
+
+	var config Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&config); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+
+	return finishConfig(&config, overrides)
+}
+
+// finishConfig applies the overrides to a decoded configuration and validates it
+func finishConfig(config *Config, overrides Overrides) (*Config, error) {
+	if err := applyEnv(config, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	overrides.apply(config)
 
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	return &config, nil
+	return config, nil
 }
 
+// defaultTimeouts are the HTTP timeouts of notifiers that do not set their own
+var defaultTimeouts = TimeoutConfig{TimeoutSeconds: 10, ConnectTimeoutSeconds: 5}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
-	if c.Namespace == "" {
-		return fmt.Errorf("namespace is required")
-	}
-
 	if len(c.Resources) == 0 {
 		return fmt.Errorf("at least one resource must be configured")
 	}
+	resources, err := expandResources(c.Resources, c.Namespace)
+	if err != nil {
+		return err
+	}
+	c.Resources = resources
+	for _, r := range c.Resources {
+		if !ClusterScopedKinds[r.Kind] && c.WatchNamespace(r) == "" {
+			return fmt.Errorf("namespace is required for %s, either at the top level or in its resource", r.Kind)
+		}
+	}
 
-	if c.Notifier.Slack.WebhookURL == "" {
-		return fmt.Errorf("slack webhook URL is required")
+	if err := c.validateNamespacePatterns(); err != nil {
+		return err
 	}
 
-	if c.Notifier.Slack.Template == "" {
-		c.Notifier.Slack.Template = "[{{ .Kind }}] {{ .Namespace }}/{{ .Name }} was {{ .EventType }}"
+	if c.LogLevel == "" {
+		c.LogLevel = "info"
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("logLevel must be one of: debug, info, warn, error (got %s)", c.LogLevel)
+	}
+	if c.LogFormat == "" {
+		c.LogFormat = "text"
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("logFormat must be one of: text, json (got %s)", c.LogFormat)
 	}
 
-	// Set deduplication defaults if not specified
-	if c.Deduplication.Enabled {
-		if c.Deduplication.TTLSeconds <= 0 {
-			c.Deduplication.TTLSeconds = 300 // Default: 5 minutes
+	if c.Notifier.Slack.WebhookURL == "" && c.Notifier.Slack.BotToken == "" &&
+		len(c.Notifier.Slack.Destinations) == 0 && !c.Notifier.hasEventNotifiers() && !c.DryRun {
+		return fmt.Errorf("slack webhook URL is required unless another notifier is enabled")
+	}
+	if c.Notifier.Slack.BotToken != "" && c.Notifier.Slack.Channel == "" {
+		return fmt.Errorf("notifier.slack.channel is required when botToken is set")
+	}
+	if err := c.Notifier.Slack.setDefaults("notifier.slack", defaultTimeouts); err != nil {
+		return err
+	}
+	for i, d := range c.Notifier.Slack.Destinations {
+		field := fmt.Sprintf("notifier.slack.destinations[%d]", i)
+		if err := c.Notifier.Slack.Destinations[i].setDefaults(field, c.Notifier.Slack.TimeoutConfig); err != nil {
+			return err
 		}
-		if c.Deduplication.MaxCacheSize <= 0 {
-			c.Deduplication.MaxCacheSize = 1000 // Default: 1000 entries
+		if d.Name == "" {
+			return fmt.Errorf("notifier.slack.destinations[%d].name is required", i)
+		}
+		if d.WebhookURL == "" && d.BotToken == "" {
+			return fmt.Errorf("notifier.slack.destinations[%d] requires webhookUrl or botToken", i)
+		}
+		if d.BotToken != "" && d.Channel == "" {
+			return fmt.Errorf("notifier.slack.destinations[%d].channel is required when botToken is set", i)
+		}
+		if err := d.RateLimit.validate(fmt.Sprintf("notifier.slack.destinations[%d]", i)); err != nil {
+			return err
+		}
+	}
+	if c.Notifier.Slack.Threading.Enabled {
+		if c.Notifier.Slack.BotToken == "" {
+			return fmt.Errorf("notifier.slack.threading requires botToken")
+		}
+		if c.Notifier.Slack.Threading.TTLMinutes == 0 {
+			c.Notifier.Slack.Threading.TTLMinutes = 1440
+		}
+		if c.Notifier.Slack.Threading.TTLMinutes < 0 {
+			return fmt.Errorf("notifier.slack.threading.ttlMinutes must not be negative")
+		}
+	}
+	if err := c.Notifier.Slack.RateLimit.validate("notifier.slack"); err != nil {
+		return err
+	}
+	if c.Notifier.Slack.Coalesce.Enabled {
+		if c.Notifier.Slack.Coalesce.IntervalSeconds == 0 {
+			c.Notifier.Slack.Coalesce.IntervalSeconds = 10
+		}
+		if c.Notifier.Slack.Coalesce.IntervalSeconds < 0 {
+			return fmt.Errorf("notifier.slack.coalesce.intervalSeconds must not be negative")
+		}
+	}
+
+	if c.Notifier.Slack.Template == "" {
+		c.Notifier.Slack.Template = "[{{ .Kind }}] {{ .Namespace }}/{{ .Name }} was {{ .EventType }}"
+	}
+
+	if c.Notifier.Slack.Format == "" {
+		c.Notifier.Slack.Format = "attachments"
+	}
+	if !validSlackFormat(c.Notifier.Slack.Format) {
+		return fmt.Errorf("notifier.slack.format must be one of: attachments, blocks, text (got %s)", c.Notifier.Slack.Format)
+	}
+
+	if c.Notifier.Slack.MaxDiffLines == nil {
+		c.Notifier.Slack.MaxDiffLines = intPtr(10)
+	}
+	if n := *c.Notifier.Slack.MaxDiffLines; n < 0 {
+		return fmt.Errorf("notifier.slack.maxDiffLines must not be negative (got %d)", n)
+	}
+
+	for i, m := range c.Notifier.Slack.Mentions {
+		if m.Mention == "" {
+			return fmt.Errorf("notifier.slack.mentions[%d] requires mention", i)
+		}
+		if m.IsEmpty() {
+			return fmt.Errorf("notifier.slack.mentions[%d] requires at least one condition", i)
+		}
+	}
+
+	if c.Notifier.SNS.Enabled && c.Notifier.SNS.TopicARN == "" {
+		return fmt.Errorf("notifier.sns.topicArn is required when sns is enabled")
+	}
+	if err := c.Notifier.SNS.setDefaults("notifier.sns", defaultTimeouts); err != nil {
+		return err
+	}
+	if c.Notifier.SQS.Enabled && c.Notifier.SQS.QueueURL == "" {
+		return fmt.Errorf("notifier.sqs.queueUrl is required when sqs is enabled")
+	}
+	if err := c.Notifier.SQS.setDefaults("notifier.sqs", defaultTimeouts); err != nil {
+		return err
+	}
+	if err := c.Notifier.Webhook.setDefaults("notifier.webhook", defaultTimeouts); err != nil {
+		return err
+	}
+	if c.Notifier.NATS.Enabled {
+		if c.Notifier.NATS.URL == "" {
+			return fmt.Errorf("notifier.nats.url is required when nats is enabled")
+		}
+		if c.Notifier.NATS.Subject == "" {
+			c.Notifier.NATS.Subject = "k8s.events.{namespace}.{kind}"
+		}
+		if c.Notifier.NATS.TimeoutSeconds <= 0 {
+			c.Notifier.NATS.TimeoutSeconds = 5
+		}
+	}
+	if c.Notifier.File.Enabled {
+		file := &c.Notifier.File
+		if file.Path == "" {
+			return fmt.Errorf("notifier.file.path is required when file is enabled")
+		}
+		if file.Format == "" {
+			file.Format = "json"
+		}
+		if file.Format != "json" && file.Format != "text" {
+			return fmt.Errorf("notifier.file.format must be one of: json, text (got %s)", file.Format)
+		}
+		if file.Format == "text" && file.Template == "" {
+			return fmt.Errorf("notifier.file.template is required when format is text")
+		}
+		if file.MaxSizeMB == 0 {
+			file.MaxSizeMB = 100
+		}
+		if file.MaxBackups == nil {
+			file.MaxBackups = intPtr(5)
+		}
+		if file.MaxSizeMB < 0 || *file.MaxBackups < 0 {
+			return fmt.Errorf("notifier.file.maxSizeMB and maxBackups must not be negative")
+		}
+	}
+
+	// Set retry defaults
+	retry := &c.Notifier.Retry
+	if retry.MaxAttempts == 0 {
+		retry.MaxAttempts = 3
+	}
+	if retry.InitialBackoffMs == 0 {
+		retry.InitialBackoffMs = 500
+	}
+	if retry.MaxBackoffSeconds == 0 {
+		retry.MaxBackoffSeconds = 30
+	}
+	if retry.MaxElapsedSeconds == 0 {
+		retry.MaxElapsedSeconds = 60
+	}
+	if retry.MaxAttempts < 0 || retry.InitialBackoffMs < 0 || retry.MaxBackoffSeconds < 0 || retry.MaxElapsedSeconds < 0 {
+		return fmt.Errorf("notifier.retry values must not be negative")
+	}
+
+	// Set circuit breaker defaults
+	if cb := &c.Notifier.CircuitBreaker; cb.Enabled {
+		if cb.FailureThreshold == 0 {
+			cb.FailureThreshold = 5
+		}
+		if cb.CoolDownSeconds == 0 {
+			cb.CoolDownSeconds = 60
+		}
+		if cb.FailureThreshold < 0 || cb.CoolDownSeconds < 0 {
+			return fmt.Errorf("notifier.circuitBreaker values must not be negative")
+		}
+	}
+
+	if tlsCfg := c.Notifier.HTTP.TLS; (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		return fmt.Errorf("notifier.http.tls.certFile and keyFile must be set together")
+	}
+	if tlsCfg := c.Notifier.NATS.TLS; (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		return fmt.Errorf("notifier.nats.tls.certFile and keyFile must be set together")
+	}
+
+	if wh := c.Notifier.Webhook; wh.Enabled {
+		if wh.URL == "" {
+			return fmt.Errorf("notifier.webhook.url is required when webhook is enabled")
+		}
+		if wh.BearerToken.IsSet() && wh.BasicAuth.Username != "" {
+			return fmt.Errorf("notifier.webhook.bearerToken and basicAuth are mutually exclusive")
+		}
+	}
+	if am := &c.Notifier.Alertmanager; am.Enabled {
+		if am.URL == "" {
+			return fmt.Errorf("notifier.alertmanager.url is required when alertmanager is enabled")
+		}
+		if am.BearerToken.IsSet() && am.BasicAuth.Username != "" {
+			return fmt.Errorf("notifier.alertmanager.bearerToken and basicAuth are mutually exclusive")
+		}
+		if am.ResolveAfterMinutes < 0 {
+			return fmt.Errorf("notifier.alertmanager.resolveAfterMinutes must not be negative")
+		}
+		if err := am.setDefaults("notifier.alertmanager", defaultTimeouts); err != nil {
+			return err
+		}
+	}
+
+	if err := c.validatePlugins(); err != nil {
+		return err
+	}
+
+	if hc := &c.Notifier.HealthCheck; hc.Enabled {
+		if hc.OnFailure == "" {
+			hc.OnFailure = "fail"
+		}
+		if hc.OnFailure != "fail" && hc.OnFailure != "alert" {
+			return fmt.Errorf("notifier.healthCheck.onFailure must be one of: fail, alert (got %s)", hc.OnFailure)
+		}
+	}
+
+	// Set dead-letter defaults
+	if dl := &c.Notifier.DeadLetter; dl.Path != "" {
+		if dl.MaxSizeMB == 0 {
+			dl.MaxSizeMB = 100
+		}
+		if dl.MaxBackups == nil {
+			dl.MaxBackups = intPtr(5)
+		}
+		if dl.MaxSizeMB < 0 || *dl.MaxBackups < 0 {
+			return fmt.Errorf("notifier.deadLetter.maxSizeMB and maxBackups must not be negative")
+		}
+	}
+	if dest := c.Notifier.DeadLetter.Destination; dest != "" {
+		enabled := map[string]bool{
+			"sns":          c.Notifier.SNS.Enabled,
+			"sqs":          c.Notifier.SQS.Enabled,
+			"nats":         c.Notifier.NATS.Enabled,
+			"stdout":       c.Notifier.Stdout.Enabled,
+			"file":         c.Notifier.File.Enabled,
+			"webhook":      c.Notifier.Webhook.Enabled,
+			"alertmanager": c.Notifier.Alertmanager.Enabled,
+		}
+		for _, p := range c.Notifier.Plugins {
+			enabled[p.Name] = true
+		}
+		if !enabled[dest] {
+			return fmt.Errorf("notifier.deadLetter.destination must be an enabled sns, sqs, nats, stdout, file, webhook, alertmanager or plugin notifier (got %s)", dest)
+		}
+	}
+
+	// Set message size limit defaults
+	limits := &c.Notifier.Slack.Limits
+	if limits.MaxFields == 0 {
+		limits.MaxFields = 10
+	}
+	if limits.MaxContainers == 0 {
+		limits.MaxContainers = 10
+	}
+	if limits.MaxMessageBytes == 0 {
+		limits.MaxMessageBytes = 40000
+	}
+	if limits.MaxFields < 0 || limits.MaxContainers < 0 || limits.MaxMessageBytes < 0 {
+		return fmt.Errorf("notifier.slack.limits must not be negative")
+	}
+
+	// Set deduplication defaults if not specified
+	if c.Deduplication.Enabled {
+		if c.Deduplication.TTLSeconds <= 0 {
+			c.Deduplication.TTLSeconds = 300 // Default: 5 minutes
+		}
+		if c.Deduplication.MaxCacheSize <= 0 {
+			c.Deduplication.MaxCacheSize = 1000 // Default: 1000 entries
+		}
+		for i, o := range c.Deduplication.Overrides {
+			if o.Kind == "" {
+				return fmt.Errorf("deduplication.overrides[%d].kind is required", i)
+			}
+			if o.TTLSeconds <= 0 {
+				return fmt.Errorf("deduplication.overrides[%d].ttlSeconds must be positive (got %d)", i, o.TTLSeconds)
+			}
 		}
 	}
 
@@ -117,7 +1351,15 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("batching.windowSeconds must be at least 30 seconds (got %d)", c.Batching.WindowSeconds)
 		}
 		if c.Batching.WindowSeconds > 600 {
-			fmt.Printf("Warning: batching.windowSeconds is %d (>10min). Consider using a shorter window for better responsiveness.\n", c.Batching.WindowSeconds)
+			slog.Warn("Batching window is longer than 10 minutes; consider a shorter window for better responsiveness", "windowSeconds", c.Batching.WindowSeconds)
+		}
+
+		// Set default window type if not specified
+		if c.Batching.WindowType == "" {
+			c.Batching.WindowType = "sliding"
+		}
+		if c.Batching.WindowType != "sliding" && c.Batching.WindowType != "fixed" {
+			return fmt.Errorf("batching.windowType must be one of: sliding, fixed (got %s)", c.Batching.WindowType)
 		}
 
 		// Set default mode if not specified
@@ -131,6 +1373,11 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("batching.mode must be one of: detailed, summary, smart (got %s)", c.Batching.Mode)
 		}
 
+		// Validate groupBy
+		if c.Batching.GroupBy != "" && c.Batching.GroupBy != "namespace" {
+			return fmt.Errorf("batching.groupBy must be empty or namespace (got %s)", c.Batching.GroupBy)
+		}
+
 		// Set smart batching defaults
 		if c.Batching.Mode == "smart" {
 			if c.Batching.Smart.MaxEventsPerGroup <= 0 {
@@ -145,6 +1392,705 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Storm.Enabled {
+		if c.Storm.WindowSeconds == 0 {
+			c.Storm.WindowSeconds = 60
+		}
+		if c.Storm.Factor == 0 {
+			c.Storm.Factor = 5
+		}
+		if c.Storm.MinEvents == 0 {
+			c.Storm.MinEvents = 30
+		}
+		if c.Storm.SummaryIntervalSeconds == 0 {
+			c.Storm.SummaryIntervalSeconds = 60
+		}
+		if c.Storm.WindowSeconds < 6 {
+			return fmt.Errorf("storm.windowSeconds must be at least 6 (got %d)", c.Storm.WindowSeconds)
+		}
+		if c.Storm.Factor <= 1 {
+			return fmt.Errorf("storm.factor must be greater than 1 (got %g)", c.Storm.Factor)
+		}
+		if c.Storm.MinEvents < 0 || c.Storm.SummaryIntervalSeconds < 0 {
+			return fmt.Errorf("storm.minEvents and storm.summaryIntervalSeconds must not be negative")
+		}
+	}
+
+	if c.NodeDrains.Enabled {
+		if !c.watches("Node") && !c.watches("Pod") {
+			return fmt.Errorf("nodeDrains requires the Node or Pod kind in resources")
+		}
+		if c.NodeDrains.WindowSeconds == 0 {
+			c.NodeDrains.WindowSeconds = 60
+		}
+		if c.NodeDrains.MinEvictions == 0 {
+			c.NodeDrains.MinEvictions = 5
+		}
+		if c.NodeDrains.WindowSeconds < 0 {
+			return fmt.Errorf("nodeDrains.windowSeconds must be positive (got %d)", c.NodeDrains.WindowSeconds)
+		}
+		if c.NodeDrains.MinEvictions < 2 {
+			return fmt.Errorf("nodeDrains.minEvictions must be at least 2 (got %d)", c.NodeDrains.MinEvictions)
+		}
+	}
+
+	if c.Correlation.Enabled {
+		if c.Correlation.WindowSeconds == 0 {
+			c.Correlation.WindowSeconds = 30
+		}
+		if c.Correlation.MaxWindowSeconds == 0 {
+			c.Correlation.MaxWindowSeconds = 300
+		}
+		if c.Correlation.WindowSeconds < 0 {
+			return fmt.Errorf("correlation.windowSeconds must be positive (got %d)", c.Correlation.WindowSeconds)
+		}
+		if c.Correlation.MaxWindowSeconds < c.Correlation.WindowSeconds {
+			return fmt.Errorf("correlation.maxWindowSeconds must be at least windowSeconds (got %d)", c.Correlation.MaxWindowSeconds)
+		}
+	}
+
+	for i, link := range c.Links {
+		if link.Name == "" || link.URL == "" {
+			return fmt.Errorf("links[%d] requires name and url", i)
+		}
+	}
+
+	if err := c.validateRoutes(); err != nil {
+		return err
+	}
+
+	for i, s := range c.Silences {
+		if s.MatcherConfig.IsEmpty() {
+			return fmt.Errorf("silences[%d] must have at least one condition", i)
+		}
+		if s.EndsAt.IsZero() {
+			return fmt.Errorf("silences[%d].endsAt is required", i)
+		}
+		if !s.StartsAt.IsZero() && !s.EndsAt.After(s.StartsAt) {
+			return fmt.Errorf("silences[%d].endsAt must be after startsAt", i)
+		}
+	}
+
+	for i := range c.Enrichment {
+		r := &c.Enrichment[i]
+		if len(r.Set) == 0 && len(r.Mappings) == 0 {
+			return fmt.Errorf("enrichment[%d] requires set or mappings", i)
+		}
+		for field, expr := range r.Set {
+			if !IsEnrichmentTarget(field) {
+				return fmt.Errorf("enrichment[%d].set: cannot set field %s", i, field)
+			}
+			if expr == "" {
+				return fmt.Errorf("enrichment[%d].set.%s requires an expression", i, field)
+			}
+		}
+		for j := range r.Mappings {
+			m := &r.Mappings[j]
+			if !IsEnrichmentSource(m.Source) {
+				return fmt.Errorf("enrichment[%d].mappings[%d]: unknown source field %s", i, j, m.Source)
+			}
+			if m.Target == "" {
+				m.Target = m.Source
+			}
+			if !IsEnrichmentTarget(m.Target) {
+				return fmt.Errorf("enrichment[%d].mappings[%d]: cannot set field %s", i, j, m.Target)
+			}
+			if len(m.Values) == 0 && m.Default == "" {
+				return fmt.Errorf("enrichment[%d].mappings[%d] requires values or default", i, j)
+			}
+		}
+	}
+
+	windowNames := make(map[string]bool, len(c.Maintenance))
+	for i := range c.Maintenance {
+		w := &c.Maintenance[i]
+		if w.Name == "" {
+			return fmt.Errorf("maintenanceWindows[%d].name is required", i)
+		}
+		if windowNames[w.Name] {
+			return fmt.Errorf("maintenanceWindows[%d].name %s is duplicated", i, w.Name)
+		}
+		windowNames[w.Name] = true
+		if w.Schedule == "" {
+			return fmt.Errorf("maintenanceWindows[%d].schedule is required", i)
+		}
+		if w.DurationMinutes <= 0 {
+			return fmt.Errorf("maintenanceWindows[%d].durationMinutes must be positive", i)
+		}
+		if w.Action == "" {
+			w.Action = MaintenanceSuppress
+		}
+		if w.Action != MaintenanceSuppress && w.Action != MaintenanceDigest {
+			return fmt.Errorf("maintenanceWindows[%d].action must be one of: suppress, digest (got %s)", i, w.Action)
+		}
+	}
+
+	if c.Reload.DebounceMs == 0 {
+		c.Reload.DebounceMs = 500
+	}
+	if c.Reload.DebounceMs < 0 {
+		return fmt.Errorf("reload.debounceMs must not be negative")
+	}
+	if dest := c.Reload.NotifyDestination; dest != "" && !c.hasSlackDestination(dest) {
+		return fmt.Errorf("reload.notifyDestination must be a configured Slack destination (got %s)", dest)
+	}
+
+	if c.OpsAlerts.Enabled {
+		if dest := c.OpsAlerts.Destination; dest != "" && !c.hasSlackDestination(dest) {
+			return fmt.Errorf("opsAlerts.destination must be a configured Slack destination (got %s)", dest)
+		}
+		if c.OpsAlerts.MinIntervalSeconds == 0 {
+			c.OpsAlerts.MinIntervalSeconds = 300
+		}
+		if c.OpsAlerts.MinIntervalSeconds < 0 {
+			return fmt.Errorf("opsAlerts.minIntervalSeconds must not be negative")
+		}
+	}
+
+	if c.Heartbeat.Enabled {
+		if c.Heartbeat.Schedule == "" {
+			c.Heartbeat.Schedule = "0 9 * * *"
+		}
+		if dest := c.Heartbeat.Destination; dest != "" && !c.hasSlackDestination(dest) {
+			return fmt.Errorf("heartbeat.destination must be a configured Slack destination (got %s)", dest)
+		}
+	}
+
+	if c.PodLogs.Enabled {
+		if c.PodLogs.TailLines == 0 {
+			c.PodLogs.TailLines = 20
+		}
+		if c.PodLogs.MaxBytes == 0 {
+			c.PodLogs.MaxBytes = 1500
+		}
+		if c.PodLogs.TailLines < 0 || c.PodLogs.MaxBytes < 0 {
+			return fmt.Errorf("podLogs.tailLines and podLogs.maxBytes must not be negative")
+		}
+		// Slack rejects text blocks over 3000 characters
+		if c.PodLogs.MaxBytes > 2500 {
+			return fmt.Errorf("podLogs.maxBytes must be at most 2500 (got %d)", c.PodLogs.MaxBytes)
+		}
+	}
+
+	if c.RelatedEvents.Enabled {
+		if len(c.RelatedEvents.Kinds) == 0 {
+			c.RelatedEvents.Kinds = []string{"Pod", "Deployment"}
+		}
+		if c.RelatedEvents.MaxEvents == 0 {
+			c.RelatedEvents.MaxEvents = 3
+		}
+		if c.RelatedEvents.MaxAgeMinutes == 0 {
+			c.RelatedEvents.MaxAgeMinutes = 60
+		}
+		if c.RelatedEvents.MaxEvents < 0 || c.RelatedEvents.MaxAgeMinutes < 0 {
+			return fmt.Errorf("relatedEvents.maxEvents and relatedEvents.maxAgeMinutes must not be negative")
+		}
+	}
+
+	if c.Manifests.Enabled {
+		if c.Manifests.MaxBytes == 0 {
+			c.Manifests.MaxBytes = 2000
+			if c.Manifests.Upload {
+				c.Manifests.MaxBytes = 100000
+			}
+		}
+		if c.Manifests.MaxBytes < 0 {
+			return fmt.Errorf("manifests.maxBytes must not be negative")
+		}
+		if c.Manifests.Upload {
+			if !c.hasSlackBotToken() {
+				return fmt.Errorf("manifests.upload requires botToken")
+			}
+			if c.Manifests.MaxBytes > 1000000 {
+				return fmt.Errorf("manifests.maxBytes must be at most 1000000 when uploaded (got %d)", c.Manifests.MaxBytes)
+			}
+		} else if c.Manifests.MaxBytes > 2500 {
+			// Slack rejects text blocks over 3000 characters
+			return fmt.Errorf("manifests.maxBytes must be at most 2500 (got %d)", c.Manifests.MaxBytes)
+		}
+	}
+
+	if c.ManifestDiff.Enabled {
+		if len(c.ManifestDiff.Kinds) == 0 {
+			c.ManifestDiff.Kinds = []string{"Deployment", "ConfigMap"}
+		}
+		if c.ManifestDiff.MaxBytes == 0 {
+			c.ManifestDiff.MaxBytes = 2000
+		}
+		if c.ManifestDiff.ContextLines == 0 {
+			c.ManifestDiff.ContextLines = 3
+		}
+		if c.ManifestDiff.MaxBytes < 0 || c.ManifestDiff.ContextLines < 0 {
+			return fmt.Errorf("manifestDiff.maxBytes and manifestDiff.contextLines must not be negative")
+		}
+		if c.ManifestDiff.MaxBytes > 2500 {
+			return fmt.Errorf("manifestDiff.maxBytes must be at most 2500 (got %d)", c.ManifestDiff.MaxBytes)
+		}
+	}
+
+	if c.Severity.Enabled {
+		if err := c.Severity.validate(); err != nil {
+			return err
+		}
+		for level, b := range c.Severity.Behaviors {
+			if !validSeverity(level) {
+				return fmt.Errorf("severity.behaviors has unknown severity: %s", level)
+			}
+			if b.Deduplicate != nil && *b.Deduplicate && !c.Deduplication.Enabled {
+				return fmt.Errorf("severity.behaviors.%s.deduplicate requires deduplication.enabled", level)
+			}
+			if b.Batch != nil && *b.Batch {
+				if !c.Batching.Enabled {
+					return fmt.Errorf("severity.behaviors.%s.batch requires batching.enabled", level)
+				}
+				if b.Immediate {
+					return fmt.Errorf("severity.behaviors.%s cannot be both immediate and batched", level)
+				}
+			}
+		}
+	}
+
+	if c.CronJobs.Enabled {
+		if !c.watches("CronJob") {
+			return fmt.Errorf("cronJobs requires the CronJob kind in resources")
+		}
+		if c.CronJobs.MissedScheduleGraceSeconds == 0 {
+			c.CronJobs.MissedScheduleGraceSeconds = 300
+		}
+		if c.CronJobs.MaxRunMinutes == 0 {
+			c.CronJobs.MaxRunMinutes = 60
+		}
+		if c.CronJobs.MissedScheduleGraceSeconds < 0 || c.CronJobs.MaxRunMinutes < 0 {
+			return fmt.Errorf("cronJobs.missedScheduleGraceSeconds and cronJobs.maxRunMinutes must not be negative")
+		}
+	}
+
+	if c.Volumes.Enabled {
+		if !c.watches("PersistentVolumeClaim") {
+			return fmt.Errorf("volumes requires the PersistentVolumeClaim kind in resources")
+		}
+		if c.Volumes.PendingMinutes == 0 {
+			c.Volumes.PendingMinutes = 10
+		}
+		if c.Volumes.PendingMinutes < 0 {
+			return fmt.Errorf("volumes.pendingMinutes must not be negative")
+		}
+	}
+
+	if c.CrashLoops.Enabled {
+		if !c.watches("Pod") {
+			return fmt.Errorf("crashLoops requires the Pod kind in resources")
+		}
+		if c.CrashLoops.IntervalMinutes == 0 {
+			c.CrashLoops.IntervalMinutes = 10
+		}
+		if c.CrashLoops.IntervalMinutes < 0 {
+			return fmt.Errorf("crashLoops.intervalMinutes must not be negative")
+		}
+	}
+
+	if c.Offline.Enabled {
+		if c.Offline.Path == "" {
+			return fmt.Errorf("offlineChanges.path is required when offlineChanges is enabled")
+		}
+		if c.Offline.IntervalMinutes == 0 {
+			c.Offline.IntervalMinutes = 5
+		}
+		if c.Offline.IntervalMinutes < 0 {
+			return fmt.Errorf("offlineChanges.intervalMinutes must not be negative")
+		}
+	}
+
+	if c.Watchdog.Enabled {
+		if c.Watchdog.StallSeconds == 0 {
+			c.Watchdog.StallSeconds = 300
+		}
+		// Informers resync every 30 seconds, so a shorter period would alert on idle kinds
+		if c.Watchdog.StallSeconds < 60 {
+			return fmt.Errorf("watchdog.stallSeconds must be at least 60 (got %d)", c.Watchdog.StallSeconds)
+		}
+	}
+
+	if c.Tracing.Enabled {
+		if c.Tracing.Endpoint == "" {
+			return fmt.Errorf("tracing.endpoint is required when tracing is enabled")
+		}
+		if c.Tracing.SampleRatio == 0 {
+			c.Tracing.SampleRatio = 1
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			return fmt.Errorf("tracing.sampleRatio must be between 0 and 1 (got %v)", c.Tracing.SampleRatio)
+		}
+	}
+
+	if c.Admin.Enabled {
+		if c.Admin.RecentEvents == 0 {
+			c.Admin.RecentEvents = 100
+		}
+		if c.Admin.RecentEvents < 0 {
+			return fmt.Errorf("admin.recentEvents must not be negative")
+		}
+	}
+
+	if c.GRPC.Enabled {
+		if c.GRPC.Address == "" {
+			c.GRPC.Address = ":9091"
+		}
+		if c.GRPC.BufferSize == 0 {
+			c.GRPC.BufferSize = 256
+		}
+		if c.GRPC.BufferSize < 0 {
+			return fmt.Errorf("grpc.bufferSize must not be negative")
+		}
+		if c.GRPC.KeepaliveSeconds == 0 {
+			c.GRPC.KeepaliveSeconds = 60
+		}
+		if c.GRPC.KeepaliveSeconds < 0 {
+			return fmt.Errorf("grpc.keepaliveSeconds must not be negative")
+		}
+		if tlsCfg := c.GRPC.TLS; (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+			return fmt.Errorf("grpc.tls.certFile and keyFile must be set together")
+		}
+		if c.GRPC.TLS.ClientCAFile != "" && c.GRPC.TLS.CertFile == "" {
+			return fmt.Errorf("grpc.tls.clientCAFile requires certFile and keyFile")
+		}
+	}
+
+	if c.History.Enabled {
+		if c.History.Path == "" {
+			return fmt.Errorf("history.path is required when history is enabled")
+		}
+		if c.History.RetentionHours == 0 {
+			c.History.RetentionHours = 168
+		}
+		if c.History.RetentionHours < 0 {
+			return fmt.Errorf("history.retentionHours must not be negative")
+		}
+	}
+
+	if c.Acks.Enabled && !c.History.Enabled {
+		return fmt.Errorf("acknowledgements require history to be enabled")
+	}
+
+	if c.Shutdown.TimeoutSeconds == 0 {
+		c.Shutdown.TimeoutSeconds = 25
+	}
+	if c.Shutdown.TimeoutSeconds < 0 {
+		return fmt.Errorf("shutdown.timeoutSeconds must not be negative")
+	}
+
+	// Set metrics defaults
+	if c.Metrics.Enabled {
+		if c.Metrics.Address == "" {
+			c.Metrics.Address = ":9090"
+		}
+		if c.Metrics.Path == "" {
+			c.Metrics.Path = "/metrics"
+		}
+	}
+
+	return nil
+}
+
+// hasSlackDestination reports whether name is "slack" for the default Slack
+// notifier or the name of a configured Slack destination
+func (c *Config) hasSlackDestination(name string) bool {
+	if name == "slack" && (c.Notifier.Slack.WebhookURL != "" || c.Notifier.Slack.BotToken != "") {
+		return true
+	}
+	for _, d := range c.Notifier.Slack.Destinations {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// intPtr returns a pointer to n, for defaults of settings where 0 is a valid value
+func intPtr(n int) *int {
+	return &n
+}
+
+// validSlackFormat reports whether the Slack message format is known
+func validSlackFormat(format string) bool {
+	return format == "attachments" || format == "blocks" || format == "text"
+}
+
+// hasSlackBotToken reports whether a Slack destination posts with a bot token
+func (c *Config) hasSlackBotToken() bool {
+	if c.Notifier.Slack.BotToken != "" {
+		return true
+	}
+	for _, d := range c.Notifier.Slack.Destinations {
+		if d.BotToken != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchNamespace returns the namespace the resource is watched in, or an empty
+// string for cluster-scoped kinds
+func (c *Config) WatchNamespace(r ResourceConfig) string {
+	if ClusterScopedKinds[r.Kind] {
+		return ""
+	}
+	if r.Namespace != "" {
+		return r.Namespace
+	}
+	return c.Namespace
+}
+
+// WatchedNamespaces returns the sorted namespaces the namespaced resources are watched in
+func (c *Config) WatchedNamespaces() []string {
+	var namespaces []string
+	for _, r := range c.Resources {
+		if namespace := c.WatchNamespace(r); namespace != "" && !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	slices.Sort(namespaces)
+	return namespaces
+}
+
+// watches reports whether the kind is in resources. Presets must have been expanded.
+func (c *Config) watches(kind string) bool {
+	for _, r := range c.Resources {
+		if r.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinDestinations are the destination names of the built-in notifiers
+var builtinDestinations = []string{"slack", "sns", "sqs", "nats", "stdout", "file", "webhook", "alertmanager"}
+
+// validatePlugins checks the plugin notifiers and sets their defaults
+func (c *Config) validatePlugins() error {
+	names := make(map[string]bool)
+	for _, name := range builtinDestinations {
+		names[name] = true
+	}
+	for i := range c.Notifier.Plugins {
+		p := &c.Notifier.Plugins[i]
+		field := fmt.Sprintf("notifier.plugins[%d]", i)
+		if p.Name == "" {
+			return fmt.Errorf("%s.name is required", field)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("%s.name %s is already used by another notifier", field, p.Name)
+		}
+		names[p.Name] = true
+
+		switch p.Type {
+		case PluginExec:
+			if len(p.Command) == 0 || p.Command[0] == "" {
+				return fmt.Errorf("%s.command is required for exec plugins", field)
+			}
+		case PluginHTTP:
+			if p.URL == "" {
+				return fmt.Errorf("%s.url is required for http plugins", field)
+			}
+		default:
+			return fmt.Errorf("%s.type must be one of: exec, http (got %s)", field, p.Type)
+		}
+		if err := p.setDefaults(field, defaultTimeouts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRouteBatching sets the defaults of the batching settings of a route
+// from the global batching settings and checks them
+func (c *Config) validateRouteBatching(i int, b *RouteBatchingConfig) error {
+	if b.WindowSeconds == 0 {
+		b.WindowSeconds = c.Batching.WindowSeconds
+	}
+	if b.WindowSeconds == 0 {
+		b.WindowSeconds = 60
+	}
+	if b.WindowSeconds < 30 {
+		return fmt.Errorf("routes[%d].batching.windowSeconds must be at least 30 seconds (got %d)", i, b.WindowSeconds)
+	}
+	if b.Mode == "" {
+		b.Mode = c.Batching.Mode
+	}
+	if b.Mode == "" {
+		b.Mode = "smart"
+	}
+	if b.Mode != "detailed" && b.Mode != "summary" && b.Mode != "smart" {
+		return fmt.Errorf("routes[%d].batching.mode must be one of: detailed, summary, smart (got %s)", i, b.Mode)
+	}
+	// Smart routes share the smart settings of the global batching
+	if b.Mode == "smart" {
+		if c.Batching.Smart.MaxEventsPerGroup <= 0 {
+			c.Batching.Smart.MaxEventsPerGroup = 5
+		}
+		if c.Batching.Smart.MaxTotalEvents <= 0 {
+			c.Batching.Smart.MaxTotalEvents = 20
+		}
+		if len(c.Batching.Smart.AlwaysShowDetails) == 0 {
+			c.Batching.Smart.AlwaysShowDetails = []string{"DELETED"}
+		}
+	}
+	return nil
+}
+
+// validateRoutes checks that destination names are unique and that every route
+// and escalation sends to a configured destination
+func (c *Config) validateRoutes() error {
+	known := make(map[string]bool)
+	for _, name := range c.Notifier.DestinationNames() {
+		if known[name] {
+			return fmt.Errorf("duplicate notifier destination name: %s", name)
+		}
+		known[name] = true
+	}
+
+	for i, r := range c.Routes {
+		if len(r.Destinations) == 0 {
+			return fmt.Errorf("routes[%d] requires at least one destination", i)
+		}
+		for _, name := range r.Destinations {
+			if !known[name] {
+				return fmt.Errorf("routes[%d] references unknown or disabled destination: %s", i, name)
+			}
+		}
+		if r.Format != "" && !validSlackFormat(r.Format) {
+			return fmt.Errorf("routes[%d].format must be one of: attachments, blocks, text (got %s)", i, r.Format)
+		}
+		if err := r.validateSeverities(fmt.Sprintf("routes[%d]", i), c.Severity.Enabled); err != nil {
+			return err
+		}
+		if r.OwnBatching() {
+			if err := c.validateRouteBatching(i, r.Batching); err != nil {
+				return err
+			}
+		}
+	}
+
+	ruleNames := make(map[string]bool, len(c.Escalations))
+	for i, r := range c.Escalations {
+		if r.Name == "" {
+			return fmt.Errorf("escalations[%d].name is required", i)
+		}
+		if ruleNames[r.Name] {
+			return fmt.Errorf("duplicate escalation name: %s", r.Name)
+		}
+		ruleNames[r.Name] = true
+		if r.AfterMinutes <= 0 {
+			return fmt.Errorf("escalations[%d].afterMinutes must be positive", i)
+		}
+		for _, name := range r.Destinations {
+			if !known[name] {
+				return fmt.Errorf("escalations[%d] references unknown or disabled destination: %s", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNamespacePatterns checks the namespace patterns. The informers only
+// watch the configured namespaces, so excluding one would drop all its events.
+func (c *Config) validateNamespacePatterns() error {
+	include, err := CompilePatterns("includeNamespacesRegex", c.IncludeNamespacesRegex)
+	if err != nil {
+		return err
+	}
+	exclude, err := CompilePatterns("excludeNamespacesRegex", c.ExcludeNamespacesRegex)
+	if err != nil {
+		return err
+	}
+	for _, namespace := range c.WatchedNamespaces() {
+		if !NamespaceAllowed(namespace, include, exclude) {
+			return fmt.Errorf("namespace %s is excluded by includeNamespacesRegex or excludeNamespacesRegex", namespace)
+		}
+	}
+	return nil
+}
+
+// CompilePatterns compiles the regular expressions of the field
+func CompilePatterns(field string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d] is invalid: %w", field, i, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// NamespaceAllowed reports whether the namespace matches one of the include
+// patterns, if any, and none of the exclude patterns
+func NamespaceAllowed(namespace string, include, exclude []*regexp.Regexp) bool {
+	matches := func(patterns []*regexp.Regexp) bool {
+		for _, re := range patterns {
+			if re.MatchString(namespace) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(include) > 0 && !matches(include) {
+		return false
+	}
+	return !matches(exclude)
+}
+
+// validSeverity reports whether the severity is one of SeverityLevels
+func validSeverity(severity string) bool {
+	return slices.Contains(SeverityLevels, severity)
+}
+
+// validate checks the severity rules and fills in the default mapping
+func (s *SeverityConfig) validate() error {
+	if s.Default != "" && !validSeverity(s.Default) {
+		return fmt.Errorf("severity.default must be one of: critical, warning, info (got %s)", s.Default)
+	}
+	for i, r := range s.Rules {
+		if !validSeverity(r.Severity) {
+			return fmt.Errorf("severity.rules[%d].severity must be one of: critical, warning, info (got %s)", i, r.Severity)
+		}
+		if r.MatcherConfig.IsEmpty() {
+			return fmt.Errorf("severity.rules[%d] must have at least one condition", i)
+		}
+	}
+
+	mappings := []struct {
+		name     string
+		mapping  *map[string]string
+		defaults map[string]string
+		valid    []string // Empty: any value
+	}{
+		{"pagerDuty", &s.Mapping.PagerDuty, defaultSeverityMapping.PagerDuty, []string{"critical", "error", "warning", "info"}},
+		{"opsgenie", &s.Mapping.Opsgenie, defaultSeverityMapping.Opsgenie, []string{"P1", "P2", "P3", "P4", "P5"}},
+		{"alertmanager", &s.Mapping.Alertmanager, defaultSeverityMapping.Alertmanager, nil},
+	}
+	for _, m := range mappings {
+		mapping := make(map[string]string, len(SeverityLevels))
+		for level, value := range *m.mapping {
+			if !validSeverity(level) {
+				return fmt.Errorf("severity.mapping.%s has unknown severity: %s", m.name, level)
+			}
+			if value == "" {
+				return fmt.Errorf("severity.mapping.%s.%s must not be empty", m.name, level)
+			}
+			if m.valid != nil && !slices.Contains(m.valid, value) {
+				return fmt.Errorf("severity.mapping.%s.%s must be one of: %s (got %q)", m.name, level, strings.Join(m.valid, ", "), value)
+			}
+			mapping[level] = value
+		}
+		for level, value := range m.defaults {
+			if _, ok := mapping[level]; !ok {
+				mapping[level] = value
+			}
+		}
+		*m.mapping = mapping
+	}
 	return nil
 }
 
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables that override config keys.
+// The variable name is the upper-cased key path joined by underscores, with
+// camelCase keys split into words: notifier.slack.webhookUrl is
+// KW_NOTIFIER_SLACK_WEBHOOK_URL and batching.enabled is KW_BATCHING_ENABLED.
+const EnvPrefix = "KW_"
+
+// hasEnvOverrides reports whether any override variable is set
+func hasEnvOverrides() bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, EnvPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyEnv overrides config keys from environment variables. Strings are used
+// as is, string lists may be comma-separated, and all other values (numbers,
+// booleans, maps and lists of objects) are parsed as YAML, e.g.
+// KW_RESOURCES='[{kind: Pod}, {kind: Deployment}]'.
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	return applyEnvStruct(reflect.ValueOf(cfg).Elem(), strings.TrimSuffix(EnvPrefix, "_"), lookup)
+}
+
+func applyEnvStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, inline := yamlKey(field)
+		if key == "-" {
+			continue
+		}
+		fv := v.Field(i)
+
+		if inline {
+			if err := applyEnvStruct(fv, prefix, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := prefix + "_" + envName(key)
+		if value, ok := lookup(name); ok {
+			if err := setEnvValue(fv, value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnvStruct(fv, name, lookup); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setEnvValue parses value into v
+func setEnvValue(v reflect.Value, value string) error {
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "["):
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+		return nil
+	}
+
+	parsed := reflect.New(v.Type())
+	if err := yaml.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+		return err
+	}
+	v.Set(parsed.Elem())
+	return nil
+}
+
+// yamlKey returns the YAML key of a struct field and whether it is inlined
+func yamlKey(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	name, opts, _ := strings.Cut(tag, ",")
+	if strings.Contains(","+opts+",", ",inline,") {
+		return "", true
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, false
+}
+
+// envName converts a camelCase key to upper snake case (maxSizeMB -> MAX_SIZE_MB)
+func envName(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
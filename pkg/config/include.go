@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadDocument reads a YAML file and merges the files listed in its include key.
+// Include entries are paths, globs or directories (every *.yaml and *.yml file)
+// relative to the including file. Objects are merged key by key, lists are
+// concatenated in include order after the including file's items, and setting a
+// scalar key to different values in two files is an error. The files read are
+// appended to files.
+func loadDocument(path string, files *[]string, visiting map[string]bool) (map[string]interface{}, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if visiting[abs] {
+		return nil, fmt.Errorf("include cycle detected at %s", path)
+	}
+	visiting[abs] = true
+	defer delete(visiting, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	*files = append(*files, path)
+
+	// Decode each file on its own first so that unknown keys are reported
+	// with the file name and line number
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var strict Config
+	if err := dec.Decode(&strict); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	doc := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var includes []string
+	if raw, ok := doc["include"]; ok {
+		delete(doc, "include")
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: include must be a list of paths", path)
+		}
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: include must be a list of paths", path)
+			}
+			includes = append(includes, s)
+		}
+	}
+
+	for _, pattern := range includes {
+		matches, err := resolveInclude(filepath.Dir(path), pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, match := range matches {
+			included, err := loadDocument(match, files, visiting)
+			if err != nil {
+				return nil, err
+			}
+			merged, err := mergeYAML(doc, included, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge %s into %s: %w", match, path, err)
+			}
+			doc = merged.(map[string]interface{})
+		}
+	}
+
+	return doc, nil
+}
+
+// resolveInclude expands an include entry to the files it refers to
+func resolveInclude(dir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		var files []string
+		for _, ext := range []string{"*.yaml", "*.yml"} {
+			matches, _ := filepath.Glob(filepath.Join(pattern, ext))
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern %s: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("include %s matched no files", pattern)
+	}
+	return matches, nil
+}
+
+// mergeYAML merges src into dst as described in loadDocument
+func mergeYAML(dst, src interface{}, key string) (interface{}, error) {
+	if dst == nil {
+		return src, nil
+	}
+	if src == nil {
+		return dst, nil
+	}
+
+	switch d := dst.(type) {
+	case map[string]interface{}:
+		s, ok := src.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: cannot merge an object with a non-object", keyName(key))
+		}
+		for k, v := range s {
+			merged, err := mergeYAML(d[k], v, joinKey(key, k))
+			if err != nil {
+				return nil, err
+			}
+			d[k] = merged
+		}
+		return d, nil
+	case []interface{}:
+		s, ok := src.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: cannot merge a list with a non-list", keyName(key))
+		}
+		return append(d, s...), nil
+	default:
+		if !reflect.DeepEqual(dst, src) {
+			return nil, fmt.Errorf("%s is set to different values (%v and %v)", keyName(key), dst, src)
+		}
+		return dst, nil
+	}
+}
+
+func joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func keyName(key string) string {
+	if key == "" {
+		return "document"
+	}
+	return key
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces secrets in a redacted configuration
+const RedactedValue = "<redacted>"
+
+// Redacted returns a copy of the configuration with secrets replaced by
+// RedactedValue. Secrets are the fields tagged with secret:"true"; for maps,
+// every value is replaced. For URLs tagged with secret:"userinfo", only the
+// credentials before the host are replaced. Unset secrets are left empty so
+// that the output still shows which credentials are configured.
+func (c *Config) Redacted() (*Config, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	var copied Config
+	if err := yaml.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	copied.Files = append([]string(nil), c.Files...)
+
+	redactValue(reflect.ValueOf(&copied).Elem())
+	return &copied, nil
+}
+
+func redactValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fv := v.Field(i)
+			switch field.Tag.Get("secret") {
+			case "true":
+				redactSecret(fv)
+				continue
+			case "userinfo":
+				if fv.Kind() == reflect.String {
+					fv.SetString(redactUserinfo(fv.String()))
+				}
+				continue
+			}
+			redactValue(fv)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redactValue(v.Index(i))
+		}
+	}
+}
+
+// redactSecret replaces a non-empty string or the non-empty values of a string map
+func redactSecret(v reflect.Value) {
+	switch {
+	case v.Kind() == reflect.String && v.String() != "":
+		v.SetString(RedactedValue)
+	case v.Kind() == reflect.Map && v.Type().Elem().Kind() == reflect.String:
+		for _, key := range v.MapKeys() {
+			if v.MapIndex(key).String() != "" {
+				v.SetMapIndex(key, reflect.ValueOf(RedactedValue).Convert(v.Type().Elem()))
+			}
+		}
+	}
+}
+
+// redactUserinfo replaces the userinfo (user:password@ or token@) of the URLs
+// in a comma-separated list, as accepted for NATS servers
+func redactUserinfo(urls string) string {
+	parts := strings.Split(urls, ",")
+	for i, u := range parts {
+		scheme := strings.Index(u, "://")
+		if scheme < 0 {
+			continue
+		}
+		authority := u[scheme+3:]
+		if end := strings.IndexAny(authority, "/?#"); end >= 0 {
+			authority = authority[:end]
+		}
+		if at := strings.LastIndex(authority, "@"); at >= 0 {
+			parts[i] = u[:scheme+3] + RedactedValue + u[scheme+3+at:]
+		}
+	}
+	return strings.Join(parts, ",")
+}
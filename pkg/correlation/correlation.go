@@ -0,0 +1,210 @@
+// Package correlation links the related events of a rollout (Deployment
+// update, ReplicaSet creation, Pod restarts) through owner references and
+// timing, so that they are sent as one story instead of separate messages.
+package correlation
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// rootKinds are the kinds of the workloads a story is about
+var rootKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+}
+
+// Story is a group of related events about a workload and the resources it controls
+type Story struct {
+	Kind      string // The workload the story is about
+	Namespace string
+	Name      string
+	Events    []*watcher.Event // In arrival order
+	StartTime time.Time
+	EndTime   time.Time // Arrival of the last event
+}
+
+// Subject returns the workload the story is about, e.g. "Deployment default/api"
+func (s *Story) Subject() string {
+	return s.Kind + " " + s.Namespace + "/" + s.Name
+}
+
+// pending is a story that is still collecting events
+type pending struct {
+	story *Story
+	keys  []string // Resources linked to the story
+}
+
+// Correlator holds back the events of a workload and the resources it
+// controls, and hands them over as one story once no related event arrived
+// for the window, or at the latest after the maximum window.
+type Correlator struct {
+	window    time.Duration
+	maxWindow time.Duration
+	onStory   func(*Story)
+	now       func() time.Time
+
+	mu      sync.Mutex
+	stories []*pending
+	index   map[string]*pending // Resource key -> story it is linked to
+	stopC   chan struct{}
+	done    chan struct{}
+}
+
+// NewCorrelator creates a Correlator and starts handing over completed stories
+func NewCorrelator(window, maxWindow time.Duration, onStory func(*Story)) *Correlator {
+	c := &Correlator{
+		window:    window,
+		maxWindow: maxWindow,
+		onStory:   onStory,
+		now:       time.Now,
+		index:     make(map[string]*pending),
+		stopC:     make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go c.loop()
+	return c
+}
+
+// resourceKey identifies a resource within the correlator
+func resourceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// Add links the event to a story and reports whether it was held back. Events
+// of a workload start a story unless they are deletions; events of resources
+// controlled by a workload with a story are added to it. Other events are not
+// held back.
+func (c *Correlator) Add(event *watcher.Event) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.find(event)
+	if p == nil {
+		kind, name, ok := workloadOf(event)
+		if !ok || event.EventType == "DELETED" {
+			return false
+		}
+		p = &pending{story: &Story{Kind: kind, Namespace: event.Namespace, Name: name, StartTime: c.now()}}
+		c.stories = append(c.stories, p)
+		c.link(p, resourceKey(kind, event.Namespace, name))
+	}
+	p.story.Events = append(p.story.Events, event)
+	p.story.EndTime = c.now()
+	c.link(p, resourceKey(event.Kind, event.Namespace, event.Name))
+	return true
+}
+
+// find returns the story the event's resource or its controller is linked to.
+// Must be called with mu held.
+func (c *Correlator) find(event *watcher.Event) *pending {
+	if p := c.index[resourceKey(event.Kind, event.Namespace, event.Name)]; p != nil {
+		return p
+	}
+	if event.Owner == nil {
+		return nil
+	}
+	if p := c.index[resourceKey(event.Owner.Kind, event.Namespace, event.Owner.Name)]; p != nil {
+		return p
+	}
+	// Pods of a ReplicaSet that was not reported belong to the Deployment it is named after
+	if event.Owner.Kind == "ReplicaSet" {
+		if name, ok := deploymentOf(event.Owner.Name); ok {
+			return c.index[resourceKey("Deployment", event.Namespace, name)]
+		}
+	}
+	return nil
+}
+
+// link records that the resource belongs to the story. Must be called with mu held.
+func (c *Correlator) link(p *pending, key string) {
+	if c.index[key] == p {
+		return
+	}
+	c.index[key] = p
+	p.keys = append(p.keys, key)
+}
+
+// workloadOf returns the workload a story about the event is rooted at: the
+// resource itself for workloads, the Deployment for its ReplicaSets
+func workloadOf(event *watcher.Event) (kind, name string, ok bool) {
+	if rootKinds[event.Kind] {
+		return event.Kind, event.Name, true
+	}
+	if event.Kind == "ReplicaSet" && event.Owner != nil && event.Owner.Kind == "Deployment" {
+		return event.Owner.Kind, event.Owner.Name, true
+	}
+	return "", "", false
+}
+
+// deploymentOf returns the name of the Deployment a ReplicaSet was created
+// for, which is the ReplicaSet's name without the pod template hash
+func deploymentOf(replicaSet string) (string, bool) {
+	i := strings.LastIndex(replicaSet, "-")
+	if i <= 0 {
+		return "", false
+	}
+	return replicaSet[:i], true
+}
+
+// Pending returns the number of stories still collecting events
+func (c *Correlator) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.stories)
+}
+
+// Stop stops the correlator and hands over the stories still collecting events
+func (c *Correlator) Stop() {
+	close(c.stopC)
+	<-c.done
+	c.flush(true)
+}
+
+func (c *Correlator) loop() {
+	defer close(c.done)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopC:
+			return
+		case <-ticker.C:
+			c.flush(false)
+		}
+	}
+}
+
+// flush hands over the completed stories, or all stories when all is set
+func (c *Correlator) flush(all bool) {
+	c.mu.Lock()
+	now := c.now()
+	var completed []*Story
+	kept := c.stories[:0]
+	for _, p := range c.stories {
+		s := p.story
+		if !all && now.Sub(s.EndTime) < c.window && now.Sub(s.StartTime) < c.maxWindow {
+			kept = append(kept, p)
+			continue
+		}
+		for _, key := range p.keys {
+			delete(c.index, key)
+		}
+		completed = append(completed, s)
+	}
+	c.stories = kept
+	c.mu.Unlock()
+
+	for _, s := range completed {
+		slog.Debug("Story completed", "kind", s.Kind, "namespace", s.Namespace, "name", s.Name, "events", len(s.Events))
+		if c.onStory != nil {
+			c.onStory(s)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package correlation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// newTestCorrelator creates a Correlator driven by now, without the background loop
+func newTestCorrelator(now *time.Time) (*Correlator, *[]*Story) {
+	var stories []*Story
+	c := &Correlator{
+		window:    30 * time.Second,
+		maxWindow: 5 * time.Minute,
+		onStory:   func(s *Story) { stories = append(stories, s) },
+		now:       func() time.Time { return *now },
+		index:     make(map[string]*pending),
+	}
+	return c, &stories
+}
+
+func event(kind, name, eventType string, owner *watcher.OwnerRef) *watcher.Event {
+	return &watcher.Event{Kind: kind, Namespace: "default", Name: name, EventType: eventType, Owner: owner}
+}
+
+func TestCorrelator_Rollout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, stories := newTestCorrelator(&now)
+
+	deployment := &watcher.OwnerRef{Kind: "Deployment", Name: "api"}
+	replicaSet := &watcher.OwnerRef{Kind: "ReplicaSet", Name: "api-7d9f"}
+	related := []*watcher.Event{
+		event("Deployment", "api", "UPDATED", nil),
+		event("ReplicaSet", "api-7d9f", "ADDED", deployment),
+		event("Pod", "api-7d9f-a", "ADDED", replicaSet),
+		event("Pod", "api-7d9f-b", "ADDED", replicaSet),
+		event("Pod", "api-7d9f-a", "UPDATED", replicaSet),
+	}
+	for _, e := range related {
+		if !c.Add(e) {
+			t.Fatalf("Expected %s/%s to be added to the story", e.Kind, e.Name)
+		}
+		now = now.Add(10 * time.Second)
+	}
+
+	// 関係のないイベントは保留されない
+	if c.Add(event("Pod", "web-5c6b-a", "UPDATED", &watcher.OwnerRef{Kind: "ReplicaSet", Name: "web-5c6b"})) {
+		t.Error("Expected an unrelated Pod not to be held back")
+	}
+	if c.Add(event("Service", "api", "UPDATED", nil)) {
+		t.Error("Expected a Service not to be held back")
+	}
+
+	now = now.Add(10 * time.Second)
+	c.flush(false)
+	if len(*stories) != 0 {
+		t.Fatal("Expected the story to wait for the window")
+	}
+
+	now = now.Add(20 * time.Second)
+	c.flush(false)
+	if len(*stories) != 1 {
+		t.Fatalf("Expected 1 story, got %d", len(*stories))
+	}
+	s := (*stories)[0]
+	if s.Subject() != "Deployment default/api" || len(s.Events) != len(related) {
+		t.Errorf("Unexpected story %s with %d events", s.Subject(), len(s.Events))
+	}
+	if c.Pending() != 0 || len(c.index) != 0 {
+		t.Errorf("Expected the story to be forgotten, got %d pending and %d linked resources", c.Pending(), len(c.index))
+	}
+}
+
+func TestCorrelator_PodWithoutReplicaSetEvent(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, _ := newTestCorrelator(&now)
+
+	c.Add(event("Deployment", "api", "UPDATED", nil))
+	// ReplicaSetのイベントがなくても名前からDeploymentに紐づける
+	if !c.Add(event("Pod", "api-7d9f-a", "ADDED", &watcher.OwnerRef{Kind: "ReplicaSet", Name: "api-7d9f"})) {
+		t.Error("Expected the Pod to be linked to the Deployment")
+	}
+}
+
+func TestCorrelator_ReplicaSetFirst(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, stories := newTestCorrelator(&now)
+
+	// ReplicaSetのイベントが先に届いてもDeploymentのストーリーになる
+	c.Add(event("ReplicaSet", "api-7d9f", "ADDED", &watcher.OwnerRef{Kind: "Deployment", Name: "api"}))
+	if !c.Add(event("Deployment", "api", "UPDATED", nil)) {
+		t.Fatal("Expected the Deployment to join the story")
+	}
+	c.flush(true)
+	if len(*stories) != 1 || len((*stories)[0].Events) != 2 || (*stories)[0].Subject() != "Deployment default/api" {
+		t.Errorf("Unexpected stories %+v", *stories)
+	}
+}
+
+func TestCorrelator_MaxWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, stories := newTestCorrelator(&now)
+
+	c.Add(event("StatefulSet", "db", "UPDATED", nil))
+	owner := &watcher.OwnerRef{Kind: "StatefulSet", Name: "db"}
+	for i := 0; i < 30; i++ {
+		now = now.Add(10 * time.Second)
+		c.Add(event("Pod", "db-0", "UPDATED", owner))
+	}
+	c.flush(false)
+	if len(*stories) != 1 {
+		t.Errorf("Expected the story to be sent after the maximum window, got %d stories", len(*stories))
+	}
+}
+
+func TestCorrelator_Deleted(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, _ := newTestCorrelator(&now)
+
+	if c.Add(event("Deployment", "api", "DELETED", nil)) {
+		t.Error("Expected a deletion not to start a story")
+	}
+}
@@ -2,10 +2,12 @@
 package dedup
 
 import (
+	"container/list"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -29,21 +31,54 @@ type CacheEntry struct {
 	Timestamp time.Time
 }
 
-// Deduplicator provides event deduplication functionality
+// Metrics represents cumulative cache counters and the current cache size
+type Metrics struct {
+	Hits        uint64 // Events suppressed as duplicates
+	Misses      uint64 // Events passed through (new or changed)
+	Evictions   uint64 // Entries evicted because the cache was full
+	Expirations uint64 // Entries removed because their TTL elapsed
+	Size        int
+	MaxSize     int
+}
+
+// lruEntry is the value stored in each element of the LRU list
+type lruEntry struct {
+	key    string
+	ttl    time.Duration
+	expiry *list.Element // Element of the expiry list of ttl, holding the LRU list element
+	CacheEntry
+}
+
+// Deduplicator provides event deduplication functionality.
+// Entries are kept in a doubly-linked list ordered by when they were last written,
+// so eviction works from the back of the list in O(1) per entry. Each TTL has its
+// own list in the same order, which is also expiry order, so TTL cleanup works
+// from the back of these lists in O(1) per expired entry.
 type Deduplicator struct {
-	cache    map[string]CacheEntry
+	cache    map[string]*list.Element
+	order    *list.List                   // Front: most recently written, Back: least recently written
+	expiries map[time.Duration]*list.List // Entries by TTL, in the same order
 	mu       sync.RWMutex
 	ttl      time.Duration
+	kindTTLs map[string]time.Duration // Per-kind TTL overrides
 	maxSize  int
 	cleanupC chan struct{}
 	stopC    chan struct{}
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
 }
 
 // NewDeduplicator creates a new Deduplicator with specified TTL and max cache size
 func NewDeduplicator(ttl time.Duration, maxSize int) *Deduplicator {
 	d := &Deduplicator{
-		cache:    make(map[string]CacheEntry),
+		cache:    make(map[string]*list.Element),
+		order:    list.New(),
+		expiries: make(map[time.Duration]*list.List),
 		ttl:      ttl,
+		kindTTLs: make(map[string]time.Duration),
 		maxSize:  maxSize,
 		cleanupC: make(chan struct{}, 1),
 		stopC:    make(chan struct{}),
@@ -55,36 +90,67 @@ func NewDeduplicator(ttl time.Duration, maxSize int) *Deduplicator {
 	return d
 }
 
+// SetKindTTL overrides the TTL used for events of the given resource kind
+func (d *Deduplicator) SetKindTTL(kind string, ttl time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.kindTTLs[kind] = ttl
+}
+
+// ttlFor returns the TTL for the given resource kind. Must be called with d.mu held.
+func (d *Deduplicator) ttlFor(kind string) time.Duration {
+	if ttl, ok := d.kindTTLs[kind]; ok {
+		return ttl
+	}
+	return d.ttl
+}
+
 // ShouldProcess checks if an event should be processed (not a duplicate)
 func (d *Deduplicator) ShouldProcess(key EventKey, data interface{}) bool {
 	signature := d.generateSignature(data)
 	cacheKey := d.makeCacheKey(key)
 
-	d.mu.RLock()
-	entry, exists := d.cache[cacheKey]
-	d.mu.RUnlock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ttl := d.ttlFor(key.Kind)
 
+	elem, exists := d.cache[cacheKey]
 	if exists {
+		entry := elem.Value.(*lruEntry)
 		// Check if signature matches and entry is still valid
-		if entry.Signature == signature && time.Since(entry.Timestamp) < d.ttl {
+		if entry.Signature == signature && time.Since(entry.Timestamp) < entry.ttl {
 			// Duplicate event within TTL
+			d.hits.Add(1)
 			return false
 		}
+
+		// New signature or expired entry, refresh in place
+		d.misses.Add(1)
+		entry.Signature = signature
+		entry.Timestamp = time.Now()
+		d.order.MoveToFront(elem)
+		d.setExpiry(elem, ttl)
+		return true
 	}
 
-	// New event or expired cache entry, update cache
-	d.mu.Lock()
-	defer d.mu.Unlock()
+	// New event, add to cache
+	d.misses.Add(1)
 
-	// Check cache size and evict oldest entry if necessary
+	// Evict the least recently written entry if the cache is full
 	if len(d.cache) >= d.maxSize {
 		d.evictOldest()
 	}
 
-	d.cache[cacheKey] = CacheEntry{
-		Signature: signature,
-		Timestamp: time.Now(),
-	}
+	elem = d.order.PushFront(&lruEntry{
+		key: cacheKey,
+		CacheEntry: CacheEntry{
+			Signature: signature,
+			Timestamp: time.Now(),
+		},
+	})
+	d.cache[cacheKey] = elem
+	d.setExpiry(elem, ttl)
 
 	// Trigger async cleanup
 	select {
@@ -112,23 +178,55 @@ func (d *Deduplicator) makeCacheKey(key EventKey) string {
 	return fmt.Sprintf("%s/%s/%s/%s", key.Kind, key.Namespace, key.Name, key.EventType)
 }
 
-// evictOldest removes the oldest entry from the cache
+// evictOldest removes the least recently written entry from the cache
 func (d *Deduplicator) evictOldest() {
-	var oldestKey string
-	var oldestTime time.Time
-	first := true
-
-	for k, v := range d.cache {
-		if first || v.Timestamp.Before(oldestTime) {
-			oldestKey = k
-			oldestTime = v.Timestamp
-			first = false
-		}
+	elem := d.order.Back()
+	if elem == nil {
+		return
+	}
+
+	d.removeElement(elem)
+	d.evictions.Add(1)
+}
+
+// setExpiry moves a just written entry to the front of the expiry list of ttl.
+// Must be called with d.mu held.
+func (d *Deduplicator) setExpiry(elem *list.Element, ttl time.Duration) {
+	entry := elem.Value.(*lruEntry)
+	if entry.expiry != nil && entry.ttl == ttl {
+		d.expiries[ttl].MoveToFront(entry.expiry)
+		return
 	}
 
-	if oldestKey != "" {
-		delete(d.cache, oldestKey)
+	d.removeExpiry(entry)
+	expiries := d.expiries[ttl]
+	if expiries == nil {
+		expiries = list.New()
+		d.expiries[ttl] = expiries
 	}
+	entry.ttl = ttl
+	entry.expiry = expiries.PushFront(elem)
+}
+
+// removeExpiry removes an entry from its expiry list, dropping lists that become empty
+func (d *Deduplicator) removeExpiry(entry *lruEntry) {
+	if entry.expiry == nil {
+		return
+	}
+	expiries := d.expiries[entry.ttl]
+	expiries.Remove(entry.expiry)
+	if expiries.Len() == 0 {
+		delete(d.expiries, entry.ttl)
+	}
+	entry.expiry = nil
+}
+
+// removeElement removes an element from the lists and the map
+func (d *Deduplicator) removeElement(elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	d.order.Remove(elem)
+	d.removeExpiry(entry)
+	delete(d.cache, entry.key)
 }
 
 // cleanupLoop periodically removes expired entries from cache
@@ -154,10 +252,18 @@ func (d *Deduplicator) cleanup() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	// Each expiry list is in expiry order, so it is checked up to its first unexpired entry
 	now := time.Now()
-	for k, v := range d.cache {
-		if now.Sub(v.Timestamp) >= d.ttl {
-			delete(d.cache, k)
+	for ttl, expiries := range d.expiries {
+		for e := expiries.Back(); e != nil; {
+			prev := e.Prev()
+			elem := e.Value.(*list.Element)
+			if now.Sub(elem.Value.(*lruEntry).Timestamp) < ttl {
+				break
+			}
+			d.removeElement(elem)
+			d.expirations.Add(1)
+			e = prev
 		}
 	}
 }
@@ -178,3 +284,19 @@ func (d *Deduplicator) Stats() map[string]interface{} {
 		"ttl":      d.ttl.String(),
 	}
 }
+
+// Metrics returns cumulative hit/miss/eviction counters and the current cache size
+func (d *Deduplicator) Metrics() Metrics {
+	d.mu.RLock()
+	size := len(d.cache)
+	d.mu.RUnlock()
+
+	return Metrics{
+		Hits:        d.hits.Load(),
+		Misses:      d.misses.Load(),
+		Evictions:   d.evictions.Load(),
+		Expirations: d.expirations.Load(),
+		Size:        size,
+		MaxSize:     d.maxSize,
+	}
+}
@@ -1,6 +1,7 @@
 package dedup
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -276,3 +277,168 @@ func BenchmarkDeduplicator_ShouldProcess(b *testing.B) {
 		d.ShouldProcess(key, data)
 	}
 }
+
+func TestDeduplicator_Metrics(t *testing.T) {
+	d := NewDeduplicator(time.Minute, 2)
+	defer d.Stop()
+
+	data := map[string]string{"status": "Running"}
+	keyA := EventKey{Kind: "Pod", Namespace: "default", Name: "a", EventType: "UPDATED"}
+	keyB := EventKey{Kind: "Pod", Namespace: "default", Name: "b", EventType: "UPDATED"}
+	keyC := EventKey{Kind: "Pod", Namespace: "default", Name: "c", EventType: "UPDATED"}
+
+	d.ShouldProcess(keyA, data) // miss
+	d.ShouldProcess(keyA, data) // hit
+	d.ShouldProcess(keyB, data) // miss
+	d.ShouldProcess(keyC, data) // miss, evicts oldest
+
+	m := d.Metrics()
+	if m.Hits != 1 {
+		t.Errorf("Expected 1 hit, got %d", m.Hits)
+	}
+	if m.Misses != 3 {
+		t.Errorf("Expected 3 misses, got %d", m.Misses)
+	}
+	if m.Evictions != 1 {
+		t.Errorf("Expected 1 eviction, got %d", m.Evictions)
+	}
+	if m.Size != 2 {
+		t.Errorf("Expected size 2, got %d", m.Size)
+	}
+	if m.MaxSize != 2 {
+		t.Errorf("Expected max size 2, got %d", m.MaxSize)
+	}
+}
+
+func TestDeduplicator_Metrics_Expirations(t *testing.T) {
+	ttl := 50 * time.Millisecond
+	d := NewDeduplicator(ttl, 100)
+	defer d.Stop()
+
+	key := EventKey{Kind: "Pod", Namespace: "default", Name: "a", EventType: "UPDATED"}
+	d.ShouldProcess(key, map[string]string{"status": "Running"})
+
+	time.Sleep(ttl + 50*time.Millisecond)
+	d.cleanup()
+
+	if m := d.Metrics(); m.Expirations != 1 {
+		t.Errorf("Expected 1 expiration, got %d", m.Expirations)
+	}
+}
+
+func TestDeduplicator_EvictsLeastRecentlyWritten(t *testing.T) {
+	d := NewDeduplicator(time.Minute, 2)
+	defer d.Stop()
+
+	keyA := EventKey{Kind: "Pod", Namespace: "default", Name: "a", EventType: "UPDATED"}
+	keyB := EventKey{Kind: "Pod", Namespace: "default", Name: "b", EventType: "UPDATED"}
+	keyC := EventKey{Kind: "Pod", Namespace: "default", Name: "c", EventType: "UPDATED"}
+
+	d.ShouldProcess(keyA, map[string]string{"v": "1"})
+	d.ShouldProcess(keyB, map[string]string{"v": "1"})
+	// Rewriting A with new data makes B the least recently written entry
+	d.ShouldProcess(keyA, map[string]string{"v": "2"})
+	d.ShouldProcess(keyC, map[string]string{"v": "1"})
+
+	// A should still be cached, B should have been evicted
+	if d.ShouldProcess(keyA, map[string]string{"v": "2"}) {
+		t.Error("Entry A should still be cached")
+	}
+	if !d.ShouldProcess(keyB, map[string]string{"v": "1"}) {
+		t.Error("Entry B should have been evicted")
+	}
+}
+
+func BenchmarkDeduplicator_ShouldProcess_FullCache(b *testing.B) {
+	d := NewDeduplicator(time.Minute, 1000)
+	defer d.Stop()
+
+	data := map[string]string{"status": "Running"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := EventKey{
+			Kind:      "Pod",
+			Namespace: "default",
+			Name:      fmt.Sprintf("test-pod-%d", i),
+			EventType: "UPDATED",
+		}
+		d.ShouldProcess(key, data)
+	}
+}
+
+func TestDeduplicator_SetKindTTL(t *testing.T) {
+	d := NewDeduplicator(time.Minute, 100)
+	defer d.Stop()
+
+	d.SetKindTTL("Pod", 50*time.Millisecond)
+
+	podKey := EventKey{Kind: "Pod", Namespace: "default", Name: "a", EventType: "UPDATED"}
+	secretKey := EventKey{Kind: "Secret", Namespace: "default", Name: "a", EventType: "UPDATED"}
+	data := map[string]string{"status": "Running"}
+
+	d.ShouldProcess(podKey, data)
+	d.ShouldProcess(secretKey, data)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// Pod uses the shorter override TTL and should be processed again
+	if !d.ShouldProcess(podKey, data) {
+		t.Error("Pod event should be processed after its override TTL expires")
+	}
+
+	// Secret uses the default TTL and should still be deduplicated
+	if d.ShouldProcess(secretKey, data) {
+		t.Error("Secret event should still be deduplicated with the default TTL")
+	}
+}
+
+func TestDeduplicator_Cleanup_KindTTL(t *testing.T) {
+	d := NewDeduplicator(time.Minute, 100)
+	defer d.Stop()
+
+	d.SetKindTTL("Pod", 50*time.Millisecond)
+
+	data := map[string]string{"status": "Running"}
+	// Written first, so it sits at the back of the list with the long default TTL
+	d.ShouldProcess(EventKey{Kind: "Secret", Namespace: "default", Name: "a", EventType: "UPDATED"}, data)
+	d.ShouldProcess(EventKey{Kind: "Pod", Namespace: "default", Name: "a", EventType: "UPDATED"}, data)
+
+	time.Sleep(100 * time.Millisecond)
+	d.cleanup()
+
+	if size := d.Stats()["size"].(int); size != 1 {
+		t.Errorf("Expected only the Secret entry to remain, got size %d", size)
+	}
+}
+
+func TestDeduplicator_ExpiryLists(t *testing.T) {
+	d := NewDeduplicator(time.Minute, 2)
+	defer d.Stop()
+
+	data := map[string]string{"status": "Running"}
+	pod := EventKey{Kind: "Pod", Namespace: "default", Name: "a", EventType: "UPDATED"}
+	d.ShouldProcess(pod, data)
+
+	// TTLを変更した後の書き込みで、エントリは新しいTTLのリストに移る
+	d.SetKindTTL("Pod", 50*time.Millisecond)
+	d.ShouldProcess(pod, map[string]string{"status": "Failed"})
+	d.ShouldProcess(EventKey{Kind: "Secret", Namespace: "default", Name: "a", EventType: "UPDATED"}, data)
+
+	d.mu.RLock()
+	if len(d.expiries) != 2 || d.expiries[time.Minute].Len() != 1 || d.expiries[50*time.Millisecond].Len() != 1 {
+		t.Errorf("Expected one entry per TTL list, got %d lists", len(d.expiries))
+	}
+	d.mu.RUnlock()
+
+	// 追い出されたエントリは期限リストからも削除される
+	d.ShouldProcess(EventKey{Kind: "Secret", Namespace: "default", Name: "b", EventType: "UPDATED"}, data)
+	time.Sleep(100 * time.Millisecond)
+	d.cleanup()
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.cache) != 2 || len(d.expiries) != 1 || d.expiries[time.Minute].Len() != 2 {
+		t.Errorf("Expected the two Secret entries in the default TTL list, got %d entries in %d lists", len(d.cache), len(d.expiries))
+	}
+}
@@ -0,0 +1,275 @@
+// Package escalation re-sends notified events that are neither acknowledged
+// nor resolved in time, e.g. to a paging destination.
+package escalation
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/filter"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Rule is a compiled config.EscalationRule
+type Rule struct {
+	Name         string
+	After        time.Duration
+	Destinations []string // Empty: the destinations of the original notification
+	matcher      *filter.EventMatcher
+}
+
+// NewRules compiles the escalation rules
+func NewRules(cfgs []config.EscalationRule) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(cfgs))
+	for _, c := range cfgs {
+		matcher, err := filter.NewEventMatcher(c.MatcherConfig)
+		if err != nil {
+			return nil, fmt.Errorf("escalation %s: %w", c.Name, err)
+		}
+		rules = append(rules, &Rule{
+			Name:         c.Name,
+			After:        time.Duration(c.AfterMinutes) * time.Minute,
+			Destinations: c.Destinations,
+			matcher:      matcher,
+		})
+	}
+	return rules, nil
+}
+
+// matches reports whether the event matches the rule; a rule without conditions matches all events
+func (r *Rule) matches(event *watcher.Event) bool {
+	return r.matcher.IsEmpty() || r.matcher.Matches(event)
+}
+
+// Escalation is an event due to be re-sent
+type Escalation struct {
+	Rule  *Rule
+	Event *watcher.Event // The notified event
+	Due   time.Time
+
+	notified []string // IDs of the events notified about the resource, each with its own Acknowledge button
+}
+
+// EscalateFunc re-sends the event of an escalation
+type EscalateFunc func(e *Escalation)
+
+// AckedFunc reports whether the notified event with the ID was acknowledged
+type AckedFunc func(id string) bool
+
+// resourceKey identifies the resource events are about
+type resourceKey struct {
+	cluster, kind, namespace, name string
+}
+
+func keyOf(e *watcher.Event) resourceKey {
+	return resourceKey{e.Cluster, e.Kind, e.Namespace, e.Name}
+}
+
+// Manager schedules the escalations of notified events. Pending escalations
+// are kept in memory and are lost on restart. A nil Manager is valid and
+// escalates nothing.
+type Manager struct {
+	onEscalate EscalateFunc
+	acked      AckedFunc
+	now        func() time.Time
+
+	mu      sync.Mutex
+	rules   []*Rule
+	pending map[resourceKey]map[string]*Escalation // Resource -> rule name -> escalation
+	stopC   chan struct{}
+	done    chan struct{}
+}
+
+// NewManager creates a Manager and starts escalating due events. acked may be
+// nil when acknowledgements are not tracked.
+func NewManager(onEscalate EscalateFunc, acked AckedFunc) *Manager {
+	m := &Manager{
+		onEscalate: onEscalate,
+		acked:      acked,
+		now:        time.Now,
+		pending:    make(map[resourceKey]map[string]*Escalation),
+		stopC:      make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go m.loop()
+	return m
+}
+
+// SetRules replaces the rules. Pending escalations of rules that were removed are cancelled.
+func (m *Manager) SetRules(rules []*Rule) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rules = rules
+	for key, byRule := range m.pending {
+		for name, e := range byRule {
+			if r := m.rule(name); r != nil {
+				e.Rule = r
+				continue
+			}
+			delete(byRule, name)
+		}
+		if len(byRule) == 0 {
+			delete(m.pending, key)
+		}
+	}
+}
+
+// rule returns the rule with the given name. Must be called with mu held.
+func (m *Manager) rule(name string) *Rule {
+	for _, r := range m.rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Matches reports whether any rule applies to the event
+func (m *Manager) Matches(event *watcher.Event) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.rules {
+		if r.matches(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve cancels the pending escalations of the event's resource whose rules
+// the event no longer matches. It is called for every event that passes the filters.
+func (m *Manager) Resolve(event *watcher.Event) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := keyOf(event)
+	byRule := m.pending[key]
+	for name, e := range byRule {
+		if e.Rule.matches(event) {
+			continue
+		}
+		slog.Info("Escalation resolved", event.LogAttrs("rule", name)...)
+		delete(byRule, name)
+	}
+	if len(byRule) == 0 {
+		delete(m.pending, key)
+	}
+}
+
+// Track schedules the escalations of a notified event. A resource with a
+// pending escalation of a rule keeps its original deadline, and acknowledging
+// any of the events notified about it since stops the escalation.
+func (m *Manager) Track(event *watcher.Event) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := keyOf(event)
+	for _, r := range m.rules {
+		if !r.matches(event) {
+			continue
+		}
+		if e := m.pending[key][r.Name]; e != nil {
+			e.notified = append(e.notified, event.ID)
+			continue
+		}
+		if m.pending[key] == nil {
+			m.pending[key] = make(map[string]*Escalation)
+		}
+		m.pending[key][r.Name] = &Escalation{Rule: r, Event: event, Due: m.now().Add(r.After), notified: []string{event.ID}}
+	}
+}
+
+// Pending returns the number of pending escalations
+func (m *Manager) Pending() int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, byRule := range m.pending {
+		n += len(byRule)
+	}
+	return n
+}
+
+// Stop stops the manager. Pending escalations are dropped.
+func (m *Manager) Stop() {
+	if m == nil {
+		return
+	}
+	close(m.stopC)
+	<-m.done
+}
+
+func (m *Manager) loop() {
+	defer close(m.done)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopC:
+			return
+		case <-ticker.C:
+			m.escalateDue()
+		}
+	}
+}
+
+// escalateDue re-sends the events whose escalation is due and that were not acknowledged
+func (m *Manager) escalateDue() {
+	m.mu.Lock()
+	now := m.now()
+	var due []*Escalation
+	for key, byRule := range m.pending {
+		for name, e := range byRule {
+			if now.Before(e.Due) {
+				continue
+			}
+			due = append(due, e)
+			delete(byRule, name)
+		}
+		if len(byRule) == 0 {
+			delete(m.pending, key)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, e := range due {
+		if m.isAcked(e) {
+			slog.Debug("Escalation skipped, event acknowledged", e.Event.LogAttrs("rule", e.Rule.Name)...)
+			continue
+		}
+		slog.Info("Escalating event", e.Event.LogAttrs("rule", e.Rule.Name, "after", e.Rule.After)...)
+		if m.onEscalate != nil {
+			m.onEscalate(e)
+		}
+	}
+}
+
+// isAcked reports whether any event notified about the resource of the escalation was acknowledged
+func (m *Manager) isAcked(e *Escalation) bool {
+	if m.acked == nil {
+		return false
+	}
+	for _, id := range e.notified {
+		if m.acked(id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package escalation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// newTestManager creates a Manager driven by now, without the background loop
+func newTestManager(t *testing.T, now *time.Time, acked AckedFunc, cfgs ...config.EscalationRule) (*Manager, *[]*Escalation) {
+	t.Helper()
+	rules, err := NewRules(cfgs)
+	if err != nil {
+		t.Fatalf("NewRules() error = %v", err)
+	}
+	var escalated []*Escalation
+	m := &Manager{
+		onEscalate: func(e *Escalation) { escalated = append(escalated, e) },
+		acked:      acked,
+		now:        func() time.Time { return *now },
+		pending:    make(map[resourceKey]map[string]*Escalation),
+	}
+	m.SetRules(rules)
+	return m, &escalated
+}
+
+var failedRollout = config.EscalationRule{
+	Name:          "failed-rollout",
+	MatcherConfig: config.MatcherConfig{Kinds: []string{"Deployment"}, Expression: `event.status == "ProgressDeadlineExceeded"`},
+	AfterMinutes:  15,
+	Destinations:  []string{"pagerduty"},
+}
+
+func deploymentEvent(id, status string) *watcher.Event {
+	return &watcher.Event{ID: id, Kind: "Deployment", Namespace: "default", Name: "api", EventType: "UPDATED", Status: status}
+}
+
+func TestManager_Escalate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, escalated := newTestManager(t, &now, nil, failedRollout)
+
+	m.Track(deploymentEvent("1", "ProgressDeadlineExceeded"))
+	// 再通知しても最初の期限が維持される
+	now = now.Add(10 * time.Minute)
+	m.Track(deploymentEvent("2", "ProgressDeadlineExceeded"))
+	if got := m.Pending(); got != 1 {
+		t.Fatalf("Pending() = %d, want 1", got)
+	}
+
+	now = now.Add(4 * time.Minute)
+	m.escalateDue()
+	if len(*escalated) != 0 {
+		t.Fatalf("escalated %d events before the deadline", len(*escalated))
+	}
+
+	now = now.Add(time.Minute)
+	m.escalateDue()
+	if len(*escalated) != 1 {
+		t.Fatalf("escalated %d events, want 1", len(*escalated))
+	}
+	e := (*escalated)[0]
+	if e.Event.ID != "1" || e.Rule.Name != "failed-rollout" {
+		t.Errorf("escalated event %s of rule %s, want 1 of failed-rollout", e.Event.ID, e.Rule.Name)
+	}
+	if m.Pending() != 0 {
+		t.Errorf("Pending() = %d after escalating, want 0", m.Pending())
+	}
+}
+
+func TestManager_Unmatched(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(t, &now, nil, failedRollout)
+
+	m.Track(deploymentEvent("1", "Available"))
+	if m.Pending() != 0 {
+		t.Errorf("Pending() = %d for an event matching no rule, want 0", m.Pending())
+	}
+	if m.Matches(deploymentEvent("1", "Available")) {
+		t.Error("Matches() = true for an event matching no rule")
+	}
+}
+
+func TestManager_Resolve(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, escalated := newTestManager(t, &now, nil, failedRollout)
+
+	m.Track(deploymentEvent("1", "ProgressDeadlineExceeded"))
+
+	// 別のリソースのイベントでは解決しない
+	other := deploymentEvent("2", "Available")
+	other.Name = "web"
+	m.Resolve(other)
+	// ルールに一致し続けるイベントでも解決しない
+	m.Resolve(deploymentEvent("3", "ProgressDeadlineExceeded"))
+	if m.Pending() != 1 {
+		t.Fatalf("Pending() = %d, want 1", m.Pending())
+	}
+
+	m.Resolve(deploymentEvent("4", "Available"))
+	now = now.Add(time.Hour)
+	m.escalateDue()
+	if len(*escalated) != 0 {
+		t.Errorf("escalated %d resolved events", len(*escalated))
+	}
+}
+
+func TestManager_Acknowledged(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	acked := map[string]bool{"1": true}
+	m, escalated := newTestManager(t, &now, func(id string) bool { return acked[id] }, failedRollout)
+
+	m.Track(deploymentEvent("1", "ProgressDeadlineExceeded"))
+	now = now.Add(time.Hour)
+	m.escalateDue()
+	if len(*escalated) != 0 {
+		t.Errorf("escalated %d acknowledged events", len(*escalated))
+	}
+}
+
+func TestManager_AcknowledgedLaterNotification(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	acked := map[string]bool{"2": true}
+	m, escalated := newTestManager(t, &now, func(id string) bool { return acked[id] }, failedRollout)
+
+	// 同じリソースの後の通知が確認されてもエスカレーションしない
+	m.Track(deploymentEvent("1", "ProgressDeadlineExceeded"))
+	now = now.Add(5 * time.Minute)
+	m.Track(deploymentEvent("2", "ProgressDeadlineExceeded"))
+	now = now.Add(time.Hour)
+	m.escalateDue()
+	if len(*escalated) != 0 {
+		t.Errorf("escalated %d events acknowledged through a later notification", len(*escalated))
+	}
+}
+
+func TestManager_SetRules(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, escalated := newTestManager(t, &now, nil, failedRollout)
+
+	m.Track(deploymentEvent("1", "ProgressDeadlineExceeded"))
+	rules, err := NewRules(nil)
+	if err != nil {
+		t.Fatalf("NewRules() error = %v", err)
+	}
+	m.SetRules(rules)
+	if m.Pending() != 0 {
+		t.Errorf("Pending() = %d after removing the rule, want 0", m.Pending())
+	}
+	now = now.Add(time.Hour)
+	m.escalateDue()
+	if len(*escalated) != 0 {
+		t.Errorf("escalated %d events of a removed rule", len(*escalated))
+	}
+}
+
+func TestManager_Nil(t *testing.T) {
+	var m *Manager
+	event := deploymentEvent("1", "ProgressDeadlineExceeded")
+	m.Track(event)
+	m.Resolve(event)
+	if m.Matches(event) || m.Pending() != 0 {
+		t.Error("nil Manager should escalate nothing")
+	}
+	m.Stop()
+}
+
+func TestNewRules_InvalidExpression(t *testing.T) {
+	_, err := NewRules([]config.EscalationRule{{
+		Name:          "broken",
+		MatcherConfig: config.MatcherConfig{Expression: "event.kind =="},
+		AfterMinutes:  5,
+	}})
+	if err == nil {
+		t.Fatal("NewRules() should fail for an invalid expression")
+	}
+}
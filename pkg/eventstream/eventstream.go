@@ -0,0 +1,284 @@
+// Package eventstream serves the filtered event feed as a gRPC server-streaming
+// API (see eventstream.proto), so that other services can subscribe to the same
+// events kube-watcher notifies about.
+//
+// The gRPC protocol is implemented on net/http over HTTP/2, and the messages
+// are encoded by the protobuf runtime; any gRPC client generated from
+// eventstream.proto can connect.
+package eventstream
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// SubscribePath is the gRPC method path of EventService.Subscribe
+const SubscribePath = "/kubewatcher.v1.EventService/Subscribe"
+
+// DefaultBufferSize is the number of events buffered per subscriber by default
+const DefaultBufferSize = 256
+
+// maxRequestBytes bounds the SubscribeRequest message
+const maxRequestBytes = 64 * 1024
+
+// gRPC status codes
+const (
+	codeOK              = 0
+	codeInvalidArgument = 3
+	codeUnimplemented   = 12
+	codeInternal        = 13
+	codeUnauthenticated = 16
+)
+
+// Filter selects the events of a subscription. Empty lists match everything.
+type Filter struct {
+	Kinds      []string
+	Namespaces []string
+	EventTypes []string
+}
+
+// Matches reports whether the event passes the filter
+func (f Filter) Matches(e *watcher.Event) bool {
+	return matchAny(f.Kinds, e.Kind) && matchAny(f.Namespaces, e.Namespace) && matchAny(f.EventTypes, e.EventType)
+}
+
+func matchAny(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Options configures a Server
+type Options struct {
+	// Token returns the bearer token required in the authorization metadata, or
+	// "" to allow unauthenticated access. It is called per call so that rotated tokens apply.
+	Token      func() (string, error)
+	BufferSize int // Events buffered per subscriber; events are dropped for subscribers that fall behind
+}
+
+type subscriber struct {
+	filter  Filter
+	events  chan []byte // Encoded Event messages
+	dropped int         // Guarded by Server.mu
+}
+
+// Server fans published events out to the subscribed gRPC streams
+type Server struct {
+	opts Options
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewServer creates a Server
+func NewServer(opts Options) *Server {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	return &Server{
+		opts:        opts,
+		subscribers: make(map[*subscriber]struct{}),
+		closed:      make(chan struct{}),
+	}
+}
+
+// Publish sends the event to the matching subscribers without blocking.
+// A nil Server is valid and publishes nothing.
+func (s *Server) Publish(e *watcher.Event) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var msg []byte
+	for sub := range s.subscribers {
+		if !sub.filter.Matches(e) {
+			continue
+		}
+		if msg == nil {
+			var err error
+			if msg, err = encodeEvent(e); err != nil {
+				slog.Error("Failed to encode event for the event stream", e.LogAttrs("error", err)...)
+				return
+			}
+		}
+		select {
+		case sub.events <- msg:
+		default:
+			sub.dropped++
+			if sub.dropped == 1 {
+				slog.Warn("Event stream subscriber is falling behind, dropping events")
+			}
+		}
+	}
+}
+
+// Subscribers returns the number of open streams
+func (s *Server) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers)
+}
+
+// Close ends all streams with status OK and rejects new calls
+func (s *Server) Close() {
+	if s == nil {
+		return
+	}
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+// ServeHTTP handles gRPC calls over HTTP/2
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		http.Error(w, "gRPC requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "not a gRPC request", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+
+	if r.URL.Path != SubscribePath {
+		writeStatus(w, codeUnimplemented, "unknown method "+r.URL.Path)
+		return
+	}
+	if code, msg := s.authenticate(r); code != codeOK {
+		writeStatus(w, code, msg)
+		return
+	}
+
+	req, err := readMessage(r.Body)
+	if err != nil {
+		writeStatus(w, codeInvalidArgument, err.Error())
+		return
+	}
+	filter, err := parseSubscribeRequest(req)
+	if err != nil {
+		writeStatus(w, codeInvalidArgument, err.Error())
+		return
+	}
+
+	sub := &subscriber{filter: filter, events: make(chan []byte, s.opts.BufferSize)}
+	s.mu.Lock()
+	select {
+	case <-s.closed:
+		s.mu.Unlock()
+		writeStatus(w, codeOK, "")
+		return
+	default:
+	}
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+	slog.Info("Event stream subscribed", "remote", r.RemoteAddr, "kinds", filter.Kinds, "namespaces", filter.Namespaces, "eventTypes", filter.EventTypes)
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		dropped := sub.dropped
+		s.mu.Unlock()
+		slog.Info("Event stream closed", "remote", r.RemoteAddr, "dropped", dropped)
+	}()
+
+	// Status is sent in trailers once the stream ends
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			w.Header().Set("Grpc-Status", strconv.Itoa(codeOK))
+			return
+		case msg := <-sub.events:
+			if err := writeMessage(w, msg); err != nil {
+				slog.Debug("Failed to write to event stream", "remote", r.RemoteAddr, "error", err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// authenticate checks the "authorization: Bearer <token>" metadata when a token is configured
+func (s *Server) authenticate(r *http.Request) (int, string) {
+	if s.opts.Token == nil {
+		return codeOK, ""
+	}
+	want, err := s.opts.Token()
+	if err != nil {
+		slog.Error("Failed to read event stream token", "error", err)
+		return codeInternal, "token unavailable"
+	}
+	if want == "" {
+		return codeOK, ""
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		return codeUnauthenticated, "unauthenticated"
+	}
+	return codeOK, ""
+}
+
+// writeStatus ends a call without messages (a gRPC "Trailers-Only" response)
+func writeStatus(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Grpc-Status", strconv.Itoa(code))
+	if msg != "" {
+		w.Header().Set("Grpc-Message", msg)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// readMessage reads one length-prefixed gRPC message
+func readMessage(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	if header[0] != 0 {
+		return nil, fmt.Errorf("compressed requests are not supported")
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxRequestBytes {
+		return nil, fmt.Errorf("request of %d bytes is too large", size)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	return msg, nil
+}
+
+// writeMessage writes one length-prefixed, uncompressed gRPC message
+func writeMessage(w io.Writer, msg []byte) error {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	_, err := w.Write(append(frame, msg...))
+	return err
+}
@@ -0,0 +1,175 @@
+package eventstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// startServer starts s on an h2c test server and returns a client speaking HTTP/2 without TLS
+func startServer(t *testing.T, s *Server) (*httptest.Server, *http.Client) {
+	t.Helper()
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+
+	ts := httptest.NewUnstartedServer(s)
+	ts.Config.Protocols = protocols
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts, &http.Client{Transport: &http.Transport{Protocols: protocols}}
+}
+
+func subscribe(t *testing.T, ts *httptest.Server, client *http.Client, token string, req []byte) *http.Response {
+	t.Helper()
+	frame := make([]byte, 5)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(req)))
+	httpReq, _ := http.NewRequestWithContext(t.Context(), http.MethodPost, ts.URL+SubscribePath, bytes.NewReader(append(frame, req...)))
+	httpReq.Header.Set("Content-Type", "application/grpc")
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// fields decodes the string fields of a message by field number
+func fields(t *testing.T, msg []byte) map[protowire.Number][]byte {
+	t.Helper()
+	out := make(map[protowire.Number][]byte)
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			t.Fatalf("Invalid tag: %v", protowire.ParseError(n))
+		}
+		msg = msg[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, msg)
+			msg = msg[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(msg)
+		if n < 0 {
+			t.Fatalf("Invalid field: %v", protowire.ParseError(n))
+		}
+		out[num] = v
+		msg = msg[n:]
+	}
+	return out
+}
+
+func waitForSubscribers(t *testing.T, s *Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Subscribers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d subscribers, got %d", n, s.Subscribers())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	s := NewServer(Options{})
+	ts, client := startServer(t, s)
+
+	// namespace=prod のイベントのみ購読
+	var req []byte
+	req = protowire.AppendTag(req, 2, protowire.BytesType)
+	req = protowire.AppendString(req, "prod")
+	resp := subscribe(t, ts, client, "", req)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/grpc" {
+		t.Fatalf("Unexpected response %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	waitForSubscribers(t, s, 1)
+
+	s.Publish(&watcher.Event{Kind: "Pod", Namespace: "dev", Name: "ignored", EventType: "ADDED"})
+	s.Publish(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "DELETED"})
+
+	var header [5]byte
+	if _, err := io.ReadFull(resp.Body, header[:]); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	msg := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(resp.Body, msg); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	f := fields(t, msg)
+	if string(f[2]) != "Pod" || string(f[3]) != "prod" || string(f[4]) != "web" || string(f[5]) != "DELETED" {
+		t.Errorf("Unexpected event %q", f)
+	}
+
+	// サーバーの停止時はステータスOKでストリームを終了する
+	s.Close()
+	if rest, _ := io.ReadAll(resp.Body); len(rest) != 0 {
+		t.Errorf("Expected no further messages, got %d bytes", len(rest))
+	}
+	if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+		t.Errorf("Expected grpc-status 0, got %q", got)
+	}
+	waitForSubscribers(t, s, 0)
+}
+
+func TestServer_Token(t *testing.T) {
+	s := NewServer(Options{Token: func() (string, error) { return "secret", nil }})
+	ts, client := startServer(t, s)
+
+	resp := subscribe(t, ts, client, "wrong", nil)
+	if got := resp.Header.Get("Grpc-Status"); got != "16" {
+		t.Errorf("Expected grpc-status 16 (UNAUTHENTICATED), got %q", got)
+	}
+
+	resp = subscribe(t, ts, client, "secret", nil)
+	if got := resp.Header.Get("Grpc-Status"); got != "" {
+		t.Errorf("Expected the stream to open, got grpc-status %q", got)
+	}
+	waitForSubscribers(t, s, 1)
+	s.Close()
+}
+
+func TestServer_UnknownMethod(t *testing.T) {
+	s := NewServer(Options{})
+	ts, client := startServer(t, s)
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, ts.URL+"/kubewatcher.v1.EventService/List", bytes.NewReader(make([]byte, 5)))
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Grpc-Status"); got != "12" {
+		t.Errorf("Expected grpc-status 12 (UNIMPLEMENTED), got %q", got)
+	}
+}
+
+func TestServer_SlowSubscriber(t *testing.T) {
+	s := NewServer(Options{BufferSize: 1})
+	sub := &subscriber{events: make(chan []byte, 1)}
+	s.subscribers[sub] = struct{}{}
+
+	// バッファが一杯の購読者へのイベントは破棄され、Publishはブロックしない
+	s.Publish(&watcher.Event{Kind: "Pod", Name: "a"})
+	s.Publish(&watcher.Event{Kind: "Pod", Name: "b"})
+	if len(sub.events) != 1 || sub.dropped != 1 {
+		t.Errorf("Expected 1 buffered and 1 dropped event, got %d and %d", len(sub.events), sub.dropped)
+	}
+}
+
+func TestServer_Nil(t *testing.T) {
+	var s *Server
+	s.Publish(&watcher.Event{Kind: "Pod"})
+	s.Close()
+}
@@ -0,0 +1,212 @@
+package eventstream
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// The messages are encoded by the protobuf runtime from the descriptor of
+// eventstream.proto below; TestSchema checks that it matches the .proto file.
+
+// schema is the descriptor of eventstream.proto
+var schema = mustSchema()
+
+var (
+	eventDesc            = schema.Messages().ByName("Event")
+	subscribeRequestDesc = schema.Messages().ByName("SubscribeRequest")
+)
+
+// mustSchema builds the descriptor of eventstream.proto, resolving
+// google.protobuf.Timestamp from the registry of the linked well-known types
+func mustSchema() protoreflect.FileDescriptor {
+	str := func(name string, number int32) *descriptorpb.FieldDescriptorProto {
+		return field(name, number, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	}
+	repeated := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return f
+	}
+	message := func(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+		return field(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, typeName)
+	}
+	int32Field := func(name string, number int32) *descriptorpb.FieldDescriptorProto {
+		return field(name, number, descriptorpb.FieldDescriptorProto_TYPE_INT32, "")
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("eventstream.proto"),
+		Package:    proto.String("kubewatcher.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("github.com/kqns91/kube-watcher/pkg/eventstream/kubewatcherv1")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("SubscribeRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					repeated(str("kinds", 1)),
+					repeated(str("namespaces", 2)),
+					repeated(str("event_types", 3)),
+				},
+			},
+			{
+				Name: proto.String("Event"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					str("cluster", 1),
+					str("kind", 2),
+					str("namespace", 3),
+					str("name", 4),
+					str("event_type", 5),
+					message("timestamp", 6, ".google.protobuf.Timestamp"),
+					message("created_at", 7, ".google.protobuf.Timestamp"),
+					repeated(message("labels", 8, ".kubewatcher.v1.Event.LabelsEntry")),
+					str("reason", 9),
+					str("message", 10),
+					str("status", 11),
+					repeated(message("containers", 12, ".kubewatcher.v1.Container")),
+					message("replicas", 13, ".kubewatcher.v1.Replicas"),
+					str("service_type", 14),
+					repeated(message("changes", 15, ".kubewatcher.v1.FieldChange")),
+				},
+				// map<string, string> is a repeated map entry message
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name:    proto.String("LabelsEntry"),
+					Field:   []*descriptorpb.FieldDescriptorProto{str("key", 1), str("value", 2)},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+			},
+			{
+				Name:  proto.String("Container"),
+				Field: []*descriptorpb.FieldDescriptorProto{str("name", 1), str("image", 2)},
+			},
+			{
+				Name:  proto.String("Replicas"),
+				Field: []*descriptorpb.FieldDescriptorProto{int32Field("desired", 1), int32Field("ready", 2), int32Field("current", 3)},
+			},
+			{
+				Name:  proto.String("FieldChange"),
+				Field: []*descriptorpb.FieldDescriptorProto{str("field", 1), str("old", 2), str("new", 3)},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("EventService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:            proto.String("Subscribe"),
+				InputType:       proto.String(".kubewatcher.v1.SubscribeRequest"),
+				OutputType:      proto.String(".kubewatcher.v1.Event"),
+				ServerStreaming: proto.Bool(true),
+			}},
+		}},
+	}
+
+	// google/protobuf/timestamp.proto is registered by the timestamppb package
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(fmt.Sprintf("invalid eventstream schema: %v", err))
+	}
+	return fd
+}
+
+// field describes a singular proto3 field
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+// encodeEvent encodes e as the Event message. Map entries are sorted by key,
+// so that the same event always has the same encoding.
+func encodeEvent(e *watcher.Event) ([]byte, error) {
+	msg := dynamicpb.NewMessage(eventDesc)
+	fields := eventDesc.Fields()
+	setString := func(m *dynamicpb.Message, name protoreflect.Name, s string) {
+		if s != "" {
+			m.Set(m.Descriptor().Fields().ByName(name), protoreflect.ValueOfString(s))
+		}
+	}
+	setTimestamp := func(name protoreflect.Name, t time.Time) {
+		if !t.IsZero() {
+			msg.Set(fields.ByName(name), protoreflect.ValueOfMessage(timestamppb.New(t).ProtoReflect()))
+		}
+	}
+	// newItem appends a message to the repeated field
+	newItem := func(name protoreflect.Name) *dynamicpb.Message {
+		list := msg.Mutable(fields.ByName(name)).List()
+		item := list.NewElement()
+		list.Append(item)
+		return item.Message().Interface().(*dynamicpb.Message)
+	}
+
+	setString(msg, "cluster", e.Cluster)
+	setString(msg, "kind", e.Kind)
+	setString(msg, "namespace", e.Namespace)
+	setString(msg, "name", e.Name)
+	setString(msg, "event_type", e.EventType)
+	setTimestamp("timestamp", e.Timestamp)
+	setTimestamp("created_at", e.CreatedAt)
+	if len(e.Labels) > 0 {
+		labels := msg.Mutable(fields.ByName("labels")).Map()
+		for k, v := range e.Labels {
+			labels.Set(protoreflect.ValueOfString(k).MapKey(), protoreflect.ValueOfString(v))
+		}
+	}
+	setString(msg, "reason", e.Reason)
+	setString(msg, "message", e.Message)
+	setString(msg, "status", e.Status)
+	for _, c := range e.Containers {
+		container := newItem("containers")
+		setString(container, "name", c.Name)
+		setString(container, "image", c.Image)
+	}
+	if r := e.Replicas; r != nil {
+		replicas := msg.Mutable(fields.ByName("replicas")).Message()
+		replicaFields := replicas.Descriptor().Fields()
+		replicas.Set(replicaFields.ByName("desired"), protoreflect.ValueOfInt32(r.Desired))
+		replicas.Set(replicaFields.ByName("ready"), protoreflect.ValueOfInt32(r.Ready))
+		replicas.Set(replicaFields.ByName("current"), protoreflect.ValueOfInt32(r.Current))
+	}
+	setString(msg, "service_type", e.ServiceType)
+	for _, c := range e.Changes {
+		change := newItem("changes")
+		setString(change, "field", c.Field)
+		setString(change, "old", c.Old)
+		setString(change, "new", c.New)
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// parseSubscribeRequest decodes a SubscribeRequest message, skipping unknown fields
+func parseSubscribeRequest(b []byte) (Filter, error) {
+	var f Filter
+	msg := dynamicpb.NewMessage(subscribeRequestDesc)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return f, fmt.Errorf("invalid request: %w", err)
+	}
+	fields := subscribeRequestDesc.Fields()
+	for _, field := range []struct {
+		name protoreflect.Name
+		dest *[]string
+	}{{"kinds", &f.Kinds}, {"namespaces", &f.Namespaces}, {"event_types", &f.EventTypes}} {
+		list := msg.Get(fields.ByName(field.name)).List()
+		for i := 0; i < list.Len(); i++ {
+			*field.dest = append(*field.dest, list.Get(i).String())
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,164 @@
+package eventstream
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestSchema(t *testing.T) {
+	source, err := os.ReadFile("eventstream.proto")
+	if err != nil {
+		t.Fatalf("Failed to read eventstream.proto: %v", err)
+	}
+
+	// メッセージごとのフィールドを .proto から読み取り、記述子と比較する
+	messageRe := regexp.MustCompile(`^message (\w+) \{`)
+	fieldRe := regexp.MustCompile(`^\s*(repeated )?(map<string, string>|[\w.]+) (\w+) = (\d+);`)
+	declared := make(map[string]int)
+	var message protoreflect.MessageDescriptor
+	for _, line := range strings.Split(string(source), "\n") {
+		if m := messageRe.FindStringSubmatch(line); m != nil {
+			message = schema.Messages().ByName(protoreflect.Name(m[1]))
+			if message == nil {
+				t.Fatalf("Message %s is missing from the schema", m[1])
+			}
+			continue
+		}
+		m := fieldRe.FindStringSubmatch(line)
+		if m == nil || message == nil {
+			continue
+		}
+		declared[string(message.Name())]++
+		repeated, typ, name := m[1] != "", m[2], m[3]
+		number, _ := strconv.Atoi(m[4])
+
+		f := message.Fields().ByName(protoreflect.Name(name))
+		if f == nil {
+			t.Errorf("Field %s.%s is missing from the schema", message.Name(), name)
+			continue
+		}
+		if int(f.Number()) != number {
+			t.Errorf("Field %s.%s has number %d, want %d", message.Name(), name, f.Number(), number)
+		}
+		var got string
+		switch {
+		case f.IsMap():
+			got = "map<string, string>"
+		case f.Kind() == protoreflect.MessageKind:
+			got = strings.TrimPrefix(string(f.Message().FullName()), "kubewatcher.v1.")
+		default:
+			got = f.Kind().String()
+		}
+		if got != typ || (f.IsList() != repeated) {
+			t.Errorf("Field %s.%s is %s (repeated: %v), want %s (repeated: %v)", message.Name(), name, got, f.IsList(), typ, repeated)
+		}
+	}
+
+	for i := 0; i < schema.Messages().Len(); i++ {
+		m := schema.Messages().Get(i)
+		if n := declared[string(m.Name())]; n != m.Fields().Len() {
+			t.Errorf("Message %s has %d fields in the schema, %d in eventstream.proto", m.Name(), m.Fields().Len(), n)
+		}
+	}
+	if method := schema.Services().ByName("EventService").Methods().ByName("Subscribe"); method == nil || !method.IsStreamingServer() {
+		t.Error("Expected the server-streaming Subscribe method")
+	} else if path := "/" + string(method.Parent().FullName()) + "/" + string(method.Name()); path != SubscribePath {
+		t.Errorf("SubscribePath = %s, want %s", SubscribePath, path)
+	}
+}
+
+func TestEncodeEvent(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 500, time.UTC)
+	e := &watcher.Event{
+		Kind:       "Deployment",
+		Namespace:  "prod",
+		Name:       "api",
+		EventType:  "UPDATED",
+		Timestamp:  ts,
+		Labels:     map[string]string{"app": "api", "tier": "backend"},
+		Containers: []watcher.ContainerInfo{{Name: "api", Image: "api:v2"}},
+		Replicas:   &watcher.ReplicaInfo{Desired: 3, Ready: 2, Current: 3},
+		Changes:    []watcher.FieldChange{{Field: "image", Old: "api:v1", New: "api:v2"}},
+	}
+	msg, err := encodeEvent(e)
+	if err != nil {
+		t.Fatalf("encodeEvent() error = %v", err)
+	}
+
+	decoded := dynamicpb.NewMessage(eventDesc)
+	if err := proto.Unmarshal(msg, decoded); err != nil {
+		t.Fatalf("Failed to decode the event: %v", err)
+	}
+	fields := eventDesc.Fields()
+	if decoded.Has(fields.ByName("cluster")) || decoded.Has(fields.ByName("created_at")) {
+		t.Error("Expected empty fields to be omitted")
+	}
+	if got := decoded.Get(fields.ByName("name")).String(); got != "api" {
+		t.Errorf("name = %q, want api", got)
+	}
+	timestamp := decoded.Get(fields.ByName("timestamp")).Message()
+	seconds := timestamp.Get(timestamp.Descriptor().Fields().ByName("seconds")).Int()
+	nanos := timestamp.Get(timestamp.Descriptor().Fields().ByName("nanos")).Int()
+	if seconds != ts.Unix() || nanos != 500 {
+		t.Errorf("Unexpected timestamp %d.%d", seconds, nanos)
+	}
+	if labels := decoded.Get(fields.ByName("labels")).Map(); labels.Len() != 2 || labels.Get(protoreflect.ValueOfString("tier").MapKey()).String() != "backend" {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+	replicas := decoded.Get(fields.ByName("replicas")).Message()
+	if got := replicas.Get(replicas.Descriptor().Fields().ByName("ready")).Int(); got != 2 {
+		t.Errorf("replicas.ready = %d, want 2", got)
+	}
+	if changes := decoded.Get(fields.ByName("changes")).List(); changes.Len() != 1 {
+		t.Errorf("Expected one change, got %d", changes.Len())
+	}
+
+	// マップのエントリはキー順に出力され、同じイベントは同じバイト列になる
+	again, _ := encodeEvent(e)
+	if string(again) != string(msg) {
+		t.Error("Expected a deterministic encoding")
+	}
+}
+
+func TestParseSubscribeRequest(t *testing.T) {
+	var req []byte
+	for _, f := range []struct {
+		num   protowire.Number
+		value string
+	}{{1, "Pod"}, {1, "Deployment"}, {3, "DELETED"}} {
+		req = protowire.AppendTag(req, f.num, protowire.BytesType)
+		req = protowire.AppendString(req, f.value)
+	}
+	// 未知のフィールドは無視する
+	req = protowire.AppendTag(req, 9, protowire.VarintType)
+	req = protowire.AppendVarint(req, 1)
+
+	f, err := parseSubscribeRequest(req)
+	if err != nil {
+		t.Fatalf("parseSubscribeRequest() error = %v", err)
+	}
+	if len(f.Kinds) != 2 || len(f.Namespaces) != 0 || len(f.EventTypes) != 1 {
+		t.Errorf("Unexpected filter %+v", f)
+	}
+	if !f.Matches(&watcher.Event{Kind: "Pod", Namespace: "any", EventType: "DELETED"}) {
+		t.Error("Expected a matching event")
+	}
+	if f.Matches(&watcher.Event{Kind: "Pod", EventType: "ADDED"}) {
+		t.Error("Expected other event types not to match")
+	}
+
+	if _, err := parseSubscribeRequest([]byte{0x0a, 0x05, 'a'}); err == nil {
+		t.Error("Expected error for a truncated request")
+	}
+}
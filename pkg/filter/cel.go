@@ -17,6 +17,19 @@ type CELFilter struct {
 
 // NewCELFilter creates a new CEL filter from an expression
 func NewCELFilter(expression string) (*CELFilter, error) {
+	program, err := compileCEL(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CELFilter{
+		expression: expression,
+		program:    program,
+	}, nil
+}
+
+// compileCEL compiles an expression over the event variable
+func compileCEL(expression string) (cel.Program, error) {
 	// Create CEL environment with event variable
 	env, err := cel.NewEnv(
 		cel.Variable("event", cel.DynType),
@@ -36,11 +49,7 @@ func NewCELFilter(expression string) (*CELFilter, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CEL program: %w", err)
 	}
-
-	return &CELFilter{
-		expression: expression,
-		program:    program,
-	}, nil
+	return program, nil
 }
 
 // Evaluate evaluates the CEL expression against an event
@@ -68,6 +77,7 @@ func (f *CELFilter) Evaluate(event *watcher.Event) (bool, error) {
 // eventToMap converts a watcher.Event to a map for CEL evaluation
 func eventToMap(event *watcher.Event) map[string]interface{} {
 	m := map[string]interface{}{
+		"cluster":   event.Cluster,
 		"kind":      event.Kind,
 		"namespace": event.Namespace,
 		"name":      event.Name,
@@ -78,6 +88,11 @@ func eventToMap(event *watcher.Event) map[string]interface{} {
 		"status":    event.Status,
 	}
 
+	// Add severity level if assigned
+	if event.Severity != nil {
+		m["severity"] = event.Severity.Level
+	}
+
 	// Add replicas info if available
 	if event.Replicas != nil {
 		m["replicas"] = map[string]interface{}{
@@ -104,6 +119,11 @@ func eventToMap(event *watcher.Event) map[string]interface{} {
 		m["serviceType"] = event.ServiceType
 	}
 
+	// Add external addresses if available
+	if len(event.Addresses) > 0 {
+		m["addresses"] = event.Addresses
+	}
+
 	return m
 }
 
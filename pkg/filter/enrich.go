@@ -0,0 +1,165 @@
+package filter
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// enrichmentRule is a compiled config.EnrichmentRule
+type enrichmentRule struct {
+	name     string
+	matcher  *EventMatcher // Empty applies to all events
+	set      []fieldExpression
+	mappings []config.FieldMapping
+}
+
+type fieldExpression struct {
+	field   string
+	program cel.Program
+}
+
+// Enricher adds and rewrites event fields before the events are filtered,
+// routed and formatted. A nil Enricher is valid and changes nothing.
+type Enricher struct {
+	rules []enrichmentRule
+}
+
+// NewEnricher compiles the enrichment rules
+func NewEnricher(rules []config.EnrichmentRule) (*Enricher, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	e := &Enricher{}
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("enrichment[%d]", i)
+		}
+		matcher, err := NewEventMatcher(r.MatcherConfig)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		compiled := enrichmentRule{name: name, matcher: matcher, mappings: r.Mappings}
+
+		// Fields are set in a stable order
+		fields := make([]string, 0, len(r.Set))
+		for field := range r.Set {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			program, err := compileCEL(r.Set[field])
+			if err != nil {
+				return nil, fmt.Errorf("%s.set.%s: %w", name, field, err)
+			}
+			compiled.set = append(compiled.set, fieldExpression{field: field, program: program})
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// Apply runs the rules in order on the event. Later rules see the fields
+// written by earlier ones. Expressions that fail leave their field unchanged.
+func (e *Enricher) Apply(event *watcher.Event) {
+	if e == nil {
+		return
+	}
+	// The labels map may be shared with the informer cache
+	labelsCopied := false
+	set := func(field, value string) {
+		if key, ok := strings.CutPrefix(field, "labels."); ok {
+			if !labelsCopied {
+				event.Labels = maps.Clone(event.Labels)
+				if event.Labels == nil {
+					event.Labels = make(map[string]string)
+				}
+				labelsCopied = true
+			}
+			event.Labels[key] = value
+			return
+		}
+		setField(event, field, value)
+	}
+
+	for _, r := range e.rules {
+		if !r.matcher.IsEmpty() && !r.matcher.Matches(event) {
+			continue
+		}
+
+		for _, fe := range r.set {
+			out, _, err := fe.program.Eval(map[string]interface{}{"event": eventToMap(event)})
+			if err != nil {
+				slog.Warn("Enrichment expression failed", event.LogAttrs("rule", r.name, "field", fe.field, "error", err)...)
+				continue
+			}
+			value, ok := out.(types.String)
+			if !ok {
+				slog.Warn("Enrichment expression did not return a string", event.LogAttrs("rule", r.name, "field", fe.field)...)
+				continue
+			}
+			set(fe.field, string(value))
+		}
+
+		for _, m := range r.mappings {
+			value, ok := m.Values[getField(event, m.Source)]
+			if !ok {
+				if m.Default == "" {
+					continue
+				}
+				value = m.Default
+			}
+			set(m.Target, value)
+		}
+	}
+}
+
+// getField returns the value of an enrichment source field
+func getField(event *watcher.Event, field string) string {
+	if key, ok := strings.CutPrefix(field, "labels."); ok {
+		return event.Labels[key]
+	}
+	switch field {
+	case "cluster":
+		return event.Cluster
+	case "kind":
+		return event.Kind
+	case "namespace":
+		return event.Namespace
+	case "name":
+		return event.Name
+	case "eventType":
+		return event.EventType
+	case "reason":
+		return event.Reason
+	case "message":
+		return event.Message
+	case "status":
+		return event.Status
+	}
+	return ""
+}
+
+// setField sets an enrichment target field other than a label
+func setField(event *watcher.Event, field, value string) {
+	switch field {
+	case "cluster":
+		event.Cluster = value
+	case "reason":
+		event.Reason = value
+	case "message":
+		event.Message = value
+	case "status":
+		event.Status = value
+	}
+}
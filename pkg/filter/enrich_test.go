@@ -0,0 +1,85 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestEnricher_Apply(t *testing.T) {
+	e, err := NewEnricher([]config.EnrichmentRule{
+		{
+			Name: "team",
+			Set:  map[string]string{"labels.team": `"app.kubernetes.io/part-of" in event.labels ? event.labels["app.kubernetes.io/part-of"] : "platform"`},
+		},
+		{
+			Name: "environment",
+			Mappings: []config.FieldMapping{{
+				Source:  "namespace",
+				Target:  "labels.env",
+				Values:  map[string]string{"prod": "production", "stg": "staging"},
+				Default: "development",
+			}},
+		},
+		{
+			// 前のルールで設定した値を参照できる
+			Name:          "prod reason",
+			Set:           map[string]string{"reason": `event.reason + " (" + event.labels.team + ")"`},
+			MatcherConfig: config.MatcherConfig{Expression: `event.labels.env == "production"`},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEnricher() error = %v", err)
+	}
+
+	labels := map[string]string{"app.kubernetes.io/part-of": "payments"}
+	event := &watcher.Event{Kind: "Pod", Namespace: "prod", Name: "api", Reason: "OOMKilled", Labels: labels}
+	e.Apply(event)
+
+	if event.Labels["team"] != "payments" {
+		t.Errorf("Expected team payments, got %q", event.Labels["team"])
+	}
+	if event.Labels["env"] != "production" {
+		t.Errorf("Expected env production, got %q", event.Labels["env"])
+	}
+	if event.Reason != "OOMKilled (payments)" {
+		t.Errorf("Expected enriched reason, got %q", event.Reason)
+	}
+	// インフォーマーと共有している可能性のある元のラベルは変更しない
+	if len(labels) != 1 {
+		t.Errorf("Expected original labels to be unchanged, got %v", labels)
+	}
+
+	// 条件に一致しないルールは適用されず、未知の値にはデフォルトが使われる
+	event = &watcher.Event{Kind: "Pod", Namespace: "dev", Name: "api", Reason: "OOMKilled"}
+	e.Apply(event)
+	if event.Labels["team"] != "platform" || event.Labels["env"] != "development" {
+		t.Errorf("Unexpected labels %v", event.Labels)
+	}
+	if event.Reason != "OOMKilled" {
+		t.Errorf("Expected reason to be unchanged, got %q", event.Reason)
+	}
+}
+
+func TestEnricher_InvalidResult(t *testing.T) {
+	e, err := NewEnricher([]config.EnrichmentRule{{Set: map[string]string{"status": `1 + 1`}}})
+	if err != nil {
+		t.Fatalf("NewEnricher() error = %v", err)
+	}
+
+	// 文字列以外の結果はフィールドを変更しない
+	event := &watcher.Event{Kind: "Pod", Status: "Running"}
+	e.Apply(event)
+	if event.Status != "Running" {
+		t.Errorf("Expected status to be unchanged, got %q", event.Status)
+	}
+
+	if _, err := NewEnricher([]config.EnrichmentRule{{Set: map[string]string{"status": `event.`}}}); err == nil {
+		t.Error("Expected compile error")
+	}
+
+	// nilのEnricherは何もしない
+	var nilEnricher *Enricher
+	nilEnricher.Apply(event)
+}
@@ -2,7 +2,8 @@
 package filter
 
 import (
-	"log"
+	"log/slog"
+	"regexp"
 
 	"github.com/kqns91/kube-watcher/pkg/config"
 	"github.com/kqns91/kube-watcher/pkg/watcher"
@@ -12,6 +13,9 @@ import (
 type Filter struct {
 	config     *config.Config
 	celFilters map[string]*CELFilter // resource kind -> CEL filter
+
+	includeNamespaces []*regexp.Regexp
+	excludeNamespaces []*regexp.Regexp
 }
 
 // NewFilter creates a new Filter instance
@@ -21,17 +25,26 @@ func NewFilter(cfg *config.Config) *Filter {
 		celFilters: make(map[string]*CELFilter),
 	}
 
+	// Patterns are validated with the configuration
+	var err error
+	if f.includeNamespaces, err = config.CompilePatterns("includeNamespacesRegex", cfg.IncludeNamespacesRegex); err != nil {
+		slog.Error("Failed to compile namespace patterns", "error", err)
+	}
+	if f.excludeNamespaces, err = config.CompilePatterns("excludeNamespacesRegex", cfg.ExcludeNamespacesRegex); err != nil {
+		slog.Error("Failed to compile namespace patterns", "error", err)
+	}
+
 	// Compile CEL expressions for filters that have them
 	for i := range cfg.Filters {
 		filterCfg := &cfg.Filters[i]
 		if filterCfg.Expression != "" {
 			celFilter, err := NewCELFilter(filterCfg.Expression)
 			if err != nil {
-				log.Printf("Failed to compile CEL expression for %s: %v", filterCfg.Resource, err)
+				slog.Error("Failed to compile CEL expression", "resource", filterCfg.Resource, "error", err)
 				continue
 			}
 			f.celFilters[filterCfg.Resource] = celFilter
-			log.Printf("CEL filter compiled for %s: %s", filterCfg.Resource, filterCfg.Expression)
+			slog.Info("CEL filter compiled", "resource", filterCfg.Resource, "expression", filterCfg.Expression)
 		}
 	}
 
@@ -40,6 +53,12 @@ func NewFilter(cfg *config.Config) *Filter {
 
 // ShouldProcess determines if an event should be processed
 func (f *Filter) ShouldProcess(event *watcher.Event) bool {
+	// Cluster-scoped resources have no namespace
+	if event.Namespace != "" && !config.NamespaceAllowed(event.Namespace, f.includeNamespaces, f.excludeNamespaces) {
+		slog.Debug("Event namespace excluded", event.LogAttrs()...)
+		return false
+	}
+
 	// Get filter configuration for this resource kind
 	filterConfig := f.config.GetFilterForResource(event.Kind)
 	if filterConfig == nil {
@@ -51,9 +70,12 @@ func (f *Filter) ShouldProcess(event *watcher.Event) bool {
 	if celFilter, exists := f.celFilters[event.Kind]; exists {
 		result, err := celFilter.Evaluate(event)
 		if err != nil {
-			log.Printf("CEL evaluation error for %s: %v", event.Kind, err)
+			slog.Warn("CEL evaluation error", event.LogAttrs("error", err)...)
 			// Fall back to basic filters on error
 		} else {
+			if !result {
+				slog.Debug("Event rejected by CEL expression", event.LogAttrs("expression", filterConfig.Expression)...)
+			}
 			return result
 		}
 	}
@@ -61,11 +83,13 @@ func (f *Filter) ShouldProcess(event *watcher.Event) bool {
 	// Fall back to basic filters
 	// Check event type
 	if !f.matchesEventType(event.EventType, filterConfig.EventTypes) {
+		slog.Debug("Event type not in filter", event.LogAttrs("eventTypes", filterConfig.EventTypes)...)
 		return false
 	}
 
 	// Check labels if specified
 	if len(filterConfig.Labels) > 0 && !f.matchesLabels(event.Labels, filterConfig.Labels) {
+		slog.Debug("Event labels do not match filter", event.LogAttrs("labels", filterConfig.Labels)...)
 		return false
 	}
 
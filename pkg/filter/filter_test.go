@@ -294,3 +294,26 @@ func TestFilter_ShouldProcess_CombinedFiltering(t *testing.T) {
 		})
 	}
 }
+
+func TestFilter_ShouldProcess_NamespacePatterns(t *testing.T) {
+	f := NewFilter(&config.Config{
+		IncludeNamespacesRegex: []string{`^(prod|pr-\d+)$`},
+		ExcludeNamespacesRegex: []string{`^pr-\d+$`},
+	})
+
+	tests := []struct {
+		namespace     string
+		shouldProcess bool
+	}{
+		{"prod", true},
+		{"pr-123", false}, // 除外パターンが優先される
+		{"staging", false},
+		{"", true}, // クラスタスコープのリソースは対象外
+	}
+	for _, tt := range tests {
+		event := &watcher.Event{Kind: "Pod", Namespace: tt.namespace, EventType: "ADDED"}
+		if got := f.ShouldProcess(event); got != tt.shouldProcess {
+			t.Errorf("ShouldProcess(%q) = %v, want %v", tt.namespace, got, tt.shouldProcess)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"fmt"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// EventMatcher matches events against a set of conditions.
+// All configured conditions must match; a matcher with no conditions matches nothing.
+type EventMatcher struct {
+	clusters   []string
+	namespaces []string
+	kinds      []string
+	names      []string
+	eventTypes []string
+	severities []string
+	labels     map[string]string
+	celFilter  *CELFilter
+}
+
+// NewEventMatcher creates a new EventMatcher from configuration
+func NewEventMatcher(cfg config.MatcherConfig) (*EventMatcher, error) {
+	m := &EventMatcher{
+		clusters:   cfg.Clusters,
+		namespaces: cfg.Namespaces,
+		kinds:      cfg.Kinds,
+		names:      cfg.Names,
+		eventTypes: cfg.EventTypes,
+		severities: cfg.Severities,
+		labels:     cfg.Labels,
+	}
+
+	if cfg.Expression != "" {
+		celFilter, err := NewCELFilter(cfg.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher expression: %w", err)
+		}
+		m.celFilter = celFilter
+	}
+
+	return m, nil
+}
+
+// IsEmpty reports whether the matcher has no conditions
+func (m *EventMatcher) IsEmpty() bool {
+	return len(m.clusters) == 0 && len(m.namespaces) == 0 && len(m.kinds) == 0 && len(m.names) == 0 && len(m.eventTypes) == 0 &&
+		len(m.severities) == 0 && len(m.labels) == 0 && m.celFilter == nil
+}
+
+// Matches reports whether the event satisfies all configured conditions
+func (m *EventMatcher) Matches(event *watcher.Event) bool {
+	if m == nil || m.IsEmpty() {
+		return false
+	}
+
+	if len(m.clusters) > 0 && !contains(m.clusters, event.Cluster) {
+		return false
+	}
+
+	if len(m.namespaces) > 0 && !contains(m.namespaces, event.Namespace) {
+		return false
+	}
+
+	if len(m.kinds) > 0 && !contains(m.kinds, event.Kind) {
+		return false
+	}
+
+	if len(m.names) > 0 && !contains(m.names, event.Name) {
+		return false
+	}
+
+	if len(m.eventTypes) > 0 && !contains(m.eventTypes, event.EventType) {
+		return false
+	}
+
+	// Events are only classified when severity is enabled
+	if len(m.severities) > 0 && (event.Severity == nil || !contains(m.severities, event.Severity.Level)) {
+		return false
+	}
+
+	for key, value := range m.labels {
+		if v, ok := event.Labels[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	if m.celFilter != nil {
+		result, err := m.celFilter.Evaluate(event)
+		if err != nil {
+			return false
+		}
+		return result
+	}
+
+	return true
+}
+
+// contains reports whether s is in values
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
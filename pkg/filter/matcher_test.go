@@ -0,0 +1,139 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestEventMatcher_Matches(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.MatcherConfig
+		event   *watcher.Event
+		matches bool
+	}{
+		{
+			name:    "empty matcher matches nothing",
+			cfg:     config.MatcherConfig{},
+			event:   &watcher.Event{Kind: "Pod", EventType: "DELETED"},
+			matches: false,
+		},
+		{
+			name:    "event type matches",
+			cfg:     config.MatcherConfig{EventTypes: []string{"DELETED"}},
+			event:   &watcher.Event{Kind: "Pod", EventType: "DELETED"},
+			matches: true,
+		},
+		{
+			name:    "event type does not match",
+			cfg:     config.MatcherConfig{EventTypes: []string{"DELETED"}},
+			event:   &watcher.Event{Kind: "Pod", EventType: "UPDATED"},
+			matches: false,
+		},
+		{
+			name:    "kind and event type must both match",
+			cfg:     config.MatcherConfig{Kinds: []string{"Deployment"}, EventTypes: []string{"DELETED"}},
+			event:   &watcher.Event{Kind: "Pod", EventType: "DELETED"},
+			matches: false,
+		},
+		{
+			name:    "CEL expression matches",
+			cfg:     config.MatcherConfig{Expression: `event.reason == "OOMKilled"`},
+			event:   &watcher.Event{Kind: "Pod", EventType: "UPDATED", Reason: "OOMKilled"},
+			matches: true,
+		},
+		{
+			name:    "CEL expression combined with event type",
+			cfg:     config.MatcherConfig{EventTypes: []string{"DELETED"}, Expression: `event.namespace == "prod"`},
+			event:   &watcher.Event{Kind: "Pod", Namespace: "staging", EventType: "DELETED"},
+			matches: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewEventMatcher(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewEventMatcher() error = %v", err)
+			}
+
+			if got := m.Matches(tt.event); got != tt.matches {
+				t.Errorf("Matches() = %v, want %v", got, tt.matches)
+			}
+		})
+	}
+}
+
+func TestNewEventMatcher_InvalidExpression(t *testing.T) {
+	_, err := NewEventMatcher(config.MatcherConfig{Expression: `event.eventType ==`})
+	if err == nil {
+		t.Error("NewEventMatcher() error = nil, want error for invalid expression")
+	}
+}
+
+func TestEventMatcher_Labels(t *testing.T) {
+	m, err := NewEventMatcher(config.MatcherConfig{
+		EventTypes: []string{"DELETED"},
+		Labels:     map[string]string{"env": "prod"},
+	})
+	if err != nil {
+		t.Fatalf("NewEventMatcher() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		event  *watcher.Event
+		expect bool
+	}{
+		{"matching label", &watcher.Event{EventType: "DELETED", Labels: map[string]string{"env": "prod", "app": "web"}}, true},
+		{"different label value", &watcher.Event{EventType: "DELETED", Labels: map[string]string{"env": "dev"}}, false},
+		{"missing label", &watcher.Event{EventType: "DELETED"}, false},
+		{"event type does not match", &watcher.Event{EventType: "ADDED", Labels: map[string]string{"env": "prod"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Matches(tt.event); got != tt.expect {
+				t.Errorf("Matches() = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestEventMatcher_NamespacesAndClusters(t *testing.T) {
+	m, err := NewEventMatcher(config.MatcherConfig{
+		Clusters:   []string{"prod-tokyo"},
+		Namespaces: []string{"payments", "orders"},
+	})
+	if err != nil {
+		t.Fatalf("NewEventMatcher() error = %v", err)
+	}
+
+	if !m.Matches(&watcher.Event{Cluster: "prod-tokyo", Namespace: "orders"}) {
+		t.Error("Expected event in listed cluster and namespace to match")
+	}
+	if m.Matches(&watcher.Event{Cluster: "prod-tokyo", Namespace: "default"}) {
+		t.Error("Expected event in other namespace not to match")
+	}
+	if m.Matches(&watcher.Event{Cluster: "staging", Namespace: "orders"}) {
+		t.Error("Expected event in other cluster not to match")
+	}
+}
+
+func TestEventMatcher_Names(t *testing.T) {
+	m, err := NewEventMatcher(config.MatcherConfig{
+		Kinds: []string{"Deployment"},
+		Names: []string{"api", "web"},
+	})
+	if err != nil {
+		t.Fatalf("NewEventMatcher() error = %v", err)
+	}
+
+	if !m.Matches(&watcher.Event{Kind: "Deployment", Name: "web"}) {
+		t.Error("Expected listed resource to match")
+	}
+	if m.Matches(&watcher.Event{Kind: "Deployment", Name: "worker"}) {
+		t.Error("Expected other resource not to match")
+	}
+}
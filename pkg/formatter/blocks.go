@@ -0,0 +1,113 @@
+package formatter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// OutputFormat represents the Slack message layout
+type OutputFormat string
+
+// Output format constants
+const (
+	// OutputAttachments renders messages with (legacy) Slack attachments
+	OutputAttachments OutputFormat = "attachments"
+	// OutputBlocks renders messages with Slack Block Kit
+	OutputBlocks OutputFormat = "blocks"
+	// OutputText renders event messages as plain text with the template
+	OutputText OutputFormat = "text"
+)
+
+// maxSectionFields is the maximum number of fields Slack accepts in a section block
+const maxSectionFields = 10
+
+// FormatSlackBlocks formats an event as a Slack Block Kit message
+func (f *Formatter) FormatSlackBlocks(event *watcher.Event) *notifier.SlackMessage {
+	msg := f.FormatSlackMessage(event)
+	attachment := msg.Attachments[0]
+
+	// Blocks have no color bar, so the event type is conveyed with an emoji
+	attachment.Title = fmt.Sprintf("%s %s", getEventEmoji(event.EventType), attachment.Title)
+
+	var blocks []notifier.SlackBlock
+	if msg.Text != "" {
+		// Mentions only notify when they appear in the blocks themselves
+		blocks = append(blocks, markdownSection(msg.Text))
+	}
+
+	return &notifier.SlackMessage{
+		Text:   withMentions(msg.Text, attachment.Title),
+		Blocks: append(blocks, attachmentToBlocks(attachment)...),
+	}
+}
+
+// FormatBatchSlackBlocks formats a batch of events as a Slack Block Kit message
+func (f *Formatter) FormatBatchSlackBlocks(batch *EventBatch, opts BatchOptions) *notifier.SlackMessage {
+	msg := f.FormatBatchSlackMessage(batch, opts)
+
+	blocks := []notifier.SlackBlock{markdownSection(msg.Text)}
+	for _, attachment := range msg.Attachments {
+		blocks = append(blocks, notifier.SlackBlock{Type: notifier.BlockTypeDivider})
+		blocks = append(blocks, attachmentToBlocks(attachment)...)
+	}
+
+	return &notifier.SlackMessage{
+		Text:   msg.Text,
+		Blocks: blocks,
+	}
+}
+
+// attachmentToBlocks renders the content of an attachment as Block Kit blocks
+func attachmentToBlocks(attachment notifier.SlackAttachment) []notifier.SlackBlock {
+	var blocks []notifier.SlackBlock
+
+	if attachment.Title != "" {
+		blocks = append(blocks, markdownSection(fmt.Sprintf("*%s*", attachment.Title)))
+	}
+
+	if attachment.Text != "" {
+		blocks = append(blocks, markdownSection(attachment.Text))
+	}
+
+	// Sections accept a limited number of fields, so spread them over several sections
+	for start := 0; start < len(attachment.Fields); start += maxSectionFields {
+		end := start + maxSectionFields
+		if end > len(attachment.Fields) {
+			end = len(attachment.Fields)
+		}
+
+		var fields []notifier.SlackText
+		for _, field := range attachment.Fields[start:end] {
+			fields = append(fields, notifier.SlackText{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("*%s*\n%s", field.Title, field.Value),
+			})
+		}
+		blocks = append(blocks, notifier.SlackBlock{
+			Type:   notifier.BlockTypeSection,
+			Fields: fields,
+		})
+	}
+
+	if attachment.Timestamp != 0 {
+		blocks = append(blocks, notifier.SlackBlock{
+			Type: notifier.BlockTypeContext,
+			Elements: []notifier.SlackText{
+				{Type: "mrkdwn", Text: time.Unix(attachment.Timestamp, 0).Format(time.RFC3339)},
+			},
+		})
+	}
+
+	return blocks
+}
+
+// markdownSection creates a section block with mrkdwn text
+func markdownSection(text string) notifier.SlackBlock {
+	return notifier.SlackBlock{
+		Type: notifier.BlockTypeSection,
+		Text: &notifier.SlackText{Type: "mrkdwn", Text: text},
+	}
+}
@@ -0,0 +1,103 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackBlocks(t *testing.T) {
+	formatter := &Formatter{}
+
+	event := &watcher.Event{
+		Kind:      "Pod",
+		Namespace: "default",
+		Name:      "test-pod",
+		EventType: "DELETED",
+		Timestamp: time.Now(),
+		Status:    "Running",
+	}
+
+	msg := formatter.FormatSlackBlocks(event)
+
+	if len(msg.Attachments) != 0 {
+		t.Errorf("Expected no attachments, got %d", len(msg.Attachments))
+	}
+	if !strings.Contains(msg.Text, "🔴") || !strings.Contains(msg.Text, "default/test-pod") {
+		t.Errorf("Expected fallback text with emoji and resource, got %q", msg.Text)
+	}
+
+	// タイトル、フィールド、コンテキストの順にブロックが並ぶ
+	if len(msg.Blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d", len(msg.Blocks))
+	}
+	if msg.Blocks[0].Type != notifier.BlockTypeSection || msg.Blocks[0].Text == nil {
+		t.Error("Expected title section first")
+	}
+	if len(msg.Blocks[1].Fields) == 0 {
+		t.Error("Expected fields section")
+	}
+	if msg.Blocks[2].Type != notifier.BlockTypeContext {
+		t.Errorf("Expected context block last, got %s", msg.Blocks[2].Type)
+	}
+
+	var hasStatus bool
+	for _, field := range msg.Blocks[1].Fields {
+		if field.Text == "*ステータス*\nRunning" {
+			hasStatus = true
+		}
+	}
+	if !hasStatus {
+		t.Error("Status field not found in blocks")
+	}
+}
+
+func TestFormatBatchSlackBlocks(t *testing.T) {
+	formatter := &Formatter{}
+	now := time.Now()
+
+	batch := &EventBatch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Namespace: "default", Name: "a", EventType: "ADDED", Timestamp: now},
+			{Kind: "Deployment", Namespace: "default", Name: "b", EventType: "UPDATED", Timestamp: now},
+		},
+		StartTime: now.Add(-time.Minute),
+		EndTime:   now,
+	}
+
+	msg := formatter.FormatBatchSlackBlocks(batch, BatchOptions{Mode: BatchModeSummary})
+
+	if msg.Blocks[0].Text == nil || msg.Blocks[0].Text.Text != msg.Text {
+		t.Error("Expected first block to contain the batch header text")
+	}
+
+	var dividers int
+	for _, block := range msg.Blocks {
+		if block.Type == notifier.BlockTypeDivider {
+			dividers++
+		}
+	}
+	if dividers != 2 {
+		t.Errorf("Expected a divider per group (2), got %d", dividers)
+	}
+}
+
+func TestAttachmentToBlocks_ManyFields(t *testing.T) {
+	attachment := notifier.SlackAttachment{Title: "title"}
+	for i := 0; i < 12; i++ {
+		attachment.Fields = append(attachment.Fields, notifier.SlackAttachmentField{Title: "f", Value: "v"})
+	}
+
+	blocks := attachmentToBlocks(attachment)
+
+	// 10フィールドを超える場合はセクションを分割
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d", len(blocks))
+	}
+	if len(blocks[1].Fields) != 10 || len(blocks[2].Fields) != 2 {
+		t.Errorf("Expected fields split 10/2, got %d/%d", len(blocks[1].Fields), len(blocks[2].Fields))
+	}
+}
@@ -0,0 +1,60 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// DefaultMaxDiffLines is the default number of field changes listed per event
+const DefaultMaxDiffLines = 10
+
+// SetMaxDiffLines sets the maximum number of field changes listed per event.
+// With 0, field changes are not listed.
+func (f *Formatter) SetMaxDiffLines(n int) {
+	if n == 0 {
+		n = -1
+	}
+	f.maxDiffLines = n
+}
+
+// formatChanges renders field changes as lines like "replicas: 3 → 5"
+func (f *Formatter) formatChanges(changes []watcher.FieldChange) []string {
+	limit := f.maxDiffLines
+	if limit == 0 {
+		limit = DefaultMaxDiffLines
+	}
+
+	var lines []string
+	for i, c := range changes {
+		if i >= limit {
+			lines = append(lines, f.labelf(LabelMoreChanges, len(changes)-limit))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• %s: `%s` → `%s`", c.Field, diffValue(c.Old), diffValue(c.New)))
+	}
+	return lines
+}
+
+// diffValue returns a placeholder for empty values so that additions and removals stay readable
+func diffValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+// changesField returns a Slack field listing the event's field changes, or false if there are none
+func (f *Formatter) changesField(event *watcher.Event) (notifier.SlackAttachmentField, bool) {
+	if len(event.Changes) == 0 || f.maxDiffLines < 0 {
+		return notifier.SlackAttachmentField{}, false
+	}
+
+	return notifier.SlackAttachmentField{
+		Title: f.label(LabelChanges),
+		Value: strings.Join(f.formatChanges(event.Changes), "\n"),
+		Short: false,
+	}, true
+}
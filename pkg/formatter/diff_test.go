@@ -0,0 +1,81 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_Changes(t *testing.T) {
+	formatter := &Formatter{}
+
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "Deployment",
+		Namespace: "default",
+		Name:      "app",
+		EventType: "UPDATED",
+		Timestamp: time.Now(),
+		Changes: []watcher.FieldChange{
+			{Field: "replicas", Old: "3", New: "5"},
+			{Field: "image[app]", Old: "app:v1", New: "app:v2"},
+		},
+	})
+
+	var changes string
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "変更内容" {
+			changes = field.Value
+		}
+	}
+
+	expected := "• replicas: `3` → `5`\n• image[app]: `app:v1` → `app:v2`"
+	if changes != expected {
+		t.Errorf("Changes field = %q, want %q", changes, expected)
+	}
+}
+
+func TestFormatChanges_MaxDiffLines(t *testing.T) {
+	formatter := &Formatter{}
+	formatter.SetMaxDiffLines(2)
+
+	changes := []watcher.FieldChange{
+		{Field: "a", Old: "1", New: "2"},
+		{Field: "b", Old: "", New: "x"},
+		{Field: "c", Old: "1", New: "2"},
+		{Field: "d", Old: "1", New: "2"},
+	}
+
+	lines := formatter.formatChanges(changes)
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %v", len(lines), lines)
+	}
+	if !strings.Contains(lines[1], "`-` → `x`") {
+		t.Errorf("Expected empty value placeholder, got %q", lines[1])
+	}
+	if lines[2] != "... 他2件" {
+		t.Errorf("Expected truncation marker, got %q", lines[2])
+	}
+}
+
+func TestFormatSlackMessage_ChangesDisabled(t *testing.T) {
+	formatter := &Formatter{}
+	// 0を指定すると変更内容のフィールドを表示しない
+	formatter.SetMaxDiffLines(0)
+
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "Deployment",
+		Namespace: "default",
+		Name:      "app",
+		EventType: "UPDATED",
+		Timestamp: time.Now(),
+		Changes:   []watcher.FieldChange{{Field: "replicas", Old: "3", New: "5"}},
+	})
+
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "変更内容" {
+			t.Errorf("Expected no changes field, got %q", field.Value)
+		}
+	}
+}
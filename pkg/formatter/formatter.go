@@ -4,6 +4,7 @@ package formatter
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -23,13 +24,38 @@ const (
 	BatchModeSummary BatchMode = "summary"
 	// BatchModeSmart uses smart grouping based on config
 	BatchModeSmart BatchMode = "smart"
+	// BatchModeStory shows correlated events as a timeline about one resource
+	BatchModeStory BatchMode = "story"
 )
 
+// GroupBy represents how batched events are organized in a message
+type GroupBy string
+
+// Group by constants
+const (
+	// GroupByNone groups events by Kind and EventType only
+	GroupByNone GroupBy = ""
+	// GroupByNamespace groups events per namespace first, then by Kind and EventType
+	GroupByNamespace GroupBy = "namespace"
+)
+
+// BatchOptions controls how a batch of events is rendered
+type BatchOptions struct {
+	Mode              BatchMode
+	MaxEventsPerGroup int      // Maximum events per group to show in detail (smart mode)
+	AlwaysShowDetails []string // Event types that are always shown in detail
+	GroupBy           GroupBy
+	SummaryStats      bool   // Prepend a statistics header (top kinds, busiest namespace, event type counts)
+	Subject           string // Resource the events are about, e.g. "Deployment default/api" (story mode)
+}
+
 // EventBatch represents a batch of events with timing info
 type EventBatch struct {
 	Events    []*watcher.Event
 	StartTime time.Time
 	EndTime   time.Time
+	// UpdateCounts holds how many UPDATED events were collapsed into an event (only when more than one)
+	UpdateCounts map[*watcher.Event]int
 }
 
 // EventGroup represents events grouped by resource and event type
@@ -41,12 +67,19 @@ type EventGroup struct {
 
 // Formatter formats events using Go templates
 type Formatter struct {
-	tmpl *template.Template
+	tmpl           *template.Template
+	eventTypeTmpls map[string]*template.Template // Per-event-type template overrides
+	labels         map[string]string             // Message labels for the configured locale
+	links          []linkTemplate                // Link URL templates attached to notifications
+	maxDiffLines   int                           // Max field changes listed per event; 0 uses the default, negative lists none
+	limits         Limits                        // Attachment size limits
+	mentions       []MentionRule                 // Conditional mentions
+	threadKey      *template.Template            // Slack thread group key; nil for one thread per resource
 }
 
 // NewFormatter creates a new Formatter with the given template string
 func NewFormatter(templateStr string) (*Formatter, error) {
-	tmpl, err := template.New("message").Parse(templateStr)
+	tmpl, err := template.New("message").Funcs(templateFuncs()).Parse(templateStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
@@ -56,29 +89,158 @@ func NewFormatter(templateStr string) (*Formatter, error) {
 	}, nil
 }
 
+// SetEventTypeTemplate sets a template used instead of the default for the given event type
+func (f *Formatter) SetEventTypeTemplate(eventType, templateStr string) error {
+	tmpl, err := template.New("message-" + eventType).Funcs(templateFuncs()).Parse(templateStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse template for %s: %w", eventType, err)
+	}
+
+	if f.eventTypeTmpls == nil {
+		f.eventTypeTmpls = make(map[string]*template.Template)
+	}
+	f.eventTypeTmpls[eventType] = tmpl
+
+	return nil
+}
+
+// templateFor returns the template for the given event type, falling back to the default
+func (f *Formatter) templateFor(eventType string) *template.Template {
+	if tmpl, ok := f.eventTypeTmpls[eventType]; ok {
+		return tmpl
+	}
+	return f.tmpl
+}
+
+// RenderedTemplate is the output of a single configured template
+type RenderedTemplate struct {
+	Name   string
+	Output string
+}
+
+// RenderTemplates renders the default template, every per-event-type override and
+// every link template against the event, returning the first execution error.
+// Per-event-type overrides are rendered with the event type set to their own type.
+func (f *Formatter) RenderTemplates(event *watcher.Event) ([]RenderedTemplate, error) {
+	var rendered []RenderedTemplate
+	execute := func(name string, tmpl *template.Template, data TemplateData) error {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		rendered = append(rendered, RenderedTemplate{Name: name, Output: buf.String()})
+		return nil
+	}
+
+	data := newTemplateData(event)
+	if err := execute("template", f.tmpl, data); err != nil {
+		return nil, err
+	}
+
+	eventTypes := make([]string, 0, len(f.eventTypeTmpls))
+	for eventType := range f.eventTypeTmpls {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+	for _, eventType := range eventTypes {
+		typed := data
+		typed.EventType = eventType
+		if err := execute("templates."+eventType, f.eventTypeTmpls[eventType], typed); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, link := range f.links {
+		if err := execute("links."+link.name, link.tmpl, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return rendered, nil
+}
+
 // TemplateData represents data available in templates
 type TemplateData struct {
+	Cluster   string
 	Kind      string
 	Namespace string
 	Name      string
 	EventType string
 	Timestamp string
+	CreatedAt string // Empty if unknown
 	Labels    map[string]string
+	OwnerKind string // Top-level workload of the resource, or the resource itself
+	OwnerName string
+
+	// Additional information
+	Reason       string
+	Message      string
+	Status       string
+	Containers   []watcher.ContainerInfo
+	Replicas     *watcher.ReplicaInfo
+	ServiceType  string
+	Addresses    []string
+	Logs         *watcher.ContainerLogs
+	Warnings     []watcher.RelatedEvent
+	Scaling      *watcher.ScalingInfo
+	Job          *watcher.JobInfo
+	CronJob      *watcher.CronJobInfo
+	Volume       *watcher.VolumeInfo
+	Rollback     *watcher.RollbackInfo
+	Manifest     string
+	ManifestDiff string
+	Severity     *watcher.Severity // nil unless severity is enabled
 }
 
-// Format formats an event using the configured template
-func (f *Formatter) Format(event *watcher.Event) (string, error) {
-	data := TemplateData{
+// newTemplateData creates template data from an event
+func newTemplateData(event *watcher.Event) TemplateData {
+	ownerKind, ownerName := event.Workload()
+	return TemplateData{
+		Cluster:   event.Cluster,
 		Kind:      event.Kind,
 		Namespace: event.Namespace,
 		Name:      event.Name,
 		EventType: event.EventType,
 		Timestamp: event.Timestamp.Format(time.RFC3339),
+		CreatedAt: formatOptionalTime(event.CreatedAt),
 		Labels:    event.Labels,
+		OwnerKind: ownerKind,
+		OwnerName: ownerName,
+
+		Reason:       event.Reason,
+		Message:      event.Message,
+		Status:       event.Status,
+		Containers:   event.Containers,
+		Replicas:     event.Replicas,
+		ServiceType:  event.ServiceType,
+		Addresses:    event.Addresses,
+		Logs:         event.Logs,
+		Warnings:     event.Warnings,
+		Scaling:      event.Scaling,
+		Job:          event.Job,
+		CronJob:      event.CronJob,
+		Volume:       event.Volume,
+		Rollback:     event.Rollback,
+		Manifest:     event.Manifest,
+		ManifestDiff: event.ManifestDiff,
+		Severity:     event.Severity,
 	}
+}
+
+// formatOptionalTime formats t as RFC3339, or returns an empty string for the zero time
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Format formats an event using the configured template
+func (f *Formatter) Format(event *watcher.Event) (string, error) {
+	data := newTemplateData(event)
 
 	var buf bytes.Buffer
-	if err := f.tmpl.Execute(&buf, data); err != nil {
+	if err := f.templateFor(event.EventType).Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
 
@@ -91,17 +253,17 @@ func (f *Formatter) FormatSlackMessage(event *watcher.Event) *notifier.SlackMess
 	color := getEventColor(event.EventType)
 
 	// Create title
-	title := fmt.Sprintf("[%s] %s/%s", event.Kind, event.Namespace, event.Name)
+	title := withCluster(event.Cluster, fmt.Sprintf("[%s] %s/%s", event.Kind, event.Namespace, event.Name))
 
 	// Create fields
 	fields := []notifier.SlackAttachmentField{
 		{
-			Title: "イベントタイプ",
+			Title: f.label(LabelEventType),
 			Value: event.EventType,
 			Short: true,
 		},
 		{
-			Title: "時刻",
+			Title: f.label(LabelTime),
 			Value: event.Timestamp.Format(time.RFC3339),
 			Short: true,
 		},
@@ -110,7 +272,7 @@ func (f *Formatter) FormatSlackMessage(event *watcher.Event) *notifier.SlackMess
 	// Add status if available
 	if event.Status != "" {
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "ステータス",
+			Title: f.label(LabelStatus),
 			Value: event.Status,
 			Short: true,
 		})
@@ -119,31 +281,53 @@ func (f *Formatter) FormatSlackMessage(event *watcher.Event) *notifier.SlackMess
 	// Add service type for services
 	if event.ServiceType != "" {
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "サービスタイプ",
+			Title: f.label(LabelServiceType),
 			Value: event.ServiceType,
 			Short: true,
 		})
 	}
 
+	// Add external addresses for LoadBalancer Services and Ingresses
+	if len(event.Addresses) > 0 {
+		fields = append(fields, notifier.SlackAttachmentField{
+			Title: f.label(LabelAddresses),
+			Value: strings.Join(event.Addresses, ", "),
+			Short: false,
+		})
+	}
+
 	// Add replica information if available
 	if event.Replicas != nil {
 		replicaInfo := fmt.Sprintf("Desired: %d, Ready: %d, Current: %d",
 			event.Replicas.Desired, event.Replicas.Ready, event.Replicas.Current)
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "レプリカ",
+			Title: f.label(LabelReplicas),
 			Value: replicaInfo,
 			Short: false,
 		})
 	}
 
+	// Add replicas, metrics and conditions for autoscalers
+	fields = append(fields, f.scalingFields(event)...)
+
+	// Add progress and failed Pods for Jobs
+	fields = append(fields, f.jobFields(event)...)
+
+	// Add the schedule and runs for CronJobs
+	if field, ok := f.cronJobField(event); ok {
+		fields = append(fields, field)
+	}
+
+	// Add the storage class and sizes for PersistentVolumeClaims
+	if field, ok := f.volumeField(event); ok {
+		fields = append(fields, field)
+	}
+
 	// Add container information if available
 	if len(event.Containers) > 0 {
-		var containerInfos []string
-		for _, c := range event.Containers {
-			containerInfos = append(containerInfos, fmt.Sprintf("• %s: `%s`", c.Name, c.Image))
-		}
+		containerInfos := f.containerLines(event.Containers, f.maxContainers())
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "コンテナ",
+			Title: f.label(LabelContainers),
 			Value: strings.Join(containerInfos, "\n"),
 			Short: false,
 		})
@@ -152,7 +336,7 @@ func (f *Formatter) FormatSlackMessage(event *watcher.Event) *notifier.SlackMess
 	// Add reason if available
 	if event.Reason != "" {
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "理由",
+			Title: f.label(LabelReason),
 			Value: event.Reason,
 			Short: false,
 		})
@@ -161,20 +345,51 @@ func (f *Formatter) FormatSlackMessage(event *watcher.Event) *notifier.SlackMess
 	// Add message if available
 	if event.Message != "" {
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "メッセージ",
+			Title: f.label(LabelMessage),
 			Value: event.Message,
 			Short: false,
 		})
 	}
 
+	// Add the Warning Events of the resource if attached
+	if field, ok := f.warningsField(event); ok {
+		fields = append(fields, field)
+	}
+
+	// Add the logs of the failing container if attached
+	if field, ok := f.logsField(event); ok {
+		fields = append(fields, field)
+	}
+
+	// Add field changes for updates
+	if field, ok := f.changesField(event); ok {
+		fields = append(fields, field)
+	}
+
+	// Add the diff of the old and new manifest if attached
+	if field, ok := f.manifestDiffField(event); ok {
+		fields = append(fields, field)
+	}
+
+	// Add the manifest of the object if attached
+	if field, ok := f.manifestField(event); ok {
+		fields = append(fields, field)
+	}
+
+	// Add dashboard links if configured
+	if field, ok := f.linksField(event); ok {
+		fields = append(fields, field)
+	}
+
 	attachment := notifier.SlackAttachment{
 		Color:     color,
 		Title:     title,
-		Fields:    fields,
+		Fields:    f.truncateFields(fields),
 		Timestamp: event.Timestamp.Unix(),
 	}
 
 	return &notifier.SlackMessage{
+		Text:        f.mentionsFor(event),
 		Attachments: []notifier.SlackAttachment{attachment},
 	}
 }
@@ -186,13 +401,21 @@ func getEventColor(eventType string) string {
 		return "good" // green
 	case "UPDATED":
 		return "warning" // yellow
-	case "DELETED":
+	case "DELETED", watcher.EventTypeWarning:
 		return "danger" // red
 	default:
 		return "#808080" // gray
 	}
 }
 
+// withCluster prefixes text with the cluster name, if known
+func withCluster(cluster, text string) string {
+	if cluster == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s] %s", cluster, text)
+}
+
 // getEventEmoji returns the emoji for an event type
 func getEventEmoji(eventType string) string {
 	switch eventType {
@@ -202,25 +425,56 @@ func getEventEmoji(eventType string) string {
 		return "🟡"
 	case "DELETED":
 		return "🔴"
+	case watcher.EventTypeWarning:
+		return "⚠️"
 	default:
 		return "📌"
 	}
 }
 
 // FormatBatchSlackMessage formats a batch of events as a Slack message
-func (f *Formatter) FormatBatchSlackMessage(batch *EventBatch, mode BatchMode, maxEventsPerGroup int, alwaysShowDetails []string) *notifier.SlackMessage {
+func (f *Formatter) FormatBatchSlackMessage(batch *EventBatch, opts BatchOptions) *notifier.SlackMessage {
+	if opts.Mode == BatchModeStory {
+		return f.formatStory(batch, opts.Subject)
+	}
+
 	totalEvents := len(batch.Events)
 	duration := batch.EndTime.Sub(batch.StartTime)
 
-	// Group events by Kind and EventType
-	groups := groupEvents(batch.Events)
-
 	// Determine if we should use summary mode
-	useSummary := mode == BatchModeSummary || (mode == BatchModeSmart && totalEvents > 20)
+	useSummary := opts.Mode == BatchModeSummary || (opts.Mode == BatchModeSmart && totalEvents > 20)
 
 	// Create main text
-	mainText := fmt.Sprintf("📦 *過去%.0f秒間の変更 (%d件)*", duration.Seconds(), totalEvents)
+	mainText := f.labelf(LabelBatchHeader, duration.Seconds(), totalEvents)
+	if len(batch.Events) > 0 {
+		mainText = withCluster(batch.Events[0].Cluster, mainText)
+	}
+
+	var attachments []notifier.SlackAttachment
+
+	if opts.SummaryStats && totalEvents > 0 {
+		attachments = append(attachments, f.buildStatsAttachment(ComputeBatchStats(batch.Events)))
+	}
+
+	if opts.GroupBy == GroupByNamespace {
+		for _, ns := range groupByNamespace(batch.Events) {
+			attachments = append(attachments, notifier.SlackAttachment{
+				Title: fmt.Sprintf("📁 %s (%s)", ns.Namespace, f.labelf(LabelEventCount, len(ns.Events))),
+			})
+			attachments = append(attachments, f.buildGroupAttachments(groupEvents(ns.Events), batch.UpdateCounts, useSummary, opts)...)
+		}
+	} else {
+		attachments = append(attachments, f.buildGroupAttachments(groupEvents(batch.Events), batch.UpdateCounts, useSummary, opts)...)
+	}
 
+	return &notifier.SlackMessage{
+		Text:        withMentions(f.mentionsFor(batch.Events...), mainText),
+		Attachments: attachments,
+	}
+}
+
+// buildGroupAttachments builds Slack attachments for Kind/EventType groups
+func (f *Formatter) buildGroupAttachments(groups []EventGroup, updateCounts map[*watcher.Event]int, useSummary bool, opts BatchOptions) []notifier.SlackAttachment {
 	var attachments []notifier.SlackAttachment
 
 	for _, group := range groups {
@@ -229,35 +483,54 @@ func (f *Formatter) FormatBatchSlackMessage(batch *EventBatch, mode BatchMode, m
 		color := getEventColor(group.EventType)
 
 		// Check if we should show details for this group
-		showDetails := !useSummary && shouldShowDetailsForGroup(mode, group.EventType, eventCount, maxEventsPerGroup, alwaysShowDetails)
+		showDetails := !useSummary && shouldShowDetailsForGroup(opts.Mode, group.EventType, eventCount, opts.MaxEventsPerGroup, opts.AlwaysShowDetails)
 
 		if showDetails {
 			// Detailed mode: show individual events
 			for _, event := range group.Events {
 				title := fmt.Sprintf("%s [%s] %s/%s", emoji, event.Kind, event.Namespace, event.Name)
-				fields := buildEventFields(event)
+				fields := f.buildEventFields(event)
+
+				// Add field changes for updates
+				if field, ok := f.changesField(event); ok {
+					fields = append(fields, field)
+				}
+
+				// Add dashboard links if configured
+				if field, ok := f.linksField(event); ok {
+					fields = append(fields, field)
+				}
+
+				// Show how many updates were collapsed into this entry
+				if count := updateCounts[event]; count > 1 {
+					fields = append(fields, notifier.SlackAttachmentField{
+						Title: f.label(LabelUpdateCount),
+						Value: f.labelf(LabelUpdateTimes, count),
+						Short: true,
+					})
+				}
 
 				attachments = append(attachments, notifier.SlackAttachment{
 					Color:     color,
 					Title:     title,
-					Fields:    fields,
+					Fields:    f.truncateFields(fields),
 					Timestamp: event.Timestamp.Unix(),
 				})
 			}
 		} else {
 			// Summary mode: group similar events
-			title := fmt.Sprintf("%s %s (%d件)", emoji, group.Kind, eventCount)
+			title := fmt.Sprintf("%s %s (%s)", emoji, group.Kind, f.labelf(LabelEventCount, eventCount))
 
 			// Create summary fields
 			fields := []notifier.SlackAttachmentField{
 				{
-					Title: "イベントタイプ",
+					Title: f.label(LabelEventType),
 					Value: group.EventType,
 					Short: true,
 				},
 				{
-					Title: "件数",
-					Value: fmt.Sprintf("%d件", eventCount),
+					Title: f.label(LabelCount),
+					Value: f.labelf(LabelEventCount, eventCount),
 					Short: true,
 				},
 			}
@@ -266,14 +539,18 @@ func (f *Formatter) FormatBatchSlackMessage(batch *EventBatch, mode BatchMode, m
 			var names []string
 			for i, event := range group.Events {
 				if i >= 10 {
-					names = append(names, fmt.Sprintf("... 他%d件", eventCount-10))
+					names = append(names, f.labelf(LabelMoreEvents, eventCount-10))
 					break
 				}
-				names = append(names, event.Name)
+				if count := updateCounts[event]; count > 1 {
+					names = append(names, fmt.Sprintf("%s (×%d)", event.Name, count))
+				} else {
+					names = append(names, event.Name)
+				}
 			}
 
 			fields = append(fields, notifier.SlackAttachmentField{
-				Title: "リソース",
+				Title: f.label(LabelResources),
 				Value: strings.Join(names, ", "),
 				Short: false,
 			})
@@ -286,10 +563,39 @@ func (f *Formatter) FormatBatchSlackMessage(batch *EventBatch, mode BatchMode, m
 		}
 	}
 
-	return &notifier.SlackMessage{
-		Text:        mainText,
-		Attachments: attachments,
+	return attachments
+}
+
+// NamespaceGroup represents events that belong to the same namespace
+type NamespaceGroup struct {
+	Namespace string
+	Events    []*watcher.Event
+}
+
+// groupByNamespace groups events by namespace, sorted by namespace name
+func groupByNamespace(events []*watcher.Event) []NamespaceGroup {
+	groupMap := make(map[string]*NamespaceGroup)
+
+	for _, event := range events {
+		if group, exists := groupMap[event.Namespace]; exists {
+			group.Events = append(group.Events, event)
+		} else {
+			groupMap[event.Namespace] = &NamespaceGroup{
+				Namespace: event.Namespace,
+				Events:    []*watcher.Event{event},
+			}
+		}
+	}
+
+	groups := make([]NamespaceGroup, 0, len(groupMap))
+	for _, group := range groupMap {
+		groups = append(groups, *group)
 	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Namespace < groups[j].Namespace
+	})
+
+	return groups
 }
 
 // groupEvents groups events by Kind and EventType
@@ -341,15 +647,15 @@ func shouldShowDetailsForGroup(mode BatchMode, eventType string, eventCount int,
 }
 
 // buildEventFields builds Slack attachment fields for an event
-func buildEventFields(event *watcher.Event) []notifier.SlackAttachmentField {
+func (f *Formatter) buildEventFields(event *watcher.Event) []notifier.SlackAttachmentField {
 	fields := []notifier.SlackAttachmentField{
 		{
-			Title: "イベントタイプ",
+			Title: f.label(LabelEventType),
 			Value: event.EventType,
 			Short: true,
 		},
 		{
-			Title: "時刻",
+			Title: f.label(LabelTime),
 			Value: event.Timestamp.Format(time.RFC3339),
 			Short: true,
 		},
@@ -358,7 +664,7 @@ func buildEventFields(event *watcher.Event) []notifier.SlackAttachmentField {
 	// Add status if available
 	if event.Status != "" {
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "ステータス",
+			Title: f.label(LabelStatus),
 			Value: event.Status,
 			Short: true,
 		})
@@ -369,24 +675,17 @@ func buildEventFields(event *watcher.Event) []notifier.SlackAttachmentField {
 		replicaInfo := fmt.Sprintf("Desired: %d, Ready: %d, Current: %d",
 			event.Replicas.Desired, event.Replicas.Ready, event.Replicas.Current)
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "レプリカ",
+			Title: f.label(LabelReplicas),
 			Value: replicaInfo,
 			Short: false,
 		})
 	}
 
-	// Add container information if available (limit to 3)
+	// Add container information if available
 	if len(event.Containers) > 0 {
-		var containerInfos []string
-		for i, c := range event.Containers {
-			if i >= 3 {
-				containerInfos = append(containerInfos, fmt.Sprintf("... 他%d個", len(event.Containers)-3))
-				break
-			}
-			containerInfos = append(containerInfos, fmt.Sprintf("• %s: `%s`", c.Name, c.Image))
-		}
+		containerInfos := f.containerLines(event.Containers, min(batchMaxContainers, f.maxContainers()))
 		fields = append(fields, notifier.SlackAttachmentField{
-			Title: "コンテナ",
+			Title: f.label(LabelContainers),
 			Value: strings.Join(containerInfos, "\n"),
 			Short: false,
 		})
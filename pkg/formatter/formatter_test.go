@@ -533,3 +533,170 @@ func TestFormatSlackMessage_ServiceType(t *testing.T) {
 		t.Error("ServiceType field not found or incorrect")
 	}
 }
+
+func TestFormatBatchSlackMessage_GroupByNamespace(t *testing.T) {
+	formatter := &Formatter{}
+	now := time.Now()
+
+	batch := &EventBatch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Namespace: "prod", Name: "a", EventType: "ADDED", Timestamp: now},
+			{Kind: "Pod", Namespace: "dev", Name: "b", EventType: "ADDED", Timestamp: now},
+			{Kind: "Pod", Namespace: "prod", Name: "c", EventType: "ADDED", Timestamp: now},
+		},
+		StartTime: now.Add(-time.Minute),
+		EndTime:   now,
+	}
+
+	msg := formatter.FormatBatchSlackMessage(batch, BatchOptions{
+		Mode:    BatchModeSummary,
+		GroupBy: GroupByNamespace,
+	})
+
+	// Namespaceヘッダーがソート順に並んでいるか確認
+	var headers []string
+	for _, a := range msg.Attachments {
+		if strings.HasPrefix(a.Title, "📁") {
+			headers = append(headers, a.Title)
+		}
+	}
+
+	expected := []string{"📁 dev (1件)", "📁 prod (2件)"}
+	if len(headers) != len(expected) {
+		t.Fatalf("Expected %d namespace headers, got %d: %v", len(expected), len(headers), headers)
+	}
+	for i := range expected {
+		if headers[i] != expected[i] {
+			t.Errorf("Header[%d] = %q, want %q", i, headers[i], expected[i])
+		}
+	}
+
+	// ヘッダー + 各Namespaceのサマリーで4つのAttachment
+	if len(msg.Attachments) != 4 {
+		t.Errorf("Expected 4 attachments, got %d", len(msg.Attachments))
+	}
+}
+
+func TestFormatBatchSlackMessage_NoGrouping(t *testing.T) {
+	formatter := &Formatter{}
+	now := time.Now()
+
+	batch := &EventBatch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Namespace: "prod", Name: "a", EventType: "DELETED", Timestamp: now},
+			{Kind: "Pod", Namespace: "dev", Name: "b", EventType: "DELETED", Timestamp: now},
+		},
+		StartTime: now.Add(-time.Minute),
+		EndTime:   now,
+	}
+
+	msg := formatter.FormatBatchSlackMessage(batch, BatchOptions{Mode: BatchModeDetailed})
+
+	if len(msg.Attachments) != 2 {
+		t.Errorf("Expected 2 detailed attachments, got %d", len(msg.Attachments))
+	}
+	for _, a := range msg.Attachments {
+		if strings.HasPrefix(a.Title, "📁") {
+			t.Errorf("Unexpected namespace header %q without groupBy", a.Title)
+		}
+	}
+}
+
+func TestFormatBatchSlackMessage_UpdateCounts(t *testing.T) {
+	formatter := &Formatter{}
+	now := time.Now()
+
+	event := &watcher.Event{Kind: "Deployment", Namespace: "default", Name: "app", EventType: "UPDATED", Timestamp: now}
+	batch := &EventBatch{
+		Events:       []*watcher.Event{event},
+		StartTime:    now.Add(-time.Minute),
+		EndTime:      now,
+		UpdateCounts: map[*watcher.Event]int{event: 4},
+	}
+
+	// 詳細表示では更新回数フィールドが追加される
+	msg := formatter.FormatBatchSlackMessage(batch, BatchOptions{Mode: BatchModeDetailed})
+	var found bool
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "更新回数" && field.Value == "4回" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Update count field not found in detailed attachment")
+	}
+
+	// サマリー表示ではリソース名に回数が付与される
+	msg = formatter.FormatBatchSlackMessage(batch, BatchOptions{Mode: BatchModeSummary})
+	var resources string
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "リソース" {
+			resources = field.Value
+		}
+	}
+	if resources != "app (×4)" {
+		t.Errorf("Expected resource list %q, got %q", "app (×4)", resources)
+	}
+}
+
+func TestFormat_EventTypeTemplate(t *testing.T) {
+	formatter, err := NewFormatter("{{ .Kind }} {{ .EventType }}")
+	if err != nil {
+		t.Fatalf("NewFormatter() error = %v", err)
+	}
+
+	if err := formatter.SetEventTypeTemplate("DELETED", "🔴 {{ .Name }} deleted"); err != nil {
+		t.Fatalf("SetEventTypeTemplate() error = %v", err)
+	}
+
+	// 上書きテンプレートが使用される
+	result, _ := formatter.Format(&watcher.Event{Kind: "Pod", Name: "web", EventType: "DELETED"})
+	if result != "🔴 web deleted" {
+		t.Errorf("Format() = %q, want %q", result, "🔴 web deleted")
+	}
+
+	// 上書きがない場合はデフォルトテンプレートにフォールバック
+	result, _ = formatter.Format(&watcher.Event{Kind: "Pod", Name: "web", EventType: "ADDED"})
+	if result != "Pod ADDED" {
+		t.Errorf("Format() = %q, want %q", result, "Pod ADDED")
+	}
+}
+
+func TestSetEventTypeTemplate_Invalid(t *testing.T) {
+	formatter, _ := NewFormatter("{{ .Kind }}")
+	if err := formatter.SetEventTypeTemplate("DELETED", "{{ .Kind"); err == nil {
+		t.Error("SetEventTypeTemplate() error = nil, want error for invalid template")
+	}
+}
+
+func TestFormatSlackMessage_ClusterName(t *testing.T) {
+	formatter := &Formatter{}
+
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Cluster:   "prod-tokyo",
+		Kind:      "Pod",
+		Namespace: "default",
+		Name:      "web",
+		EventType: "ADDED",
+		Timestamp: time.Now(),
+	})
+
+	if msg.Attachments[0].Title != "[prod-tokyo] [Pod] default/web" {
+		t.Errorf("Expected cluster name in title, got %q", msg.Attachments[0].Title)
+	}
+}
+
+func TestFormatBatchSlackMessage_ClusterName(t *testing.T) {
+	formatter := &Formatter{}
+	now := time.Now()
+
+	msg := formatter.FormatBatchSlackMessage(&EventBatch{
+		Events:    []*watcher.Event{{Cluster: "prod-tokyo", Kind: "Pod", Name: "web", EventType: "ADDED", Timestamp: now}},
+		StartTime: now,
+		EndTime:   now,
+	}, BatchOptions{})
+
+	if !strings.HasPrefix(msg.Text, "[prod-tokyo] 📦") {
+		t.Errorf("Expected cluster name in batch header, got %q", msg.Text)
+	}
+}
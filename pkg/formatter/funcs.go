@@ -0,0 +1,215 @@
+package formatter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs returns the functions available in message templates.
+// Names and argument order follow Sprig so templates stay portable.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"trim":      strings.TrimSpace,
+		"replace":   func(old, repl, s string) string { return strings.ReplaceAll(s, old, repl) },
+		"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"quote":     func(s string) string { return fmt.Sprintf("%q", s) },
+		"default":   defaultValue,
+		"trunc":     trunc,
+		"join":      join,
+		"ternary":   ternary,
+		"date":      date,
+
+		// Durations
+		"age":           age,
+		"since":         since,
+		"humanDuration": humanDuration,
+	}
+}
+
+// defaultValue returns def if given is empty, otherwise given
+func defaultValue(def interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return def
+	}
+	return given[0]
+}
+
+// isEmpty reports whether v is nil or the zero value of its type, or an empty collection
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// trunc truncates s to n runes. A negative n keeps the last -n runes.
+func trunc(n int, s string) string {
+	runes := []rune(s)
+	if n >= 0 {
+		if len(runes) > n {
+			return string(runes[:n])
+		}
+		return s
+	}
+	if len(runes) > -n {
+		return string(runes[len(runes)+n:])
+	}
+	return s
+}
+
+// join joins the elements of a list with sep
+func join(sep string, list interface{}) string {
+	switch l := list.(type) {
+	case []string:
+		return strings.Join(l, sep)
+	case nil:
+		return ""
+	}
+
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Sprint(list)
+	}
+
+	parts := make([]string, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep)
+}
+
+// ternary returns trueVal if cond is true, otherwise falseVal
+func ternary(trueVal, falseVal interface{}, cond bool) interface{} {
+	if cond {
+		return trueVal
+	}
+	return falseVal
+}
+
+// date formats a time using a Go layout. The value may be a time.Time or an RFC3339 string.
+func date(layout string, value interface{}) string {
+	t, ok := toTime(value)
+	if !ok {
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+	return t.Format(layout)
+}
+
+// toTime converts a time.Time, *time.Time or RFC3339 string to a time
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, true
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+// since returns the time elapsed since value, or zero if value is not a time
+func since(value interface{}) time.Duration {
+	t, ok := toTime(value)
+	if !ok || t.IsZero() {
+		return 0
+	}
+	return time.Since(t)
+}
+
+// age returns the humanized time elapsed since start, e.g. "3h12m".
+// If end is given, the duration between start and end is returned instead.
+func age(start interface{}, end ...interface{}) string {
+	from, ok := toTime(start)
+	if !ok || from.IsZero() {
+		return ""
+	}
+
+	to := time.Now()
+	if len(end) > 0 {
+		if to, ok = toTime(end[0]); !ok {
+			return ""
+		}
+	}
+	return humanDuration(to.Sub(from))
+}
+
+// humanDuration formats a duration with its two most significant units, e.g. "3h12m" or "2d4h".
+// The value may be a time.Duration, a number of seconds or a Go duration string.
+func humanDuration(value interface{}) string {
+	var d time.Duration
+	switch v := value.(type) {
+	case time.Duration:
+		d = v
+	case int:
+		d = time.Duration(v) * time.Second
+	case int64:
+		d = time.Duration(v) * time.Second
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return v
+		}
+		d = parsed
+	default:
+		return fmt.Sprint(value)
+	}
+
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	d = d.Round(time.Second)
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := int(d % time.Minute / time.Second)
+
+	var s string
+	switch {
+	case days > 0:
+		s = joinUnits(days, "d", hours, "h")
+	case hours > 0:
+		s = joinUnits(hours, "h", minutes, "m")
+	case minutes > 0:
+		s = joinUnits(minutes, "m", seconds, "s")
+	default:
+		s = fmt.Sprintf("%ds", seconds)
+	}
+	return sign + s
+}
+
+// joinUnits formats a major and minor unit pair, omitting a zero minor unit
+func joinUnits(major int, majorUnit string, minor int, minorUnit string) string {
+	if minor == 0 {
+		return fmt.Sprintf("%d%s", major, majorUnit)
+	}
+	return fmt.Sprintf("%d%s%d%s", major, majorUnit, minor, minorUnit)
+}
@@ -0,0 +1,129 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormat_TemplateFuncs(t *testing.T) {
+	timestamp := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	event := &watcher.Event{
+		Kind:      "Pod",
+		Namespace: "default",
+		Name:      "very-long-pod-name-abc123",
+		EventType: "DELETED",
+		Timestamp: timestamp,
+		Labels:    map[string]string{"app": "web"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"upper", `{{ upper .EventType }}`, "DELETED"},
+		{"lower", `{{ .Kind | lower }}`, "pod"},
+		{"default for missing label", `{{ index .Labels "team" | default "unknown" }}`, "unknown"},
+		{"default keeps value", `{{ index .Labels "app" | default "unknown" }}`, "web"},
+		{"trunc", `{{ trunc 8 .Name }}`, "very-lon"},
+		{"trunc negative", `{{ trunc -6 .Name }}`, "abc123"},
+		{"ternary", `{{ ternary "🔴" "🟢" (eq .EventType "DELETED") }}`, "🔴"},
+		{"date", `{{ date "2006-01-02 15:04" .Timestamp }}`, "2024-01-15 10:30"},
+		{"replace", `{{ replace "-" "_" .Name }}`, "very_long_pod_name_abc123"},
+		{"contains", `{{ if contains "long" .Name }}yes{{ end }}`, "yes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFormatter(tt.template)
+			if err != nil {
+				t.Fatalf("NewFormatter() error = %v", err)
+			}
+
+			result, err := f.Format(event)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Format() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	if got := join(", ", []string{"a", "b"}); got != "a, b" {
+		t.Errorf("join([]string) = %q, want %q", got, "a, b")
+	}
+	if got := join("-", []int{1, 2, 3}); got != "1-2-3" {
+		t.Errorf("join([]int) = %q, want %q", got, "1-2-3")
+	}
+	if got := join(",", nil); got != "" {
+		t.Errorf("join(nil) = %q, want empty", got)
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 30*time.Second, "12m30s"},
+		{3*time.Hour + 12*time.Minute + 40*time.Second, "3h12m"},
+		{2 * time.Hour, "2h"},
+		{52 * time.Hour, "2d4h"},
+		{-90 * time.Second, "-1m30s"},
+		{90, "1m30s"},
+		{"1h30m", "1h30m"},
+	}
+
+	for _, tt := range tests {
+		if got := humanDuration(tt.input); got != tt.expected {
+			t.Errorf("humanDuration(%v) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestFormat_DurationFuncs(t *testing.T) {
+	timestamp := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	event := &watcher.Event{
+		Kind:      "Pod",
+		Name:      "web",
+		EventType: "DELETED",
+		Timestamp: timestamp,
+		CreatedAt: timestamp.Add(-(3*time.Hour + 12*time.Minute)),
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"age between two times", `ran for {{ age .CreatedAt .Timestamp }}`, "ran for 3h12m"},
+		{"age of unknown time", `{{ age "" }}`, ""},
+		{"since is positive", `{{ if gt (since .Timestamp).Hours 1.0 }}old{{ end }}`, "old"},
+		{"humanDuration of since", `{{ if humanDuration (since .Timestamp) }}ok{{ end }}`, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFormatter(tt.template)
+			if err != nil {
+				t.Fatalf("NewFormatter() error = %v", err)
+			}
+
+			result, err := f.Format(event)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Format() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
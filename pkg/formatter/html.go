@@ -0,0 +1,90 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// htmlRenderer renders Slack mrkdwn elements as escaped HTML
+type htmlRenderer struct{}
+
+func (htmlRenderer) text(s string) string { return html.EscapeString(s) }
+func (htmlRenderer) bold(s string) string { return "<strong>" + s + "</strong>" }
+func (htmlRenderer) code(s string) string { return "<code>" + html.EscapeString(s) + "</code>" }
+func (htmlRenderer) link(target, label string) string {
+	// Links built from event and template data could use schemes such as javascript:
+	if !isWebURL(target) {
+		return html.EscapeString(label)
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(target), html.EscapeString(label))
+}
+
+// isWebURL reports whether s is an absolute http or https URL
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// FormatHTML formats an event as an HTML fragment
+func (f *Formatter) FormatHTML(event *watcher.Event) string {
+	attachment := f.FormatSlackMessage(event).Attachments[0]
+	attachment.Title = fmt.Sprintf("%s %s", getEventEmoji(event.EventType), attachment.Title)
+
+	var b strings.Builder
+	writeHTMLAttachment(&b, attachment)
+	return b.String()
+}
+
+// FormatBatchHTML formats a batch of events as an HTML fragment
+func (f *Formatter) FormatBatchHTML(batch *EventBatch, opts BatchOptions) string {
+	msg := f.FormatBatchSlackMessage(batch, opts)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<p>%s</p>\n", convertMrkdwn(msg.Text, htmlRenderer{}))
+	for _, attachment := range msg.Attachments {
+		b.WriteString("<hr>\n")
+		writeHTMLAttachment(&b, attachment)
+	}
+	return b.String()
+}
+
+// writeHTMLAttachment renders an attachment as a heading followed by a field list
+func writeHTMLAttachment(b *strings.Builder, attachment notifier.SlackAttachment) {
+	r := htmlRenderer{}
+
+	if attachment.Title != "" {
+		fmt.Fprintf(b, "<h3>%s</h3>\n", convertMrkdwn(attachment.Title, r))
+	}
+	if attachment.Text != "" {
+		fmt.Fprintf(b, "<p>%s</p>\n", strings.Join(convertLines(attachment.Text, r), "<br>"))
+	}
+
+	if len(attachment.Fields) == 0 {
+		return
+	}
+
+	b.WriteString("<ul>\n")
+	for _, field := range attachment.Fields {
+		value := strings.Join(convertLines(strings.Join(valueLines(field.Value), "\n"), r), "<br>")
+		if field.Title != "" {
+			fmt.Fprintf(b, "<li><strong>%s</strong>: %s</li>\n", html.EscapeString(field.Title), value)
+		} else {
+			fmt.Fprintf(b, "<li>%s</li>\n", value)
+		}
+	}
+	b.WriteString("</ul>\n")
+}
+
+// convertLines converts each line of a mrkdwn text separately
+func convertLines(s string, r markupRenderer) []string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = convertMrkdwn(line, r)
+	}
+	return lines
+}
@@ -0,0 +1,184 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Label keys used in formatted messages
+const (
+	LabelEventType        = "eventType"
+	LabelTime             = "time"
+	LabelStatus           = "status"
+	LabelServiceType      = "serviceType"
+	LabelAddresses        = "addresses"
+	LabelReplicas         = "replicas"
+	LabelContainers       = "containers"
+	LabelReason           = "reason"
+	LabelMessage          = "message"
+	LabelCount            = "count"
+	LabelResources        = "resources"
+	LabelUpdateCount      = "updateCount"
+	LabelSummary          = "summary"
+	LabelEventTypeCounts  = "eventTypeCounts"
+	LabelTopKinds         = "topKinds"
+	LabelBusiestNamespace = "busiestNamespace"
+	LabelLinks            = "links"
+	LabelChanges          = "changes"
+	LabelWarnings         = "warnings"
+	LabelScaling          = "scaling"
+	LabelConditions       = "conditions"
+	LabelJob              = "job"
+	LabelFailedPods       = "failedPods"
+	LabelSchedule         = "schedule"
+	LabelVolume           = "volume"
+	LabelManifest         = "manifest"
+	LabelManifestDiff     = "manifestDiff"
+
+	// Format strings
+	LabelBatchHeader    = "batchHeader"    // Seconds (%.0f) and event count (%d)
+	LabelEventCount     = "eventCount"     // Event count (%d)
+	LabelMoreEvents     = "moreEvents"     // Number of omitted events (%d)
+	LabelMoreContainers = "moreContainers" // Number of omitted containers (%d)
+	LabelUpdateTimes    = "updateTimes"    // Number of updates (%d)
+	LabelMoreChanges    = "moreChanges"    // Number of omitted field changes (%d)
+	LabelMoreFields     = "moreFields"     // Number of omitted fields (%d)
+	LabelStoryHeader    = "storyHeader"    // Resource (%s), event count (%d) and seconds (%.0f)
+	LabelLogs           = "logs"           // Container (%s)
+	LabelPreviousLogs   = "previousLogs"   // Container (%s), whose previous instance the logs are from
+)
+
+// DefaultLocale is the locale used when none is configured
+const DefaultLocale = "ja"
+
+// locales holds the built-in message labels
+var locales = map[string]map[string]string{
+	"ja": {
+		LabelEventType:        "イベントタイプ",
+		LabelTime:             "時刻",
+		LabelStatus:           "ステータス",
+		LabelServiceType:      "サービスタイプ",
+		LabelAddresses:        "外部アドレス",
+		LabelReplicas:         "レプリカ",
+		LabelContainers:       "コンテナ",
+		LabelReason:           "理由",
+		LabelMessage:          "メッセージ",
+		LabelCount:            "件数",
+		LabelResources:        "リソース",
+		LabelUpdateCount:      "更新回数",
+		LabelSummary:          "📊 サマリー",
+		LabelEventTypeCounts:  "イベントタイプ別",
+		LabelTopKinds:         "上位リソース",
+		LabelBusiestNamespace: "最多Namespace",
+		LabelLinks:            "リンク",
+		LabelChanges:          "変更内容",
+		LabelWarnings:         "警告イベント",
+		LabelScaling:          "スケーリング",
+		LabelConditions:       "条件",
+		LabelJob:              "ジョブ",
+		LabelFailedPods:       "失敗したPod",
+		LabelSchedule:         "スケジュール",
+		LabelVolume:           "ボリューム",
+		LabelManifest:         "マニフェスト",
+		LabelManifestDiff:     "マニフェストの差分",
+		LabelBatchHeader:      "📦 *過去%.0f秒間の変更 (%d件)*",
+		LabelEventCount:       "%d件",
+		LabelMoreEvents:       "... 他%d件",
+		LabelMoreContainers:   "... 他%d個",
+		LabelUpdateTimes:      "%d回",
+		LabelMoreChanges:      "... 他%d件",
+		LabelMoreFields:       "... 他%d項目",
+		LabelStoryHeader:      "🎬 *%sのロールアウト (%d件, %.0f秒間)*",
+		LabelLogs:             "ログ (%s)",
+		LabelPreviousLogs:     "前回のコンテナのログ (%s)",
+	},
+	"en": {
+		LabelEventType:        "Event Type",
+		LabelTime:             "Time",
+		LabelStatus:           "Status",
+		LabelServiceType:      "Service Type",
+		LabelAddresses:        "External Addresses",
+		LabelReplicas:         "Replicas",
+		LabelContainers:       "Containers",
+		LabelReason:           "Reason",
+		LabelMessage:          "Message",
+		LabelCount:            "Count",
+		LabelResources:        "Resources",
+		LabelUpdateCount:      "Updates",
+		LabelSummary:          "📊 Summary",
+		LabelEventTypeCounts:  "By Event Type",
+		LabelTopKinds:         "Top Kinds",
+		LabelBusiestNamespace: "Busiest Namespace",
+		LabelLinks:            "Links",
+		LabelChanges:          "Changes",
+		LabelWarnings:         "Warning Events",
+		LabelScaling:          "Scaling",
+		LabelConditions:       "Conditions",
+		LabelJob:              "Job",
+		LabelFailedPods:       "Failed Pods",
+		LabelSchedule:         "Schedule",
+		LabelVolume:           "Volume",
+		LabelManifest:         "Manifest",
+		LabelManifestDiff:     "Manifest Diff",
+		LabelBatchHeader:      "📦 *Changes in the last %.0f seconds (%d events)*",
+		LabelEventCount:       "%d events",
+		LabelMoreEvents:       "... and %d more",
+		LabelMoreContainers:   "... and %d more",
+		LabelUpdateTimes:      "%d times",
+		LabelMoreChanges:      "... and %d more",
+		LabelMoreFields:       "... and %d more fields",
+		LabelStoryHeader:      "🎬 *Rollout of %s (%d events over %.0f seconds)*",
+		LabelLogs:             "Logs (%s)",
+		LabelPreviousLogs:     "Logs of the Previous Container (%s)",
+	},
+}
+
+// SetLocale selects a built-in locale and applies label overrides on top of it
+func (f *Formatter) SetLocale(locale string, overrides map[string]string) error {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	base, ok := locales[locale]
+	if !ok {
+		return fmt.Errorf("unsupported locale: %s (supported: %s)", locale, strings.Join(SupportedLocales(), ", "))
+	}
+
+	labels := make(map[string]string, len(base))
+	for k, v := range base {
+		labels[k] = v
+	}
+	for k, v := range overrides {
+		if _, known := base[k]; !known {
+			return fmt.Errorf("unknown label key: %s", k)
+		}
+		labels[k] = v
+	}
+
+	f.labels = labels
+	return nil
+}
+
+// SupportedLocales returns the names of the built-in locales
+func SupportedLocales() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// label returns the label for a key in the configured locale
+func (f *Formatter) label(key string) string {
+	if v, ok := f.labels[key]; ok {
+		return v
+	}
+	return locales[DefaultLocale][key]
+}
+
+// labelf formats a label that is a format string
+func (f *Formatter) labelf(key string, args ...interface{}) string {
+	return fmt.Sprintf(f.label(key), args...)
+}
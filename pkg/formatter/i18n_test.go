@@ -0,0 +1,96 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestSetLocale_English(t *testing.T) {
+	formatter := &Formatter{}
+	if err := formatter.SetLocale("en", nil); err != nil {
+		t.Fatalf("SetLocale() error = %v", err)
+	}
+
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "Pod",
+		Namespace: "default",
+		Name:      "test-pod",
+		EventType: "ADDED",
+		Timestamp: time.Now(),
+		Status:    "Running",
+	})
+
+	titles := make(map[string]bool)
+	for _, field := range msg.Attachments[0].Fields {
+		titles[field.Title] = true
+	}
+
+	for _, expected := range []string{"Event Type", "Time", "Status"} {
+		if !titles[expected] {
+			t.Errorf("Expected field %q in English locale, got %v", expected, titles)
+		}
+	}
+}
+
+func TestSetLocale_Overrides(t *testing.T) {
+	formatter := &Formatter{}
+	if err := formatter.SetLocale("en", map[string]string{LabelEventType: "Action"}); err != nil {
+		t.Fatalf("SetLocale() error = %v", err)
+	}
+
+	if got := formatter.label(LabelEventType); got != "Action" {
+		t.Errorf("label(eventType) = %q, want %q", got, "Action")
+	}
+	if got := formatter.label(LabelTime); got != "Time" {
+		t.Errorf("label(time) = %q, want %q", got, "Time")
+	}
+}
+
+func TestSetLocale_Errors(t *testing.T) {
+	formatter := &Formatter{}
+
+	if err := formatter.SetLocale("fr", nil); err == nil {
+		t.Error("SetLocale() error = nil, want error for unsupported locale")
+	}
+	if err := formatter.SetLocale("en", map[string]string{"unknown": "x"}); err == nil {
+		t.Error("SetLocale() error = nil, want error for unknown label key")
+	}
+}
+
+func TestLabel_DefaultsToJapanese(t *testing.T) {
+	// ゼロ値のFormatterは日本語ラベルを使用する
+	formatter := &Formatter{}
+	if got := formatter.label(LabelEventType); got != "イベントタイプ" {
+		t.Errorf("label(eventType) = %q, want %q", got, "イベントタイプ")
+	}
+}
+
+func TestLocales_Complete(t *testing.T) {
+	// すべてのロケールが同じキーを持つことを確認
+	for name, labels := range locales {
+		for key := range locales[DefaultLocale] {
+			if _, ok := labels[key]; !ok {
+				t.Errorf("Locale %q is missing key %q", name, key)
+			}
+		}
+	}
+}
+
+func TestFormatBatchSlackMessage_EnglishHeader(t *testing.T) {
+	formatter := &Formatter{}
+	_ = formatter.SetLocale("en", nil)
+	now := time.Now()
+
+	msg := formatter.FormatBatchSlackMessage(&EventBatch{
+		Events:    []*watcher.Event{{Kind: "Pod", Name: "a", EventType: "ADDED", Timestamp: now}},
+		StartTime: now.Add(-30 * time.Second),
+		EndTime:   now,
+	}, BatchOptions{Mode: BatchModeSummary})
+
+	if !strings.Contains(msg.Text, "Changes in the last 30 seconds (1 events)") {
+		t.Errorf("Unexpected batch header %q", msg.Text)
+	}
+}
@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// jobFields renders the progress of a Job and its failed Pods, with the command
+// to read the logs of the latest one
+func (f *Formatter) jobFields(event *watcher.Event) []notifier.SlackAttachmentField {
+	j := event.Job
+	if j == nil {
+		return nil
+	}
+
+	fields := []notifier.SlackAttachmentField{{
+		Title: f.label(LabelJob),
+		Value: fmt.Sprintf("Succeeded: %d/%d, Failed: %d (BackoffLimit: %d), Active: %d",
+			j.Succeeded, j.Completions, j.Failed, j.BackoffLimit, j.Active),
+		Short: false,
+	}}
+
+	if len(j.FailedPods) > 0 {
+		lines := make([]string, 0, len(j.FailedPods)+1)
+		for _, p := range j.FailedPods {
+			lines = append(lines, fmt.Sprintf("• `%s`: %s", p.Name, p.Reason))
+		}
+		lines = append(lines, fmt.Sprintf("`kubectl logs -n %s %s`", event.Namespace, j.FailedPods[0].Name))
+		fields = append(fields, notifier.SlackAttachmentField{
+			Title: f.label(LabelFailedPods),
+			Value: strings.Join(lines, "\n"),
+			Short: false,
+		})
+	}
+	return fields
+}
+
+// cronJobField renders the schedule and the runs of a CronJob
+func (f *Formatter) cronJobField(event *watcher.Event) (notifier.SlackAttachmentField, bool) {
+	cj := event.CronJob
+	if cj == nil {
+		return notifier.SlackAttachmentField{}, false
+	}
+
+	schedule := fmt.Sprintf("`%s`", cj.Schedule)
+	if cj.TimeZone != "" {
+		schedule += " (" + cj.TimeZone + ")"
+	}
+	lines := []string{
+		schedule,
+		fmt.Sprintf("Last Schedule: %s, Last Successful: %s", timeOrDash(cj.LastScheduleTime), timeOrDash(cj.LastSuccessfulTime)),
+	}
+	if len(cj.Active) > 0 {
+		names := make([]string, len(cj.Active))
+		for i, j := range cj.Active {
+			names[i] = j.Name
+		}
+		lines = append(lines, "Active: "+strings.Join(names, ", "))
+	}
+	return notifier.SlackAttachmentField{
+		Title: f.label(LabelSchedule),
+		Value: strings.Join(lines, "\n"),
+		Short: false,
+	}, true
+}
+
+// timeOrDash formats a time, or "-" for the zero time
+func timeOrDash(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
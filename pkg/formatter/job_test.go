@@ -0,0 +1,43 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_Job(t *testing.T) {
+	formatter := &Formatter{}
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "Job",
+		Namespace: "prod",
+		Name:      "migrate",
+		EventType: "UPDATED",
+		Timestamp: time.Now(),
+		Status:    "Failed",
+		Reason:    "BackoffLimitExceeded",
+		Job: &watcher.JobInfo{
+			Completions:  1,
+			BackoffLimit: 3,
+			Failed:       4,
+			FailedPods: []watcher.FailedPod{
+				{Name: "migrate-b", Reason: "Error (exit code 1)"},
+				{Name: "migrate-a", Reason: "DeadlineExceeded"},
+			},
+		},
+	})
+
+	fields := map[string]string{}
+	for _, field := range msg.Attachments[0].Fields {
+		fields[field.Title] = field.Value
+	}
+	if want := "Succeeded: 0/1, Failed: 4 (BackoffLimit: 3), Active: 0"; fields["ジョブ"] != want {
+		t.Errorf("Job field = %q, want %q", fields["ジョブ"], want)
+	}
+	// 最新の失敗したPodのログを確認するコマンドが付く
+	want := "• `migrate-b`: Error (exit code 1)\n• `migrate-a`: DeadlineExceeded\n`kubectl logs -n prod migrate-b`"
+	if fields["失敗したPod"] != want {
+		t.Errorf("Failed pods field = %q, want %q", fields["失敗したPod"], want)
+	}
+}
@@ -0,0 +1,80 @@
+package formatter
+
+import (
+	"fmt"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Default limits applied when no explicit limits are configured
+const (
+	DefaultMaxFields     = 10
+	DefaultMaxContainers = 10
+
+	// batchMaxContainers keeps detailed batch entries compact
+	batchMaxContainers = 3
+)
+
+// Limits controls how much detail is included in a single attachment
+type Limits struct {
+	MaxFields     int // Max fields per attachment
+	MaxContainers int // Max containers listed per event
+}
+
+// SetLimits sets the attachment size limits. Non-positive values use the defaults.
+func (f *Formatter) SetLimits(limits Limits) {
+	f.limits = limits
+}
+
+// maxFields returns the configured max fields per attachment
+func (f *Formatter) maxFields() int {
+	if f.limits.MaxFields > 0 {
+		return f.limits.MaxFields
+	}
+	return DefaultMaxFields
+}
+
+// maxContainers returns the configured max containers listed per event
+func (f *Formatter) maxContainers() int {
+	if f.limits.MaxContainers > 0 {
+		return f.limits.MaxContainers
+	}
+	return DefaultMaxContainers
+}
+
+// containerLines renders up to limit containers, followed by a count of the omitted ones
+func (f *Formatter) containerLines(containers []watcher.ContainerInfo, limit int) []string {
+	var lines []string
+	for i, c := range containers {
+		if i >= limit {
+			lines = append(lines, f.labelf(LabelMoreContainers, len(containers)-limit))
+			break
+		}
+		line := fmt.Sprintf("• %s: `%s`", c.Name, c.Image)
+		if c.Reason != "" {
+			line += " (" + c.Reason + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// truncateFields limits fields to the configured maximum. The last kept slot is used
+// for a marker showing how many fields were omitted.
+func (f *Formatter) truncateFields(fields []notifier.SlackAttachmentField) []notifier.SlackAttachmentField {
+	limit := f.maxFields()
+	if len(fields) <= limit {
+		return fields
+	}
+
+	kept := limit - 1
+	if kept < 0 {
+		kept = 0
+	}
+	truncated := append([]notifier.SlackAttachmentField(nil), fields[:kept]...)
+	return append(truncated, notifier.SlackAttachmentField{
+		Value: f.labelf(LabelMoreFields, len(fields)-kept),
+		Short: false,
+	})
+}
@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_MaxContainers(t *testing.T) {
+	formatter := &Formatter{}
+	formatter.SetLimits(Limits{MaxContainers: 2})
+
+	event := &watcher.Event{Kind: "Pod", Name: "big", EventType: "ADDED", Timestamp: time.Now()}
+	for i := 0; i < 20; i++ {
+		event.Containers = append(event.Containers, watcher.ContainerInfo{Name: fmt.Sprintf("c%d", i), Image: "img"})
+	}
+
+	msg := formatter.FormatSlackMessage(event)
+
+	var containers string
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "コンテナ" {
+			containers = field.Value
+		}
+	}
+
+	lines := strings.Split(containers, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 2 containers and a marker, got %d lines: %q", len(lines), containers)
+	}
+	if lines[2] != "... 他18個" {
+		t.Errorf("Expected truncation marker, got %q", lines[2])
+	}
+}
+
+func TestFormatSlackMessage_MaxFields(t *testing.T) {
+	formatter := &Formatter{}
+	formatter.SetLimits(Limits{MaxFields: 3})
+
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:        "Service",
+		Name:        "svc",
+		EventType:   "UPDATED",
+		Timestamp:   time.Now(),
+		Status:      "Active",
+		ServiceType: "ClusterIP",
+		Reason:      "Changed",
+		Message:     "details",
+	})
+
+	fields := msg.Attachments[0].Fields
+	if len(fields) != 3 {
+		t.Fatalf("Expected 3 fields, got %d", len(fields))
+	}
+	// 最後のフィールドは省略された件数を示す
+	if fields[2].Value != "... 他4項目" {
+		t.Errorf("Expected truncation marker, got %q", fields[2].Value)
+	}
+}
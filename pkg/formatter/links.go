@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Link represents a named URL template attached to notifications
+type Link struct {
+	Name string
+	URL  string // Go template rendered with TemplateData
+}
+
+// linkTemplate represents a parsed link
+type linkTemplate struct {
+	name string
+	tmpl *template.Template
+}
+
+// SetLinks parses link URL templates to be attached to notifications
+func (f *Formatter) SetLinks(links []Link) error {
+	parsed := make([]linkTemplate, 0, len(links))
+	for _, link := range links {
+		tmpl, err := template.New("link-" + link.Name).Funcs(templateFuncs()).Parse(link.URL)
+		if err != nil {
+			return fmt.Errorf("failed to parse link template %q: %w", link.Name, err)
+		}
+		parsed = append(parsed, linkTemplate{name: link.Name, tmpl: tmpl})
+	}
+
+	f.links = parsed
+	return nil
+}
+
+// renderLinks renders the configured links for an event as Slack mrkdwn links.
+// Links that fail to render or render to an empty URL are skipped.
+func (f *Formatter) renderLinks(event *watcher.Event) []string {
+	if len(f.links) == 0 {
+		return nil
+	}
+
+	data := newTemplateData(event)
+
+	var rendered []string
+	for _, link := range f.links {
+		var buf bytes.Buffer
+		if err := link.tmpl.Execute(&buf, data); err != nil {
+			continue
+		}
+		url := strings.TrimSpace(buf.String())
+		if url == "" {
+			continue
+		}
+		rendered = append(rendered, fmt.Sprintf("<%s|%s>", url, link.name))
+	}
+
+	return rendered
+}
+
+// linksField returns a Slack field with the rendered links, or false if there are none
+func (f *Formatter) linksField(event *watcher.Event) (notifier.SlackAttachmentField, bool) {
+	links := f.renderLinks(event)
+	if len(links) == 0 {
+		return notifier.SlackAttachmentField{}, false
+	}
+
+	return notifier.SlackAttachmentField{
+		Title: f.label(LabelLinks),
+		Value: strings.Join(links, " | "),
+		Short: false,
+	}, true
+}
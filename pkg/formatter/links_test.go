@@ -0,0 +1,58 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_Links(t *testing.T) {
+	formatter := &Formatter{}
+	err := formatter.SetLinks([]Link{
+		{Name: "Logs", URL: "https://grafana.example.com/?ns={{ .Namespace }}&pod={{ .Name }}"},
+		{Name: "Team", URL: `{{ with index .Labels "team" }}https://teams.example.com/{{ . }}{{ end }}`},
+	})
+	if err != nil {
+		t.Fatalf("SetLinks() error = %v", err)
+	}
+
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "Pod",
+		Namespace: "prod",
+		Name:      "web-1",
+		EventType: "DELETED",
+		Timestamp: time.Now(),
+	})
+
+	var links string
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "リンク" {
+			links = field.Value
+		}
+	}
+
+	// 空のURLになるリンクはスキップされる
+	expected := "<https://grafana.example.com/?ns=prod&pod=web-1|Logs>"
+	if links != expected {
+		t.Errorf("Links field = %q, want %q", links, expected)
+	}
+}
+
+func TestFormatSlackMessage_NoLinks(t *testing.T) {
+	formatter := &Formatter{}
+
+	msg := formatter.FormatSlackMessage(&watcher.Event{Kind: "Pod", Name: "web-1", EventType: "ADDED", Timestamp: time.Now()})
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "リンク" {
+			t.Error("Links field should not be present without configured links")
+		}
+	}
+}
+
+func TestSetLinks_InvalidTemplate(t *testing.T) {
+	formatter := &Formatter{}
+	if err := formatter.SetLinks([]Link{{Name: "bad", URL: "{{ .Name"}}); err == nil {
+		t.Error("SetLinks() error = nil, want error for invalid template")
+	}
+}
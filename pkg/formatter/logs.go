@@ -0,0 +1,26 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// logsField renders the logs attached to an event as a code block
+func (f *Formatter) logsField(event *watcher.Event) (notifier.SlackAttachmentField, bool) {
+	if event.Logs == nil || event.Logs.Text == "" {
+		return notifier.SlackAttachmentField{}, false
+	}
+	label := LabelLogs
+	if event.Logs.Previous {
+		label = LabelPreviousLogs
+	}
+	// A fence inside the logs would end the code block early
+	text := strings.ReplaceAll(event.Logs.Text, "```", "'''")
+	return notifier.SlackAttachmentField{
+		Title: f.labelf(label, event.Logs.Container),
+		Value: "```\n" + text + "\n```",
+		Short: false,
+	}, true
+}
@@ -0,0 +1,35 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_Logs(t *testing.T) {
+	formatter := &Formatter{}
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:       "Pod",
+		Namespace:  "prod",
+		Name:       "web-1",
+		EventType:  "UPDATED",
+		Timestamp:  time.Now(),
+		Reason:     watcher.ReasonCrashLoopBackOff,
+		Containers: []watcher.ContainerInfo{{Name: "app", Image: "web:1.0", Reason: watcher.ReasonCrashLoopBackOff}},
+		Logs:       &watcher.ContainerLogs{Container: "app", Previous: true, Text: "panic: ```boom```"},
+	})
+
+	fields := map[string]string{}
+	for _, field := range msg.Attachments[0].Fields {
+		fields[field.Title] = field.Value
+	}
+	if got := fields["コンテナ"]; got != "• app: `web:1.0` (CrashLoopBackOff)" {
+		t.Errorf("Containers field = %q", got)
+	}
+	// ログ内のコードフェンスでブロックが途切れない
+	want := "```\npanic: '''boom'''\n```"
+	if got := fields["前回のコンテナのログ (app)"]; got != want {
+		t.Errorf("Logs field = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// manifestDiffField renders the diff of the old and new manifest as a code block
+func (f *Formatter) manifestDiffField(event *watcher.Event) (notifier.SlackAttachmentField, bool) {
+	if event.ManifestDiff == "" {
+		return notifier.SlackAttachmentField{}, false
+	}
+	return notifier.SlackAttachmentField{
+		Title: f.label(LabelManifestDiff),
+		Value: codeBlock(event.ManifestDiff),
+		Short: false,
+	}, true
+}
+
+// manifestField renders the YAML manifest attached to an event as a code block
+func (f *Formatter) manifestField(event *watcher.Event) (notifier.SlackAttachmentField, bool) {
+	if event.Manifest == "" {
+		return notifier.SlackAttachmentField{}, false
+	}
+	return notifier.SlackAttachmentField{
+		Title: f.label(LabelManifest),
+		Value: codeBlock(event.Manifest),
+		Short: false,
+	}, true
+}
+
+// codeBlock renders YAML as a code block. A fence inside it, e.g. in the data
+// of a ConfigMap, would end the code block early.
+func codeBlock(text string) string {
+	return "```\n" + strings.ReplaceAll(text, "```", "'''") + "\n```"
+}
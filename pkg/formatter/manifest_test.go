@@ -0,0 +1,51 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_Manifest(t *testing.T) {
+	formatter := &Formatter{}
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "ConfigMap",
+		Namespace: "prod",
+		Name:      "readme",
+		EventType: "UPDATED",
+		Timestamp: time.Now(),
+		Manifest:  "apiVersion: v1\nkind: ConfigMap\ndata:\n  README.md: ```sh```",
+	})
+
+	fields := map[string]string{}
+	for _, field := range msg.Attachments[0].Fields {
+		fields[field.Title] = field.Value
+	}
+	// マニフェスト内のコードフェンスでブロックが途切れない
+	want := "```\napiVersion: v1\nkind: ConfigMap\ndata:\n  README.md: '''sh'''\n```"
+	if got := fields["マニフェスト"]; got != want {
+		t.Errorf("Manifest field = %q, want %q", got, want)
+	}
+}
+
+func TestFormatSlackMessage_ManifestDiff(t *testing.T) {
+	formatter := &Formatter{}
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:         "ConfigMap",
+		Namespace:    "prod",
+		Name:         "app",
+		EventType:    "UPDATED",
+		Timestamp:    time.Now(),
+		ManifestDiff: "@@ -1,2 +1,2 @@\n data:\n-  LOG_LEVEL: info\n+  LOG_LEVEL: debug",
+	})
+
+	fields := map[string]string{}
+	for _, field := range msg.Attachments[0].Fields {
+		fields[field.Title] = field.Value
+	}
+	want := "```\n@@ -1,2 +1,2 @@\n data:\n-  LOG_LEVEL: info\n+  LOG_LEVEL: debug\n```"
+	if got := fields["マニフェストの差分"]; got != want {
+		t.Errorf("Manifest diff field = %q, want %q", got, want)
+	}
+}
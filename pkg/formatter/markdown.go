@@ -0,0 +1,70 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// markdownRenderer renders Slack mrkdwn elements as CommonMark
+type markdownRenderer struct{}
+
+func (markdownRenderer) text(s string) string          { return s }
+func (markdownRenderer) bold(s string) string          { return "**" + s + "**" }
+func (markdownRenderer) code(s string) string          { return "`" + s + "`" }
+func (markdownRenderer) link(url, label string) string { return fmt.Sprintf("[%s](%s)", label, url) }
+
+// FormatMarkdown formats an event as a Markdown document
+func (f *Formatter) FormatMarkdown(event *watcher.Event) string {
+	attachment := f.FormatSlackMessage(event).Attachments[0]
+	attachment.Title = fmt.Sprintf("%s %s", getEventEmoji(event.EventType), attachment.Title)
+
+	var b strings.Builder
+	writeMarkdownAttachment(&b, attachment)
+	return b.String()
+}
+
+// FormatBatchMarkdown formats a batch of events as a Markdown document
+func (f *Formatter) FormatBatchMarkdown(batch *EventBatch, opts BatchOptions) string {
+	msg := f.FormatBatchSlackMessage(batch, opts)
+
+	var b strings.Builder
+	b.WriteString(convertMrkdwn(msg.Text, markdownRenderer{}))
+	b.WriteString("\n")
+	for _, attachment := range msg.Attachments {
+		b.WriteString("\n---\n\n")
+		writeMarkdownAttachment(&b, attachment)
+	}
+	return b.String()
+}
+
+// writeMarkdownAttachment renders an attachment as a heading followed by a field list
+func writeMarkdownAttachment(b *strings.Builder, attachment notifier.SlackAttachment) {
+	r := markdownRenderer{}
+
+	if attachment.Title != "" {
+		fmt.Fprintf(b, "### %s\n\n", convertMrkdwn(attachment.Title, r))
+	}
+	if attachment.Text != "" {
+		fmt.Fprintf(b, "%s\n\n", convertMrkdwn(attachment.Text, r))
+	}
+
+	for _, field := range attachment.Fields {
+		lines := valueLines(field.Value)
+		prefix := "- "
+		if field.Title != "" {
+			prefix = fmt.Sprintf("- **%s**: ", field.Title)
+		}
+
+		if len(lines) == 1 {
+			fmt.Fprintf(b, "%s%s\n", prefix, convertMrkdwn(lines[0], r))
+			continue
+		}
+		fmt.Fprintf(b, "%s\n", strings.TrimSuffix(prefix, " "))
+		for _, line := range lines {
+			fmt.Fprintf(b, "  - %s\n", convertMrkdwn(line, r))
+		}
+	}
+}
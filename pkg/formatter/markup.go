@@ -0,0 +1,81 @@
+package formatter
+
+import (
+	"strings"
+)
+
+// markupRenderer renders the elements of Slack mrkdwn in another markup language
+type markupRenderer interface {
+	text(s string) string
+	bold(s string) string
+	code(s string) string
+	link(url, label string) string
+}
+
+// convertMrkdwn converts the subset of Slack mrkdwn produced by the formatter
+// (*bold*, `code`, <url|label> links and <!here>-style mentions) using r
+func convertMrkdwn(s string, r markupRenderer) string {
+	var b strings.Builder
+	var plain strings.Builder
+
+	flush := func() {
+		if plain.Len() > 0 {
+			b.WriteString(r.text(plain.String()))
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '`', '*':
+			end := strings.IndexByte(s[i+1:], s[i])
+			if end <= 0 || strings.Contains(s[i+1:i+1+end], "\n") {
+				break
+			}
+			flush()
+			inner := s[i+1 : i+1+end]
+			if s[i] == '`' {
+				b.WriteString(r.code(inner))
+			} else {
+				b.WriteString(r.bold(convertMrkdwn(inner, r)))
+			}
+			i += end + 2
+			continue
+		case '<':
+			end := strings.IndexByte(s[i+1:], '>')
+			if end <= 0 {
+				break
+			}
+			flush()
+			target, label, _ := strings.Cut(s[i+1:i+1+end], "|")
+			if strings.HasPrefix(target, "!") {
+				// Special mentions such as <!here> or <!subteam^ID|@team>
+				if label == "" {
+					label = "@" + strings.TrimPrefix(target, "!")
+				}
+				b.WriteString(r.text(label))
+			} else {
+				if label == "" {
+					label = target
+				}
+				b.WriteString(r.link(target, label))
+			}
+			i += end + 2
+			continue
+		}
+		plain.WriteByte(s[i])
+		i++
+	}
+	flush()
+
+	return b.String()
+}
+
+// valueLines splits a multi-line field value into lines without list bullets
+func valueLines(value string) []string {
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "• ")
+	}
+	return lines
+}
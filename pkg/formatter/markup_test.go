@@ -0,0 +1,122 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestConvertMrkdwn(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		markdown string
+		html     string
+	}{
+		{
+			name:     "bold and code",
+			input:    "*Pod* `default/web`",
+			markdown: "**Pod** `default/web`",
+			html:     "<strong>Pod</strong> <code>default/web</code>",
+		},
+		{
+			name:     "link",
+			input:    "<https://example.com/?a=1&b=2|Logs>",
+			markdown: "[Logs](https://example.com/?a=1&b=2)",
+			html:     `<a href="https://example.com/?a=1&amp;b=2">Logs</a>`,
+		},
+		{
+			name:     "mention",
+			input:    "<!here> alert",
+			markdown: "@here alert",
+			html:     "@here alert",
+		},
+		{
+			name:     "unmatched markers are kept",
+			input:    "3 * 5 < 20",
+			markdown: "3 * 5 < 20",
+			html:     "3 * 5 &lt; 20",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertMrkdwn(tt.input, markdownRenderer{}); got != tt.markdown {
+				t.Errorf("markdown = %q, want %q", got, tt.markdown)
+			}
+			if got := convertMrkdwn(tt.input, htmlRenderer{}); got != tt.html {
+				t.Errorf("html = %q, want %q", got, tt.html)
+			}
+		})
+	}
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	formatter := &Formatter{}
+
+	md := formatter.FormatMarkdown(&watcher.Event{
+		Kind:       "Pod",
+		Namespace:  "default",
+		Name:       "web",
+		EventType:  "DELETED",
+		Timestamp:  time.Now(),
+		Containers: []watcher.ContainerInfo{{Name: "app", Image: "app:v1"}, {Name: "proxy", Image: "envoy:v1"}},
+	})
+
+	for _, want := range []string{
+		"### 🔴 [Pod] default/web\n",
+		"- **イベントタイプ**: DELETED\n",
+		"- **コンテナ**:\n  - app: `app:v1`\n  - proxy: `envoy:v1`\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestWriteHTMLAttachment_Links(t *testing.T) {
+	var b strings.Builder
+	writeHTMLAttachment(&b, notifier.SlackAttachment{
+		Text: "<https://grafana.example.com/d?ns=prod&pod=web|Logs> <javascript:alert(1)|Click> <JavaScript:alert(2)> <data:text/html,x|Data> <//evil.example.com|Relative>",
+	})
+	out := b.String()
+
+	if !strings.Contains(out, `<a href="https://grafana.example.com/d?ns=prod&amp;pod=web">Logs</a>`) {
+		t.Errorf("Expected the https link, got:\n%s", out)
+	}
+	// http/https以外のスキームや相対URLはリンクにせずラベルのみ表示する
+	if strings.Count(out, "<a ") != 1 || strings.Contains(out, `href="javascript`) || strings.Contains(out, `href="data`) {
+		t.Errorf("Expected only the https link, got:\n%s", out)
+	}
+	for _, label := range []string{"Click", "JavaScript:alert(2)", "Data", "Relative"} {
+		if !strings.Contains(out, label) {
+			t.Errorf("Expected label %q as text, got:\n%s", label, out)
+		}
+	}
+}
+
+func TestFormatBatchHTML(t *testing.T) {
+	formatter := &Formatter{}
+	now := time.Now()
+
+	out := formatter.FormatBatchHTML(&EventBatch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Namespace: "default", Name: "<script>", EventType: "ADDED", Timestamp: now},
+		},
+		StartTime: now.Add(-time.Minute),
+		EndTime:   now,
+	}, BatchOptions{Mode: "detailed"})
+
+	if !strings.HasPrefix(out, "<p>📦 <strong>") {
+		t.Errorf("Expected bold batch header, got:\n%s", out)
+	}
+	if strings.Contains(out, "<script>") {
+		t.Errorf("Expected event content to be escaped, got:\n%s", out)
+	}
+	if !strings.Contains(out, "<hr>\n<h3>") || !strings.Contains(out, "<ul>\n<li><strong>イベントタイプ</strong>: ADDED</li>") {
+		t.Errorf("Unexpected HTML layout:\n%s", out)
+	}
+}
@@ -0,0 +1,64 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// EventMatcher reports whether an event satisfies a set of conditions
+type EventMatcher interface {
+	Matches(event *watcher.Event) bool
+}
+
+// MentionRule adds Mention to messages containing an event that matches Matcher
+type MentionRule struct {
+	Mention string // "here", "channel", "subteam:<ID>", "user:<ID>" or raw Slack syntax
+	Matcher EventMatcher
+}
+
+// SetMentions sets the rules for injecting mentions into messages
+func (f *Formatter) SetMentions(rules []MentionRule) {
+	f.mentions = rules
+}
+
+// mentionsFor returns the Slack mentions triggered by the events, in rule order
+func (f *Formatter) mentionsFor(events ...*watcher.Event) string {
+	var mentions []string
+	for _, rule := range f.mentions {
+		for _, event := range events {
+			if rule.Matcher.Matches(event) {
+				mentions = append(mentions, slackMention(rule.Mention))
+				break
+			}
+		}
+	}
+	return strings.Join(mentions, " ")
+}
+
+// slackMention converts a mention shorthand to Slack syntax
+func slackMention(mention string) string {
+	switch {
+	case strings.HasPrefix(mention, "<"):
+		return mention
+	case mention == "here" || mention == "channel" || mention == "everyone":
+		return "<!" + mention + ">"
+	case strings.HasPrefix(mention, "subteam:"):
+		return "<!subteam^" + strings.TrimPrefix(mention, "subteam:") + ">"
+	case strings.HasPrefix(mention, "user:"):
+		return "<@" + strings.TrimPrefix(mention, "user:") + ">"
+	default:
+		return mention
+	}
+}
+
+// withMentions prefixes text with mentions
+func withMentions(mentions, text string) string {
+	if mentions == "" {
+		return text
+	}
+	if text == "" {
+		return mentions
+	}
+	return mentions + " " + text
+}
@@ -0,0 +1,83 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// eventTypeMatcher matches events by event type
+type eventTypeMatcher string
+
+func (m eventTypeMatcher) Matches(event *watcher.Event) bool {
+	return event.EventType == string(m)
+}
+
+func TestSlackMention(t *testing.T) {
+	tests := map[string]string{
+		"here":             "<!here>",
+		"channel":          "<!channel>",
+		"subteam:S012345":  "<!subteam^S012345>",
+		"user:U012345":     "<@U012345>",
+		"<!subteam^S9|@a>": "<!subteam^S9|@a>",
+	}
+	for input, expected := range tests {
+		if got := slackMention(input); got != expected {
+			t.Errorf("slackMention(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestFormatSlackMessage_Mentions(t *testing.T) {
+	formatter := &Formatter{}
+	formatter.SetMentions([]MentionRule{
+		{Mention: "here", Matcher: eventTypeMatcher("DELETED")},
+		{Mention: "user:U1", Matcher: eventTypeMatcher("ADDED")},
+	})
+
+	msg := formatter.FormatSlackMessage(&watcher.Event{Kind: "Pod", Name: "web", EventType: "DELETED", Timestamp: time.Now()})
+	if msg.Text != "<!here>" {
+		t.Errorf("Expected <!here> mention, got %q", msg.Text)
+	}
+
+	msg = formatter.FormatSlackMessage(&watcher.Event{Kind: "Pod", Name: "web", EventType: "UPDATED", Timestamp: time.Now()})
+	if msg.Text != "" {
+		t.Errorf("Expected no mention, got %q", msg.Text)
+	}
+}
+
+func TestFormatBatchSlackMessage_Mentions(t *testing.T) {
+	formatter := &Formatter{}
+	formatter.SetMentions([]MentionRule{
+		{Mention: "here", Matcher: eventTypeMatcher("DELETED")},
+		{Mention: "user:U1", Matcher: eventTypeMatcher("ADDED")},
+	})
+
+	now := time.Now()
+	msg := formatter.FormatBatchSlackMessage(&EventBatch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Name: "a", EventType: "DELETED", Timestamp: now},
+			{Kind: "Pod", Name: "b", EventType: "DELETED", Timestamp: now},
+			{Kind: "Pod", Name: "c", EventType: "ADDED", Timestamp: now},
+		},
+		StartTime: now,
+		EndTime:   now,
+	}, BatchOptions{})
+
+	// 各メンションは1回だけ付与される
+	if !strings.HasPrefix(msg.Text, "<!here> <@U1> 📦") {
+		t.Errorf("Expected mentions before the batch header, got %q", msg.Text)
+	}
+}
+
+func TestFormatSlackBlocks_Mentions(t *testing.T) {
+	formatter := &Formatter{}
+	formatter.SetMentions([]MentionRule{{Mention: "channel", Matcher: eventTypeMatcher("DELETED")}})
+
+	msg := formatter.FormatSlackBlocks(&watcher.Event{Kind: "Pod", Name: "web", EventType: "DELETED", Timestamp: time.Now()})
+	if msg.Blocks[0].Text == nil || msg.Blocks[0].Text.Text != "<!channel>" {
+		t.Errorf("Expected mention in the first block, got %+v", msg.Blocks[0])
+	}
+}
@@ -0,0 +1,41 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// scalingFields renders the replicas and metrics of an autoscaler, and its
+// conditions, which explain why it acted
+func (f *Formatter) scalingFields(event *watcher.Event) []notifier.SlackAttachmentField {
+	s := event.Scaling
+	if s == nil {
+		return nil
+	}
+
+	lines := []string{fmt.Sprintf("%s: %d → %d (Min: %d, Max: %d)", s.Target, s.CurrentReplicas, s.DesiredReplicas, s.MinReplicas, s.MaxReplicas)}
+	for _, m := range s.Metrics {
+		lines = append(lines, fmt.Sprintf("• %s: %s (Target: %s)", m.Name, m.Current, m.Target))
+	}
+	fields := []notifier.SlackAttachmentField{{
+		Title: f.label(LabelScaling),
+		Value: strings.Join(lines, "\n"),
+		Short: false,
+	}}
+
+	if len(s.Conditions) > 0 {
+		conditions := make([]string, 0, len(s.Conditions))
+		for _, c := range s.Conditions {
+			conditions = append(conditions, fmt.Sprintf("• %s: %s (%s)", c.Type, c.Status, c.Reason))
+		}
+		fields = append(fields, notifier.SlackAttachmentField{
+			Title: f.label(LabelConditions),
+			Value: strings.Join(conditions, "\n"),
+			Short: false,
+		})
+	}
+	return fields
+}
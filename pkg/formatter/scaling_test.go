@@ -0,0 +1,40 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_Scaling(t *testing.T) {
+	formatter := &Formatter{}
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "HorizontalPodAutoscaler",
+		Namespace: "prod",
+		Name:      "web",
+		EventType: "UPDATED",
+		Timestamp: time.Now(),
+		Reason:    "SucceededRescale",
+		Scaling: &watcher.ScalingInfo{
+			Target:          "Deployment/web",
+			MinReplicas:     2,
+			MaxReplicas:     10,
+			CurrentReplicas: 3,
+			DesiredReplicas: 5,
+			Metrics:         []watcher.MetricInfo{{Name: "cpu", Current: "85%", Target: "70%"}},
+			Conditions:      []watcher.ScalingCondition{{Type: "AbleToScale", Status: "True", Reason: "SucceededRescale"}},
+		},
+	})
+
+	fields := map[string]string{}
+	for _, field := range msg.Attachments[0].Fields {
+		fields[field.Title] = field.Value
+	}
+	if want := "Deployment/web: 3 → 5 (Min: 2, Max: 10)\n• cpu: 85% (Target: 70%)"; fields["スケーリング"] != want {
+		t.Errorf("Scaling field = %q, want %q", fields["スケーリング"], want)
+	}
+	if want := "• AbleToScale: True (SucceededRescale)"; fields["条件"] != want {
+		t.Errorf("Conditions field = %q, want %q", fields["条件"], want)
+	}
+}
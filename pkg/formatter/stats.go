@@ -0,0 +1,114 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// CountEntry represents a name with its event count
+type CountEntry struct {
+	Name  string
+	Count int
+}
+
+// BatchStats represents computed statistics for a batch of events
+type BatchStats struct {
+	TopKinds         []CountEntry // Up to 3 kinds with the most events
+	BusiestNamespace CountEntry
+	EventTypeCounts  []CountEntry // Ordered ADDED, UPDATED, DELETED, then others by name
+}
+
+// ComputeBatchStats computes summary statistics for a batch of events
+func ComputeBatchStats(events []*watcher.Event) BatchStats {
+	kinds := make(map[string]int)
+	namespaces := make(map[string]int)
+	eventTypes := make(map[string]int)
+
+	for _, event := range events {
+		kinds[event.Kind]++
+		namespaces[event.Namespace]++
+		eventTypes[event.EventType]++
+	}
+
+	stats := BatchStats{
+		TopKinds: sortedCounts(kinds),
+	}
+	if len(stats.TopKinds) > 3 {
+		stats.TopKinds = stats.TopKinds[:3]
+	}
+	if ns := sortedCounts(namespaces); len(ns) > 0 {
+		stats.BusiestNamespace = ns[0]
+	}
+
+	// Well-known event types first, in lifecycle order
+	for _, t := range []string{"ADDED", "UPDATED", "DELETED"} {
+		if count, ok := eventTypes[t]; ok {
+			stats.EventTypeCounts = append(stats.EventTypeCounts, CountEntry{Name: t, Count: count})
+			delete(eventTypes, t)
+		}
+	}
+	others := make([]string, 0, len(eventTypes))
+	for t := range eventTypes {
+		others = append(others, t)
+	}
+	sort.Strings(others)
+	for _, t := range others {
+		stats.EventTypeCounts = append(stats.EventTypeCounts, CountEntry{Name: t, Count: eventTypes[t]})
+	}
+
+	return stats
+}
+
+// sortedCounts returns map entries sorted by count descending, then by name
+func sortedCounts(counts map[string]int) []CountEntry {
+	entries := make([]CountEntry, 0, len(counts))
+	for name, count := range counts {
+		entries = append(entries, CountEntry{Name: name, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// buildStatsAttachment builds a Slack attachment summarizing batch statistics
+func (f *Formatter) buildStatsAttachment(stats BatchStats) notifier.SlackAttachment {
+	var typeCounts []string
+	for _, e := range stats.EventTypeCounts {
+		typeCounts = append(typeCounts, fmt.Sprintf("%s %s: %d", getEventEmoji(e.Name), e.Name, e.Count))
+	}
+
+	var topKinds []string
+	for _, e := range stats.TopKinds {
+		topKinds = append(topKinds, fmt.Sprintf("%s: %d", e.Name, e.Count))
+	}
+
+	return notifier.SlackAttachment{
+		Color: "#439FE0",
+		Title: f.label(LabelSummary),
+		Fields: []notifier.SlackAttachmentField{
+			{
+				Title: f.label(LabelEventTypeCounts),
+				Value: strings.Join(typeCounts, "\n"),
+				Short: true,
+			},
+			{
+				Title: f.label(LabelTopKinds),
+				Value: strings.Join(topKinds, "\n"),
+				Short: true,
+			},
+			{
+				Title: f.label(LabelBusiestNamespace),
+				Value: fmt.Sprintf("%s (%s)", stats.BusiestNamespace.Name, f.labelf(LabelEventCount, stats.BusiestNamespace.Count)),
+				Short: true,
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestComputeBatchStats(t *testing.T) {
+	events := []*watcher.Event{
+		{Kind: "Pod", Namespace: "prod", EventType: "ADDED"},
+		{Kind: "Pod", Namespace: "prod", EventType: "DELETED"},
+		{Kind: "Pod", Namespace: "dev", EventType: "UPDATED"},
+		{Kind: "Deployment", Namespace: "prod", EventType: "UPDATED"},
+		{Kind: "Deployment", Namespace: "dev", EventType: "UPDATED"},
+		{Kind: "Service", Namespace: "dev", EventType: "ADDED"},
+		{Kind: "ConfigMap", Namespace: "prod", EventType: "ADDED"},
+	}
+
+	stats := ComputeBatchStats(events)
+
+	// 上位3種別（同数の場合は名前順）
+	expectedKinds := []CountEntry{{"Pod", 3}, {"Deployment", 2}, {"ConfigMap", 1}}
+	if len(stats.TopKinds) != len(expectedKinds) {
+		t.Fatalf("Expected %d top kinds, got %d", len(expectedKinds), len(stats.TopKinds))
+	}
+	for i, e := range expectedKinds {
+		if stats.TopKinds[i] != e {
+			t.Errorf("TopKinds[%d] = %v, want %v", i, stats.TopKinds[i], e)
+		}
+	}
+
+	if stats.BusiestNamespace != (CountEntry{"prod", 4}) {
+		t.Errorf("BusiestNamespace = %v, want {prod 4}", stats.BusiestNamespace)
+	}
+
+	expectedTypes := []CountEntry{{"ADDED", 3}, {"UPDATED", 3}, {"DELETED", 1}}
+	for i, e := range expectedTypes {
+		if stats.EventTypeCounts[i] != e {
+			t.Errorf("EventTypeCounts[%d] = %v, want %v", i, stats.EventTypeCounts[i], e)
+		}
+	}
+}
+
+func TestFormatBatchSlackMessage_SummaryStats(t *testing.T) {
+	formatter := &Formatter{}
+	now := time.Now()
+
+	batch := &EventBatch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Namespace: "prod", Name: "a", EventType: "ADDED", Timestamp: now},
+		},
+		StartTime: now.Add(-time.Minute),
+		EndTime:   now,
+	}
+
+	msg := formatter.FormatBatchSlackMessage(batch, BatchOptions{Mode: BatchModeSummary, SummaryStats: true})
+	if len(msg.Attachments) == 0 || msg.Attachments[0].Title != "📊 サマリー" {
+		t.Fatal("Expected summary statistics attachment first")
+	}
+
+	msg = formatter.FormatBatchSlackMessage(batch, BatchOptions{Mode: BatchModeSummary})
+	if msg.Attachments[0].Title == "📊 サマリー" {
+		t.Error("Summary statistics should not be included unless enabled")
+	}
+}
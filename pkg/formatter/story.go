@@ -0,0 +1,54 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+)
+
+// formatStory formats correlated events as a timeline about the subject,
+// followed by the field changes that started it
+func (f *Formatter) formatStory(batch *EventBatch, subject string) *notifier.SlackMessage {
+	mainText := f.labelf(LabelStoryHeader, subject, len(batch.Events), batch.EndTime.Sub(batch.StartTime).Seconds())
+	if len(batch.Events) == 0 {
+		return &notifier.SlackMessage{Text: mainText}
+	}
+	mainText = withCluster(batch.Events[0].Cluster, mainText)
+
+	color := getEventColor(batch.Events[0].EventType)
+	lines := make([]string, 0, len(batch.Events))
+	for _, event := range batch.Events {
+		line := fmt.Sprintf("`%s` %s [%s] %s %s", event.Timestamp.Format("15:04:05"), getEventEmoji(event.EventType), event.Kind, event.Name, event.EventType)
+		if event.Status != "" {
+			line += " — " + event.Status
+		}
+		if event.Reason != "" {
+			line += " (" + event.Reason + ")"
+		}
+		lines = append(lines, line)
+		if event.EventType == "DELETED" {
+			color = getEventColor(event.EventType)
+		}
+	}
+
+	attachment := notifier.SlackAttachment{
+		Color:     color,
+		Text:      strings.Join(lines, "\n"),
+		Timestamp: batch.Events[0].Timestamp.Unix(),
+	}
+	for _, event := range batch.Events {
+		if field, ok := f.changesField(event); ok {
+			attachment.Fields = append(attachment.Fields, field)
+			break
+		}
+	}
+	if field, ok := f.linksField(batch.Events[0]); ok {
+		attachment.Fields = append(attachment.Fields, field)
+	}
+
+	return &notifier.SlackMessage{
+		Text:        withMentions(f.mentionsFor(batch.Events...), mainText),
+		Attachments: []notifier.SlackAttachment{attachment},
+	}
+}
@@ -0,0 +1,45 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatBatchSlackMessage_Story(t *testing.T) {
+	f := &Formatter{}
+	if err := f.SetLocale("en", nil); err != nil {
+		t.Fatalf("SetLocale() error = %v", err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	batch := &EventBatch{
+		Events: []*watcher.Event{
+			{Kind: "Deployment", Namespace: "default", Name: "api", EventType: "UPDATED", Timestamp: start,
+				Changes: []watcher.FieldChange{{Field: "image", Old: "api:1", New: "api:2"}}},
+			{Kind: "ReplicaSet", Namespace: "default", Name: "api-7d9f", EventType: "ADDED", Timestamp: start.Add(time.Second)},
+			{Kind: "Pod", Namespace: "default", Name: "api-7d9f-x2k4", EventType: "UPDATED", Timestamp: start.Add(5 * time.Second), Status: "Running"},
+		},
+		StartTime: start,
+		EndTime:   start.Add(45 * time.Second),
+	}
+
+	msg := f.FormatBatchSlackMessage(batch, BatchOptions{Mode: BatchModeStory, Subject: "Deployment default/api"})
+
+	if !strings.Contains(msg.Text, "Rollout of Deployment default/api (3 events over 45 seconds)") {
+		t.Errorf("Unexpected header %q", msg.Text)
+	}
+	if len(msg.Attachments) != 1 {
+		t.Fatalf("Expected one attachment, got %d", len(msg.Attachments))
+	}
+	// 時系列で1行ずつ表示される
+	lines := strings.Split(msg.Attachments[0].Text, "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], "`12:00:00`") || !strings.Contains(lines[2], "[Pod] api-7d9f-x2k4 UPDATED — Running") {
+		t.Errorf("Unexpected timeline %q", msg.Attachments[0].Text)
+	}
+	fields := msg.Attachments[0].Fields
+	if len(fields) != 1 || !strings.Contains(fields[0].Value, "`api:1` → `api:2`") {
+		t.Errorf("Expected the changes of the rollout, got %+v", fields)
+	}
+}
@@ -0,0 +1,44 @@
+package formatter
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// FormatSlackText formats an event as a plain text message rendered with the
+// template, for channels where a terse line is preferred over a rich layout.
+// The attachments are used instead when the template fails or renders nothing.
+func (f *Formatter) FormatSlackText(event *watcher.Event) *notifier.SlackMessage {
+	text, err := f.Format(event)
+	if err != nil || strings.TrimSpace(text) == "" {
+		slog.Warn("Failed to render the template, sending attachments instead", event.LogAttrs("error", err)...)
+		return f.FormatSlackMessage(event)
+	}
+	return &notifier.SlackMessage{Text: withMentions(f.mentionsFor(event), strings.TrimSpace(text))}
+}
+
+// FormatBatchSlackText formats a batch as a plain text message: the batch or
+// story header followed by one line per event rendered with the template.
+// The attachments are used instead when the template fails or renders nothing.
+func (f *Formatter) FormatBatchSlackText(batch *EventBatch, opts BatchOptions) *notifier.SlackMessage {
+	header := f.labelf(LabelBatchHeader, batch.EndTime.Sub(batch.StartTime).Seconds(), len(batch.Events))
+	if opts.Mode == BatchModeStory {
+		header = f.labelf(LabelStoryHeader, opts.Subject, len(batch.Events), batch.EndTime.Sub(batch.StartTime).Seconds())
+	}
+	lines := []string{header}
+	if len(batch.Events) > 0 {
+		lines[0] = withCluster(batch.Events[0].Cluster, header)
+	}
+	for _, event := range batch.Events {
+		text, err := f.Format(event)
+		if err != nil || strings.TrimSpace(text) == "" {
+			slog.Warn("Failed to render the template, sending attachments instead", event.LogAttrs("error", err)...)
+			return f.FormatBatchSlackMessage(batch, opts)
+		}
+		lines = append(lines, strings.TrimSpace(text))
+	}
+	return &notifier.SlackMessage{Text: withMentions(f.mentionsFor(batch.Events...), strings.Join(lines, "\n"))}
+}
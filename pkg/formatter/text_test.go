@@ -0,0 +1,56 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackText(t *testing.T) {
+	f, err := NewFormatter("{{ .Kind }} {{ .Namespace }}/{{ .Name }} was {{ .EventType }}\n")
+	if err != nil {
+		t.Fatalf("NewFormatter() error = %v", err)
+	}
+	msg := f.FormatSlackText(&watcher.Event{Kind: "Pod", Namespace: "dev", Name: "web", EventType: "DELETED"})
+	if msg.Text != "Pod dev/web was DELETED" || len(msg.Attachments) != 0 {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+
+	// テンプレートが失敗した場合はアタッチメントで送る
+	f, err = NewFormatter("{{ .Replicas.Desired }}")
+	if err != nil {
+		t.Fatalf("NewFormatter() error = %v", err)
+	}
+	msg = f.FormatSlackText(&watcher.Event{Kind: "Pod", Namespace: "dev", Name: "web", EventType: "DELETED"})
+	if len(msg.Attachments) != 1 {
+		t.Errorf("Expected attachments when the template fails, got %+v", msg)
+	}
+}
+
+func TestFormatBatchSlackText(t *testing.T) {
+	f, err := NewFormatter("{{ .Kind }} {{ .Name }} {{ .EventType }}")
+	if err != nil {
+		t.Fatalf("NewFormatter() error = %v", err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	batch := &EventBatch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Namespace: "dev", Name: "web", EventType: "ADDED"},
+			{Kind: "Pod", Namespace: "dev", Name: "db", EventType: "DELETED"},
+		},
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Second),
+	}
+
+	// ヘッダーの後にイベントごとにテンプレートで整形した行が続く
+	msg := f.FormatBatchSlackText(batch, BatchOptions{})
+	lines := strings.Split(msg.Text, "\n")
+	if len(lines) != 3 || lines[1] != "Pod web ADDED" || lines[2] != "Pod db DELETED" || len(msg.Attachments) != 0 {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+	if !strings.Contains(lines[0], "30") {
+		t.Errorf("Expected the batch header, got %q", lines[0])
+	}
+}
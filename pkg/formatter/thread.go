@@ -0,0 +1,44 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// SetThreadKey parses the template rendering the Slack thread key of events,
+// so that events with the same key are replied to in one thread. An empty
+// template keeps one thread per resource.
+func (f *Formatter) SetThreadKey(templateStr string) error {
+	if templateStr == "" {
+		f.threadKey = nil
+		return nil
+	}
+	tmpl, err := template.New("thread-key").Funcs(templateFuncs()).Parse(templateStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse thread key template: %w", err)
+	}
+	f.threadKey = tmpl
+	return nil
+}
+
+// ThreadKey returns the key of the Slack thread the event is replied to. It
+// falls back to the resource when no template is set, or the template fails
+// or renders an empty key.
+func (f *Formatter) ThreadKey(event *watcher.Event) string {
+	resource := event.Kind + "/" + event.Namespace + "/" + event.Name
+	if f.threadKey == nil {
+		return resource
+	}
+	var buf bytes.Buffer
+	if err := f.threadKey.Execute(&buf, newTemplateData(event)); err != nil {
+		return resource
+	}
+	if key := strings.TrimSpace(buf.String()); key != "" {
+		return key
+	}
+	return resource
+}
@@ -0,0 +1,43 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestThreadKey(t *testing.T) {
+	deployment := &watcher.Event{Kind: "Deployment", Namespace: "prod", Name: "web"}
+	replicaSet := &watcher.Event{Kind: "ReplicaSet", Namespace: "prod", Name: "web-7d4b9",
+		Owner: &watcher.OwnerRef{Kind: "Deployment", Name: "web"}}
+	pod := &watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web-7d4b9-x2k8p",
+		Owner: &watcher.OwnerRef{Kind: "ReplicaSet", Name: "web-7d4b9"}}
+
+	// テンプレート未設定時はリソースごとのスレッド
+	f := &Formatter{}
+	if got := f.ThreadKey(pod); got != "Pod/prod/web-7d4b9-x2k8p" {
+		t.Errorf("ThreadKey() = %q, want the resource", got)
+	}
+
+	// ロールアウトのイベントは種類をまたいで同じスレッドになる
+	if err := f.SetThreadKey("{{ .Namespace }}/{{ .OwnerName }}"); err != nil {
+		t.Fatalf("SetThreadKey() error = %v", err)
+	}
+	for _, event := range []*watcher.Event{deployment, replicaSet, pod} {
+		if got := f.ThreadKey(event); got != "prod/web" {
+			t.Errorf("ThreadKey(%s) = %q, want prod/web", event.Kind, got)
+		}
+	}
+
+	// 空のキーになる場合はリソースごとのスレッドに戻る
+	if err := f.SetThreadKey(`{{ index .Labels "app" }}`); err != nil {
+		t.Fatalf("SetThreadKey() error = %v", err)
+	}
+	if got := f.ThreadKey(deployment); got != "Deployment/prod/web" {
+		t.Errorf("ThreadKey() = %q, want the resource", got)
+	}
+
+	if err := f.SetThreadKey("{{ .Namespace"); err == nil {
+		t.Error("Expected error for an invalid template")
+	}
+}
@@ -0,0 +1,34 @@
+package formatter
+
+import (
+	"fmt"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// volumeField renders the storage class and sizes of a PersistentVolumeClaim
+func (f *Formatter) volumeField(event *watcher.Event) (notifier.SlackAttachmentField, bool) {
+	v := event.Volume
+	if v == nil {
+		return notifier.SlackAttachmentField{}, false
+	}
+	value := fmt.Sprintf("Storage Class: %s, Requested: %s, Capacity: %s",
+		orDash(v.StorageClass), orDash(v.Requested), orDash(v.Capacity))
+	if v.VolumeName != "" {
+		value += "\nVolume: " + v.VolumeName
+	}
+	return notifier.SlackAttachmentField{
+		Title: f.label(LabelVolume),
+		Value: value,
+		Short: false,
+	}, true
+}
+
+// orDash returns s, or "-" when it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
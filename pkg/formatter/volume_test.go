@@ -0,0 +1,36 @@
+package formatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_Volume(t *testing.T) {
+	formatter := &Formatter{}
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "PersistentVolumeClaim",
+		Namespace: "prod",
+		Name:      "data",
+		EventType: watcher.EventTypeWarning,
+		Timestamp: time.Now(),
+		Status:    "Pending",
+		Reason:    watcher.ReasonClaimPending,
+		Volume:    &watcher.VolumeInfo{StorageClass: "fast", Requested: "10Gi"},
+	})
+
+	if msg.Attachments[0].Color != "danger" {
+		t.Errorf("Expected warnings to be red, got %q", msg.Attachments[0].Color)
+	}
+	var volume string
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "ボリューム" {
+			volume = field.Value
+		}
+	}
+	// 未割り当ての容量は"-"で表示する
+	if want := "Storage Class: fast, Requested: 10Gi, Capacity: -"; volume != want {
+		t.Errorf("Volume field = %q, want %q", volume, want)
+	}
+}
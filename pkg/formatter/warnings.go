@@ -0,0 +1,32 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// maxWarningMessage is the number of characters of an Event message shown
+const maxWarningMessage = 200
+
+// warningsField renders the Warning Events attached to an event, one reason per line
+func (f *Formatter) warningsField(event *watcher.Event) (notifier.SlackAttachmentField, bool) {
+	if len(event.Warnings) == 0 {
+		return notifier.SlackAttachmentField{}, false
+	}
+	lines := make([]string, 0, len(event.Warnings))
+	for _, w := range event.Warnings {
+		message := w.Message
+		if runes := []rune(message); len(runes) > maxWarningMessage {
+			message = string(runes[:maxWarningMessage]) + "…"
+		}
+		lines = append(lines, fmt.Sprintf("• *%s* (×%d): %s", w.Reason, w.Count, message))
+	}
+	return notifier.SlackAttachmentField{
+		Title: f.label(LabelWarnings),
+		Value: strings.Join(lines, "\n"),
+		Short: false,
+	}, true
+}
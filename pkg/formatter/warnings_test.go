@@ -0,0 +1,39 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFormatSlackMessage_Warnings(t *testing.T) {
+	formatter := &Formatter{}
+	msg := formatter.FormatSlackMessage(&watcher.Event{
+		Kind:      "Pod",
+		Namespace: "prod",
+		Name:      "web-1",
+		EventType: "UPDATED",
+		Timestamp: time.Now(),
+		Warnings: []watcher.RelatedEvent{
+			{Reason: "BackOff", Message: "Back-off restarting failed container", Count: 5},
+			{Reason: "FailedMount", Message: strings.Repeat("x", 300), Count: 1},
+		},
+	})
+
+	var warnings string
+	for _, field := range msg.Attachments[0].Fields {
+		if field.Title == "警告イベント" {
+			warnings = field.Value
+		}
+	}
+	lines := strings.Split(warnings, "\n")
+	if len(lines) != 2 || lines[0] != "• *BackOff* (×5): Back-off restarting failed container" {
+		t.Fatalf("Unexpected warnings field %q", warnings)
+	}
+	// 長いメッセージは切り詰められる
+	if want := "• *FailedMount* (×1): " + strings.Repeat("x", 200) + "…"; lines[1] != want {
+		t.Errorf("Expected a truncated message, got %q", lines[1])
+	}
+}
@@ -0,0 +1,297 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// acksFile is the journal of the acknowledgement state in the history directory
+const acksFile = "acks.jsonl"
+
+// ErrNotTracked is returned when acknowledging an event that is not tracked
+var ErrNotTracked = errors.New("event is not tracked")
+
+// Ack records who acknowledged an event
+type Ack struct {
+	By      string    `json:"by,omitempty"`
+	Comment string    `json:"comment,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Tracked is a notified event that needs an acknowledgement
+type Tracked struct {
+	ID         string    `json:"id"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Kind       string    `json:"kind"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name"`
+	EventType  string    `json:"eventType"`
+	Reason     string    `json:"reason,omitempty"`
+	NotifiedAt time.Time `json:"notifiedAt"`
+	Ack        *Ack      `json:"ack,omitempty"` // Nil until acknowledged
+}
+
+// journalEntry is a line of the journal: an event starting to be tracked, or
+// the acknowledgement of a tracked event
+type journalEntry struct {
+	Tracked *Tracked `json:"tracked,omitempty"`
+	ID      string   `json:"id,omitempty"`
+	Ack     *Ack     `json:"ack,omitempty"`
+}
+
+// Acks keeps the acknowledgement state of notified events in a journal next to
+// the event history, so that it survives restarts. Events notified before the
+// retention are forgotten. A nil Acks is valid and tracks nothing.
+type Acks struct {
+	path      string
+	retention time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	events map[string]*Tracked
+	order  []*Tracked // Oldest first
+}
+
+// OpenAcks opens the journal in dir, compacting it to the events within the retention
+func OpenAcks(dir string, retention time.Duration) (*Acks, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("history path is required")
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	a := &Acks{
+		path:      filepath.Join(dir, acksFile),
+		retention: retention,
+		now:       time.Now,
+		events:    make(map[string]*Tracked),
+	}
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	a.prune()
+	if err := a.compact(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// load replays the journal, skipping lines that cannot be parsed
+func (a *Acks) load() error {
+	f, err := os.Open(a.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open acknowledgements: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		switch {
+		case entry.Tracked != nil && a.events[entry.Tracked.ID] == nil:
+			a.events[entry.Tracked.ID] = entry.Tracked
+			a.order = append(a.order, entry.Tracked)
+		case entry.Ack != nil:
+			if t := a.events[entry.ID]; t != nil {
+				t.Ack = entry.Ack
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read acknowledgements: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites the journal with the current state and opens it for appending
+func (a *Acks) compact() error {
+	tmp := a.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write acknowledgements: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, t := range a.order {
+		if err := enc.Encode(journalEntry{Tracked: t}); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write acknowledgements: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write acknowledgements: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write acknowledgements: %w", err)
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		return fmt.Errorf("failed to write acknowledgements: %w", err)
+	}
+
+	a.file, err = os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open acknowledgements: %w", err)
+	}
+	return nil
+}
+
+// prune forgets events notified before the retention. Must be called with mu
+// held, except while opening.
+func (a *Acks) prune() {
+	cutoff := a.now().Add(-a.retention)
+	i := 0
+	for i < len(a.order) && a.order[i].NotifiedAt.Before(cutoff) {
+		delete(a.events, a.order[i].ID)
+		i++
+	}
+	a.order = a.order[i:]
+}
+
+// append writes an entry to the journal. Must be called with mu held.
+func (a *Acks) append(entry journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal acknowledgement: %w", err)
+	}
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write acknowledgements: %w", err)
+	}
+	return nil
+}
+
+// Track starts tracking a notified event until it is acknowledged. Events
+// already tracked are ignored.
+func (a *Acks) Track(e *watcher.Event) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if e.ID == "" {
+		return fmt.Errorf("event has no ID")
+	}
+	if a.events[e.ID] != nil {
+		return nil
+	}
+	a.prune()
+	t := &Tracked{
+		ID:         e.ID,
+		Cluster:    e.Cluster,
+		Kind:       e.Kind,
+		Namespace:  e.Namespace,
+		Name:       e.Name,
+		EventType:  e.EventType,
+		Reason:     e.Reason,
+		NotifiedAt: a.now(),
+	}
+	if err := a.append(journalEntry{Tracked: t}); err != nil {
+		return err
+	}
+	a.events[t.ID] = t
+	a.order = append(a.order, t)
+	return nil
+}
+
+// IsTracked reports whether the event is tracked
+func (a *Acks) IsTracked(id string) bool {
+	if a == nil {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.events[id] != nil
+}
+
+// IsAcknowledged reports whether the tracked event was acknowledged
+func (a *Acks) IsAcknowledged(id string) bool {
+	if a == nil {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	t := a.events[id]
+	return t != nil && t.Ack != nil
+}
+
+// Acknowledge records the acknowledgement of a tracked event. The first
+// acknowledgement is kept; acknowledging again returns it unchanged.
+func (a *Acks) Acknowledge(id string, ack Ack) (Tracked, error) {
+	if a == nil {
+		return Tracked{}, ErrNotTracked
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	t := a.events[id]
+	if t == nil {
+		return Tracked{}, ErrNotTracked
+	}
+	if t.Ack != nil {
+		return *t, nil
+	}
+	if ack.At.IsZero() {
+		ack.At = a.now()
+	}
+	if err := a.append(journalEntry{ID: id, Ack: &ack}); err != nil {
+		return Tracked{}, err
+	}
+	t.Ack = &ack
+	return *t, nil
+}
+
+// List returns the tracked events, newest first. With unacknowledged set, only
+// events that have not been acknowledged are returned.
+func (a *Acks) List(unacknowledged bool) []Tracked {
+	out := []Tracked{}
+	if a == nil {
+		return out
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.prune()
+	for i := len(a.order) - 1; i >= 0; i-- {
+		t := a.order[i]
+		if unacknowledged && t.Ack != nil {
+			continue
+		}
+		out = append(out, *t)
+	}
+	return out
+}
+
+// Close closes the journal
+func (a *Acks) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
@@ -0,0 +1,90 @@
+package history
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestAcks_TrackAcknowledge(t *testing.T) {
+	dir := t.TempDir()
+	a, err := OpenAcks(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("OpenAcks() error = %v", err)
+	}
+
+	for _, id := range []string{"a", "b", "a"} {
+		if err := a.Track(&watcher.Event{ID: id, Kind: "Pod", Namespace: "prod", Name: "api-" + id, EventType: "DELETED"}); err != nil {
+			t.Fatalf("Track() error = %v", err)
+		}
+	}
+	if got := a.List(false); len(got) != 2 || got[0].ID != "b" {
+		t.Fatalf("Expected 2 tracked events newest first, got %+v", got)
+	}
+
+	acked, err := a.Acknowledge("a", Ack{By: "alice"})
+	if err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+	if acked.Ack == nil || acked.Ack.By != "alice" || acked.Ack.At.IsZero() {
+		t.Errorf("Unexpected acknowledgement %+v", acked.Ack)
+	}
+	// 2回目の確認応答は最初の記録を返す
+	if again, _ := a.Acknowledge("a", Ack{By: "bob"}); again.Ack.By != "alice" {
+		t.Errorf("Expected the first acknowledgement to be kept, got %q", again.Ack.By)
+	}
+	if _, err := a.Acknowledge("unknown", Ack{}); !errors.Is(err, ErrNotTracked) {
+		t.Errorf("Expected ErrNotTracked, got %v", err)
+	}
+	if got := a.List(true); len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("Expected only b to be unacknowledged, got %+v", got)
+	}
+	a.Close()
+
+	// 再起動後も状態が復元される
+	a, err = OpenAcks(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("OpenAcks() error = %v", err)
+	}
+	defer a.Close()
+	if got := a.List(true); len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("Expected state to be restored, got %+v", got)
+	}
+	if !a.IsTracked("a") || a.IsTracked("unknown") {
+		t.Error("Unexpected tracked state after reopening")
+	}
+	if !a.IsAcknowledged("a") || a.IsAcknowledged("b") {
+		t.Error("Unexpected acknowledged state after reopening")
+	}
+}
+
+func TestAcks_Retention(t *testing.T) {
+	dir := t.TempDir()
+	a, err := OpenAcks(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenAcks() error = %v", err)
+	}
+	defer a.Close()
+
+	now := time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	_ = a.Track(&watcher.Event{ID: "old", Kind: "Pod", Name: "a"})
+	now = now.Add(90 * time.Minute)
+	_ = a.Track(&watcher.Event{ID: "new", Kind: "Pod", Name: "b"})
+
+	// 保持期間を過ぎたイベントは追跡されない
+	if got := a.List(true); len(got) != 1 || got[0].ID != "new" {
+		t.Errorf("Expected only the recent event, got %+v", got)
+	}
+	if err := a.Track(&watcher.Event{Kind: "Pod"}); err == nil {
+		t.Error("Expected error for an event without ID")
+	}
+
+	// nilのAcksは何も追跡しない
+	var nilAcks *Acks
+	if err := nilAcks.Track(&watcher.Event{ID: "x"}); err != nil || len(nilAcks.List(false)) != 0 {
+		t.Error("Expected nil Acks to track nothing")
+	}
+}
@@ -0,0 +1,40 @@
+// Package history keeps processed events so that past changes can be queried,
+// either in memory (Recent) or on disk with time-based retention (Store).
+package history
+
+import (
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Query selects events. Empty fields match everything.
+type Query struct {
+	Kind      string
+	Namespace string
+	Since     time.Time // Events at or after this time
+	Until     time.Time // Events before this time
+	Limit     int       // Maximum number of events returned, 0 for all
+}
+
+// Matches reports whether e is selected by the query, ignoring the limit
+func (q Query) Matches(e *watcher.Event) bool {
+	if q.Kind != "" && e.Kind != q.Kind {
+		return false
+	}
+	if q.Namespace != "" && e.Namespace != q.Namespace {
+		return false
+	}
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Timestamp.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Lister returns the events selected by a query, newest first
+type Lister interface {
+	List(q Query) ([]*watcher.Event, error)
+}
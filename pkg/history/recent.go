@@ -0,0 +1,68 @@
+package history
+
+import (
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// DefaultRecentEvents is the number of events kept by default
+const DefaultRecentEvents = 100
+
+// Recent keeps the most recent events in memory in a ring buffer.
+// A nil Recent is valid and keeps nothing.
+type Recent struct {
+	mu     sync.Mutex
+	events []*watcher.Event
+	next   int // Index the next event is written to
+	full   bool
+}
+
+// NewRecent creates a Recent keeping up to size events
+func NewRecent(size int) *Recent {
+	if size <= 0 {
+		size = DefaultRecentEvents
+	}
+	return &Recent{events: make([]*watcher.Event, size)}
+}
+
+// Add records an event, replacing the oldest one when full
+func (r *Recent) Add(e *watcher.Event) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// List returns the events selected by q, newest first
+func (r *Recent) List(q Query) ([]*watcher.Event, error) {
+	if r == nil {
+		return nil, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.events)
+	}
+	var out []*watcher.Event
+	for i := 1; i <= count; i++ {
+		e := r.events[(r.next-i+len(r.events))%len(r.events)]
+		if !q.Matches(e) {
+			continue
+		}
+		out = append(out, e)
+		if q.Limit > 0 && len(out) == q.Limit {
+			break
+		}
+	}
+	return out, nil
+}
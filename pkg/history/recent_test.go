@@ -0,0 +1,30 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestRecent_Wraps(t *testing.T) {
+	r := NewRecent(3)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		r.Add(&watcher.Event{Kind: "Pod", Name: name})
+	}
+
+	// 古いイベントから上書きされ、新しい順に返される
+	events, _ := r.List(Query{})
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	if len(names) != 3 || names[0] != "e" || names[2] != "c" {
+		t.Errorf("Expected [e d c], got %v", names)
+	}
+
+	var nilRecent *Recent
+	nilRecent.Add(&watcher.Event{})
+	if got, _ := nilRecent.List(Query{}); got != nil {
+		t.Errorf("Expected nil from a nil Recent, got %v", got)
+	}
+}
@@ -0,0 +1,222 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// DefaultRetention is how long events are kept by default
+const DefaultRetention = 7 * 24 * time.Hour
+
+// Segment files hold the events recorded in one hour (UTC), e.g. events-2024010114.jsonl
+const (
+	segmentPrefix = "events-"
+	segmentSuffix = ".jsonl"
+	segmentLayout = "2006010215"
+)
+
+// Store appends events to hourly JSON-lines segment files in a directory and
+// removes segments older than the retention
+type Store struct {
+	dir       string
+	retention time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File
+	segment time.Time // Hour of the open segment
+}
+
+// Open opens the store in dir, creating the directory if needed
+func Open(dir string, retention time.Duration) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("history path is required")
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	s := &Store{dir: dir, retention: retention, now: time.Now}
+	s.prune(s.now())
+	return s, nil
+}
+
+// Add appends an event to the segment of the current hour
+func (s *Store) Add(e *watcher.Event) error {
+	// Object references are not persisted, only the extracted event fields
+	stored := *e
+	stored.Ref = nil
+	line, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hour := s.now().UTC().Truncate(time.Hour)
+	if s.file == nil || !hour.Equal(s.segment) {
+		if err := s.openSegment(hour); err != nil {
+			return err
+		}
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// openSegment switches to the segment of hour and removes expired segments.
+// Must be called with mu held.
+func (s *Store) openSegment(hour time.Time) error {
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+	f, err := os.OpenFile(s.segmentPath(hour), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history segment: %w", err)
+	}
+	s.file = f
+	s.segment = hour
+	s.prune(hour)
+	return nil
+}
+
+func (s *Store) segmentPath(hour time.Time) string {
+	return filepath.Join(s.dir, segmentPrefix+hour.Format(segmentLayout)+segmentSuffix)
+}
+
+// segments returns the hours of the segment files, oldest first
+func (s *Store) segments() ([]time.Time, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+	var hours []time.Time
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		hour, err := time.Parse(segmentLayout, strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix))
+		if err != nil {
+			continue
+		}
+		hours = append(hours, hour)
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Before(hours[j]) })
+	return hours, nil
+}
+
+// prune removes segments that ended before the retention
+func (s *Store) prune(now time.Time) {
+	hours, err := s.segments()
+	if err != nil {
+		slog.Error("Failed to prune history", "error", err)
+		return
+	}
+	cutoff := now.Add(-s.retention)
+	for _, hour := range hours {
+		if hour.Add(time.Hour).After(cutoff) {
+			break
+		}
+		if err := os.Remove(s.segmentPath(hour)); err != nil {
+			slog.Error("Failed to remove expired history segment", "segment", hour.Format(segmentLayout), "error", err)
+		}
+	}
+}
+
+// List returns the stored events selected by q, newest first. Only the
+// listing of the segments is done under the lock: segments are append-only,
+// so they are read while events are added, at worst missing a line being
+// written, and a segment pruned meanwhile reads as empty.
+func (s *Store) List(q Query) ([]*watcher.Event, error) {
+	s.mu.Lock()
+	hours, err := s.segments()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*watcher.Event
+	for i := len(hours) - 1; i >= 0; i-- {
+		hour := hours[i]
+		// Segments are selected by recording time; allow for events recorded shortly
+		// after their timestamp, e.g. batched or delayed by the informer resync
+		if !q.Until.IsZero() && !hour.Before(q.Until.Add(time.Hour)) {
+			continue
+		}
+		if !q.Since.IsZero() && hour.Add(time.Hour).Before(q.Since) {
+			break
+		}
+
+		events, err := s.readSegment(hour)
+		if err != nil {
+			return nil, err
+		}
+		for j := len(events) - 1; j >= 0; j-- {
+			if !q.Matches(events[j]) {
+				continue
+			}
+			out = append(out, events[j])
+			if q.Limit > 0 && len(out) == q.Limit {
+				return out, nil
+			}
+		}
+	}
+	return out, nil
+}
+
+// readSegment reads the events of a segment in recording order, skipping lines
+// that cannot be parsed, such as a line cut off by a crash
+func (s *Store) readSegment(hour time.Time) ([]*watcher.Event, error) {
+	f, err := os.Open(s.segmentPath(hour))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history segment: %w", err)
+	}
+	defer f.Close()
+
+	var events []*watcher.Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e watcher.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		events = append(events, &e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history segment: %w", err)
+	}
+	return events, nil
+}
+
+// Close closes the open segment
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
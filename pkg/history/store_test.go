@@ -0,0 +1,126 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestStore_AddList(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, time.Hour*24)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	base := time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)
+	now := base
+	s.now = func() time.Time { return now }
+
+	add := func(minutes int, kind, namespace, name string) {
+		now = base.Add(time.Duration(minutes) * time.Minute)
+		e := &watcher.Event{Kind: kind, Namespace: namespace, Name: name, EventType: "UPDATED", Timestamp: now}
+		if err := s.Add(e); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	add(0, "Pod", "prod", "a")
+	add(30, "Deployment", "prod", "b")
+	add(70, "Pod", "dev", "c") // 次の1時間のセグメント
+	add(130, "Pod", "prod", "d")
+
+	names := func(q Query) []string {
+		events, err := s.List(q)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		var out []string
+		for _, e := range events {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+
+	if got := names(Query{}); len(got) != 4 || got[0] != "d" || got[3] != "a" {
+		t.Errorf("Expected all events newest first, got %v", got)
+	}
+	if got := names(Query{Kind: "Pod", Namespace: "prod"}); len(got) != 2 || got[0] != "d" || got[1] != "a" {
+		t.Errorf("Expected prod Pods, got %v", got)
+	}
+	// 14:00〜15:00に発生したイベント
+	got := names(Query{Since: base, Until: base.Add(time.Hour)})
+	if len(got) != 2 || got[0] != "b" || got[1] != "a" {
+		t.Errorf("Expected events between 14:00 and 15:00, got %v", got)
+	}
+	if got := names(Query{Limit: 1}); len(got) != 1 || got[0] != "d" {
+		t.Errorf("Expected the newest event, got %v", got)
+	}
+
+	// 再オープン後も読み込める
+	_ = s.Close()
+	reopened, err := Open(dir, time.Since(base)+24*time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reopened.Close()
+	reopened.now = func() time.Time { return now }
+	if events, _ := reopened.List(Query{}); len(events) != 4 {
+		t.Errorf("Expected 4 events after reopening, got %d", len(events))
+	}
+}
+
+func TestStore_Retention(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	for i := 0; i < 5; i++ {
+		if err := s.Add(&watcher.Event{Kind: "Pod", Name: "web", Timestamp: now}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		now = now.Add(time.Hour)
+	}
+
+	// 14時の時点で、12時より前に終わったセグメントは保持期間（2時間）を過ぎている
+	segments, _ := filepath.Glob(filepath.Join(dir, "events-*.jsonl"))
+	if len(segments) != 3 {
+		t.Errorf("Expected 3 segments within the retention, got %v", segments)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events-2024010111.jsonl")); !os.IsNotExist(err) {
+		t.Error("Expected the expired segment to be removed")
+	}
+}
+
+func TestStore_SkipsCorruptLines(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	path := filepath.Join(dir, "events-2024010110.jsonl")
+	data := `{"Kind":"Pod","Name":"ok","Timestamp":"2024-01-01T10:00:00Z"}` + "\n" + `{"Kind":"Po`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(dir, time.Since(now)+24*time.Hour)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+	s.now = func() time.Time { return now }
+
+	events, err := s.List(Query{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Name != "ok" {
+		t.Errorf("Expected the valid event only, got %v", events)
+	}
+}
@@ -0,0 +1,74 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"0 2 * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"0 2 * * SAT",
+	} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// 2024-01-01は月曜日
+	base := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * 6", time.Date(2024, 1, 6, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 7", time.Date(2024, 1, 7, 2, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 3 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 10 1,15 * *", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
+		// 日と曜日の両方を指定した場合はどちらかに一致すればよい
+		{"0 0 15 * 3", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		// 存在しない日付
+		{"0 0 31 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule() error = %v", err)
+			}
+			if got := s.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_NextLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	s, err := ParseSchedule("0 2 * * *")
+	if err != nil {
+		t.Fatalf("ParseSchedule() error = %v", err)
+	}
+
+	// スケジュールは渡した時刻のタイムゾーンで評価される
+	got := s.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).In(tokyo))
+	if want := time.Date(2024, 1, 2, 2, 0, 0, 0, tokyo); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
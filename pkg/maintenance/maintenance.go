@@ -0,0 +1,216 @@
+// Package maintenance suppresses events during recurring maintenance windows,
+// optionally delivering them as a digest once the window ends.
+package maintenance
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	_ "time/tzdata" // Time zones of schedules must resolve in minimal images
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/filter"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Window is a compiled maintenance window
+type Window struct {
+	Name     string
+	Action   string // config.MaintenanceSuppress or config.MaintenanceDigest
+	schedule *Schedule
+	duration time.Duration
+	location *time.Location
+	matcher  *filter.EventMatcher // Empty matches all events
+}
+
+// NewWindows compiles the configured windows
+func NewWindows(cfgs []config.MaintenanceWindow) ([]*Window, error) {
+	windows := make([]*Window, 0, len(cfgs))
+	for _, c := range cfgs {
+		schedule, err := ParseSchedule(c.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %s: %w", c.Name, err)
+		}
+		location := time.Local
+		if c.Timezone != "" {
+			if location, err = time.LoadLocation(c.Timezone); err != nil {
+				return nil, fmt.Errorf("maintenance window %s: %w", c.Name, err)
+			}
+		}
+		matcher, err := filter.NewEventMatcher(c.MatcherConfig)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %s: %w", c.Name, err)
+		}
+		windows = append(windows, &Window{
+			Name:     c.Name,
+			Action:   c.Action,
+			schedule: schedule,
+			duration: time.Duration(c.DurationMinutes) * time.Minute,
+			location: location,
+			matcher:  matcher,
+		})
+	}
+	return windows, nil
+}
+
+// Active returns the start of the window occurrence containing t, if any
+func (w *Window) Active(t time.Time) (time.Time, bool) {
+	// The occurrence containing t is the first one starting after t - duration
+	start := w.schedule.Next(t.In(w.location).Add(-w.duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// Digest holds the events collected during one occurrence of a digest window
+type Digest struct {
+	Window    string
+	StartTime time.Time
+	EndTime   time.Time
+	Events    []*watcher.Event
+}
+
+// DigestFunc delivers a digest when its window ends
+type DigestFunc func(d *Digest)
+
+// Manager evaluates events against the maintenance windows.
+// A nil Manager is valid and suppresses nothing.
+type Manager struct {
+	onDigest DigestFunc
+	now      func() time.Time
+
+	mu      sync.Mutex
+	windows []*Window
+	digests map[string]*Digest // Window name -> digest of the current occurrence
+	stopC   chan struct{}
+	done    chan struct{}
+}
+
+// NewManager creates a Manager and starts delivering digests as windows end
+func NewManager(onDigest DigestFunc) *Manager {
+	m := &Manager{
+		onDigest: onDigest,
+		now:      time.Now,
+		digests:  make(map[string]*Digest),
+		stopC:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go m.loop()
+	return m
+}
+
+// SetWindows replaces the windows. Pending digests of windows that were removed
+// or are no longer digests are delivered immediately.
+func (m *Manager) SetWindows(windows []*Window) {
+	m.mu.Lock()
+	m.windows = windows
+	var flush []*Digest
+	for name, d := range m.digests {
+		if w := m.window(name); w == nil || w.Action != config.MaintenanceDigest {
+			flush = append(flush, d)
+			delete(m.digests, name)
+		}
+	}
+	m.mu.Unlock()
+
+	m.deliver(flush)
+}
+
+// window returns the window with the given name. Must be called with mu held.
+func (m *Manager) window(name string) *Window {
+	for _, w := range m.windows {
+		if w.Name == name {
+			return w
+		}
+	}
+	return nil
+}
+
+// Suppress reports whether the event falls into an active window whose
+// conditions it matches. Events of digest windows are kept for the digest.
+func (m *Manager) Suppress(event *watcher.Event) (*Window, bool) {
+	if m == nil {
+		return nil, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for _, w := range m.windows {
+		start, ok := w.Active(now)
+		if !ok || (!w.matcher.IsEmpty() && !w.matcher.Matches(event)) {
+			continue
+		}
+		if w.Action == config.MaintenanceDigest {
+			d := m.digests[w.Name]
+			if d == nil {
+				d = &Digest{Window: w.Name, StartTime: start, EndTime: start.Add(w.duration)}
+				m.digests[w.Name] = d
+			}
+			d.Events = append(d.Events, event)
+		}
+		return w, true
+	}
+	return nil, false
+}
+
+// Stop stops the manager and delivers the pending digests
+func (m *Manager) Stop() {
+	if m == nil {
+		return
+	}
+	close(m.stopC)
+	<-m.done
+
+	m.mu.Lock()
+	flush := make([]*Digest, 0, len(m.digests))
+	for name, d := range m.digests {
+		flush = append(flush, d)
+		delete(m.digests, name)
+	}
+	m.mu.Unlock()
+
+	m.deliver(flush)
+}
+
+func (m *Manager) loop() {
+	defer close(m.done)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopC:
+			return
+		case <-ticker.C:
+			m.flushEnded()
+		}
+	}
+}
+
+// flushEnded delivers the digests of windows that have ended
+func (m *Manager) flushEnded() {
+	m.mu.Lock()
+	now := m.now()
+	var flush []*Digest
+	for name, d := range m.digests {
+		if !now.Before(d.EndTime) {
+			flush = append(flush, d)
+			delete(m.digests, name)
+		}
+	}
+	m.mu.Unlock()
+
+	m.deliver(flush)
+}
+
+func (m *Manager) deliver(digests []*Digest) {
+	for _, d := range digests {
+		slog.Info("Maintenance window ended, sending digest", "window", d.Window, "events", len(d.Events))
+		if m.onDigest != nil {
+			m.onDigest(d)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// newTestManager creates a Manager driven by now, without the background loop
+func newTestManager(t *testing.T, now *time.Time, cfgs ...config.MaintenanceWindow) (*Manager, *[]*Digest) {
+	t.Helper()
+	windows, err := NewWindows(cfgs)
+	if err != nil {
+		t.Fatalf("NewWindows() error = %v", err)
+	}
+	var digests []*Digest
+	m := &Manager{
+		onDigest: func(d *Digest) { digests = append(digests, d) },
+		now:      func() time.Time { return *now },
+		digests:  make(map[string]*Digest),
+	}
+	m.SetWindows(windows)
+	return m, &digests
+}
+
+func TestWindow_Active(t *testing.T) {
+	windows, err := NewWindows([]config.MaintenanceWindow{{
+		Name:            "node-upgrade",
+		Schedule:        "0 2 * * 6",
+		DurationMinutes: 120,
+		Timezone:        "Asia/Tokyo",
+	}})
+	if err != nil {
+		t.Fatalf("NewWindows() error = %v", err)
+	}
+	w := windows[0]
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	start := time.Date(2024, 1, 6, 2, 0, 0, 0, tokyo)
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		active bool
+	}{
+		{"開始前", start.Add(-time.Second), false},
+		{"開始時刻", start, true},
+		{"期間中（UTC）", start.Add(90 * time.Minute).UTC(), true},
+		{"終了直前", start.Add(2*time.Hour - time.Second), true},
+		{"終了時刻", start.Add(2 * time.Hour), false},
+		{"翌日", start.Add(24 * time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := w.Active(tt.t)
+			if ok != tt.active {
+				t.Fatalf("Active(%v) = %v, want %v", tt.t, ok, tt.active)
+			}
+			if ok && !got.Equal(start) {
+				t.Errorf("Expected start %v, got %v", start, got)
+			}
+		})
+	}
+}
+
+func TestNewWindows_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.MaintenanceWindow
+	}{
+		{"不正なcron式", config.MaintenanceWindow{Name: "w", Schedule: "0 2 * *", DurationMinutes: 60}},
+		{"不明なタイムゾーン", config.MaintenanceWindow{Name: "w", Schedule: "0 2 * * *", DurationMinutes: 60, Timezone: "Mars/Olympus"}},
+		{"不正な式", config.MaintenanceWindow{Name: "w", Schedule: "0 2 * * *", DurationMinutes: 60, MatcherConfig: config.MatcherConfig{Expression: "kind =="}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWindows([]config.MaintenanceWindow{tt.cfg}); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestManager_Suppress(t *testing.T) {
+	now := time.Date(2024, 1, 6, 2, 30, 0, 0, time.UTC)
+	m, digests := newTestManager(t, &now, config.MaintenanceWindow{
+		Name:            "node-upgrade",
+		Schedule:        "0 2 * * 6",
+		DurationMinutes: 60,
+		Timezone:        "UTC",
+		Action:          config.MaintenanceSuppress,
+		MatcherConfig:   config.MatcherConfig{Kinds: []string{"Node"}},
+	})
+
+	if w, ok := m.Suppress(&watcher.Event{Kind: "Node", Name: "node-1"}); !ok || w.Name != "node-upgrade" {
+		t.Error("Expected matching event to be suppressed")
+	}
+	if _, ok := m.Suppress(&watcher.Event{Kind: "Pod", Name: "web"}); ok {
+		t.Error("Expected event not matching the conditions to pass")
+	}
+
+	now = now.Add(time.Hour)
+	if _, ok := m.Suppress(&watcher.Event{Kind: "Node", Name: "node-1"}); ok {
+		t.Error("Expected event after the window to pass")
+	}
+	m.flushEnded()
+	if len(*digests) != 0 {
+		t.Errorf("Expected no digest for a suppress window, got %d", len(*digests))
+	}
+}
+
+func TestManager_Digest(t *testing.T) {
+	now := time.Date(2024, 1, 6, 2, 30, 0, 0, time.UTC)
+	m, digests := newTestManager(t, &now, config.MaintenanceWindow{
+		Name:            "node-upgrade",
+		Schedule:        "0 2 * * 6",
+		DurationMinutes: 60,
+		Timezone:        "UTC",
+		Action:          config.MaintenanceDigest,
+	})
+
+	m.Suppress(&watcher.Event{Kind: "Node", Name: "node-1"})
+	m.Suppress(&watcher.Event{Kind: "Pod", Name: "web"})
+
+	// 期間中はダイジェストを送らない
+	m.flushEnded()
+	if len(*digests) != 0 {
+		t.Fatalf("Expected no digest during the window, got %d", len(*digests))
+	}
+
+	now = now.Add(30 * time.Minute)
+	m.flushEnded()
+	if len(*digests) != 1 {
+		t.Fatalf("Expected one digest after the window, got %d", len(*digests))
+	}
+	d := (*digests)[0]
+	if d.Window != "node-upgrade" || len(d.Events) != 2 {
+		t.Errorf("Unexpected digest %+v", d)
+	}
+	if !d.StartTime.Equal(time.Date(2024, 1, 6, 2, 0, 0, 0, time.UTC)) || !d.EndTime.Equal(now) {
+		t.Errorf("Unexpected digest period %v - %v", d.StartTime, d.EndTime)
+	}
+}
+
+func TestManager_SetWindowsFlushesRemoved(t *testing.T) {
+	now := time.Date(2024, 1, 6, 2, 30, 0, 0, time.UTC)
+	m, digests := newTestManager(t, &now, config.MaintenanceWindow{
+		Name:            "node-upgrade",
+		Schedule:        "0 2 * * 6",
+		DurationMinutes: 60,
+		Timezone:        "UTC",
+		Action:          config.MaintenanceDigest,
+	})
+	m.Suppress(&watcher.Event{Kind: "Node", Name: "node-1"})
+
+	// 設定から削除されたウィンドウのダイジェストはすぐに送る
+	m.SetWindows(nil)
+	if len(*digests) != 1 {
+		t.Errorf("Expected the pending digest to be delivered, got %d", len(*digests))
+	}
+	if _, ok := m.Suppress(&watcher.Event{Kind: "Node", Name: "node-1"}); ok {
+		t.Error("Expected no suppression without windows")
+	}
+}
+
+func TestManager_Nil(t *testing.T) {
+	var m *Manager
+	if _, ok := m.Suppress(&watcher.Event{Kind: "Pod"}); ok {
+		t.Error("Expected nil manager to suppress nothing")
+	}
+	m.Stop()
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Anomaly reasons reported by kube_watcher_anomalies
+const (
+	AnomalyPodFailed           = "PodFailed"           // Pod phase is Failed or Unknown
+	AnomalyReplicasUnavailable = "ReplicasUnavailable" // Fewer ready replicas than desired
+	AnomalyRolloutStalled      = "RolloutStalled"      // Deployment is no longer progressing
+)
+
+type resourceKey struct {
+	kind, namespace, name string
+}
+
+type anomaly struct {
+	kind, namespace, reason string
+}
+
+// EventMetrics counts events and tracks resources currently in an abnormal
+// state, so that alerting rules can be written in PromQL.
+// A nil EventMetrics is valid and records nothing.
+type EventMetrics struct {
+	mu        sync.Mutex
+	events    map[[3]string]float64 // kind, namespace, event type -> count
+	anomalies map[resourceKey]anomaly
+}
+
+// NewEventMetrics creates an empty EventMetrics
+func NewEventMetrics() *EventMetrics {
+	return &EventMetrics{
+		events:    make(map[[3]string]float64),
+		anomalies: make(map[resourceKey]anomaly),
+	}
+}
+
+// Record counts an event and updates the anomaly state of its resource
+func (m *EventMetrics) Record(e *watcher.Event) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events[[3]string{e.Kind, e.Namespace, e.EventType}]++
+
+	key := resourceKey{e.Kind, e.Namespace, e.Name}
+	reason := anomalyReason(e)
+	if reason == "" || e.EventType == "DELETED" {
+		delete(m.anomalies, key)
+		return
+	}
+	m.anomalies[key] = anomaly{kind: e.Kind, namespace: e.Namespace, reason: reason}
+}
+
+// anomalyReason returns why the resource is abnormal according to the event, or ""
+func anomalyReason(e *watcher.Event) string {
+	switch {
+	case e.Kind == "Pod" && (e.Status == "Failed" || e.Status == "Unknown"):
+		return AnomalyPodFailed
+	case e.Kind == "Deployment" && e.Status == "False":
+		// Status holds the Progressing condition, which turns False when the progress deadline is exceeded
+		return AnomalyRolloutStalled
+	case e.Replicas != nil && e.Replicas.Ready < e.Replicas.Desired:
+		return AnomalyReplicasUnavailable
+	}
+	return ""
+}
+
+// Register registers the event metrics with the registry
+func (m *EventMetrics) Register(registry *Registry) {
+	registry.RegisterVec("kube_watcher_event_total", "Events that passed the filters.", TypeCounter,
+		[]string{"kind", "namespace", "event_type"}, m.eventSamples)
+	registry.RegisterVec("kube_watcher_anomalies", "Resources currently in an abnormal state according to their latest event.", TypeGauge,
+		[]string{"kind", "namespace", "reason"}, m.anomalySamples)
+}
+
+func (m *EventMetrics) eventSamples() []Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	samples := make([]Sample, 0, len(m.events))
+	for labels, count := range m.events {
+		samples = append(samples, Sample{LabelValues: labels[:], Value: count})
+	}
+	return samples
+}
+
+func (m *EventMetrics) anomalySamples() []Sample {
+	m.mu.Lock()
+	counts := make(map[anomaly]float64)
+	for _, a := range m.anomalies {
+		counts[a]++
+	}
+	m.mu.Unlock()
+
+	samples := make([]Sample, 0, len(counts))
+	for a, count := range counts {
+		samples = append(samples, Sample{LabelValues: []string{a.kind, a.namespace, a.reason}, Value: count})
+	}
+	return samples
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func scrape(t *testing.T, m *EventMetrics) string {
+	t.Helper()
+	r := NewRegistry()
+	m.Register(r)
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	return buf.String()
+}
+
+func TestEventMetrics_EventTotal(t *testing.T) {
+	m := NewEventMetrics()
+	m.Record(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web-1", EventType: "UPDATED"})
+	m.Record(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web-2", EventType: "UPDATED"})
+	m.Record(&watcher.Event{Kind: "Node", Name: "node-1", EventType: "ADDED"})
+
+	out := scrape(t, m)
+	for _, line := range []string{
+		`kube_watcher_event_total{kind="Pod",namespace="prod",event_type="UPDATED"} 2`,
+		`kube_watcher_event_total{kind="Node",namespace="",event_type="ADDED"} 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, out)
+		}
+	}
+}
+
+func TestEventMetrics_Anomalies(t *testing.T) {
+	m := NewEventMetrics()
+	m.Record(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web-1", EventType: "UPDATED", Status: "Failed"})
+	m.Record(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web-2", EventType: "UPDATED", Status: "Failed"})
+	m.Record(&watcher.Event{Kind: "Deployment", Namespace: "prod", Name: "api", EventType: "UPDATED",
+		Status: "True", Replicas: &watcher.ReplicaInfo{Desired: 3, Ready: 1}})
+	m.Record(&watcher.Event{Kind: "Deployment", Namespace: "dev", Name: "api", EventType: "UPDATED",
+		Status: "False", Reason: "ProgressDeadlineExceeded", Replicas: &watcher.ReplicaInfo{Desired: 1, Ready: 0}})
+
+	out := scrape(t, m)
+	for _, line := range []string{
+		`kube_watcher_anomalies{kind="Pod",namespace="prod",reason="PodFailed"} 2`,
+		`kube_watcher_anomalies{kind="Deployment",namespace="prod",reason="ReplicasUnavailable"} 1`,
+		`kube_watcher_anomalies{kind="Deployment",namespace="dev",reason="RolloutStalled"} 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, out)
+		}
+	}
+
+	// 正常な状態に戻ったリソースや削除されたリソースは除外される
+	m.Record(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web-1", EventType: "UPDATED", Status: "Running"})
+	m.Record(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web-2", EventType: "DELETED", Status: "Failed"})
+	m.Record(&watcher.Event{Kind: "Deployment", Namespace: "prod", Name: "api", EventType: "UPDATED",
+		Status: "True", Replicas: &watcher.ReplicaInfo{Desired: 3, Ready: 3}})
+
+	out = scrape(t, m)
+	if strings.Contains(out, `reason="PodFailed"`) || strings.Contains(out, `reason="ReplicasUnavailable"`) {
+		t.Errorf("Expected resolved anomalies to be removed:\n%s", out)
+	}
+	if !strings.Contains(out, `reason="RolloutStalled"} 1`) {
+		t.Errorf("Expected the stalled rollout to remain:\n%s", out)
+	}
+}
+
+func TestEventMetrics_Nil(t *testing.T) {
+	var m *EventMetrics
+	m.Record(&watcher.Event{Kind: "Pod"})
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the default histogram buckets in seconds, suited to the
+// latencies of in-process stages and HTTP requests
+var DefBuckets = []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec counts observations in buckets, separately for each combination
+// of label values. A nil HistogramVec is valid and records nothing.
+type HistogramVec struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram // Joined label values -> histogram
+}
+
+// histogram is the state of one labeled histogram
+type histogram struct {
+	labelValues []string
+	counts      []uint64 // Per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec creates a HistogramVec with the given upper bounds, or
+// DefBuckets when none are given
+func NewHistogramVec(buckets ...float64) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &HistogramVec{
+		buckets: sorted,
+		series:  make(map[string]*histogram),
+	}
+}
+
+// Observe records a value for the label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+// HistogramSample is the state of one labeled histogram
+type HistogramSample struct {
+	LabelValues []string
+	Buckets     []float64 // Upper bounds
+	Counts      []uint64  // Cumulative counts per bucket
+	Count       uint64
+	Sum         float64
+}
+
+// Samples returns the current state of each labeled histogram
+func (h *HistogramVec) Samples() []HistogramSample {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := make([]HistogramSample, 0, len(h.series))
+	for _, s := range h.series {
+		counts := make([]uint64, len(s.counts))
+		var cumulative uint64
+		for i, c := range s.counts {
+			cumulative += c
+			counts[i] = cumulative
+		}
+		samples = append(samples, HistogramSample{
+			LabelValues: s.labelValues,
+			Buckets:     h.buckets,
+			Counts:      counts,
+			Count:       s.count,
+			Sum:         s.sum,
+		})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].LabelValues, "\xff") < strings.Join(samples[j].LabelValues, "\xff")
+	})
+	return samples
+}
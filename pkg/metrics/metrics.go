@@ -0,0 +1,200 @@
+// Package metrics provides a minimal Prometheus-compatible metrics registry.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Type represents the Prometheus metric type
+type Type string
+
+// Metric type constants
+const (
+	// TypeCounter is a monotonically increasing value
+	TypeCounter Type = "counter"
+	// TypeGauge is a value that can go up and down
+	TypeGauge Type = "gauge"
+	// TypeHistogram counts observations in buckets
+	TypeHistogram Type = "histogram"
+)
+
+// ValueFunc returns the current value of a metric
+type ValueFunc func() float64
+
+// Sample is one labeled value of a metric
+type Sample struct {
+	LabelValues []string // In the order of the registered label names
+	Value       float64
+}
+
+// SamplesFunc returns the current samples of a labeled metric
+type SamplesFunc func() []Sample
+
+// collector represents a registered metric
+type collector struct {
+	name    string
+	help    string
+	typ     Type
+	fn      ValueFunc
+	labels  []string
+	samples SamplesFunc   // Set instead of fn for labeled metrics
+	hist    *HistogramVec // Set instead of fn for histograms
+}
+
+// Registry holds registered metrics and renders them in the Prometheus text format
+type Registry struct {
+	collectors map[string]collector
+	mu         sync.RWMutex
+}
+
+// NewRegistry creates a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]collector),
+	}
+}
+
+// Register registers a metric whose value is read from fn at scrape time.
+// Registering the same name again replaces the previous metric.
+func (r *Registry) Register(name, help string, typ Type, fn ValueFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors[name] = collector{
+		name: name,
+		help: help,
+		typ:  typ,
+		fn:   fn,
+	}
+}
+
+// RegisterVec registers a metric with labels whose samples are read from fn at
+// scrape time. Registering the same name again replaces the previous metric.
+func (r *Registry) RegisterVec(name, help string, typ Type, labels []string, fn SamplesFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors[name] = collector{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		labels:  labels,
+		samples: fn,
+	}
+}
+
+// RegisterHistogram registers a histogram with labels. Registering the same
+// name again replaces the previous metric.
+func (r *Registry) RegisterHistogram(name, help string, labels []string, h *HistogramVec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors[name] = collector{
+		name:   name,
+		help:   help,
+		typ:    TypeHistogram,
+		labels: labels,
+		hist:   h,
+	}
+}
+
+// WriteTo writes all registered metrics to w in the Prometheus text format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.RUnlock()
+
+	// Sort by name for stable output
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].name < collectors[j].name
+	})
+
+	var b strings.Builder
+	for _, c := range collectors {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", c.name, c.help, c.name, c.typ)
+		if c.typ == TypeHistogram {
+			writeHistogram(&b, c)
+			continue
+		}
+		if c.samples == nil {
+			fmt.Fprintf(&b, "%s %s\n", c.name, formatValue(c.fn()))
+			continue
+		}
+		for _, s := range sortedSamples(c.samples()) {
+			fmt.Fprintf(&b, "%s{%s} %s\n", c.name, formatLabels(c.labels, s.LabelValues), formatValue(s.Value))
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeHistogram writes the buckets, sum and count of each labeled histogram
+func writeHistogram(b *strings.Builder, c collector) {
+	for _, s := range c.hist.Samples() {
+		labels := formatLabels(c.labels, s.LabelValues)
+		sep := ""
+		if labels != "" {
+			sep = ","
+		}
+		for i, bound := range s.Buckets {
+			fmt.Fprintf(b, "%s_bucket{%s%sle=\"%s\"} %d\n", c.name, labels, sep, formatValue(bound), s.Counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s%sle=\"+Inf\"} %d\n", c.name, labels, sep, s.Count)
+		if labels == "" {
+			fmt.Fprintf(b, "%s_sum %s\n%s_count %d\n", c.name, formatValue(s.Sum), c.name, s.Count)
+			continue
+		}
+		fmt.Fprintf(b, "%s_sum{%s} %s\n%s_count{%s} %d\n", c.name, labels, formatValue(s.Sum), c.name, labels, s.Count)
+	}
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels renders name="value" pairs, escaping backslashes, quotes and newlines
+func formatLabels(names, values []string) string {
+	pairs := make([]string, 0, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(value)+`"`)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// sortedSamples orders samples by label values for stable output
+func sortedSamples(samples []Sample) []Sample {
+	sort.Slice(samples, func(i, j int) bool {
+		a, b := samples[i].LabelValues, samples[j].LabelValues
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return samples
+}
+
+// Handler returns an http.Handler that serves the registered metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+	r.Register("b_total", "Second metric", TypeCounter, func() float64 { return 3 })
+	r.Register("a_size", "First metric", TypeGauge, func() float64 { return 1.5 })
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	expected := "# HELP a_size First metric\n# TYPE a_size gauge\na_size 1.5\n" +
+		"# HELP b_total Second metric\n# TYPE b_total counter\nb_total 3\n"
+	if buf.String() != expected {
+		t.Errorf("WriteTo() output =\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
+
+func TestRegistry_RegisterReplaces(t *testing.T) {
+	r := NewRegistry()
+	r.Register("value", "Value", TypeGauge, func() float64 { return 1 })
+	r.Register("value", "Value", TypeGauge, func() float64 { return 2 })
+
+	var buf bytes.Buffer
+	_, _ = r.WriteTo(&buf)
+
+	if strings.Count(buf.String(), "\nvalue ") != 1 || !strings.Contains(buf.String(), "value 2\n") {
+		t.Errorf("Expected a single replaced metric, got:\n%s", buf.String())
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.Register("up", "Up", TypeGauge, func() float64 { return 1 })
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Unexpected Content-Type %q", rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "up 1\n") {
+		t.Errorf("Expected body to contain metric, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestRegistry_RegisterVec(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterVec("events_total", "Events", TypeCounter, []string{"kind", "namespace"}, func() []Sample {
+		return []Sample{
+			{LabelValues: []string{"Pod", "prod"}, Value: 2},
+			{LabelValues: []string{"Deployment", `a"b\c`}, Value: 1},
+		}
+	})
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	// サンプルはラベル値の順に並び、ラベル値はエスケープされる
+	expected := "# HELP events_total Events\n# TYPE events_total counter\n" +
+		`events_total{kind="Deployment",namespace="a\"b\\c"} 1` + "\n" +
+		`events_total{kind="Pod",namespace="prod"} 2` + "\n"
+	if buf.String() != expected {
+		t.Errorf("WriteTo() output =\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
+
+func TestRegistry_RegisterHistogram(t *testing.T) {
+	h := NewHistogramVec(0.1, 1)
+	h.Observe(0.05, "filter")
+	h.Observe(0.5, "filter")
+	h.Observe(3, "filter")
+
+	r := NewRegistry()
+	r.RegisterHistogram("duration_seconds", "Duration", []string{"stage"}, h)
+
+	var buf bytes.Buffer
+	_, _ = r.WriteTo(&buf)
+
+	// バケットは累積で、+Infは全件数になる
+	expected := "# HELP duration_seconds Duration\n# TYPE duration_seconds histogram\n" +
+		"duration_seconds_bucket{stage=\"filter\",le=\"0.1\"} 1\n" +
+		"duration_seconds_bucket{stage=\"filter\",le=\"1\"} 2\n" +
+		"duration_seconds_bucket{stage=\"filter\",le=\"+Inf\"} 3\n" +
+		"duration_seconds_sum{stage=\"filter\"} 3.55\n" +
+		"duration_seconds_count{stage=\"filter\"} 3\n"
+	if buf.String() != expected {
+		t.Errorf("WriteTo() output =\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
+
+func TestHistogramVec_Nil(t *testing.T) {
+	var h *HistogramVec
+	h.Observe(1, "filter")
+	if len(h.Samples()) != 0 {
+		t.Error("Expected a nil HistogramVec to record nothing")
+	}
+}
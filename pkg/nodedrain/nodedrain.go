@@ -0,0 +1,192 @@
+// Package nodedrain detects Nodes being cordoned and drained. The cordon of a
+// Node and the Pod evictions from it are held back and handed over as one
+// drain, instead of a message per evicted Pod.
+package nodedrain
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// maxDuration bounds how long the evictions of a drain are collected, so that
+// a Node that keeps losing Pods is still reported
+const maxDuration = 10 * time.Minute
+
+// Drain is the cordon of a Node and the Pod evictions from it
+type Drain struct {
+	Node      string
+	Cordon    *watcher.Event   // The Node's cordon; nil when the drain was detected from evictions alone
+	Evictions []*watcher.Event // Evicted Pods, in arrival order
+	Earlier   int              // Evictions sent before the drain was detected
+	StartTime time.Time
+	EndTime   time.Time // Arrival of the last event
+}
+
+// Evicted returns the number of Pods evicted from the Node
+func (d *Drain) Evicted() int {
+	return len(d.Evictions) + d.Earlier
+}
+
+// Detector holds back the cordon of a Node and the evictions from it, and
+// hands them over as one drain once no eviction arrived for the window. A
+// drain is also detected without a cordon when at least minEvictions Pods are
+// evicted from a Node within the window.
+type Detector struct {
+	window       time.Duration
+	minEvictions int
+	onDrain      func(*Drain)
+	now          func() time.Time
+
+	mu     sync.Mutex
+	drains map[string]*Drain      // Node -> drain collecting evictions
+	recent map[string][]time.Time // Node -> evictions within the window outside drains
+	stopC  chan struct{}
+	done   chan struct{}
+}
+
+// NewDetector creates a Detector and starts handing over completed drains
+func NewDetector(window time.Duration, minEvictions int, onDrain func(*Drain)) *Detector {
+	d := &Detector{
+		window:       window,
+		minEvictions: minEvictions,
+		onDrain:      onDrain,
+		now:          time.Now,
+		drains:       make(map[string]*Drain),
+		recent:       make(map[string][]time.Time),
+		stopC:        make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go d.loop()
+	return d
+}
+
+// isCordon reports whether the event is a Node becoming unschedulable
+func isCordon(event *watcher.Event) bool {
+	if event.Kind != "Node" || event.EventType != "UPDATED" || event.Status != watcher.StatusCordoned {
+		return false
+	}
+	for _, c := range event.Changes {
+		if c.Field == "status" {
+			return true
+		}
+	}
+	return false
+}
+
+// isEviction reports whether the event is a Pod removed from its Node: a
+// deletion, as done by a drain, or an eviction by the kubelet
+func isEviction(event *watcher.Event) bool {
+	if event.Kind != "Pod" || event.NodeName == "" {
+		return false
+	}
+	return event.EventType == "DELETED" || event.Reason == "Evicted"
+}
+
+// Add adds the event to the drain of its Node and reports whether it was held
+// back. Cordons always start a drain; evictions are held back while their Node
+// is drained, and start a drain once minEvictions arrived within the window.
+func (d *Detector) Add(event *watcher.Event) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+
+	if isCordon(event) {
+		drain := d.drains[event.Name]
+		if drain == nil {
+			drain = d.start(event.Name, now)
+		}
+		if drain.Cordon == nil {
+			drain.Cordon = event
+		}
+		drain.EndTime = now
+		return true
+	}
+	if !isEviction(event) {
+		return false
+	}
+
+	node := event.NodeName
+	drain := d.drains[node]
+	if drain == nil {
+		recent := append(d.recent[node], now)
+		for len(recent) > 0 && now.Sub(recent[0]) >= d.window {
+			recent = recent[1:]
+		}
+		if len(recent) < d.minEvictions {
+			d.recent[node] = recent
+			return false
+		}
+		drain = d.start(node, now)
+		drain.Earlier = len(recent) - 1
+	}
+	drain.Evictions = append(drain.Evictions, event)
+	drain.EndTime = now
+	return true
+}
+
+// start opens a drain of the Node. Must be called with mu held.
+func (d *Detector) start(node string, now time.Time) *Drain {
+	drain := &Drain{Node: node, StartTime: now}
+	d.drains[node] = drain
+	delete(d.recent, node)
+	return drain
+}
+
+// Pending returns the number of drains still collecting evictions
+func (d *Detector) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.drains)
+}
+
+// Stop stops the detector and hands over the drains still collecting evictions
+func (d *Detector) Stop() {
+	close(d.stopC)
+	<-d.done
+	d.flush(true)
+}
+
+func (d *Detector) loop() {
+	defer close(d.done)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopC:
+			return
+		case <-ticker.C:
+			d.flush(false)
+		}
+	}
+}
+
+// flush hands over the completed drains, or all drains when all is set
+func (d *Detector) flush(all bool) {
+	d.mu.Lock()
+	now := d.now()
+	var completed []*Drain
+	for node, drain := range d.drains {
+		if !all && now.Sub(drain.EndTime) < d.window && now.Sub(drain.StartTime) < maxDuration {
+			continue
+		}
+		delete(d.drains, node)
+		completed = append(completed, drain)
+	}
+	for node, recent := range d.recent {
+		if len(recent) == 0 || now.Sub(recent[len(recent)-1]) >= d.window {
+			delete(d.recent, node)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, drain := range completed {
+		slog.Info("Node drain completed", "node", drain.Node, "cordoned", drain.Cordon != nil, "evicted", drain.Evicted())
+		if d.onDrain != nil {
+			d.onDrain(drain)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package nodedrain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// newTestDetector creates a Detector driven by now, without the background loop
+func newTestDetector(now *time.Time) (*Detector, *[]*Drain) {
+	var drains []*Drain
+	d := &Detector{
+		window:       time.Minute,
+		minEvictions: 3,
+		onDrain:      func(drain *Drain) { drains = append(drains, drain) },
+		now:          func() time.Time { return *now },
+		drains:       make(map[string]*Drain),
+		recent:       make(map[string][]time.Time),
+	}
+	return d, &drains
+}
+
+func cordon(node string) *watcher.Event {
+	return &watcher.Event{Kind: "Node", Name: node, EventType: "UPDATED", Status: watcher.StatusCordoned,
+		Changes: []watcher.FieldChange{{Field: "status", Old: watcher.StatusSchedulable, New: watcher.StatusCordoned}}}
+}
+
+func deleted(pod, node string) *watcher.Event {
+	return &watcher.Event{Kind: "Pod", Namespace: "default", Name: pod, EventType: "DELETED", NodeName: node}
+}
+
+func TestDetector_CordonAndDrain(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, drains := newTestDetector(&now)
+
+	if !d.Add(cordon("node-1")) {
+		t.Fatal("Expected the cordon to be held back")
+	}
+	for _, pod := range []string{"a", "b"} {
+		now = now.Add(10 * time.Second)
+		if !d.Add(deleted(pod, "node-1")) {
+			t.Fatalf("Expected the eviction of %s to be held back", pod)
+		}
+	}
+
+	// 他のNodeのPodやNode以外の変更は保留しない
+	if d.Add(deleted("c", "node-2")) {
+		t.Error("Expected a deletion from another Node not to be held back")
+	}
+	if d.Add(&watcher.Event{Kind: "Pod", Name: "d", EventType: "UPDATED", NodeName: "node-1", Status: "Running"}) {
+		t.Error("Expected a Pod update not to be held back")
+	}
+
+	now = now.Add(30 * time.Second)
+	d.flush(false)
+	if len(*drains) != 0 {
+		t.Fatal("Expected the drain to wait until no eviction arrived for the window")
+	}
+
+	now = now.Add(30 * time.Second)
+	d.flush(false)
+	if len(*drains) != 1 {
+		t.Fatalf("Expected one drain, got %d", len(*drains))
+	}
+	drain := (*drains)[0]
+	if drain.Node != "node-1" || drain.Cordon == nil || drain.Evicted() != 2 {
+		t.Errorf("Unexpected drain %+v", drain)
+	}
+	if d.Pending() != 0 {
+		t.Errorf("Expected no pending drains, got %d", d.Pending())
+	}
+}
+
+func TestDetector_EvictionsWithoutCordon(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, drains := newTestDetector(&now)
+
+	// しきい値に達するまでの退避はそのまま通知される
+	for _, pod := range []string{"a", "b"} {
+		if d.Add(deleted(pod, "node-1")) {
+			t.Fatalf("Expected the eviction of %s to be sent before the threshold", pod)
+		}
+		now = now.Add(5 * time.Second)
+	}
+	if !d.Add(&watcher.Event{Kind: "Pod", Name: "c", EventType: "UPDATED", NodeName: "node-1", Reason: "Evicted"}) {
+		t.Fatal("Expected the eviction reaching the threshold to be held back")
+	}
+	if !d.Add(deleted("d", "node-1")) {
+		t.Fatal("Expected the evictions of the drained Node to be held back")
+	}
+
+	d.flush(true)
+	if len(*drains) != 1 {
+		t.Fatalf("Expected one drain, got %d", len(*drains))
+	}
+	if drain := (*drains)[0]; drain.Cordon != nil || drain.Earlier != 2 || drain.Evicted() != 4 {
+		t.Errorf("Unexpected drain %+v", drain)
+	}
+}
+
+func TestDetector_SpreadEvictions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, _ := newTestDetector(&now)
+
+	// ウィンドウより間隔の空いた退避はドレインとみなさない
+	for _, pod := range []string{"a", "b", "c", "d"} {
+		if d.Add(deleted(pod, "node-1")) {
+			t.Fatalf("Expected the eviction of %s not to be held back", pod)
+		}
+		now = now.Add(40 * time.Second)
+	}
+}
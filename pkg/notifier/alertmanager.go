@@ -0,0 +1,187 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// DefaultAlertName is the alertname label of alerts sent to Alertmanager
+const DefaultAlertName = "KubernetesResourceChange"
+
+// AlertmanagerConfig contains Prometheus Alertmanager settings
+type AlertmanagerConfig struct {
+	URL          string            // Base URL, e.g. http://alertmanager.monitoring:9093
+	AlertName    string            // Defaults to DefaultAlertName
+	Labels       map[string]string // Static labels added to every alert (e.g. severity)
+	ResolveAfter time.Duration     // Sets endsAt; Alertmanager's resolve_timeout applies when zero
+	GeneratorURL string            // Link back to the source shown by receivers (optional)
+	BearerToken  SecretSource
+	Username     string // Basic auth, used when BearerToken is not set
+	Password     SecretSource
+	HTTPClient   *http.Client // Defaults to a client with DefaultHTTPTimeout
+}
+
+// alertmanagerAlert is an alert in the Alertmanager v2 API
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       *time.Time        `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// AlertmanagerNotifier posts events as alerts to Alertmanager's /api/v2/alerts,
+// so that existing Alertmanager routing, inhibition, silences and receivers apply
+type AlertmanagerNotifier struct {
+	cfg        AlertmanagerConfig
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewAlertmanagerNotifier creates a new AlertmanagerNotifier
+func NewAlertmanagerNotifier(cfg AlertmanagerConfig) (*AlertmanagerNotifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("alertmanager URL is required")
+	}
+	if cfg.AlertName == "" {
+		cfg.AlertName = DefaultAlertName
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+
+	return &AlertmanagerNotifier{
+		cfg:        cfg,
+		endpoint:   strings.TrimRight(cfg.URL, "/") + "/api/v2/alerts",
+		httpClient: httpClient,
+	}, nil
+}
+
+// Name identifies the notifier in logs
+func (a *AlertmanagerNotifier) Name() string {
+	return "alertmanager"
+}
+
+// NotifyEvent posts an event as a single alert
+func (a *AlertmanagerNotifier) NotifyEvent(event *watcher.Event) error {
+	return a.post([]alertmanagerAlert{a.alert(NewEventPayload(event))})
+}
+
+// NotifyBatch posts all events of a batch in one request
+func (a *AlertmanagerNotifier) NotifyBatch(batch *BatchPayload) error {
+	alerts := make([]alertmanagerAlert, 0, len(batch.Events))
+	for _, e := range batch.Events {
+		alerts = append(alerts, a.alert(e))
+	}
+	return a.post(alerts)
+}
+
+// alert converts an event to an alert. Identifying fields become labels so that
+// Alertmanager can route and group on them; descriptive fields become annotations.
+func (a *AlertmanagerNotifier) alert(e *EventPayload) alertmanagerAlert {
+	labels := make(map[string]string, len(a.cfg.Labels)+7)
+	for k, v := range a.cfg.Labels {
+		labels[k] = v
+	}
+	// Alertmanager treats empty label values as absent, so they are omitted
+	setLabel := func(key, value string) {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	setLabel("alertname", a.cfg.AlertName)
+	setLabel("cluster", e.Cluster)
+	setLabel("namespace", e.Namespace)
+	setLabel("kind", e.Kind)
+	setLabel("name", e.Name)
+	setLabel("event_type", e.EventType)
+	setLabel("reason", e.Reason)
+	// The mapped severity overrides a static severity label
+	if e.Severity != nil {
+		setLabel("severity", e.Severity.Alertmanager)
+	}
+
+	annotations := map[string]string{
+		"summary": fmt.Sprintf("%s %s was %s", e.Kind, resourceName(e), e.EventType),
+	}
+	if e.Message != "" {
+		annotations["description"] = e.Message
+	}
+	if e.Status != "" {
+		annotations["status"] = e.Status
+	}
+
+	alert := alertmanagerAlert{
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     e.Timestamp,
+		GeneratorURL: a.cfg.GeneratorURL,
+	}
+	if a.cfg.ResolveAfter > 0 {
+		endsAt := e.Timestamp.Add(a.cfg.ResolveAfter)
+		alert.EndsAt = &endsAt
+	}
+	return alert
+}
+
+// resourceName returns namespace/name, or name for cluster-scoped resources
+func resourceName(e *EventPayload) string {
+	if e.Namespace == "" {
+		return e.Name
+	}
+	return e.Namespace + "/" + e.Name
+}
+
+// CheckHealth queries the status endpoint of the Alertmanager API
+func (a *AlertmanagerNotifier) CheckHealth() error {
+	req, err := http.NewRequest("GET", strings.TrimSuffix(a.endpoint, "/alerts")+"/status", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := setAuthorization(req, a.cfg.BearerToken, a.cfg.Username, a.cfg.Password); err != nil {
+		return err
+	}
+	return checkEndpoint(a.httpClient, req, func(status int) bool {
+		return status == http.StatusOK
+	})
+}
+
+// post sends alerts to the Alertmanager API
+func (a *AlertmanagerNotifier) post(alerts []alertmanagerAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alerts: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := setAuthorization(req, a.cfg.BearerToken, a.cfg.Username, a.cfg.Password); err != nil {
+		return err
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newStatusError(resp, fmt.Sprintf("alertmanager returned status %d", resp.StatusCode))
+	}
+	return nil
+}
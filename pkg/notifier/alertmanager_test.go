@@ -0,0 +1,155 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestAlertmanagerNotifier_NotifyEvent(t *testing.T) {
+	var path, auth string
+	var alerts []alertmanagerAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&alerts)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n, err := NewAlertmanagerNotifier(AlertmanagerConfig{
+		URL:          server.URL + "/",
+		Labels:       map[string]string{"severity": "info"},
+		ResolveAfter: 5 * time.Minute,
+		BearerToken:  SecretSource{Value: "token"},
+	})
+	if err != nil {
+		t.Fatalf("NewAlertmanagerNotifier() error = %v", err)
+	}
+
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	event := &watcher.Event{
+		Kind:      "Pod",
+		Namespace: "default",
+		Name:      "web",
+		EventType: "DELETED",
+		Timestamp: ts,
+		Message:   "Back-off restarting failed container",
+	}
+	if err := n.NotifyEvent(event); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+
+	if path != "/api/v2/alerts" {
+		t.Errorf("Expected /api/v2/alerts, got %s", path)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("Unexpected Authorization header: %q", auth)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(alerts))
+	}
+
+	alert := alerts[0]
+	want := map[string]string{
+		"alertname":  DefaultAlertName,
+		"severity":   "info",
+		"namespace":  "default",
+		"kind":       "Pod",
+		"name":       "web",
+		"event_type": "DELETED",
+	}
+	for k, v := range want {
+		if alert.Labels[k] != v {
+			t.Errorf("Label %s = %q, want %q", k, alert.Labels[k], v)
+		}
+	}
+	// 空の値はラベルに含めない
+	if _, ok := alert.Labels["cluster"]; ok {
+		t.Error("Expected empty cluster label to be omitted")
+	}
+	if alert.Annotations["summary"] != "Pod default/web was DELETED" {
+		t.Errorf("Unexpected summary: %q", alert.Annotations["summary"])
+	}
+	if alert.Annotations["description"] != event.Message {
+		t.Errorf("Unexpected description: %q", alert.Annotations["description"])
+	}
+	if !alert.StartsAt.Equal(ts) || alert.EndsAt == nil || !alert.EndsAt.Equal(ts.Add(5*time.Minute)) {
+		t.Errorf("Unexpected startsAt/endsAt: %v / %v", alert.StartsAt, alert.EndsAt)
+	}
+}
+
+func TestAlertmanagerNotifier_SeverityLabel(t *testing.T) {
+	var alerts []alertmanagerAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&alerts)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n, err := NewAlertmanagerNotifier(AlertmanagerConfig{
+		URL:    server.URL,
+		Labels: map[string]string{"severity": "info", "team": "platform"},
+	})
+	if err != nil {
+		t.Fatalf("NewAlertmanagerNotifier() error = %v", err)
+	}
+
+	// マッピングされた重大度が固定ラベルより優先される
+	event := &watcher.Event{Kind: "Deployment", Namespace: "default", Name: "web", EventType: "UPDATED",
+		Severity: &watcher.Severity{Level: "critical", Alertmanager: "page"}}
+	if err := n.NotifyEvent(event); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d", len(alerts))
+	}
+	if got := alerts[0].Labels["severity"]; got != "page" {
+		t.Errorf("Expected the mapped severity label, got %q", got)
+	}
+	if got := alerts[0].Labels["team"]; got != "platform" {
+		t.Errorf("Expected the static labels to be kept, got %q", got)
+	}
+}
+
+func TestAlertmanagerNotifier_NotifyBatch(t *testing.T) {
+	var alerts []alertmanagerAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&alerts)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n, _ := NewAlertmanagerNotifier(AlertmanagerConfig{URL: server.URL})
+	batch := NewBatchPayload([]*watcher.Event{
+		{Kind: "Pod", Name: "a", EventType: "ADDED"},
+		{Kind: "Node", Name: "node-1", EventType: "UPDATED"},
+	}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", len(alerts))
+	}
+	if alerts[1].Annotations["summary"] != "Node node-1 was UPDATED" || alerts[1].EndsAt != nil {
+		t.Errorf("Unexpected alert: %+v", alerts[1])
+	}
+}
+
+func TestAlertmanagerNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	n, _ := NewAlertmanagerNotifier(AlertmanagerConfig{URL: server.URL})
+	err := n.NotifyEvent(&watcher.Event{Kind: "Pod", Name: "web", EventType: "ADDED"})
+	if err == nil || !IsRetryable(err) {
+		t.Errorf("Expected retryable error, got %v", err)
+	}
+}
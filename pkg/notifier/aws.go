@@ -0,0 +1,202 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AWSAuthConfig contains AWS credential settings.
+// Without static keys, the standard AWS_* environment variables are used.
+type AWSAuthConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	RoleARN         string // Optional role assumed via STS
+	STSEndpoint     string // Overrides the regional STS endpoint
+}
+
+// awsCredentialProvider resolves and caches AWS credentials
+type awsCredentialProvider struct {
+	cfg        AWSAuthConfig
+	httpClient *http.Client
+	mu         sync.Mutex
+	cached     awsCredentials
+}
+
+// newAWSCredentialProvider creates a credential provider from configuration
+func newAWSCredentialProvider(cfg AWSAuthConfig, httpClient *http.Client) *awsCredentialProvider {
+	return &awsCredentialProvider{cfg: cfg, httpClient: httpClient}
+}
+
+// Get returns valid credentials, refreshing assumed-role credentials before they expire
+func (p *awsCredentialProvider) Get() (awsCredentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cached.AccessKeyID != "" && (p.cached.Expires.IsZero() || time.Until(p.cached.Expires) > 5*time.Minute) {
+		return p.cached, nil
+	}
+
+	creds, err := p.resolve()
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	p.cached = creds
+	return creds, nil
+}
+
+// resolve obtains credentials from static keys, the environment, web identity or STS
+func (p *awsCredentialProvider) resolve() (awsCredentials, error) {
+	base := awsCredentials{
+		AccessKeyID:     p.cfg.AccessKeyID,
+		SecretAccessKey: p.cfg.SecretAccessKey,
+		SessionToken:    p.cfg.SessionToken,
+	}
+	if base.AccessKeyID == "" {
+		base = awsCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+
+	roleARN := p.cfg.RoleARN
+	if base.AccessKeyID == "" {
+		// IAM Roles for Service Accounts (IRSA)
+		tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+		if roleARN == "" {
+			roleARN = os.Getenv("AWS_ROLE_ARN")
+		}
+		if tokenFile == "" || roleARN == "" {
+			return awsCredentials{}, fmt.Errorf("no AWS credentials configured")
+		}
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return awsCredentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+		}
+		return p.assumeRole(url.Values{
+			"Action":           {"AssumeRoleWithWebIdentity"},
+			"WebIdentityToken": {strings.TrimSpace(string(token))},
+		}, roleARN, nil)
+	}
+
+	if roleARN == "" {
+		return base, nil
+	}
+	return p.assumeRole(url.Values{"Action": {"AssumeRole"}}, roleARN, &base)
+}
+
+// stsResponse holds the credentials returned by AssumeRole and AssumeRoleWithWebIdentity
+type stsResponse struct {
+	AssumeRole  stsCredentials `xml:"AssumeRoleResult>Credentials"`
+	WebIdentity stsCredentials `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+type stsCredentials struct {
+	AccessKeyID     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+// assumeRole calls STS to obtain temporary credentials. Requests are signed with
+// base credentials when given; AssumeRoleWithWebIdentity is unsigned.
+func (p *awsCredentialProvider) assumeRole(form url.Values, roleARN string, base *awsCredentials) (awsCredentials, error) {
+	form.Set("Version", "2011-06-15")
+	form.Set("RoleArn", roleARN)
+	form.Set("RoleSessionName", "kube-watcher")
+	body := []byte(form.Encode())
+
+	endpoint := p.cfg.STSEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com/", p.cfg.Region)
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to create STS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if base != nil {
+		signV4(req, body, *base, p.cfg.Region, "sts", time.Now())
+	}
+
+	respBody, err := doAWSRequest(p.httpClient, req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to assume role %s: %w", roleARN, err)
+	}
+
+	var resp stsResponse
+	if err := xml.Unmarshal(respBody, &resp); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to parse STS response: %w", err)
+	}
+	creds := resp.AssumeRole
+	if creds.AccessKeyID == "" {
+		creds = resp.WebIdentity
+	}
+	if creds.AccessKeyID == "" {
+		return awsCredentials{}, fmt.Errorf("STS response contained no credentials")
+	}
+
+	return awsCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expires:         creds.Expiration,
+	}, nil
+}
+
+// postAWSQuery signs and sends a Query API request with the provider's credentials
+func postAWSQuery(client *http.Client, creds *awsCredentialProvider, endpoint, region, service string, form url.Values) ([]byte, error) {
+	body := []byte(form.Encode())
+
+	c, err := creds.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	signV4(req, body, c, region, service, time.Now())
+
+	return doAWSRequest(client, req)
+}
+
+// doAWSRequest sends a request and returns the response body, treating non-2xx responses as errors
+func doAWSRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newStatusError(resp, fmt.Sprintf("AWS API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+	}
+	return body, nil
+}
+
+// regionFromARN returns the region part of an ARN (arn:partition:service:region:account:resource)
+func regionFromARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 {
+		return ""
+	}
+	return parts[3]
+}
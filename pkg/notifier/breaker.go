@@ -0,0 +1,186 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// ErrCircuitOpen is returned instead of delivering while a circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker stops calling a destination after consecutive transient failures.
+// While open, deliveries are dropped and counted. After the cool-down a single
+// trial delivery is let through, closing the circuit on success.
+type CircuitBreaker struct {
+	name     string
+	onChange func(name string, open bool, err error)
+	now      func() time.Time
+
+	mu        sync.Mutex
+	threshold int
+	coolDown  time.Duration
+	failures  int
+	open      bool
+	openUntil time.Time
+	probing   bool
+
+	dropped atomic.Int64
+}
+
+// NewCircuitBreaker creates a circuit breaker for the named destination that opens
+// after threshold consecutive failures. onChange, if set, is called on every
+// open and close transition with the error that opened the circuit.
+func NewCircuitBreaker(name string, threshold int, coolDown time.Duration, onChange func(name string, open bool, err error)) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		coolDown:  coolDown,
+		onChange:  onChange,
+		now:       time.Now,
+	}
+}
+
+// Do calls fn unless the circuit is open, in which case ErrCircuitOpen is returned
+func (b *CircuitBreaker) Do(fn func() error) error {
+	if b == nil {
+		return fn()
+	}
+
+	if !b.allow() {
+		b.dropped.Add(1)
+		return ErrCircuitOpen
+	}
+
+	err := fn()
+	b.record(err)
+	return err
+}
+
+// SetPolicy changes the number of consecutive failures opening the circuit and
+// the cool-down, e.g. on a configuration reload. An open circuit stays open.
+func (b *CircuitBreaker) SetPolicy(threshold int, coolDown time.Duration) {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = threshold
+	b.coolDown = coolDown
+}
+
+// Name returns the name of the guarded destination
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// IsOpen reports whether deliveries are currently being dropped
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// Dropped returns the number of deliveries dropped while the circuit was open
+func (b *CircuitBreaker) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// allow reports whether a delivery may be attempted, starting a trial after the cool-down
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the state with the result of a delivery.
+// Only transient failures count; other errors show the destination is reachable.
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+
+	wasOpen := b.open
+	b.probing = false
+	if err != nil && IsRetryable(err) {
+		b.failures++
+		if b.open || b.failures >= b.threshold {
+			b.open = true
+			b.openUntil = b.now().Add(b.coolDown)
+		}
+	} else {
+		b.failures = 0
+		b.open = false
+	}
+	nowOpen := b.open
+
+	b.mu.Unlock()
+
+	if wasOpen != nowOpen && b.onChange != nil {
+		b.onChange(b.name, nowOpen, err)
+	}
+}
+
+// breakerNotifier guards the wrapped notifier with a circuit breaker
+type breakerNotifier struct {
+	EventNotifier
+	breaker *CircuitBreaker
+}
+
+// WithCircuitBreaker wraps n so that deliveries go through breaker
+func WithCircuitBreaker(n EventNotifier, breaker *CircuitBreaker) EventNotifier {
+	if breaker == nil {
+		return n
+	}
+	return &breakerNotifier{EventNotifier: n, breaker: breaker}
+}
+
+// NotifyEvent delivers an event unless the circuit is open
+func (b *breakerNotifier) NotifyEvent(event *watcher.Event) error {
+	return b.breaker.Do(func() error {
+		return b.EventNotifier.NotifyEvent(event)
+	})
+}
+
+// NotifyBatch delivers a batch unless the circuit is open
+func (b *breakerNotifier) NotifyBatch(batch *BatchPayload) error {
+	return b.breaker.Do(func() error {
+		return b.EventNotifier.NotifyBatch(batch)
+	})
+}
+
+// NotifyEventContext delivers an event with ctx unless the circuit is open
+func (b *breakerNotifier) NotifyEventContext(ctx context.Context, event *watcher.Event) error {
+	return b.breaker.Do(func() error {
+		return NotifyEventContext(ctx, b.EventNotifier, event)
+	})
+}
+
+// NotifyBatchContext delivers a batch with ctx unless the circuit is open
+func (b *breakerNotifier) NotifyBatchContext(ctx context.Context, batch *BatchPayload) error {
+	return b.breaker.Do(func() error {
+		return NotifyBatchContext(ctx, b.EventNotifier, batch)
+	})
+}
+
+// Close closes the wrapped notifier if it holds resources
+func (b *breakerNotifier) Close() error {
+	if closer, ok := b.EventNotifier.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package notifier
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+type breakerTransition struct {
+	name string
+	open bool
+}
+
+func newTestBreaker(threshold int) (*CircuitBreaker, *time.Time, *[]breakerTransition) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var transitions []breakerTransition
+	b := NewCircuitBreaker("slack", threshold, time.Minute, func(name string, open bool, err error) {
+		transitions = append(transitions, breakerTransition{name, open})
+	})
+	b.now = func() time.Time { return now }
+	return b, &now, &transitions
+}
+
+var errUnavailable = &StatusError{StatusCode: http.StatusServiceUnavailable, msg: "unavailable"}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _, transitions := newTestBreaker(3)
+	failing := func() error { return errUnavailable }
+
+	for i := 0; i < 3; i++ {
+		if err := b.Do(failing); err != errUnavailable {
+			t.Fatalf("Attempt %d: expected delivery error, got %v", i, err)
+		}
+	}
+	if !b.IsOpen() {
+		t.Fatal("Expected circuit to open after 3 failures")
+	}
+
+	// オープン中は送信せずにドロップする
+	called := false
+	if err := b.Do(func() error { called = true; return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if called {
+		t.Error("Expected delivery to be skipped while open")
+	}
+	if b.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped delivery, got %d", b.Dropped())
+	}
+	if len(*transitions) != 1 || !(*transitions)[0].open {
+		t.Errorf("Expected a single open transition, got %v", *transitions)
+	}
+}
+
+func TestCircuitBreaker_ClosesAfterSuccessfulTrial(t *testing.T) {
+	b, now, transitions := newTestBreaker(1)
+	b.Do(func() error { return errUnavailable })
+
+	// クールダウン後の試行が失敗すると再びオープンになる
+	*now = now.Add(2 * time.Minute)
+	if err := b.Do(func() error { return errUnavailable }); err != errUnavailable {
+		t.Fatalf("Expected trial delivery after cool-down, got %v", err)
+	}
+	if !b.IsOpen() {
+		t.Fatal("Expected circuit to reopen after failed trial")
+	}
+	if err := b.Do(func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected cool-down to restart, got %v", err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if err := b.Do(func() error { return nil }); err != nil {
+		t.Fatalf("Expected successful trial, got %v", err)
+	}
+	if b.IsOpen() {
+		t.Error("Expected circuit to close after successful trial")
+	}
+
+	want := []breakerTransition{{"slack", true}, {"slack", false}}
+	if len(*transitions) != len(want) || (*transitions)[0] != want[0] || (*transitions)[1] != want[1] {
+		t.Errorf("Transitions = %v, want %v", *transitions, want)
+	}
+}
+
+func TestCircuitBreaker_SetPolicy(t *testing.T) {
+	b, now, _ := newTestBreaker(3)
+	b.Do(func() error { return errUnavailable })
+
+	// 変更後の閾値は記録済みの失敗回数にも適用される
+	b.SetPolicy(2, 5*time.Minute)
+	b.Do(func() error { return errUnavailable })
+	if !b.IsOpen() {
+		t.Fatal("Expected circuit to open at the new threshold")
+	}
+	*now = now.Add(2 * time.Minute)
+	if err := b.Do(func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the new cool-down to apply, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_IgnoresNonTransientErrors(t *testing.T) {
+	b, _, _ := newTestBreaker(1)
+	badRequest := &StatusError{StatusCode: http.StatusBadRequest, msg: "bad request"}
+
+	b.Do(func() error { return badRequest })
+	if b.IsOpen() {
+		t.Error("Expected non-transient errors not to open the circuit")
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	b, _, _ := newTestBreaker(1)
+	flaky := &flakyNotifier{failures: 10}
+	n := WithCircuitBreaker(flaky, b)
+
+	n.NotifyEvent(&watcher.Event{})
+	if err := n.NotifyBatch(&BatchPayload{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if flaky.calls != 1 {
+		t.Errorf("Expected 1 delivery attempt, got %d", flaky.calls)
+	}
+}
@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// Coalescer suppresses byte-identical messages to a destination within an
+// interval, as produced by overlapping filters or routes. The first message is
+// sent and repeats within the interval are counted instead; once the interval
+// has passed, report is called with the number of repeats, if any.
+type Coalescer struct {
+	interval time.Duration
+	report   func(repeats int)
+
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]*coalesced
+}
+
+// coalesced is a message sent within the interval
+type coalesced struct {
+	repeats int
+}
+
+// NewCoalescer creates a Coalescer for the given interval
+func NewCoalescer(interval time.Duration, report func(repeats int)) *Coalescer {
+	return &Coalescer{
+		interval: interval,
+		report:   report,
+		seen:     make(map[[sha256.Size]byte]*coalesced),
+	}
+}
+
+// Do calls send unless the same message was sent within the interval, in which
+// case the repeat is counted and nil is returned. A message that failed to be
+// sent is forgotten, so that a repeat can deliver it.
+func (c *Coalescer) Do(message []byte, send func() error) error {
+	if c == nil {
+		return send()
+	}
+
+	key := sha256.Sum256(message)
+	c.mu.Lock()
+	if entry, ok := c.seen[key]; ok {
+		entry.repeats++
+		c.mu.Unlock()
+		return nil
+	}
+	entry := &coalesced{}
+	c.seen[key] = entry
+	c.mu.Unlock()
+
+	if err := send(); err != nil {
+		c.mu.Lock()
+		delete(c.seen, key)
+		c.mu.Unlock()
+		return err
+	}
+	time.AfterFunc(c.interval, func() { c.expire(key, entry) })
+	return nil
+}
+
+// expire forgets a message once its interval has passed and reports its repeats
+func (c *Coalescer) expire(key [sha256.Size]byte, entry *coalesced) {
+	c.mu.Lock()
+	if c.seen[key] == entry {
+		delete(c.seen, key)
+	}
+	repeats := entry.repeats
+	c.mu.Unlock()
+
+	if repeats > 0 && c.report != nil {
+		c.report(repeats)
+	}
+}
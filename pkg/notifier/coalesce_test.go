@@ -0,0 +1,35 @@
+package notifier
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCoalescer_RetriesFailedMessage(t *testing.T) {
+	c := NewCoalescer(time.Minute, nil)
+
+	calls := 0
+	failing := func() error { calls++; return errors.New("timeout") }
+	if err := c.Do([]byte("message"), failing); err == nil {
+		t.Fatal("Expected the error of the send to be returned")
+	}
+	// 送信に失敗したメッセージは抑止されずに再送できる
+	if err := c.Do([]byte("message"), func() error { calls++; return nil }); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if err := c.Do([]byte("message"), func() error { calls++; return nil }); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 sends, got %d", calls)
+	}
+}
+
+func TestCoalescer_Nil(t *testing.T) {
+	var c *Coalescer
+	called := false
+	if err := c.Do([]byte("message"), func() error { called = true; return nil }); err != nil || !called {
+		t.Errorf("Expected a nil Coalescer to send, got called=%v err=%v", called, err)
+	}
+}
@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// DeadLetterRecord is a failed delivery with its full payload, written as one JSON line
+type DeadLetterRecord struct {
+	FailedAt    time.Time     `json:"failedAt"`
+	Destination string        `json:"destination"`
+	Error       string        `json:"error"`
+	Event       *EventPayload `json:"event,omitempty"`
+	Batch       *BatchPayload `json:"batch,omitempty"`
+}
+
+// DeadLetterQueue records deliveries that failed after all retries, so that they
+// are not silently lost. Records are appended to a file, forwarded to a fallback
+// notifier, or both.
+type DeadLetterQueue struct {
+	file     *FileNotifier
+	fallback EventNotifier
+}
+
+// NewDeadLetterQueue creates a DeadLetterQueue. Either file or fallback may be nil.
+func NewDeadLetterQueue(file *FileNotifier, fallback EventNotifier) *DeadLetterQueue {
+	return &DeadLetterQueue{file: file, fallback: fallback}
+}
+
+// AddEvent records an event that could not be delivered to destination
+func (q *DeadLetterQueue) AddEvent(destination string, event *watcher.Event, cause error) error {
+	if q == nil {
+		return nil
+	}
+
+	var errs []error
+	if q.file != nil {
+		errs = append(errs, q.writeRecord(&DeadLetterRecord{
+			FailedAt:    time.Now(),
+			Destination: destination,
+			Error:       cause.Error(),
+			Event:       NewEventPayload(event),
+		}))
+	}
+	if q.fallback != nil && q.fallback.Name() != destination {
+		errs = append(errs, q.fallback.NotifyEvent(event))
+	}
+	return errors.Join(errs...)
+}
+
+// AddBatch records a batch that could not be delivered to destination
+func (q *DeadLetterQueue) AddBatch(destination string, batch *BatchPayload, cause error) error {
+	if q == nil {
+		return nil
+	}
+
+	var errs []error
+	if q.file != nil {
+		errs = append(errs, q.writeRecord(&DeadLetterRecord{
+			FailedAt:    time.Now(),
+			Destination: destination,
+			Error:       cause.Error(),
+			Batch:       batch,
+		}))
+	}
+	if q.fallback != nil && q.fallback.Name() != destination {
+		errs = append(errs, q.fallback.NotifyBatch(batch))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the dead-letter file
+func (q *DeadLetterQueue) Close() error {
+	if q == nil || q.file == nil {
+		return nil
+	}
+	return q.file.Close()
+}
+
+// writeRecord appends a record to the dead-letter file
+func (q *DeadLetterQueue) writeRecord(record *DeadLetterRecord) error {
+	line, err := jsonLine(record)
+	if err != nil {
+		return err
+	}
+	if err := q.file.write(line); err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	return nil
+}
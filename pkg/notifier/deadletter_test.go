@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestDeadLetterQueue_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	file, err := NewFileNotifier(FileConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewFileNotifier() error = %v", err)
+	}
+	q := NewDeadLetterQueue(file, nil)
+	defer q.Close()
+
+	event := &watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "DELETED"}
+	if err := q.AddEvent("slack", event, errors.New("giving up after 3 attempts")); err != nil {
+		t.Fatalf("AddEvent() error = %v", err)
+	}
+	batch := NewBatchPayload([]*watcher.Event{event}, time.Now(), time.Now())
+	if err := q.AddBatch("sns", batch, ErrCircuitOpen); err != nil {
+		t.Fatalf("AddBatch() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(lines))
+	}
+
+	var record DeadLetterRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Invalid record: %v", err)
+	}
+	if record.Destination != "slack" || record.Error != "giving up after 3 attempts" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+	if record.Event == nil || record.Event.Name != "web" {
+		t.Errorf("Expected full event payload, got %+v", record.Event)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("Invalid record: %v", err)
+	}
+	if record.Batch == nil || len(record.Batch.Events) != 1 {
+		t.Errorf("Expected batch payload, got %+v", record.Batch)
+	}
+}
+
+func TestDeadLetterQueue_Fallback(t *testing.T) {
+	fallback := &flakyNotifier{}
+	q := NewDeadLetterQueue(nil, fallback)
+
+	if err := q.AddEvent("slack", &watcher.Event{}, errors.New("failed")); err != nil {
+		t.Fatalf("AddEvent() error = %v", err)
+	}
+	if fallback.calls != 1 {
+		t.Errorf("Expected event to be forwarded to fallback, got %d calls", fallback.calls)
+	}
+
+	// 失敗した通知先自身には転送しない
+	q.AddEvent("flaky", &watcher.Event{}, errors.New("failed"))
+	if fallback.calls != 1 {
+		t.Errorf("Expected no forwarding to the failing destination, got %d calls", fallback.calls)
+	}
+}
+
+func TestDeadLetterQueue_Nil(t *testing.T) {
+	var q *DeadLetterQueue
+	if err := q.AddEvent("slack", &watcher.Event{}, errors.New("failed")); err != nil {
+		t.Errorf("Expected nil queue to ignore records, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// DryRun writes notifications as JSON lines instead of delivering them, so that
+// filters, routes and templates can be tried out without sending anything
+type DryRun struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// dryRunRecord is a notification that would have been sent
+type dryRunRecord struct {
+	DryRun      bool        `json:"dryRun"`
+	Destination string      `json:"destination"`
+	Payload     interface{} `json:"payload"`
+}
+
+// NewDryRun creates a DryRun writing to w
+func NewDryRun(w io.Writer) *DryRun {
+	return &DryRun{w: w}
+}
+
+// write records payload as one JSON line
+func (d *DryRun) write(destination string, payload interface{}) error {
+	data, err := json.Marshal(dryRunRecord{DryRun: true, Destination: destination, Payload: payload})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	data = append(data, '\n')
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.w.Write(data); err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	return nil
+}
+
+// Notifier returns an EventNotifier for the destination name that writes to d
+func (d *DryRun) Notifier(name string) EventNotifier {
+	return &dryRunNotifier{name: name, dryRun: d}
+}
+
+// dryRunNotifier stands in for an event notifier in dry-run mode
+type dryRunNotifier struct {
+	name   string
+	dryRun *DryRun
+}
+
+// Name returns the destination name
+func (n *dryRunNotifier) Name() string {
+	return n.name
+}
+
+// NotifyEvent records an event
+func (n *dryRunNotifier) NotifyEvent(event *watcher.Event) error {
+	return n.dryRun.write(n.name, NewEventPayload(event))
+}
+
+// NotifyBatch records a batch
+func (n *dryRunNotifier) NotifyBatch(batch *BatchPayload) error {
+	return n.dryRun.write(n.name, batch)
+}
@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestDryRun(t *testing.T) {
+	var buf bytes.Buffer
+	dryRun := NewDryRun(&buf)
+
+	slack := NewSlackNotifier("https://hooks.slack.com/services/never-called")
+	slack.SetDryRun(dryRun, "prod-alerts")
+	if err := slack.Send("hello"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := dryRun.Notifier("sns").NotifyEvent(&watcher.Event{Kind: "Pod", Name: "web", EventType: "DELETED"}); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	type record struct {
+		DryRun      bool            `json:"dryRun"`
+		Destination string          `json:"destination"`
+		Payload     json.RawMessage `json:"payload"`
+	}
+	var records []record
+	for _, line := range lines {
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", line, err)
+		}
+		records = append(records, r)
+	}
+
+	if !records[0].DryRun || records[0].Destination != "prod-alerts" || !strings.Contains(string(records[0].Payload), `"text":"hello"`) {
+		t.Errorf("Unexpected slack record: %s", lines[0])
+	}
+	if records[1].Destination != "sns" || !strings.Contains(string(records[1].Payload), `"eventType":"DELETED"`) {
+		t.Errorf("Unexpected sns record: %s", lines[1])
+	}
+}
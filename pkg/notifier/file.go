@@ -0,0 +1,197 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Default file sink rotation settings
+const (
+	DefaultFileMaxSizeBytes = 100 * 1024 * 1024
+	DefaultFileMaxBackups   = 5
+)
+
+// FileConfig contains file sink settings
+type FileConfig struct {
+	Path         string
+	MaxSizeBytes int64 // Rotate when the file would exceed this size
+	MaxBackups   int   // Number of rotated files to keep (path.1 ... path.N)
+
+	// Render formats an event as text. Events are written as JSON lines when nil.
+	Render func(event *watcher.Event) (string, error)
+}
+
+// FileNotifier appends events to a local file with size-based rotation
+type FileNotifier struct {
+	cfg  FileConfig
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileNotifier creates a new FileNotifier and opens the file for appending
+func NewFileNotifier(cfg FileConfig) (*FileNotifier, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("file path is required")
+	}
+	if cfg.MaxSizeBytes <= 0 {
+		cfg.MaxSizeBytes = DefaultFileMaxSizeBytes
+	}
+	if cfg.MaxBackups < 0 {
+		cfg.MaxBackups = 0
+	}
+
+	n := &FileNotifier{cfg: cfg}
+	if err := n.open(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Name identifies the notifier in logs
+func (n *FileNotifier) Name() string {
+	return "file"
+}
+
+// NotifyEvent appends an event to the file
+func (n *FileNotifier) NotifyEvent(event *watcher.Event) error {
+	entry, err := n.render(event)
+	if err != nil {
+		return err
+	}
+	return n.write(entry)
+}
+
+// NotifyBatch appends each event of the batch to the file
+func (n *FileNotifier) NotifyBatch(batch *BatchPayload) error {
+	var b strings.Builder
+	if n.cfg.Render == nil || len(batch.events) != len(batch.Events) {
+		for _, payload := range batch.Events {
+			entry, err := jsonLine(payload)
+			if err != nil {
+				return err
+			}
+			b.WriteString(entry)
+		}
+		return n.write(b.String())
+	}
+
+	for _, event := range batch.events {
+		entry, err := n.render(event)
+		if err != nil {
+			return err
+		}
+		b.WriteString(entry)
+	}
+	return n.write(b.String())
+}
+
+// Close closes the file
+func (n *FileNotifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.file == nil {
+		return nil
+	}
+	err := n.file.Close()
+	n.file = nil
+	return err
+}
+
+// render formats an event as a newline-terminated entry
+func (n *FileNotifier) render(event *watcher.Event) (string, error) {
+	if n.cfg.Render == nil {
+		return jsonLine(NewEventPayload(event))
+	}
+
+	text, err := n.cfg.Render(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to render event: %w", err)
+	}
+	return strings.TrimRight(text, "\n") + "\n", nil
+}
+
+// jsonLine encodes v as a single JSON line
+func jsonLine(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// write appends data, rotating the file first if it would grow beyond the size limit
+func (n *FileNotifier) write(data string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.file == nil {
+		if err := n.open(); err != nil {
+			return err
+		}
+	}
+
+	if n.size > 0 && n.size+int64(len(data)) > n.cfg.MaxSizeBytes {
+		if err := n.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate %s: %w", n.cfg.Path, err)
+		}
+	}
+
+	written, err := n.file.WriteString(data)
+	n.size += int64(written)
+	if err != nil {
+		return fmt.Errorf("failed to write to %s: %w", n.cfg.Path, err)
+	}
+	return nil
+}
+
+// open opens the file for appending and records its current size
+func (n *FileNotifier) open() error {
+	file, err := os.OpenFile(n.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", n.cfg.Path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat %s: %w", n.cfg.Path, err)
+	}
+
+	n.file = file
+	n.size = info.Size()
+	return nil
+}
+
+// rotate shifts path.N-1 to path.N, moves the current file to path.1 and reopens it
+func (n *FileNotifier) rotate() error {
+	if err := n.file.Close(); err != nil {
+		return err
+	}
+	n.file = nil
+
+	if n.cfg.MaxBackups == 0 {
+		if err := os.Remove(n.cfg.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return n.open()
+	}
+
+	for i := n.cfg.MaxBackups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", n.cfg.Path, i)
+		to := fmt.Sprintf("%s.%d", n.cfg.Path, i+1)
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(n.cfg.Path, n.cfg.Path+".1"); err != nil {
+		return err
+	}
+	return n.open()
+}
@@ -0,0 +1,138 @@
+package notifier
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestFileNotifier_JSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	n, err := NewFileNotifier(FileConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewFileNotifier() error = %v", err)
+	}
+	defer n.Close()
+
+	event := &watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"}
+	if err := n.NotifyEvent(event); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+	batch := NewBatchPayload([]*watcher.Event{event, event}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	// バッチはイベントごとに1行で書き込まれる
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %q", len(lines), data)
+	}
+	var payload EventPayload
+	if err := json.Unmarshal([]byte(lines[0]), &payload); err != nil {
+		t.Fatalf("Invalid JSON line: %v", err)
+	}
+	if payload.Name != "web" || payload.EventType != "ADDED" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+}
+
+func TestFileNotifier_TextRender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	n, err := NewFileNotifier(FileConfig{
+		Path: path,
+		Render: func(event *watcher.Event) (string, error) {
+			return event.EventType + " " + event.Kind + "/" + event.Name + "\n", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewFileNotifier() error = %v", err)
+	}
+	defer n.Close()
+
+	events := []*watcher.Event{
+		{Kind: "Pod", Name: "a", EventType: "ADDED"},
+		{Kind: "Pod", Name: "b", EventType: "DELETED"},
+	}
+	if err := n.NotifyBatch(NewBatchPayload(events, time.Now(), time.Now())); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "ADDED Pod/a\nDELETED Pod/b\n" {
+		t.Errorf("Unexpected file contents: %q", data)
+	}
+}
+
+func TestFileNotifier_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	n, err := NewFileNotifier(FileConfig{
+		Path:         path,
+		MaxSizeBytes: 10,
+		MaxBackups:   2,
+		Render: func(event *watcher.Event) (string, error) {
+			return event.Name, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewFileNotifier() error = %v", err)
+	}
+	defer n.Close()
+
+	// 各行は8バイトなので書き込みごとにローテーションされる
+	for _, name := range []string{"event-1", "event-2", "event-3", "event-4"} {
+		if err := n.NotifyEvent(&watcher.Event{Name: name}); err != nil {
+			t.Fatalf("NotifyEvent() error = %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "event-4\n",
+		path + ".1": "event-3\n",
+		path + ".2": "event-2\n",
+	}
+	for file, want := range expected {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), data, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected at most 2 backups, stat error = %v", err)
+	}
+}
+
+func TestFileNotifier_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	if err := os.WriteFile(path, []byte("previous\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := NewFileNotifier(FileConfig{Path: path, Render: func(event *watcher.Event) (string, error) {
+		return event.Name, nil
+	}})
+	if err != nil {
+		t.Fatalf("NewFileNotifier() error = %v", err)
+	}
+	if err := n.NotifyEvent(&watcher.Event{Name: "next"}); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+	n.Close()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "previous\nnext\n" {
+		t.Errorf("Unexpected file contents: %q", data)
+	}
+}
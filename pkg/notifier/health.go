@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HealthChecker is implemented by notifiers that can verify their endpoint and
+// credentials without delivering a notification
+type HealthChecker interface {
+	// CheckHealth returns an error if notifications are expected to fail
+	CheckHealth() error
+}
+
+// checkEndpoint sends req and returns an error unless healthy accepts the response status
+func checkEndpoint(client *http.Client, req *http.Request, healthy func(status int) bool) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if !healthy(resp.StatusCode) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return newStatusError(resp, fmt.Sprintf("health check returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+	}
+	return nil
+}
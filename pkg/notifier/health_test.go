@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// statusServer returns a server that answers every request with status and records the last request
+func statusServer(t *testing.T, status int, body string, last **http.Request) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if last != nil {
+			*last = r
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSlackNotifier_CheckHealth_Webhook(t *testing.T) {
+	// 有効なWebhookは空のメッセージを400で拒否する（投稿はされない）
+	valid := statusServer(t, http.StatusBadRequest, "no_text", nil)
+	if err := NewSlackNotifier(valid.URL).CheckHealth(); err != nil {
+		t.Errorf("CheckHealth() error = %v, want nil", err)
+	}
+
+	revoked := statusServer(t, http.StatusNotFound, "no_service", nil)
+	if err := NewSlackNotifier(revoked.URL).CheckHealth(); err == nil {
+		t.Error("Expected error for unknown webhook")
+	}
+}
+
+func TestSlackNotifier_CheckHealth_Bot(t *testing.T) {
+	var req *http.Request
+	server := statusServer(t, http.StatusOK, `{"ok":false,"error":"invalid_auth"}`, &req)
+
+	n := NewSlackBotNotifier("xoxb-test", "C123")
+	n.authTestURL = server.URL
+	if err := n.CheckHealth(); err == nil {
+		t.Error("Expected error for invalid token")
+	}
+	if req.Header.Get("Authorization") != "Bearer xoxb-test" {
+		t.Errorf("Unexpected Authorization header: %q", req.Header.Get("Authorization"))
+	}
+}
+
+func TestWebhookNotifier_CheckHealth(t *testing.T) {
+	var req *http.Request
+	server := statusServer(t, http.StatusMethodNotAllowed, "", &req)
+	n, _ := NewWebhookNotifier(WebhookConfig{URL: server.URL, BearerToken: SecretSource{Value: "token"}})
+	if err := n.CheckHealth(); err != nil {
+		t.Errorf("CheckHealth() error = %v, want nil", err)
+	}
+	if req.Method != "HEAD" || req.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("Unexpected request: %s %v", req.Method, req.Header)
+	}
+
+	unauthorized := statusServer(t, http.StatusUnauthorized, "", nil)
+	n, _ = NewWebhookNotifier(WebhookConfig{URL: unauthorized.URL})
+	if err := n.CheckHealth(); err == nil {
+		t.Error("Expected error for rejected credentials")
+	}
+}
+
+func TestAlertmanagerNotifier_CheckHealth(t *testing.T) {
+	var req *http.Request
+	server := statusServer(t, http.StatusOK, "{}", &req)
+	n, _ := NewAlertmanagerNotifier(AlertmanagerConfig{URL: server.URL})
+	if err := n.CheckHealth(); err != nil {
+		t.Errorf("CheckHealth() error = %v, want nil", err)
+	}
+	if req.URL.Path != "/api/v2/status" {
+		t.Errorf("Expected /api/v2/status, got %s", req.URL.Path)
+	}
+}
+
+func TestSQSNotifier_CheckHealth(t *testing.T) {
+	server := statusServer(t, http.StatusBadRequest, "<Error><Code>AWS.SimpleQueueService.NonExistentQueue</Code></Error>", nil)
+	n, _ := NewSQSNotifier(SQSConfig{
+		QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789012/missing",
+		Endpoint: server.URL,
+		Auth:     AWSAuthConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	})
+	if err := n.CheckHealth(); err == nil {
+		t.Error("Expected error for missing queue")
+	}
+}
+
+func TestSNSNotifier_CheckHealth(t *testing.T) {
+	var action string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action = r.PostFormValue("Action")
+	}))
+	defer server.Close()
+	n, _ := NewSNSNotifier(SNSConfig{
+		TopicARN: "arn:aws:sns:us-east-1:123456789012:k8s-events",
+		Endpoint: server.URL,
+		Auth:     AWSAuthConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	})
+	if err := n.CheckHealth(); err != nil {
+		t.Errorf("CheckHealth() error = %v, want nil", err)
+	}
+	if action != "GetTopicAttributes" {
+		t.Errorf("Unexpected action: %q", action)
+	}
+}
@@ -0,0 +1,124 @@
+package notifier
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// DefaultHTTPTimeout is the request timeout of the HTTP-based notifiers
+const DefaultHTTPTimeout = 10 * time.Second
+
+// HTTPConfig contains outbound HTTP client settings shared by the HTTP-based notifiers
+type HTTPConfig struct {
+	ProxyURL string // Proxy for all requests; HTTP_PROXY, HTTPS_PROXY and NO_PROXY are honored when empty
+	NoProxy  string // Hosts that bypass ProxyURL, in NO_PROXY syntax
+
+	CAFile   string // PEM bundle trusted in addition to the system roots
+	CertFile string // Client certificate for mutual TLS
+	KeyFile  string // Client certificate key for mutual TLS
+
+	ConnectTimeout time.Duration // TCP connect timeout; the transport default is used when zero
+}
+
+// NewHTTPClient creates an HTTP client with the given settings and request timeout
+func NewHTTPClient(cfg HTTPConfig, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ConnectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   cfg.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", urlParseError(err))
+		}
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL: missing host")
+		}
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  cfg.ProxyURL,
+			HTTPSProxy: cfg.ProxyURL,
+			NoProxy:    cfg.NoProxy,
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}, nil
+}
+
+// newTLSConfig builds the client TLS configuration, or returns nil for the defaults.
+// The client certificate is reloaded on each handshake so that rotated files are used.
+func newTLSConfig(cfg HTTPConfig) (*tls.Config, error) {
+	if cfg.CAFile == "" && cfg.CertFile == "" && cfg.KeyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("both client certificate and key files are required")
+		}
+		if _, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile); err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			return &cert, nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// urlParseError returns the cause of an error from url.Parse, without the
+// URL, which may carry credentials
+func urlParseError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,408 @@
+package notifier
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// DefaultNATSSubject is the subject pattern used when none is configured
+const DefaultNATSSubject = "k8s.events.{namespace}.{kind}"
+
+// maxNATSAckSize bounds the JetStream acknowledgements read from the server,
+// which are small JSON documents
+const maxNATSAckSize = 64 * 1024
+
+// NATSConfig contains NATS publisher settings
+type NATSConfig struct {
+	URL       string // nats://host:4222 or tls://host:4222
+	Subject   string // Pattern with {cluster}, {namespace}, {kind}, {name} and {eventType} placeholders
+	Token     string
+	User      string
+	Password  string
+	JetStream bool          // Wait for JetStream publish acknowledgements
+	Timeout   time.Duration // Per message, including its acknowledgement
+
+	CAFile   string // PEM bundle trusted in addition to the system roots
+	CertFile string // Client certificate for mutual TLS
+	KeyFile  string // Client certificate key for mutual TLS
+}
+
+// NATSNotifier publishes event payloads to NATS subjects using the core NATS protocol
+type NATSNotifier struct {
+	cfg       NATSConfig
+	tlsConfig *tls.Config // nil for the defaults
+
+	mu     sync.Mutex // Serializes use of the connection
+	conn   net.Conn
+	reader *bufio.Reader
+	inbox  string // Subscription prefix for JetStream acknowledgements
+	seq    int
+
+	// The batch whose publish last failed and how many of its events were
+	// published, so that a retry of the batch resumes after them
+	partial     *BatchPayload
+	partialSent int
+}
+
+// NewNATSNotifier creates a new NATSNotifier. The connection is established on first use.
+func NewNATSNotifier(cfg NATSConfig) (*NATSNotifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("nats url is required")
+	}
+	if _, err := url.Parse(cfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid nats url: %w", urlParseError(err))
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = DefaultNATSSubject
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	tlsConfig, err := newTLSConfig(HTTPConfig{CAFile: cfg.CAFile, CertFile: cfg.CertFile, KeyFile: cfg.KeyFile})
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats tls settings: %w", err)
+	}
+
+	return &NATSNotifier{cfg: cfg, tlsConfig: tlsConfig}, nil
+}
+
+// Name identifies the notifier in logs
+func (n *NATSNotifier) Name() string {
+	return "nats"
+}
+
+// NotifyEvent publishes an event to its subject
+func (n *NATSNotifier) NotifyEvent(event *watcher.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err := n.publish([]*EventPayload{NewEventPayload(event)})
+	return err
+}
+
+// NotifyBatch publishes each event of the batch to its own subject. When the
+// same batch is retried after a failure, the events published by the failed
+// attempt are skipped: acknowledged ones with JetStream, written ones otherwise.
+func (n *NATSNotifier) NotifyBatch(batch *BatchPayload) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	events := batch.Events
+	skipped := 0
+	if n.partial == batch {
+		skipped = n.partialSent
+		events = events[skipped:]
+	}
+	sent, err := n.publish(events)
+	if err != nil {
+		n.partial, n.partialSent = batch, skipped+sent
+		return err
+	}
+	n.partial, n.partialSent = nil, 0
+	return nil
+}
+
+// Close closes the connection
+func (n *NATSNotifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.closeConn()
+}
+
+// CheckHealth connects to the server if needed and round-trips a PING
+func (n *NATSNotifier) CheckHealth() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err := n.publish(nil)
+	return err
+}
+
+// Subject renders the subject pattern for an event
+func (n *NATSNotifier) Subject(event *EventPayload) string {
+	return strings.NewReplacer(
+		"{cluster}", subjectToken(event.Cluster),
+		"{namespace}", subjectToken(event.Namespace),
+		"{kind}", subjectToken(event.Kind),
+		"{name}", subjectToken(event.Name),
+		"{eventType}", subjectToken(event.EventType),
+	).Replace(n.cfg.Subject)
+}
+
+// subjectToken makes a value safe to use as a single subject token
+func subjectToken(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// publish sends the events and waits until the server has processed them,
+// returning how many were published. The connection is dropped on failure
+// and re-established on the next publish. n.mu must be held.
+func (n *NATSNotifier) publish(events []*EventPayload) (int, error) {
+	sent, err := n.publishLocked(events)
+	if err != nil {
+		n.closeConn()
+		return sent, fmt.Errorf("failed to publish to nats: %w", err)
+	}
+	return sent, nil
+}
+
+func (n *NATSNotifier) publishLocked(events []*EventPayload) (int, error) {
+	if n.conn == nil {
+		if err := n.connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Every message gets the full timeout, so that large batches do not time out
+	for i, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return i, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		if err := n.conn.SetDeadline(time.Now().Add(n.cfg.Timeout)); err != nil {
+			return i, err
+		}
+
+		subject := n.Subject(event)
+		if !n.cfg.JetStream {
+			if _, err := fmt.Fprintf(n.conn, "PUB %s %d\r\n%s\r\n", subject, len(data), data); err != nil {
+				return i, err
+			}
+			continue
+		}
+
+		n.seq++
+		reply := n.inbox + strconv.Itoa(n.seq)
+		if _, err := fmt.Fprintf(n.conn, "PUB %s %s %d\r\n%s\r\n", subject, reply, len(data), data); err != nil {
+			return i, err
+		}
+		if err := n.waitForAck(reply); err != nil {
+			return i, err
+		}
+	}
+
+	if n.cfg.JetStream {
+		return len(events), nil
+	}
+	// Round-trip a PING so that errors for the published messages are reported
+	if err := n.conn.SetDeadline(time.Now().Add(n.cfg.Timeout)); err != nil {
+		return len(events), err
+	}
+	if _, err := io.WriteString(n.conn, "PING\r\n"); err != nil {
+		return len(events), err
+	}
+	return len(events), n.waitFor(func(line string) (bool, error) {
+		return line == "PONG", nil
+	})
+}
+
+// connect dials the server and performs the CONNECT handshake
+func (n *NATSNotifier) connect() error {
+	u, err := url.Parse(n.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid nats url: %w", urlParseError(err))
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	conn, err := net.DialTimeout("tcp", host, n.cfg.Timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", host, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(n.cfg.Timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	reader := bufio.NewReader(conn)
+
+	// The server greets with INFO
+	line, err := readLine(reader)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read server info: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected server greeting: %q", line)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+
+	if u.Scheme == "tls" || info.TLSRequired {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if n.tlsConfig != nil {
+			tlsConfig = n.tlsConfig.Clone()
+		}
+		tlsConfig.ServerName = u.Hostname()
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return fmt.Errorf("tls handshake failed: %w", err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	user, pass := n.cfg.User, n.cfg.Password
+	if u.User != nil && user == "" {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+	}
+	connectOpts, _ := json.Marshal(map[string]interface{}{
+		"verbose":    false,
+		"pedantic":   false,
+		"name":       "kube-watcher",
+		"lang":       "go",
+		"protocol":   1,
+		"user":       user,
+		"pass":       pass,
+		"auth_token": n.cfg.Token,
+	})
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connectOpts); err != nil {
+		conn.Close()
+		return err
+	}
+
+	n.conn = conn
+	n.reader = reader
+
+	// Authentication errors are reported before the PONG
+	if err := n.waitFor(func(line string) (bool, error) {
+		return line == "PONG", nil
+	}); err != nil {
+		n.closeConn()
+		return err
+	}
+
+	if n.cfg.JetStream {
+		n.inbox = "_INBOX." + randomToken() + "."
+		if _, err := fmt.Fprintf(conn, "SUB %s* 1\r\n", n.inbox); err != nil {
+			n.closeConn()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitForAck waits for the JetStream acknowledgement sent to reply
+func (n *NATSNotifier) waitForAck(reply string) error {
+	return n.waitFor(func(line string) (bool, error) {
+		if !strings.HasPrefix(line, "MSG ") {
+			return false, nil
+		}
+
+		// MSG <subject> <sid> [reply-to] <#bytes>
+		fields := strings.Fields(line)
+		if len(fields) != 4 && len(fields) != 5 {
+			return false, fmt.Errorf("malformed MSG: %q", line)
+		}
+		size, err := strconv.Atoi(fields[len(fields)-1])
+		if err != nil || size < 0 || size > maxNATSAckSize {
+			return false, fmt.Errorf("malformed MSG: %q", line)
+		}
+		payload := make([]byte, size+2) // Payload followed by CRLF
+		if _, err := io.ReadFull(n.reader, payload); err != nil {
+			return false, err
+		}
+		if fields[1] != reply {
+			return false, nil
+		}
+
+		var ack struct {
+			Stream string `json:"stream"`
+			Error  *struct {
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(payload[:size], &ack); err != nil {
+			return false, fmt.Errorf("invalid jetstream ack: %w", err)
+		}
+		if ack.Error != nil {
+			return false, fmt.Errorf("jetstream error: %s", ack.Error.Description)
+		}
+		if ack.Stream == "" {
+			return false, fmt.Errorf("no jetstream stream matches the subject")
+		}
+		return true, nil
+	})
+}
+
+// waitFor reads protocol lines until done returns true, answering server PINGs
+// and converting -ERR lines into errors
+func (n *NATSNotifier) waitFor(done func(line string) (bool, error)) error {
+	for {
+		line, err := readLine(n.reader)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PING":
+			if _, err := io.WriteString(n.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+		}
+
+		ok, err := done(line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+}
+
+func (n *NATSNotifier) closeConn() error {
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	n.reader = nil
+	return err
+}
+
+// readLine reads a single CRLF-terminated protocol line
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// randomToken returns a random hex string for inbox subjects
+func randomToken() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
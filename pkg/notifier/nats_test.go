@@ -0,0 +1,250 @@
+package notifier
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// fakeNATSServer implements the subset of the NATS protocol used by NATSNotifier
+type fakeNATSServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	subjects []string
+	payloads []EventPayload
+	connects []string
+	ackError string
+	rejected string // Name of the events whose publish is rejected
+	ackLine  string // Sent instead of the acknowledgement when set
+}
+
+func newFakeNATSServer(t *testing.T) *fakeNATSServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &fakeNATSServer{listener: listener}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeNATSServer) URL() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *fakeNATSServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeNATSServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\"}\r\n")
+
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "CONNECT":
+			s.mu.Lock()
+			s.connects = append(s.connects, strings.TrimPrefix(line, "CONNECT "))
+			s.mu.Unlock()
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case "PUB":
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+			var payload EventPayload
+			json.Unmarshal(data[:size], &payload)
+
+			s.mu.Lock()
+			s.subjects = append(s.subjects, fields[1])
+			s.payloads = append(s.payloads, payload)
+			ack := fmt.Sprintf(`{"stream":"EVENTS","seq":%d}`, len(s.payloads))
+			if s.ackError != "" || (s.rejected != "" && payload.Name == s.rejected) {
+				ack = fmt.Sprintf(`{"error":{"code":503,"description":%q}}`, s.ackError+"rejected")
+			}
+			ackLine := s.ackLine
+			s.mu.Unlock()
+
+			if len(fields) == 4 && ackLine != "" {
+				io.WriteString(conn, ackLine)
+			} else if len(fields) == 4 {
+				fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", fields[2], len(ack), ack)
+			}
+		}
+	}
+}
+
+func TestNATSNotifier_Subject(t *testing.T) {
+	n, err := NewNATSNotifier(NATSConfig{URL: "nats://localhost:4222", Subject: "k8s.{cluster}.{namespace}.{kind}.{name}"})
+	if err != nil {
+		t.Fatalf("NewNATSNotifier() error = %v", err)
+	}
+
+	subject := n.Subject(&EventPayload{Kind: "ConfigMap", Name: "kube-root-ca.crt"})
+	if subject != "k8s._._.ConfigMap.kube-root-ca_crt" {
+		t.Errorf("Subject() = %q", subject)
+	}
+}
+
+func TestNATSNotifier_Publish(t *testing.T) {
+	server := newFakeNATSServer(t)
+
+	n, err := NewNATSNotifier(NATSConfig{URL: server.URL(), Token: "secret"})
+	if err != nil {
+		t.Fatalf("NewNATSNotifier() error = %v", err)
+	}
+	defer n.Close()
+
+	if err := n.NotifyEvent(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "DELETED"}); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+	batch := NewBatchPayload([]*watcher.Event{
+		{Kind: "Deployment", Namespace: "dev", Name: "api", EventType: "UPDATED"},
+	}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	expected := []string{"k8s.events.prod.Pod", "k8s.events.dev.Deployment"}
+	if strings.Join(server.subjects, ",") != strings.Join(expected, ",") {
+		t.Errorf("Subjects = %v, want %v", server.subjects, expected)
+	}
+	if server.payloads[0].Name != "web" {
+		t.Errorf("Unexpected payload %+v", server.payloads[0])
+	}
+	// 接続は再利用される
+	if len(server.connects) != 1 || !strings.Contains(server.connects[0], `"auth_token":"secret"`) {
+		t.Errorf("Expected a single authenticated connection, got %v", server.connects)
+	}
+}
+
+func TestNATSNotifier_JetStream(t *testing.T) {
+	server := newFakeNATSServer(t)
+
+	n, err := NewNATSNotifier(NATSConfig{URL: server.URL(), JetStream: true})
+	if err != nil {
+		t.Fatalf("NewNATSNotifier() error = %v", err)
+	}
+	defer n.Close()
+
+	event := &watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "ADDED"}
+	if err := n.NotifyEvent(event); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+
+	server.mu.Lock()
+	server.ackError = "insufficient resources"
+	server.mu.Unlock()
+
+	if err := n.NotifyEvent(event); err == nil || !strings.Contains(err.Error(), "insufficient resources") {
+		t.Errorf("Expected JetStream error, got %v", err)
+	}
+}
+
+func TestNATSNotifier_ConnectionError(t *testing.T) {
+	n, err := NewNATSNotifier(NATSConfig{URL: "nats://127.0.0.1:1", Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewNATSNotifier() error = %v", err)
+	}
+
+	if err := n.NotifyEvent(&watcher.Event{Kind: "Pod", Name: "web"}); err == nil {
+		t.Error("Expected connection error, got nil")
+	}
+}
+
+func TestNATSNotifier_RetryBatch(t *testing.T) {
+	server := newFakeNATSServer(t)
+	server.rejected = "b"
+
+	n, err := NewNATSNotifier(NATSConfig{URL: server.URL(), JetStream: true})
+	if err != nil {
+		t.Fatalf("NewNATSNotifier() error = %v", err)
+	}
+	defer n.Close()
+
+	batch := NewBatchPayload([]*watcher.Event{
+		{Kind: "Pod", Namespace: "prod", Name: "a", EventType: "ADDED"},
+		{Kind: "Pod", Namespace: "prod", Name: "b", EventType: "ADDED"},
+		{Kind: "Pod", Namespace: "prod", Name: "c", EventType: "ADDED"},
+	}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err == nil {
+		t.Fatal("Expected the rejected event to fail the batch")
+	}
+
+	// 再試行では確認済みのイベントを再送しない
+	server.mu.Lock()
+	server.rejected = ""
+	server.mu.Unlock()
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	var names []string
+	for _, p := range server.payloads {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "a,b,b,c" {
+		t.Errorf("Published %s, want a,b,b,c", got)
+	}
+}
+
+func TestNATSNotifier_MalformedAck(t *testing.T) {
+	for _, line := range []string{
+		"MSG 5\r\n",
+		"MSG _INBOX.x\r\n",
+		"MSG _INBOX.x 1 -5\r\n",
+		"MSG _INBOX.x 1 99999999999\r\n",
+	} {
+		server := newFakeNATSServer(t)
+		server.ackLine = line
+
+		n, err := NewNATSNotifier(NATSConfig{URL: server.URL(), JetStream: true, Timeout: time.Second})
+		if err != nil {
+			t.Fatalf("NewNATSNotifier() error = %v", err)
+		}
+		// 不正な応答はパニックせずエラーになる
+		if err := n.NotifyEvent(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web"}); err == nil || !strings.Contains(err.Error(), "malformed MSG") {
+			t.Errorf("Expected an error for %q, got %v", line, err)
+		}
+		n.Close()
+	}
+}
+
+func TestNewNATSNotifier_TLS(t *testing.T) {
+	if _, err := NewNATSNotifier(NATSConfig{URL: "tls://localhost:4222", CAFile: "/nonexistent/ca.crt"}); err == nil {
+		t.Error("Expected error for a missing CA file")
+	}
+	if _, err := NewNATSNotifier(NATSConfig{URL: "tls://localhost:4222", CertFile: "/nonexistent/tls.crt"}); err == nil {
+		t.Error("Expected error for a client certificate without key")
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // Notifier sends notifications to external services
@@ -14,25 +17,111 @@ type Notifier interface {
 	Send(message string) error
 }
 
-// SlackNotifier sends notifications to Slack via webhook
+// Slack message size limits used when splitting oversized messages
+const (
+	// DefaultMaxAttachments is the maximum number of attachments sent in a single message
+	DefaultMaxAttachments = 50
+	// DefaultMaxMessageBytes is the maximum JSON payload size sent in a single message
+	DefaultMaxMessageBytes = 40000
+	// MaxBlocks is the maximum number of blocks Slack accepts in a single message
+	MaxBlocks = 50
+	// MaxSectionTextChars is the maximum length of the text of a section block
+	MaxSectionTextChars = 3000
+	// MaxSectionFields is the maximum number of fields of a section block
+	MaxSectionFields = 10
+)
+
+// Web API endpoints used in bot-token mode
+const (
+	slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+	slackAuthTestURL    = "https://slack.com/api/auth.test"
+)
+
+// SlackNotifier sends notifications to Slack via webhook, or via the Web API
+// when a bot token is configured
 type SlackNotifier struct {
-	webhookURL string
-	httpClient *http.Client
+	webhookURL      string
+	authTestURL     string // Bot-token mode only
+	uploadURLs      slackUploadURLs
+	botToken        string
+	channel         string
+	httpClient      *http.Client
+	maxAttachments  int
+	maxMessageBytes int
+	retry           RetryPolicy
+	breaker         *CircuitBreaker
+	dryRun          *DryRun
+	dryRunName      string
+	coalescer       *Coalescer
+	limiter         *RateLimiter
+
+	// Threading state (bot-token mode only)
+	threadTTL time.Duration
+	threadsMu sync.Mutex
+	threads   map[string]slackThread
+}
+
+// slackThread records the root message of a resource thread
+type slackThread struct {
+	ts       string
+	lastUsed time.Time
+	starting chan struct{} // Set while the root message is being sent; closed when done
 }
 
 // SlackMessage represents a Slack message payload
 type SlackMessage struct {
+	Channel     string            `json:"channel,omitempty"`   // Bot-token mode only
+	ThreadTS    string            `json:"thread_ts,omitempty"` // Bot-token mode only
 	Text        string            `json:"text,omitempty"`
+	Blocks      []SlackBlock      `json:"blocks,omitempty"`
 	Attachments []SlackAttachment `json:"attachments,omitempty"`
+
+	Unlimited bool `json:"-"` // Sent even when the rate limit is exceeded
+}
+
+// Slack Block Kit block types
+const (
+	BlockTypeSection = "section"
+	BlockTypeContext = "context"
+	BlockTypeDivider = "divider"
+	BlockTypeHeader  = "header"
+)
+
+// SlackBlock represents a Slack Block Kit layout block
+type SlackBlock struct {
+	Type      string       `json:"type"`
+	Text      *SlackText   `json:"text,omitempty"`
+	Fields    []SlackText  `json:"fields,omitempty"`
+	Elements  []SlackText  `json:"elements,omitempty"`
+	Accessory *SlackButton `json:"accessory,omitempty"` // Section blocks only
+}
+
+// ActionAcknowledge is the action ID of the button acknowledging an event. Its value is the event ID.
+const ActionAcknowledge = "kube_watcher_ack"
+
+// SlackButton represents a Slack Block Kit button element. Clicks are sent to
+// the Slack app's interactivity request URL.
+type SlackButton struct {
+	Type     string    `json:"type"` // "button"
+	Text     SlackText `json:"text"`
+	ActionID string    `json:"action_id"`
+	Value    string    `json:"value,omitempty"`
+	Style    string    `json:"style,omitempty"` // "primary" | "danger"
+}
+
+// SlackText represents a Slack Block Kit text object
+type SlackText struct {
+	Type string `json:"type"` // "mrkdwn" | "plain_text"
+	Text string `json:"text"`
 }
 
 // SlackAttachment represents a Slack message attachment
 type SlackAttachment struct {
-	Color     string              `json:"color,omitempty"`
-	Title     string              `json:"title,omitempty"`
-	Text      string              `json:"text,omitempty"`
+	Color     string                 `json:"color,omitempty"`
+	Title     string                 `json:"title,omitempty"`
+	Text      string                 `json:"text,omitempty"`
 	Fields    []SlackAttachmentField `json:"fields,omitempty"`
-	Timestamp int64               `json:"ts,omitempty"`
+	Timestamp int64                  `json:"ts,omitempty"`
 }
 
 // SlackAttachmentField represents a field in a Slack attachment
@@ -47,9 +136,124 @@ func NewSlackNotifier(webhookURL string) *SlackNotifier {
 	return &SlackNotifier{
 		webhookURL: webhookURL,
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: DefaultHTTPTimeout,
 		},
+		maxAttachments:  DefaultMaxAttachments,
+		maxMessageBytes: DefaultMaxMessageBytes,
+	}
+}
+
+// NewSlackBotNotifier creates a new SlackNotifier that posts to channel with
+// chat.postMessage using a bot token. Unlike webhooks, this mode can reply in threads.
+func NewSlackBotNotifier(botToken, channel string) *SlackNotifier {
+	s := NewSlackNotifier(slackPostMessageURL)
+	s.authTestURL = slackAuthTestURL
+	s.uploadURLs = slackUploadURLs{getURL: slackGetUploadURL, complete: slackCompleteUploadURL}
+	s.botToken = botToken
+	s.channel = channel
+	return s
+}
+
+// EnableThreading makes SendThreaded post follow-up messages with the same key
+// as replies to the first one. Threads unused for longer than ttl are forgotten.
+// Threading requires bot-token mode and is a no-op for webhooks.
+func (s *SlackNotifier) EnableThreading(ttl time.Duration) {
+	if s.botToken == "" {
+		return
+	}
+
+	s.threadsMu.Lock()
+	defer s.threadsMu.Unlock()
+	s.threadTTL = ttl
+	if s.threads == nil {
+		s.threads = make(map[string]slackThread)
+	}
+}
+
+// InheritThreads copies known threads from a previous notifier posting to the same
+// channel, so that a configuration reload does not start new threads
+func (s *SlackNotifier) InheritThreads(prev *SlackNotifier) {
+	if prev == nil || prev == s || prev.channel != s.channel {
+		return
+	}
+
+	prev.threadsMu.Lock()
+	defer prev.threadsMu.Unlock()
+	s.threadsMu.Lock()
+	defer s.threadsMu.Unlock()
+	if s.threads == nil {
+		return
+	}
+	for key, thread := range prev.threads {
+		if thread.starting == nil {
+			s.threads[key] = thread
+		}
+	}
+}
+
+// SetHTTPClient replaces the HTTP client, e.g. one created by NewHTTPClient
+func (s *SlackNotifier) SetHTTPClient(client *http.Client) {
+	s.httpClient = client
+}
+
+// SetRetryPolicy sets how failed requests are retried
+func (s *SlackNotifier) SetRetryPolicy(policy RetryPolicy) {
+	s.retry = policy
+}
+
+// SetCircuitBreaker guards deliveries with breaker; nil disables it
+func (s *SlackNotifier) SetCircuitBreaker(breaker *CircuitBreaker) {
+	s.breaker = breaker
+}
+
+// SetDryRun makes the notifier write messages to d under the destination name
+// instead of posting them
+func (s *SlackNotifier) SetDryRun(d *DryRun, name string) {
+	s.dryRun = d
+	s.dryRunName = name
+}
+
+// SetCoalesceInterval makes the notifier send byte-identical messages within
+// interval only once, followed by a note with the number of repeats. Non-positive
+// values disable coalescing.
+func (s *SlackNotifier) SetCoalesceInterval(interval time.Duration) {
+	if interval <= 0 {
+		s.coalescer = nil
+		return
+	}
+	s.coalescer = NewCoalescer(interval, func(repeats int) {
+		text := fmt.Sprintf(":repeat: The previous message was repeated %d more times within %s", repeats, interval)
+		if _, err := s.sendParts(&SlackMessage{Text: text}, ""); err != nil {
+			slog.Warn("Failed to report coalesced Slack messages", "repeats", repeats, "error", err)
+		}
+	})
+}
+
+// SetRateLimit limits the messages sent to perMinute per minute, with bursts of
+// up to burst messages. Messages over the limit are not sent; instead, the number
+// of suppressed messages is posted a minute after the limit was first exceeded.
+// A non-positive perMinute disables the limit.
+func (s *SlackNotifier) SetRateLimit(perMinute, burst int) {
+	if perMinute <= 0 {
+		s.limiter = nil
+		return
 	}
+	s.limiter = NewRateLimiter(perMinute, burst, func(suppressed int) {
+		slog.Warn("Slack messages suppressed by the rate limit", "limit", perMinute, "suppressed", suppressed)
+		text := fmt.Sprintf(":no_bell: Rate limit of %d messages per minute exceeded: suppressed %d notifications in the last minute", perMinute, suppressed)
+		if _, err := s.sendParts(&SlackMessage{Text: text}, ""); err != nil {
+			slog.Warn("Failed to report rate-limited Slack messages", "suppressed", suppressed, "error", err)
+		}
+	})
+}
+
+// SetMaxMessageBytes sets the approximate JSON size limit of a single Slack request.
+// Non-positive values restore DefaultMaxMessageBytes.
+func (s *SlackNotifier) SetMaxMessageBytes(n int) {
+	if n <= 0 {
+		n = DefaultMaxMessageBytes
+	}
+	s.maxMessageBytes = n
 }
 
 // Send sends a message to Slack
@@ -61,29 +265,401 @@ func (s *SlackNotifier) Send(message string) error {
 	return s.SendMessage(&payload)
 }
 
-// SendMessage sends a SlackMessage to Slack
+// SendMessage sends a SlackMessage to Slack.
+// Messages exceeding the size limits are split and sent as sequential parts,
+// and parts that are still too large have their longest values truncated.
 func (s *SlackNotifier) SendMessage(payload *SlackMessage) error {
+	_, err := s.send(payload, "")
+	return err
+}
+
+// SendThreaded sends a SlackMessage as a reply in the thread identified by key.
+// The first message for a key becomes the thread root. Without threading enabled
+// this is equivalent to SendMessage.
+func (s *SlackNotifier) SendThreaded(key string, payload *SlackMessage) error {
+	_, err := s.sendThreaded(key, payload)
+	return err
+}
+
+// sendThreaded sends a message like SendThreaded and returns the timestamp of
+// the thread it can be replied to in: the thread it was posted in, or the
+// message itself. The timestamp is empty unless the message was posted in
+// bot-token mode.
+func (s *SlackNotifier) sendThreaded(key string, payload *SlackMessage) (string, error) {
+	if s.threads == nil || key == "" {
+		return s.send(payload, "")
+	}
+
+	// The lock is not held while sending, as sends are retried with backoff.
+	// A new thread is reserved, so that messages with the same key wait for
+	// its root message instead of starting threads of their own.
+	thread := s.reserveThread(key)
+	ts, err := s.send(payload, thread.ts)
+
+	s.threadsMu.Lock()
+	defer s.threadsMu.Unlock()
+	if thread.starting != nil {
+		if err == nil && ts != "" {
+			s.threads[key] = slackThread{ts: ts, lastUsed: time.Now()}
+		} else {
+			delete(s.threads, key)
+		}
+		close(thread.starting)
+	} else if current, ok := s.threads[key]; ok && err == nil {
+		current.lastUsed = time.Now()
+		s.threads[key] = current
+	}
+
+	if err != nil {
+		return "", err
+	}
+	if ts == "" || thread.ts == "" {
+		return ts, nil
+	}
+	return thread.ts, nil
+}
+
+// reserveThread returns the thread of the key to reply in. If there is none,
+// a thread is reserved and returned with its starting channel set; the caller
+// must replace or delete it and close the channel once the root message is sent.
+func (s *SlackNotifier) reserveThread(key string) slackThread {
+	for {
+		s.threadsMu.Lock()
+		s.pruneThreads(time.Now())
+		thread, ok := s.threads[key]
+		if !ok {
+			thread = slackThread{starting: make(chan struct{})}
+			s.threads[key] = thread
+			s.threadsMu.Unlock()
+			return thread
+		}
+		s.threadsMu.Unlock()
+
+		if thread.starting == nil {
+			return thread
+		}
+		<-thread.starting
+	}
+}
+
+// pruneThreads forgets threads that have not been used within the TTL
+func (s *SlackNotifier) pruneThreads(now time.Time) {
+	if s.threadTTL <= 0 {
+		return
+	}
+	for key, thread := range s.threads {
+		if thread.starting == nil && now.Sub(thread.lastUsed) > s.threadTTL {
+			delete(s.threads, key)
+		}
+	}
+}
+
+// send sends a message unless an identical one was sent to the same thread
+// within the coalescing interval or the rate limit is exceeded, in which case
+// it returns an empty timestamp
+func (s *SlackNotifier) send(payload *SlackMessage, threadTS string) (string, error) {
+	if s.coalescer == nil {
+		return s.limitedSend(payload, threadTS)
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal slack message: %w", err)
+	}
+	var ts string
+	err = s.coalescer.Do(append([]byte(threadTS+"\n"), message...), func() error {
+		var err error
+		ts, err = s.limitedSend(payload, threadTS)
+		return err
+	})
+	return ts, err
+}
+
+// limitedSend sends a message unless the rate limit is exceeded. Unlimited
+// messages are sent regardless and do not count against the limit.
+func (s *SlackNotifier) limitedSend(payload *SlackMessage, threadTS string) (string, error) {
+	if !payload.Unlimited && !s.limiter.Allow() {
+		return "", nil
+	}
+	return s.sendParts(payload, threadTS)
+}
+
+// sendParts splits and posts a message, replying in threadTS if set. When starting a
+// new thread, later parts of a split message are posted as replies to the first.
+// It returns the timestamp of the first part.
+func (s *SlackNotifier) sendParts(payload *SlackMessage, threadTS string) (string, error) {
+	parts := SplitMessage(payload, s.maxAttachments, s.maxMessageBytes)
+
+	firstTS := ""
+	for i, part := range parts {
+		msg := *TruncateMessage(part, s.maxMessageBytes)
+		if s.botToken != "" {
+			msg.Channel = s.channel
+			msg.ThreadTS = threadTS
+		}
+
+		var ts string
+		err := s.breaker.Do(func() error {
+			return s.retry.Do(func() error {
+				var err error
+				ts, err = s.post(&msg)
+				return err
+			})
+		})
+		if err != nil {
+			if len(parts) > 1 {
+				return "", fmt.Errorf("failed to send part %d/%d: %w", i+1, len(parts), err)
+			}
+			return "", err
+		}
+
+		if i == 0 {
+			firstTS = ts
+			if threadTS == "" && s.threads != nil {
+				threadTS = ts
+			}
+		}
+	}
+
+	return firstTS, nil
+}
+
+// slackAPIResponse is the response of chat.postMessage
+type slackAPIResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	TS    string `json:"ts,omitempty"`
+}
+
+// post sends a single SlackMessage to the webhook or Web API, returning the
+// message timestamp in bot-token mode
+func (s *SlackNotifier) post(payload *SlackMessage) (string, error) {
+	if s.dryRun != nil {
+		return "", s.dryRun.write(s.dryRunName, payload)
+	}
+
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal slack message: %w", err)
+		return "", fmt.Errorf("failed to marshal slack message: %w", err)
 	}
 
 	req, err := http.NewRequest("POST", s.webhookURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if s.botToken != "" {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Authorization", "Bearer "+s.botToken)
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("slack API returned non-200 status code: %d", resp.StatusCode)
+		return "", newStatusError(resp, fmt.Sprintf("slack API returned non-200 status code: %d", resp.StatusCode))
+	}
+
+	if s.botToken == "" {
+		return "", nil
+	}
+
+	var result slackAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode slack API response: %w", err)
+	}
+	if !result.OK {
+		return "", fmt.Errorf("slack API returned error: %s", result.Error)
+	}
+
+	return result.TS, nil
+}
+
+// CheckHealth verifies the bot token with auth.test, or the webhook URL with an empty
+// message. Slack rejects an empty message to an existing webhook with 400, while
+// revoked or mistyped webhooks return 403, 404 or 410, so nothing is posted.
+func (s *SlackNotifier) CheckHealth() error {
+	if s.botToken != "" {
+		req, err := http.NewRequest("POST", s.authTestURL, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+s.botToken)
+
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return newStatusError(resp, fmt.Sprintf("slack API returned non-200 status code: %d", resp.StatusCode))
+		}
+		var result slackAPIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode slack API response: %w", err)
+		}
+		if !result.OK {
+			return fmt.Errorf("slack API returned error: %s", result.Error)
+		}
+		return nil
+	}
+
+	req, err := http.NewRequest("POST", s.webhookURL, bytes.NewBufferString("{}"))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return checkEndpoint(s.httpClient, req, func(status int) bool {
+		return status == http.StatusBadRequest
+	})
+}
+
+// SplitMessage splits a message into parts that each contain at most maxAttachments
+// attachments and MaxBlocks blocks and roughly maxBytes of JSON. Section blocks
+// exceeding the text or field limits are split into several sections first. The
+// blocks are sent before the attachments. Each part after splitting carries a
+// "(part i/n)" marker appended to the message text. A message within the limits
+// is returned unchanged as a single part.
+func SplitMessage(msg *SlackMessage, maxAttachments, maxBytes int) []*SlackMessage {
+	if blocks, split := splitSections(msg.Blocks); split {
+		copied := *msg
+		copied.Blocks = blocks
+		msg = &copied
+	}
+	if len(msg.Attachments)+len(msg.Blocks) <= 1 {
+		return []*SlackMessage{msg}
+	}
+
+	// Reserve room for the text and the part marker
+	baseSize := len(msg.Text) + len(" (part 00/00)") + len(`{"text":"","blocks":[],"attachments":[]}`)
+
+	blockChunks := chunkItems(msg.Blocks, MaxBlocks, maxBytes, baseSize)
+	attachmentChunks := chunkItems(msg.Attachments, maxAttachments, maxBytes, baseSize)
+	if len(blockChunks) <= 1 && len(attachmentChunks) <= 1 && messageFits(msg, maxBytes) {
+		return []*SlackMessage{msg}
+	}
+
+	var parts []*SlackMessage
+	for _, chunk := range blockChunks {
+		parts = append(parts, &SlackMessage{Blocks: chunk})
+	}
+	for _, chunk := range attachmentChunks {
+		parts = append(parts, &SlackMessage{Attachments: chunk})
+	}
+
+	for i, part := range parts {
+		part.Text = fmt.Sprintf("%s (part %d/%d)", msg.Text, i+1, len(parts))
+	}
+
+	return parts
+}
+
+// messageFits reports whether the JSON of msg is at most maxBytes long
+func messageFits(msg *SlackMessage, maxBytes int) bool {
+	if maxBytes <= 0 {
+		return true
+	}
+	data, err := json.Marshal(msg)
+	return err == nil && len(data) <= maxBytes
+}
+
+// splitSections splits section blocks with a text longer than
+// MaxSectionTextChars or more than MaxSectionFields fields into several
+// sections. The first keeps the accessory. It reports whether any was split.
+func splitSections(blocks []SlackBlock) ([]SlackBlock, bool) {
+	var out []SlackBlock
+	split := false
+	for i, block := range blocks {
+		var texts []string
+		if block.Type == BlockTypeSection && block.Text != nil {
+			texts = splitText(block.Text.Text, MaxSectionTextChars)
+		}
+		if block.Type != BlockTypeSection || (len(texts) <= 1 && len(block.Fields) <= MaxSectionFields) {
+			if split {
+				out = append(out, block)
+			}
+			continue
+		}
+		if !split {
+			out = append(out, blocks[:i]...)
+			split = true
+		}
+
+		first := SlackBlock{Type: BlockTypeSection, Accessory: block.Accessory}
+		if len(texts) > 0 {
+			first.Text = &SlackText{Type: block.Text.Type, Text: texts[0]}
+		}
+		fields := block.Fields
+		first.Fields = fields[:min(len(fields), MaxSectionFields)]
+		out = append(out, first)
+		for _, text := range texts[min(len(texts), 1):] {
+			out = append(out, SlackBlock{Type: BlockTypeSection, Text: &SlackText{Type: block.Text.Type, Text: text}})
+		}
+		for j := MaxSectionFields; j < len(fields); j += MaxSectionFields {
+			out = append(out, SlackBlock{Type: BlockTypeSection, Fields: fields[j:min(len(fields), j+MaxSectionFields)]})
+		}
+	}
+	if !split {
+		return blocks, false
+	}
+	return out, true
+}
+
+// splitText splits s into parts of at most maxChars characters, at line
+// breaks where possible
+func splitText(s string, maxChars int) []string {
+	if utf8.RuneCountInString(s) <= maxChars {
+		return []string{s}
+	}
+	var parts []string
+	runes := []rune(s)
+	for len(runes) > maxChars {
+		cut := maxChars
+		next := maxChars
+		for i := maxChars; i > 0; i-- {
+			if runes[i] == '\n' {
+				// The line break separating the parts is dropped
+				cut, next = i, i+1
+				break
+			}
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = runes[next:]
+	}
+	return append(parts, string(runes))
+}
+
+// chunkItems groups items into chunks of at most maxItems items and roughly maxBytes
+// of JSON each, accounting for baseSize bytes of surrounding payload
+func chunkItems[T any](items []T, maxItems, maxBytes, baseSize int) [][]T {
+	var chunks [][]T
+	var current []T
+	currentSize := baseSize
+
+	for _, item := range items {
+		data, _ := json.Marshal(item)
+		size := len(data) + 1 // Separator
+
+		full := (maxItems > 0 && len(current) >= maxItems) ||
+			(maxBytes > 0 && currentSize+size > maxBytes)
+		if full && len(current) > 0 {
+			chunks = append(chunks, current)
+			current = nil
+			currentSize = baseSize
+		}
+
+		current = append(current, item)
+		currentSize += size
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
 	}
 
-	return nil
+	return chunks
 }
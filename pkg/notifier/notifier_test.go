@@ -2,10 +2,14 @@ package notifier
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestNewSlackNotifier(t *testing.T) {
@@ -172,3 +176,418 @@ func TestSlackMessage_JSON(t *testing.T) {
 		t.Errorf("Expected color %q, got %q", msg.Attachments[0].Color, decoded.Attachments[0].Color)
 	}
 }
+
+func TestSplitMessage_WithinLimits(t *testing.T) {
+	msg := &SlackMessage{
+		Text: "batch",
+		Attachments: []SlackAttachment{
+			{Title: "a"},
+			{Title: "b"},
+		},
+	}
+
+	parts := SplitMessage(msg, 10, DefaultMaxMessageBytes)
+	if len(parts) != 1 {
+		t.Fatalf("Expected 1 part, got %d", len(parts))
+	}
+	if parts[0] != msg {
+		t.Error("Expected original message to be returned unchanged")
+	}
+}
+
+func TestSplitMessage_ByAttachmentCount(t *testing.T) {
+	msg := &SlackMessage{Text: "batch"}
+	for i := 0; i < 5; i++ {
+		msg.Attachments = append(msg.Attachments, SlackAttachment{Title: "item"})
+	}
+
+	parts := SplitMessage(msg, 2, DefaultMaxMessageBytes)
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+
+	// 各パートに part i/n マーカーが付与されているか確認
+	for i, part := range parts {
+		expected := fmt.Sprintf("batch (part %d/3)", i+1)
+		if part.Text != expected {
+			t.Errorf("Part %d text = %q, want %q", i, part.Text, expected)
+		}
+	}
+
+	if len(parts[2].Attachments) != 1 {
+		t.Errorf("Expected last part to have 1 attachment, got %d", len(parts[2].Attachments))
+	}
+}
+
+func TestSplitMessage_ByBytes(t *testing.T) {
+	msg := &SlackMessage{Text: "batch"}
+	for i := 0; i < 4; i++ {
+		msg.Attachments = append(msg.Attachments, SlackAttachment{Text: strings.Repeat("x", 400)})
+	}
+
+	parts := SplitMessage(msg, 0, 1000)
+	if len(parts) < 2 {
+		t.Fatalf("Expected message to be split by size, got %d parts", len(parts))
+	}
+
+	for i, part := range parts {
+		data, _ := json.Marshal(part)
+		if len(data) > 1000 {
+			t.Errorf("Part %d is %d bytes, exceeds limit", i, len(data))
+		}
+	}
+}
+
+func TestSlackNotifier_SendMessage_Split(t *testing.T) {
+	var received []SlackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg SlackMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, msg)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL)
+	notifier.maxAttachments = 2
+
+	msg := &SlackMessage{Text: "batch"}
+	for i := 0; i < 3; i++ {
+		msg.Attachments = append(msg.Attachments, SlackAttachment{Title: "item"})
+	}
+
+	if err := notifier.SendMessage(msg); err != nil {
+		t.Fatalf("SendMessage() error = %v, want nil", err)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(received))
+	}
+	if received[1].Text != "batch (part 2/2)" {
+		t.Errorf("Expected second part marker, got %q", received[1].Text)
+	}
+}
+
+func TestSplitMessage_Blocks(t *testing.T) {
+	msg := &SlackMessage{Text: "batch"}
+	for i := 0; i < MaxBlocks+10; i++ {
+		msg.Blocks = append(msg.Blocks, SlackBlock{Type: BlockTypeDivider})
+	}
+
+	parts := SplitMessage(msg, DefaultMaxAttachments, DefaultMaxMessageBytes)
+	if len(parts) != 2 {
+		t.Fatalf("Expected 2 parts, got %d", len(parts))
+	}
+	if len(parts[0].Blocks) != MaxBlocks {
+		t.Errorf("Expected first part to have %d blocks, got %d", MaxBlocks, len(parts[0].Blocks))
+	}
+	if parts[1].Text != "batch (part 2/2)" {
+		t.Errorf("Expected part marker, got %q", parts[1].Text)
+	}
+}
+
+func TestSplitMessage_BlocksAndAttachments(t *testing.T) {
+	msg := &SlackMessage{
+		Text:        "batch",
+		Blocks:      []SlackBlock{{Type: BlockTypeHeader, Text: &SlackText{Type: "plain_text", Text: "header"}}},
+		Attachments: []SlackAttachment{{Title: "a"}, {Title: "b"}},
+	}
+	// 制限内ならブロックと添付ファイルをそのまま1件で送る
+	if parts := SplitMessage(msg, DefaultMaxAttachments, DefaultMaxMessageBytes); len(parts) != 1 || parts[0] != msg {
+		t.Fatalf("Expected the message unchanged, got %d parts", len(parts))
+	}
+
+	// 分割してもブロックの後に添付ファイルが送られる
+	parts := SplitMessage(msg, 1, DefaultMaxMessageBytes)
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+	if len(parts[0].Blocks) != 1 || len(parts[0].Attachments) != 0 {
+		t.Errorf("Expected the blocks in the first part, got %+v", parts[0])
+	}
+	if len(parts[1].Attachments) != 1 || parts[2].Attachments[0].Title != "b" || parts[2].Text != "batch (part 3/3)" {
+		t.Errorf("Expected an attachment in each later part, got %+v / %+v", parts[1], parts[2])
+	}
+}
+
+func TestSplitMessage_LongSection(t *testing.T) {
+	line := strings.Repeat("あ", 999)
+	button := &SlackButton{Type: "button", Text: SlackText{Type: "plain_text", Text: "Ack"}}
+	section := SlackBlock{
+		Type:      BlockTypeSection,
+		Text:      &SlackText{Type: "mrkdwn", Text: strings.Repeat(line+"\n", 4) + strings.Repeat("い", 3500)},
+		Accessory: button,
+	}
+	for i := 0; i < 12; i++ {
+		section.Fields = append(section.Fields, SlackText{Type: "mrkdwn", Text: fmt.Sprintf("field %d", i)})
+	}
+	msg := &SlackMessage{Text: "event", Blocks: []SlackBlock{{Type: BlockTypeDivider}, section}}
+
+	parts := SplitMessage(msg, DefaultMaxAttachments, 0)
+	if len(parts) != 1 {
+		t.Fatalf("Expected 1 part, got %d", len(parts))
+	}
+	blocks := parts[0].Blocks
+	// 3000文字を超えるテキストは行単位で、10を超えるフィールドは10件ずつ分割される
+	if len(blocks) != 6 {
+		t.Fatalf("Expected 6 blocks, got %d", len(blocks))
+	}
+	if blocks[1].Text.Text != strings.Repeat(line+"\n", 2)+line || blocks[1].Accessory != button || len(blocks[1].Fields) != MaxSectionFields {
+		t.Errorf("Unexpected first section %+v", blocks[1])
+	}
+	if blocks[2].Text.Text != line || blocks[2].Accessory != nil {
+		t.Errorf("Expected the fourth line in the second section, got %d characters", utf8.RuneCountInString(blocks[2].Text.Text))
+	}
+	if n := utf8.RuneCountInString(blocks[3].Text.Text); n != MaxSectionTextChars {
+		t.Errorf("Expected a line without breaks cut at %d characters, got %d", MaxSectionTextChars, n)
+	}
+	if blocks[4].Text.Text != strings.Repeat("い", 500) {
+		t.Errorf("Expected the rest of the line in the fifth section, got %d characters", utf8.RuneCountInString(blocks[4].Text.Text))
+	}
+	if len(blocks[5].Fields) != 2 || blocks[5].Text != nil {
+		t.Errorf("Expected the remaining fields in the last section, got %+v", blocks[5])
+	}
+	// 元のメッセージは変更されない
+	if len(msg.Blocks) != 2 {
+		t.Error("Original message should not be modified")
+	}
+}
+
+func TestSlackNotifier_BotToken_Threading(t *testing.T) {
+	var received []SlackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("Unexpected Authorization header: %q", r.Header.Get("Authorization"))
+		}
+		var msg SlackMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, msg)
+		fmt.Fprintf(w, `{"ok":true,"ts":"1700000000.%06d"}`, len(received))
+	}))
+	defer server.Close()
+
+	notifier := NewSlackBotNotifier("xoxb-test", "C123")
+	notifier.webhookURL = server.URL
+	notifier.EnableThreading(time.Hour)
+
+	for _, key := range []string{"Pod/default/web", "Pod/default/web", "Pod/default/api"} {
+		if err := notifier.SendThreaded(key, &SlackMessage{Text: key}); err != nil {
+			t.Fatalf("SendThreaded() error = %v", err)
+		}
+	}
+
+	if len(received) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(received))
+	}
+	if received[0].Channel != "C123" || received[0].ThreadTS != "" {
+		t.Errorf("First message should start a thread in C123, got %+v", received[0])
+	}
+	// 同じリソースの2件目は最初のメッセージへの返信になる
+	if received[1].ThreadTS != "1700000000.000001" {
+		t.Errorf("Expected reply to first message, got thread_ts %q", received[1].ThreadTS)
+	}
+	if received[2].ThreadTS != "" {
+		t.Errorf("Different resource should start a new thread, got thread_ts %q", received[2].ThreadTS)
+	}
+}
+
+func TestSlackNotifier_Threading_Concurrent(t *testing.T) {
+	var mu sync.Mutex
+	threadTS := make(map[string]string)
+	slowReceived := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg SlackMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		if msg.Text == "slow" {
+			close(slowReceived)
+			<-release
+		}
+		mu.Lock()
+		threadTS[msg.Text] = msg.ThreadTS
+		mu.Unlock()
+		fmt.Fprintf(w, `{"ok":true,"ts":"ts-%s"}`, msg.Text)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackBotNotifier("xoxb-test", "C123")
+	notifier.webhookURL = server.URL
+	notifier.EnableThreading(time.Hour)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		notifier.SendThreaded("web", &SlackMessage{Text: "slow"})
+	}()
+	<-slowReceived
+
+	// 送信中のスレッドがあっても、他のキーの送信はブロックされない
+	done := make(chan struct{})
+	go func() {
+		notifier.SendThreaded("api", &SlackMessage{Text: "other"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send of another key was blocked by a slow send")
+	}
+
+	// 同じキーの2件目は最初のメッセージの送信を待ち、そのスレッドに返信する
+	go func() {
+		defer wg.Done()
+		notifier.SendThreaded("web", &SlackMessage{Text: "reply"})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if threadTS["slow"] != "" || threadTS["reply"] != "ts-slow" {
+		t.Errorf("Expected the reply in the thread of the first message, got %v", threadTS)
+	}
+}
+
+func TestSlackNotifier_InheritThreads(t *testing.T) {
+	prev := NewSlackBotNotifier("xoxb-test", "C123")
+	prev.EnableThreading(time.Hour)
+	prev.threads["web"] = slackThread{ts: "1.1", lastUsed: time.Now()}
+	prev.threads["api"] = slackThread{starting: make(chan struct{})}
+
+	next := NewSlackBotNotifier("xoxb-test", "C123")
+	next.EnableThreading(time.Hour)
+	next.InheritThreads(prev)
+
+	// 送信中で予約されただけのスレッドは引き継がない
+	if len(next.threads) != 1 || next.threads["web"].ts != "1.1" {
+		t.Errorf("Unexpected inherited threads %v", next.threads)
+	}
+	// 引き継いだスレッドは複製で、元の通知先の更新は影響しない
+	prev.threads["web"] = slackThread{ts: "2.2", lastUsed: time.Now()}
+	if next.threads["web"].ts != "1.1" {
+		t.Error("Expected inherited threads to be copies")
+	}
+}
+
+func TestSlackNotifier_Threading_TTL(t *testing.T) {
+	var threadTS []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg SlackMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		threadTS = append(threadTS, msg.ThreadTS)
+		fmt.Fprint(w, `{"ok":true,"ts":"1.1"}`)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackBotNotifier("xoxb-test", "C123")
+	notifier.webhookURL = server.URL
+	notifier.EnableThreading(time.Minute)
+
+	notifier.SendThreaded("key", &SlackMessage{Text: "first"})
+	// 期限切れのスレッドは忘れられる
+	thread := notifier.threads["key"]
+	thread.lastUsed = time.Now().Add(-2 * time.Minute)
+	notifier.threads["key"] = thread
+	notifier.SendThreaded("key", &SlackMessage{Text: "second"})
+
+	if len(threadTS) != 2 || threadTS[1] != "" {
+		t.Errorf("Expected expired thread to be restarted, got %q", threadTS)
+	}
+}
+
+func TestSlackNotifier_BotToken_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackBotNotifier("xoxb-test", "C404")
+	notifier.webhookURL = server.URL
+
+	err := notifier.SendMessage(&SlackMessage{Text: "test"})
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Errorf("Expected channel_not_found error, got %v", err)
+	}
+}
+
+func TestSlackNotifier_Coalesce(t *testing.T) {
+	var mu sync.Mutex
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg SlackMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		mu.Lock()
+		texts = append(texts, msg.Text)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewSlackNotifier(server.URL)
+	n.SetCoalesceInterval(50 * time.Millisecond)
+	for _, text := range []string{"pod restarted", "pod restarted", "pod deleted", "pod restarted"} {
+		if err := n.Send(text); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	// 同一メッセージの繰り返しは間隔の経過後に件数として報告される
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := len(texts)
+		mu.Unlock()
+		if got >= 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(texts) != 3 || texts[0] != "pod restarted" || texts[1] != "pod deleted" {
+		t.Fatalf("Unexpected messages %q", texts)
+	}
+	if !strings.Contains(texts[2], "repeated 2 more times") {
+		t.Errorf("Expected the repeats to be reported, got %q", texts[2])
+	}
+}
+
+func TestSlackNotifier_RateLimit_Unlimited(t *testing.T) {
+	var mu sync.Mutex
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg SlackMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		mu.Lock()
+		texts = append(texts, msg.Text)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewSlackNotifier(server.URL)
+	n.SetRateLimit(1, 1)
+	for _, msg := range []*SlackMessage{
+		{Text: "first"},
+		{Text: "limited"},
+		{Text: "critical", Unlimited: true},
+	} {
+		if err := n.SendMessage(msg); err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+	}
+
+	// 上限を超えてもUnlimitedなメッセージは送信される
+	mu.Lock()
+	defer mu.Unlock()
+	if len(texts) != 2 || texts[0] != "first" || texts[1] != "critical" {
+		t.Errorf("Unexpected messages %q", texts)
+	}
+}
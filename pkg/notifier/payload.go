@@ -0,0 +1,329 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// EventNotifier delivers events as structured payloads, for destinations other than Slack
+type EventNotifier interface {
+	// Name identifies the notifier in logs
+	Name() string
+	// NotifyEvent delivers a single event
+	NotifyEvent(event *watcher.Event) error
+	// NotifyBatch delivers a batch of events
+	NotifyBatch(batch *BatchPayload) error
+}
+
+// ContextNotifier is implemented by event notifiers that pass the trace
+// context in ctx on to the receiver, with the W3C traceparent header
+type ContextNotifier interface {
+	NotifyEventContext(ctx context.Context, event *watcher.Event) error
+	NotifyBatchContext(ctx context.Context, batch *BatchPayload) error
+}
+
+// NotifyEventContext delivers an event with n, passing ctx on if n supports it
+func NotifyEventContext(ctx context.Context, n EventNotifier, event *watcher.Event) error {
+	if cn, ok := n.(ContextNotifier); ok {
+		return cn.NotifyEventContext(ctx, event)
+	}
+	return n.NotifyEvent(event)
+}
+
+// NotifyBatchContext delivers a batch with n, passing ctx on if n supports it
+func NotifyBatchContext(ctx context.Context, n EventNotifier, batch *BatchPayload) error {
+	if cn, ok := n.(ContextNotifier); ok {
+		return cn.NotifyBatchContext(ctx, batch)
+	}
+	return n.NotifyBatch(batch)
+}
+
+// EventPayload is the JSON representation of an event
+type EventPayload struct {
+	ID           string               `json:"id,omitempty"`
+	Cluster      string               `json:"cluster,omitempty"`
+	Kind         string               `json:"kind"`
+	Namespace    string               `json:"namespace,omitempty"`
+	Name         string               `json:"name"`
+	EventType    string               `json:"eventType"`
+	Timestamp    time.Time            `json:"timestamp"`
+	Labels       map[string]string    `json:"labels,omitempty"`
+	Severity     *SeverityPayload     `json:"severity,omitempty"`
+	Reason       string               `json:"reason,omitempty"`
+	Message      string               `json:"message,omitempty"`
+	Status       string               `json:"status,omitempty"`
+	Containers   []ContainerPayload   `json:"containers,omitempty"`
+	Replicas     *ReplicaPayload      `json:"replicas,omitempty"`
+	ServiceType  string               `json:"serviceType,omitempty"`
+	NodeName     string               `json:"nodeName,omitempty"`
+	Addresses    []string             `json:"addresses,omitempty"`
+	Changes      []FieldChangePayload `json:"changes,omitempty"`
+	Logs         *LogsPayload         `json:"logs,omitempty"`
+	Warnings     []WarningPayload     `json:"warnings,omitempty"`
+	Scaling      *ScalingPayload      `json:"scaling,omitempty"`
+	Job          *JobPayload          `json:"job,omitempty"`
+	CronJob      *CronJobPayload      `json:"cronJob,omitempty"`
+	Volume       *VolumePayload       `json:"volume,omitempty"`
+	Rollback     *RollbackPayload     `json:"rollback,omitempty"`
+	Manifest     string               `json:"manifest,omitempty"`
+	ManifestDiff string               `json:"manifestDiff,omitempty"`
+}
+
+// ContainerPayload is the JSON representation of a container
+type ContainerPayload struct {
+	Name      string            `json:"name"`
+	Image     string            `json:"image"`
+	Reason    string            `json:"reason,omitempty"`
+	Resources map[string]string `json:"resources,omitempty"` // Requests and limits keyed like "requests.cpu"
+	Restarts  int32             `json:"restarts,omitempty"`
+}
+
+// ScalingPayload is the JSON representation of the state of an autoscaler
+type ScalingPayload struct {
+	Target          string                    `json:"target"`
+	MinReplicas     int32                     `json:"minReplicas"`
+	MaxReplicas     int32                     `json:"maxReplicas"`
+	CurrentReplicas int32                     `json:"currentReplicas"`
+	DesiredReplicas int32                     `json:"desiredReplicas"`
+	Metrics         []MetricPayload           `json:"metrics,omitempty"`
+	Conditions      []ScalingConditionPayload `json:"conditions,omitempty"`
+}
+
+// MetricPayload is the JSON representation of an autoscaler metric
+type MetricPayload struct {
+	Name    string `json:"name"`
+	Current string `json:"current"`
+	Target  string `json:"target"`
+}
+
+// ScalingConditionPayload is the JSON representation of an autoscaler condition
+type ScalingConditionPayload struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// JobPayload is the JSON representation of the progress of a Job
+type JobPayload struct {
+	Completions  int32              `json:"completions"`
+	BackoffLimit int32              `json:"backoffLimit"`
+	Active       int32              `json:"active"`
+	Succeeded    int32              `json:"succeeded"`
+	Failed       int32              `json:"failed"`
+	FailedPods   []FailedPodPayload `json:"failedPods,omitempty"`
+}
+
+// CronJobPayload is the JSON representation of the schedule and runs of a CronJob
+type CronJobPayload struct {
+	Schedule           string     `json:"schedule"`
+	TimeZone           string     `json:"timeZone,omitempty"`
+	Suspended          bool       `json:"suspended,omitempty"`
+	LastScheduleTime   *time.Time `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime *time.Time `json:"lastSuccessfulTime,omitempty"`
+	Active             []string   `json:"active,omitempty"` // Names of the running Jobs
+}
+
+// SeverityPayload is the JSON representation of the severity of an event,
+// with the values to use for each paging integration
+type SeverityPayload struct {
+	Level        string `json:"level"`
+	PagerDuty    string `json:"pagerDuty,omitempty"`
+	Opsgenie     string `json:"opsgenie,omitempty"`
+	Alertmanager string `json:"alertmanager,omitempty"`
+}
+
+// RollbackPayload is the JSON representation of the rollback of a Deployment
+type RollbackPayload struct {
+	FromRevision int64    `json:"fromRevision"`
+	ToRevision   int64    `json:"toRevision"`
+	FromImages   []string `json:"fromImages,omitempty"`
+	ToImages     []string `json:"toImages,omitempty"`
+}
+
+// VolumePayload is the JSON representation of the state of a PersistentVolumeClaim
+type VolumePayload struct {
+	StorageClass string `json:"storageClass,omitempty"`
+	Requested    string `json:"requested,omitempty"`
+	Capacity     string `json:"capacity,omitempty"`
+	VolumeName   string `json:"volumeName,omitempty"`
+	ResizeError  string `json:"resizeError,omitempty"`
+}
+
+// FailedPodPayload is the JSON representation of a failed Pod of a Job
+type FailedPodPayload struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// WarningPayload is the JSON representation of the Warning Events of a reason
+type WarningPayload struct {
+	Reason   string    `json:"reason"`
+	Message  string    `json:"message"`
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// LogsPayload is the JSON representation of the logs of a failing container
+type LogsPayload struct {
+	Container string `json:"container"`
+	Previous  bool   `json:"previous,omitempty"`
+	Text      string `json:"text"`
+}
+
+// ReplicaPayload is the JSON representation of replica counts
+type ReplicaPayload struct {
+	Desired int32 `json:"desired"`
+	Ready   int32 `json:"ready"`
+	Current int32 `json:"current"`
+}
+
+// FieldChangePayload is the JSON representation of a field change
+type FieldChangePayload struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// BatchPayload is the JSON representation of a batch of events
+type BatchPayload struct {
+	StartTime time.Time       `json:"startTime"`
+	EndTime   time.Time       `json:"endTime"`
+	Events    []*EventPayload `json:"events"`
+
+	// events keeps the source events for notifiers that render their own format
+	events []*watcher.Event
+}
+
+// NewEventPayload converts an event to its JSON representation
+func NewEventPayload(event *watcher.Event) *EventPayload {
+	p := &EventPayload{
+		ID:          event.ID,
+		Cluster:     event.Cluster,
+		Kind:        event.Kind,
+		Namespace:   event.Namespace,
+		Name:        event.Name,
+		EventType:   event.EventType,
+		Timestamp:   event.Timestamp,
+		Labels:      event.Labels,
+		Reason:      event.Reason,
+		Message:     event.Message,
+		Status:      event.Status,
+		ServiceType: event.ServiceType,
+		NodeName:    event.NodeName,
+		Addresses:   event.Addresses,
+	}
+
+	for _, c := range event.Containers {
+		p.Containers = append(p.Containers, ContainerPayload{Name: c.Name, Image: c.Image, Reason: c.Reason, Resources: c.Resources, Restarts: c.Restarts})
+	}
+	if event.Replicas != nil {
+		p.Replicas = &ReplicaPayload{
+			Desired: event.Replicas.Desired,
+			Ready:   event.Replicas.Ready,
+			Current: event.Replicas.Current,
+		}
+	}
+	for _, c := range event.Changes {
+		p.Changes = append(p.Changes, FieldChangePayload{Field: c.Field, Old: c.Old, New: c.New})
+	}
+	if s := event.Scaling; s != nil {
+		p.Scaling = &ScalingPayload{
+			Target:          s.Target,
+			MinReplicas:     s.MinReplicas,
+			MaxReplicas:     s.MaxReplicas,
+			CurrentReplicas: s.CurrentReplicas,
+			DesiredReplicas: s.DesiredReplicas,
+		}
+		for _, m := range s.Metrics {
+			p.Scaling.Metrics = append(p.Scaling.Metrics, MetricPayload{Name: m.Name, Current: m.Current, Target: m.Target})
+		}
+		for _, c := range s.Conditions {
+			p.Scaling.Conditions = append(p.Scaling.Conditions, ScalingConditionPayload{Type: c.Type, Status: c.Status, Reason: c.Reason, Message: c.Message})
+		}
+	}
+	if j := event.Job; j != nil {
+		p.Job = &JobPayload{
+			Completions:  j.Completions,
+			BackoffLimit: j.BackoffLimit,
+			Active:       j.Active,
+			Succeeded:    j.Succeeded,
+			Failed:       j.Failed,
+		}
+		for _, pod := range j.FailedPods {
+			p.Job.FailedPods = append(p.Job.FailedPods, FailedPodPayload{Name: pod.Name, Reason: pod.Reason})
+		}
+	}
+	if cj := event.CronJob; cj != nil {
+		p.CronJob = &CronJobPayload{
+			Schedule:           cj.Schedule,
+			TimeZone:           cj.TimeZone,
+			Suspended:          cj.Suspended,
+			LastScheduleTime:   optionalTime(cj.LastScheduleTime),
+			LastSuccessfulTime: optionalTime(cj.LastSuccessfulTime),
+		}
+		for _, j := range cj.Active {
+			p.CronJob.Active = append(p.CronJob.Active, j.Name)
+		}
+	}
+	if s := event.Severity; s != nil {
+		p.Severity = &SeverityPayload{
+			Level:        s.Level,
+			PagerDuty:    s.PagerDuty,
+			Opsgenie:     s.Opsgenie,
+			Alertmanager: s.Alertmanager,
+		}
+	}
+	if r := event.Rollback; r != nil {
+		p.Rollback = &RollbackPayload{
+			FromRevision: r.FromRevision,
+			ToRevision:   r.ToRevision,
+			FromImages:   r.FromImages,
+			ToImages:     r.ToImages,
+		}
+	}
+	if v := event.Volume; v != nil {
+		p.Volume = &VolumePayload{
+			StorageClass: v.StorageClass,
+			Requested:    v.Requested,
+			Capacity:     v.Capacity,
+			VolumeName:   v.VolumeName,
+			ResizeError:  v.ResizeError,
+		}
+	}
+	for _, w := range event.Warnings {
+		p.Warnings = append(p.Warnings, WarningPayload{Reason: w.Reason, Message: w.Message, Count: w.Count, LastSeen: w.LastSeen})
+	}
+	if event.Logs != nil {
+		p.Logs = &LogsPayload{Container: event.Logs.Container, Previous: event.Logs.Previous, Text: event.Logs.Text}
+	}
+	p.Manifest = event.Manifest
+	p.ManifestDiff = event.ManifestDiff
+
+	return p
+}
+
+// optionalTime returns nil for the zero time, so that it is omitted
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// NewBatchPayload converts a batch of events to its JSON representation
+func NewBatchPayload(events []*watcher.Event, startTime, endTime time.Time) *BatchPayload {
+	payloads := make([]*EventPayload, len(events))
+	for i, event := range events {
+		payloads[i] = NewEventPayload(event)
+	}
+
+	return &BatchPayload{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Events:    payloads,
+		events:    events,
+	}
+}
@@ -0,0 +1,284 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/tracing"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// PluginProtocolVersion is the version of the plugin message format. It is
+// increased on incompatible changes.
+const PluginProtocolVersion = 1
+
+// ExitTempFail is the exit code with which exec plugins report a transient
+// failure that should be retried (EX_TEMPFAIL from sysexits.h)
+const ExitTempFail = 75
+
+// maxPluginStderr bounds the plugin output kept for error messages
+const maxPluginStderr = 1024
+
+// PluginMessage is the JSON document passed to plugins: on stdin for exec
+// plugins and as the request body for HTTP plugins. Exactly one of Event and
+// Batch is set, according to Type.
+type PluginMessage struct {
+	Version int           `json:"version"`
+	Plugin  string        `json:"plugin"` // Name of the plugin in the configuration
+	Type    string        `json:"type"`   // "event" or "batch"
+	Event   *EventPayload `json:"event,omitempty"`
+	Batch   *BatchPayload `json:"batch,omitempty"`
+}
+
+func newEventMessage(plugin string, event *watcher.Event) *PluginMessage {
+	return &PluginMessage{Version: PluginProtocolVersion, Plugin: plugin, Type: "event", Event: NewEventPayload(event)}
+}
+
+func newBatchMessage(plugin string, batch *BatchPayload) *PluginMessage {
+	return &PluginMessage{Version: PluginProtocolVersion, Plugin: plugin, Type: "batch", Batch: batch}
+}
+
+// PluginError is returned when an exec plugin fails
+type PluginError struct {
+	ExitCode int  // -1 when the plugin did not exit normally
+	TimedOut bool // The plugin was killed after the timeout
+	Stderr   string
+}
+
+func (e *PluginError) Error() string {
+	var msg string
+	switch {
+	case e.TimedOut:
+		msg = "plugin timed out"
+	case e.ExitCode < 0:
+		msg = "plugin did not exit normally"
+	default:
+		msg = fmt.Sprintf("plugin exited with code %d", e.ExitCode)
+	}
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// Temporary reports whether the delivery should be retried: the plugin timed
+// out or exited with ExitTempFail
+func (e *PluginError) Temporary() bool {
+	return e.TimedOut || e.ExitCode == ExitTempFail
+}
+
+// ExecPluginConfig contains settings for a plugin run as a process
+type ExecPluginConfig struct {
+	Name    string
+	Command []string          // Program and arguments; the program is looked up in PATH
+	Env     map[string]string // Added to kube-watcher's environment
+	Timeout time.Duration     // Kills the process after this long (0: DefaultHTTPTimeout)
+}
+
+// ExecPlugin delivers notifications by running a command per notification with
+// a PluginMessage on its standard input. Exit code 0 means delivered,
+// ExitTempFail a transient failure; any other code is a permanent failure.
+type ExecPlugin struct {
+	cfg  ExecPluginConfig
+	path string
+	env  []string
+}
+
+// NewExecPlugin creates a new ExecPlugin
+func NewExecPlugin(cfg ExecPluginConfig) (*ExecPlugin, error) {
+	if len(cfg.Command) == 0 {
+		return nil, fmt.Errorf("plugin %s: command is required", cfg.Name)
+	}
+	path, err := exec.LookPath(cfg.Command[0])
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", cfg.Name, err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultHTTPTimeout
+	}
+
+	env := os.Environ()
+	for key, value := range cfg.Env {
+		env = append(env, key+"="+value)
+	}
+	return &ExecPlugin{cfg: cfg, path: path, env: env}, nil
+}
+
+// Name identifies the notifier in logs
+func (p *ExecPlugin) Name() string {
+	return p.cfg.Name
+}
+
+// NotifyEvent runs the plugin with an event message
+func (p *ExecPlugin) NotifyEvent(event *watcher.Event) error {
+	return p.run(newEventMessage(p.cfg.Name, event))
+}
+
+// NotifyBatch runs the plugin with a batch message
+func (p *ExecPlugin) NotifyBatch(batch *BatchPayload) error {
+	return p.run(newBatchMessage(p.cfg.Name, batch))
+}
+
+// run starts the plugin, writes msg to its stdin and waits for it to exit
+func (p *ExecPlugin) run(msg *PluginMessage) error {
+	input, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.path, p.cfg.Command[1:]...)
+	cmd.Env = p.env
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &limitedWriter{w: &stderr, n: maxPluginStderr}
+	// Output to stdout is discarded; the exit code is the result
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if err == nil {
+		return nil
+	}
+	pluginErr := &PluginError{ExitCode: -1, TimedOut: ctx.Err() != nil, Stderr: strings.TrimSpace(stderr.String())}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		pluginErr.ExitCode = exitErr.ExitCode()
+	} else if !pluginErr.TimedOut {
+		return fmt.Errorf("failed to run plugin: %w", err)
+	}
+	return pluginErr
+}
+
+// limitedWriter keeps the first n bytes written and discards the rest
+type limitedWriter struct {
+	w *bytes.Buffer
+	n int
+}
+
+func (l *limitedWriter) Write(b []byte) (int, error) {
+	if remaining := l.n - l.w.Len(); remaining > 0 {
+		if len(b) > remaining {
+			l.w.Write(b[:remaining])
+		} else {
+			l.w.Write(b)
+		}
+	}
+	return len(b), nil
+}
+
+// HTTPPluginConfig contains settings for a plugin served over HTTP, typically
+// by a sidecar container
+type HTTPPluginConfig struct {
+	Name        string
+	URL         string            // Receives each PluginMessage with POST
+	HealthURL   string            // Optional, checked with GET by CheckHealth
+	Headers     map[string]string // Static headers added to every request
+	BearerToken SecretSource      // Sent as "Authorization: Bearer <token>"
+
+	HTTPClient *http.Client // Defaults to a client with DefaultHTTPTimeout
+}
+
+// HTTPPlugin delivers notifications by posting a PluginMessage as JSON. Any 2xx
+// status means delivered; 429 and 5xx are retried like other HTTP notifiers.
+type HTTPPlugin struct {
+	cfg        HTTPPluginConfig
+	httpClient *http.Client
+}
+
+// NewHTTPPlugin creates a new HTTPPlugin
+func NewHTTPPlugin(cfg HTTPPluginConfig) (*HTTPPlugin, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("plugin %s: url is required", cfg.Name)
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+	return &HTTPPlugin{cfg: cfg, httpClient: httpClient}, nil
+}
+
+// Name identifies the notifier in logs
+func (p *HTTPPlugin) Name() string {
+	return p.cfg.Name
+}
+
+// NotifyEvent posts an event message
+func (p *HTTPPlugin) NotifyEvent(event *watcher.Event) error {
+	return p.NotifyEventContext(context.Background(), event)
+}
+
+// NotifyBatch posts a batch message
+func (p *HTTPPlugin) NotifyBatch(batch *BatchPayload) error {
+	return p.NotifyBatchContext(context.Background(), batch)
+}
+
+// NotifyEventContext posts an event message with the trace context of ctx
+func (p *HTTPPlugin) NotifyEventContext(ctx context.Context, event *watcher.Event) error {
+	return p.post(ctx, newEventMessage(p.cfg.Name, event))
+}
+
+// NotifyBatchContext posts a batch message with the trace context of ctx
+func (p *HTTPPlugin) NotifyBatchContext(ctx context.Context, batch *BatchPayload) error {
+	return p.post(ctx, newBatchMessage(p.cfg.Name, batch))
+}
+
+func (p *HTTPPlugin) post(ctx context.Context, msg *PluginMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+	if err := p.setHeaders(req); err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newStatusError(resp, fmt.Sprintf("plugin %s returned status %d", p.cfg.Name, resp.StatusCode))
+	}
+	return nil
+}
+
+// CheckHealth sends a GET request to the health URL, if configured, and expects a 2xx status
+func (p *HTTPPlugin) CheckHealth() error {
+	if p.cfg.HealthURL == "" {
+		return nil
+	}
+	req, err := http.NewRequest("GET", p.cfg.HealthURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := p.setHeaders(req); err != nil {
+		return err
+	}
+	return checkEndpoint(p.httpClient, req, func(status int) bool {
+		return status >= 200 && status < 300
+	})
+}
+
+func (p *HTTPPlugin) setHeaders(req *http.Request) error {
+	for key, value := range p.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	return setAuthorization(req, p.cfg.BearerToken, "", SecretSource{})
+}
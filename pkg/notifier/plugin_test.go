@@ -0,0 +1,123 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestExecPlugin_NotifyEvent(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "message.json")
+	p, err := NewExecPlugin(ExecPluginConfig{
+		Name:    "pager",
+		Command: []string{"sh", "-c", `cat > "$OUT"`},
+		Env:     map[string]string{"OUT": out},
+	})
+	if err != nil {
+		t.Fatalf("NewExecPlugin() error = %v", err)
+	}
+
+	if err := p.NotifyEvent(&watcher.Event{Kind: "Pod", Name: "web", EventType: "DELETED"}); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Plugin did not receive the message: %v", err)
+	}
+	var msg PluginMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Invalid message %q: %v", data, err)
+	}
+	if msg.Version != PluginProtocolVersion || msg.Plugin != "pager" || msg.Type != "event" || msg.Event == nil || msg.Event.Name != "web" {
+		t.Errorf("Unexpected message %s", data)
+	}
+}
+
+func TestExecPlugin_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		timeout   time.Duration
+		retryable bool
+		contains  string
+	}{
+		{name: "恒久的な失敗", script: "echo invalid routing key >&2; exit 1", retryable: false, contains: "code 1: invalid routing key"},
+		{name: "一時的な失敗", script: "exit 75", retryable: true, contains: "code 75"},
+		{name: "タイムアウト", script: "sleep 5", timeout: 100 * time.Millisecond, retryable: true, contains: "timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewExecPlugin(ExecPluginConfig{Name: "pager", Command: []string{"sh", "-c", tt.script}, Timeout: tt.timeout})
+			if err != nil {
+				t.Fatalf("NewExecPlugin() error = %v", err)
+			}
+			err = p.NotifyBatch(NewBatchPayload(nil, time.Now(), time.Now()))
+			var pluginErr *PluginError
+			if !errors.As(err, &pluginErr) {
+				t.Fatalf("Expected a PluginError, got %v", err)
+			}
+			if IsRetryable(err) != tt.retryable || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Unexpected error %q (retryable %v)", err, IsRetryable(err))
+			}
+		})
+	}
+}
+
+func TestNewExecPlugin_CommandNotFound(t *testing.T) {
+	if _, err := NewExecPlugin(ExecPluginConfig{Name: "pager", Command: []string{"kube-watcher-no-such-plugin"}}); err == nil {
+		t.Error("Expected an error for a missing command")
+	}
+}
+
+func TestHTTPPlugin(t *testing.T) {
+	var (
+		auth string
+		msg  PluginMessage
+	)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&msg)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	p, err := NewHTTPPlugin(HTTPPluginConfig{
+		Name:        "ticketing",
+		URL:         server.URL + "/notify",
+		HealthURL:   server.URL + "/healthz",
+		BearerToken: SecretSource{Value: "s3cret"},
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPPlugin() error = %v", err)
+	}
+	if err := p.CheckHealth(); err != nil {
+		t.Errorf("CheckHealth() error = %v", err)
+	}
+
+	events := []*watcher.Event{{Kind: "Pod", Name: "web"}}
+	if err := p.NotifyBatch(NewBatchPayload(events, time.Now(), time.Now())); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+	if auth != "Bearer s3cret" || msg.Plugin != "ticketing" || msg.Type != "batch" || msg.Batch == nil || len(msg.Batch.Events) != 1 {
+		t.Errorf("Unexpected request: auth %q, message %+v", auth, msg)
+	}
+
+	// 5xxは再試行対象
+	status = http.StatusServiceUnavailable
+	if err := p.NotifyEvent(events[0]); !IsRetryable(err) {
+		t.Errorf("Expected a retryable error, got %v", err)
+	}
+}
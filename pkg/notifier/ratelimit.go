@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimitInterval is the period over which suppressed messages are summarized
+const RateLimitInterval = time.Minute
+
+// RateLimiter is a token bucket limiting the messages sent to a destination.
+// Messages over the limit are suppressed and counted; a minute after the first
+// suppression, report is called with the number of suppressed messages so that
+// they are summarized instead of dropped silently. A nil RateLimiter allows
+// every message.
+type RateLimiter struct {
+	rate   float64 // Tokens per second
+	burst  float64
+	report func(suppressed int)
+	now    func() time.Time
+
+	mu         sync.Mutex
+	tokens     float64
+	last       time.Time
+	suppressed int
+	reporting  bool // A report is scheduled
+}
+
+// NewRateLimiter creates a RateLimiter allowing perMinute messages per minute
+// on average and up to burst messages at once. A non-positive burst defaults
+// to perMinute.
+func NewRateLimiter(perMinute, burst int, report func(suppressed int)) *RateLimiter {
+	if burst <= 0 {
+		burst = perMinute
+	}
+	return &RateLimiter{
+		rate:   float64(perMinute) / RateLimitInterval.Seconds(),
+		burst:  float64(burst),
+		report: report,
+		now:    time.Now,
+		tokens: float64(burst),
+	}
+}
+
+// Allow reports whether a message may be sent now, taking a token if so
+func (l *RateLimiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return true
+	}
+
+	l.suppressed++
+	if !l.reporting {
+		l.reporting = true
+		time.AfterFunc(RateLimitInterval, l.flush)
+	}
+	return false
+}
+
+// flush reports the messages suppressed since the last report
+func (l *RateLimiter) flush() {
+	l.mu.Lock()
+	suppressed := l.suppressed
+	l.suppressed = 0
+	l.reporting = false
+	l.mu.Unlock()
+
+	if suppressed > 0 && l.report != nil {
+		l.report(suppressed)
+	}
+}
@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var reported []int
+	l := NewRateLimiter(30, 2, func(suppressed int) { reported = append(reported, suppressed) })
+	l.now = func() time.Time { return now }
+
+	// バースト分は即座に送信でき、超過分は抑止される
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if l.Allow() {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("Expected 2 messages within the burst, got %d", allowed)
+	}
+
+	// 毎分30件なので2秒で1件分回復する
+	now = now.Add(2 * time.Second)
+	if !l.Allow() {
+		t.Error("Expected a token to be refilled after 2 seconds")
+	}
+	if l.Allow() {
+		t.Error("Expected only one token to be refilled")
+	}
+
+	l.flush()
+	if len(reported) != 1 || reported[0] != 4 {
+		t.Errorf("Expected 4 suppressed messages to be reported, got %v", reported)
+	}
+	l.flush()
+	if len(reported) != 1 {
+		t.Errorf("Expected nothing to be reported without suppressed messages, got %v", reported)
+	}
+}
+
+func TestRateLimiter_Nil(t *testing.T) {
+	var l *RateLimiter
+	if !l.Allow() {
+		t.Error("Expected a nil RateLimiter to allow every message")
+	}
+}
@@ -0,0 +1,172 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// RetryPolicy controls retries of failed deliveries with exponential backoff and jitter.
+// The zero value disables retries.
+type RetryPolicy struct {
+	MaxAttempts     int           // Total attempts including the first
+	InitialInterval time.Duration // Backoff before the first retry, doubled on each retry
+	MaxInterval     time.Duration // Upper bound of a single backoff
+	MaxElapsed      time.Duration // Give up when the next retry would start after this (0: no limit)
+}
+
+// sleep is replaced in tests
+var sleep = time.Sleep
+
+// StatusError is returned when an HTTP API responds with an unsuccessful status code
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // From the Retry-After header, if any
+	msg        string
+}
+
+func (e *StatusError) Error() string {
+	return e.msg
+}
+
+// newStatusError creates a StatusError for resp with the given message
+func newStatusError(resp *http.Response, msg string) *StatusError {
+	e := &StatusError{StatusCode: resp.StatusCode, msg: msg}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
+
+// IsRetryable reports whether err is transient: a network error or timeout, a rate
+// limit (429), a server error (5xx) or a temporary plugin failure
+func IsRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var pluginErr *PluginError
+	if errors.As(err, &pluginErr) {
+		return pluginErr.Temporary()
+	}
+
+	// url.Error implements net.Error itself; its cause tells whether the
+	// request failed on the network or permanently (e.g. an invalid URL or
+	// an untrusted certificate)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true // The connection was closed by the server
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, or the policy is exhausted
+func (p RetryPolicy) Do(fn func() error) error {
+	start := time.Now()
+	interval := p.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) {
+			return err
+		}
+		if attempt >= p.MaxAttempts {
+			return retriesExhausted(attempt, err)
+		}
+
+		wait := jitter(interval)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > wait {
+			wait = statusErr.RetryAfter
+		}
+		if p.MaxElapsed > 0 && time.Since(start)+wait > p.MaxElapsed {
+			return retriesExhausted(attempt, err)
+		}
+
+		sleep(wait)
+		interval *= 2
+		if p.MaxInterval > 0 && interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+	}
+}
+
+// retriesExhausted annotates the last error with the number of attempts made
+func retriesExhausted(attempts int, err error) error {
+	if attempts == 1 {
+		return err
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+}
+
+// jitter returns a random duration between d/2 and d
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
+
+// retryNotifier retries failed deliveries of the wrapped notifier
+type retryNotifier struct {
+	EventNotifier
+	policy RetryPolicy
+}
+
+// WithRetry wraps n so that transient delivery failures are retried according to policy
+func WithRetry(n EventNotifier, policy RetryPolicy) EventNotifier {
+	if policy.MaxAttempts <= 1 {
+		return n
+	}
+	return &retryNotifier{EventNotifier: n, policy: policy}
+}
+
+// NotifyEvent delivers an event, retrying transient failures
+func (r *retryNotifier) NotifyEvent(event *watcher.Event) error {
+	return r.policy.Do(func() error {
+		return r.EventNotifier.NotifyEvent(event)
+	})
+}
+
+// NotifyBatch delivers a batch, retrying transient failures
+func (r *retryNotifier) NotifyBatch(batch *BatchPayload) error {
+	return r.policy.Do(func() error {
+		return r.EventNotifier.NotifyBatch(batch)
+	})
+}
+
+// NotifyEventContext delivers an event with ctx, retrying transient failures
+func (r *retryNotifier) NotifyEventContext(ctx context.Context, event *watcher.Event) error {
+	return r.policy.Do(func() error {
+		return NotifyEventContext(ctx, r.EventNotifier, event)
+	})
+}
+
+// NotifyBatchContext delivers a batch with ctx, retrying transient failures
+func (r *retryNotifier) NotifyBatchContext(ctx context.Context, batch *BatchPayload) error {
+	return r.policy.Do(func() error {
+		return NotifyBatchContext(ctx, r.EventNotifier, batch)
+	})
+}
+
+// Close closes the wrapped notifier if it holds resources
+func (r *retryNotifier) Close() error {
+	if closer, ok := r.EventNotifier.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
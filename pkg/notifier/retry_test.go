@@ -0,0 +1,201 @@
+package notifier
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// noSleep disables backoff waits and records them
+func noSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	orig := sleep
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	t.Cleanup(func() { sleep = orig })
+	return &waits
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	waits := noSleep(t)
+	policy := RetryPolicy{MaxAttempts: 4, InitialInterval: 100 * time.Millisecond, MaxInterval: 300 * time.Millisecond}
+
+	calls := 0
+	err := policy.Do(func() error {
+		calls++
+		if calls < 4 {
+			return &StatusError{StatusCode: http.StatusServiceUnavailable, msg: "unavailable"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v, want nil", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", calls)
+	}
+
+	// 指数バックオフ（ジッター付き）で上限を超えない
+	bounds := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, wait := range *waits {
+		if wait < bounds[i]/2 || wait > bounds[i] {
+			t.Errorf("Wait %d = %v, want between %v and %v", i, wait, bounds[i]/2, bounds[i])
+		}
+	}
+}
+
+func TestRetryPolicy_Do_NonRetryable(t *testing.T) {
+	noSleep(t)
+	policy := RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}
+
+	calls := 0
+	err := policy.Do(func() error {
+		calls++
+		return &StatusError{StatusCode: http.StatusBadRequest, msg: "bad request"}
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected a single attempt for 400, got %d calls (err=%v)", calls, err)
+	}
+}
+
+func TestRetryPolicy_Do_Exhausted(t *testing.T) {
+	noSleep(t)
+	policy := RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}
+
+	calls := 0
+	lastErr := &StatusError{StatusCode: http.StatusTooManyRequests, msg: "rate limited"}
+	err := policy.Do(func() error {
+		calls++
+		return lastErr
+	})
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+	if !errors.Is(err, lastErr) || !strings.Contains(err.Error(), "3 attempts") {
+		t.Errorf("Expected wrapped error after 3 attempts, got %v", err)
+	}
+}
+
+func TestRetryPolicy_Do_MaxElapsed(t *testing.T) {
+	noSleep(t)
+	policy := RetryPolicy{MaxAttempts: 10, InitialInterval: time.Minute, MaxElapsed: 30 * time.Second}
+
+	calls := 0
+	policy.Do(func() error {
+		calls++
+		return &StatusError{StatusCode: http.StatusBadGateway, msg: "bad gateway"}
+	})
+	// 次のリトライが上限時間を超える場合は諦める
+	if calls != 1 {
+		t.Errorf("Expected to give up before exceeding max elapsed time, got %d calls", calls)
+	}
+}
+
+func TestIsRetryable_RequestErrors(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer tlsServer.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { time.Sleep(200 * time.Millisecond) }))
+	defer slow.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	closed.Close()
+
+	// タイムアウトと接続エラーだけを再試行し、URLや証明書の誤りは再試行しない
+	tests := []struct {
+		name      string
+		url       string
+		retryable bool
+	}{
+		{"unsupported scheme", "ftp://example.com/hook", false},
+		{"untrusted certificate", tlsServer.URL, false},
+		{"timeout", slow.URL, true},
+		{"connection refused", closed.URL, true},
+	}
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(tt.url)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatal("Expected a request error")
+			}
+			if got := IsRetryable(err); got != tt.retryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", err, got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestSlackNotifier_Retry(t *testing.T) {
+	waits := noSleep(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL)
+	notifier.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialInterval: 10 * time.Millisecond})
+
+	if err := notifier.Send("test message"); err != nil {
+		t.Fatalf("Send() error = %v, want nil", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 requests, got %d", calls)
+	}
+	// Retry-Afterヘッダーを優先する
+	if len(*waits) != 1 || (*waits)[0] != 2*time.Second {
+		t.Errorf("Expected to wait for Retry-After, got %v", *waits)
+	}
+}
+
+type flakyNotifier struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyNotifier) Name() string { return "flaky" }
+
+func (f *flakyNotifier) NotifyEvent(event *watcher.Event) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return &StatusError{StatusCode: http.StatusInternalServerError, msg: "internal error"}
+	}
+	return nil
+}
+
+func (f *flakyNotifier) NotifyBatch(batch *BatchPayload) error {
+	return f.NotifyEvent(nil)
+}
+
+func TestWithRetry(t *testing.T) {
+	noSleep(t)
+	flaky := &flakyNotifier{failures: 2}
+
+	n := WithRetry(flaky, RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond})
+	if err := n.NotifyEvent(&watcher.Event{}); err != nil {
+		t.Fatalf("NotifyEvent() error = %v, want nil", err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", flaky.calls)
+	}
+	if n.Name() != "flaky" {
+		t.Errorf("Expected wrapped name, got %q", n.Name())
+	}
+
+	// リトライ無効の場合はラップしない
+	if WithRetry(flaky, RetryPolicy{MaxAttempts: 1}) != EventNotifier(flaky) {
+		t.Error("Expected notifier to be returned unwrapped")
+	}
+}
@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSignV4(t *testing.T) {
+	// AWS Signature Version 4 test suite: get-vanilla
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	creds := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	signV4(req, nil, creds, "us-east-1", "service", now)
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Authorization = %q, want %q", got, expected)
+	}
+}
+
+func TestSignV4_QueryParameters(t *testing.T) {
+	// AWS Signature Version 4 test suite: get-vanilla-query-order-key-case
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/?Param2=value2&Param1=value1", nil)
+	creds := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	signV4(req, nil, creds, "us-east-1", "service", now)
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Authorization = %q, want %q", got, expected)
+	}
+}
+
+func TestSignV4_Post(t *testing.T) {
+	// AWS Signature Version 4 test suite: post-vanilla
+	req, _ := http.NewRequest("POST", "https://example.amazonaws.com/", nil)
+	creds := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	signV4(req, nil, creds, "us-east-1", "service", now)
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Authorization = %q, want %q", got, expected)
+	}
+}
+
+func TestSignV4_Suite(t *testing.T) {
+	// AWS Signature Version 4 test suite: パスとクエリのエンコード
+	unreserved := "-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	tests := []struct {
+		name      string
+		url       string
+		signature string
+	}{
+		{"get-vanilla-query-unreserved", "https://example.amazonaws.com/?" + unreserved + "=" + unreserved, "9c3e54bfcdf0b19771a7f523ee5669cdf59bc7cc0884027167c21bb143a40197"},
+		{"get-vanilla-utf8-query", "https://example.amazonaws.com/?%E1%88%B4=bar", "2cdec8eed098649ff3a119c94853b13c643bcf08f8b0a1d91e12c9027818dd04"},
+		{"get-utf8", "https://example.amazonaws.com/%E1%88%B4", "8318018e0b0f223aa2bbf98705b62bb787dc9c0e678f255a891fd03141be5d85"},
+		{"get-space", "https://example.amazonaws.com/example%20space/", "652487583200325589f1fba4c7e578f72c47cb61beeca81406b39ddec1366741"},
+	}
+	creds := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			signV4(req, nil, creds, "us-east-1", "service", now)
+
+			expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != expected {
+				t.Errorf("Authorization = %q, want %q", got, expected)
+			}
+		})
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	// AWS Signature Version 4 test suite の get-vanilla-query-* と get-vanilla-utf8-query
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"order-key", "Param2=value2&Param1=value1", "Param1=value1&Param2=value2"},
+		{"order-value", "Param1=value2&Param1=Value1", "Param1=Value1&Param1=value2"},
+		{"empty-value", "Param1=", "Param1="},
+		{
+			"unreserved",
+			"-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz=-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+			"-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz=-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+		},
+		{"utf8", "%E1%88%B4=bar", "%E1%88%B4=bar"},
+		{"space", "Param1=value%201", "Param1=value%201"},
+		{"plus", "Param1=a%2Bb", "Param1=a%2Bb"},
+		{"reserved", "Param1=a*b%21%27%28%29", "Param1=a%2Ab%21%27%28%29"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := canonicalQuery(values); got != tt.want {
+				t.Errorf("canonicalQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	// AWS Signature Version 4 test suite の get-space, get-unreserved, get-utf8
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"https://example.amazonaws.com", "/"},
+		{"https://example.amazonaws.com/", "/"},
+		{"https://example.amazonaws.com/example%20space/", "/example%20space/"},
+		{"https://example.amazonaws.com/-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", "/-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"},
+		{"https://example.amazonaws.com/%E1%88%B4", "/%E1%88%B4"},
+		{"https://example.amazonaws.com/a*b/c%2Fd", "/a%2Ab/c%2Fd"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := canonicalPath(u); got != tt.want {
+			t.Errorf("canonicalPath(%q) = %q, want %q", tt.rawURL, got, tt.want)
+		}
+	}
+}
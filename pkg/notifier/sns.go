@@ -0,0 +1,149 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// SNSConfig contains Amazon SNS publisher settings
+type SNSConfig struct {
+	TopicARN string
+	Endpoint string // Overrides the regional SNS endpoint (e.g. for LocalStack)
+	Auth     AWSAuthConfig
+
+	HTTPClient *http.Client // Defaults to a client with DefaultHTTPTimeout
+}
+
+// SNSNotifier publishes event payloads to an Amazon SNS topic
+type SNSNotifier struct {
+	topicARN   string
+	endpoint   string
+	region     string
+	creds      *awsCredentialProvider
+	httpClient *http.Client
+
+	// The batch whose publish last failed and how many of its events were
+	// published, so that a retry of the batch resumes after them
+	mu          sync.Mutex
+	partial     *BatchPayload
+	partialSent int
+}
+
+// NewSNSNotifier creates a new SNSNotifier
+func NewSNSNotifier(cfg SNSConfig) (*SNSNotifier, error) {
+	if cfg.TopicARN == "" {
+		return nil, fmt.Errorf("sns topic ARN is required")
+	}
+
+	if cfg.Auth.Region == "" {
+		cfg.Auth.Region = regionFromARN(cfg.TopicARN)
+	}
+	if cfg.Auth.Region == "" {
+		return nil, fmt.Errorf("sns region could not be determined from topic ARN %q", cfg.TopicARN)
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sns.%s.amazonaws.com/", cfg.Auth.Region)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+	return &SNSNotifier{
+		topicARN:   cfg.TopicARN,
+		endpoint:   endpoint,
+		region:     cfg.Auth.Region,
+		creds:      newAWSCredentialProvider(cfg.Auth, httpClient),
+		httpClient: httpClient,
+	}, nil
+}
+
+// Name identifies the notifier in logs
+func (s *SNSNotifier) Name() string {
+	return "sns"
+}
+
+// NotifyEvent publishes an event to the topic
+func (s *SNSNotifier) NotifyEvent(event *watcher.Event) error {
+	return s.publish(NewEventPayload(event))
+}
+
+// NotifyBatch publishes each event of the batch as a separate message,
+// so that subscribers always receive single events. When the same batch is
+// retried after a failure, the events published by the failed attempt are skipped.
+func (s *SNSNotifier) NotifyBatch(batch *BatchPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := 0
+	if s.partial == batch {
+		sent = s.partialSent
+	}
+	for _, event := range batch.Events[sent:] {
+		if err := s.publish(event); err != nil {
+			s.partial, s.partialSent = batch, sent
+			return err
+		}
+		sent++
+	}
+	s.partial, s.partialSent = nil, 0
+	return nil
+}
+
+// publish sends a single event payload. Kind, namespace and event type are also
+// set as message attributes so subscribers can use SNS filter policies.
+func (s *SNSNotifier) publish(event *EventPayload) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	form := url.Values{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {s.topicARN},
+		"Message":  {string(message)},
+	}
+	attributes := []struct{ name, value string }{
+		{"kind", event.Kind},
+		{"namespace", event.Namespace},
+		{"eventType", event.EventType},
+	}
+	n := 0
+	for _, attr := range attributes {
+		if attr.value == "" {
+			continue
+		}
+		n++
+		prefix := "MessageAttributes.entry." + strconv.Itoa(n)
+		form.Set(prefix+".Name", attr.name)
+		form.Set(prefix+".Value.DataType", "String")
+		form.Set(prefix+".Value.StringValue", attr.value)
+	}
+
+	if _, err := postAWSQuery(s.httpClient, s.creds, s.endpoint, s.region, "sns", form); err != nil {
+		return fmt.Errorf("failed to publish to sns: %w", err)
+	}
+	return nil
+}
+
+// CheckHealth verifies the credentials and the topic with GetTopicAttributes
+func (s *SNSNotifier) CheckHealth() error {
+	form := url.Values{
+		"Action":   {"GetTopicAttributes"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {s.topicARN},
+	}
+	if _, err := postAWSQuery(s.httpClient, s.creds, s.endpoint, s.region, "sns", form); err != nil {
+		return fmt.Errorf("failed to get sns topic attributes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestNewSNSNotifier_RegionFromARN(t *testing.T) {
+	n, err := NewSNSNotifier(SNSConfig{TopicARN: "arn:aws:sns:ap-northeast-1:123456789012:k8s-events"})
+	if err != nil {
+		t.Fatalf("NewSNSNotifier() error = %v", err)
+	}
+	if n.region != "ap-northeast-1" {
+		t.Errorf("Expected region from ARN, got %q", n.region)
+	}
+	if n.endpoint != "https://sns.ap-northeast-1.amazonaws.com/" {
+		t.Errorf("Unexpected endpoint %q", n.endpoint)
+	}
+
+	if _, err := NewSNSNotifier(SNSConfig{TopicARN: "invalid"}); err == nil {
+		t.Error("Expected error for ARN without region")
+	}
+}
+
+func TestSNSNotifier_NotifyEvent(t *testing.T) {
+	var received []*http.Request
+	var forms []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+		}
+		form := make(map[string]string)
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		received = append(received, r)
+		forms = append(forms, form)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n, err := NewSNSNotifier(SNSConfig{
+		TopicARN: "arn:aws:sns:us-east-1:123456789012:k8s-events",
+		Endpoint: server.URL,
+		Auth:     AWSAuthConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewSNSNotifier() error = %v", err)
+	}
+
+	event := &watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "DELETED", Timestamp: time.Now()}
+	if err := n.NotifyEvent(event); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(received))
+	}
+	if auth := received[0].Header.Get("Authorization"); !strings.Contains(auth, "Credential=AKID/") || !strings.Contains(auth, "/us-east-1/sns/aws4_request") {
+		t.Errorf("Unexpected Authorization header %q", auth)
+	}
+
+	form := forms[0]
+	if form["Action"] != "Publish" || form["TopicArn"] != "arn:aws:sns:us-east-1:123456789012:k8s-events" {
+		t.Errorf("Unexpected publish parameters: %v", form)
+	}
+	var payload EventPayload
+	if err := json.Unmarshal([]byte(form["Message"]), &payload); err != nil {
+		t.Fatalf("Message is not a JSON event payload: %v", err)
+	}
+	if payload.Name != "web" || payload.EventType != "DELETED" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if form["MessageAttributes.entry.1.Name"] != "kind" || form["MessageAttributes.entry.1.Value.StringValue"] != "Pod" {
+		t.Errorf("Expected kind message attribute, got %v", form)
+	}
+}
+
+func TestSNSNotifier_RetryBatch(t *testing.T) {
+	var names []string
+	failing := "b"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		var payload EventPayload
+		_ = json.Unmarshal([]byte(r.PostForm.Get("Message")), &payload)
+		names = append(names, payload.Name)
+		if payload.Name == failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	n, err := NewSNSNotifier(SNSConfig{
+		TopicARN: "arn:aws:sns:us-east-1:123456789012:k8s-events",
+		Endpoint: server.URL,
+		Auth:     AWSAuthConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewSNSNotifier() error = %v", err)
+	}
+
+	batch := NewBatchPayload([]*watcher.Event{
+		{Kind: "Pod", Namespace: "prod", Name: "a", EventType: "ADDED"},
+		{Kind: "Pod", Namespace: "prod", Name: "b", EventType: "ADDED"},
+		{Kind: "Pod", Namespace: "prod", Name: "c", EventType: "ADDED"},
+	}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err == nil {
+		t.Fatal("Expected the failed event to fail the batch")
+	}
+
+	// 再試行では発行済みのイベントを再送しない
+	failing = ""
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+	if got := strings.Join(names, ","); got != "a,b,b,c" {
+		t.Errorf("Published %s, want a,b,b,c", got)
+	}
+}
+
+func TestSNSNotifier_AssumeRole(t *testing.T) {
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		actions = append(actions, r.PostForm.Get("Action"))
+
+		switch r.PostForm.Get("Action") {
+		case "AssumeRole":
+			w.Write([]byte(`<AssumeRoleResponse><AssumeRoleResult><Credentials>
+				<AccessKeyId>ASSUMED</AccessKeyId>
+				<SecretAccessKey>assumed-secret</SecretAccessKey>
+				<SessionToken>token</SessionToken>
+				<Expiration>2099-01-01T00:00:00Z</Expiration>
+			</Credentials></AssumeRoleResult></AssumeRoleResponse>`))
+		case "Publish":
+			if r.Header.Get("X-Amz-Security-Token") != "token" {
+				t.Errorf("Expected session token of the assumed role")
+			}
+			if !strings.Contains(r.Header.Get("Authorization"), "Credential=ASSUMED/") {
+				t.Errorf("Expected request to be signed with assumed credentials")
+			}
+		}
+	}))
+	defer server.Close()
+
+	n, err := NewSNSNotifier(SNSConfig{
+		TopicARN: "arn:aws:sns:us-east-1:123456789012:k8s-events",
+		Endpoint: server.URL,
+		Auth: AWSAuthConfig{
+			AccessKeyID:     "AKID",
+			SecretAccessKey: "secret",
+			RoleARN:         "arn:aws:iam::123456789012:role/publisher",
+			STSEndpoint:     server.URL,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSNSNotifier() error = %v", err)
+	}
+
+	batch := NewBatchPayload([]*watcher.Event{
+		{Kind: "Pod", Name: "a", EventType: "ADDED"},
+		{Kind: "Pod", Name: "b", EventType: "ADDED"},
+	}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+
+	// 認証情報はキャッシュされ、AssumeRoleは1回だけ呼ばれる
+	expected := []string{"AssumeRole", "Publish", "Publish"}
+	if strings.Join(actions, ",") != strings.Join(expected, ",") {
+		t.Errorf("Actions = %v, want %v", actions, expected)
+	}
+}
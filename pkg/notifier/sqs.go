@@ -0,0 +1,182 @@
+package notifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// SQSConfig contains Amazon SQS sender settings
+type SQSConfig struct {
+	QueueURL string
+	Endpoint string // Overrides the queue URL as request endpoint (e.g. for LocalStack)
+	Auth     AWSAuthConfig
+
+	HTTPClient *http.Client // Defaults to a client with DefaultHTTPTimeout
+}
+
+// SQSNotifier sends event payloads to an Amazon SQS queue.
+// For FIFO queues, messages about the same resource share a message group so that
+// they are delivered in order.
+type SQSNotifier struct {
+	queueURL   string
+	endpoint   string
+	region     string
+	fifo       bool
+	creds      *awsCredentialProvider
+	httpClient *http.Client
+
+	// The batch whose send last failed and how many of its events were
+	// sent, so that a retry of the batch resumes after them
+	mu          sync.Mutex
+	partial     *BatchPayload
+	partialSent int
+}
+
+// NewSQSNotifier creates a new SQSNotifier
+func NewSQSNotifier(cfg SQSConfig) (*SQSNotifier, error) {
+	if cfg.QueueURL == "" {
+		return nil, fmt.Errorf("sqs queue URL is required")
+	}
+
+	if cfg.Auth.Region == "" {
+		cfg.Auth.Region = regionFromQueueURL(cfg.QueueURL)
+	}
+	if cfg.Auth.Region == "" {
+		return nil, fmt.Errorf("sqs region could not be determined from queue URL %q", cfg.QueueURL)
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = cfg.QueueURL
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+	return &SQSNotifier{
+		queueURL:   cfg.QueueURL,
+		endpoint:   endpoint,
+		region:     cfg.Auth.Region,
+		fifo:       strings.HasSuffix(cfg.QueueURL, ".fifo"),
+		creds:      newAWSCredentialProvider(cfg.Auth, httpClient),
+		httpClient: httpClient,
+	}, nil
+}
+
+// Name identifies the notifier in logs
+func (s *SQSNotifier) Name() string {
+	return "sqs"
+}
+
+// NotifyEvent sends an event to the queue
+func (s *SQSNotifier) NotifyEvent(event *watcher.Event) error {
+	return s.send(NewEventPayload(event))
+}
+
+// NotifyBatch sends each event of the batch as a separate message,
+// so that consumers always receive single events. When the same batch is
+// retried after a failure, the events sent by the failed attempt are skipped.
+func (s *SQSNotifier) NotifyBatch(batch *BatchPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := 0
+	if s.partial == batch {
+		sent = s.partialSent
+	}
+	for _, event := range batch.Events[sent:] {
+		if err := s.send(event); err != nil {
+			s.partial, s.partialSent = batch, sent
+			return err
+		}
+		sent++
+	}
+	s.partial, s.partialSent = nil, 0
+	return nil
+}
+
+// send sends a single event payload. Identifying fields are also set as message
+// attributes so consumers can inspect them without parsing the body.
+func (s *SQSNotifier) send(event *EventPayload) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	form := url.Values{
+		"Action":      {"SendMessage"},
+		"Version":     {"2012-11-05"},
+		"QueueUrl":    {s.queueURL},
+		"MessageBody": {string(message)},
+	}
+	if s.fifo {
+		sum := sha256.Sum256(message)
+		form.Set("MessageGroupId", event.Kind+"/"+event.Namespace+"/"+event.Name)
+		form.Set("MessageDeduplicationId", hex.EncodeToString(sum[:]))
+	}
+	attributes := []struct{ name, value string }{
+		{"cluster", event.Cluster},
+		{"kind", event.Kind},
+		{"namespace", event.Namespace},
+		{"name", event.Name},
+		{"eventType", event.EventType},
+	}
+	n := 0
+	for _, attr := range attributes {
+		if attr.value == "" {
+			continue
+		}
+		n++
+		prefix := "MessageAttribute." + strconv.Itoa(n)
+		form.Set(prefix+".Name", attr.name)
+		form.Set(prefix+".Value.DataType", "String")
+		form.Set(prefix+".Value.StringValue", attr.value)
+	}
+
+	if _, err := postAWSQuery(s.httpClient, s.creds, s.endpoint, s.region, "sqs", form); err != nil {
+		return fmt.Errorf("failed to send to sqs: %w", err)
+	}
+	return nil
+}
+
+// CheckHealth verifies the credentials and the queue with GetQueueAttributes
+func (s *SQSNotifier) CheckHealth() error {
+	form := url.Values{
+		"Action":          {"GetQueueAttributes"},
+		"Version":         {"2012-11-05"},
+		"QueueUrl":        {s.queueURL},
+		"AttributeName.1": {"QueueArn"},
+	}
+	if _, err := postAWSQuery(s.httpClient, s.creds, s.endpoint, s.region, "sqs", form); err != nil {
+		return fmt.Errorf("failed to get sqs queue attributes: %w", err)
+	}
+	return nil
+}
+
+// regionFromQueueURL returns the region of a queue URL in either the current
+// (sqs.<region>.amazonaws.com) or the legacy (<region>.queue.amazonaws.com) form
+func regionFromQueueURL(queueURL string) string {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(u.Hostname(), ".")
+	switch {
+	case len(parts) >= 4 && parts[0] == "sqs":
+		return parts[1]
+	case len(parts) >= 4 && parts[1] == "queue":
+		return parts[0]
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,178 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestRegionFromQueueURL(t *testing.T) {
+	tests := map[string]string{
+		"https://sqs.ap-northeast-1.amazonaws.com/123456789012/k8s-events": "ap-northeast-1",
+		"https://eu-west-1.queue.amazonaws.com/123456789012/k8s-events":    "eu-west-1",
+		"https://sqs.cn-north-1.amazonaws.com.cn/123456789012/k8s-events":  "cn-north-1",
+		"http://localhost:4566/000000000000/k8s-events":                    "",
+		"https://sqs.us-east-1.amazonaws.com/123456789012/k8s-events.fifo": "us-east-1",
+	}
+	for queueURL, want := range tests {
+		if got := regionFromQueueURL(queueURL); got != want {
+			t.Errorf("regionFromQueueURL(%q) = %q, want %q", queueURL, got, want)
+		}
+	}
+
+	if _, err := NewSQSNotifier(SQSConfig{QueueURL: "http://localhost:4566/000000000000/k8s-events"}); err == nil {
+		t.Error("Expected error for queue URL without region")
+	}
+}
+
+func TestSQSNotifier_NotifyEvent(t *testing.T) {
+	var received []*http.Request
+	var forms []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+		}
+		form := make(map[string]string)
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		received = append(received, r)
+		forms = append(forms, form)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	queueURL := "https://sqs.us-east-1.amazonaws.com/123456789012/k8s-events"
+	n, err := NewSQSNotifier(SQSConfig{
+		QueueURL: queueURL,
+		Endpoint: server.URL,
+		Auth:     AWSAuthConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewSQSNotifier() error = %v", err)
+	}
+
+	event := &watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "DELETED", Timestamp: time.Now()}
+	if err := n.NotifyEvent(event); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(received))
+	}
+	if auth := received[0].Header.Get("Authorization"); !strings.Contains(auth, "/us-east-1/sqs/aws4_request") {
+		t.Errorf("Unexpected Authorization header %q", auth)
+	}
+
+	form := forms[0]
+	if form["Action"] != "SendMessage" || form["QueueUrl"] != queueURL {
+		t.Errorf("Unexpected send parameters: %v", form)
+	}
+	if _, ok := form["MessageGroupId"]; ok {
+		t.Error("Expected no message group for a standard queue")
+	}
+	var payload EventPayload
+	if err := json.Unmarshal([]byte(form["MessageBody"]), &payload); err != nil {
+		t.Fatalf("MessageBody is not a JSON event payload: %v", err)
+	}
+	if payload.Name != "web" || payload.EventType != "DELETED" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	// 空のclusterは省略されるため、kindが1番目の属性になる
+	if form["MessageAttribute.1.Name"] != "kind" || form["MessageAttribute.1.Value.StringValue"] != "Pod" {
+		t.Errorf("Expected kind message attribute, got %v", form)
+	}
+}
+
+func TestSQSNotifier_FIFO(t *testing.T) {
+	var forms []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		forms = append(forms, map[string]string{
+			"MessageGroupId":         r.PostForm.Get("MessageGroupId"),
+			"MessageDeduplicationId": r.PostForm.Get("MessageDeduplicationId"),
+		})
+	}))
+	defer server.Close()
+
+	n, err := NewSQSNotifier(SQSConfig{
+		QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789012/k8s-events.fifo",
+		Endpoint: server.URL,
+		Auth:     AWSAuthConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewSQSNotifier() error = %v", err)
+	}
+
+	batch := NewBatchPayload([]*watcher.Event{
+		{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "ADDED"},
+		{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "DELETED"},
+	}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+
+	if len(forms) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(forms))
+	}
+	// 同じリソースのイベントは同じメッセージグループで順序が保証される
+	if forms[0]["MessageGroupId"] != "Pod/prod/web" || forms[1]["MessageGroupId"] != "Pod/prod/web" {
+		t.Errorf("Unexpected message groups: %v", forms)
+	}
+	if forms[0]["MessageDeduplicationId"] == "" || forms[0]["MessageDeduplicationId"] == forms[1]["MessageDeduplicationId"] {
+		t.Errorf("Expected distinct deduplication IDs: %v", forms)
+	}
+}
+
+func TestSQSNotifier_RetryBatch(t *testing.T) {
+	var names []string
+	failing := "b"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		var payload EventPayload
+		_ = json.Unmarshal([]byte(r.PostForm.Get("MessageBody")), &payload)
+		names = append(names, payload.Name)
+		if payload.Name == failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	n, err := NewSQSNotifier(SQSConfig{
+		QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789012/k8s-events",
+		Endpoint: server.URL,
+		Auth:     AWSAuthConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewSQSNotifier() error = %v", err)
+	}
+
+	batch := NewBatchPayload([]*watcher.Event{
+		{Kind: "Pod", Namespace: "prod", Name: "a", EventType: "ADDED"},
+		{Kind: "Pod", Namespace: "prod", Name: "b", EventType: "ADDED"},
+		{Kind: "Pod", Namespace: "prod", Name: "c", EventType: "ADDED"},
+	}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err == nil {
+		t.Fatal("Expected the failed event to fail the batch")
+	}
+
+	// 再試行では送信済みのイベントを再送しない
+	failing = ""
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+	if got := strings.Join(names, ","); got != "a,b,b,c" {
+		t.Errorf("Sent %s, want a,b,b,c", got)
+	}
+
+	// 別のバッチは最初から送信する
+	names = nil
+	if err := n.NotifyBatch(NewBatchPayload([]*watcher.Event{{Kind: "Pod", Namespace: "prod", Name: "d"}}, time.Now(), time.Now())); err != nil || len(names) != 1 {
+		t.Errorf("Expected a new batch to be sent in full, got %v (err=%v)", names, err)
+	}
+}
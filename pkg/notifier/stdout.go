@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// StdoutNotifier writes event payloads as JSON lines, e.g. to standard output
+// for collection by a log shipper
+type StdoutNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutNotifier creates a new StdoutNotifier writing to w
+func NewStdoutNotifier(w io.Writer) *StdoutNotifier {
+	return &StdoutNotifier{w: w}
+}
+
+// Name identifies the notifier in logs
+func (s *StdoutNotifier) Name() string {
+	return "stdout"
+}
+
+// NotifyEvent writes an event as a single JSON line
+func (s *StdoutNotifier) NotifyEvent(event *watcher.Event) error {
+	return s.writeLine(NewEventPayload(event))
+}
+
+// NotifyBatch writes a batch as a single JSON line
+func (s *StdoutNotifier) NotifyBatch(batch *BatchPayload) error {
+	return s.writeLine(batch)
+}
+
+// writeLine encodes v followed by a newline in a single write
+func (s *StdoutNotifier) writeLine(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(data); err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestStdoutNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	n := NewStdoutNotifier(&buf)
+
+	if err := n.NotifyEvent(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "DELETED"}); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+	batch := NewBatchPayload([]*watcher.Event{
+		{Kind: "Pod", Name: "a", EventType: "ADDED"},
+		{Kind: "Pod", Name: "b", EventType: "ADDED"},
+	}, time.Now(), time.Now())
+	if err := n.NotifyBatch(batch); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSON lines, got %d: %q", len(lines), buf.String())
+	}
+
+	var event EventPayload
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil || event.Name != "web" {
+		t.Errorf("Unexpected event line %q (err=%v)", lines[0], err)
+	}
+
+	var decoded BatchPayload
+	if err := json.Unmarshal([]byte(lines[1]), &decoded); err != nil || len(decoded.Events) != 2 {
+		t.Errorf("Unexpected batch line %q (err=%v)", lines[1], err)
+	}
+}
@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// truncationMarker is appended to values shortened to fit the message size limit
+const truncationMarker = "…"
+
+// TruncateMessage shortens the longest attachment texts and field values and block
+// texts of a message until its JSON fits within maxBytes. It is meant for parts that
+// cannot be split any further, such as a single attachment with a huge status message.
+// A message within the limit is returned unchanged; otherwise a truncated copy is returned.
+func TruncateMessage(msg *SlackMessage, maxBytes int) *SlackMessage {
+	if maxBytes <= 0 {
+		return msg
+	}
+
+	copied := false
+	for {
+		data, err := json.Marshal(msg)
+		if err != nil || len(data) <= maxBytes {
+			return msg
+		}
+		overshoot := len(data) - maxBytes
+
+		if !copied {
+			msg = cloneMessage(msg)
+			copied = true
+		}
+
+		// Find the longest value that can still be shortened
+		var longest *string
+		consider := func(s *string) {
+			if longest == nil || len(*s) > len(*longest) {
+				longest = s
+			}
+		}
+		for i := range msg.Blocks {
+			b := &msg.Blocks[i]
+			if b.Text != nil {
+				consider(&b.Text.Text)
+			}
+			for j := range b.Fields {
+				consider(&b.Fields[j].Text)
+			}
+			for j := range b.Elements {
+				consider(&b.Elements[j].Text)
+			}
+		}
+		for i := range msg.Attachments {
+			a := &msg.Attachments[i]
+			consider(&a.Text)
+			for j := range a.Fields {
+				consider(&a.Fields[j].Value)
+			}
+		}
+		if longest == nil || len(*longest) <= len(truncationMarker) {
+			return msg
+		}
+
+		keep := len(*longest) - overshoot - len(truncationMarker)
+		if keep < 0 {
+			keep = 0
+		}
+		// Avoid cutting a multi-byte character in half
+		for keep > 0 && !utf8.RuneStart((*longest)[keep]) {
+			keep--
+		}
+		*longest = (*longest)[:keep] + truncationMarker
+	}
+}
+
+// cloneMessage returns a copy of msg whose blocks, attachments and their texts
+// can be modified safely
+func cloneMessage(msg *SlackMessage) *SlackMessage {
+	clone := *msg
+	clone.Blocks = make([]SlackBlock, len(msg.Blocks))
+	for i, b := range msg.Blocks {
+		if b.Text != nil {
+			text := *b.Text
+			b.Text = &text
+		}
+		b.Fields = append([]SlackText(nil), b.Fields...)
+		b.Elements = append([]SlackText(nil), b.Elements...)
+		clone.Blocks[i] = b
+	}
+	clone.Attachments = make([]SlackAttachment, len(msg.Attachments))
+	for i, a := range msg.Attachments {
+		a.Fields = append([]SlackAttachmentField(nil), a.Fields...)
+		clone.Attachments[i] = a
+	}
+	return &clone
+}
@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateMessage(t *testing.T) {
+	msg := &SlackMessage{
+		Attachments: []SlackAttachment{
+			{
+				Title: "pod",
+				Fields: []SlackAttachmentField{
+					{Title: "Status", Value: "Failed"},
+					{Title: "Message", Value: strings.Repeat("エラー", 500)},
+				},
+			},
+		},
+	}
+
+	truncated := TruncateMessage(msg, 500)
+
+	data, _ := json.Marshal(truncated)
+	if len(data) > 500 {
+		t.Errorf("Truncated message is %d bytes, exceeds limit", len(data))
+	}
+
+	value := truncated.Attachments[0].Fields[1].Value
+	if !strings.HasSuffix(value, truncationMarker) {
+		t.Errorf("Expected truncation marker, got %q", value)
+	}
+	if !utf8.ValidString(value) || !strings.HasPrefix(value, "エラー") {
+		t.Errorf("Expected value to be cut on a character boundary, got %q", value)
+	}
+	if truncated.Attachments[0].Fields[0].Value != "Failed" {
+		t.Error("Short fields should be left untouched")
+	}
+
+	// 元のメッセージは変更されない
+	if len(msg.Attachments[0].Fields[1].Value) != len(strings.Repeat("エラー", 500)) {
+		t.Error("Original message should not be modified")
+	}
+}
+
+func TestTruncateMessage_Blocks(t *testing.T) {
+	msg := &SlackMessage{
+		Blocks: []SlackBlock{
+			{Type: BlockTypeHeader, Text: &SlackText{Type: "plain_text", Text: "Pod web"}},
+			{Type: BlockTypeSection, Text: &SlackText{Type: "mrkdwn", Text: strings.Repeat("x", 2000)}},
+		},
+		Attachments: []SlackAttachment{{Text: "attachment"}},
+	}
+
+	truncated := TruncateMessage(msg, 1000)
+	data, _ := json.Marshal(truncated)
+	if len(data) > 1000 {
+		t.Errorf("Truncated message is %d bytes, exceeds limit", len(data))
+	}
+	if !strings.HasSuffix(truncated.Blocks[1].Text.Text, truncationMarker) || truncated.Blocks[0].Text.Text != "Pod web" {
+		t.Errorf("Expected the long section to be truncated, got %+v", truncated.Blocks)
+	}
+	if len(truncated.Attachments) != 1 || truncated.Attachments[0].Text != "attachment" {
+		t.Error("Attachments should be kept")
+	}
+	// 元のブロックは変更されない
+	if len(msg.Blocks[1].Text.Text) != 2000 {
+		t.Error("Original message should not be modified")
+	}
+}
+
+func TestTruncateMessage_WithinLimit(t *testing.T) {
+	msg := &SlackMessage{Text: "short"}
+	if TruncateMessage(msg, DefaultMaxMessageBytes) != msg {
+		t.Error("Expected message within the limit to be returned unchanged")
+	}
+}
@@ -0,0 +1,147 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Web API endpoints of the two-step file upload used in bot-token mode
+const (
+	slackGetUploadURL      = "https://slack.com/api/files.getUploadURLExternal"
+	slackCompleteUploadURL = "https://slack.com/api/files.completeUploadExternal"
+)
+
+// slackUploadURLs are the endpoints of the file upload, replaced in tests
+type slackUploadURLs struct {
+	getURL   string
+	complete string
+}
+
+// SlackFile is a text file uploaded along with a message, e.g. the manifest of
+// the changed object
+type SlackFile struct {
+	Name    string `json:"name"` // File name, e.g. "deployment-web.yaml"
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content"`
+}
+
+// SendThreadedWithFile sends a message like SendThreaded and uploads file as a
+// reply to it. Files are only uploaded in bot-token mode; webhooks get the
+// message alone. Messages that were not posted, because they were coalesced or
+// rate-limited, get no file either. A failed upload is logged, since the
+// message itself was delivered.
+func (s *SlackNotifier) SendThreadedWithFile(key string, payload *SlackMessage, file *SlackFile) error {
+	ts, err := s.sendThreaded(key, payload)
+	if err != nil || file == nil {
+		return err
+	}
+	if s.botToken == "" {
+		return nil
+	}
+	if s.dryRun != nil {
+		return s.dryRun.write(s.dryRunName, file)
+	}
+	if ts == "" {
+		return nil
+	}
+
+	err = s.breaker.Do(func() error {
+		return s.retry.Do(func() error {
+			return s.upload(file, ts)
+		})
+	})
+	if err != nil {
+		slog.Warn("Failed to upload file to Slack", "file", file.Name, "error", err)
+	}
+	return nil
+}
+
+// slackUploadTicket is the response of files.getUploadURLExternal
+type slackUploadTicket struct {
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+// upload uploads file to the thread threadTS: it obtains an upload URL, posts
+// the content to it, and shares the file in the channel
+func (s *SlackNotifier) upload(file *SlackFile, threadTS string) error {
+	var ticket slackUploadTicket
+	err := s.callAPI(s.uploadURLs.getURL, url.Values{
+		"filename": {file.Name},
+		"length":   {strconv.Itoa(len(file.Content))},
+	}, &ticket)
+	if err != nil {
+		return fmt.Errorf("failed to get upload URL: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", ticket.UploadURL, strings.NewReader(file.Content))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp, fmt.Sprintf("slack upload returned non-200 status code: %d", resp.StatusCode))
+	}
+
+	files, err := json.Marshal([]map[string]string{{"id": ticket.FileID, "title": file.Title}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal files: %w", err)
+	}
+	err = s.callAPI(s.uploadURLs.complete, url.Values{
+		"files":      {string(files)},
+		"channel_id": {s.channel},
+		"thread_ts":  {threadTS},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to complete upload: %w", err)
+	}
+	return nil
+}
+
+// callAPI posts a form to a Web API method and decodes the response into result, if set
+func (s *SlackNotifier) callAPI(endpoint string, form url.Values, result interface{}) error {
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.botToken)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp, fmt.Sprintf("slack API returned non-200 status code: %d", resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read slack API response: %w", err)
+	}
+	var status slackAPIResponse
+	if err := json.Unmarshal(body, &status); err != nil {
+		return fmt.Errorf("failed to decode slack API response: %w", err)
+	}
+	if !status.OK {
+		return fmt.Errorf("slack API returned error: %s", status.Error)
+	}
+	if result != nil {
+		if err := json.Unmarshal(body, result); err != nil {
+			return fmt.Errorf("failed to decode slack API response: %w", err)
+		}
+	}
+	return nil
+}
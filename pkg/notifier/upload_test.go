@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlackNotifier_SendThreadedWithFile(t *testing.T) {
+	var content, completed string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":true,"ts":"1700000000.000001"}`)
+	})
+	var server *httptest.Server
+	mux.HandleFunc("/files.getUploadURLExternal", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("filename") != "deployment-web.yaml" || r.FormValue("length") != "16" {
+			t.Errorf("Unexpected upload request %v", r.Form)
+		}
+		fmt.Fprintf(w, `{"ok":true,"upload_url":"%s/upload","file_id":"F123"}`, server.URL)
+	})
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		content = string(data)
+	})
+	mux.HandleFunc("/files.completeUploadExternal", func(w http.ResponseWriter, r *http.Request) {
+		completed = r.FormValue("files") + " " + r.FormValue("channel_id") + " " + r.FormValue("thread_ts")
+		fmt.Fprint(w, `{"ok":true}`)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	notifier := NewSlackBotNotifier("xoxb-test", "C123")
+	notifier.webhookURL = server.URL + "/chat.postMessage"
+	notifier.uploadURLs = slackUploadURLs{getURL: server.URL + "/files.getUploadURLExternal", complete: server.URL + "/files.completeUploadExternal"}
+
+	file := &SlackFile{Name: "deployment-web.yaml", Title: "Deployment default/web", Content: "kind: Deployment"}
+	if err := notifier.SendThreadedWithFile("Deployment/default/web", &SlackMessage{Text: "updated"}, file); err != nil {
+		t.Fatalf("SendThreadedWithFile() error = %v", err)
+	}
+
+	if content != file.Content {
+		t.Errorf("Uploaded content = %q, want %q", content, file.Content)
+	}
+	// ファイルはメッセージのスレッドに共有される
+	want := `[{"id":"F123","title":"Deployment default/web"}] C123 1700000000.000001`
+	if completed != want {
+		t.Errorf("Completed upload = %q, want %q", completed, want)
+	}
+}
+
+func TestSlackNotifier_SendThreadedWithFile_Webhook(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	// Webhookではファイルを送らずメッセージだけを送る
+	notifier := NewSlackNotifier(server.URL)
+	if err := notifier.SendThreadedWithFile("", &SlackMessage{Text: "updated"}, &SlackFile{Name: "a.yaml", Content: "a: 1"}); err != nil {
+		t.Fatalf("SendThreadedWithFile() error = %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected only the message to be sent, got %d requests", requests)
+	}
+}
+
+func TestSlackNotifier_SendThreadedWithFile_DryRun(t *testing.T) {
+	var out bytes.Buffer
+	notifier := NewSlackBotNotifier("xoxb-test", "C123")
+	notifier.SetDryRun(NewDryRun(&out), "slack")
+
+	if err := notifier.SendThreadedWithFile("", &SlackMessage{Text: "updated"}, &SlackFile{Name: "a.yaml", Content: "a: 1"}); err != nil {
+		t.Fatalf("SendThreadedWithFile() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"name":"a.yaml"`) {
+		t.Errorf("Expected the message and the file to be recorded, got %q", out.String())
+	}
+}
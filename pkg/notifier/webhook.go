@@ -0,0 +1,230 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/tracing"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// SecretSource is a credential given inline, read from an environment variable,
+// or read from a file such as a mounted Kubernetes Secret. Files are re-read on
+// every use so that rotated secrets are picked up.
+type SecretSource struct {
+	Value string
+	Env   string
+	File  string
+}
+
+// IsSet reports whether any source is configured
+func (s SecretSource) IsSet() bool {
+	return s.Value != "" || s.Env != "" || s.File != ""
+}
+
+// Resolve returns the secret value
+func (s SecretSource) Resolve() (string, error) {
+	switch {
+	case s.File != "":
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", s.Env)
+		}
+		return value, nil
+	default:
+		return s.Value, nil
+	}
+}
+
+// WebhookConfig contains generic webhook settings
+type WebhookConfig struct {
+	URL         string
+	Headers     map[string]string // Static headers added to every request
+	BearerToken SecretSource      // Sent as "Authorization: Bearer <token>"
+	Username    string            // Basic auth, used when BearerToken is not set
+	Password    SecretSource
+	Signing     *WebhookSigning // Sign payloads with HMAC-SHA256 when set
+
+	HTTPClient *http.Client // Defaults to a client with DefaultHTTPTimeout
+}
+
+// DefaultSignatureHeader is the header carrying the payload signature
+const DefaultSignatureHeader = "X-Signature-256"
+
+// WebhookSigning contains HMAC-SHA256 payload signing settings.
+// The signature header is "sha256=<hex HMAC>". Without a timestamp header the HMAC
+// covers the body (GitHub style); otherwise it covers "<unix timestamp>.<body>" and
+// the timestamp is sent so receivers can reject replayed requests.
+type WebhookSigning struct {
+	Secret          SecretSource
+	SignatureHeader string // Defaults to DefaultSignatureHeader
+	TimestampHeader string // Optional, e.g. X-Signature-Timestamp
+}
+
+// WebhookNotifier posts event payloads as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	cfg        WebhookConfig
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewWebhookNotifier creates a new WebhookNotifier
+func NewWebhookNotifier(cfg WebhookConfig) (*WebhookNotifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+	if cfg.Signing != nil && cfg.Signing.SignatureHeader == "" {
+		signing := *cfg.Signing
+		signing.SignatureHeader = DefaultSignatureHeader
+		cfg.Signing = &signing
+	}
+	return &WebhookNotifier{cfg: cfg, httpClient: httpClient, now: time.Now}, nil
+}
+
+// Name identifies the notifier in logs
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// NotifyEvent posts an event payload
+func (w *WebhookNotifier) NotifyEvent(event *watcher.Event) error {
+	return w.NotifyEventContext(context.Background(), event)
+}
+
+// NotifyBatch posts a batch payload
+func (w *WebhookNotifier) NotifyBatch(batch *BatchPayload) error {
+	return w.NotifyBatchContext(context.Background(), batch)
+}
+
+// NotifyEventContext posts an event payload with the trace context of ctx
+func (w *WebhookNotifier) NotifyEventContext(ctx context.Context, event *watcher.Event) error {
+	return w.post(ctx, NewEventPayload(event))
+}
+
+// NotifyBatchContext posts a batch payload with the trace context of ctx
+func (w *WebhookNotifier) NotifyBatchContext(ctx context.Context, batch *BatchPayload) error {
+	return w.post(ctx, batch)
+}
+
+// post sends v as JSON with the configured headers and credentials
+func (w *WebhookNotifier) post(ctx context.Context, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+	for key, value := range w.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	if err := setAuthorization(req, w.cfg.BearerToken, w.cfg.Username, w.cfg.Password); err != nil {
+		return err
+	}
+	if err := w.sign(req, body); err != nil {
+		return err
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newStatusError(resp, fmt.Sprintf("webhook returned status %d", resp.StatusCode))
+	}
+	return nil
+}
+
+// CheckHealth sends a HEAD request with the configured credentials. Endpoints usually
+// only accept POST, so any response except missing or rejected credentials, an
+// unknown path or a server error is taken as healthy.
+func (w *WebhookNotifier) CheckHealth() error {
+	req, err := http.NewRequest("HEAD", w.cfg.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range w.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	if err := setAuthorization(req, w.cfg.BearerToken, w.cfg.Username, w.cfg.Password); err != nil {
+		return err
+	}
+	return checkEndpoint(w.httpClient, req, func(status int) bool {
+		switch status {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+			return false
+		}
+		return status < 500
+	})
+}
+
+// sign adds the HMAC signature headers for body to req
+func (w *WebhookNotifier) sign(req *http.Request, body []byte) error {
+	signing := w.cfg.Signing
+	if signing == nil {
+		return nil
+	}
+
+	secret, err := signing.Secret.Resolve()
+	if err != nil {
+		return fmt.Errorf("failed to resolve signing secret: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	if signing.TimestampHeader != "" {
+		timestamp := strconv.FormatInt(w.now().Unix(), 10)
+		req.Header.Set(signing.TimestampHeader, timestamp)
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+	req.Header.Set(signing.SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// setAuthorization adds bearer or basic auth credentials to req
+func setAuthorization(req *http.Request, bearerToken SecretSource, username string, password SecretSource) error {
+	if bearerToken.IsSet() {
+		token, err := bearerToken.Resolve()
+		if err != nil {
+			return fmt.Errorf("failed to resolve bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	if username != "" {
+		secret, err := password.Resolve()
+		if err != nil {
+			return fmt.Errorf("failed to resolve password: %w", err)
+		}
+		req.SetBasicAuth(username, secret)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package notifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/tracing"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestWebhookNotifier_NotifyEvent(t *testing.T) {
+	var received *http.Request
+	var payload EventPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600)
+
+	n, err := NewWebhookNotifier(WebhookConfig{
+		URL:         server.URL,
+		Headers:     map[string]string{"X-Team": "platform"},
+		BearerToken: SecretSource{File: tokenFile},
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+
+	if err := n.NotifyEvent(&watcher.Event{Kind: "Pod", Name: "web", EventType: "DELETED"}); err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+
+	if received.Header.Get("X-Team") != "platform" {
+		t.Errorf("Expected static header, got %q", received.Header.Get("X-Team"))
+	}
+	// シークレットファイルの末尾の改行は除去される
+	if received.Header.Get("Authorization") != "Bearer s3cret" {
+		t.Errorf("Unexpected Authorization header: %q", received.Header.Get("Authorization"))
+	}
+	if payload.Name != "web" || payload.EventType != "DELETED" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+}
+
+func TestWebhookNotifier_TraceContext(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	webhook, err := NewWebhookNotifier(WebhookConfig{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+	// リトライやサーキットブレーカーでラップしてもトレースコンテキストは伝播される
+	n := WithCircuitBreaker(WithRetry(webhook, RetryPolicy{MaxAttempts: 2}), NewCircuitBreaker("webhook", 5, time.Minute, nil))
+	ctx := tracing.ContextWithRemoteSpanContext(context.Background(), tracing.SpanContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Sampled: true,
+	})
+	if err := NotifyEventContext(ctx, n, &watcher.Event{Kind: "Pod", Name: "web", EventType: "DELETED"}); err != nil {
+		t.Fatalf("NotifyEventContext() error = %v", err)
+	}
+	if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+
+	// コンテキストなしの送信ではヘッダーを付けない
+	if err := n.NotifyBatch(&BatchPayload{}); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+	if traceparent != "" {
+		t.Errorf("Unexpected traceparent %q", traceparent)
+	}
+}
+
+func TestWebhookNotifier_BasicAuthFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_PASSWORD", "hunter2")
+
+	var user, pass string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ = r.BasicAuth()
+	}))
+	defer server.Close()
+
+	n, _ := NewWebhookNotifier(WebhookConfig{
+		URL:      server.URL,
+		Username: "kube-watcher",
+		Password: SecretSource{Env: "WEBHOOK_PASSWORD"},
+	})
+	if err := n.NotifyBatch(NewBatchPayload(nil, time.Now(), time.Now())); err != nil {
+		t.Fatalf("NotifyBatch() error = %v", err)
+	}
+	if user != "kube-watcher" || pass != "hunter2" {
+		t.Errorf("Unexpected basic auth credentials: %q/%q", user, pass)
+	}
+}
+
+func TestWebhookNotifier_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	n, _ := NewWebhookNotifier(WebhookConfig{URL: server.URL})
+	err := n.NotifyEvent(&watcher.Event{})
+	if err == nil || !IsRetryable(err) {
+		t.Errorf("Expected retryable error for 502, got %v", err)
+	}
+
+	n, _ = NewWebhookNotifier(WebhookConfig{URL: server.URL, BearerToken: SecretSource{Env: "KUBE_WATCHER_UNSET_TOKEN"}})
+	if err := n.NotifyEvent(&watcher.Event{}); err == nil {
+		t.Error("Expected error for unset token environment variable")
+	}
+}
+
+func TestWebhookNotifier_Signing(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		timestampHeader string
+		signedContent   func(body []byte) []byte
+	}{
+		{
+			name:          "body only",
+			signedContent: func(body []byte) []byte { return body },
+		},
+		{
+			name:            "with timestamp",
+			timestampHeader: "X-Signature-Timestamp",
+			signedContent:   func(body []byte) []byte { return append([]byte("1700000000."), body...) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, _ := NewWebhookNotifier(WebhookConfig{
+				URL: server.URL,
+				Signing: &WebhookSigning{
+					Secret:          SecretSource{Value: "shared-secret"},
+					TimestampHeader: tt.timestampHeader,
+				},
+			})
+			n.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+			if err := n.NotifyEvent(&watcher.Event{Kind: "Pod", Name: "web"}); err != nil {
+				t.Fatalf("NotifyEvent() error = %v", err)
+			}
+
+			// 受信側と同じ方法で署名を検証する
+			mac := hmac.New(sha256.New, []byte("shared-secret"))
+			mac.Write(tt.signedContent(body))
+			want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+			if got := headers.Get(DefaultSignatureHeader); got != want {
+				t.Errorf("Signature = %q, want %q", got, want)
+			}
+			if tt.timestampHeader != "" && headers.Get(tt.timestampHeader) != "1700000000" {
+				t.Errorf("Expected timestamp header, got %q", headers.Get(tt.timestampHeader))
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// clusterReaderTimeout bounds the time spent reading additional information
+// about a resource, which delays its notification
+const clusterReaderTimeout = 5 * time.Second
+
+// clusterReader reads additional information about resources from the API
+// server; implemented by watcher.Watcher
+type clusterReader interface {
+	ContainerLogs(ctx context.Context, namespace, pod, container string, tailLines, limitBytes int64, previous bool) (string, error)
+	RelatedEvents(ctx context.Context, ref *corev1.ObjectReference, since time.Time, limit int) ([]watcher.RelatedEvent, error)
+	FailedPods(ctx context.Context, namespace, job string, limit int) ([]watcher.FailedPod, error)
+	Manifest(kind, namespace, name string) (string, error)
+}
+
+// maxFailedPods is the number of failed Pods listed for a Job
+const maxFailedPods = 5
+
+// reader returns the cluster reader, or nil before Start
+func (p *Pipeline) reader() clusterReader {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.cluster
+}
+
+// attachLogs attaches the tail of the log of the first failing container to a
+// Pod event, saving the first round trip with kubectl logs. The log of the
+// previous instance is preferred, as it holds the output of the crash; the
+// current one is read when there is none yet.
+func (p *Pipeline) attachLogs(ctx context.Context, cfg config.PodLogsConfig, event *watcher.Event) {
+	reader := p.reader()
+	if !cfg.Enabled || reader == nil || event.Kind != "Pod" || event.EventType == "DELETED" || event.Logs != nil {
+		return
+	}
+	var container string
+	for _, c := range event.Containers {
+		if c.Reason != "" {
+			container = c.Name
+			break
+		}
+	}
+	if container == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterReaderTimeout)
+	defer cancel()
+	for _, previous := range []bool{true, false} {
+		text, err := reader.ContainerLogs(ctx, event.Namespace, event.Name, container, int64(cfg.TailLines), int64(cfg.MaxBytes), previous)
+		if err != nil {
+			slog.Debug("Failed to fetch container logs", event.LogAttrs("container", container, "previous", previous, "error", err)...)
+			continue
+		}
+		if text = strings.TrimRight(text, "\n"); text == "" {
+			continue
+		}
+		event.Logs = &watcher.ContainerLogs{Container: container, Previous: previous, Text: truncateLogs(text, cfg.MaxBytes)}
+		return
+	}
+}
+
+// truncateLogs keeps the last maxBytes bytes of the logs, from a line boundary
+// where possible, since the end of the log is where the failure is
+func truncateLogs(text string, maxBytes int) string {
+	if len(text) <= maxBytes {
+		return text
+	}
+	text = text[len(text)-maxBytes:]
+	if i := strings.IndexByte(text, '\n'); i >= 0 && i < len(text)-1 {
+		text = text[i+1:]
+	}
+	return strings.ToValidUTF8(text, "")
+}
+
+// attachFailedPods lists the failed Pods of a Job with a failed Pod, so that
+// the notification names the Pods to inspect and why they failed
+func (p *Pipeline) attachFailedPods(ctx context.Context, event *watcher.Event) {
+	reader := p.reader()
+	if reader == nil || event.Job == nil || event.Job.Failed == 0 || event.Job.FailedPods != nil || event.EventType == "DELETED" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterReaderTimeout)
+	defer cancel()
+	pods, err := reader.FailedPods(ctx, event.Namespace, event.Name, maxFailedPods)
+	if err != nil {
+		slog.Debug("Failed to list failed pods", event.LogAttrs("error", err)...)
+		return
+	}
+	if len(pods) > 0 {
+		event.Job.FailedPods = pods
+	}
+}
+
+// attachRelatedEvents attaches the recent Warning Events of the resource, which
+// carry the why that otherwise needs kubectl describe. Events without an object
+// reference, such as injected ones, are skipped.
+func (p *Pipeline) attachRelatedEvents(ctx context.Context, cfg config.RelatedEventsConfig, event *watcher.Event) {
+	reader := p.reader()
+	if !cfg.Enabled || reader == nil || event.Ref == nil || event.Warnings != nil || !slices.Contains(cfg.Kinds, event.Kind) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterReaderTimeout)
+	defer cancel()
+	since := time.Now().Add(-time.Duration(cfg.MaxAgeMinutes) * time.Minute)
+	warnings, err := reader.RelatedEvents(ctx, event.Ref, since, cfg.MaxEvents)
+	if err != nil {
+		slog.Debug("Failed to list related events", event.LogAttrs("error", err)...)
+		return
+	}
+	if len(warnings) > 0 {
+		event.Warnings = warnings
+	}
+}
+
+// attachManifest attaches the YAML of the changed object, so that reviewers see
+// its new state without cluster access. Deleted objects have no state to show.
+func (p *Pipeline) attachManifest(cfg config.ManifestsConfig, event *watcher.Event) {
+	reader := p.reader()
+	if !cfg.Enabled || reader == nil || event.EventType == "DELETED" || event.Manifest != "" {
+		return
+	}
+	if len(cfg.Kinds) > 0 && !slices.Contains(cfg.Kinds, event.Kind) {
+		return
+	}
+
+	manifest, err := reader.Manifest(event.Kind, event.Namespace, event.Name)
+	if err != nil {
+		slog.Debug("Failed to render manifest", event.LogAttrs("error", err)...)
+		return
+	}
+	event.Manifest = truncateManifest(manifest, cfg.MaxBytes)
+}
+
+// manifestTruncated marks the end of a truncated manifest
+const manifestTruncated = "# ... truncated"
+
+// truncateManifest keeps the first maxBytes bytes of the manifest, up to a line
+// boundary where possible, since the metadata and spec come first
+func truncateManifest(text string, maxBytes int) string {
+	text = strings.TrimRight(text, "\n")
+	if len(text) <= maxBytes {
+		return text
+	}
+	text = text[:max(maxBytes-len(manifestTruncated)-1, 0)]
+	if i := strings.LastIndexByte(text, '\n'); i > 0 {
+		text = text[:i]
+	}
+	return strings.ToValidUTF8(text, "") + "\n" + manifestTruncated
+}
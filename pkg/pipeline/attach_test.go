@@ -0,0 +1,252 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// fakeCluster returns fixed logs for the previous and current container
+// instances, and fixed related Events, failed Pods and manifests
+type fakeCluster struct {
+	previous, current string
+	calls             int
+	warnings          []watcher.RelatedEvent
+	limit             int
+	failedPods        []watcher.FailedPod
+	manifests         map[string]string // Kind/name -> YAML
+}
+
+func (f *fakeCluster) Manifest(kind, _, name string) (string, error) {
+	manifest, ok := f.manifests[kind+"/"+name]
+	if !ok {
+		return "", errors.New("not found in the cache")
+	}
+	return manifest, nil
+}
+
+func (f *fakeCluster) FailedPods(_ context.Context, _, _ string, _ int) ([]watcher.FailedPod, error) {
+	return f.failedPods, nil
+}
+
+func (f *fakeCluster) RelatedEvents(_ context.Context, _ *corev1.ObjectReference, _ time.Time, limit int) ([]watcher.RelatedEvent, error) {
+	f.limit = limit
+	return f.warnings, nil
+}
+
+func (f *fakeCluster) ContainerLogs(_ context.Context, _, _, _ string, _, _ int64, previous bool) (string, error) {
+	f.calls++
+	if previous {
+		if f.previous == "" {
+			return "", errors.New("previous terminated container not found")
+		}
+		return f.previous, nil
+	}
+	return f.current, nil
+}
+
+func TestPipeline_AttachLogs(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.PodLogs = config.PodLogsConfig{Enabled: true}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var notified []*watcher.Event
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out, Hooks: Hooks{OnNotify: func(e *watcher.Event) {
+		notified = append(notified, e)
+	}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	logs := &fakeCluster{previous: "panic: nil map\n", current: "starting\n"}
+	p.cluster = logs
+
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "crash", EventType: "ADDED",
+		Containers: []watcher.ContainerInfo{{Name: "sidecar"}, {Name: "app", Reason: watcher.ReasonCrashLoopBackOff}}})
+	// 失敗していないPodのログは取得しない
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "healthy", EventType: "ADDED",
+		Containers: []watcher.ContainerInfo{{Name: "app"}}})
+
+	if len(notified) != 2 {
+		t.Fatalf("Expected 2 notified events, got %d", len(notified))
+	}
+	// クラッシュの出力が残る前回のコンテナのログが優先される
+	got := notified[0].Logs
+	if got == nil || got.Container != "app" || !got.Previous || got.Text != "panic: nil map" {
+		t.Errorf("Unexpected logs %+v", got)
+	}
+	if notified[1].Logs != nil || logs.calls != 1 {
+		t.Errorf("Expected no logs to be fetched for the healthy Pod, got %+v after %d calls", notified[1].Logs, logs.calls)
+	}
+
+	// 前回のコンテナがない場合は現在のコンテナのログを使う
+	logs.previous = ""
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "oom", EventType: "ADDED",
+		Containers: []watcher.ContainerInfo{{Name: "app", Reason: watcher.ReasonOOMKilled}}})
+	if got := notified[2].Logs; got == nil || got.Previous || got.Text != "starting" {
+		t.Errorf("Expected the logs of the current container, got %+v", got)
+	}
+}
+
+func TestPipeline_AttachRelatedEvents(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.RelatedEvents = config.RelatedEventsConfig{Enabled: true}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var notified []*watcher.Event
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out, Hooks: Hooks{OnNotify: func(e *watcher.Event) {
+		notified = append(notified, e)
+	}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	cluster := &fakeCluster{warnings: []watcher.RelatedEvent{{Reason: "FailedScheduling", Message: "0/3 nodes are available", Count: 4}}}
+	p.cluster = cluster
+
+	ref := &corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web"}
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED", Ref: ref})
+	// オブジェクト参照のない注入されたイベントには付与しない
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "injected", EventType: "ADDED"})
+
+	if len(notified) != 2 {
+		t.Fatalf("Expected 2 notified events, got %d", len(notified))
+	}
+	if got := notified[0].Warnings; len(got) != 1 || got[0].Reason != "FailedScheduling" || cluster.limit != 3 {
+		t.Errorf("Unexpected warnings %+v with limit %d", got, cluster.limit)
+	}
+	if notified[1].Warnings != nil {
+		t.Errorf("Expected no warnings for an injected event, got %+v", notified[1].Warnings)
+	}
+}
+
+func TestPipeline_AttachFailedPods(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Filters = []config.FilterConfig{{Resource: "Job", EventTypes: []string{"UPDATED"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var notified []*watcher.Event
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out, Hooks: Hooks{OnNotify: func(e *watcher.Event) {
+		notified = append(notified, e)
+	}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	p.cluster = &fakeCluster{failedPods: []watcher.FailedPod{{Name: "migrate-x7k2p", Reason: "Error (exit code 1)"}}}
+
+	p.HandleEvent(&watcher.Event{Kind: "Job", Namespace: "default", Name: "migrate", EventType: "UPDATED",
+		Status: "Failed", Job: &watcher.JobInfo{Failed: 7, BackoffLimit: 6}})
+	// 失敗したPodのないJobでは問い合わせない
+	p.HandleEvent(&watcher.Event{Kind: "Job", Namespace: "default", Name: "report", EventType: "UPDATED",
+		Status: "Complete", Job: &watcher.JobInfo{Succeeded: 1}})
+
+	if len(notified) != 2 {
+		t.Fatalf("Expected 2 notified events, got %d", len(notified))
+	}
+	if got := notified[0].Job.FailedPods; len(got) != 1 || got[0].Name != "migrate-x7k2p" {
+		t.Errorf("Unexpected failed pods %+v", got)
+	}
+	if got := notified[1].Job.FailedPods; got != nil {
+		t.Errorf("Expected no failed pods for a complete Job, got %+v", got)
+	}
+}
+
+func TestPipeline_AttachManifest(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Resources = []config.ResourceConfig{{Kind: "Pod"}, {Kind: "Deployment"}}
+	cfg.Filters = []config.FilterConfig{{Resource: "Pod", EventTypes: []string{"ADDED"}}, {Resource: "Deployment"}}
+	cfg.Manifests = config.ManifestsConfig{Enabled: true, Kinds: []string{"Deployment"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var notified []*watcher.Event
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out, Hooks: Hooks{OnNotify: func(e *watcher.Event) {
+		notified = append(notified, e)
+	}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	manifest := "apiVersion: apps/v1\nkind: Deployment\n"
+	p.cluster = &fakeCluster{manifests: map[string]string{"Deployment/web": manifest, "Pod/web-1": manifest}}
+
+	p.HandleEvent(&watcher.Event{Kind: "Deployment", Namespace: "default", Name: "web", EventType: "UPDATED"})
+	// 削除されたリソースや対象外の種類には添付しない
+	p.HandleEvent(&watcher.Event{Kind: "Deployment", Namespace: "default", Name: "web", EventType: "DELETED"})
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web-1", EventType: "ADDED"})
+
+	if len(notified) != 3 {
+		t.Fatalf("Expected 3 notified events, got %d", len(notified))
+	}
+	if got := notified[0].Manifest; got != "apiVersion: apps/v1\nkind: Deployment" {
+		t.Errorf("Unexpected manifest %q", got)
+	}
+	for _, e := range notified[1:] {
+		if e.Manifest != "" {
+			t.Errorf("Expected no manifest for %s %s, got %q", e.EventType, e.Kind, e.Manifest)
+		}
+	}
+}
+
+func TestTruncateManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxBytes int
+		want     string
+	}{
+		{"fits", "a: 1\nb: 2\n", 10, "a: 1\nb: 2"},
+		// 先頭を残し、途中で切れた行は捨てる
+		{"line boundary", "kind: Deployment\nspec:\n  replicas: 3\n  paused: false\n", 40, "kind: Deployment\nspec:\n" + manifestTruncated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateManifest(tt.text, tt.maxBytes); got != tt.want {
+				t.Errorf("truncateManifest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateLogs(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxBytes int
+		want     string
+	}{
+		{"fits", "a\nb", 10, "a\nb"},
+		// 末尾を残し、途中で切れた行は捨てる
+		{"line boundary", "first line\nsecond\nthird", 14, "second\nthird"},
+		{"single line", "0123456789", 4, "6789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateLogs(tt.text, tt.maxBytes); got != tt.want {
+				t.Errorf("truncateLogs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
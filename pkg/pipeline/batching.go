@@ -0,0 +1,174 @@
+package pipeline
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/kqns91/kube-watcher/pkg/batcher"
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/formatter"
+	"github.com/kqns91/kube-watcher/pkg/router"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Batch keys identify the batcher collecting the events sent to a destination.
+// Routes batching in their own window are keyed by their index.
+const (
+	globalBatch = -1 // The global batcher, for routes without batching settings
+	noBatch     = -2 // No batcher: the route sends its events unbatched
+)
+
+// batchKey returns the key of the batcher collecting the events sent through
+// the route with the index, or through no route (-1)
+func batchKey(routes []config.RouteConfig, route int) int {
+	if route < 0 || route >= len(routes) {
+		return globalBatch
+	}
+	switch r := routes[route]; {
+	case r.Unbatched():
+		return noBatch
+	case r.OwnBatching():
+		return route
+	default:
+		return globalBatch
+	}
+}
+
+// routeName returns the name of the route with the index, as used in logs
+func routeName(routes []config.RouteConfig, route int) string {
+	if name := routes[route].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("routes[%d]", route)
+}
+
+// newBatchers creates the global batcher, if batching is enabled, and a
+// batcher for every route batching in its own window. The batches are routed
+// with r, the router the events were batched with, so that batchers flushed
+// on reload deliver their events as configured when they were added. Must be
+// called with p.mu held, after the formatters of c are installed.
+func (p *Pipeline) newBatchers(c *config.Config, r *router.Router) map[int]*batcher.Batcher {
+	batchers := make(map[int]*batcher.Batcher)
+	formatting := p.c.batchFormatting(r)
+	newBatcher := func(key int, cfg batcher.Config, opts formatter.BatchOptions) *batcher.Batcher {
+		return batcher.NewBatcher(cfg, func(batch *batcher.Batch) {
+			if p.hooks.OnBatch != nil {
+				p.hooks.OnBatch(batch)
+			}
+			p.deliverSplitBatch("batch", batch, opts, func(events []*watcher.Event, destinations []string) map[string][]*watcher.Event {
+				return splitBatch(r, c.Routes, key, events, destinations)
+			}, formatting)
+		})
+	}
+
+	batchConfig := batcher.Config{
+		Enabled:       true,
+		WindowSeconds: c.Batching.WindowSeconds,
+		WindowType:    batcher.WindowType(c.Batching.WindowType),
+		Mode:          batcher.BatchMode(c.Batching.Mode),
+		Smart: batcher.SmartConfig{
+			MaxEventsPerGroup: c.Batching.Smart.MaxEventsPerGroup,
+			MaxTotalEvents:    c.Batching.Smart.MaxTotalEvents,
+			AlwaysShowDetails: c.Batching.Smart.AlwaysShowDetails,
+		},
+	}
+	if c.Batching.Enabled {
+		global := batchConfig
+		if c.Batching.SpoolPath != "" {
+			global.Store = batcher.NewFileStore(c.Batching.SpoolPath)
+		}
+		batchers[globalBatch] = newBatcher(globalBatch, global, batchOptions(c))
+	}
+
+	for i, route := range c.Routes {
+		if !route.OwnBatching() {
+			continue
+		}
+		routeConfig := batchConfig
+		routeConfig.WindowSeconds = route.Batching.WindowSeconds
+		routeConfig.Mode = batcher.BatchMode(route.Batching.Mode)
+		if routeConfig.WindowType == "" {
+			routeConfig.WindowType = batcher.WindowSliding
+		}
+		if c.Batching.SpoolPath != "" {
+			routeConfig.Store = batcher.NewFileStore(fmt.Sprintf("%s.route-%d", c.Batching.SpoolPath, i))
+		}
+		opts := batchOptions(c)
+		opts.Mode = formatter.BatchMode(route.Batching.Mode)
+		batchers[i] = newBatcher(i, routeConfig, opts)
+		slog.Info("Route batching enabled", "route", routeName(c.Routes, i), "windowSeconds", route.Batching.WindowSeconds, "mode", route.Batching.Mode)
+	}
+	return batchers
+}
+
+// stopBatchers stops the batchers, flushing their pending events
+func stopBatchers(batchers map[int]*batcher.Batcher) {
+	for _, b := range batchers {
+		b.Stop()
+	}
+}
+
+// routeBatchers returns the batchers of the routes batching in their own window by route name
+func (c components) routeBatchers() map[string]*batcher.Batcher {
+	batchers := make(map[string]*batcher.Batcher)
+	for key, b := range c.batchers {
+		if key != globalBatch {
+			batchers[routeName(c.config.Routes, key)] = b
+		}
+	}
+	return batchers
+}
+
+// batchDestinations assigns the selected destinations of an event to the
+// batchers collecting their events, by batch key. Destinations whose events
+// are not batched are returned separately.
+func (c components) batchDestinations(destinations router.Selection) (batched map[int][]string, unbatched []string) {
+	batched = make(map[int][]string)
+	for _, name := range destinationNames(c.slack, c.sinks) {
+		if !destinations.Includes(name) {
+			continue
+		}
+		key := batchKey(c.config.Routes, destinations.Route(name))
+		if c.batchers[key] == nil {
+			unbatched = append(unbatched, name)
+			continue
+		}
+		batched[key] = append(batched[key], name)
+	}
+	return batched, unbatched
+}
+
+// addToBatchers adds the event to the batchers with the keys. Each batcher
+// gets its own copy, as batchers merge the changes of repeated updates into
+// the events they hold.
+func (c components) addToBatchers(keys map[int][]string, event *watcher.Event) {
+	sorted := make([]int, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Ints(sorted)
+	for i, key := range sorted {
+		added := event
+		if i > 0 {
+			copied := *event
+			added = &copied
+		}
+		c.batchers[key].Add(added)
+	}
+}
+
+// splitBatch groups the events of the batcher with the key by the
+// destinations they are sent to through the routes batched by it
+func splitBatch(r *router.Router, routes []config.RouteConfig, key int, events []*watcher.Event, destinations []string) map[string][]*watcher.Event {
+	groups := make(map[string][]*watcher.Event)
+	for _, event := range events {
+		sel := r.Route(event)
+		for _, name := range destinations {
+			if sel.Includes(name) && batchKey(routes, sel.Route(name)) == key {
+				groups[name] = append(groups[name], event)
+			}
+		}
+	}
+	return groups
+}
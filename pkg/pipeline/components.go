@@ -0,0 +1,738 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/correlation"
+	"github.com/kqns91/kube-watcher/pkg/dedup"
+	"github.com/kqns91/kube-watcher/pkg/escalation"
+	"github.com/kqns91/kube-watcher/pkg/filter"
+	"github.com/kqns91/kube-watcher/pkg/formatter"
+	"github.com/kqns91/kube-watcher/pkg/maintenance"
+	"github.com/kqns91/kube-watcher/pkg/nodedrain"
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/router"
+	"github.com/kqns91/kube-watcher/pkg/severity"
+	"github.com/kqns91/kube-watcher/pkg/silence"
+	"github.com/kqns91/kube-watcher/pkg/storm"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// apply builds the components of a configuration. The new pipeline is built
+// completely before it replaces the current one, so a failing step leaves the
+// running configuration untouched.
+func (p *Pipeline) apply(c *config.Config) error {
+	// Initialize formatter
+	newFmt, err := NewFormatter(c)
+	if err != nil {
+		return err
+	}
+
+	// Initialize router
+	newRouter, err := router.NewRouter(c.Routes)
+	if err != nil {
+		return err
+	}
+	newRouteFormats, err := newRouteFormats(c)
+	if err != nil {
+		return err
+	}
+
+	if err := silence.Validate(c.Silences); err != nil {
+		return err
+	}
+	newWindows, err := maintenance.NewWindows(c.Maintenance)
+	if err != nil {
+		return err
+	}
+
+	newEscalations, err := escalation.NewRules(c.Escalations)
+	if err != nil {
+		return err
+	}
+
+	newHeartbeat, err := newHeartbeatSchedule(c.Heartbeat)
+	if err != nil {
+		return err
+	}
+
+	newEnricher, err := filter.NewEnricher(c.Enrichment)
+	if err != nil {
+		return err
+	}
+
+	// Initialize deduplication bypass matcher
+	newBypass, err := filter.NewEventMatcher(c.Deduplication.NeverDedupe)
+	if err != nil {
+		return err
+	}
+
+	var newSeverity *severity.Classifier
+	if c.Severity.Enabled {
+		if newSeverity, err = severity.NewClassifier(c.Severity); err != nil {
+			return err
+		}
+	}
+
+	// Critical events are tracked when the acknowledgement store was opened
+	var newCritical *filter.EventMatcher
+	if c.Acks.Enabled && p.acks != nil {
+		if newCritical, err = filter.NewEventMatcher(c.Acks.Critical); err != nil {
+			return err
+		}
+	}
+
+	// Initialize notifiers (Slack is optional when other notifiers are enabled).
+	// Circuit breakers are kept by notifier name, so that a reload neither closes
+	// the circuit of a failing destination nor resets its dropped count.
+	cb := c.Notifier.CircuitBreaker
+	breakerThreshold, breakerCoolDown := cb.FailureThreshold, time.Duration(cb.CoolDownSeconds)*time.Second
+	prevBreakers := make(map[string]*notifier.CircuitBreaker)
+	for _, b := range p.current().breakers {
+		prevBreakers[b.Name()] = b
+	}
+	var newBreakers []*notifier.CircuitBreaker
+	newBreaker := func(name string) *notifier.CircuitBreaker {
+		if !cb.Enabled {
+			return nil
+		}
+		b := prevBreakers[name]
+		if b != nil {
+			delete(prevBreakers, name)
+		} else {
+			b = notifier.NewCircuitBreaker(name, breakerThreshold, breakerCoolDown, p.reportNotifierHealth)
+		}
+		newBreakers = append(newBreakers, b)
+		return b
+	}
+
+	var dryRun *notifier.DryRun
+	if c.DryRun {
+		dryRun = p.dryRun
+	}
+	newSlack, err := newSlackNotifiers(c, newBreaker, dryRun)
+	if err != nil {
+		return err
+	}
+
+	var newSinks []notifier.EventNotifier
+	if dryRun != nil {
+		newSinks = newDryRunSinks(c, dryRun)
+	} else if newSinks, err = newEventNotifiers(c); err != nil {
+		return err
+	}
+	if hc := c.Notifier.HealthCheck; hc.Enabled && dryRun == nil {
+		if failures := checkNotifierHealth(newSlack, newSinks, hc.TestMessage); len(failures) > 0 {
+			if hc.OnFailure == "fail" {
+				closeNotifiers(newSinks)
+				return healthCheckError(failures)
+			}
+			slog.Warn("Notifier health check failed", "error", healthCheckError(failures))
+			// Report through the Slack destinations that passed the check
+			for _, d := range newSlack {
+				if failures[d.name] != nil {
+					continue
+				}
+				for name, cause := range failures {
+					text := ":rotating_light: Notifier *" + name + "* failed the health check: " + cause.Error()
+					if err := d.notifier.Send(text); err != nil {
+						slog.Error("Failed to send health check alert", "destination", d.name, "error", err)
+					}
+				}
+			}
+		} else {
+			slog.Info("Notifier health check passed")
+		}
+	}
+
+	retryPolicy := newRetryPolicy(c)
+	for i, n := range newSinks {
+		newSinks[i] = notifier.WithCircuitBreaker(notifier.WithRetry(n, retryPolicy), newBreaker(n.Name()))
+	}
+	newDeadLetters, err := newDeadLetterQueue(c, newSinks)
+	if err != nil {
+		closeNotifiers(newSinks)
+		return err
+	}
+
+	// The event recorder is kept across reloads
+	prevRecorder := p.current().recorder
+	newRecorder := prevRecorder
+	if !c.K8sEvents.Enabled || c.DryRun {
+		newRecorder = nil
+	} else if newRecorder == nil {
+		if newRecorder, err = watcher.NewEventRecorder(); err != nil {
+			closeNotifiers(newSinks)
+			_ = newDeadLetters.Close()
+			return err
+		}
+		slog.Info("Recording Kubernetes Events for notifications", "reason", watcher.NotifiedReason)
+	}
+
+	// Everything that can fail has succeeded: replace the running components.
+	// The managers report through the components, so they are updated before the lock is taken.
+	if err := p.silences.SetConfigured(c.Silences); err != nil {
+		closeNotifiers(newSinks)
+		_ = newDeadLetters.Close()
+		if newRecorder != prevRecorder {
+			newRecorder.Stop()
+		}
+		return err
+	}
+	p.maintenance.SetWindows(newWindows)
+	p.escalations.SetRules(newEscalations)
+	p.heartbeat.SetSchedule(newHeartbeat)
+	if p.hooks.OnConfig != nil {
+		p.hooks.OnConfig(c)
+	}
+	if c.DryRun {
+		slog.Info("Dry-run mode: notifications are printed to stdout instead of being sent")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.c.config = c
+	p.c.cluster = c.ClusterName
+	if p.c.cluster == "" {
+		p.c.cluster = p.detectedCluster
+	}
+
+	for _, d := range newSlack {
+		for _, prev := range p.c.slack {
+			if prev.name == d.name {
+				d.notifier.InheritThreads(prev.notifier)
+			}
+		}
+	}
+	closeNotifiers(p.c.sinks)
+	if err := p.c.deadLetters.Close(); err != nil {
+		slog.Error("Failed to close dead-letter file", "error", err)
+	}
+	p.c.formatter = newFmt
+	p.c.router = newRouter
+	p.c.routeFormats = newRouteFormats
+	p.c.enricher = newEnricher
+	p.c.dedupBypass = newBypass
+	p.c.critical = newCritical
+	p.c.severity = newSeverity
+	p.c.slack = newSlack
+	p.c.sinks = newSinks
+	// Kept breakers take the new settings once the reload can no longer fail.
+	// The drops of removed breakers stay counted in the dropped total.
+	for _, b := range newBreakers {
+		b.SetPolicy(breakerThreshold, breakerCoolDown)
+	}
+	for _, b := range prevBreakers {
+		p.removedDropped.Add(b.Dropped())
+	}
+	p.c.breakers = newBreakers
+	p.c.deadLetters = newDeadLetters
+	if p.c.recorder != newRecorder {
+		p.c.recorder.Stop()
+	}
+	p.c.recorder = newRecorder
+
+	// Initialize filter
+	p.c.filter = filter.NewFilter(c)
+
+	// Initialize or update deduplicator
+	if c.Deduplication.Enabled {
+		if p.c.dedup != nil {
+			p.c.dedup.Stop()
+		}
+		ttl := time.Duration(c.Deduplication.TTLSeconds) * time.Second
+		p.c.dedup = dedup.NewDeduplicator(ttl, c.Deduplication.MaxCacheSize)
+		for _, o := range c.Deduplication.Overrides {
+			kindTTL := time.Duration(o.TTLSeconds) * time.Second
+			p.c.dedup.SetKindTTL(o.Kind, kindTTL)
+			slog.Info("Deduplication TTL override", "kind", o.Kind, "ttl", kindTTL)
+		}
+		slog.Info("Deduplication enabled", "ttl", ttl, "maxCacheSize", c.Deduplication.MaxCacheSize)
+	} else if p.c.dedup != nil {
+		p.c.dedup.Stop()
+		p.c.dedup = nil
+		slog.Info("Deduplication disabled")
+	}
+
+	// The previous drain and storm detectors, correlator and batcher are flushed
+	// after the lock is released, because their handlers read the components.
+	// Events arriving until the new ones are installed are sent immediately.
+	prevDrains := p.c.drains
+	p.c.drains = nil
+	prevStorms := p.c.storms
+	p.c.storms = nil
+	prevCorrelator := p.c.correlator
+	p.c.correlator = nil
+	prevBatchers := p.c.batchers
+	p.c.batchers = nil
+	p.mu.Unlock()
+	if prevDrains != nil {
+		prevDrains.Stop()
+	}
+	if prevStorms != nil {
+		prevStorms.Stop()
+	}
+	if prevCorrelator != nil {
+		prevCorrelator.Stop()
+	}
+	stopBatchers(prevBatchers)
+	p.mu.Lock()
+
+	// Initialize node drain detection
+	if c.NodeDrains.Enabled {
+		window := time.Duration(c.NodeDrains.WindowSeconds) * time.Second
+		p.c.drains = nodedrain.NewDetector(window, c.NodeDrains.MinEvictions, p.deliverDrain)
+		slog.Info("Node drain detection enabled", "window", window, "minEvictions", c.NodeDrains.MinEvictions)
+	} else if prevDrains != nil {
+		slog.Info("Node drain detection disabled")
+	}
+
+	// Initialize storm detection
+	if c.Storm.Enabled {
+		window := time.Duration(c.Storm.WindowSeconds) * time.Second
+		p.c.storms = p.newStormDetector(window, storm.Config{
+			Window:          window,
+			Factor:          c.Storm.Factor,
+			MinEvents:       c.Storm.MinEvents,
+			SummaryInterval: time.Duration(c.Storm.SummaryIntervalSeconds) * time.Second,
+		})
+		slog.Info("Storm detection enabled", "window", window, "factor", c.Storm.Factor, "minEvents", c.Storm.MinEvents)
+	} else if prevStorms != nil {
+		slog.Info("Storm detection disabled")
+	}
+
+	// Initialize correlator
+	if c.Correlation.Enabled {
+		window := time.Duration(c.Correlation.WindowSeconds) * time.Second
+		maxWindow := time.Duration(c.Correlation.MaxWindowSeconds) * time.Second
+		p.c.correlator = correlation.NewCorrelator(window, maxWindow, p.deliverStory)
+		slog.Info("Correlation enabled", "window", window, "maxWindow", maxWindow)
+	} else if prevCorrelator != nil {
+		slog.Info("Correlation disabled")
+	}
+
+	// Initialize the global batcher and the batchers of the routes batching in their own window
+	p.c.batchers = p.newBatchers(c, newRouter)
+	if c.Batching.Enabled {
+		slog.Info("Batching enabled", "windowSeconds", c.Batching.WindowSeconds, "windowType", c.Batching.WindowType, "mode", c.Batching.Mode)
+	} else if prevBatchers[globalBatch] != nil {
+		slog.Info("Batching disabled")
+	}
+
+	return nil
+}
+
+// NewFormatter creates a formatter configured from the Slack notifier settings
+func NewFormatter(c *config.Config) (*formatter.Formatter, error) {
+	f, err := formatter.NewFormatter(c.Notifier.Slack.Template)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.SetLocale(c.Notifier.Slack.Locale, c.Notifier.Slack.Labels); err != nil {
+		return nil, err
+	}
+	links := make([]formatter.Link, 0, len(c.Links))
+	for _, l := range c.Links {
+		links = append(links, formatter.Link{Name: l.Name, URL: l.URL})
+	}
+	if err := f.SetLinks(links); err != nil {
+		return nil, err
+	}
+	f.SetMaxDiffLines(*c.Notifier.Slack.MaxDiffLines)
+	if err := f.SetThreadKey(c.Notifier.Slack.Threading.GroupKey); err != nil {
+		return nil, err
+	}
+	var mentions []formatter.MentionRule
+	for _, m := range c.Notifier.Slack.Mentions {
+		matcher, err := filter.NewEventMatcher(m.MatcherConfig)
+		if err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, formatter.MentionRule{Mention: m.Mention, Matcher: matcher})
+	}
+	f.SetMentions(mentions)
+	f.SetLimits(formatter.Limits{
+		MaxFields:     c.Notifier.Slack.Limits.MaxFields,
+		MaxContainers: c.Notifier.Slack.Limits.MaxContainers,
+	})
+	for eventType, tmpl := range c.Notifier.Slack.Templates {
+		if err := f.SetEventTypeTemplate(eventType, tmpl); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// routeFormatting is the Slack formatting of a route overriding notifier.slack
+type routeFormatting struct {
+	formatter *formatter.Formatter
+	format    formatter.OutputFormat
+}
+
+// newRouteFormats creates the formatters of the routes overriding the Slack
+// formatting, by route index. A route setting a template also replaces the
+// per-event-type templates, so that its template is used for all events.
+func newRouteFormats(c *config.Config) (map[int]routeFormatting, error) {
+	formats := make(map[int]routeFormatting)
+	for i, r := range c.Routes {
+		if !r.HasFormatting() {
+			continue
+		}
+		routeConfig := *c
+		if r.Template != "" || len(r.Templates) > 0 {
+			routeConfig.Notifier.Slack.Template = r.Template
+			routeConfig.Notifier.Slack.Templates = r.Templates
+		}
+		if r.Format != "" {
+			routeConfig.Notifier.Slack.Format = r.Format
+		}
+		f, err := NewFormatter(&routeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("routes[%d]: %w", i, err)
+		}
+		formats[i] = routeFormatting{formatter: f, format: formatter.OutputFormat(routeConfig.Notifier.Slack.Format)}
+	}
+	return formats, nil
+}
+
+// slackFormatting returns the formatter and output format of the route with
+// the index, or the default ones of notifier.slack
+func (c components) slackFormatting(route int) (*formatter.Formatter, formatter.OutputFormat) {
+	if f, ok := c.routeFormats[route]; ok {
+		return f.formatter, f.format
+	}
+	return c.formatter, formatter.OutputFormat(c.config.Notifier.Slack.Format)
+}
+
+// slackDestination is a Slack notifier that routes can send to
+type slackDestination struct {
+	name     string
+	notifier *notifier.SlackNotifier
+}
+
+// newSlackNotifiers creates the default Slack notifier ("slack") and the additional
+// Slack destinations. All of them share the default Slack formatting settings.
+// newBreaker returns the circuit breaker for a destination, or nil.
+// In dry-run mode, messages are written to dryRun and the default Slack notifier
+// is created even without a webhook URL.
+func newSlackNotifiers(c *config.Config, newBreaker func(name string) *notifier.CircuitBreaker, dryRun *notifier.DryRun) ([]slackDestination, error) {
+	slack := c.Notifier.Slack
+	var destinations []slackDestination
+
+	add := func(name, webhookURL, botToken, channel string, rateLimit config.RateLimitConfig, timeouts config.TimeoutConfig) error {
+		var n *notifier.SlackNotifier
+		switch {
+		case botToken != "":
+			n = notifier.NewSlackBotNotifier(botToken, channel)
+			if slack.Threading.Enabled {
+				n.EnableThreading(time.Duration(slack.Threading.TTLMinutes) * time.Minute)
+			}
+		case webhookURL != "", dryRun != nil:
+			n = notifier.NewSlackNotifier(webhookURL)
+		default:
+			return nil
+		}
+		if dryRun != nil {
+			n.SetDryRun(dryRun, name)
+		}
+		httpClient, err := newHTTPClient(c, timeouts)
+		if err != nil {
+			return err
+		}
+		n.SetHTTPClient(httpClient)
+		n.SetMaxMessageBytes(slack.Limits.MaxMessageBytes)
+		n.SetRateLimit(rateLimit.MessagesPerMinute, rateLimit.Burst)
+		if slack.Coalesce.Enabled {
+			n.SetCoalesceInterval(time.Duration(slack.Coalesce.IntervalSeconds) * time.Second)
+		}
+		n.SetRetryPolicy(newRetryPolicy(c))
+		n.SetCircuitBreaker(newBreaker(name))
+		destinations = append(destinations, slackDestination{name: name, notifier: n})
+		return nil
+	}
+
+	if err := add("slack", slack.WebhookURL, slack.BotToken, slack.Channel, slack.RateLimit, slack.TimeoutConfig); err != nil {
+		return nil, err
+	}
+	for _, d := range slack.Destinations {
+		if err := add(d.Name, d.WebhookURL, d.BotToken, d.Channel, d.RateLimit, d.TimeoutConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return destinations, nil
+}
+
+// newHTTPClient creates the HTTP client of a single notifier with its own timeouts
+func newHTTPClient(c *config.Config, timeouts config.TimeoutConfig) (*http.Client, error) {
+	h := c.Notifier.HTTP
+	return notifier.NewHTTPClient(notifier.HTTPConfig{
+		ProxyURL:       h.ProxyURL,
+		NoProxy:        h.NoProxy,
+		CAFile:         h.TLS.CAFile,
+		CertFile:       h.TLS.CertFile,
+		KeyFile:        h.TLS.KeyFile,
+		ConnectTimeout: time.Duration(timeouts.ConnectTimeoutSeconds) * time.Second,
+	}, time.Duration(timeouts.TimeoutSeconds)*time.Second)
+}
+
+// newDryRunSinks creates dry-run stand-ins for the enabled notifiers other than Slack
+func newDryRunSinks(c *config.Config, dryRun *notifier.DryRun) []notifier.EventNotifier {
+	slack := map[string]bool{"slack": true}
+	for _, d := range c.Notifier.Slack.Destinations {
+		slack[d.Name] = true
+	}
+
+	var sinks []notifier.EventNotifier
+	for _, name := range c.Notifier.DestinationNames() {
+		if !slack[name] {
+			sinks = append(sinks, dryRun.Notifier(name))
+		}
+	}
+	return sinks
+}
+
+// newRetryPolicy converts the retry settings to a notifier.RetryPolicy
+func newRetryPolicy(c *config.Config) notifier.RetryPolicy {
+	r := c.Notifier.Retry
+	return notifier.RetryPolicy{
+		MaxAttempts:     r.MaxAttempts,
+		InitialInterval: time.Duration(r.InitialBackoffMs) * time.Millisecond,
+		MaxInterval:     time.Duration(r.MaxBackoffSeconds) * time.Second,
+		MaxElapsed:      time.Duration(r.MaxElapsedSeconds) * time.Second,
+	}
+}
+
+// newEventNotifiers creates the enabled notifiers that receive structured event payloads
+func newEventNotifiers(c *config.Config) ([]notifier.EventNotifier, error) {
+	var sinks []notifier.EventNotifier
+
+	if sns := c.Notifier.SNS; sns.Enabled {
+		httpClient, err := newHTTPClient(c, sns.TimeoutConfig)
+		if err != nil {
+			return nil, err
+		}
+		n, err := notifier.NewSNSNotifier(notifier.SNSConfig{
+			TopicARN: sns.TopicARN,
+			Endpoint: sns.Endpoint,
+			Auth: notifier.AWSAuthConfig{
+				Region:          sns.Region,
+				AccessKeyID:     sns.AccessKeyID,
+				SecretAccessKey: sns.SecretAccessKey,
+				SessionToken:    sns.SessionToken,
+				RoleARN:         sns.RoleARN,
+			},
+			HTTPClient: httpClient,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, n)
+	}
+
+	if sqs := c.Notifier.SQS; sqs.Enabled {
+		httpClient, err := newHTTPClient(c, sqs.TimeoutConfig)
+		if err != nil {
+			return nil, err
+		}
+		n, err := notifier.NewSQSNotifier(notifier.SQSConfig{
+			QueueURL: sqs.QueueURL,
+			Endpoint: sqs.Endpoint,
+			Auth: notifier.AWSAuthConfig{
+				Region:          sqs.Region,
+				AccessKeyID:     sqs.AccessKeyID,
+				SecretAccessKey: sqs.SecretAccessKey,
+				SessionToken:    sqs.SessionToken,
+				RoleARN:         sqs.RoleARN,
+			},
+			HTTPClient: httpClient,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, n)
+	}
+
+	if c.Notifier.Stdout.Enabled {
+		sinks = append(sinks, notifier.NewStdoutNotifier(os.Stdout))
+	}
+
+	if nats := c.Notifier.NATS; nats.Enabled {
+		n, err := notifier.NewNATSNotifier(notifier.NATSConfig{
+			URL:       nats.URL,
+			Subject:   nats.Subject,
+			Token:     nats.Token,
+			User:      nats.User,
+			Password:  nats.Password,
+			JetStream: nats.JetStream,
+			Timeout:   time.Duration(nats.TimeoutSeconds) * time.Second,
+			CAFile:    nats.TLS.CAFile,
+			CertFile:  nats.TLS.CertFile,
+			KeyFile:   nats.TLS.KeyFile,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, n)
+	}
+
+	if file := c.Notifier.File; file.Enabled {
+		cfg := notifier.FileConfig{
+			Path:         file.Path,
+			MaxSizeBytes: int64(file.MaxSizeMB) * 1024 * 1024,
+			MaxBackups:   *file.MaxBackups,
+		}
+		if file.Format == "text" {
+			f, err := formatter.NewFormatter(file.Template)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Render = f.Format
+		}
+
+		n, err := notifier.NewFileNotifier(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, n)
+	}
+
+	if wh := c.Notifier.Webhook; wh.Enabled {
+		httpClient, err := newHTTPClient(c, wh.TimeoutConfig)
+		if err != nil {
+			return nil, err
+		}
+		cfg := notifier.WebhookConfig{
+			URL:         wh.URL,
+			Headers:     wh.Headers,
+			BearerToken: SecretSource(wh.BearerToken),
+			Username:    wh.BasicAuth.Username,
+			Password:    SecretSource(wh.BasicAuth.Password),
+			HTTPClient:  httpClient,
+		}
+		if wh.Signing.Secret.IsSet() {
+			cfg.Signing = &notifier.WebhookSigning{
+				Secret:          SecretSource(wh.Signing.Secret),
+				SignatureHeader: wh.Signing.SignatureHeader,
+				TimestampHeader: wh.Signing.TimestampHeader,
+			}
+		}
+
+		n, err := notifier.NewWebhookNotifier(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, n)
+	}
+
+	if am := c.Notifier.Alertmanager; am.Enabled {
+		httpClient, err := newHTTPClient(c, am.TimeoutConfig)
+		if err != nil {
+			return nil, err
+		}
+		n, err := notifier.NewAlertmanagerNotifier(notifier.AlertmanagerConfig{
+			URL:          am.URL,
+			AlertName:    am.AlertName,
+			Labels:       am.Labels,
+			ResolveAfter: time.Duration(am.ResolveAfterMinutes) * time.Minute,
+			GeneratorURL: am.GeneratorURL,
+			BearerToken:  SecretSource(am.BearerToken),
+			Username:     am.BasicAuth.Username,
+			Password:     SecretSource(am.BasicAuth.Password),
+			HTTPClient:   httpClient,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, n)
+	}
+
+	for _, plugin := range c.Notifier.Plugins {
+		n, err := newPlugin(c, plugin)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, n)
+	}
+
+	return sinks, nil
+}
+
+// newPlugin creates the notifier of a plugin
+func newPlugin(c *config.Config, plugin config.PluginConfig) (notifier.EventNotifier, error) {
+	if plugin.Type == config.PluginExec {
+		return notifier.NewExecPlugin(notifier.ExecPluginConfig{
+			Name:    plugin.Name,
+			Command: plugin.Command,
+			Env:     plugin.Env,
+			Timeout: time.Duration(plugin.TimeoutSeconds) * time.Second,
+		})
+	}
+
+	httpClient, err := newHTTPClient(c, plugin.TimeoutConfig)
+	if err != nil {
+		return nil, err
+	}
+	return notifier.NewHTTPPlugin(notifier.HTTPPluginConfig{
+		Name:        plugin.Name,
+		URL:         plugin.URL,
+		HealthURL:   plugin.HealthURL,
+		Headers:     plugin.Headers,
+		BearerToken: SecretSource(plugin.BearerToken),
+		HTTPClient:  httpClient,
+	})
+}
+
+// SecretSource converts a secret reference from the configuration
+func SecretSource(s config.SecretConfig) notifier.SecretSource {
+	return notifier.SecretSource{Value: s.Value, Env: s.Env, File: s.File}
+}
+
+// closeNotifiers releases connections held by replaced notifiers
+func closeNotifiers(sinks []notifier.EventNotifier) {
+	for _, n := range sinks {
+		if closer, ok := n.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				slog.Error("Failed to close notifier", "notifier", n.Name(), "error", err)
+			}
+		}
+	}
+}
+
+// newDeadLetterQueue creates the dead-letter queue, forwarding to the named sink if configured
+func newDeadLetterQueue(c *config.Config, sinks []notifier.EventNotifier) (*notifier.DeadLetterQueue, error) {
+	dl := c.Notifier.DeadLetter
+	if dl.Path == "" && dl.Destination == "" {
+		return nil, nil
+	}
+
+	var file *notifier.FileNotifier
+	if dl.Path != "" {
+		var err error
+		file, err = notifier.NewFileNotifier(notifier.FileConfig{
+			Path:         dl.Path,
+			MaxSizeBytes: int64(dl.MaxSizeMB) * 1024 * 1024,
+			MaxBackups:   *dl.MaxBackups,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var fallback notifier.EventNotifier
+	for _, n := range sinks {
+		if n.Name() == dl.Destination {
+			fallback = n
+		}
+	}
+
+	return notifier.NewDeadLetterQueue(file, fallback), nil
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// maxCrashLoopPods is the number of crash-looping Pods named in a report
+const maxCrashLoopPods = 10
+
+// crashLoopMonitor reports the crash-looping Pods of each workload as one
+// aggregated warning, repeated every interval while any Pod of the workload
+// is crash-looping, instead of a notification per Pod
+type crashLoopMonitor struct {
+	interval time.Duration
+	emit     func(event *watcher.Event)
+	now      func() time.Time
+
+	reported map[string]time.Time // Kind/namespace/name of the workload -> last report
+}
+
+// newCrashLoopMonitor creates a crashLoopMonitor passing the reports to emit
+func newCrashLoopMonitor(cfg config.CrashLoopsConfig, emit func(event *watcher.Event)) *crashLoopMonitor {
+	return &crashLoopMonitor{
+		interval: time.Duration(cfg.IntervalMinutes) * time.Minute,
+		emit:     emit,
+		now:      time.Now,
+		reported: make(map[string]time.Time),
+	}
+}
+
+// workloadPods are the Pods of a workload
+type workloadPods struct {
+	kind, namespace, name string
+	total                 int
+	crashing              []string // Names of the crash-looping Pods
+	restarts              int32
+}
+
+// check reports the workloads with crash-looping Pods that were not reported
+// within the interval
+func (m *crashLoopMonitor) check(pods []*watcher.Event) {
+	now := m.now()
+	workloads := make(map[string]*workloadPods)
+	var keys []string
+	for _, pod := range pods {
+		kind, name, ok := podWorkload(pod)
+		if !ok {
+			continue
+		}
+		key := kind + "/" + pod.Namespace + "/" + name
+		w := workloads[key]
+		if w == nil {
+			w = &workloadPods{kind: kind, namespace: pod.Namespace, name: name}
+			workloads[key] = w
+			keys = append(keys, key)
+		}
+		w.total++
+		for _, c := range pod.Containers {
+			w.restarts += c.Restarts
+		}
+		if crashLooping(pod) {
+			w.crashing = append(w.crashing, pod.Name)
+		}
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		w := workloads[key]
+		if len(w.crashing) == 0 {
+			continue
+		}
+		if last, ok := m.reported[key]; ok && now.Sub(last) < m.interval {
+			continue
+		}
+		m.reported[key] = now
+		m.emit(crashLoopReport(w, now))
+	}
+	for key := range m.reported {
+		if w := workloads[key]; w == nil || len(w.crashing) == 0 {
+			delete(m.reported, key)
+		}
+	}
+}
+
+// crashLoopReport returns the aggregated warning about the crash-looping Pods of a workload
+func crashLoopReport(w *workloadPods, now time.Time) *watcher.Event {
+	message := fmt.Sprintf("%s: %d/%d pods crash-looping, restarts: %d", w.name, len(w.crashing), w.total, w.restarts)
+	named := w.crashing[:min(len(w.crashing), maxCrashLoopPods)]
+	message += "\nPods: " + strings.Join(named, ", ")
+	if more := len(w.crashing) - len(named); more > 0 {
+		message += fmt.Sprintf(" and %d more", more)
+	}
+	return &watcher.Event{
+		Kind:      w.kind,
+		Namespace: w.namespace,
+		Name:      w.name,
+		EventType: watcher.EventTypeWarning,
+		Timestamp: now,
+		Reason:    watcher.ReasonCrashLoopBackOff,
+		Message:   message,
+	}
+}
+
+// crashLooping reports whether a container of the Pod keeps crashing: it is
+// in CrashLoopBackOff, or was restarted after being OOMKilled
+func crashLooping(pod *watcher.Event) bool {
+	for _, c := range pod.Containers {
+		if c.Reason == watcher.ReasonCrashLoopBackOff || (c.Reason == watcher.ReasonOOMKilled && c.Restarts > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// podWorkload returns the workload controlling the Pod: the Deployment of its
+// ReplicaSet, or its controller
+func podWorkload(pod *watcher.Event) (kind, name string, ok bool) {
+	if pod.Owner == nil {
+		return "", "", false
+	}
+	kind, name = pod.Workload()
+	return kind, name, true
+}
@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func testPod(name, owner string, restarts int32, reason string) *watcher.Event {
+	return &watcher.Event{Kind: "Pod", Namespace: "prod", Name: name, Owner: &watcher.OwnerRef{Kind: "ReplicaSet", Name: owner},
+		Containers: []watcher.ContainerInfo{{Name: "app", Restarts: restarts, Reason: reason}}}
+}
+
+func TestCrashLoopMonitor(t *testing.T) {
+	var reports []*watcher.Event
+	m := newCrashLoopMonitor(config.CrashLoopsConfig{IntervalMinutes: 10}, func(e *watcher.Event) {
+		reports = append(reports, e)
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	pods := []*watcher.Event{
+		testPod("web-app-7d9f-a", "web-app-7d9f", 12, watcher.ReasonCrashLoopBackOff),
+		testPod("web-app-7d9f-b", "web-app-7d9f", 10, watcher.ReasonOOMKilled),
+		testPod("web-app-7d9f-c", "web-app-7d9f", 0, ""),
+		testPod("api-5c6b-a", "api-5c6b", 1, ""),
+		// コントローラのないPodは集約しない
+		{Kind: "Pod", Namespace: "prod", Name: "debug", Containers: []watcher.ContainerInfo{{Reason: watcher.ReasonCrashLoopBackOff}}},
+	}
+	m.check(pods)
+	if len(reports) != 1 {
+		t.Fatalf("Expected one report, got %d", len(reports))
+	}
+	r := reports[0]
+	if r.Kind != "Deployment" || r.Name != "web-app" || r.EventType != watcher.EventTypeWarning || r.Reason != watcher.ReasonCrashLoopBackOff {
+		t.Errorf("Unexpected report %+v", r)
+	}
+	if want := "web-app: 2/3 pods crash-looping, restarts: 22\nPods: web-app-7d9f-a, web-app-7d9f-b"; r.Message != want {
+		t.Errorf("Message = %q, want %q", r.Message, want)
+	}
+
+	// 間隔内は再報告しない
+	now = now.Add(5 * time.Minute)
+	m.check(pods)
+	if len(reports) != 1 {
+		t.Fatalf("Expected no report within the interval, got %d", len(reports))
+	}
+	now = now.Add(5 * time.Minute)
+	m.check(pods)
+	if len(reports) != 2 {
+		t.Fatalf("Expected a report after the interval, got %d", len(reports))
+	}
+
+	// 回復したワークロードは次にクラッシュしたときにすぐ報告する
+	m.check(pods[2:])
+	if len(m.reported) != 0 {
+		t.Errorf("Expected the recovered workload to be forgotten, got %v", m.reported)
+	}
+	m.check(pods)
+	if len(reports) != 3 {
+		t.Errorf("Expected an immediate report after recovering, got %d", len(reports))
+	}
+}
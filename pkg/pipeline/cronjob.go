@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/maintenance"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// cronMacros are the schedule shorthands accepted by Kubernetes
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronMonitor synthesizes warning events for CronJobs that missed a scheduled
+// run and for their Jobs that run for too long. Neither shows up as a change of
+// a resource: nothing happens when a run is missed. Each missed run and each
+// long-running Job is warned about once.
+type cronMonitor struct {
+	grace  time.Duration
+	maxRun time.Duration
+	emit   func(event *watcher.Event)
+	now    func() time.Time
+
+	missed      map[string]time.Time // Namespace/name of the CronJob -> missed run warned about
+	longRunning map[string]bool      // Namespace/name of the Job -> warned about
+}
+
+// newCronMonitor creates a cronMonitor passing the warnings to emit
+func newCronMonitor(cfg config.CronJobsConfig, emit func(event *watcher.Event)) *cronMonitor {
+	return &cronMonitor{
+		grace:       time.Duration(cfg.MissedScheduleGraceSeconds) * time.Second,
+		maxRun:      time.Duration(cfg.MaxRunMinutes) * time.Minute,
+		emit:        emit,
+		now:         time.Now,
+		missed:      make(map[string]time.Time),
+		longRunning: make(map[string]bool),
+	}
+}
+
+// check warns about the missed runs and long-running Jobs found since the last check
+func (m *cronMonitor) check(cronJobs []*watcher.Event) {
+	now := m.now()
+	seen := make(map[string]bool)
+	running := make(map[string]bool)
+	for _, cj := range cronJobs {
+		info := cj.CronJob
+		if info == nil || info.Suspended {
+			continue
+		}
+		key := cj.Namespace + "/" + cj.Name
+		seen[key] = true
+
+		if due := m.missedRun(cj, now); due.IsZero() {
+			delete(m.missed, key)
+		} else if !m.missed[key].Equal(due) {
+			m.missed[key] = due
+			last := "never"
+			if !info.LastScheduleTime.IsZero() {
+				last = info.LastScheduleTime.Format(time.RFC3339)
+			}
+			m.emit(warningEvent(cj, now, watcher.ReasonMissedSchedule, fmt.Sprintf(
+				"No Job was started for the run scheduled at %s (schedule %q); last run scheduled at %s",
+				due.Format(time.RFC3339), info.Schedule, last)))
+		}
+
+		for _, job := range info.Active {
+			if job.ScheduledTime.IsZero() || now.Sub(job.ScheduledTime) < m.maxRun {
+				continue
+			}
+			jobKey := cj.Namespace + "/" + job.Name
+			running[jobKey] = true
+			if m.longRunning[jobKey] {
+				continue
+			}
+			m.longRunning[jobKey] = true
+			m.emit(warningEvent(cj, now, watcher.ReasonLongRunningJob, fmt.Sprintf(
+				"Job %s has been running for %s, longer than %s",
+				job.Name, now.Sub(job.ScheduledTime).Truncate(time.Minute), m.maxRun)))
+		}
+	}
+
+	for key := range m.missed {
+		if !seen[key] {
+			delete(m.missed, key)
+		}
+	}
+	for key := range m.longRunning {
+		if !running[key] {
+			delete(m.longRunning, key)
+		}
+	}
+}
+
+// missedRun returns the first run after the last one that should have started
+// more than the grace period ago, or the zero time if none was missed
+func (m *cronMonitor) missedRun(cj *watcher.Event, now time.Time) time.Time {
+	schedule, loc, err := parseCronJobSchedule(cj.CronJob.Schedule, cj.CronJob.TimeZone)
+	if err != nil {
+		slog.Debug("Unsupported CronJob schedule", cj.LogAttrs("schedule", cj.CronJob.Schedule, "error", err)...)
+		return time.Time{}
+	}
+	last := cj.CronJob.LastScheduleTime
+	if last.IsZero() {
+		last = cj.CreatedAt
+	}
+	if last.IsZero() {
+		return time.Time{}
+	}
+	due := schedule.Next(last.In(loc))
+	if due.IsZero() || now.Sub(due) < m.grace {
+		return time.Time{}
+	}
+	return due
+}
+
+// parseCronJobSchedule parses the schedule of a CronJob with its time zone, which
+// is set in the spec or with a CRON_TZ= or TZ= prefix. Without one, the schedule
+// is in the local time of the controller manager, which is UTC in most clusters.
+func parseCronJobSchedule(expr, timeZone string) (*maintenance.Schedule, *time.Location, error) {
+	expr = strings.TrimSpace(expr)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if rest, ok := strings.CutPrefix(expr, prefix); ok {
+			timeZone, expr, _ = strings.Cut(rest, " ")
+			expr = strings.TrimSpace(expr)
+		}
+	}
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	loc := time.UTC
+	if timeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return nil, nil, err
+		}
+	}
+	schedule, err := maintenance.ParseSchedule(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schedule, loc, nil
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestCronMonitor(t *testing.T) {
+	var warnings []*watcher.Event
+	m := newCronMonitor(config.CronJobsConfig{MissedScheduleGraceSeconds: 300, MaxRunMinutes: 60}, func(e *watcher.Event) {
+		warnings = append(warnings, e)
+	})
+	now := time.Date(2024, 1, 1, 12, 10, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	cronJob := func(lastSchedule time.Time, active ...watcher.ActiveJob) *watcher.Event {
+		return &watcher.Event{Kind: "CronJob", Namespace: "prod", Name: "report", CronJob: &watcher.CronJobInfo{
+			Schedule: "0 * * * *", LastScheduleTime: lastSchedule, Active: active,
+		}}
+	}
+
+	// 12:00の実行が猶予の5分を過ぎても始まっていない
+	m.check([]*watcher.Event{cronJob(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))})
+	if len(warnings) != 1 || warnings[0].EventType != watcher.EventTypeWarning || warnings[0].Reason != watcher.ReasonMissedSchedule {
+		t.Fatalf("Expected a missed schedule warning, got %+v", warnings)
+	}
+	if !strings.Contains(warnings[0].Message, "2024-01-01T12:00:00Z") {
+		t.Errorf("Expected the missed run in the message, got %q", warnings[0].Message)
+	}
+
+	// 同じ実行について繰り返し警告しない
+	m.check([]*watcher.Event{cronJob(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))})
+	if len(warnings) != 1 {
+		t.Fatalf("Expected the missed run to be warned about once, got %d warnings", len(warnings))
+	}
+
+	// 実行が始まれば見逃しは解消し、長時間実行中のジョブを警告する
+	started := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	m.check([]*watcher.Event{cronJob(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), watcher.ActiveJob{Name: "report-28401000", ScheduledTime: started})})
+	if len(warnings) != 2 || warnings[1].Reason != watcher.ReasonLongRunningJob || !strings.Contains(warnings[1].Message, "report-28401000 has been running for 2h10m0s") {
+		t.Fatalf("Expected a long-running job warning, got %+v", warnings[len(warnings)-1])
+	}
+	if len(m.missed) != 0 {
+		t.Errorf("Expected the missed run to be cleared, got %v", m.missed)
+	}
+
+	// 一時停止中のCronJobは確認しない
+	suspended := cronJob(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	suspended.CronJob.Suspended = true
+	m.check([]*watcher.Event{suspended})
+	if len(warnings) != 2 {
+		t.Errorf("Expected no warning for a suspended CronJob, got %+v", warnings[len(warnings)-1])
+	}
+}
+
+func TestParseCronJobSchedule(t *testing.T) {
+	schedule, loc, err := parseCronJobSchedule("CRON_TZ=Asia/Tokyo @daily", "")
+	if err != nil {
+		t.Fatalf("parseCronJobSchedule() error = %v", err)
+	}
+	if loc.String() != "Asia/Tokyo" {
+		t.Errorf("Expected the time zone of the prefix, got %s", loc)
+	}
+	next := schedule.Next(time.Date(2024, 1, 1, 12, 0, 0, 0, loc))
+	if !next.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, loc)) {
+		t.Errorf("Next() = %s, want midnight in Tokyo", next)
+	}
+
+	if _, _, err := parseCronJobSchedule("0 * * * *", "Mars/Olympus"); err == nil {
+		t.Error("Expected error for an unknown time zone")
+	}
+}
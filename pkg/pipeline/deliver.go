@@ -0,0 +1,596 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/batcher"
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/correlation"
+	"github.com/kqns91/kube-watcher/pkg/dedup"
+	"github.com/kqns91/kube-watcher/pkg/formatter"
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/router"
+	"github.com/kqns91/kube-watcher/pkg/tracing"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// HandleEvent passes an event through the pipeline. It is the handler of the
+// watchers started by Start, and can be called directly to inject events.
+func (p *Pipeline) HandleEvent(event *watcher.Event) {
+	p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	p.heartbeat.Processed()
+
+	c := p.current()
+	event.Cluster = c.cluster
+	// Enrichment runs first so that every later stage sees the derived fields,
+	// followed by the severity, which may depend on them
+	start := time.Now()
+	c.enricher.Apply(event)
+	c.severity.Classify(event)
+	p.observe(stageEnrich, start)
+
+	// The trace starts when the informer delivered the event
+	ctx, span := p.tracer.StartAt(context.Background(), "event", event.Timestamp, eventSpanAttributes(event)...)
+	defer span.End()
+
+	// Apply filters
+	_, filterSpan := tracing.Start(ctx, "filter")
+	start = time.Now()
+	passed := c.filter.ShouldProcess(event)
+	p.observe(stageFilter, start)
+	filterSpan.SetAttributes(tracing.Bool("passed", passed))
+	filterSpan.End()
+	if !passed {
+		p.dropped(span, event, StageFilter)
+		slog.Debug("Event filtered out", event.LogAttrs()...)
+		return
+	}
+	if p.hooks.OnAccepted != nil {
+		p.hooks.OnAccepted(event)
+	}
+	// Any accepted event can resolve an escalation, even if it is not notified
+	p.escalations.Resolve(event)
+
+	if s, silenced := p.silences.Silenced(event); silenced {
+		p.dropped(span, event, StageSilence)
+		slog.Debug("Event silenced", event.LogAttrs("silence", s.ID)...)
+		return
+	}
+	if window, suppressed := p.maintenance.Suppress(event); suppressed {
+		span.SetAttributes(tracing.String("maintenanceWindow", window.Name))
+		p.dropped(span, event, StageMaintenance)
+		slog.Debug("Event suppressed by maintenance window", event.LogAttrs("window", window.Name, "action", window.Action)...)
+		return
+	}
+	// Crash-looping Pods of workloads are reported per workload by the crash loop monitor instead
+	if p.crashLoops.Load() && event.Kind == "Pod" && event.EventType == "UPDATED" && crashLooping(event) {
+		if _, _, ok := podWorkload(event); ok {
+			p.dropped(span, event, StageCrashLoop)
+			slog.Debug("Event left to the crash loop report", event.LogAttrs()...)
+			return
+		}
+	}
+
+	behavior := c.behavior(event)
+
+	// Apply deduplication if enabled, unless the event is configured to always be sent
+	if c.dedup != nil && behavior.Deduplicated() && !c.dedupBypass.Matches(event) {
+		key := dedup.EventKey{
+			Kind:      event.Kind,
+			Namespace: event.Namespace,
+			Name:      event.Name,
+			EventType: event.EventType,
+		}
+		_, dedupSpan := tracing.Start(ctx, "dedup")
+		start := time.Now()
+		unique := c.dedup.ShouldProcess(key, event)
+		p.observe(stageDedup, start)
+		dedupSpan.SetAttributes(tracing.Bool("duplicate", !unique))
+		dedupSpan.End()
+		if p.hooks.OnDedup != nil {
+			p.hooks.OnDedup(event, !unique)
+		}
+		if !unique {
+			p.dropped(span, event, StageDedup)
+			slog.Debug("Event deduplicated", event.LogAttrs()...)
+			return
+		}
+	}
+
+	// The ID identifies the notification, so it is assigned after deduplication
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	p.attachLogs(ctx, c.config.PodLogs, event)
+	p.attachRelatedEvents(ctx, c.config.RelatedEvents, event)
+	p.attachFailedPods(ctx, event)
+	p.attachManifest(c.config.Manifests, event)
+	if p.hooks.OnNotify != nil {
+		p.hooks.OnNotify(event)
+	}
+
+	// Immediate events are never held back
+	if behavior.Immediate {
+		span.SetAttributes(tracing.Bool("immediate", true))
+		p.deliverEvent(ctx, span, c, event)
+		return
+	}
+
+	// The cordon of a Node and the Pod evictions from it are held back and sent as one drain
+	if c.drains != nil && c.drains.Add(event) {
+		span.SetAttributes(tracing.Bool("drain", true))
+		slog.Debug("Event added to node drain", event.LogAttrs()...)
+		return
+	}
+
+	// Events of a kind and namespace in a storm are held back and sent as summaries
+	if c.storms != nil && c.storms.Add(event) {
+		span.SetAttributes(tracing.Bool("storm", true))
+		slog.Debug("Event added to storm summary", event.LogAttrs()...)
+		return
+	}
+
+	// Related events of a rollout are held back and sent as one story
+	if c.correlator != nil && c.correlator.Add(event) {
+		span.SetAttributes(tracing.Bool("correlated", true))
+		slog.Debug("Event added to story", event.LogAttrs()...)
+		return
+	}
+	p.deliverEvent(ctx, span, c, event)
+}
+
+// deliverEvent adds an event to the batches of its routed destinations, or
+// sends it to the destinations whose events are not batched
+func (p *Pipeline) deliverEvent(ctx context.Context, span *tracing.Span, c components, event *watcher.Event) {
+	_, routeSpan := tracing.Start(ctx, "route")
+	start := time.Now()
+	destinations := c.router.Route(event)
+	p.observe(stageRoute, start)
+	routeSpan.End()
+	if destinations.IsEmpty() {
+		p.dropped(span, event, StageRouter)
+		slog.Debug("Event matched no route", event.LogAttrs()...)
+		return
+	}
+
+	// Destinations batched globally or by their route get the event with their batch
+	behavior := c.behavior(event)
+	if len(c.batchers) > 0 && behavior.Batched() {
+		batched, unbatched := c.batchDestinations(destinations)
+		if len(batched) > 0 {
+			_, batchSpan := tracing.Start(ctx, "batcher")
+			c.addToBatchers(batched, event)
+			batchSpan.End()
+			span.SetAttributes(tracing.Bool("batched", true))
+			slog.Debug("Event added to batch", event.LogAttrs()...)
+		}
+		if len(unbatched) == 0 {
+			return
+		}
+		destinations = destinations.Only(unbatched)
+	}
+
+	// The other destinations are sent to immediately
+	tracked := p.track(c, event)
+	sends := eventSends(c.sinks, c.deadLetters, p.ops, destinations, event)
+
+	// Uploaded manifests are left out of the message
+	formatted, file := event, manifestFile(c.config.Manifests, event)
+	if file != nil {
+		withoutManifest := *event
+		withoutManifest.Manifest = ""
+		formatted = &withoutManifest
+	}
+
+	// Messages are formatted once per formatting, as routes may override it
+	slackMessages := make(map[int]*notifier.SlackMessage)
+	threadKey := c.formatter.ThreadKey(event)
+	for _, d := range c.slack {
+		if !destinations.Includes(d.name) {
+			continue
+		}
+
+		route := destinations.Formatting(d.name)
+		slackMessage := slackMessages[route]
+		if slackMessage == nil {
+			_, formatSpan := tracing.Start(ctx, "format")
+			start := time.Now()
+			f, format := c.slackFormatting(route)
+			switch format {
+			case formatter.OutputBlocks:
+				slackMessage = f.FormatSlackBlocks(formatted)
+				if tracked && c.config.Acks.SlackSigningSecret.IsSet() {
+					slackMessage.Blocks = append(slackMessage.Blocks, ackBlock(event))
+				}
+			case formatter.OutputText:
+				slackMessage = f.FormatSlackText(formatted)
+			default:
+				slackMessage = f.FormatSlackMessage(formatted)
+			}
+			p.observe(stageFormat, start)
+			formatSpan.End()
+			slackMessage.Unlimited = !behavior.RateLimited()
+			slackMessages[route] = slackMessage
+		}
+
+		// Send notification, replying in the thread of the key when threading is enabled
+		sends = append(sends, send{
+			destination: d.name,
+			do:          func(context.Context) error { return d.notifier.SendThreadedWithFile(threadKey, slackMessage, file) },
+			failed: func(err error) {
+				slog.Error("Failed to send notification", event.LogAttrs("destination", d.name, "error", err)...)
+				deadLetterEvent(c.deadLetters, p.ops, d.name, event, err)
+			},
+			sent: func() { slog.Info("Notification sent", event.LogAttrs("destination", d.name)...) },
+		})
+	}
+	delivered, err := p.fanOut(ctx, sends)
+	span.SetError(err)
+	c.recorder.Notified(event, delivered)
+	if len(delivered) > 0 {
+		p.heartbeat.Sent(1)
+		p.escalations.Track(event)
+	}
+}
+
+// deliverStory sends the events of a story as one message. A story of a single
+// event is delivered like any other event.
+func (p *Pipeline) deliverStory(story *correlation.Story) {
+	if len(story.Events) == 1 {
+		event := story.Events[0]
+		ctx, span := p.tracer.Start(context.Background(), "story", eventSpanAttributes(event)...)
+		defer span.End()
+		p.deliverEvent(ctx, span, p.current(), event)
+		return
+	}
+	opts := formatter.BatchOptions{Mode: formatter.BatchModeStory, Subject: story.Subject()}
+	p.deliverBatch("story", &batcher.Batch{Events: story.Events, StartTime: story.StartTime, EndTime: story.EndTime}, opts)
+}
+
+// dropped records the stage that dropped an event
+func (p *Pipeline) dropped(span *tracing.Span, event *watcher.Event, stage Stage) {
+	span.SetAttributes(tracing.String("droppedBy", string(stage)))
+	if p.hooks.OnDropped != nil {
+		p.hooks.OnDropped(event, stage)
+	}
+}
+
+// deliverBatch routes a batch of events to the notifiers and posts it to each Slack
+// destination as one message. Used for stories, storm summaries and maintenance digests.
+func (p *Pipeline) deliverBatch(spanName string, batch *batcher.Batch, batchOpts formatter.BatchOptions) {
+	c := p.current()
+	p.deliverSplitBatch(spanName, batch, batchOpts, c.router.Split, c.batchFormatting(c.router))
+}
+
+// batchFormatting resolves the Slack formatting of the events of a batch sent to a destination
+type batchFormatting func(events []*watcher.Event, destination string) (*formatter.Formatter, formatter.OutputFormat)
+
+// batchFormatting returns the Slack formatting of batches routed with r: the
+// formatting of the route the first event was sent to the destination through
+func (c components) batchFormatting(r *router.Router) batchFormatting {
+	return func(events []*watcher.Event, destination string) (*formatter.Formatter, formatter.OutputFormat) {
+		return c.slackFormatting(r.Route(events[0]).Formatting(destination))
+	}
+}
+
+// deliverSplitBatch delivers a batch of events, split by destination with
+// split, to the notifiers, posting it to each Slack destination as one message
+// formatted as resolved by formatting
+func (p *Pipeline) deliverSplitBatch(spanName string, batch *batcher.Batch, batchOpts formatter.BatchOptions, split func(events []*watcher.Event, destinations []string) map[string][]*watcher.Event, formatting batchFormatting) {
+	c := p.current()
+
+	ctx, span := p.tracer.Start(context.Background(), spanName, tracing.Int("events", len(batch.Events)))
+	defer span.End()
+
+	// Split the batch by routed destination
+	_, routeSpan := tracing.Start(ctx, "route")
+	start := time.Now()
+	groups := split(batch.Events, destinationNames(c.slack, c.sinks))
+	p.observe(stageRoute, start)
+	routeSpan.End()
+	routed := make(map[*watcher.Event]bool, len(batch.Events))
+	for _, events := range groups {
+		for _, e := range events {
+			routed[e] = true
+		}
+	}
+	unrouted := 0
+	for _, e := range batch.Events {
+		switch {
+		case !routed[e]:
+			unrouted++
+			p.dropped(span, e, StageRouter)
+			slog.Debug("Batched event matched no route", e.LogAttrs()...)
+		case c.critical != nil:
+			p.track(c, e)
+		}
+	}
+	if unrouted > 0 {
+		slog.Info("Batched events matched no route", "batch", spanName, "events", unrouted)
+	}
+	sends := batchSends(c.sinks, c.deadLetters, p.ops, groups, batch.StartTime, batch.EndTime)
+
+	for _, d := range c.slack {
+		events := groups[d.name]
+		if len(events) == 0 {
+			continue
+		}
+
+		// Convert batcher.Batch to formatter.EventBatch
+		formatterBatch := &formatter.EventBatch{
+			Events:       events,
+			StartTime:    batch.StartTime,
+			EndTime:      batch.EndTime,
+			UpdateCounts: batch.UpdateCounts,
+		}
+
+		// Format batch message
+		_, formatSpan := tracing.Start(ctx, "format", tracing.String("destination", d.name))
+		start := time.Now()
+		var slackMessage *notifier.SlackMessage
+		f, format := formatting(events, d.name)
+		switch format {
+		case formatter.OutputBlocks:
+			slackMessage = f.FormatBatchSlackBlocks(formatterBatch, batchOpts)
+		case formatter.OutputText:
+			slackMessage = f.FormatBatchSlackText(formatterBatch, batchOpts)
+		default:
+			slackMessage = f.FormatBatchSlackMessage(formatterBatch, batchOpts)
+		}
+		p.observe(stageFormat, start)
+		formatSpan.End()
+
+		sends = append(sends, send{
+			destination: d.name,
+			attrs:       []tracing.Attribute{tracing.Int("events", len(events))},
+			do:          func(context.Context) error { return d.notifier.SendMessage(slackMessage) },
+			failed: func(err error) {
+				slog.Error("Failed to send batch notification", "destination", d.name, "error", err)
+				deadLetterBatch(c.deadLetters, p.ops, d.name, notifier.NewBatchPayload(events, batch.StartTime, batch.EndTime), err)
+			},
+			sent: func() { slog.Info("Batch notification sent", "destination", d.name, "events", len(events)) },
+		})
+	}
+	delivered, err := p.fanOut(ctx, sends)
+	span.SetError(err)
+	recordBatch(c.recorder, groups, delivered)
+	sent := deliveredEvents(groups, delivered)
+	p.heartbeat.Sent(len(sent))
+	for _, e := range sent {
+		p.escalations.Track(e)
+	}
+}
+
+// eventSends returns the sends of an event to the selected notifiers. Failures
+// are logged and recorded in the dead-letter queue.
+func eventSends(sinks []notifier.EventNotifier, deadLetters *notifier.DeadLetterQueue, ops *opsAlerter, destinations router.Selection, event *watcher.Event) []send {
+	var sends []send
+	for _, n := range sinks {
+		if !destinations.Includes(n.Name()) {
+			continue
+		}
+		sends = append(sends, send{
+			destination: n.Name(),
+			do:          func(ctx context.Context) error { return notifier.NotifyEventContext(ctx, n, event) },
+			failed: func(err error) {
+				slog.Error("Failed to send notification", event.LogAttrs("destination", n.Name(), "error", err)...)
+				deadLetterEvent(deadLetters, ops, n.Name(), event, err)
+			},
+		})
+	}
+	return sends
+}
+
+// batchSends returns the sends of each notifier's routed share of a batch.
+// Failures are logged and recorded in the dead-letter queue.
+func batchSends(sinks []notifier.EventNotifier, deadLetters *notifier.DeadLetterQueue, ops *opsAlerter, groups map[string][]*watcher.Event, startTime, endTime time.Time) []send {
+	var sends []send
+	for _, n := range sinks {
+		events := groups[n.Name()]
+		if len(events) == 0 {
+			continue
+		}
+		payload := notifier.NewBatchPayload(events, startTime, endTime)
+		sends = append(sends, send{
+			destination: n.Name(),
+			attrs:       []tracing.Attribute{tracing.Int("events", len(events))},
+			do:          func(ctx context.Context) error { return notifier.NotifyBatchContext(ctx, n, payload) },
+			failed: func(err error) {
+				slog.Error("Failed to send batch notification", "destination", n.Name(), "events", len(events), "error", err)
+				deadLetterBatch(deadLetters, ops, n.Name(), payload, err)
+			},
+		})
+	}
+	return sends
+}
+
+// recordBatch records a Kubernetes Event for each event of a batch, listing the
+// destinations its share of the batch was delivered to
+func recordBatch(recorder *watcher.EventRecorder, groups map[string][]*watcher.Event, delivered []string) {
+	if recorder == nil {
+		return
+	}
+	var order []*watcher.Event
+	destinations := make(map[*watcher.Event][]string)
+	for _, name := range delivered {
+		for _, e := range groups[name] {
+			if _, seen := destinations[e]; !seen {
+				order = append(order, e)
+			}
+			destinations[e] = append(destinations[e], name)
+		}
+	}
+	for _, e := range order {
+		recorder.Notified(e, destinations[e])
+	}
+}
+
+// deliveredEvents returns the events of a batch that were delivered to at least one destination
+func deliveredEvents(groups map[string][]*watcher.Event, delivered []string) []*watcher.Event {
+	var events []*watcher.Event
+	seen := make(map[*watcher.Event]bool)
+	for _, name := range delivered {
+		for _, e := range groups[name] {
+			if !seen[e] {
+				seen[e] = true
+				events = append(events, e)
+			}
+		}
+	}
+	return events
+}
+
+// batchOptions returns the batch message options of the batching configuration
+func batchOptions(c *config.Config) formatter.BatchOptions {
+	return formatter.BatchOptions{
+		Mode:              formatter.BatchMode(c.Batching.Mode),
+		MaxEventsPerGroup: c.Batching.Smart.MaxEventsPerGroup,
+		AlwaysShowDetails: c.Batching.Smart.AlwaysShowDetails,
+		GroupBy:           formatter.GroupBy(c.Batching.GroupBy),
+		SummaryStats:      c.Batching.SummaryStats,
+	}
+}
+
+// digestOptions formats maintenance digests, which can hold many events, as a
+// per-namespace summary
+var digestOptions = formatter.BatchOptions{
+	Mode:         formatter.BatchModeSummary,
+	GroupBy:      formatter.GroupByNamespace,
+	SummaryStats: true,
+}
+
+// deadLetterEvent records an undeliverable event, raising an ops alert when it is lost
+func deadLetterEvent(deadLetters *notifier.DeadLetterQueue, ops *opsAlerter, destination string, event *watcher.Event, cause error) {
+	if deadLetters == nil {
+		ops.notificationLost(destination, cause)
+		return
+	}
+	if err := deadLetters.AddEvent(destination, event, cause); err != nil {
+		slog.Error("Failed to record dead letter", "destination", destination, "error", err)
+		ops.notificationLost(destination, err)
+	}
+}
+
+// deadLetterBatch records an undeliverable batch, raising an ops alert when it is lost
+func deadLetterBatch(deadLetters *notifier.DeadLetterQueue, ops *opsAlerter, destination string, batch *notifier.BatchPayload, cause error) {
+	if deadLetters == nil {
+		ops.notificationLost(destination, cause)
+		return
+	}
+	if err := deadLetters.AddBatch(destination, batch, cause); err != nil {
+		slog.Error("Failed to record dead letter", "destination", destination, "error", err)
+		ops.notificationLost(destination, err)
+	}
+}
+
+// destinationNames lists the names of all active destinations
+func destinationNames(slack []slackDestination, sinks []notifier.EventNotifier) []string {
+	names := make([]string, 0, len(slack)+len(sinks))
+	for _, d := range slack {
+		names = append(names, d.name)
+	}
+	for _, n := range sinks {
+		names = append(names, n.Name())
+	}
+	return names
+}
+
+// track starts tracking a critical event until it is acknowledged, reporting whether it is tracked
+func (p *Pipeline) track(c components, event *watcher.Event) bool {
+	if c.critical == nil || !(c.critical.IsEmpty() || c.critical.Matches(event)) {
+		return false
+	}
+	if err := p.acks.Track(event); err != nil {
+		slog.Error("Failed to track event for acknowledgement", event.LogAttrs("error", err)...)
+		return false
+	}
+	return true
+}
+
+// ackBlock is the section with the button acknowledging the event
+func ackBlock(event *watcher.Event) notifier.SlackBlock {
+	return notifier.SlackBlock{
+		Type: notifier.BlockTypeSection,
+		Text: &notifier.SlackText{Type: "mrkdwn", Text: "This event needs an acknowledgement"},
+		Accessory: &notifier.SlackButton{
+			Type:     "button",
+			Text:     notifier.SlackText{Type: "plain_text", Text: "Acknowledge"},
+			ActionID: notifier.ActionAcknowledge,
+			Value:    event.ID,
+			Style:    "primary",
+		},
+	}
+}
+
+// newEventID returns a random event ID
+func newEventID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// semconvNameKeys are the OpenTelemetry semantic convention attributes naming
+// objects of the kinds they define
+var semconvNameKeys = map[string]string{
+	"Pod":         "k8s.pod.name",
+	"Deployment":  "k8s.deployment.name",
+	"ReplicaSet":  "k8s.replicaset.name",
+	"StatefulSet": "k8s.statefulset.name",
+	"DaemonSet":   "k8s.daemonset.name",
+	"Job":         "k8s.job.name",
+	"CronJob":     "k8s.cronjob.name",
+	"Node":        "k8s.node.name",
+	"Namespace":   "k8s.namespace.name",
+}
+
+// eventSpanAttributes returns the span attributes identifying an event. The
+// cluster, namespace and object names use the semantic convention keys where
+// they exist; the kind and event type have no convention and are namespaced.
+func eventSpanAttributes(e *watcher.Event) []tracing.Attribute {
+	attrs := []tracing.Attribute{
+		tracing.String("kube_watcher.object.kind", e.Kind),
+		tracing.String("kube_watcher.object.name", e.Name),
+		tracing.String("kube_watcher.event.type", e.EventType),
+	}
+	if e.Cluster != "" {
+		attrs = append(attrs, tracing.String("k8s.cluster.name", e.Cluster))
+	}
+	if e.Namespace != "" {
+		attrs = append(attrs, tracing.String("k8s.namespace.name", e.Namespace))
+	}
+	if key := semconvNameKeys[e.Kind]; key != "" {
+		attrs = append(attrs, tracing.String(key, e.Name))
+	}
+	return attrs
+}
+
+// manifestFile returns the manifest attached to the event as a file to upload
+// to Slack, or nil unless manifests are uploaded
+func manifestFile(cfg config.ManifestsConfig, event *watcher.Event) *notifier.SlackFile {
+	if !cfg.Upload || event.Manifest == "" {
+		return nil
+	}
+	title := event.Kind + " " + event.Name
+	if event.Namespace != "" {
+		title = event.Kind + " " + event.Namespace + "/" + event.Name
+	}
+	return &notifier.SlackFile{
+		Name:    strings.ToLower(event.Kind) + "-" + event.Name + ".yaml",
+		Title:   title,
+		Content: event.Manifest,
+	}
+}
+
+// behavior returns the pipeline behavior configured for the severity of the event
+func (c components) behavior(event *watcher.Event) config.SeverityBehavior {
+	if event.Severity == nil {
+		return config.SeverityBehavior{}
+	}
+	return c.config.Severity.Behaviors[event.Severity.Level]
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/kqns91/kube-watcher/pkg/escalation"
+	"github.com/kqns91/kube-watcher/pkg/formatter"
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/router"
+	"github.com/kqns91/kube-watcher/pkg/tracing"
+)
+
+// escalate re-sends the event of an escalation to the rule's destinations, or
+// to the destinations the event is routed to when the rule has none
+func (p *Pipeline) escalate(e *escalation.Escalation) {
+	c := p.current()
+	event := e.Event
+
+	ctx, span := p.tracer.Start(context.Background(), "escalation", append(eventSpanAttributes(event), tracing.String("rule", e.Rule.Name))...)
+	defer span.End()
+
+	destinations := c.router.Route(event)
+	if len(e.Rule.Destinations) > 0 {
+		destinations = router.Select(e.Rule.Destinations)
+	}
+	sends := eventSends(c.sinks, c.deadLetters, p.ops, destinations, event)
+
+	var slackMessage *notifier.SlackMessage
+	for _, d := range c.slack {
+		if !destinations.Includes(d.name) {
+			continue
+		}
+		if slackMessage == nil {
+			slackMessage = p.escalationMessage(c, e)
+		}
+
+		// Escalations are posted as new messages so that they are not hidden in a thread
+		sends = append(sends, send{
+			destination: d.name,
+			do:          func(context.Context) error { return d.notifier.SendMessage(slackMessage) },
+			failed: func(err error) {
+				slog.Error("Failed to send escalation", event.LogAttrs("destination", d.name, "rule", e.Rule.Name, "error", err)...)
+				deadLetterEvent(c.deadLetters, p.ops, d.name, event, err)
+			},
+		})
+	}
+	delivered, err := p.fanOut(ctx, sends)
+	span.SetError(err)
+	c.recorder.Notified(event, delivered)
+}
+
+// escalationMessage formats the event of an escalation, headed by the rule that escalated it
+func (p *Pipeline) escalationMessage(c components, e *escalation.Escalation) *notifier.SlackMessage {
+	text := fmt.Sprintf(":rotating_light: Escalated by `%s`: not acknowledged or resolved within %d minutes", e.Rule.Name, int(e.Rule.After.Minutes()))
+	if formatter.OutputFormat(c.config.Notifier.Slack.Format) != formatter.OutputBlocks {
+		msg := c.formatter.FormatSlackMessage(e.Event)
+		msg.Text = withLine(text, msg.Text)
+		return msg
+	}
+
+	msg := c.formatter.FormatSlackBlocks(e.Event)
+	msg.Text = withLine(text, msg.Text)
+	header := notifier.SlackBlock{
+		Type: notifier.BlockTypeSection,
+		Text: &notifier.SlackText{Type: "mrkdwn", Text: text},
+	}
+	msg.Blocks = append([]notifier.SlackBlock{header}, msg.Blocks...)
+	if c.critical != nil && p.acks.IsTracked(e.Event.ID) && c.config.Acks.SlackSigningSecret.IsSet() {
+		msg.Blocks = append(msg.Blocks, ackBlock(e.Event))
+	}
+	return msg
+}
+
+// withLine prepends a line to text
+func withLine(line, text string) string {
+	if text == "" {
+		return line
+	}
+	return line + "\n" + text
+}
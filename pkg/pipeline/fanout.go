@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/metrics"
+	"github.com/kqns91/kube-watcher/pkg/tracing"
+	"golang.org/x/sync/errgroup"
+)
+
+// send is a notification to one destination
+type send struct {
+	destination string
+	attrs       []tracing.Attribute             // Additional attributes of the notify span
+	do          func(ctx context.Context) error // Called with the context of the notify span
+	failed      func(err error)                 // Logs and records the failure, e.g. in the dead-letter queue
+	sent        func()                          // Logs the success; optional
+}
+
+// fanOut performs the sends, recording the time taken by each destination and
+// by the sends as a whole
+func (p *Pipeline) fanOut(ctx context.Context, sends []send) ([]string, error) {
+	if len(sends) > 0 {
+		defer p.observe(stageSend, time.Now())
+	}
+	return fanOut(ctx, sends, p.sendDurations)
+}
+
+// fanOut performs the sends concurrently, so that a slow destination does not
+// delay the others. It returns the destinations that succeeded, in the order
+// of the sends, and the failures joined into one error. The time taken by each
+// destination is recorded in durations, if set.
+func fanOut(ctx context.Context, sends []send, durations *metrics.HistogramVec) ([]string, error) {
+	errs := make([]error, len(sends))
+	var g errgroup.Group
+	for i, s := range sends {
+		g.Go(func() error {
+			spanCtx, span := tracing.Start(ctx, "notify", append([]tracing.Attribute{tracing.String("destination", s.destination)}, s.attrs...)...)
+			span.SetKind(tracing.SpanKindClient)
+			start := time.Now()
+			errs[i] = s.do(spanCtx)
+			durations.Observe(time.Since(start).Seconds(), s.destination)
+			span.SetError(errs[i])
+			span.End()
+			if errs[i] != nil {
+				s.failed(errs[i])
+			} else if s.sent != nil {
+				s.sent()
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var delivered []string
+	var failures []error
+	for i, s := range sends {
+		if errs[i] != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", s.destination, errs[i]))
+			continue
+		}
+		delivered = append(delivered, s.destination)
+	}
+	return delivered, errors.Join(failures...)
+}
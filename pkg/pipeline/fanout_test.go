@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFanOut(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var failed []string
+
+	sends := []send{
+		// 遅い通知先が他の通知先を待たせない
+		{destination: "slow", do: func(context.Context) error { <-release; return nil }},
+		{destination: "fast", do: func(context.Context) error { close(release); return nil }},
+		{destination: "broken", do: func(context.Context) error { return errors.New("timeout") }},
+	}
+	for i := range sends {
+		name := sends[i].destination
+		sends[i].failed = func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, name)
+		}
+	}
+
+	done := make(chan struct{})
+	var delivered []string
+	var err error
+	go func() {
+		delivered, err = fanOut(context.Background(), sends, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fanOut() did not send concurrently")
+	}
+
+	if strings.Join(delivered, ",") != "slow,fast" {
+		t.Errorf("delivered = %v, want [slow fast]", delivered)
+	}
+	if err == nil || !strings.Contains(err.Error(), "broken: timeout") {
+		t.Errorf("Expected the aggregated error to name the failed destination, got %v", err)
+	}
+	if len(failed) != 1 || failed[0] != "broken" {
+		t.Errorf("Expected only broken to be reported as failed, got %v", failed)
+	}
+}
+
+func TestFanOut_Empty(t *testing.T) {
+	delivered, err := fanOut(context.Background(), nil, nil)
+	if len(delivered) != 0 || err != nil {
+		t.Errorf("fanOut(nil) = %v, %v", delivered, err)
+	}
+}
@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+)
+
+// healthTestMessage is posted to Slack destinations when health.testMessage is set
+const healthTestMessage = ":white_check_mark: kube-watcher is connected to this channel"
+
+// checkNotifierHealth checks all notifiers concurrently and returns the failures
+// by destination name. With testMessage, Slack destinations post a test message
+// instead of the lightweight check.
+func checkNotifierHealth(slack []slackDestination, sinks []notifier.EventNotifier, testMessage bool) map[string]error {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures = make(map[string]error)
+	)
+	check := func(name string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				mu.Lock()
+				failures[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, d := range slack {
+		n := d.notifier
+		if testMessage {
+			check(d.name, func() error { return n.Send(healthTestMessage) })
+		} else {
+			check(d.name, n.CheckHealth)
+		}
+	}
+	for _, n := range sinks {
+		if hc, ok := n.(notifier.HealthChecker); ok {
+			check(n.Name(), hc.CheckHealth)
+		}
+	}
+
+	wg.Wait()
+	return failures
+}
+
+// healthCheckError combines health check failures into one error
+func healthCheckError(failures map[string]error) error {
+	names := make([]string, 0, len(failures))
+	for name := range failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, failures[name]))
+	}
+	return fmt.Errorf("notifier health check failed: %s", strings.Join(msgs, "; "))
+}
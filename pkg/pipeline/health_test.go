@@ -0,0 +1,36 @@
+package pipeline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+)
+
+func TestCheckNotifierHealth(t *testing.T) {
+	valid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer valid.Close()
+	invalid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer invalid.Close()
+
+	slack := []slackDestination{
+		{name: "slack", notifier: notifier.NewSlackNotifier(valid.URL)},
+		{name: "prod-alerts", notifier: notifier.NewSlackNotifier(invalid.URL)},
+	}
+	// stdoutはヘルスチェック対象外
+	sinks := []notifier.EventNotifier{notifier.NewStdoutNotifier(&strings.Builder{})}
+
+	failures := checkNotifierHealth(slack, sinks, false)
+	if len(failures) != 1 || failures["prod-alerts"] == nil {
+		t.Fatalf("Expected only prod-alerts to fail, got %v", failures)
+	}
+	if err := healthCheckError(failures); !strings.Contains(err.Error(), "prod-alerts: ") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
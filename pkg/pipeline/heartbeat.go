@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/maintenance"
+)
+
+// heartbeatSchedule is a compiled heartbeat configuration
+type heartbeatSchedule struct {
+	schedule    *maintenance.Schedule
+	location    *time.Location
+	destination string // Slack destination; empty for all
+}
+
+// newHeartbeatSchedule compiles the heartbeat configuration, returning nil when it is disabled
+func newHeartbeatSchedule(c config.HeartbeatConfig) (*heartbeatSchedule, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	schedule, err := maintenance.ParseSchedule(c.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("heartbeat: %w", err)
+	}
+	location := time.Local
+	if c.Timezone != "" {
+		if location, err = time.LoadLocation(c.Timezone); err != nil {
+			return nil, fmt.Errorf("heartbeat: %w", err)
+		}
+	}
+	return &heartbeatSchedule{schedule: schedule, location: location, destination: c.Destination}, nil
+}
+
+// heartbeat posts a scheduled message with the number of events processed and
+// sent since the previous one, confirming that the notification path works end
+// to end. A nil heartbeat is valid and counts nothing.
+type heartbeat struct {
+	send func(destination, text string)
+	now  func() time.Time
+
+	processed atomic.Int64 // Events received from the watchers
+	sent      atomic.Int64 // Events delivered to at least one destination
+
+	mu       sync.Mutex
+	schedule *heartbeatSchedule
+	since    time.Time
+	timer    *time.Timer
+}
+
+// newHeartbeat creates a heartbeat posting with send. It is idle until a schedule is set.
+func newHeartbeat(send func(destination, text string)) *heartbeat {
+	return &heartbeat{send: send, now: time.Now, since: time.Now()}
+}
+
+// SetSchedule replaces the schedule; nil disables the heartbeat. The counts
+// are kept, so the next message covers the time since the previous one.
+func (h *heartbeat) SetSchedule(s *heartbeatSchedule) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.schedule = s
+	h.reschedule()
+}
+
+// reschedule arms the timer for the next beat. Must be called with mu held.
+func (h *heartbeat) reschedule() {
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	if h.schedule == nil {
+		return
+	}
+	next := h.schedule.schedule.Next(h.now().In(h.schedule.location))
+	if next.IsZero() {
+		slog.Warn("Heartbeat schedule never matches")
+		return
+	}
+	h.timer = time.AfterFunc(time.Until(next), h.beat)
+}
+
+// beat posts the counts since the previous beat and arms the timer for the next one
+func (h *heartbeat) beat() {
+	h.mu.Lock()
+	if h.schedule == nil {
+		h.mu.Unlock()
+		return
+	}
+	now := h.now()
+	period := now.Sub(h.since)
+	h.since = now
+	destination := h.schedule.destination
+	processed, sent := h.processed.Swap(0), h.sent.Swap(0)
+	h.reschedule()
+	h.mu.Unlock()
+
+	slog.Info("Heartbeat", "processed", processed, "sent", sent, "period", period)
+	h.send(destination, heartbeatMessage(processed, sent, period))
+}
+
+// Processed counts an event received from the watchers
+func (h *heartbeat) Processed() {
+	if h != nil {
+		h.processed.Add(1)
+	}
+}
+
+// Sent counts events delivered to at least one destination
+func (h *heartbeat) Sent(n int) {
+	if h != nil {
+		h.sent.Add(int64(n))
+	}
+}
+
+// Stop stops posting
+func (h *heartbeat) Stop() {
+	h.SetSchedule(nil)
+}
+
+// heartbeatMessage reports the activity within the period
+func heartbeatMessage(processed, sent int64, period time.Duration) string {
+	return fmt.Sprintf(":heartbeat: kube-watcher alive: %d events processed, %d sent in the last %s", processed, sent, formatPeriod(period))
+}
+
+// formatPeriod formats a duration rounded to minutes, e.g. "24h" or "1h30m"
+func formatPeriod(d time.Duration) string {
+	d = d.Round(time.Minute)
+	switch {
+	case d < time.Minute:
+		return "minute"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%dh%dm", d/time.Hour, d%time.Hour/time.Minute)
+	}
+}
@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+)
+
+func TestHeartbeat(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	var destination, text string
+	h := newHeartbeat(func(d, t string) { destination, text = d, t })
+	h.now = func() time.Time { return now }
+	h.since = now.Add(-24 * time.Hour)
+
+	schedule, err := newHeartbeatSchedule(config.HeartbeatConfig{Enabled: true, Schedule: "0 9 * * *", Timezone: "UTC", Destination: "ops"})
+	if err != nil {
+		t.Fatalf("newHeartbeatSchedule() error = %v", err)
+	}
+	h.SetSchedule(schedule)
+	defer h.Stop()
+
+	for i := 0; i < 5; i++ {
+		h.Processed()
+	}
+	h.Sent(2)
+	h.beat()
+
+	want := ":heartbeat: kube-watcher alive: 5 events processed, 2 sent in the last 24h"
+	if destination != "ops" || text != want {
+		t.Errorf("Unexpected heartbeat %q to %q", text, destination)
+	}
+
+	// 件数は次の周期に向けてリセットされる
+	now = now.Add(90 * time.Minute)
+	h.beat()
+	if text != ":heartbeat: kube-watcher alive: 0 events processed, 0 sent in the last 1h30m" {
+		t.Errorf("Expected the counts to be reset, got %q", text)
+	}
+}
+
+func TestNewHeartbeatSchedule_Invalid(t *testing.T) {
+	if _, err := newHeartbeatSchedule(config.HeartbeatConfig{Enabled: true, Schedule: "0 9 * *"}); err == nil {
+		t.Error("Expected error for a schedule with 4 fields")
+	}
+	if s, err := newHeartbeatSchedule(config.HeartbeatConfig{}); s != nil || err != nil {
+		t.Errorf("Expected no schedule when disabled, got %v, %v", s, err)
+	}
+}
@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/dedup"
+	"github.com/kqns91/kube-watcher/pkg/metrics"
+)
+
+// Stages whose processing time is recorded in kube_watcher_pipeline_stage_duration_seconds
+const (
+	stageEnrich = "enrich"
+	stageFilter = "filter"
+	stageDedup  = "dedup"
+	stageRoute  = "route"
+	stageFormat = "format" // Slack messages only; the other notifiers encode their payloads when sending
+	stageSend   = "send"   // All destinations of a notification, sent concurrently
+)
+
+// observe records the processing time of a stage that started at start
+func (p *Pipeline) observe(stage string, start time.Time) {
+	p.stageDurations.Observe(time.Since(start).Seconds(), stage)
+}
+
+// queueLengths returns the number of items waiting in each queue of the pipeline
+func (p *Pipeline) queueLengths() []metrics.Sample {
+	c := p.current()
+	samples := []metrics.Sample{
+		{LabelValues: []string{"in_flight"}, Value: float64(p.inFlight.Load())},
+		{LabelValues: []string{"escalation"}, Value: float64(p.escalations.Pending())},
+	}
+	if len(c.batchers) > 0 {
+		pending := 0
+		for _, b := range c.batchers {
+			pending += b.Pending()
+		}
+		samples = append(samples, metrics.Sample{LabelValues: []string{"batcher"}, Value: float64(pending)})
+	}
+	if c.drains != nil {
+		samples = append(samples, metrics.Sample{LabelValues: []string{"node_drain"}, Value: float64(c.drains.Pending())})
+	}
+	if c.storms != nil {
+		samples = append(samples, metrics.Sample{LabelValues: []string{"storm"}, Value: float64(c.storms.Held())})
+	}
+	if c.correlator != nil {
+		samples = append(samples, metrics.Sample{LabelValues: []string{"correlation"}, Value: float64(c.correlator.Pending())})
+	}
+	return samples
+}
+
+// RegisterMetrics registers the stage latency, queue length, deduplication cache
+// and circuit breaker metrics of the active components, which may change on reload
+func (p *Pipeline) RegisterMetrics(registry *metrics.Registry) {
+	registry.RegisterHistogram("kube_watcher_pipeline_stage_duration_seconds", "Time spent processing an event or batch in each pipeline stage.",
+		[]string{"stage"}, p.stageDurations)
+	registry.RegisterHistogram("kube_watcher_notifier_send_duration_seconds", "Time spent delivering a notification to each destination, including retries.",
+		[]string{"destination"}, p.sendDurations)
+	registry.RegisterVec("kube_watcher_pipeline_queue_length", "Items currently waiting in each pipeline queue: events being processed, batched events, stories being correlated and pending escalations.", metrics.TypeGauge,
+		[]string{"queue"}, p.queueLengths)
+
+	dedupMetric := func(fn func(m dedup.Metrics) float64) metrics.ValueFunc {
+		return func() float64 {
+			d := p.State().Deduplicator
+			if d == nil {
+				return 0
+			}
+			return fn(d.Metrics())
+		}
+	}
+
+	registry.Register("kube_watcher_dedup_hits_total", "Events suppressed as duplicates.", metrics.TypeCounter,
+		dedupMetric(func(m dedup.Metrics) float64 { return float64(m.Hits) }))
+	registry.Register("kube_watcher_dedup_misses_total", "Events that passed deduplication.", metrics.TypeCounter,
+		dedupMetric(func(m dedup.Metrics) float64 { return float64(m.Misses) }))
+	registry.Register("kube_watcher_dedup_evictions_total", "Cache entries evicted because the cache was full.", metrics.TypeCounter,
+		dedupMetric(func(m dedup.Metrics) float64 { return float64(m.Evictions) }))
+	registry.Register("kube_watcher_dedup_expirations_total", "Cache entries removed after their TTL elapsed.", metrics.TypeCounter,
+		dedupMetric(func(m dedup.Metrics) float64 { return float64(m.Expirations) }))
+	registry.Register("kube_watcher_dedup_cache_size", "Current number of entries in the deduplication cache.", metrics.TypeGauge,
+		dedupMetric(func(m dedup.Metrics) float64 { return float64(m.Size) }))
+	registry.Register("kube_watcher_dedup_cache_max_size", "Configured maximum deduplication cache size.", metrics.TypeGauge,
+		dedupMetric(func(m dedup.Metrics) float64 { return float64(m.MaxSize) }))
+
+	registry.Register("kube_watcher_notifier_circuit_open", "Notifiers whose circuit breaker is open.", metrics.TypeGauge,
+		func() float64 {
+			open := 0
+			for _, b := range p.State().Breakers {
+				if b.IsOpen() {
+					open++
+				}
+			}
+			return float64(open)
+		})
+	// Breakers removed on reload keep counting through removedDropped, so that
+	// the total never decreases
+	registry.Register("kube_watcher_notifier_dropped_total", "Notifications dropped while a circuit breaker was open.", metrics.TypeCounter,
+		func() float64 {
+			dropped := p.removedDropped.Load()
+			for _, b := range p.State().Breakers {
+				dropped += b.Dropped()
+			}
+			return float64(dropped)
+		})
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// monitorInterval is how often the state of the cached objects is checked by
+// the monitors, which warn about conditions that are not a change of a resource
+const monitorInterval = time.Minute
+
+// runMonitor passes the cached objects of the kind to check every
+// monitorInterval until ctx is cancelled
+func runMonitor(ctx context.Context, w *watcher.Watcher, kind string, check func(objects []*watcher.Event)) {
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check(w.Cached(kind))
+		}
+	}
+}
+
+// warningEvent returns a warning event about the object
+func warningEvent(object *watcher.Event, now time.Time, reason, message string) *watcher.Event {
+	warning := *object
+	warning.EventType = watcher.EventTypeWarning
+	warning.Timestamp = now
+	warning.Reason = reason
+	warning.Message = message
+	return &warning
+}
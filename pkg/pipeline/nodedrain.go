@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kqns91/kube-watcher/pkg/nodedrain"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// maxDrainPods is the number of evicted Pods named in a drain notification
+const maxDrainPods = 10
+
+// deliverDrain sends the cordon of a Node and the evictions from it as one notification
+func (p *Pipeline) deliverDrain(d *nodedrain.Drain) {
+	c := p.current()
+	event := drainEvent(d)
+	event.Cluster = c.cluster
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	ctx, span := p.tracer.Start(context.Background(), "drain", eventSpanAttributes(event)...)
+	defer span.End()
+	p.deliverEvent(ctx, span, c, event)
+}
+
+// drainEvent returns the notification about a drain: the Node's cordon
+// updated with the evictions, or a warning when the Node was not cordoned
+func drainEvent(d *nodedrain.Drain) *watcher.Event {
+	var event watcher.Event
+	if d.Cordon != nil {
+		event = *d.Cordon
+	} else {
+		event = watcher.Event{
+			Kind:      "Node",
+			Name:      d.Node,
+			EventType: watcher.EventTypeWarning,
+			Timestamp: d.StartTime,
+		}
+	}
+
+	if d.Evicted() == 0 {
+		event.Reason = watcher.ReasonNodeCordoned
+		event.Message = fmt.Sprintf("Node %s cordoned", d.Node)
+		return &event
+	}
+	event.Reason = watcher.ReasonNodeDrained
+	if d.Cordon != nil {
+		event.Message = fmt.Sprintf("Node %s cordoned and being drained (%d pods evicted)", d.Node, d.Evicted())
+	} else {
+		event.Message = fmt.Sprintf("Node %s being drained (%d pods evicted)", d.Node, d.Evicted())
+	}
+
+	pods := make([]string, 0, min(len(d.Evictions), maxDrainPods))
+	for _, e := range d.Evictions[:min(len(d.Evictions), maxDrainPods)] {
+		pods = append(pods, e.Namespace+"/"+e.Name)
+	}
+	event.Message += "\nEvicted: " + strings.Join(pods, ", ")
+	if more := len(d.Evictions) - len(pods); more > 0 {
+		event.Message += fmt.Sprintf(" and %d more", more)
+	}
+	return &event
+}
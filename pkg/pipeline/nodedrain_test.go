@@ -0,0 +1,45 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/nodedrain"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestDrainEvent(t *testing.T) {
+	cordon := &watcher.Event{Kind: "Node", Name: "node-1", EventType: "UPDATED", Status: watcher.StatusCordoned}
+
+	// 退避のないcordonはそのまま通知する
+	event := drainEvent(&nodedrain.Drain{Node: "node-1", Cordon: cordon})
+	if event.Reason != watcher.ReasonNodeCordoned || event.Message != "Node node-1 cordoned" || event.EventType != "UPDATED" {
+		t.Errorf("Unexpected cordon event %+v", event)
+	}
+	if cordon.Reason != "" {
+		t.Error("Expected the held cordon not to be modified")
+	}
+
+	var evictions []*watcher.Event
+	for i := range 12 {
+		evictions = append(evictions, &watcher.Event{Kind: "Pod", Namespace: "prod", Name: fmt.Sprintf("web-%d", i)})
+	}
+	event = drainEvent(&nodedrain.Drain{Node: "node-1", Cordon: cordon, Evictions: evictions})
+	if event.Reason != watcher.ReasonNodeDrained {
+		t.Errorf("Reason = %q", event.Reason)
+	}
+	if want := "Node node-1 cordoned and being drained (12 pods evicted)\nEvicted: prod/web-0, "; !strings.HasPrefix(event.Message, want) {
+		t.Errorf("Expected the message to start with %q, got %q", want, event.Message)
+	}
+	if !strings.HasSuffix(event.Message, "prod/web-9 and 2 more") {
+		t.Errorf("Expected the Pods beyond the limit to be counted, got %q", event.Message)
+	}
+
+	// cordonなしで検知したドレインは警告として通知し、検知前の退避も数える
+	event = drainEvent(&nodedrain.Drain{Node: "node-2", Evictions: evictions[:1], Earlier: 4})
+	if event.EventType != watcher.EventTypeWarning || event.Kind != "Node" || event.Name != "node-2" ||
+		!strings.HasPrefix(event.Message, "Node node-2 being drained (5 pods evicted)") {
+		t.Errorf("Unexpected drain event %+v", event)
+	}
+}
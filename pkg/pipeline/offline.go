@@ -0,0 +1,372 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// checkpointInterval is how often the progress of the informers is saved
+// between snapshots, which bounds the changes that are reported again after
+// an unclean shutdown
+const checkpointInterval = 10 * time.Second
+
+// maxOfflineDeletions is the number of deleted objects named in a report
+const maxOfflineDeletions = 10
+
+// offlineSnapshot is the state of the cached objects, saved for the next run
+type offlineSnapshot struct {
+	Taken    time.Time                   `json:"taken"`
+	Watched  []string                    `json:"watched"`            // ScopeKey of every watched kind and namespace
+	Objects  map[string]string           `json:"objects"`            // Kind/namespace/name -> fingerprint
+	Progress map[string]watcher.Progress `json:"progress,omitempty"` // ScopeKey -> progress of its informer
+}
+
+// offlineCheckpoint is the progress of the informers since the snapshot was
+// taken, saved much more often than the snapshot
+type offlineCheckpoint struct {
+	Taken    time.Time                   `json:"taken"`
+	Progress map[string]watcher.Progress `json:"progress"`
+	Deleted  []string                    `json:"deleted,omitempty"` // Objects of the snapshot whose deletion was notified since
+}
+
+// offlineTracker reports the changes made while kube-watcher was not running:
+// once the cache has synced, it is compared with the snapshot saved by the
+// previous run, and the snapshot is refreshed every interval from then on.
+// Objects notified by the previous run after its snapshot was taken are
+// recognized by the checkpointed progress, so that they are not reported again.
+type offlineTracker struct {
+	path     string
+	interval time.Duration
+	watched  map[string]bool // ScopeKey of every watched kind and namespace
+	notify   func(text string)
+	now      func() time.Time
+
+	mu       sync.Mutex
+	compared bool            // The previous snapshot was compared, so the cache may replace it
+	saved    map[string]bool // Objects of the last snapshot saved
+}
+
+// newOfflineTracker creates an offlineTracker for the watched resources,
+// passing its report to notify
+func newOfflineTracker(cfg *config.Config, notify func(text string)) *offlineTracker {
+	watched := make(map[string]bool)
+	for _, r := range cfg.Resources {
+		watched[watcher.ScopeKey(r.Kind, cfg.WatchNamespace(r))] = true
+	}
+	return &offlineTracker{
+		path:     cfg.Offline.Path,
+		interval: time.Duration(cfg.Offline.IntervalMinutes) * time.Minute,
+		watched:  watched,
+		notify:   notify,
+		now:      time.Now,
+	}
+}
+
+// checkpointPath is the file of the progress saved between snapshots
+func (t *offlineTracker) checkpointPath() string {
+	return t.path + ".progress"
+}
+
+// run waits for the cache of w to sync, reports the changes since the previous
+// run, then refreshes the snapshot and checkpoints the progress until ctx is
+// cancelled
+func (t *offlineTracker) run(ctx context.Context, w *watcher.Watcher) {
+	poll := time.NewTicker(time.Second)
+	defer poll.Stop()
+	for !w.Synced() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+	t.compare(w.Fingerprints(), w.Progress())
+
+	snapshots := time.NewTicker(t.interval)
+	defer snapshots.Stop()
+	checkpoints := time.NewTicker(checkpointInterval)
+	defer checkpoints.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-snapshots.C:
+			t.save(w.Fingerprints(), w.Progress())
+		case <-checkpoints.C:
+			t.checkpoint(w.Keys(), w.Progress())
+		}
+	}
+}
+
+// compare reports the changes between the previous run and the synced cache,
+// then replaces the snapshot
+func (t *offlineTracker) compare(objects map[string]watcher.Fingerprint, progress map[string]watcher.Progress) {
+	previous, checkpoint, err := t.load()
+	if err != nil {
+		slog.Warn("Failed to load the snapshot of the previous run", "path", t.path, "error", err)
+	}
+	if previous != nil {
+		changes := offlineChanges(previous, checkpoint, t.watched, objects)
+		if text := offlineMessage(changes, checkpoint.Taken, t.now()); text != "" {
+			t.notify(text)
+		} else {
+			slog.Info("No changes while offline", "since", checkpoint.Taken)
+		}
+	}
+
+	t.mu.Lock()
+	t.compared = true
+	t.mu.Unlock()
+	t.save(objects, progress)
+}
+
+// save replaces the snapshot with the cached objects. Nothing is saved before
+// the previous snapshot was compared, as the cache may not have synced yet.
+func (t *offlineTracker) save(objects map[string]watcher.Fingerprint, progress map[string]watcher.Progress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.compared {
+		return
+	}
+	snapshot := &offlineSnapshot{Taken: t.now(), Objects: make(map[string]string, len(objects)), Progress: progress}
+	for key := range t.watched {
+		snapshot.Watched = append(snapshot.Watched, key)
+	}
+	sort.Strings(snapshot.Watched)
+	saved := make(map[string]bool, len(objects))
+	for key, f := range objects {
+		snapshot.Objects[key] = f.Hash
+		saved[key] = true
+	}
+	if err := writeJSONFile(t.path, snapshot); err != nil {
+		slog.Error("Failed to save the snapshot", "path", t.path, "error", err)
+		return
+	}
+	t.saved = saved
+	// The snapshot includes the progress checkpointed so far
+	if err := os.Remove(t.checkpointPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Failed to remove the progress checkpoint", "path", t.checkpointPath(), "error", err)
+	}
+}
+
+// checkpoint saves the progress of the informers and the objects of the
+// snapshot deleted since it was taken
+func (t *offlineTracker) checkpoint(keys []string, progress map[string]watcher.Progress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.compared || t.saved == nil {
+		return
+	}
+	cached := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		cached[key] = true
+	}
+	checkpoint := &offlineCheckpoint{Taken: t.now(), Progress: progress}
+	for key := range t.saved {
+		if !cached[key] {
+			checkpoint.Deleted = append(checkpoint.Deleted, key)
+		}
+	}
+	sort.Strings(checkpoint.Deleted)
+	if err := writeJSONFile(t.checkpointPath(), checkpoint); err != nil {
+		slog.Error("Failed to save the progress checkpoint", "path", t.checkpointPath(), "error", err)
+	}
+}
+
+// load reads the snapshot of the previous run and the progress checkpointed
+// after it was taken, or the progress of the snapshot itself. A missing
+// snapshot yields no snapshot.
+func (t *offlineTracker) load() (*offlineSnapshot, *offlineCheckpoint, error) {
+	var snapshot offlineSnapshot
+	if found, err := readJSONFile(t.path, &snapshot); err != nil || !found {
+		return nil, nil, err
+	}
+	checkpoint := &offlineCheckpoint{Taken: snapshot.Taken, Progress: snapshot.Progress}
+	var later offlineCheckpoint
+	found, err := readJSONFile(t.checkpointPath(), &later)
+	if err != nil {
+		slog.Warn("Failed to load the progress checkpoint", "path", t.checkpointPath(), "error", err)
+	}
+	if found && !later.Taken.Before(snapshot.Taken) {
+		checkpoint = &later
+	}
+	return &snapshot, checkpoint, nil
+}
+
+// readJSONFile parses the JSON file into v and reports whether it exists
+func readJSONFile(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeJSONFile writes v to path as JSON atomically
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// kindChanges counts the changed objects of a kind
+type kindChanges struct {
+	added, updated int
+	deleted        []string // Namespace/name of the deleted objects
+}
+
+// offlineChanges counts the objects added, updated and deleted per kind
+// between the previous run and the current cache. Objects whose resource
+// version is within the checkpointed progress, and deletions notified after
+// the snapshot, were seen by the previous run and are skipped. So are objects
+// of kinds that were not watched in their namespace by both runs, as they
+// were not all seen by both.
+func offlineChanges(previous *offlineSnapshot, checkpoint *offlineCheckpoint, watched map[string]bool, current map[string]watcher.Fingerprint) map[string]*kindChanges {
+	watchedBefore := make(map[string]bool, len(previous.Watched))
+	for _, scope := range previous.Watched {
+		watchedBefore[scope] = true
+	}
+	// The scope of an object is the Kind/namespace prefix of its key
+	scopeOf := func(key string) (kind, scope string) {
+		kind, rest, _ := strings.Cut(key, "/")
+		namespace, _, _ := strings.Cut(rest, "/")
+		return kind, watcher.ScopeKey(kind, namespace)
+	}
+	watchedByBoth := func(key string) (string, bool) {
+		kind, scope := scopeOf(key)
+		return kind, watchedBefore[scope] && watched[scope]
+	}
+	seen := func(key string, f watcher.Fingerprint) bool {
+		_, scope := scopeOf(key)
+		return f.ResourceVersion != 0 && f.ResourceVersion <= checkpoint.Progress[scope].ResourceVersion
+	}
+	notified := make(map[string]bool, len(checkpoint.Deleted))
+	for _, key := range checkpoint.Deleted {
+		notified[key] = true
+	}
+
+	changes := make(map[string]*kindChanges)
+	count := func(kind string) *kindChanges {
+		c := changes[kind]
+		if c == nil {
+			c = &kindChanges{}
+			changes[kind] = c
+		}
+		return c
+	}
+	for key, f := range current {
+		kind, ok := watchedByBoth(key)
+		if !ok || seen(key, f) {
+			continue
+		}
+		before, existed := previous.Objects[key]
+		switch {
+		case !existed:
+			count(kind).added++
+		case before != f.Hash:
+			count(kind).updated++
+		}
+	}
+	for key := range previous.Objects {
+		kind, ok := watchedByBoth(key)
+		if !ok || notified[key] {
+			continue
+		}
+		if _, exists := current[key]; !exists {
+			c := count(kind)
+			c.deleted = append(c.deleted, strings.TrimPrefix(key[len(kind)+1:], "/"))
+		}
+	}
+	return changes
+}
+
+// offlineMessage summarizes the changes made since the previous run was last
+// known to be watching, e.g. "While kube-watcher was offline: 3 Deployments
+// updated, 1 Service deleted", naming the deleted objects as they are not
+// notified otherwise. It returns "" when nothing changed.
+func offlineMessage(changes map[string]*kindChanges, since, now time.Time) string {
+	kinds := make([]string, 0, len(changes))
+	for kind := range changes {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var parts, deleted []string
+	for _, kind := range kinds {
+		c := changes[kind]
+		sort.Strings(c.deleted)
+		for _, change := range []struct {
+			n    int
+			verb string
+		}{{c.added, "added"}, {c.updated, "updated"}, {len(c.deleted), "deleted"}} {
+			if change.n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s %s", change.n, pluralKind(kind, change.n), change.verb))
+			}
+		}
+		for _, name := range c.deleted {
+			deleted = append(deleted, kind+" "+name)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	message := fmt.Sprintf(":hourglass: While kube-watcher was offline (since %s, %s): %s",
+		since.Format(time.RFC3339), now.Sub(since).Round(time.Second), strings.Join(parts, ", "))
+	if len(deleted) > 0 {
+		named := deleted[:min(len(deleted), maxOfflineDeletions)]
+		message += "\nDeleted: " + strings.Join(named, ", ")
+		if more := len(deleted) - len(named); more > 0 {
+			message += fmt.Sprintf(" and %d more", more)
+		}
+	}
+	return message
+}
+
+// pluralKind returns the kind in the plural unless n is 1, e.g. "Ingresses"
+func pluralKind(kind string, n int) string {
+	switch {
+	case n == 1:
+		return kind
+	case strings.HasSuffix(kind, "s"):
+		return kind + "es"
+	default:
+		return kind + "s"
+	}
+}
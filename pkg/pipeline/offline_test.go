@@ -0,0 +1,151 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestOfflineMessage(t *testing.T) {
+	taken := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := &offlineSnapshot{
+		Taken:   taken,
+		Watched: []string{"Deployment/default", "Service/default", "Ingress/default", "Pod/default", "Node/"},
+		Objects: map[string]string{
+			"Deployment/default/api": "a1",
+			"Deployment/default/web": "w1",
+			"Deployment/default/job": "j1",
+			"Service/default/api":    "s1",
+			"Ingress/default/api":    "i1",
+			"Ingress/default/web":    "i2",
+			"Pod/default/api-1":      "p1",
+			"Node//node-1":           "n1",
+		},
+	}
+	checkpoint := &offlineCheckpoint{Taken: taken}
+	watched := map[string]bool{"Deployment/default": true, "Service/default": true, "Ingress/default": true, "Pod/staging": true, "Node/": true}
+	current := map[string]watcher.Fingerprint{
+		"Deployment/default/api": {Hash: "a2"},
+		"Deployment/default/web": {Hash: "w2"},
+		"Deployment/default/job": {Hash: "j1"},
+		"Ingress/default/api":    {Hash: "i1"},
+		"Ingress/default/web":    {Hash: "i2"},
+		"Ingress/default/admin":  {Hash: "i3"},
+		"Ingress/default/docs":   {Hash: "i4"},
+		"Pod/staging/api-2":      {Hash: "p2"},
+	}
+
+	got := offlineMessage(offlineChanges(previous, checkpoint, watched, current), taken, taken.Add(2*time.Hour))
+	want := "2 Deployments updated, 2 Ingresses added, 1 Node deleted, 1 Service deleted\nDeleted: Node node-1, Service default/api"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("Expected the summary to end with %q, got %q", want, got)
+	}
+	// 監視するNamespaceが変わったオブジェクトは比較しない
+	if strings.Contains(got, "Pod") {
+		t.Errorf("Expected Pods watched in another namespace to be skipped, got %q", got)
+	}
+	if !strings.Contains(got, "2h0m0s") {
+		t.Errorf("Expected the time since the previous run, got %q", got)
+	}
+
+	unchanged := map[string]watcher.Fingerprint{}
+	for key, hash := range previous.Objects {
+		unchanged[key] = watcher.Fingerprint{Hash: hash}
+	}
+	if got := offlineMessage(offlineChanges(previous, checkpoint, watched, unchanged), taken, taken); got != "" {
+		t.Errorf("Expected no message without changes, got %q", got)
+	}
+}
+
+func TestOfflineChanges_Checkpoint(t *testing.T) {
+	taken := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := &offlineSnapshot{
+		Taken:   taken,
+		Watched: []string{"Deployment/default", "Deployment/prod"},
+		Objects: map[string]string{
+			"Deployment/default/api":    "a1",
+			"Deployment/default/web":    "w1",
+			"Deployment/default/old":    "o1",
+			"Deployment/default/legacy": "l1",
+			"Deployment/prod/api":       "p1",
+		},
+	}
+	// スナップショット後も前回の実行が通知した変更と削除は報告しない
+	checkpoint := &offlineCheckpoint{
+		Taken:    taken.Add(4 * time.Minute),
+		Progress: map[string]watcher.Progress{"Deployment/default": {ResourceVersion: 100}, "Deployment/prod": {ResourceVersion: 50}},
+		Deleted:  []string{"Deployment/default/old"},
+	}
+	current := map[string]watcher.Fingerprint{
+		"Deployment/default/api":   {Hash: "a2", ResourceVersion: 90},  // 停止前に通知済み
+		"Deployment/default/web":   {Hash: "w2", ResourceVersion: 120}, // 停止中に更新
+		"Deployment/default/batch": {Hash: "b1", ResourceVersion: 95},  // 停止前に作成
+		"Deployment/default/cache": {Hash: "c1", ResourceVersion: 130}, // 停止中に作成
+		"Deployment/prod/api":      {Hash: "p2", ResourceVersion: 90},  // 進捗は名前空間ごとなので停止中の更新
+	}
+
+	changes := offlineChanges(previous, checkpoint, map[string]bool{"Deployment/default": true, "Deployment/prod": true}, current)
+	c := changes["Deployment"]
+	if c == nil || c.added != 1 || c.updated != 2 || len(c.deleted) != 1 || c.deleted[0] != "default/legacy" {
+		t.Errorf("Unexpected changes %+v", c)
+	}
+}
+
+func TestOfflineTracker_Snapshot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var notices []string
+	tracker := &offlineTracker{
+		path:    filepath.Join(t.TempDir(), "snapshot.json"),
+		watched: map[string]bool{"Service/default": true},
+		notify:  func(text string) { notices = append(notices, text) },
+		now:     func() time.Time { return now },
+	}
+	progress := map[string]watcher.Progress{"Service/default": {ResourceVersion: 10}}
+
+	// 比較前は同期前のキャッシュでスナップショットを上書きしない
+	tracker.save(map[string]watcher.Fingerprint{"Service/default/partial": {Hash: "x"}}, progress)
+	if s, _, err := tracker.load(); err != nil || s != nil {
+		t.Fatalf("Expected no snapshot before the comparison, got %+v, %v", s, err)
+	}
+
+	// 前回のスナップショットがなければ通知しない
+	tracker.compare(map[string]watcher.Fingerprint{
+		"Service/default/api": {Hash: "s1", ResourceVersion: 5},
+		"Service/default/web": {Hash: "w1", ResourceVersion: 10},
+	}, progress)
+	if len(notices) != 0 {
+		t.Fatalf("Expected no notice on the first run, got %v", notices)
+	}
+
+	// 通知済みの削除をチェックポイントに記録する
+	now = now.Add(time.Minute)
+	tracker.checkpoint([]string{"Service/default/api"}, map[string]watcher.Progress{"Service/default": {ResourceVersion: 15}})
+	_, checkpoint, err := tracker.load()
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if !checkpoint.Taken.Equal(now) || len(checkpoint.Deleted) != 1 || checkpoint.Deleted[0] != "Service/default/web" {
+		t.Fatalf("Unexpected checkpoint %+v", checkpoint)
+	}
+
+	now = now.Add(time.Hour)
+	tracker.compare(map[string]watcher.Fingerprint{"Service/default/api": {Hash: "s2", ResourceVersion: 20}}, progress)
+	if len(notices) != 1 || !strings.HasSuffix(notices[0], "1 Service updated") {
+		t.Fatalf("Expected only the update to be reported, got %v", notices)
+	}
+	if !strings.Contains(notices[0], "since 2024-01-01T00:01:00Z") {
+		t.Errorf("Expected the gap to start at the checkpoint, got %q", notices[0])
+	}
+
+	// 保存したスナップショットは以前のチェックポイントを置き換える
+	s, checkpoint, err := tracker.load()
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if !s.Taken.Equal(now) || s.Objects["Service/default/api"] != "s2" || !checkpoint.Taken.Equal(now) {
+		t.Errorf("Expected the snapshot to be replaced, got %+v, %+v", s, checkpoint)
+	}
+}
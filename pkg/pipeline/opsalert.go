@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// opsAlerter posts alerts about kube-watcher's own failures, at most one per
+// key and interval so that a persistent failure does not flood the channel.
+// A nil opsAlerter is valid and sends nothing.
+type opsAlerter struct {
+	send        func(text string)
+	minInterval time.Duration
+	now         func() time.Time
+
+	mu         sync.Mutex
+	last       map[string]time.Time // Key -> time the last alert was sent
+	suppressed map[string]int       // Key -> alerts suppressed since then
+}
+
+// newOpsAlerter creates an opsAlerter posting with send
+func newOpsAlerter(minInterval time.Duration, send func(text string)) *opsAlerter {
+	return &opsAlerter{
+		send:        send,
+		minInterval: minInterval,
+		now:         time.Now,
+		last:        make(map[string]time.Time),
+		suppressed:  make(map[string]int),
+	}
+}
+
+// alert posts text unless an alert with the same key was sent within the interval.
+// key identifies the failure, e.g. "watch:Pod".
+func (a *opsAlerter) alert(key, text string) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	now := a.now()
+	if last, ok := a.last[key]; ok && now.Sub(last) < a.minInterval {
+		a.suppressed[key]++
+		a.mu.Unlock()
+		slog.Debug("Ops alert suppressed", "key", key)
+		return
+	}
+	suppressed := a.suppressed[key]
+	a.last[key] = now
+	delete(a.suppressed, key)
+	a.mu.Unlock()
+
+	if suppressed > 0 {
+		text += fmt.Sprintf(" (%d similar alerts suppressed)", suppressed)
+	}
+	a.send(":rotating_light: kube-watcher: " + text)
+}
+
+// sendOpsAlert posts text to the named Slack destination, or to all of them when name is empty
+func sendOpsAlert(slack []slackDestination, name, text string) {
+	for _, d := range slack {
+		if name != "" && d.name != name {
+			continue
+		}
+		if err := d.notifier.Send(text); err != nil {
+			slog.Error("Failed to send ops alert", "destination", d.name, "error", err)
+		}
+	}
+}
+
+// sendMetaNotice posts a notice about the pipeline itself to the ops
+// destination, or to all Slack destinations when ops alerts are disabled
+func (p *Pipeline) sendMetaNotice(text string) {
+	currentSlack, opsConfig := p.currentOps()
+	destination := ""
+	if opsConfig.Enabled {
+		destination = opsConfig.Destination
+	}
+	sendOpsAlert(currentSlack, destination, text)
+}
+
+// notificationLost alerts that a notification to destination could not be
+// delivered nor recorded in the dead-letter queue
+func (a *opsAlerter) notificationLost(destination string, err error) {
+	a.alert("lost:"+destination, fmt.Sprintf("notifications to *%s* are being lost: %v", destination, err))
+}
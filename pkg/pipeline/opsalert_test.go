@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpsAlerter_RateLimit(t *testing.T) {
+	var sent []string
+	a := newOpsAlerter(5*time.Minute, func(text string) { sent = append(sent, text) })
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	a.alert("watch:Pod", "watch failed")
+	a.alert("watch:Pod", "watch failed")
+	a.alert("watch:Pod", "watch failed")
+	// キーが異なるアラートは個別に送信される
+	a.alert("reload", "reload failed")
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 alerts within the interval, got %v", sent)
+	}
+
+	// 間隔を過ぎると抑制された件数を添えて再送する
+	now = now.Add(5 * time.Minute)
+	a.alert("watch:Pod", "watch failed")
+	if len(sent) != 3 || !strings.HasSuffix(sent[2], "watch failed (2 similar alerts suppressed)") {
+		t.Errorf("Unexpected alerts %v", sent)
+	}
+
+	now = now.Add(5 * time.Minute)
+	a.alert("watch:Pod", "watch failed")
+	if len(sent) != 4 || strings.Contains(sent[3], "suppressed") {
+		t.Errorf("Expected the suppressed count to be reset, got %v", sent)
+	}
+}
+
+func TestOpsAlerter_Nil(t *testing.T) {
+	var a *opsAlerter
+	a.alert("reload", "reload failed")
+	a.notificationLost("slack", errors.New("timeout"))
+}
+
+func TestOpsAlerter_NotificationLost(t *testing.T) {
+	var sent []string
+	a := newOpsAlerter(time.Minute, func(text string) { sent = append(sent, text) })
+
+	a.notificationLost("webhook", errors.New("connection refused"))
+	a.notificationLost("webhook", errors.New("connection refused"))
+	if len(sent) != 1 || !strings.Contains(sent[0], "*webhook*") || !strings.Contains(sent[0], "connection refused") {
+		t.Errorf("Unexpected alerts %v", sent)
+	}
+}
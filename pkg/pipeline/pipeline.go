@@ -0,0 +1,461 @@
+// Package pipeline wires the kube-watcher components together: it watches the
+// configured resources and passes their events through the filters, silences,
+// maintenance windows, deduplication, batching and routing to the notifiers.
+//
+// Other Go programs can embed kube-watcher by creating a Pipeline from a
+// configuration and observing the events through Hooks:
+//
+//	p, err := pipeline.New(cfg, pipeline.Options{
+//		Hooks: pipeline.Hooks{
+//			OnNotify: func(e *watcher.Event) { ... },
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	go p.Start(ctx)
+//	defer p.Stop()
+package pipeline
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/batcher"
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/correlation"
+	"github.com/kqns91/kube-watcher/pkg/dedup"
+	"github.com/kqns91/kube-watcher/pkg/escalation"
+	"github.com/kqns91/kube-watcher/pkg/filter"
+	"github.com/kqns91/kube-watcher/pkg/formatter"
+	"github.com/kqns91/kube-watcher/pkg/history"
+	"github.com/kqns91/kube-watcher/pkg/maintenance"
+	"github.com/kqns91/kube-watcher/pkg/metrics"
+	"github.com/kqns91/kube-watcher/pkg/nodedrain"
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/router"
+	"github.com/kqns91/kube-watcher/pkg/severity"
+	"github.com/kqns91/kube-watcher/pkg/silence"
+	"github.com/kqns91/kube-watcher/pkg/storm"
+	"github.com/kqns91/kube-watcher/pkg/tracing"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Stage is the pipeline stage that dropped an event
+type Stage string
+
+// Stages reported to Hooks.OnDropped. They are also recorded as the droppedBy span attribute.
+const (
+	StageFilter      Stage = "filter"      // Excluded by the filters
+	StageSilence     Stage = "silence"     // Matched an active silence
+	StageMaintenance Stage = "maintenance" // Fell into a maintenance window
+	StageCrashLoop   Stage = "crashloop"   // Reported in the crash loop report of its workload
+	StageDedup       Stage = "dedup"       // Duplicate of a recent event
+	StageRouter      Stage = "router"      // Matched no route
+)
+
+// Hooks observe events as they pass through the pipeline. All hooks are
+// optional and are called synchronously, so they must not block.
+type Hooks struct {
+	// OnAccepted is called for events that passed the filters
+	OnAccepted func(e *watcher.Event)
+	// OnDropped is called for events that are not notified
+	OnDropped func(e *watcher.Event, stage Stage)
+	// OnDedup is called with the result of deduplication for events subject to it
+	OnDedup func(e *watcher.Event, duplicate bool)
+	// OnNotify is called for events that are about to be batched or sent
+	OnNotify func(e *watcher.Event)
+	// OnBatch is called when a batching window is flushed, before the batch is sent
+	OnBatch func(batch *batcher.Batch)
+	// OnConfig is called when a configuration is applied, including the initial one
+	OnConfig func(c *config.Config)
+}
+
+// Options configures a Pipeline
+type Options struct {
+	Hooks        Hooks
+	Tracer       *tracing.Tracer // Traces the events; nil disables tracing
+	DryRunOutput io.Writer       // Receives the notifications in dry-run mode; defaults to os.Stdout
+	Acks         *history.Acks   // Tracks the critical events until acknowledged; nil disables tracking
+}
+
+// ReloadStatus is the result of the last configuration reload
+type ReloadStatus struct {
+	At  time.Time
+	Err error // nil when the configuration was applied
+}
+
+// State is a snapshot of the active configuration and components
+type State struct {
+	Config        *config.Config
+	Cluster       string
+	LastReload    *ReloadStatus               // nil until the first reload
+	Deduplicator  *dedup.Deduplicator         // nil when deduplication is disabled
+	Batcher       *batcher.Batcher            // nil when batching is disabled
+	RouteBatchers map[string]*batcher.Batcher // Route name -> batcher of the routes batching in their own window
+	Breakers      []*notifier.CircuitBreaker
+}
+
+// components are the parts of the pipeline that are replaced on reload
+type components struct {
+	config       *config.Config
+	cluster      string
+	formatter    *formatter.Formatter
+	enricher     *filter.Enricher
+	filter       *filter.Filter
+	dedup        *dedup.Deduplicator
+	dedupBypass  *filter.EventMatcher
+	correlator   *correlation.Correlator  // nil when correlation is disabled
+	storms       *storm.Detector          // nil when storm detection is disabled
+	drains       *nodedrain.Detector      // nil when node drain detection is disabled
+	batchers     map[int]*batcher.Batcher // Batch key -> batcher; see batchKey
+	slack        []slackDestination
+	sinks        []notifier.EventNotifier // Notifiers receiving structured event payloads
+	router       *router.Router
+	routeFormats map[int]routeFormatting // Route index -> Slack formatting overrides
+	breakers     []*notifier.CircuitBreaker
+	deadLetters  *notifier.DeadLetterQueue
+	recorder     *watcher.EventRecorder // Records Kubernetes Events for sent notifications
+	critical     *filter.EventMatcher   // Events tracked until acknowledged; nil when acknowledgements are disabled
+	severity     *severity.Classifier   // nil when severity is disabled
+}
+
+// Pipeline processes the events of the watched resources
+type Pipeline struct {
+	hooks           Hooks
+	tracer          *tracing.Tracer
+	dryRun          *notifier.DryRun // Shared by all notifiers in dry-run mode so that lines are not interleaved
+	detectedCluster string           // Used when clusterName is not configured
+	acks            *history.Acks
+
+	ops         *opsAlerter
+	silences    *silence.Manager
+	maintenance *maintenance.Manager
+	escalations *escalation.Manager
+	heartbeat   *heartbeat
+
+	stageDurations *metrics.HistogramVec // Processing time per stage
+	sendDurations  *metrics.HistogramVec // Delivery time per destination
+	inFlight       atomic.Int64          // Events being processed by HandleEvent
+	crashLoops     atomic.Bool           // Crash-looping Pods are reported per workload; set by Start
+	removedDropped atomic.Int64          // Notifications dropped by circuit breakers removed on reload
+
+	mu         sync.RWMutex // Protects the fields below
+	c          components
+	lastReload *ReloadStatus
+	cancel     context.CancelFunc // Stops Start
+	stopped    chan struct{}      // Closed when Start returns
+	cluster    clusterReader      // Reads container logs, related Events and failed Pods; set by Start
+
+	drainOnce sync.Once
+}
+
+// New creates a Pipeline with the initial configuration. The resources are
+// watched once Start is called.
+func New(cfg *config.Config, opts Options) (*Pipeline, error) {
+	output := opts.DryRunOutput
+	if output == nil {
+		output = os.Stdout
+	}
+	p := &Pipeline{
+		hooks:           opts.Hooks,
+		tracer:          opts.Tracer,
+		dryRun:          notifier.NewDryRun(output),
+		detectedCluster: watcher.DetectClusterName(),
+		acks:            opts.Acks,
+		stageDurations:  metrics.NewHistogramVec(),
+		sendDurations:   metrics.NewHistogramVec(),
+	}
+
+	// Alerts about failures of kube-watcher itself, posted to the ops destination
+	p.ops = newOpsAlerter(time.Duration(cfg.OpsAlerts.MinIntervalSeconds)*time.Second, func(text string) {
+		currentSlack, opsConfig := p.currentOps()
+		if opsConfig.Enabled {
+			sendOpsAlert(currentSlack, opsConfig.Destination, text)
+		}
+	})
+
+	// Silences suppress matching events until they expire; the number of
+	// suppressed events is reported when a silence ends
+	p.silences = silence.NewManager(func(s silence.Silence) {
+		slog.Info("Silence ended", "id", s.ID, "source", s.Source, "suppressed", s.Suppressed)
+		if s.Suppressed == 0 {
+			return
+		}
+		p.sendMetaNotice(silenceEndedMessage(s))
+	})
+
+	// Maintenance windows suppress matching events; digest windows deliver
+	// them as one batch when the window ends
+	p.maintenance = maintenance.NewManager(func(d *maintenance.Digest) {
+		if len(d.Events) == 0 {
+			return
+		}
+		p.deliverBatch("maintenanceDigest", &batcher.Batch{Events: d.Events, StartTime: d.StartTime, EndTime: d.EndTime}, digestOptions)
+	})
+
+	// Notified events matching an escalation rule are re-sent when they are
+	// neither acknowledged nor resolved in time
+	var acked escalation.AckedFunc
+	if p.acks != nil {
+		acked = p.acks.IsAcknowledged
+	}
+	p.escalations = escalation.NewManager(p.escalate, acked)
+
+	// The heartbeat reports the activity since the previous one on a schedule
+	p.heartbeat = newHeartbeat(func(destination, text string) {
+		currentSlack, _ := p.currentOps()
+		sendOpsAlert(currentSlack, destination, text)
+	})
+
+	if err := p.apply(cfg); err != nil {
+		p.silences.Stop()
+		p.maintenance.Stop()
+		p.escalations.Stop()
+		p.heartbeat.Stop()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Start watches the resources until ctx is cancelled or Stop is called, then
+// drains the pipeline. It returns once the pipeline is drained.
+func (p *Pipeline) Start(ctx context.Context) error {
+	w, err := watcher.NewWatcher(p.State().Config, p.HandleEvent)
+	if err != nil {
+		return err
+	}
+	w.SetWatchErrorHandler(func(kind string, err error) {
+		p.ops.alert("watch:"+kind, "watching *"+kind+"* resources failed, events may be missed: "+err.Error())
+	})
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopped := make(chan struct{})
+	defer close(stopped)
+	p.mu.Lock()
+	p.cancel = cancel
+	p.stopped = stopped
+	p.cluster = w
+	p.mu.Unlock()
+
+	if cfg := p.State().Config.Watchdog; cfg.Enabled {
+		go newWatchdog(time.Duration(cfg.StallSeconds)*time.Second, p.sendMetaNotice).run(ctx, w)
+	}
+	if cfg := p.State().Config.CronJobs; cfg.Enabled {
+		go runMonitor(ctx, w, "CronJob", newCronMonitor(cfg, p.HandleEvent).check)
+	}
+	if cfg := p.State().Config.Volumes; cfg.Enabled {
+		go runMonitor(ctx, w, "PersistentVolumeClaim", newVolumeMonitor(cfg, p.HandleEvent).check)
+	}
+	if cfg := p.State().Config.CrashLoops; cfg.Enabled {
+		p.crashLoops.Store(true)
+		go runMonitor(ctx, w, "Pod", newCrashLoopMonitor(cfg, p.HandleEvent).check)
+	}
+	var offline *offlineTracker
+	if cfg := p.State().Config; cfg.Offline.Enabled {
+		offline = newOfflineTracker(cfg, p.sendMetaNotice)
+		go offline.run(ctx, w)
+	}
+
+	// Start returns after the informers and their running event handlers have stopped
+	if err := w.Start(ctx); err != nil {
+		return err
+	}
+	// The cache is kept after the informers stopped, so the snapshot is up to date on the next start
+	if offline != nil {
+		offline.save(w.Fingerprints(), w.Progress())
+	}
+	p.drain()
+	return nil
+}
+
+// Stop stops watching and drains the pipeline: the pending batch and the
+// maintenance digests are delivered and the notifiers are closed. It blocks
+// until the notifications being sent are done.
+func (p *Pipeline) Stop() {
+	p.mu.RLock()
+	cancel, stopped := p.cancel, p.stopped
+	p.mu.RUnlock()
+	if cancel != nil {
+		cancel()
+		<-stopped
+	}
+	p.drain()
+}
+
+// drain flushes the pending notifications and closes the notifiers. Called once
+// no more events are delivered.
+func (p *Pipeline) drain() {
+	p.drainOnce.Do(func() {
+		// Deliver the digests of windows still open
+		p.maintenance.Stop()
+		p.silences.Stop()
+		p.escalations.Stop()
+		p.heartbeat.Stop()
+
+		// Node drains are sent first, as their notifications may still be batched
+		p.mu.Lock()
+		finalDrains := p.c.drains
+		p.c.drains = nil
+		p.mu.Unlock()
+		if finalDrains != nil {
+			finalDrains.Stop()
+		}
+
+		// Storms are summarized first, then stories are completed, as their
+		// single events may still be batched
+		p.mu.Lock()
+		finalStorms := p.c.storms
+		p.c.storms = nil
+		p.mu.Unlock()
+		if finalStorms != nil {
+			finalStorms.Stop()
+		}
+
+		p.mu.Lock()
+		finalCorrelator := p.c.correlator
+		p.c.correlator = nil
+		p.mu.Unlock()
+		if finalCorrelator != nil {
+			finalCorrelator.Stop()
+		}
+
+		p.mu.Lock()
+		finalBatchers := p.c.batchers
+		p.c.batchers = nil
+		p.mu.Unlock()
+		stopBatchers(finalBatchers)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.c.dedup != nil {
+			p.c.dedup.Stop()
+			p.c.dedup = nil
+		}
+		closeNotifiers(p.c.sinks)
+		if err := p.c.deadLetters.Close(); err != nil {
+			slog.Error("Failed to close dead-letter file", "error", err)
+		}
+		p.c.recorder.Stop()
+		p.c.recorder = nil
+	})
+}
+
+// Reload applies a new configuration. When it fails, the previous configuration
+// stays active; report the error with ReloadFailed.
+func (p *Pipeline) Reload(cfg *config.Config) error {
+	slog.Info("Applying new configuration", "namespaces", cfg.WatchedNamespaces())
+	if err := p.apply(cfg); err != nil {
+		return err
+	}
+	p.reloaded(nil)
+	return nil
+}
+
+// ReloadFailed records and reports a configuration that could not be loaded or applied
+func (p *Pipeline) ReloadFailed(err error) {
+	p.reloaded(err)
+}
+
+// reloaded reports the reload result with the notification settings of the active configuration
+func (p *Pipeline) reloaded(reloadErr error) {
+	p.mu.Lock()
+	p.lastReload = &ReloadStatus{At: time.Now(), Err: reloadErr}
+	currentSlack := p.c.slack
+	currentConfig := p.c.config
+	p.mu.Unlock()
+
+	if currentConfig.Reload.Notify {
+		sendReloadMessage(currentSlack, currentConfig.Reload.NotifyDestination, reloadMessage(strings.Join(currentConfig.WatchedNamespaces(), ", "), reloadErr))
+	}
+	if reloadErr != nil {
+		p.ops.alert("reload", "configuration reload failed, the previous configuration stays active: "+reloadErr.Error())
+	}
+}
+
+// State returns a snapshot of the active configuration and components
+func (p *Pipeline) State() State {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return State{
+		Config:        p.c.config,
+		Cluster:       p.c.cluster,
+		LastReload:    p.lastReload,
+		Deduplicator:  p.c.dedup,
+		Batcher:       p.c.batchers[globalBatch],
+		RouteBatchers: p.c.routeBatchers(),
+		Breakers:      p.c.breakers,
+	}
+}
+
+// Silences returns the silence manager, for the admin API
+func (p *Pipeline) Silences() *silence.Manager {
+	return p.silences
+}
+
+// Broadcast posts text to all Slack destinations
+func (p *Pipeline) Broadcast(text string) {
+	p.mu.RLock()
+	currentSlack := p.c.slack
+	p.mu.RUnlock()
+	for _, d := range currentSlack {
+		if err := d.notifier.Send(text); err != nil {
+			slog.Error("Failed to send message", "destination", d.name, "error", err)
+		}
+	}
+}
+
+// current returns the active components
+func (p *Pipeline) current() components {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.c
+}
+
+// currentOps returns the Slack destinations and ops alert settings of the active configuration
+func (p *Pipeline) currentOps() ([]slackDestination, config.OpsAlertsConfig) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.c.config == nil {
+		return nil, config.OpsAlertsConfig{}
+	}
+	return p.c.slack, p.c.config.OpsAlerts
+}
+
+// reportNotifierHealth reports circuit breaker transitions as a meta-alert through
+// the other Slack destinations, or through the ops destination when ops alerts are enabled
+func (p *Pipeline) reportNotifierHealth(name string, open bool, cause error) {
+	var text string
+	if open {
+		slog.Warn("Circuit breaker opened", "notifier", name, "error", cause)
+		text = ":rotating_light: Notifier *" + name + "* is unhealthy, notifications are suspended: " + cause.Error()
+	} else {
+		slog.Info("Circuit breaker closed", "notifier", name)
+		text = ":white_check_mark: Notifier *" + name + "* has recovered"
+	}
+
+	currentSlack, opsConfig := p.currentOps()
+	if opsConfig.Enabled {
+		if open {
+			p.ops.alert("breaker:"+name, "notifier *"+name+"* is unhealthy, notifications are suspended: "+cause.Error())
+		} else {
+			sendOpsAlert(currentSlack, opsConfig.Destination, text)
+		}
+		return
+	}
+	for _, d := range currentSlack {
+		if d.name == name {
+			continue
+		}
+		if err := d.notifier.Send(text); err != nil {
+			slog.Error("Failed to send notifier health alert", "destination", d.name, "error", err)
+		}
+	}
+}
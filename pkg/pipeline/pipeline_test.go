@@ -0,0 +1,590 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/batcher"
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/escalation"
+	"github.com/kqns91/kube-watcher/pkg/history"
+	"github.com/kqns91/kube-watcher/pkg/metrics"
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := &config.Config{
+		Namespace: "default",
+		Resources: []config.ResourceConfig{{Kind: "Pod"}},
+		Filters:   []config.FilterConfig{{Resource: "Pod", EventTypes: []string{"ADDED"}}},
+		DryRun:    true,
+	}
+	cfg.Deduplication.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+	return cfg
+}
+
+func TestPipeline_HandleEvent(t *testing.T) {
+	var (
+		out      bytes.Buffer
+		accepted []string
+		dropped  []Stage
+		notified []string
+	)
+	p, err := New(newTestConfig(t), Options{
+		DryRunOutput: &out,
+		Hooks: Hooks{
+			OnAccepted: func(e *watcher.Event) { accepted = append(accepted, e.Name) },
+			OnDropped:  func(e *watcher.Event, stage Stage) { dropped = append(dropped, stage) },
+			OnNotify:   func(e *watcher.Event) { notified = append(notified, e.Name) },
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	// フィルタで除外されるイベント
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "UPDATED"})
+	// 通知されるイベントと、その重複
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"})
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"})
+
+	if len(dropped) != 2 || dropped[0] != StageFilter || dropped[1] != StageDedup {
+		t.Errorf("Unexpected dropped stages %v", dropped)
+	}
+	if len(accepted) != 2 || len(notified) != 1 {
+		t.Errorf("Expected 2 accepted and 1 notified event, got %v and %v", accepted, notified)
+	}
+	if n := strings.Count(out.String(), "\n"); n != 1 || !strings.Contains(out.String(), `"destination":"slack"`) {
+		t.Errorf("Expected one dry-run notification to slack, got %q", out.String())
+	}
+}
+
+func TestPipeline_Reload(t *testing.T) {
+	var configs []*config.Config
+	p, err := New(newTestConfig(t), Options{
+		DryRunOutput: &bytes.Buffer{},
+		Hooks:        Hooks{OnConfig: func(c *config.Config) { configs = append(configs, c) }},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+	if p.State().LastReload != nil {
+		t.Error("Expected no reload status before the first reload")
+	}
+
+	next := newTestConfig(t)
+	next.ClusterName = "prod"
+	if err := p.Reload(next); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	state := p.State()
+	if state.Config != next || state.Cluster != "prod" || len(configs) != 2 {
+		t.Errorf("Expected the new configuration to be active, got cluster %q after %d configurations", state.Cluster, len(configs))
+	}
+	if state.LastReload == nil || state.LastReload.Err != nil {
+		t.Errorf("Unexpected reload status %+v", state.LastReload)
+	}
+
+	// 適用できない設定では以前の設定が維持される
+	invalid := newTestConfig(t)
+	invalid.Notifier.Slack.Template = "{{"
+	if err := p.Reload(invalid); err == nil {
+		t.Fatal("Expected an error for an invalid template")
+	}
+	p.ReloadFailed(errors.New("invalid template"))
+	state = p.State()
+	if state.Config != next {
+		t.Error("Expected the previous configuration to stay active")
+	}
+	if state.LastReload == nil || state.LastReload.Err == nil {
+		t.Errorf("Expected the failure to be recorded, got %+v", state.LastReload)
+	}
+}
+
+func TestPipeline_ReloadKeepsBreakers(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Notifier.CircuitBreaker = config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 3, CoolDownSeconds: 60}
+	p, err := New(cfg, Options{DryRunOutput: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+	before := p.State().Breakers
+	if len(before) == 0 {
+		t.Fatal("Expected circuit breakers for the notifiers")
+	}
+
+	// 同じ名前の通知先はリロード後も同じブレーカーを使い、状態を引き継ぐ
+	next := newTestConfig(t)
+	next.Notifier.CircuitBreaker = config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 5, CoolDownSeconds: 30}
+	if err := p.Reload(next); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	after := p.State().Breakers
+	if len(after) != len(before) {
+		t.Fatalf("Expected %d breakers after reload, got %d", len(before), len(after))
+	}
+	for i := range before {
+		if after[i] != before[i] {
+			t.Errorf("Expected breaker %s to be kept across the reload", before[i].Name())
+		}
+	}
+}
+
+func TestPipeline_ReloadKeepsDroppedCount(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Notifier.CircuitBreaker = config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, CoolDownSeconds: 60}
+	p, err := New(cfg, Options{DryRunOutput: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+	breaker := p.State().Breakers[0]
+	_ = breaker.Do(func() error { return &notifier.StatusError{StatusCode: 503} })
+	_ = breaker.Do(func() error { return nil })
+	if breaker.Dropped() != 1 {
+		t.Fatalf("Expected 1 dropped notification, got %d", breaker.Dropped())
+	}
+
+	registry := metrics.NewRegistry()
+	p.RegisterMetrics(registry)
+
+	// ブレーカーを無効にしてリロードしても、破棄された通知の数は減らない
+	next := newTestConfig(t)
+	if err := p.Reload(next); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(p.State().Breakers) != 0 {
+		t.Fatalf("Expected no circuit breakers after reload, got %d", len(p.State().Breakers))
+	}
+	var buf bytes.Buffer
+	_, _ = registry.WriteTo(&buf)
+	if !strings.Contains(buf.String(), "kube_watcher_notifier_dropped_total 1") {
+		t.Errorf("Expected the dropped count to survive the reload, got:\n%s", buf.String())
+	}
+}
+
+func TestPipeline_Acks(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.History = config.HistoryConfig{Enabled: true, Path: t.TempDir()}
+	cfg.Acks = config.AcksConfig{
+		Enabled:            true,
+		Critical:           config.MatcherConfig{Expression: `event.name == "db"`},
+		SlackSigningSecret: config.SecretConfig{Value: "secret"},
+	}
+	cfg.Notifier.Slack.Format = "blocks"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+	acks, err := history.OpenAcks(cfg.History.Path, 0)
+	if err != nil {
+		t.Fatalf("OpenAcks() error = %v", err)
+	}
+	defer acks.Close()
+
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out, Acks: acks})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	web := &watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"}
+	db := &watcher.Event{Kind: "Pod", Namespace: "default", Name: "db", EventType: "ADDED"}
+	p.HandleEvent(web)
+	p.HandleEvent(db)
+
+	// すべてのイベントにIDが付与され、重要なイベントのみ追跡される
+	if web.ID == "" || db.ID == "" || web.ID == db.ID {
+		t.Fatalf("Expected unique event IDs, got %q and %q", web.ID, db.ID)
+	}
+	if tracked := acks.List(true); len(tracked) != 1 || tracked[0].ID != db.ID {
+		t.Errorf("Expected only db to be tracked, got %+v", tracked)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "kube_watcher_ack") || !strings.Contains(lines[1], `"value":"`+db.ID+`"`) {
+		t.Errorf("Expected the Acknowledge button on the db notification only, got %q", out.String())
+	}
+}
+
+func TestPipeline_Escalations(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Filters[0].EventTypes = []string{"ADDED", "UPDATED"}
+	cfg.Deduplication.Enabled = false
+	cfg.Escalations = []config.EscalationRule{{
+		Name:          "crash",
+		MatcherConfig: config.MatcherConfig{Expression: `event.status == "CrashLoopBackOff"`},
+		AfterMinutes:  5,
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	crash := &watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED", Status: "CrashLoopBackOff"}
+	p.HandleEvent(crash)
+	if n := p.escalations.Pending(); n != 1 {
+		t.Fatalf("Expected 1 pending escalation, got %d", n)
+	}
+
+	// エスカレーションは元の通知と同じ宛先に再送される
+	out.Reset()
+	rules, _ := escalation.NewRules(cfg.Escalations)
+	p.escalate(&escalation.Escalation{Rule: rules[0], Event: crash})
+	if !strings.Contains(out.String(), `"destination":"slack"`) || !strings.Contains(out.String(), "Escalated by `crash`") {
+		t.Errorf("Expected the escalation to be sent to slack, got %q", out.String())
+	}
+
+	// 条件に一致しなくなったイベントで解決される
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "UPDATED", Status: "Running"})
+	if n := p.escalations.Pending(); n != 0 {
+		t.Errorf("Expected the escalation to be resolved, got %d pending", n)
+	}
+}
+
+func TestPipeline_Correlation(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Resources = []config.ResourceConfig{{Kind: "Deployment"}, {Kind: "ReplicaSet"}, {Kind: "Pod"}}
+	cfg.Filters = nil
+	cfg.Deduplication.Enabled = false
+	cfg.Correlation.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	p.HandleEvent(&watcher.Event{Kind: "Deployment", Namespace: "default", Name: "api", EventType: "UPDATED"})
+	p.HandleEvent(&watcher.Event{Kind: "ReplicaSet", Namespace: "default", Name: "api-7d9f", EventType: "ADDED", Owner: &watcher.OwnerRef{Kind: "Deployment", Name: "api"}})
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "api-7d9f-a", EventType: "ADDED", Owner: &watcher.OwnerRef{Kind: "ReplicaSet", Name: "api-7d9f"}})
+	// 関係のないイベントはすぐに送信される
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"})
+	if n := strings.Count(out.String(), "\n"); n != 1 {
+		t.Fatalf("Expected only the unrelated event to be sent, got %q", out.String())
+	}
+
+	// 停止時に保留中のストーリーが1件の通知として送信される
+	p.Stop()
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "Deployment default/api") {
+		t.Errorf("Expected one story notification, got %q", out.String())
+	}
+}
+
+func TestPipeline_Storm(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Storm = config.StormConfig{Enabled: true, MinEvents: 3}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: name, EventType: "ADDED"})
+	}
+	// 3件目で嵐が始まり、以降は保留される
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], "Event storm started") {
+		t.Fatalf("Expected 2 notifications and the start of the storm, got %q", out.String())
+	}
+
+	// 停止時に保留中のイベントが要約され、終了が通知される
+	p.Stop()
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 || !strings.Contains(lines[4], "Event storm ended: 3 Pod events in namespace default") {
+		t.Errorf("Expected a summary and the end of the storm, got %q", out.String())
+	}
+}
+
+func TestPipeline_SeverityBehaviors(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Batching = config.BatchingConfig{Enabled: true, WindowSeconds: 30}
+	never := false
+	cfg.Severity = config.SeverityConfig{
+		Enabled: true,
+		Rules:   []config.SeverityRule{{Severity: "critical", MatcherConfig: config.MatcherConfig{Names: []string{"db"}}}},
+		Behaviors: map[string]config.SeverityBehavior{
+			"critical": {Immediate: true, Deduplicate: &never},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// criticalのイベントは重複排除もバッチ処理もされずにすぐ送信される
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "db", EventType: "ADDED"})
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "db", EventType: "ADDED"})
+	// それ以外のイベントはバッチに追加される
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"})
+	if n := strings.Count(out.String(), "\n"); n != 2 {
+		t.Fatalf("Expected the critical events to be sent immediately, got %q", out.String())
+	}
+
+	p.Stop()
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], "web") {
+		t.Errorf("Expected the batch to be sent on stop, got %q", out.String())
+	}
+}
+
+func TestPipeline_UnroutedBatchedEvents(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Severity = config.SeverityConfig{Enabled: true}
+	cfg.Notifier.Slack.WebhookURL = "https://example.com/slack"
+	cfg.Routes = []config.RouteConfig{
+		{MatcherConfig: config.MatcherConfig{Severities: []string{"critical"}}, Destinations: []string{"slack"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var (
+		out     bytes.Buffer
+		dropped []string
+	)
+	p, err := New(cfg, Options{
+		DryRunOutput: &out,
+		Hooks: Hooks{OnDropped: func(e *watcher.Event, stage Stage) {
+			if stage == StageRouter {
+				dropped = append(dropped, e.Name)
+			}
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	// どのルートにも一致しないバッチ内のイベントはルーターで除外されたと報告する
+	now := time.Now()
+	p.deliverBatch("story", &batcher.Batch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Namespace: "default", Name: "db", EventType: "DELETED", Severity: &watcher.Severity{Level: "critical"}},
+			{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED", Severity: &watcher.Severity{Level: "info"}},
+		},
+		StartTime: now,
+		EndTime:   now,
+	}, batchOptions(cfg))
+
+	if len(dropped) != 1 || dropped[0] != "web" {
+		t.Errorf("Expected web to be dropped by the router, got %v", dropped)
+	}
+	if !strings.Contains(out.String(), "db") || strings.Contains(out.String(), "web") {
+		t.Errorf("Expected only db to be sent, got %q", out.String())
+	}
+}
+
+func TestPipeline_Metrics(t *testing.T) {
+	var out bytes.Buffer
+	p, err := New(newTestConfig(t), Options{DryRunOutput: &out})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"})
+
+	registry := metrics.NewRegistry()
+	p.RegisterMetrics(registry)
+	var buf bytes.Buffer
+	_, _ = registry.WriteTo(&buf)
+
+	// 通知されたイベントは各ステージの処理時間と通知先ごとの送信時間が記録される
+	for _, stage := range []string{"enrich", "filter", "dedup", "route", "format", "send"} {
+		if !strings.Contains(buf.String(), `kube_watcher_pipeline_stage_duration_seconds_count{stage="`+stage+`"} 1`) {
+			t.Errorf("Expected the %s stage to be observed once", stage)
+		}
+	}
+	if !strings.Contains(buf.String(), `kube_watcher_notifier_send_duration_seconds_count{destination="slack"} 1`) {
+		t.Error("Expected the send to slack to be observed")
+	}
+	if !strings.Contains(buf.String(), `kube_watcher_pipeline_queue_length{queue="in_flight"} 0`) {
+		t.Errorf("Expected no events in flight, got:\n%s", buf.String())
+	}
+}
+
+// parseDryRun returns the payloads of dry-run output by destination
+func parseDryRun(t *testing.T, out string) map[string]string {
+	t.Helper()
+	payloads := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var record struct {
+			Destination string          `json:"destination"`
+			Payload     json.RawMessage `json:"payload"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid dry-run record %q: %v", line, err)
+		}
+		payloads[record.Destination] = string(record.Payload)
+	}
+	return payloads
+}
+
+func TestPipeline_RouteFormatting(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Notifier.Slack.WebhookURL = "https://example.com/slack"
+	cfg.Notifier.Slack.Destinations = []config.SlackDestinationConfig{
+		{Name: "incidents", WebhookURL: "https://example.com/incidents"},
+		{Name: "noisy", WebhookURL: "https://example.com/noisy"},
+	}
+	cfg.Routes = []config.RouteConfig{
+		{Destinations: []string{"incidents"}, Format: "blocks", Continue: true},
+		{Destinations: []string{"noisy"}, Template: "{{.Kind}} {{.Name}}", Format: "text", Continue: true},
+		{Destinations: []string{"slack"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Invalid test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	p, err := New(cfg, Options{DryRunOutput: &out})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Stop()
+
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"})
+
+	// 通知先ごとにルートの書式で整形される
+	payloads := parseDryRun(t, out.String())
+	if len(payloads) != 3 {
+		t.Fatalf("Expected 3 destinations, got %q", out.String())
+	}
+	if !strings.Contains(payloads["incidents"], `"blocks"`) {
+		t.Errorf("Expected Block Kit for incidents, got %s", payloads["incidents"])
+	}
+	if !strings.Contains(payloads["noisy"], `"text":"Pod web"`) {
+		t.Errorf("Expected the route template for noisy, got %s", payloads["noisy"])
+	}
+	if !strings.Contains(payloads["slack"], `"attachments"`) {
+		t.Errorf("Expected the default attachments for slack, got %s", payloads["slack"])
+	}
+
+	// バッチ通知も通知先ごとにルートの書式で整形される
+	out.Reset()
+	now := time.Now()
+	p.deliverBatch("storm", &batcher.Batch{
+		Events: []*watcher.Event{
+			{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"},
+			{Kind: "Pod", Namespace: "default", Name: "db", EventType: "DELETED"},
+		},
+		StartTime: now,
+		EndTime:   now,
+	}, batchOptions(cfg))
+	payloads = parseDryRun(t, out.String())
+	if !strings.Contains(payloads["incidents"], `"blocks"`) {
+		t.Errorf("Expected a Block Kit batch for incidents, got %s", payloads["incidents"])
+	}
+	if !strings.Contains(payloads["noisy"], `\nPod web\nPod db"`) {
+		t.Errorf("Expected the route template for the noisy batch, got %s", payloads["noisy"])
+	}
+	if !strings.Contains(payloads["slack"], `"attachments"`) {
+		t.Errorf("Expected a default attachments batch for slack, got %s", payloads["slack"])
+	}
+}
+
+func TestPipeline_RouteBatching(t *testing.T) {
+	newConfig := func() *config.Config {
+		cfg := newTestConfig(t)
+		cfg.Batching = config.BatchingConfig{Enabled: true, WindowSeconds: 30}
+		cfg.Notifier.Slack.WebhookURL = "https://example.com/slack"
+		cfg.Notifier.Slack.Destinations = []config.SlackDestinationConfig{
+			{Name: "prod", WebhookURL: "https://example.com/prod"},
+			{Name: "noise", WebhookURL: "https://example.com/noise"},
+		}
+		never := false
+		cfg.Routes = []config.RouteConfig{
+			{Destinations: []string{"prod"}, Continue: true, Batching: &config.RouteBatchingConfig{Enabled: &never}},
+			{Name: "noise", Destinations: []string{"noise"}, Continue: true, Batching: &config.RouteBatchingConfig{WindowSeconds: 600, Mode: "summary"}},
+			{Destinations: []string{"slack"}},
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("Invalid test config: %v", err)
+		}
+		return cfg
+	}
+	destinations := func(out string) []string {
+		var names []string
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			var record struct {
+				Destination string `json:"destination"`
+			}
+			if err := json.Unmarshal([]byte(line), &record); err == nil {
+				names = append(names, record.Destination)
+			}
+		}
+		return names
+	}
+
+	var out bytes.Buffer
+	p, err := New(newConfig(), Options{DryRunOutput: &out})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "web", EventType: "ADDED"})
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "db", EventType: "ADDED"})
+
+	// バッチ処理しないルートの通知先にはすぐ送信される
+	if got := destinations(out.String()); len(got) != 2 || got[0] != "prod" || got[1] != "prod" {
+		t.Fatalf("Expected the events to be sent to prod immediately, got %v", got)
+	}
+	state := p.State()
+	if state.Batcher == nil || state.Batcher.Pending() != 2 {
+		t.Error("Expected the events to be pending in the global batch")
+	}
+	if b := state.RouteBatchers["noise"]; b == nil || b.Pending() != 2 {
+		t.Errorf("Expected the events to be pending in the batch of the noise route, got %v", state.RouteBatchers)
+	}
+
+	// 再読み込みでルートごとのバッチも送信される
+	if err := p.Reload(newConfig()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	got := destinations(out.String())
+	if len(got) != 4 {
+		t.Fatalf("Expected one batch per batched destination after the reload, got %v", got)
+	}
+	batched := strings.Join(got[2:], ",")
+	if batched != "noise,slack" && batched != "slack,noise" {
+		t.Errorf("Expected the batches to be sent to noise and slack, got %v", got)
+	}
+
+	p.HandleEvent(&watcher.Event{Kind: "Pod", Namespace: "default", Name: "cache", EventType: "ADDED"})
+	p.Stop()
+	if got := destinations(out.String()); len(got) != 7 {
+		t.Errorf("Expected the pending batches to be sent on stop, got %v", got)
+	}
+}
@@ -0,0 +1,26 @@
+package pipeline
+
+import "log/slog"
+
+// reloadMessage is posted to Slack after a reload when reload.notify is set
+func reloadMessage(namespace string, reloadErr error) string {
+	if reloadErr != nil {
+		return ":x: kube-watcher configuration was rejected, the previous configuration stays active: " + reloadErr.Error()
+	}
+	if namespace == "" {
+		return ":white_check_mark: kube-watcher configuration reloaded"
+	}
+	return ":white_check_mark: kube-watcher configuration reloaded for namespace *" + namespace + "*"
+}
+
+// sendReloadMessage posts text to the named Slack destination, or to all of them when name is empty
+func sendReloadMessage(slack []slackDestination, name, text string) {
+	for _, d := range slack {
+		if name != "" && d.name != name {
+			continue
+		}
+		if err := d.notifier.Send(text); err != nil {
+			slog.Error("Failed to send reload notification", "destination", d.name, "error", err)
+		}
+	}
+}
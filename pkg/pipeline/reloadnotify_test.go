@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/notifier"
+)
+
+func TestReloadMessage(t *testing.T) {
+	if msg := reloadMessage("prod", nil); !strings.Contains(msg, "reloaded for namespace *prod*") {
+		t.Errorf("Unexpected success message: %q", msg)
+	}
+	msg := reloadMessage("prod", errors.New("invalid configuration: namespace is required"))
+	if !strings.Contains(msg, "rejected") || !strings.Contains(msg, "namespace is required") {
+		t.Errorf("Unexpected failure message: %q", msg)
+	}
+}
+
+func TestSendReloadMessage(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	newServer := func(name string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body)
+			mu.Lock()
+			received = append(received, name)
+			mu.Unlock()
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	slack := []slackDestination{
+		{name: "slack", notifier: notifier.NewSlackNotifier(newServer("slack").URL)},
+		{name: "ops", notifier: notifier.NewSlackNotifier(newServer("ops").URL)},
+	}
+
+	// 送信先を指定した場合はその送信先にのみ投稿する
+	sendReloadMessage(slack, "ops", "reloaded")
+	if len(received) != 1 || received[0] != "ops" {
+		t.Errorf("Expected only ops, got %v", received)
+	}
+
+	received = nil
+	sendReloadMessage(slack, "", "reloaded")
+	if len(received) != 2 {
+		t.Errorf("Expected all destinations, got %v", received)
+	}
+}
@@ -0,0 +1,16 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/kqns91/kube-watcher/pkg/silence"
+)
+
+// silenceEndedMessage reports how many events a silence suppressed
+func silenceEndedMessage(s silence.Silence) string {
+	text := fmt.Sprintf(":mute: Silence `%s` ended, %d events were suppressed", s.ID, s.Suppressed)
+	if s.Comment != "" {
+		text += " (" + s.Comment + ")"
+	}
+	return text
+}
@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/batcher"
+	"github.com/kqns91/kube-watcher/pkg/formatter"
+	"github.com/kqns91/kube-watcher/pkg/storm"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// stormOptions formats the events of a storm as a summary
+var stormOptions = formatter.BatchOptions{
+	Mode:         formatter.BatchModeSummary,
+	SummaryStats: true,
+}
+
+// newStormDetector creates the detector of the storm configuration, announcing
+// storms and delivering their summaries through the pipeline
+func (p *Pipeline) newStormDetector(window time.Duration, cfg storm.Config) *storm.Detector {
+	return storm.NewDetector(cfg, storm.Handlers{
+		Started: func(s storm.Storm) { p.sendMetaNotice(stormStartedMessage(s, window)) },
+		Summary: func(s storm.Storm, events []*watcher.Event, since, until time.Time) {
+			p.deliverBatch("storm", &batcher.Batch{Events: events, StartTime: since, EndTime: until}, stormOptions)
+		},
+		Ended: func(s storm.Storm) { p.sendMetaNotice(stormEndedMessage(s)) },
+	})
+}
+
+// stormStartedMessage announces that the events of a storm are summarized from now on
+func stormStartedMessage(s storm.Storm, window time.Duration) string {
+	return fmt.Sprintf(":cyclone: Event storm started: %d %s events in %s within %s (usually %.0f). Sending summaries until it subsides",
+		s.Peak, s.Kind, stormScope(s), window, s.Baseline)
+}
+
+// stormEndedMessage reports how long a storm lasted and how many events it summarized
+func stormEndedMessage(s storm.Storm) string {
+	return fmt.Sprintf(":sunny: Event storm ended: %d %s events in %s were summarized over %s",
+		s.Events, s.Kind, stormScope(s), s.EndTime.Sub(s.StartTime).Round(time.Second))
+}
+
+// stormScope describes the namespace of a storm
+func stormScope(s storm.Storm) string {
+	if s.Namespace == "" {
+		return "the cluster scope"
+	}
+	return "namespace " + s.Namespace
+}
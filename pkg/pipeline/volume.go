@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// volumeMonitor synthesizes warning events for PersistentVolumeClaims that
+// stay Pending, e.g. because no volume can be provisioned, and for failed
+// resizes. Each is warned about once until it is resolved.
+type volumeMonitor struct {
+	pending time.Duration
+	emit    func(event *watcher.Event)
+	now     func() time.Time
+
+	pendingWarned map[string]bool   // Namespace/name -> warned about being Pending
+	resizeWarned  map[string]string // Namespace/name -> resize error warned about
+}
+
+// newVolumeMonitor creates a volumeMonitor passing the warnings to emit
+func newVolumeMonitor(cfg config.VolumesConfig, emit func(event *watcher.Event)) *volumeMonitor {
+	return &volumeMonitor{
+		pending:       time.Duration(cfg.PendingMinutes) * time.Minute,
+		emit:          emit,
+		now:           time.Now,
+		pendingWarned: make(map[string]bool),
+		resizeWarned:  make(map[string]string),
+	}
+}
+
+// check warns about the claims that became stuck or failed to resize since the last check
+func (m *volumeMonitor) check(claims []*watcher.Event) {
+	now := m.now()
+	seen := make(map[string]bool)
+	for _, pvc := range claims {
+		v := pvc.Volume
+		if v == nil {
+			continue
+		}
+		key := pvc.Namespace + "/" + pvc.Name
+		seen[key] = true
+
+		pending := pvc.Status == "Pending" && !pvc.CreatedAt.IsZero() && now.Sub(pvc.CreatedAt) >= m.pending
+		switch {
+		case pending && !m.pendingWarned[key]:
+			m.pendingWarned[key] = true
+			m.emit(warningEvent(pvc, now, watcher.ReasonClaimPending, fmt.Sprintf(
+				"PersistentVolumeClaim has been Pending for %s (storage class %s, requested %s)",
+				now.Sub(pvc.CreatedAt).Truncate(time.Minute), storageClassName(v), v.Requested)))
+		case !pending:
+			delete(m.pendingWarned, key)
+		}
+
+		switch {
+		case v.ResizeError != "" && m.resizeWarned[key] != v.ResizeError:
+			m.resizeWarned[key] = v.ResizeError
+			m.emit(warningEvent(pvc, now, watcher.ReasonResizeFailed, fmt.Sprintf(
+				"Resizing to %s failed (storage class %s, capacity %s): %s",
+				v.Requested, storageClassName(v), v.Capacity, v.ResizeError)))
+		case v.ResizeError == "":
+			delete(m.resizeWarned, key)
+		}
+	}
+
+	for key := range m.pendingWarned {
+		if !seen[key] {
+			delete(m.pendingWarned, key)
+		}
+	}
+	for key := range m.resizeWarned {
+		if !seen[key] {
+			delete(m.resizeWarned, key)
+		}
+	}
+}
+
+// storageClassName returns the storage class of the claim, or "(default)"
+// when it uses the default storage class
+func storageClassName(v *watcher.VolumeInfo) string {
+	if v.StorageClass == "" {
+		return "(default)"
+	}
+	return v.StorageClass
+}
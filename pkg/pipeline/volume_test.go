@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestVolumeMonitor(t *testing.T) {
+	var warnings []*watcher.Event
+	m := newVolumeMonitor(config.VolumesConfig{PendingMinutes: 10}, func(e *watcher.Event) {
+		warnings = append(warnings, e)
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	pending := &watcher.Event{Kind: "PersistentVolumeClaim", Namespace: "prod", Name: "data", Status: "Pending",
+		CreatedAt: now.Add(-5 * time.Minute), Volume: &watcher.VolumeInfo{Requested: "10Gi"}}
+
+	// しきい値に達するまでは警告しない
+	m.check([]*watcher.Event{pending})
+	if len(warnings) != 0 {
+		t.Fatalf("Expected no warning before the threshold, got %+v", warnings)
+	}
+
+	pending.CreatedAt = now.Add(-15 * time.Minute)
+	m.check([]*watcher.Event{pending})
+	m.check([]*watcher.Event{pending})
+	if len(warnings) != 1 || warnings[0].Reason != watcher.ReasonClaimPending {
+		t.Fatalf("Expected one pending warning, got %+v", warnings)
+	}
+	if want := "Pending for 15m0s (storage class (default), requested 10Gi)"; !strings.Contains(warnings[0].Message, want) {
+		t.Errorf("Expected %q in the message, got %q", want, warnings[0].Message)
+	}
+
+	// 拡張の失敗はエラーが変わるたびに警告する
+	bound := &watcher.Event{Kind: "PersistentVolumeClaim", Namespace: "prod", Name: "data", Status: "Bound",
+		Volume: &watcher.VolumeInfo{StorageClass: "fast", Requested: "20Gi", Capacity: "10Gi", ResizeError: "quota exceeded"}}
+	m.check([]*watcher.Event{bound})
+	m.check([]*watcher.Event{bound})
+	if len(warnings) != 2 || warnings[1].Reason != watcher.ReasonResizeFailed ||
+		warnings[1].Message != "Resizing to 20Gi failed (storage class fast, capacity 10Gi): quota exceeded" {
+		t.Fatalf("Expected one resize warning, got %+v", warnings[len(warnings)-1])
+	}
+	if len(m.pendingWarned) != 0 {
+		t.Errorf("Expected the pending warning to be cleared once bound, got %v", m.pendingWarned)
+	}
+}
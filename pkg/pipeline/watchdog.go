@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// watchdog alerts when an informer has not synced its cache or has stopped
+// delivering notifications, and again when it recovers. A silently dead
+// watcher is worse than a noisy one.
+type watchdog struct {
+	stall  time.Duration
+	notify func(text string)
+	now    func() time.Time
+
+	stalled map[string]bool // Names of the stalled informers, see InformerStatus.Name
+}
+
+// newWatchdog creates a watchdog alerting after stall without activity
+func newWatchdog(stall time.Duration, notify func(text string)) *watchdog {
+	return &watchdog{
+		stall:   stall,
+		notify:  notify,
+		now:     time.Now,
+		stalled: make(map[string]bool),
+	}
+}
+
+// run checks the informers of w until ctx is cancelled
+func (d *watchdog) run(ctx context.Context, w *watcher.Watcher) {
+	ticker := time.NewTicker(d.stall / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.check(w.Informers())
+		}
+	}
+}
+
+// check alerts on informers that became stalled or recovered since the last check
+func (d *watchdog) check(statuses []watcher.InformerStatus) {
+	for _, s := range statuses {
+		reason := d.stallReason(s)
+		name := s.Name()
+		switch {
+		case reason != "" && !d.stalled[name]:
+			d.stalled[name] = true
+			slog.Error("Informer stalled", "kind", s.Kind, "namespace", s.Namespace, "reason", reason)
+			d.notify(fmt.Sprintf(":warning: Watchdog: the *%s* informer %s, events may be missed", name, reason))
+		case reason == "" && d.stalled[name]:
+			delete(d.stalled, name)
+			slog.Info("Informer recovered", "kind", s.Kind, "namespace", s.Namespace)
+			d.notify(fmt.Sprintf(":white_check_mark: Watchdog: the *%s* informer is delivering events again", name))
+		}
+	}
+}
+
+// stallReason describes why the informer is stalled, or returns "" if it is not.
+// Informers with an empty cache are not expected to deliver anything, not even resyncs.
+func (d *watchdog) stallReason(s watcher.InformerStatus) string {
+	now := d.now()
+	if !s.Synced {
+		if now.Sub(s.Started) < d.stall {
+			return ""
+		}
+		return fmt.Sprintf("has not synced its cache within %s", d.stall)
+	}
+	last := s.LastEvent
+	if last.Before(s.Started) {
+		last = s.Started
+	}
+	if s.Objects == 0 || now.Sub(last) < d.stall {
+		return ""
+	}
+	return fmt.Sprintf("has not delivered any events, including resyncs, for %s", now.Sub(last).Round(time.Second))
+}
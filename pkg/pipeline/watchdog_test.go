@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func TestWatchdog(t *testing.T) {
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := started
+	var notices []string
+	d := newWatchdog(5*time.Minute, func(text string) { notices = append(notices, text) })
+	d.now = func() time.Time { return now }
+
+	statuses := []watcher.InformerStatus{
+		{Kind: "Pod", Synced: true, Objects: 3, Started: started, LastEvent: started.Add(time.Minute)},
+		// キャッシュが空の種類は再同期もないので監視しない
+		{Kind: "Secret", Synced: true, Objects: 0, Started: started},
+		{Kind: "Deployment", Synced: false, Started: started},
+		// 名前空間ごとのインフォーマーは別々に監視する
+		{Kind: "Pod", Namespace: "prod", Synced: true, Objects: 1, Started: started, LastEvent: started.Add(6 * time.Minute)},
+	}
+
+	now = started.Add(4 * time.Minute)
+	d.check(statuses)
+	if len(notices) != 0 {
+		t.Fatalf("Expected no alert within the stall period, got %q", notices)
+	}
+
+	now = started.Add(7 * time.Minute)
+	d.check(statuses)
+	if len(notices) != 2 || !strings.Contains(notices[0], "*Pod* informer has not delivered any events") || !strings.Contains(notices[1], "*Deployment* informer has not synced") {
+		t.Fatalf("Expected alerts for Pod and Deployment, got %q", notices)
+	}
+
+	// 同じ状態では再通知せず、回復したら通知する
+	d.check(statuses)
+	statuses[0].LastEvent = now
+	d.check(statuses)
+	if len(notices) != 3 || !strings.Contains(notices[2], "*Pod* informer is delivering events again") {
+		t.Errorf("Expected one recovery notice, got %q", notices)
+	}
+}
@@ -0,0 +1,207 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// KubeWatcherResource is the custom resource holding the configuration in its spec
+var KubeWatcherResource = schema.GroupVersionResource{
+	Group:    "kubewatcher.kqns91.github.io",
+	Version:  "v1alpha1",
+	Resource: "kubewatchers",
+}
+
+// CRDWatcher reads the configuration from a KubeWatcher custom resource and
+// watches it with an informer. Its spec has the same layout as the config file.
+type CRDWatcher struct {
+	client    dynamic.Interface
+	namespace string
+	name      string
+	overrides config.Overrides
+	callbacks []ReloadCallback
+	onError   []ErrorCallback
+	mu        sync.RWMutex
+	stopCh    chan struct{}
+
+	// reloadMu runs reloads one at a time, as both the informer and Reload
+	// requests apply configurations
+	reloadMu sync.Mutex
+
+	generation int64 // Generation of the last loaded resource
+}
+
+// NewCRDWatcher creates a new CRDWatcher for the named resource
+func NewCRDWatcher(client dynamic.Interface, namespace, name string) *CRDWatcher {
+	return &CRDWatcher{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// SetOverrides sets the command-line overrides applied to every loaded configuration
+func (cw *CRDWatcher) SetOverrides(overrides config.Overrides) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.overrides = overrides
+}
+
+// AddCallback adds a callback to be called when the resource changes
+func (cw *CRDWatcher) AddCallback(cb ReloadCallback) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.callbacks = append(cw.callbacks, cb)
+}
+
+// AddErrorCallback adds a callback to be called when a reload fails
+func (cw *CRDWatcher) AddErrorCallback(cb ErrorCallback) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.onError = append(cw.onError, cb)
+}
+
+// Load reads the configuration from the resource
+func (cw *CRDWatcher) Load(ctx context.Context) (*config.Config, error) {
+	obj, err := cw.client.Resource(KubeWatcherResource).Namespace(cw.namespace).Get(ctx, cw.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get KubeWatcher %s/%s: %w", cw.namespace, cw.name, err)
+	}
+	cfg, err := cw.parse(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	cw.mu.Lock()
+	cw.generation = obj.GetGeneration()
+	cw.mu.Unlock()
+	return cfg, nil
+}
+
+// Start begins watching the resource for changes
+func (cw *CRDWatcher) Start() {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(cw.client, 0, cw.namespace,
+		func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", cw.name).String()
+		})
+	informer := factory.ForResource(KubeWatcherResource).Informer()
+	_, _ = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    cw.onChange,
+		UpdateFunc: func(_, obj interface{}) { cw.onChange(obj) },
+		DeleteFunc: func(interface{}) {
+			slog.Warn("KubeWatcher was deleted, keeping the current configuration", "namespace", cw.namespace, "name", cw.name)
+		},
+	})
+
+	factory.Start(cw.stopCh)
+	slog.Info("Configuration hot-reload enabled", "namespace", cw.namespace, "name", cw.name)
+}
+
+// Stop stops watching the resource
+func (cw *CRDWatcher) Stop() {
+	close(cw.stopCh)
+}
+
+// onChange reloads the configuration when the spec changed. Status and
+// metadata updates do not change the generation and are ignored.
+func (cw *CRDWatcher) onChange(obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	cw.reloadMu.Lock()
+	defer cw.reloadMu.Unlock()
+
+	cw.mu.Lock()
+	if u.GetGeneration() == cw.generation {
+		cw.mu.Unlock()
+		return
+	}
+	cw.generation = u.GetGeneration()
+	cw.mu.Unlock()
+
+	slog.Info("KubeWatcher changed, reloading...", "namespace", cw.namespace, "name", cw.name)
+	cfg, err := cw.parse(u)
+	if err != nil {
+		slog.Error("Failed to reload config", "error", err)
+		cw.reportError(err)
+		return
+	}
+	cw.apply(cfg)
+}
+
+// Reload reads the resource and applies it immediately, even if its spec did
+// not change. It waits for a reload in progress to finish first.
+func (cw *CRDWatcher) Reload() {
+	cw.reloadMu.Lock()
+	defer cw.reloadMu.Unlock()
+
+	slog.Info("Reload requested, reloading KubeWatcher...", "namespace", cw.namespace, "name", cw.name)
+	cfg, err := cw.Load(context.Background())
+	if err != nil {
+		slog.Error("Failed to reload config", "error", err)
+		cw.reportError(err)
+		return
+	}
+	cw.apply(cfg)
+}
+
+// apply calls the callbacks with a reloaded configuration
+func (cw *CRDWatcher) apply(cfg *config.Config) {
+	slog.Info("Configuration reloaded successfully")
+
+	cw.mu.RLock()
+	callbacks := make([]ReloadCallback, len(cw.callbacks))
+	copy(callbacks, cw.callbacks)
+	cw.mu.RUnlock()
+
+	for _, cb := range callbacks {
+		if err := cb(cfg); err != nil {
+			slog.Error("Reload callback error", "error", err)
+			cw.reportError(err)
+		}
+	}
+}
+
+// reportError calls the error callbacks
+func (cw *CRDWatcher) reportError(err error) {
+	cw.mu.RLock()
+	onError := make([]ErrorCallback, len(cw.onError))
+	copy(onError, cw.onError)
+	cw.mu.RUnlock()
+
+	for _, cb := range onError {
+		cb(err)
+	}
+}
+
+// parse builds the configuration from the resource's spec
+func (cw *CRDWatcher) parse(obj *unstructured.Unstructured) (*config.Config, error) {
+	spec, found, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil || !found {
+		return nil, fmt.Errorf("KubeWatcher %s/%s has no spec", cw.namespace, cw.name)
+	}
+
+	cw.mu.RLock()
+	overrides := cw.overrides
+	cw.mu.RUnlock()
+
+	cfg, err := config.LoadConfigFromSpec(spec, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("KubeWatcher %s/%s: %w", cw.namespace, cw.name, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,161 @@
+package reload
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newKubeWatcher(generation int64, kinds ...string) *unstructured.Unstructured {
+	resources := make([]interface{}, 0, len(kinds))
+	for _, kind := range kinds {
+		resources = append(resources, map[string]interface{}{"kind": kind})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kubewatcher.kqns91.github.io/v1alpha1",
+		"kind":       "KubeWatcher",
+		"metadata":   map[string]interface{}{"name": "main", "namespace": "monitoring"},
+		"spec": map[string]interface{}{
+			"namespace": "production",
+			"resources": resources,
+			"notifier": map[string]interface{}{
+				"slack": map[string]interface{}{"webhookUrl": "https://example.com/webhook"},
+			},
+		},
+	}}
+	obj.SetGeneration(generation)
+	return obj
+}
+
+func newFakeClient(objects ...runtime.Object) *fake.FakeDynamicClient {
+	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{KubeWatcherResource: "KubeWatcherList"}, objects...)
+}
+
+func TestCRDWatcher_Load(t *testing.T) {
+	cw := NewCRDWatcher(newFakeClient(newKubeWatcher(1, "Pod", "Deployment")), "monitoring", "main")
+	cw.SetOverrides(config.Overrides{LogLevel: "debug"})
+
+	cfg, err := cw.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Namespace != "production" || len(cfg.Resources) != 2 || cfg.LogLevel != "debug" {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+
+	// 世代が変わっていなくても Reload では再適用される
+	reloaded := make(chan bool, 1)
+	cw.AddCallback(func(*config.Config) error {
+		reloaded <- true
+		return nil
+	})
+	cw.Reload()
+	select {
+	case <-reloaded:
+	default:
+		t.Error("Reload() did not call the callback")
+	}
+
+	missing := NewCRDWatcher(newFakeClient(), "monitoring", "main")
+	if _, err := missing.Load(context.Background()); err == nil {
+		t.Error("Expected error for missing resource")
+	}
+}
+
+func TestCRDWatcher_Load_UnknownField(t *testing.T) {
+	obj := newKubeWatcher(1, "Pod")
+	_ = unstructured.SetNestedField(obj.Object, "typo", "spec", "namespce")
+
+	cw := NewCRDWatcher(newFakeClient(obj), "monitoring", "main")
+	if _, err := cw.Load(context.Background()); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+}
+
+func TestCRDWatcher_ReloadOnChange(t *testing.T) {
+	client := newFakeClient(newKubeWatcher(1, "Pod"))
+	cw := NewCRDWatcher(client, "monitoring", "main")
+	if _, err := cw.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	resources := make(chan int, 10)
+	cw.AddCallback(func(cfg *config.Config) error {
+		resources <- len(cfg.Resources)
+		return nil
+	})
+	cw.Start()
+	defer cw.Stop()
+
+	// 初期の追加イベントは読み込み済みの世代なので無視される
+	select {
+	case n := <-resources:
+		t.Fatalf("Unexpected reload with %d resources", n)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	_, err := client.Resource(KubeWatcherResource).Namespace("monitoring").
+		Update(context.Background(), newKubeWatcher(2, "Pod", "Service"), metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	select {
+	case n := <-resources:
+		if n != 2 {
+			t.Errorf("Expected 2 resources, got %d", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Callback was not called within timeout")
+	}
+}
+
+func TestCRDWatcher_SerializesReloads(t *testing.T) {
+	cw := NewCRDWatcher(newFakeClient(newKubeWatcher(1, "Pod")), "monitoring", "main")
+	if _, err := cw.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var active, overlaps, calls atomic.Int32
+	cw.AddCallback(func(*config.Config) error {
+		if active.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(10 * time.Millisecond)
+		active.Add(-1)
+		calls.Add(1)
+		return nil
+	})
+
+	// Reload の要求とリソースの変更が同時に起きても、適用は1つずつ行われる
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cw.Reload()
+		}()
+		go func(generation int64) {
+			defer wg.Done()
+			cw.onChange(newKubeWatcher(generation, "Pod", "Service"))
+		}(int64(i + 2))
+	}
+	wg.Wait()
+
+	if calls.Load() == 0 {
+		t.Fatal("Expected the callback to be called")
+	}
+	if n := overlaps.Load(); n > 0 {
+		t.Errorf("Expected reloads not to overlap, got %d overlapping calls", n)
+	}
+}
@@ -2,9 +2,10 @@
 package reload
 
 import (
-	"log"
+	"log/slog"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/kqns91/kube-watcher/pkg/config"
@@ -13,13 +14,27 @@ import (
 // ReloadCallback is called when configuration is reloaded
 type ReloadCallback func(*config.Config) error
 
+// ErrorCallback is called when a changed configuration could not be loaded or
+// was rejected by a ReloadCallback
+type ErrorCallback func(error)
+
+// DefaultDebounce is how long the config file must stay unchanged before it is reloaded
+const DefaultDebounce = 500 * time.Millisecond
+
 // ConfigWatcher watches configuration file for changes
 type ConfigWatcher struct {
 	configPath string
+	overrides  config.Overrides
+	debounce   time.Duration
 	watcher    *fsnotify.Watcher
 	callbacks  []ReloadCallback
+	onError    []ErrorCallback
 	mu         sync.RWMutex
 	stopCh     chan struct{}
+	reloadCh   chan struct{} // Reload requests, e.g. on SIGHUP
+
+	includesMu sync.Mutex
+	includes   map[string]bool // Base names of included files, whose changes also trigger a reload
 }
 
 // NewConfigWatcher creates a new ConfigWatcher
@@ -39,14 +54,38 @@ func NewConfigWatcher(configPath string) (*ConfigWatcher, error) {
 
 	cw := &ConfigWatcher{
 		configPath: configPath,
+		debounce:   DefaultDebounce,
 		watcher:    watcher,
 		callbacks:  make([]ReloadCallback, 0),
 		stopCh:     make(chan struct{}),
+		reloadCh:   make(chan struct{}, 1),
+	}
+
+	if cfg, err := config.LoadConfig(configPath); err == nil {
+		cw.watchIncludes(cfg)
 	}
 
 	return cw, nil
 }
 
+// SetOverrides sets the command-line overrides applied to every reloaded configuration
+func (cw *ConfigWatcher) SetOverrides(overrides config.Overrides) {
+	cw.mu.Lock()
+	cw.overrides = overrides
+	cw.mu.Unlock()
+
+	if cfg, err := config.LoadConfigWithOverrides(cw.configPath, overrides); err == nil {
+		cw.watchIncludes(cfg)
+	}
+}
+
+// SetDebounce sets how long the config file must stay unchanged before it is
+// reloaded. A ConfigMap update produces several events as its symlinks are
+// swapped, which then cause a single reload. Must be called before Start.
+func (cw *ConfigWatcher) SetDebounce(d time.Duration) {
+	cw.debounce = d
+}
+
 // AddCallback adds a callback to be called when config is reloaded
 func (cw *ConfigWatcher) AddCallback(cb ReloadCallback) {
 	cw.mu.Lock()
@@ -54,10 +93,26 @@ func (cw *ConfigWatcher) AddCallback(cb ReloadCallback) {
 	cw.callbacks = append(cw.callbacks, cb)
 }
 
+// AddErrorCallback adds a callback to be called when a reload fails
+func (cw *ConfigWatcher) AddErrorCallback(cb ErrorCallback) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.onError = append(cw.onError, cb)
+}
+
 // Start begins watching for configuration changes
 func (cw *ConfigWatcher) Start() {
 	go cw.watchLoop()
-	log.Println("Configuration hot-reload enabled")
+	slog.Info("Configuration hot-reload enabled", "path", cw.configPath)
+}
+
+// Reload reloads the configuration immediately, without waiting for a file
+// event. Requests made while a reload is pending are merged.
+func (cw *ConfigWatcher) Reload() {
+	select {
+	case cw.reloadCh <- struct{}{}:
+	default:
+	}
 }
 
 // Stop stops watching for configuration changes
@@ -68,11 +123,25 @@ func (cw *ConfigWatcher) Stop() {
 
 // watchLoop watches for file system events
 func (cw *ConfigWatcher) watchLoop() {
+	// Fires once the file has not changed for the debounce delay
+	settled := time.NewTimer(cw.debounce)
+	settled.Stop()
+	defer settled.Stop()
+
 	for {
 		select {
 		case <-cw.stopCh:
 			return
 
+		case <-settled.C:
+			slog.Info("Configuration file changed, reloading...")
+			cw.reloadConfig()
+
+		case <-cw.reloadCh:
+			settled.Stop()
+			slog.Info("Reload requested, reloading configuration...")
+			cw.reloadConfig()
+
 		case event, ok := <-cw.watcher.Events:
 			if !ok {
 				return
@@ -80,10 +149,9 @@ func (cw *ConfigWatcher) watchLoop() {
 
 			// Check if the event is for our config file
 			// Kubernetes ConfigMaps create symlinks, so we need to handle various events
-			if event.Name == cw.configPath || filepath.Base(event.Name) == filepath.Base(cw.configPath) {
+			if event.Name == cw.configPath || filepath.Base(event.Name) == filepath.Base(cw.configPath) || cw.isIncluded(event.Name) {
 				if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
-					log.Printf("Configuration file changed, reloading...")
-					cw.reloadConfig()
+					settled.Reset(cw.debounce)
 				}
 			}
 
@@ -91,7 +159,7 @@ func (cw *ConfigWatcher) watchLoop() {
 			if !ok {
 				return
 			}
-			log.Printf("Config watcher error: %v", err)
+			slog.Error("Config watcher error", "error", err)
 		}
 	}
 }
@@ -99,13 +167,19 @@ func (cw *ConfigWatcher) watchLoop() {
 // reloadConfig reloads the configuration and calls callbacks
 func (cw *ConfigWatcher) reloadConfig() {
 	// Load new configuration
-	cfg, err := config.LoadConfig(cw.configPath)
+	cw.mu.RLock()
+	overrides := cw.overrides
+	cw.mu.RUnlock()
+
+	cfg, err := config.LoadConfigWithOverrides(cw.configPath, overrides)
 	if err != nil {
-		log.Printf("Failed to reload config: %v", err)
+		slog.Error("Failed to reload config", "error", err)
+		cw.reportError(err)
 		return
 	}
 
-	log.Println("Configuration reloaded successfully")
+	slog.Info("Configuration reloaded successfully")
+	cw.watchIncludes(cfg)
 
 	// Call all callbacks
 	cw.mu.RLock()
@@ -115,7 +189,46 @@ func (cw *ConfigWatcher) reloadConfig() {
 
 	for _, cb := range callbacks {
 		if err := cb(cfg); err != nil {
-			log.Printf("Reload callback error: %v", err)
+			slog.Error("Reload callback error", "error", err)
+			cw.reportError(err)
 		}
 	}
 }
+
+// reportError calls the error callbacks
+func (cw *ConfigWatcher) reportError(err error) {
+	cw.mu.RLock()
+	onError := make([]ErrorCallback, len(cw.onError))
+	copy(onError, cw.onError)
+	cw.mu.RUnlock()
+
+	for _, cb := range onError {
+		cb(err)
+	}
+}
+
+// watchIncludes watches the directories of the files included by cfg
+func (cw *ConfigWatcher) watchIncludes(cfg *config.Config) {
+	includes := make(map[string]bool)
+	for _, file := range cfg.Files {
+		if file == cw.configPath {
+			continue
+		}
+		includes[filepath.Base(file)] = true
+		// Adding an already watched directory is a no-op
+		if err := cw.watcher.Add(filepath.Dir(file)); err != nil {
+			slog.Error("Failed to watch included config file", "file", file, "error", err)
+		}
+	}
+
+	cw.includesMu.Lock()
+	cw.includes = includes
+	cw.includesMu.Unlock()
+}
+
+// isIncluded reports whether name is a file included by the current configuration
+func (cw *ConfigWatcher) isIncluded(name string) bool {
+	cw.includesMu.Lock()
+	defer cw.includesMu.Unlock()
+	return cw.includes[filepath.Base(name)]
+}
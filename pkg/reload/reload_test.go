@@ -3,6 +3,7 @@ package reload
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -194,3 +195,160 @@ notifier:
 		}
 	}
 }
+
+func TestConfigWatcher_ReloadOnIncludedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	teamDir := filepath.Join(tmpDir, "teams")
+	if err := os.Mkdir(teamDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	mainConfig := `
+include: ["teams"]
+namespace: default
+resources:
+  - kind: Pod
+notifier:
+  slack:
+    webhookUrl: "https://example.com/webhook"
+`
+	if err := os.WriteFile(configPath, []byte(mainConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	teamPath := filepath.Join(teamDir, "payments.yaml")
+	if err := os.WriteFile(teamPath, []byte("filters: []\n"), 0644); err != nil {
+		t.Fatalf("Failed to write team file: %v", err)
+	}
+
+	watcher, err := NewConfigWatcher(configPath)
+	if err != nil {
+		t.Fatalf("NewConfigWatcher() error = %v", err)
+	}
+	defer watcher.Stop()
+
+	filters := make(chan int, 1)
+	watcher.AddCallback(func(cfg *config.Config) error {
+		filters <- len(cfg.Filters)
+		return nil
+	})
+	watcher.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	// インクルードされたファイルの変更でもリロードされる
+	teamConfig := `
+filters:
+  - resource: Pod
+    eventTypes: ["DELETED"]
+`
+	if err := os.WriteFile(teamPath, []byte(teamConfig), 0644); err != nil {
+		t.Fatalf("Failed to update team file: %v", err)
+	}
+
+	// 書き込み途中の空ファイルで先にリロードされることがあるため、最終的な内容を待つ
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case n := <-filters:
+			if n == 1 {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Included file change was not reloaded within timeout")
+		}
+	}
+}
+
+func TestConfigWatcher_Debounce(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `
+namespace: default
+resources:
+  - kind: Pod
+notifier:
+  slack:
+    webhookUrl: "https://example.com/webhook"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	watcher, err := NewConfigWatcher(configPath)
+	if err != nil {
+		t.Fatalf("NewConfigWatcher() error = %v", err)
+	}
+	defer watcher.Stop()
+	watcher.SetDebounce(200 * time.Millisecond)
+
+	reloads := make(chan string, 10)
+	watcher.AddCallback(func(cfg *config.Config) error {
+		reloads <- cfg.Namespace
+		return nil
+	})
+	watcher.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	// 短い間隔での連続した変更は、最後の内容で1回だけ再読み込みされる
+	for _, ns := range []string{"one", "two", "three"} {
+		content := strings.Replace(configContent, "namespace: default", "namespace: "+ns, 1)
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to update config file: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	select {
+	case ns := <-reloads:
+		if ns != "three" {
+			t.Errorf("Expected namespace 'three', got %q", ns)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Callback was not called within timeout")
+	}
+	select {
+	case ns := <-reloads:
+		t.Errorf("Unexpected second reload with namespace %q", ns)
+	case <-time.After(400 * time.Millisecond):
+	}
+}
+
+func TestConfigWatcher_ReloadRequest(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `
+namespace: default
+resources:
+  - kind: Pod
+notifier:
+  slack:
+    webhookUrl: "https://example.com/webhook"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	watcher, err := NewConfigWatcher(configPath)
+	if err != nil {
+		t.Fatalf("NewConfigWatcher() error = %v", err)
+	}
+	defer watcher.Stop()
+
+	callbackCalled := make(chan bool, 1)
+	watcher.AddCallback(func(cfg *config.Config) error {
+		callbackCalled <- true
+		return nil
+	})
+	watcher.Start()
+
+	// ファイルの変更がなくても再読み込みされる（SIGHUP）
+	watcher.Reload()
+
+	select {
+	case <-callbackCalled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Callback was not called within timeout")
+	}
+}
@@ -0,0 +1,157 @@
+// Package router selects notifier destinations for events based on routing rules.
+package router
+
+import (
+	"fmt"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/filter"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// route is a compiled routing rule
+type route struct {
+	name         string
+	matcher      *filter.EventMatcher // nil matches every event
+	destinations []string
+	continues    bool
+	formatting   bool // The route overrides the Slack formatting
+}
+
+// Router selects notifier destinations for events.
+// Without routes every event is sent to all destinations.
+type Router struct {
+	routes []route
+}
+
+// Selection is the set of destinations an event is sent to
+type Selection struct {
+	all     bool
+	names   map[string]bool
+	routes  map[string]int // Destination -> index of the first matching route sending to it
+	formats map[string]int // Destination -> index of the route whose formatting applies
+}
+
+// Includes reports whether the named destination is selected
+func (s Selection) Includes(name string) bool {
+	return s.all || s.names[name]
+}
+
+// Formatting returns the index of the route whose Slack formatting overrides
+// apply to the named destination: the first matching route sending to it, if
+// that route overrides the formatting. It returns -1 for the default formatting.
+func (s Selection) Formatting(name string) int {
+	if i, ok := s.formats[name]; ok {
+		return i
+	}
+	return -1
+}
+
+// Route returns the index of the first matching route sending to the named
+// destination, or -1 when every destination is selected without routes
+func (s Selection) Route(name string) int {
+	if i, ok := s.routes[name]; ok {
+		return i
+	}
+	return -1
+}
+
+// Only returns the selection narrowed to the named destinations
+func (s Selection) Only(names []string) Selection {
+	only := Selection{names: make(map[string]bool, len(names)), routes: s.routes, formats: s.formats}
+	for _, name := range names {
+		if s.Includes(name) {
+			only.names[name] = true
+		}
+	}
+	return only
+}
+
+// IsEmpty reports whether no destination is selected
+func (s Selection) IsEmpty() bool {
+	return !s.all && len(s.names) == 0
+}
+
+// Select returns the selection of the named destinations
+func Select(names []string) Selection {
+	sel := Selection{names: make(map[string]bool, len(names))}
+	for _, name := range names {
+		sel.names[name] = true
+	}
+	return sel
+}
+
+// NewRouter creates a new Router from routing rules
+func NewRouter(routes []config.RouteConfig) (*Router, error) {
+	r := &Router{}
+	for i, rc := range routes {
+		compiled := route{
+			name:         rc.Name,
+			destinations: rc.Destinations,
+			continues:    rc.Continue,
+			formatting:   rc.HasFormatting(),
+		}
+		if compiled.name == "" {
+			compiled.name = fmt.Sprintf("routes[%d]", i)
+		}
+		if !rc.MatcherConfig.IsEmpty() {
+			matcher, err := filter.NewEventMatcher(rc.MatcherConfig)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", compiled.name, err)
+			}
+			compiled.matcher = matcher
+		}
+		r.routes = append(r.routes, compiled)
+	}
+	return r, nil
+}
+
+// Route returns the destinations for an event. Routes are evaluated in order and
+// evaluation stops at the first match unless the route continues. Events matching
+// no route are not sent anywhere.
+func (r *Router) Route(event *watcher.Event) Selection {
+	if r == nil || len(r.routes) == 0 {
+		return Selection{all: true}
+	}
+
+	sel := Selection{names: make(map[string]bool)}
+	for i, rt := range r.routes {
+		if rt.matcher != nil && !rt.matcher.Matches(event) {
+			continue
+		}
+		for _, name := range rt.destinations {
+			if !sel.names[name] {
+				if sel.routes == nil {
+					sel.routes = make(map[string]int)
+				}
+				sel.routes[name] = i
+				if rt.formatting {
+					if sel.formats == nil {
+						sel.formats = make(map[string]int)
+					}
+					sel.formats[name] = i
+				}
+			}
+			sel.names[name] = true
+		}
+		if !rt.continues {
+			break
+		}
+	}
+	return sel
+}
+
+// Split groups events by destination, keeping their order. Only the given
+// destinations are included, and destinations receiving no events are omitted.
+func (r *Router) Split(events []*watcher.Event, destinations []string) map[string][]*watcher.Event {
+	groups := make(map[string][]*watcher.Event)
+	for _, event := range events {
+		sel := r.Route(event)
+		for _, name := range destinations {
+			if sel.Includes(name) {
+				groups[name] = append(groups[name], event)
+			}
+		}
+	}
+	return groups
+}
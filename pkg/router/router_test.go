@@ -0,0 +1,224 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+	r, err := NewRouter([]config.RouteConfig{
+		{
+			Name:          "prod-deletions",
+			MatcherConfig: config.MatcherConfig{Namespaces: []string{"prod"}, EventTypes: []string{"DELETED"}},
+			Destinations:  []string{"prod-alerts", "sns"},
+			Continue:      true,
+		},
+		{
+			MatcherConfig: config.MatcherConfig{Namespaces: []string{"prod"}},
+			Destinations:  []string{"prod-alerts"},
+		},
+		{
+			Destinations: []string{"slack"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	return r
+}
+
+func TestRouter_Route(t *testing.T) {
+	r := newTestRouter(t)
+
+	tests := []struct {
+		name     string
+		event    *watcher.Event
+		included []string
+		excluded []string
+	}{
+		{
+			name:     "continue evaluates later routes",
+			event:    &watcher.Event{Namespace: "prod", EventType: "DELETED"},
+			included: []string{"prod-alerts", "sns"},
+			excluded: []string{"slack"},
+		},
+		{
+			name:     "first match wins",
+			event:    &watcher.Event{Namespace: "prod", EventType: "ADDED"},
+			included: []string{"prod-alerts"},
+			excluded: []string{"sns", "slack"},
+		},
+		{
+			name:     "catch-all route",
+			event:    &watcher.Event{Namespace: "dev", EventType: "DELETED"},
+			included: []string{"slack"},
+			excluded: []string{"prod-alerts", "sns"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel := r.Route(tt.event)
+			for _, name := range tt.included {
+				if !sel.Includes(name) {
+					t.Errorf("Expected %s to be selected", name)
+				}
+			}
+			for _, name := range tt.excluded {
+				if sel.Includes(name) {
+					t.Errorf("Expected %s not to be selected", name)
+				}
+			}
+		})
+	}
+}
+
+func TestRouter_NoRoutes(t *testing.T) {
+	// ルートが未設定の場合はすべての通知先に送信する
+	var r *Router
+	if !r.Route(&watcher.Event{}).Includes("anything") {
+		t.Error("Expected nil router to select all destinations")
+	}
+
+	r, _ = NewRouter(nil)
+	if !r.Route(&watcher.Event{}).Includes("slack") {
+		t.Error("Expected router without routes to select all destinations")
+	}
+}
+
+func TestRouter_Unmatched(t *testing.T) {
+	r, _ := NewRouter([]config.RouteConfig{
+		{MatcherConfig: config.MatcherConfig{Kinds: []string{"Pod"}}, Destinations: []string{"slack"}},
+	})
+
+	if sel := r.Route(&watcher.Event{Kind: "Service"}); !sel.IsEmpty() {
+		t.Error("Expected unmatched event to have no destinations")
+	}
+}
+
+func TestRouter_Split(t *testing.T) {
+	r := newTestRouter(t)
+	events := []*watcher.Event{
+		{Name: "a", Namespace: "prod", EventType: "DELETED"},
+		{Name: "b", Namespace: "dev", EventType: "ADDED"},
+		{Name: "c", Namespace: "prod", EventType: "UPDATED"},
+	}
+
+	groups := r.Split(events, []string{"slack", "prod-alerts", "sns", "nats"})
+
+	if got := names(groups["prod-alerts"]); got != "ac" {
+		t.Errorf("prod-alerts = %q, want %q", got, "ac")
+	}
+	if got := names(groups["sns"]); got != "a" {
+		t.Errorf("sns = %q, want %q", got, "a")
+	}
+	if got := names(groups["slack"]); got != "b" {
+		t.Errorf("slack = %q, want %q", got, "b")
+	}
+	if _, ok := groups["nats"]; ok {
+		t.Error("Expected destinations without events to be omitted")
+	}
+}
+
+func TestSelection_Route(t *testing.T) {
+	r := newTestRouter(t)
+
+	sel := r.Route(&watcher.Event{Name: "a", Namespace: "prod", EventType: "DELETED"})
+	// 通知先ごとに、その通知先に送る最初に一致したルートを返す
+	for name, want := range map[string]int{"prod-alerts": 0, "sns": 0, "slack": -1} {
+		if got := sel.Route(name); got != want {
+			t.Errorf("Route(%q) = %d, want %d", name, got, want)
+		}
+	}
+
+	only := sel.Only([]string{"sns", "slack"})
+	if only.Includes("prod-alerts") || !only.Includes("sns") || only.Includes("slack") {
+		t.Error("Expected Only() to keep only sns")
+	}
+	if got := only.Route("sns"); got != 0 {
+		t.Errorf("Only().Route(sns) = %d, want 0", got)
+	}
+}
+
+func TestRouter_Severities(t *testing.T) {
+	r, err := NewRouter([]config.RouteConfig{
+		{MatcherConfig: config.MatcherConfig{Severities: []string{"critical"}}, Destinations: []string{"pagerduty"}, Continue: true},
+		{Destinations: []string{"slack"}},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	critical := r.Route(&watcher.Event{Name: "db", Severity: &watcher.Severity{Level: "critical"}})
+	if !critical.Includes("pagerduty") || !critical.Includes("slack") {
+		t.Error("Expected a critical event to be routed to pagerduty and slack")
+	}
+	// 重要度が分類されていないイベントは重要度の条件に一致しない
+	for _, event := range []*watcher.Event{
+		{Name: "web", Severity: &watcher.Severity{Level: "warning"}},
+		{Name: "api"},
+	} {
+		if sel := r.Route(event); sel.Includes("pagerduty") || !sel.Includes("slack") {
+			t.Errorf("Expected %s to be routed to slack only", event.Name)
+		}
+	}
+}
+
+func TestNewRouter_InvalidExpression(t *testing.T) {
+	_, err := NewRouter([]config.RouteConfig{
+		{MatcherConfig: config.MatcherConfig{Expression: "event.kind =="}, Destinations: []string{"slack"}},
+	})
+	if err == nil {
+		t.Error("Expected error for invalid expression")
+	}
+}
+
+func names(events []*watcher.Event) string {
+	s := ""
+	for _, e := range events {
+		s += e.Name
+	}
+	return s
+}
+
+func TestSelect(t *testing.T) {
+	sel := Select([]string{"pagerduty"})
+	if !sel.Includes("pagerduty") || sel.Includes("slack") {
+		t.Error("Expected only the named destination to be selected")
+	}
+	if !Select(nil).IsEmpty() {
+		t.Error("Expected an empty selection without names")
+	}
+}
+
+func TestRouter_Formatting(t *testing.T) {
+	r, err := NewRouter([]config.RouteConfig{
+		{
+			MatcherConfig: config.MatcherConfig{Namespaces: []string{"prod"}},
+			Destinations:  []string{"incidents", "slack"},
+			Format:        "blocks",
+			Continue:      true,
+		},
+		{
+			Destinations: []string{"slack", "noisy"},
+			Template:     "{{.Kind}} {{.Name}}",
+			Format:       "text",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	// 通知先ごとに最初にマッチしたルートの書式を使う
+	sel := r.Route(&watcher.Event{Namespace: "prod"})
+	for name, want := range map[string]int{"incidents": 0, "slack": 0, "noisy": 1, "sns": -1} {
+		if got := sel.Formatting(name); got != want {
+			t.Errorf("Formatting(%s) = %d, want %d", name, got, want)
+		}
+	}
+	if got := r.Route(&watcher.Event{Namespace: "dev"}).Formatting("slack"); got != 1 {
+		t.Errorf("Formatting(slack) = %d, want 1", got)
+	}
+}
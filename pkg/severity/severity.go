@@ -0,0 +1,69 @@
+// Package severity classifies events into the internal severity levels and
+// maps them to the severities and priorities of paging integrations.
+package severity
+
+import (
+	"fmt"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/filter"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// rule is a compiled config.SeverityRule
+type rule struct {
+	level   string
+	matcher *filter.EventMatcher
+}
+
+// Classifier assigns a severity to events. A nil Classifier is valid and
+// classifies nothing.
+type Classifier struct {
+	rules        []rule
+	defaultLevel string // Empty: by the event type
+	mapping      config.SeverityMappingConfig
+}
+
+// NewClassifier compiles the severity rules of the validated configuration
+func NewClassifier(cfg config.SeverityConfig) (*Classifier, error) {
+	rules := make([]rule, 0, len(cfg.Rules))
+	for i, r := range cfg.Rules {
+		matcher, err := filter.NewEventMatcher(r.MatcherConfig)
+		if err != nil {
+			return nil, fmt.Errorf("severity rule %d: %w", i, err)
+		}
+		rules = append(rules, rule{level: r.Severity, matcher: matcher})
+	}
+	return &Classifier{rules: rules, defaultLevel: cfg.Default, mapping: cfg.Mapping}, nil
+}
+
+// Level returns the severity level of the event: that of the first matching
+// rule, or the default
+func (c *Classifier) Level(event *watcher.Event) string {
+	for _, r := range c.rules {
+		if r.matcher.Matches(event) {
+			return r.level
+		}
+	}
+	if c.defaultLevel != "" {
+		return c.defaultLevel
+	}
+	if event.EventType == watcher.EventTypeWarning {
+		return "warning"
+	}
+	return "info"
+}
+
+// Classify assigns the severity to the event
+func (c *Classifier) Classify(event *watcher.Event) {
+	if c == nil {
+		return
+	}
+	level := c.Level(event)
+	event.Severity = &watcher.Severity{
+		Level:        level,
+		PagerDuty:    c.mapping.PagerDuty[level],
+		Opsgenie:     c.mapping.Opsgenie[level],
+		Alertmanager: c.mapping.Alertmanager[level],
+	}
+}
@@ -0,0 +1,92 @@
+package severity
+
+import (
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// newTestClassifier creates a Classifier from the validated configuration
+func newTestClassifier(t *testing.T, cfg config.SeverityConfig) *Classifier {
+	t.Helper()
+	cfg.Enabled = true
+	c := &config.Config{
+		Namespace: "default",
+		Resources: []config.ResourceConfig{{Kind: "Pod"}},
+		Notifier:  config.NotifierConfig{Slack: config.SlackConfig{WebhookURL: "https://example.com"}},
+		Severity:  cfg,
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	classifier, err := NewClassifier(c.Severity)
+	if err != nil {
+		t.Fatalf("NewClassifier() error = %v", err)
+	}
+	return classifier
+}
+
+func TestClassifier_Rules(t *testing.T) {
+	c := newTestClassifier(t, config.SeverityConfig{
+		Rules: []config.SeverityRule{
+			{Severity: "critical", MatcherConfig: config.MatcherConfig{Namespaces: []string{"prod"}, EventTypes: []string{watcher.EventTypeWarning}}},
+			{Severity: "info", MatcherConfig: config.MatcherConfig{EventTypes: []string{watcher.EventTypeWarning}}},
+		},
+		Mapping: config.SeverityMappingConfig{PagerDuty: map[string]string{"info": "warning"}},
+	})
+
+	tests := []struct {
+		name  string
+		event *watcher.Event
+		want  watcher.Severity
+	}{
+		{
+			name:  "first matching rule",
+			event: &watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: watcher.EventTypeWarning},
+			want:  watcher.Severity{Level: "critical", PagerDuty: "critical", Opsgenie: "P1", Alertmanager: "critical"},
+		},
+		{
+			name:  "later rule",
+			event: &watcher.Event{Kind: "Pod", Namespace: "dev", Name: "web", EventType: watcher.EventTypeWarning},
+			want:  watcher.Severity{Level: "info", PagerDuty: "warning", Opsgenie: "P5", Alertmanager: "info"},
+		},
+		{
+			name:  "no matching rule",
+			event: &watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "DELETED"},
+			want:  watcher.Severity{Level: "info", PagerDuty: "warning", Opsgenie: "P5", Alertmanager: "info"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.Classify(tt.event)
+			if tt.event.Severity == nil || *tt.event.Severity != tt.want {
+				t.Errorf("Classify() = %+v, want %+v", tt.event.Severity, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifier_Default(t *testing.T) {
+	// デフォルト未指定時はWARNINGイベントをwarning、それ以外をinfoとする
+	c := newTestClassifier(t, config.SeverityConfig{})
+	if got := c.Level(&watcher.Event{Kind: "Pod", EventType: watcher.EventTypeWarning}); got != "warning" {
+		t.Errorf("Expected warning for WARNING events, got %s", got)
+	}
+	if got := c.Level(&watcher.Event{Kind: "Pod", EventType: "UPDATED"}); got != "info" {
+		t.Errorf("Expected info for other events, got %s", got)
+	}
+
+	c = newTestClassifier(t, config.SeverityConfig{Default: "critical"})
+	if got := c.Level(&watcher.Event{Kind: "Pod", EventType: "UPDATED"}); got != "critical" {
+		t.Errorf("Expected the configured default, got %s", got)
+	}
+
+	// nilのClassifierは何もしない
+	var none *Classifier
+	event := &watcher.Event{Kind: "Pod"}
+	none.Classify(event)
+	if event.Severity != nil {
+		t.Errorf("Expected no severity, got %+v", event.Severity)
+	}
+}
@@ -0,0 +1,275 @@
+// Package silence suppresses notifications for events matching a silence until
+// it expires, similar to Alertmanager silences.
+package silence
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/filter"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// Sources of silences
+const (
+	SourceConfig = "config" // Declared in the configuration, replaced on reload
+	SourceAPI    = "api"    // Created through the admin API, kept in memory only
+)
+
+// Silence suppresses notifications for matching events between StartsAt and EndsAt
+type Silence struct {
+	ID         string               `json:"id"`
+	Matcher    config.MatcherConfig `json:"matcher"`
+	Comment    string               `json:"comment,omitempty"`
+	CreatedBy  string               `json:"createdBy,omitempty"`
+	Source     string               `json:"source"`
+	StartsAt   time.Time            `json:"startsAt"`
+	EndsAt     time.Time            `json:"endsAt"`
+	Suppressed int64                `json:"suppressed"` // Events suppressed so far
+}
+
+// ExpireFunc is called when a silence expires or is removed, with its final suppressed count
+type ExpireFunc func(s Silence)
+
+type entry struct {
+	Silence
+	matcher *filter.EventMatcher
+}
+
+// Manager holds the silences and expires them in the background.
+// A nil Manager is valid and silences nothing.
+type Manager struct {
+	onExpire ExpireFunc
+	now      func() time.Time
+
+	mu       sync.Mutex
+	silences map[string]*entry
+	stopC    chan struct{}
+}
+
+// NewManager creates a Manager and starts checking for expired silences
+func NewManager(onExpire ExpireFunc) *Manager {
+	m := &Manager{
+		onExpire: onExpire,
+		now:      time.Now,
+		silences: make(map[string]*entry),
+		stopC:    make(chan struct{}),
+	}
+	go m.expireLoop()
+	return m
+}
+
+// Add adds a silence. ID and Source are assigned; StartsAt defaults to now.
+func (m *Manager) Add(s Silence) (Silence, error) {
+	s.ID = randomID()
+	s.Source = SourceAPI
+	s.Suppressed = 0
+	e, err := m.newEntry(s)
+	if err != nil {
+		return Silence{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.silences[e.ID] = e
+	return e.Silence, nil
+}
+
+func (m *Manager) newEntry(s Silence) (*entry, error) {
+	if s.Matcher.IsEmpty() {
+		return nil, fmt.Errorf("silence matcher must have at least one condition")
+	}
+	if s.StartsAt.IsZero() {
+		s.StartsAt = m.now()
+	}
+	if !s.EndsAt.After(s.StartsAt) || !s.EndsAt.After(m.now()) {
+		return nil, fmt.Errorf("silence must end in the future and after it starts")
+	}
+	matcher, err := filter.NewEventMatcher(s.Matcher)
+	if err != nil {
+		return nil, err
+	}
+	return &entry{Silence: s, matcher: matcher}, nil
+}
+
+// Remove expires a silence early and reports it
+func (m *Manager) Remove(id string) (Silence, bool) {
+	m.mu.Lock()
+	e, ok := m.silences[id]
+	if ok {
+		delete(m.silences, id)
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return Silence{}, false
+	}
+	m.report(e.Silence)
+	return e.Silence, true
+}
+
+// SetConfigured replaces the silences declared in the configuration. Silences
+// that are still declared keep their suppressed counts; silences that are no
+// longer declared are reported as expired.
+func (m *Manager) SetConfigured(silences []config.SilenceConfig) error {
+	next := make(map[string]*entry, len(silences))
+	for i, sc := range silences {
+		s := Silence{
+			ID:       configID(sc),
+			Matcher:  sc.MatcherConfig,
+			Comment:  sc.Comment,
+			Source:   SourceConfig,
+			StartsAt: sc.StartsAt,
+			EndsAt:   sc.EndsAt,
+		}
+		// Silences that already ended are skipped, so that an old entry left in the file is harmless
+		if !s.EndsAt.After(m.now()) {
+			continue
+		}
+		e, err := m.newEntry(s)
+		if err != nil {
+			return fmt.Errorf("silences[%d]: %w", i, err)
+		}
+		next[e.ID] = e
+	}
+
+	var removed []Silence
+	m.mu.Lock()
+	for id, e := range m.silences {
+		if e.Source != SourceConfig {
+			continue
+		}
+		if n, ok := next[id]; ok {
+			n.Suppressed = e.Suppressed
+		} else {
+			removed = append(removed, e.Silence)
+		}
+		delete(m.silences, id)
+	}
+	for id, e := range next {
+		m.silences[id] = e
+	}
+	m.mu.Unlock()
+
+	for _, s := range removed {
+		m.report(s)
+	}
+	return nil
+}
+
+// Validate checks that the matchers of the declared silences compile, so that a
+// configuration can be rejected before it replaces the running one
+func Validate(silences []config.SilenceConfig) error {
+	for i, sc := range silences {
+		if _, err := filter.NewEventMatcher(sc.MatcherConfig); err != nil {
+			return fmt.Errorf("silences[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// List returns the active and pending silences ordered by end time
+func (m *Manager) List() []Silence {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]Silence, 0, len(m.silences))
+	for _, e := range m.silences {
+		out = append(out, e.Silence)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].EndsAt.Equal(out[j].EndsAt) {
+			return out[i].EndsAt.Before(out[j].EndsAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Silenced reports whether the event matches an active silence, counting it as suppressed
+func (m *Manager) Silenced(event *watcher.Event) (Silence, bool) {
+	if m == nil {
+		return Silence{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for _, e := range m.silences {
+		if now.Before(e.StartsAt) || !now.Before(e.EndsAt) {
+			continue
+		}
+		if e.matcher.Matches(event) {
+			e.Suppressed++
+			return e.Silence, true
+		}
+	}
+	return Silence{}, false
+}
+
+// Stop stops checking for expired silences
+func (m *Manager) Stop() {
+	close(m.stopC)
+}
+
+func (m *Manager) expireLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopC:
+			return
+		case <-ticker.C:
+			m.expire()
+		}
+	}
+}
+
+// expire removes the silences that have ended and reports them
+func (m *Manager) expire() {
+	var expired []Silence
+	m.mu.Lock()
+	now := m.now()
+	for id, e := range m.silences {
+		if !now.Before(e.EndsAt) {
+			expired = append(expired, e.Silence)
+			delete(m.silences, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, s := range expired {
+		m.report(s)
+	}
+}
+
+func (m *Manager) report(s Silence) {
+	if m.onExpire != nil {
+		m.onExpire(s)
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// configID derives a stable ID from the declaration, so that a silence keeps its
+// suppressed count across reloads as long as it is unchanged
+func configID(sc config.SilenceConfig) string {
+	data, _ := json.Marshal(sc)
+	sum := sha256.Sum256(data)
+	return "config-" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,174 @@
+package silence
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// newTestManager creates a Manager driven by now, without the background expiry loop
+func newTestManager(now *time.Time) (*Manager, *[]Silence) {
+	var mu sync.Mutex
+	var reported []Silence
+	m := &Manager{
+		onExpire: func(s Silence) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, s)
+		},
+		now:      func() time.Time { return *now },
+		silences: make(map[string]*entry),
+		stopC:    make(chan struct{}),
+	}
+	return m, &reported
+}
+
+func TestManager_Silenced(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, reported := newTestManager(&now)
+
+	s, err := m.Add(Silence{
+		Matcher: config.MatcherConfig{Namespaces: []string{"staging"}, Names: []string{"web-1", "web-2"}},
+		Comment: "maintenance",
+		EndsAt:  now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if s.ID == "" || s.Source != SourceAPI || !s.StartsAt.Equal(now) {
+		t.Errorf("Unexpected silence %+v", s)
+	}
+
+	if _, ok := m.Silenced(&watcher.Event{Kind: "Pod", Namespace: "staging", Name: "web-1"}); !ok {
+		t.Error("Expected matching event to be silenced")
+	}
+	m.Silenced(&watcher.Event{Kind: "Pod", Namespace: "staging", Name: "web-2"})
+	if _, ok := m.Silenced(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web-1"}); ok {
+		t.Error("Expected event in another namespace not to be silenced")
+	}
+
+	// 期限切れで抑止件数が報告される
+	now = now.Add(time.Hour)
+	m.expire()
+	if len(*reported) != 1 || (*reported)[0].Suppressed != 2 || (*reported)[0].Comment != "maintenance" {
+		t.Fatalf("Expected expiry to be reported with 2 suppressed events, got %+v", *reported)
+	}
+	if len(m.List()) != 0 {
+		t.Error("Expected expired silence to be removed")
+	}
+}
+
+func TestManager_Pending(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(&now)
+
+	if _, err := m.Add(Silence{
+		Matcher:  config.MatcherConfig{Kinds: []string{"Pod"}},
+		StartsAt: now.Add(time.Hour),
+		EndsAt:   now.Add(2 * time.Hour),
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// 開始前のサイレンスは抑止しない
+	if _, ok := m.Silenced(&watcher.Event{Kind: "Pod"}); ok {
+		t.Error("Expected pending silence not to apply")
+	}
+	now = now.Add(90 * time.Minute)
+	if _, ok := m.Silenced(&watcher.Event{Kind: "Pod"}); !ok {
+		t.Error("Expected started silence to apply")
+	}
+}
+
+func TestManager_AddInvalid(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(&now)
+
+	tests := []struct {
+		name string
+		s    Silence
+	}{
+		{"条件なし", Silence{EndsAt: now.Add(time.Hour)}},
+		{"終了時刻が過去", Silence{Matcher: config.MatcherConfig{Kinds: []string{"Pod"}}, EndsAt: now.Add(-time.Hour)}},
+		{"不正な式", Silence{Matcher: config.MatcherConfig{Expression: "kind =="}, EndsAt: now.Add(time.Hour)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Add(tt.s); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestManager_Remove(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, reported := newTestManager(&now)
+
+	s, _ := m.Add(Silence{Matcher: config.MatcherConfig{Kinds: []string{"Pod"}}, EndsAt: now.Add(time.Hour)})
+	m.Silenced(&watcher.Event{Kind: "Pod"})
+
+	removed, ok := m.Remove(s.ID)
+	if !ok || removed.Suppressed != 1 {
+		t.Errorf("Expected removed silence with 1 suppressed event, got %+v, %v", removed, ok)
+	}
+	if len(*reported) != 1 {
+		t.Errorf("Expected removal to be reported, got %d reports", len(*reported))
+	}
+	if _, ok := m.Remove(s.ID); ok {
+		t.Error("Expected second removal to fail")
+	}
+}
+
+func TestManager_SetConfigured(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, reported := newTestManager(&now)
+
+	kept := config.SilenceConfig{MatcherConfig: config.MatcherConfig{Kinds: []string{"Pod"}}, EndsAt: now.Add(time.Hour)}
+	dropped := config.SilenceConfig{MatcherConfig: config.MatcherConfig{Kinds: []string{"Node"}}, EndsAt: now.Add(time.Hour)}
+	ended := config.SilenceConfig{MatcherConfig: config.MatcherConfig{Kinds: []string{"Job"}}, EndsAt: now.Add(-time.Hour)}
+	if err := m.SetConfigured([]config.SilenceConfig{kept, dropped, ended}); err != nil {
+		t.Fatalf("SetConfigured() error = %v", err)
+	}
+	// 終了済みのサイレンスは読み込まない
+	if got := len(m.List()); got != 2 {
+		t.Fatalf("Expected 2 silences, got %d", got)
+	}
+	api, _ := m.Add(Silence{Matcher: config.MatcherConfig{Kinds: []string{"Service"}}, EndsAt: now.Add(time.Hour)})
+
+	m.Silenced(&watcher.Event{Kind: "Pod"})
+	m.Silenced(&watcher.Event{Kind: "Node"})
+
+	// 再読み込み後も変更のないサイレンスは件数を引き継ぎ、削除されたものは報告する
+	if err := m.SetConfigured([]config.SilenceConfig{kept}); err != nil {
+		t.Fatalf("SetConfigured() error = %v", err)
+	}
+	if len(*reported) != 1 || (*reported)[0].Matcher.Kinds[0] != "Node" || (*reported)[0].Suppressed != 1 {
+		t.Errorf("Expected the dropped silence to be reported, got %+v", *reported)
+	}
+	list := m.List()
+	if len(list) != 2 {
+		t.Fatalf("Expected the kept and API silences, got %+v", list)
+	}
+	for _, s := range list {
+		if s.Source == SourceConfig && s.Suppressed != 1 {
+			t.Errorf("Expected suppressed count to be kept, got %d", s.Suppressed)
+		}
+		if s.Source == SourceAPI && s.ID != api.ID {
+			t.Errorf("Unexpected API silence %+v", s)
+		}
+	}
+}
+
+func TestManager_Nil(t *testing.T) {
+	var m *Manager
+	if _, ok := m.Silenced(&watcher.Event{Kind: "Pod"}); ok {
+		t.Error("Expected nil manager to silence nothing")
+	}
+	if len(m.List()) != 0 {
+		t.Error("Expected no silences")
+	}
+}
@@ -0,0 +1,296 @@
+// Package stats aggregates recent pipeline activity in memory for the /stats endpoint.
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// DefaultWindow is the period the aggregates cover
+const DefaultWindow = time.Hour
+
+// topResources is the number of noisiest resources reported
+const topResources = 10
+
+// maxUnackedEvents is the number of unacknowledged events listed
+const maxUnackedEvents = 50
+
+// ResourceKey identifies a watched resource
+type ResourceKey struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// ResourceCount is the number of events of a resource
+type ResourceCount struct {
+	ResourceKey
+	Events int64 `json:"events"`
+}
+
+// DedupStats summarizes deduplication decisions
+type DedupStats struct {
+	Hits    int64   `json:"hits"`    // Events suppressed as duplicates
+	Misses  int64   `json:"misses"`  // Events passed through
+	HitRate float64 `json:"hitRate"` // Hits / (Hits + Misses), 0 without decisions
+}
+
+// BatchStats summarizes flushed batches
+type BatchStats struct {
+	Count   int64   `json:"count"`
+	Events  int64   `json:"events"`
+	Average float64 `json:"average"`
+	Max     int     `json:"max"`
+}
+
+// UnackedEvent is a notified critical event awaiting an acknowledgement
+type UnackedEvent struct {
+	ID string `json:"id"`
+	ResourceKey
+	EventType  string    `json:"eventType"`
+	Reason     string    `json:"reason,omitempty"`
+	NotifiedAt time.Time `json:"notifiedAt"`
+}
+
+// AckStats summarizes the critical events that have not been acknowledged,
+// regardless of the window
+type AckStats struct {
+	Count  int            `json:"count"`
+	Events []UnackedEvent `json:"events"` // Newest first, at most maxUnackedEvents
+}
+
+// Snapshot is the JSON document served by the /stats endpoint
+type Snapshot struct {
+	Window       string           `json:"window"`
+	Since        time.Time        `json:"since"`
+	Events       int64            `json:"events"` // Events that passed the filters
+	ByKind       map[string]int64 `json:"byKind"`
+	ByEventType  map[string]int64 `json:"byEventType"`
+	ByNamespace  map[string]int64 `json:"byNamespace"`
+	TopResources []ResourceCount  `json:"topResources"`
+	Dedup        DedupStats       `json:"dedup"`
+	Batches      BatchStats       `json:"batches"`
+	Unacked      *AckStats        `json:"unacknowledged,omitempty"` // Set when acknowledgements are tracked
+}
+
+// bucket holds the counts of one minute
+type bucket struct {
+	minute      time.Time
+	byKind      map[string]int64
+	byEventType map[string]int64
+	byNamespace map[string]int64
+	byResource  map[ResourceKey]int64
+	dedup       DedupStats
+	batches     BatchStats
+}
+
+func newBucket(minute time.Time) *bucket {
+	return &bucket{
+		minute:      minute,
+		byKind:      make(map[string]int64),
+		byEventType: make(map[string]int64),
+		byNamespace: make(map[string]int64),
+		byResource:  make(map[ResourceKey]int64),
+	}
+}
+
+// Aggregator keeps per-minute counts over a sliding window.
+// A nil Aggregator is valid and records nothing.
+type Aggregator struct {
+	window  time.Duration
+	now     func() time.Time
+	unacked func() []UnackedEvent
+	mu      sync.Mutex
+	buckets []*bucket // Oldest first
+}
+
+// NewAggregator creates an Aggregator covering the last window (DefaultWindow if <= 0)
+func NewAggregator(window time.Duration) *Aggregator {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Aggregator{
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// SetUnacknowledged sets the source of the unacknowledged critical events, newest
+// first, reported in the snapshot. It must be called before the snapshot is served.
+func (a *Aggregator) SetUnacknowledged(unacked func() []UnackedEvent) {
+	if a != nil {
+		a.unacked = unacked
+	}
+}
+
+// current returns the bucket of the current minute, dropping buckets outside the window.
+// Must be called with mu held.
+func (a *Aggregator) current() *bucket {
+	minute := a.now().Truncate(time.Minute)
+	a.prune(minute)
+	if n := len(a.buckets); n > 0 && a.buckets[n-1].minute.Equal(minute) {
+		return a.buckets[n-1]
+	}
+	b := newBucket(minute)
+	a.buckets = append(a.buckets, b)
+	return b
+}
+
+// prune drops buckets that ended before the window. Must be called with mu held.
+func (a *Aggregator) prune(minute time.Time) {
+	cutoff := minute.Add(-a.window)
+	i := 0
+	for i < len(a.buckets) && !a.buckets[i].minute.After(cutoff) {
+		i++
+	}
+	a.buckets = a.buckets[i:]
+}
+
+// RecordEvent counts an event that passed the filters
+func (a *Aggregator) RecordEvent(e *watcher.Event) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	b := a.current()
+	b.byKind[e.Kind]++
+	b.byEventType[e.EventType]++
+	if e.Namespace != "" {
+		b.byNamespace[e.Namespace]++
+	}
+	b.byResource[ResourceKey{Kind: e.Kind, Namespace: e.Namespace, Name: e.Name}]++
+}
+
+// RecordDedup counts a deduplication decision
+func (a *Aggregator) RecordDedup(duplicate bool) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	b := a.current()
+	if duplicate {
+		b.dedup.Hits++
+	} else {
+		b.dedup.Misses++
+	}
+}
+
+// RecordBatch counts a flushed batch of size events
+func (a *Aggregator) RecordBatch(size int) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	b := a.current()
+	b.batches.Count++
+	b.batches.Events += int64(size)
+	if size > b.batches.Max {
+		b.batches.Max = size
+	}
+}
+
+// Snapshot returns the aggregates over the window
+func (a *Aggregator) Snapshot() Snapshot {
+	s := Snapshot{
+		ByKind:       make(map[string]int64),
+		ByEventType:  make(map[string]int64),
+		ByNamespace:  make(map[string]int64),
+		TopResources: []ResourceCount{},
+	}
+	if a == nil {
+		return s
+	}
+
+	if a.unacked != nil {
+		events := a.unacked()
+		s.Unacked = &AckStats{Count: len(events), Events: events[:min(len(events), maxUnackedEvents)]}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	a.prune(now.Truncate(time.Minute))
+	s.Window = a.window.String()
+	s.Since = now.Add(-a.window)
+
+	resources := make(map[ResourceKey]int64)
+	for _, b := range a.buckets {
+		for k, n := range b.byKind {
+			s.ByKind[k] += n
+			s.Events += n
+		}
+		for t, n := range b.byEventType {
+			s.ByEventType[t] += n
+		}
+		for ns, n := range b.byNamespace {
+			s.ByNamespace[ns] += n
+		}
+		for r, n := range b.byResource {
+			resources[r] += n
+		}
+		s.Dedup.Hits += b.dedup.Hits
+		s.Dedup.Misses += b.dedup.Misses
+		s.Batches.Count += b.batches.Count
+		s.Batches.Events += b.batches.Events
+		if b.batches.Max > s.Batches.Max {
+			s.Batches.Max = b.batches.Max
+		}
+	}
+
+	if total := s.Dedup.Hits + s.Dedup.Misses; total > 0 {
+		s.Dedup.HitRate = float64(s.Dedup.Hits) / float64(total)
+	}
+	if s.Batches.Count > 0 {
+		s.Batches.Average = float64(s.Batches.Events) / float64(s.Batches.Count)
+	}
+
+	for r, n := range resources {
+		s.TopResources = append(s.TopResources, ResourceCount{ResourceKey: r, Events: n})
+	}
+	// Most events first, then by name for stable output
+	sort.Slice(s.TopResources, func(i, j int) bool {
+		x, y := s.TopResources[i], s.TopResources[j]
+		if x.Events != y.Events {
+			return x.Events > y.Events
+		}
+		if x.Kind != y.Kind {
+			return x.Kind < y.Kind
+		}
+		if x.Namespace != y.Namespace {
+			return x.Namespace < y.Namespace
+		}
+		return x.Name < y.Name
+	})
+	if len(s.TopResources) > topResources {
+		s.TopResources = s.TopResources[:topResources]
+	}
+
+	return s
+}
+
+// Handler returns an http.Handler that serves the snapshot as JSON
+func (a *Aggregator) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(a.Snapshot())
+	})
+}
@@ -0,0 +1,157 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+func newTestAggregator(now *time.Time) *Aggregator {
+	a := NewAggregator(time.Hour)
+	a.now = func() time.Time { return *now }
+	return a
+}
+
+func TestAggregator_Snapshot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := newTestAggregator(&now)
+
+	for i := 0; i < 3; i++ {
+		a.RecordEvent(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "UPDATED"})
+	}
+	a.RecordEvent(&watcher.Event{Kind: "Deployment", Namespace: "dev", Name: "api", EventType: "ADDED"})
+	a.RecordEvent(&watcher.Event{Kind: "Node", Name: "node-1", EventType: "UPDATED"})
+	a.RecordDedup(true)
+	a.RecordDedup(false)
+	a.RecordDedup(false)
+	a.RecordDedup(false)
+	a.RecordBatch(2)
+	a.RecordBatch(6)
+
+	s := a.Snapshot()
+	if s.Events != 5 {
+		t.Errorf("Expected 5 events, got %d", s.Events)
+	}
+	if s.ByKind["Pod"] != 3 || s.ByKind["Deployment"] != 1 || s.ByKind["Node"] != 1 {
+		t.Errorf("Unexpected byKind %v", s.ByKind)
+	}
+	if s.ByEventType["UPDATED"] != 4 || s.ByEventType["ADDED"] != 1 {
+		t.Errorf("Unexpected byEventType %v", s.ByEventType)
+	}
+	// クラスタースコープのリソースは名前空間別の集計に含めない
+	if len(s.ByNamespace) != 2 || s.ByNamespace["prod"] != 3 {
+		t.Errorf("Unexpected byNamespace %v", s.ByNamespace)
+	}
+	if len(s.TopResources) != 3 || s.TopResources[0].Name != "web" || s.TopResources[0].Events != 3 {
+		t.Errorf("Unexpected topResources %+v", s.TopResources)
+	}
+	if s.Dedup.Hits != 1 || s.Dedup.Misses != 3 || s.Dedup.HitRate != 0.25 {
+		t.Errorf("Unexpected dedup stats %+v", s.Dedup)
+	}
+	if s.Batches.Count != 2 || s.Batches.Events != 8 || s.Batches.Average != 4 || s.Batches.Max != 6 {
+		t.Errorf("Unexpected batch stats %+v", s.Batches)
+	}
+}
+
+func TestAggregator_Window(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := newTestAggregator(&now)
+
+	a.RecordEvent(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "old", EventType: "UPDATED"})
+	now = now.Add(30 * time.Minute)
+	a.RecordEvent(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "new", EventType: "UPDATED"})
+
+	if s := a.Snapshot(); s.Events != 2 {
+		t.Errorf("Expected 2 events within the window, got %d", s.Events)
+	}
+
+	// 1時間を過ぎたイベントは集計から外れる
+	now = now.Add(31 * time.Minute)
+	s := a.Snapshot()
+	if s.Events != 1 || s.TopResources[0].Name != "new" {
+		t.Errorf("Expected only the recent event, got %+v", s)
+	}
+	if len(a.buckets) != 1 {
+		t.Errorf("Expected expired buckets to be dropped, got %d", len(a.buckets))
+	}
+}
+
+func TestAggregator_TopResourcesLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := newTestAggregator(&now)
+
+	for i := 0; i < topResources+5; i++ {
+		a.RecordEvent(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: string(rune('a' + i)), EventType: "UPDATED"})
+	}
+
+	s := a.Snapshot()
+	if len(s.TopResources) != topResources {
+		t.Errorf("Expected %d top resources, got %d", topResources, len(s.TopResources))
+	}
+	// 件数が同じ場合は名前順
+	if s.TopResources[0].Name != "a" {
+		t.Errorf("Expected stable order, got %+v", s.TopResources[0])
+	}
+}
+
+func TestAggregator_Nil(t *testing.T) {
+	var a *Aggregator
+	a.RecordEvent(&watcher.Event{Kind: "Pod"})
+	a.RecordDedup(true)
+	a.RecordBatch(1)
+	if s := a.Snapshot(); s.Events != 0 {
+		t.Errorf("Expected empty snapshot, got %+v", s)
+	}
+}
+
+func TestAggregator_Handler(t *testing.T) {
+	a := NewAggregator(0)
+	a.RecordEvent(&watcher.Event{Kind: "Pod", Namespace: "prod", Name: "web", EventType: "UPDATED"})
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected Content-Type %q", rec.Header().Get("Content-Type"))
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if s.Window != "1h0m0s" || s.Events != 1 || s.TopResources[0].Kind != "Pod" {
+		t.Errorf("Unexpected response %+v", s)
+	}
+
+	rec = httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/stats", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", rec.Code)
+	}
+}
+
+func TestAggregator_Unacknowledged(t *testing.T) {
+	a := NewAggregator(0)
+	// 確認応答を追跡していない場合は含めない
+	if s := a.Snapshot(); s.Unacked != nil {
+		t.Errorf("Expected no unacknowledged section, got %+v", s.Unacked)
+	}
+
+	var events []UnackedEvent
+	for i := 0; i < maxUnackedEvents+5; i++ {
+		events = append(events, UnackedEvent{ID: fmt.Sprintf("%d", i), ResourceKey: ResourceKey{Kind: "Pod", Name: "web"}})
+	}
+	a.SetUnacknowledged(func() []UnackedEvent { return events })
+
+	s := a.Snapshot()
+	if s.Unacked == nil || s.Unacked.Count != maxUnackedEvents+5 || len(s.Unacked.Events) != maxUnackedEvents {
+		t.Errorf("Expected all events counted and the list limited, got %+v", s.Unacked)
+	}
+}
@@ -0,0 +1,230 @@
+// Package storm detects event storms: abnormal spikes in the rate of events of
+// a kind in a namespace compared to its rolling baseline. While a storm lasts,
+// its events are held back and handed over as periodic summaries.
+package storm
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// buckets is the number of buckets the rate window is divided into
+const buckets = 6
+
+// baselinePeriod is the period the baseline is averaged over
+const baselinePeriod = 10 * time.Minute
+
+// Config configures a Detector
+type Config struct {
+	Window          time.Duration // The rate is the number of events within this window
+	Factor          float64       // A storm starts when the rate exceeds the baseline times this factor
+	MinEvents       int           // ... and is at least this many events
+	SummaryInterval time.Duration // The held events are summarized this often
+}
+
+// Storm is an ongoing or ended spike of events of a kind in a namespace
+type Storm struct {
+	Kind      string
+	Namespace string
+	StartTime time.Time
+	EndTime   time.Time // Zero while the storm lasts
+	Baseline  float64   // Usual number of events per window before the storm
+	Peak      int       // Highest number of events within a window
+	Events    int       // Events held back during the storm
+}
+
+// Handlers are called by the Detector outside its lock
+type Handlers struct {
+	Started func(s Storm)
+	Summary func(s Storm, events []*watcher.Event, since, until time.Time)
+	Ended   func(s Storm)
+}
+
+// series is the rate state of a kind in a namespace
+type series struct {
+	counts   [buckets]int
+	pos      int
+	baseline float64
+
+	storm        *Storm
+	held         []*watcher.Event
+	summarySince time.Time
+}
+
+// rate returns the number of events within the window
+func (s *series) rate() int {
+	n := 0
+	for _, c := range s.counts {
+		n += c
+	}
+	return n
+}
+
+// Detector measures the event rate of each kind and namespace, holding back
+// the events of those in a storm
+type Detector struct {
+	cfg      Config
+	handlers Handlers
+	now      func() time.Time
+
+	mu     sync.Mutex
+	series map[[2]string]*series // Kind, namespace -> series
+	stopC  chan struct{}
+	done   chan struct{}
+}
+
+// NewDetector creates a Detector and starts measuring the rates
+func NewDetector(cfg Config, handlers Handlers) *Detector {
+	d := &Detector{
+		cfg:      cfg,
+		handlers: handlers,
+		now:      time.Now,
+		series:   make(map[[2]string]*series),
+		stopC:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go d.loop()
+	return d
+}
+
+// threshold returns the rate at which a series with the baseline is in a storm
+func (d *Detector) threshold(baseline float64) float64 {
+	return max(float64(d.cfg.MinEvents), d.cfg.Factor*baseline)
+}
+
+// Add counts the event and reports whether it was held back because its kind
+// and namespace are in a storm. The event that starts a storm is held back too.
+func (d *Detector) Add(event *watcher.Event) bool {
+	d.mu.Lock()
+	key := [2]string{event.Kind, event.Namespace}
+	s := d.series[key]
+	if s == nil {
+		s = &series{}
+		d.series[key] = s
+	}
+	s.counts[s.pos]++
+
+	var started *Storm
+	if s.storm == nil {
+		rate := s.rate()
+		if float64(rate) < d.threshold(s.baseline) {
+			d.mu.Unlock()
+			return false
+		}
+		now := d.now()
+		s.storm = &Storm{Kind: event.Kind, Namespace: event.Namespace, StartTime: now, Baseline: s.baseline, Peak: rate}
+		s.summarySince = now
+		started = s.storm
+	}
+	s.storm.Events++
+	s.storm.Peak = max(s.storm.Peak, s.rate())
+	s.held = append(s.held, event)
+	var snapshot *Storm
+	if started != nil {
+		copied := *started
+		snapshot = &copied
+	}
+	d.mu.Unlock()
+
+	if snapshot != nil {
+		slog.Warn("Event storm started", "kind", snapshot.Kind, "namespace", snapshot.Namespace, "rate", snapshot.Peak, "baseline", snapshot.Baseline)
+		if d.handlers.Started != nil {
+			d.handlers.Started(*snapshot)
+		}
+	}
+	return true
+}
+
+// Held returns the number of events held back for the next summaries
+func (d *Detector) Held() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for _, s := range d.series {
+		n += len(s.held)
+	}
+	return n
+}
+
+// Stop stops the detector, summarizing the held events and ending the ongoing storms
+func (d *Detector) Stop() {
+	close(d.stopC)
+	<-d.done
+	d.tick(true)
+}
+
+func (d *Detector) loop() {
+	defer close(d.done)
+	ticker := time.NewTicker(d.cfg.Window / buckets)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopC:
+			return
+		case <-ticker.C:
+			d.tick(false)
+		}
+	}
+}
+
+// summary is a batch of held events to hand over
+type summary struct {
+	storm        Storm
+	events       []*watcher.Event
+	since, until time.Time
+	ended        bool
+}
+
+// tick advances the rate windows: storms whose rate dropped below the
+// threshold end, the held events are summarized when due, and the baselines
+// of the other series are updated. With all set, every storm ends.
+func (d *Detector) tick(all bool) {
+	d.mu.Lock()
+	now := d.now()
+	alpha := (d.cfg.Window / buckets).Seconds() / baselinePeriod.Seconds()
+	var summaries []summary
+	for key, s := range d.series {
+		rate := s.rate()
+		if s.storm != nil {
+			// Storms end below half the threshold, so that a rate around it does not flap
+			ended := all || float64(rate) < d.threshold(s.storm.Baseline)/2
+			if ended || (len(s.held) > 0 && now.Sub(s.summarySince) >= d.cfg.SummaryInterval) {
+				if ended {
+					s.storm.EndTime = now
+				}
+				summaries = append(summaries, summary{storm: *s.storm, events: s.held, since: s.summarySince, until: now, ended: ended})
+				s.held = nil
+				s.summarySince = now
+			}
+			if ended {
+				s.storm = nil
+			}
+		} else {
+			s.baseline += alpha * (float64(rate) - s.baseline)
+		}
+
+		s.pos = (s.pos + 1) % buckets
+		s.counts[s.pos] = 0
+		if s.storm == nil && rate == 0 && s.baseline < 0.01 {
+			delete(d.series, key)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, sum := range summaries {
+		if len(sum.events) > 0 && d.handlers.Summary != nil {
+			d.handlers.Summary(sum.storm, sum.events, sum.since, sum.until)
+		}
+		if !sum.ended {
+			continue
+		}
+		slog.Info("Event storm ended", "kind", sum.storm.Kind, "namespace", sum.storm.Namespace, "events", sum.storm.Events, "duration", sum.storm.EndTime.Sub(sum.storm.StartTime))
+		if d.handlers.Ended != nil {
+			d.handlers.Ended(sum.storm)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package storm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kqns91/kube-watcher/pkg/watcher"
+)
+
+// recorder collects the calls of the handlers
+type recorder struct {
+	started, ended []Storm
+	summaries      [][]*watcher.Event
+}
+
+// newTestDetector creates a Detector driven by now, without the background loop
+func newTestDetector(now *time.Time) (*Detector, *recorder) {
+	r := &recorder{}
+	d := &Detector{
+		cfg: Config{Window: time.Minute, Factor: 5, MinEvents: 10, SummaryInterval: time.Minute},
+		handlers: Handlers{
+			Started: func(s Storm) { r.started = append(r.started, s) },
+			Summary: func(s Storm, events []*watcher.Event, since, until time.Time) {
+				r.summaries = append(r.summaries, events)
+			},
+			Ended: func(s Storm) { r.ended = append(r.ended, s) },
+		},
+		now:    func() time.Time { return *now },
+		series: make(map[[2]string]*series),
+	}
+	return d, r
+}
+
+func pod(namespace string) *watcher.Event {
+	return &watcher.Event{Kind: "Pod", Namespace: namespace, Name: "web", EventType: "UPDATED"}
+}
+
+// advance moves the clock and the detector by one bucket
+func advance(d *Detector, now *time.Time) {
+	*now = now.Add(d.cfg.Window / buckets)
+	d.tick(false)
+}
+
+func TestDetector_Storm(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, r := newTestDetector(&now)
+
+	// 平常時は10秒ごとに1件
+	for i := 0; i < 60; i++ {
+		if d.Add(pod("prod")) {
+			t.Fatal("Expected events at the usual rate not to be held back")
+		}
+		advance(d, &now)
+	}
+
+	// 急増すると嵐になり、以降のイベントは保留される
+	held := 0
+	for i := 0; i < 30; i++ {
+		if d.Add(pod("prod")) {
+			held++
+		}
+	}
+	if len(r.started) != 1 || r.started[0].Namespace != "prod" {
+		t.Fatalf("Expected a storm to start in prod, got %+v", r.started)
+	}
+	// 基準の5倍を超えた時点から保留される
+	if held == 0 || held == 30 {
+		t.Errorf("Expected the events over the threshold to be held back, got %d", held)
+	}
+	// 他のnamespaceには影響しない
+	if d.Add(pod("dev")) {
+		t.Error("Expected events of another namespace not to be held back")
+	}
+
+	// 間隔ごとに要約が送られる
+	for i := 0; i < buckets; i++ {
+		d.Add(pod("prod"))
+		d.Add(pod("prod"))
+		advance(d, &now)
+	}
+	if len(r.summaries) != 1 || len(r.summaries[0]) != held+2*buckets {
+		t.Fatalf("Expected one summary of the held events, got %d summaries", len(r.summaries))
+	}
+
+	// 収まると終了通知が送られる
+	for i := 0; i < buckets; i++ {
+		advance(d, &now)
+	}
+	if len(r.ended) != 1 || r.ended[0].Events != held+2*buckets {
+		t.Fatalf("Expected the storm to end after %d events, got %+v", held+2*buckets, r.ended)
+	}
+	if len(r.summaries) != 1 || d.Held() != 0 {
+		t.Errorf("Expected no further summary without events, got %d summaries and %d held", len(r.summaries), d.Held())
+	}
+	if d.Add(pod("prod")) {
+		t.Error("Expected events not to be held back after the storm")
+	}
+}
+
+func TestDetector_StopEndsStorms(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, r := newTestDetector(&now)
+	for i := 0; i < 10; i++ {
+		d.Add(pod("prod"))
+	}
+	d.tick(true)
+	if len(r.started) != 1 || len(r.ended) != 1 || len(r.summaries) != 1 || len(r.summaries[0]) != 1 {
+		t.Errorf("Expected the storm to be summarized and ended, got %+v", r)
+	}
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// TraceparentHeader is the W3C Trace Context header carrying the span context
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span across process boundaries
+type SpanContext struct {
+	TraceID string // 32 lowercase hex digits
+	SpanID  string // 16 lowercase hex digits
+	Sampled bool
+	Remote  bool // Extracted from an incoming request
+}
+
+// IsValid reports whether the trace and span IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.SpanID != ""
+}
+
+// traceparentRe matches version 00 of the traceparent header, excluding the
+// all-zero IDs that the specification declares invalid
+var traceparentRe = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+const (
+	invalidTraceID = "00000000000000000000000000000000"
+	invalidSpanID  = "0000000000000000"
+)
+
+// Inject sets the traceparent header to the span in ctx, if any
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	header.Set(TraceparentHeader, fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags))
+}
+
+// Extract returns ctx with the remote span context of the traceparent header.
+// Spans started from the returned context continue the caller's trace.
+// Missing or malformed headers are ignored.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	m := traceparentRe.FindStringSubmatch(header.Get(TraceparentHeader))
+	if m == nil || m[1] == invalidTraceID || m[2] == invalidSpanID {
+		return ctx
+	}
+	var flags byte
+	fmt.Sscanf(m[3], "%02x", &flags)
+	return ContextWithRemoteSpanContext(ctx, SpanContext{TraceID: m[1], SpanID: m[2], Sampled: flags&1 == 1, Remote: true})
+}
+
+type remoteKey struct{}
+
+// ContextWithRemoteSpanContext returns ctx with sc as the parent of the spans started from it
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the span in ctx, or the
+// remote span context if no span was started from it
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span, _ := ctx.Value(spanKey{}).(*Span); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// Sampler decides whether a new span is recorded, as the samplers of the
+// OpenTelemetry SDK do
+type Sampler interface {
+	// ShouldSample reports whether the span with the trace ID and parent is recorded.
+	// parent is invalid for root spans.
+	ShouldSample(traceID string, parent SpanContext) bool
+}
+
+// AlwaysSample records every span
+func AlwaysSample() Sampler {
+	return ratioSampler{bound: 1 << 63}
+}
+
+// NeverSample records no span
+func NeverSample() Sampler {
+	return ratioSampler{}
+}
+
+// TraceIDRatioBased records the fraction of traces, deciding on the trace ID
+// so that every service sampling by the same ratio records the same traces
+func TraceIDRatioBased(fraction float64) Sampler {
+	switch {
+	case fraction >= 1:
+		return AlwaysSample()
+	case fraction <= 0:
+		return NeverSample()
+	}
+	return ratioSampler{bound: uint64(fraction * (1 << 63))}
+}
+
+type ratioSampler struct {
+	bound uint64 // Traces whose lower 63 bits of the ID are below this are recorded
+}
+
+func (s ratioSampler) ShouldSample(traceID string, _ SpanContext) bool {
+	b, err := hex.DecodeString(traceID)
+	if err != nil || len(b) != 16 {
+		return false
+	}
+	return binary.BigEndian.Uint64(b[8:])>>1 < s.bound
+}
+
+// ParentBased follows the sampling decision of the parent span, and decides
+// with root for spans without a parent
+func ParentBased(root Sampler) Sampler {
+	return parentSampler{root: root}
+}
+
+type parentSampler struct {
+	root Sampler
+}
+
+func (s parentSampler) ShouldSample(traceID string, parent SpanContext) bool {
+	if parent.IsValid() {
+		return parent.Sampled
+	}
+	return s.root.ShouldSample(traceID, parent)
+}
@@ -0,0 +1,239 @@
+// Package tracing provides OpenTelemetry-compatible tracing of the event pipeline.
+// Spans are exported to an OTLP/HTTP endpoint (e.g. an OpenTelemetry Collector)
+// using the JSON encoding, and their context is propagated to receivers with
+// the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Config contains tracer settings
+type Config struct {
+	Endpoint       string            // OTLP/HTTP base URL, e.g. http://otel-collector:4318
+	ServiceName    string            // Reported as the service.name resource attribute
+	ServiceVersion string            // Reported as the service.version resource attribute
+	Resource       []Attribute       // Additional resource attributes, e.g. k8s.cluster.name
+	Headers        map[string]string // Sent with every export request (e.g. authentication)
+	Sampler        Sampler           // Defaults to ParentBased(AlwaysSample())
+	BatchSize      int               // Spans per export request (default 512)
+	FlushInterval  time.Duration     // Maximum delay before ended spans are exported (default 5s)
+
+	HTTPClient *http.Client // Defaults to a client with a 10s timeout
+}
+
+// Attribute is a key-value pair attached to a span
+type Attribute struct {
+	Key   string
+	Value interface{} // string, bool, int, int64 or float64
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer creates spans and exports them in the background.
+// A nil Tracer is valid and creates spans that are not recorded.
+type Tracer struct {
+	sampler  Sampler
+	exporter *exporter
+}
+
+// NewTracer creates a Tracer exporting to cfg.Endpoint
+func NewTracer(cfg Config) *Tracer {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "kube-watcher"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.Sampler == nil {
+		cfg.Sampler = ParentBased(AlwaysSample())
+	}
+	return &Tracer{
+		sampler:  cfg.Sampler,
+		exporter: newExporter(cfg),
+	}
+}
+
+// Start starts a span, continuing the trace of the span or remote span context
+// in ctx if any, and starting a new trace otherwise
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return t.StartAt(ctx, name, time.Now(), attrs...)
+}
+
+// StartAt starts a span that began at start, e.g. when the event was received
+func (t *Tracer) StartAt(ctx context.Context, name string, start time.Time, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	traceID := parent.TraceID
+	if !parent.IsValid() {
+		traceID = randomHex(16)
+	}
+	if !t.sampler.ShouldSample(traceID, parent) {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer:   t,
+		traceID:  traceID,
+		spanID:   randomHex(8),
+		parentID: parent.SpanID,
+		name:     name,
+		kind:     SpanKindInternal,
+		start:    start,
+		attrs:    attrs,
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Shutdown exports the remaining spans and stops the exporter
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.exporter.shutdown(ctx)
+}
+
+type spanKey struct{}
+
+// Start starts a child of the span in ctx. Without a recorded parent span the
+// returned span is nil, on which all methods are no-ops.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer:   parent.tracer,
+		traceID:  parent.traceID,
+		spanID:   randomHex(8),
+		parentID: parent.spanID,
+		name:     name,
+		kind:     SpanKindInternal,
+		start:    time.Now(),
+		attrs:    attrs,
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanKind describes the relationship of a span to its parent and children,
+// using the values of the OTLP protocol
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1 // An operation within kube-watcher
+	SpanKindClient   SpanKind = 3 // A request to a remote service, e.g. a notification
+)
+
+// Span is a timed operation of a trace
+type Span struct {
+	tracer   *Tracer
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	start    time.Time
+
+	mu     sync.Mutex
+	kind   SpanKind
+	end    time.Time
+	attrs  []Attribute
+	errMsg string
+	failed bool
+	ended  bool
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetKind sets the kind of the span (default SpanKindInternal)
+func (s *Span) SetKind(kind SpanKind) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kind = kind
+}
+
+// SetError marks the span as failed
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.errMsg = err.Error()
+}
+
+// End ends the span and queues it for export. Only the first call has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.exporter.add(s)
+}
+
+// TraceID returns the hex-encoded trace ID, or "" for a span that is not recorded
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.traceID
+}
+
+// SpanContext returns the context propagated to receivers of the span's
+// requests, which is invalid for a span that is not recorded
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceID: s.traceID, SpanID: s.spanID, Sampled: true}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
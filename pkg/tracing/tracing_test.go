@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector records the spans posted to /v1/traces
+type collector struct {
+	mu       sync.Mutex
+	spans    []otlpSpan
+	resource map[string]string
+	headers  http.Header
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+	c := &collector{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.headers = r.Header
+		for _, rs := range req.ResourceSpans {
+			c.resource = make(map[string]string)
+			for _, a := range rs.Resource.Attributes {
+				c.resource[a.Key] = *a.Value.StringValue
+			}
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func TestTracer_ExportsSpans(t *testing.T) {
+	c, server := newCollector(t)
+	tracer := NewTracer(Config{
+		Endpoint:       server.URL,
+		ServiceVersion: "v1.2.3",
+		Resource:       []Attribute{String("k8s.cluster.name", "prod"), String("host.name", "node-1")},
+		Headers:        map[string]string{"Authorization": "Bearer token"},
+	})
+
+	ctx, root := tracer.StartAt(context.Background(), "event", time.Now().Add(-time.Second), String("kind", "Pod"))
+	_, child := Start(ctx, "notify", String("destination", "slack"))
+	child.SetKind(SpanKindClient)
+	child.SetError(errors.New("status 500"))
+	child.End()
+	root.SetAttributes(Int("events", 1))
+	root.End()
+	root.End() // 2回目は無視される
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(c.spans))
+	}
+	notify, event := c.spans[0], c.spans[1]
+	if notify.TraceID != event.TraceID || notify.ParentSpanID != event.SpanID || event.ParentSpanID != "" {
+		t.Errorf("Unexpected span relationship: %+v / %+v", notify, event)
+	}
+	if len(event.TraceID) != 32 || len(event.SpanID) != 16 {
+		t.Errorf("Unexpected ID lengths: %s / %s", event.TraceID, event.SpanID)
+	}
+	if notify.Kind != int(SpanKindClient) || event.Kind != int(SpanKindInternal) {
+		t.Errorf("Unexpected span kinds %d / %d", notify.Kind, event.Kind)
+	}
+	if notify.Status == nil || notify.Status.Code != 2 || notify.Status.Message != "status 500" {
+		t.Errorf("Unexpected status: %+v", notify.Status)
+	}
+	if len(event.Attributes) != 2 || *event.Attributes[1].Value.IntValue != "1" {
+		t.Errorf("Unexpected attributes: %+v", event.Attributes)
+	}
+	if c.headers.Get("Authorization") != "Bearer token" {
+		t.Errorf("Unexpected headers %v", c.headers)
+	}
+	// 設定したリソース属性は検出した値より優先される
+	for key, want := range map[string]string{
+		"service.name":           "kube-watcher",
+		"service.version":        "v1.2.3",
+		"telemetry.sdk.language": "go",
+		"k8s.cluster.name":       "prod",
+		"host.name":              "node-1",
+	} {
+		if got := c.resource[key]; got != want {
+			t.Errorf("Resource attribute %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	_, server := newCollector(t)
+	tracer := NewTracer(Config{Endpoint: server.URL})
+	defer tracer.Shutdown(context.Background())
+
+	// 呼び出し元のtraceparentを引き継ぎ、送信先には自身のスパンを伝播する
+	incoming := http.Header{}
+	incoming.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, span := tracer.Start(Extract(context.Background(), incoming), "event")
+	if span.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.parentID != "00f067aa0ba902b7" {
+		t.Errorf("Expected the remote parent to be continued, got trace %s parent %s", span.TraceID(), span.parentID)
+	}
+	ctx, child := Start(ctx, "notify")
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+	if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + child.spanID + "-01"; outgoing.Get(TraceparentHeader) != want {
+		t.Errorf("traceparent = %q, want %q", outgoing.Get(TraceparentHeader), want)
+	}
+
+	// 記録されていない親はParentBasedのサンプラーで記録しない
+	incoming.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	if _, span := tracer.Start(Extract(context.Background(), incoming), "event"); span != nil {
+		t.Error("Expected the span of an unsampled parent not to be recorded")
+	}
+
+	// 不正なヘッダーは無視し、新しいトレースを開始する
+	for _, value := range []string{
+		"",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+	} {
+		incoming.Set(TraceparentHeader, value)
+		if sc := SpanContextFromContext(Extract(context.Background(), incoming)); sc.IsValid() {
+			t.Errorf("Expected %q to be ignored, got %+v", value, sc)
+		}
+	}
+
+	// スパンがなければヘッダーを設定しない
+	outgoing = http.Header{}
+	Inject(context.Background(), outgoing)
+	if len(outgoing) != 0 {
+		t.Errorf("Unexpected headers %v", outgoing)
+	}
+}
+
+func TestTraceIDRatioBased(t *testing.T) {
+	sampler := TraceIDRatioBased(0.25)
+	sampled := 0
+	for i := 0; i < 4000; i++ {
+		traceID := randomHex(16)
+		decision := sampler.ShouldSample(traceID, SpanContext{})
+		if decision != sampler.ShouldSample(traceID, SpanContext{}) {
+			t.Fatal("Expected the same decision for the same trace ID")
+		}
+		if decision {
+			sampled++
+		}
+	}
+	if sampled < 800 || sampled > 1200 {
+		t.Errorf("Sampled %d of 4000 traces, want about 1000", sampled)
+	}
+
+	// 下位64ビットで判定する
+	if !sampler.ShouldSample("ffffffffffffffff0000000000000000", SpanContext{}) {
+		t.Error("Expected a low trace ID to be sampled")
+	}
+	if sampler.ShouldSample("0000000000000000ffffffffffffffff", SpanContext{}) {
+		t.Error("Expected a high trace ID not to be sampled")
+	}
+	if !AlwaysSample().ShouldSample("0000000000000000ffffffffffffffff", SpanContext{}) || NeverSample().ShouldSample("00000000000000000000000000000001", SpanContext{}) {
+		t.Error("Unexpected decision of AlwaysSample or NeverSample")
+	}
+}
+
+func TestTracer_NotRecorded(t *testing.T) {
+	// nilのTracerやサンプリングされなかったトレースのスパンは記録されない
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "event")
+	if span != nil {
+		t.Fatal("Expected nil span from nil tracer")
+	}
+	_, child := Start(ctx, "filter")
+	child.SetAttributes(Bool("passed", true))
+	child.End()
+	if child.TraceID() != "" {
+		t.Error("Expected empty trace ID")
+	}
+
+	c, server := newCollector(t)
+	tracer = NewTracer(Config{Endpoint: server.URL, Sampler: ParentBased(TraceIDRatioBased(0))})
+	_, span = tracer.Start(context.Background(), "event")
+	span.End()
+	_ = tracer.Shutdown(context.Background())
+	if len(c.spans) != 0 {
+		t.Errorf("Expected no spans, got %d", len(c.spans))
+	}
+
+	// シャットダウン後に終了したスパンは破棄される（パニックしない）
+	tracer = NewTracer(Config{Endpoint: server.URL})
+	_, span = tracer.Start(context.Background(), "event")
+	_ = tracer.Shutdown(context.Background())
+	span.End()
+}
@@ -0,0 +1,55 @@
+// Package version provides build metadata of kube-watcher.
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build metadata, injected at build time with
+//
+//	-ldflags "-X github.com/kqns91/kube-watcher/pkg/version.Version=v1.2.3 ..."
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// Get returns the build metadata. Commit and date fall back to the VCS
+// information embedded by the Go toolchain when not injected.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// String returns a one-line description, e.g. "v1.2.3 (commit abc1234, built 2024-01-01T00:00:00Z)"
+func (i Info) String() string {
+	commit := i.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	return fmt.Sprintf("%s (commit %s, built %s)", i.Version, commit, i.Date)
+}
@@ -0,0 +1,24 @@
+package version
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	Version, Commit, Date = "v1.2.3", "0123456789abcdef", "2024-01-01T00:00:00Z"
+	t.Cleanup(func() { Version, Commit, Date = "dev", "", "" })
+
+	info := Get()
+	if info.Version != "v1.2.3" || info.Commit != "0123456789abcdef" {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+	// コミットハッシュは短縮して表示する
+	if got, want := info.String(), "v1.2.3 (commit 0123456, built 2024-01-01T00:00:00Z)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestGet_Defaults(t *testing.T) {
+	info := Get()
+	if info.Version != "dev" || info.Commit == "" || info.Date == "" {
+		t.Errorf("Expected non-empty defaults, got %+v", info)
+	}
+}
@@ -0,0 +1,64 @@
+package watcher
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// Statuses of LoadBalancer Services and Ingresses
+const (
+	StatusAddressPending  = "Pending"  // No external address has been assigned yet
+	StatusAddressAssigned = "Assigned" // The resource is reachable at its external addresses
+)
+
+// serviceAddresses returns the external IPs and hostnames of a LoadBalancer
+// Service, and nil for other types
+func serviceAddresses(svc *corev1.Service) []string {
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return nil
+	}
+	var addresses []string
+	for _, in := range svc.Status.LoadBalancer.Ingress {
+		addresses = appendAddress(addresses, in.IP, in.Hostname)
+	}
+	return addresses
+}
+
+// ingressAddresses returns the external IPs and hostnames of an Ingress
+func ingressAddresses(ing *networkingv1.Ingress) []string {
+	var addresses []string
+	for _, in := range ing.Status.LoadBalancer.Ingress {
+		addresses = appendAddress(addresses, in.IP, in.Hostname)
+	}
+	return addresses
+}
+
+// appendAddress appends the IP or hostname of a load balancer ingress point
+func appendAddress(addresses []string, ip, hostname string) []string {
+	if ip != "" {
+		return append(addresses, ip)
+	}
+	if hostname != "" {
+		return append(addresses, hostname)
+	}
+	return addresses
+}
+
+// addressStatus returns whether external addresses have been assigned
+func addressStatus(addresses []string) string {
+	if len(addresses) == 0 {
+		return StatusAddressPending
+	}
+	return StatusAddressAssigned
+}
+
+// ingressChanged reports whether an Ingress gained or lost an address, or its
+// rules were added or removed
+func ingressChanged(oldIng, newIng *networkingv1.Ingress) bool {
+	if !slices.Equal(ingressAddresses(oldIng), ingressAddresses(newIng)) {
+		return true
+	}
+	return len(oldIng.Spec.Rules) != len(newIng.Spec.Rules)
+}
@@ -0,0 +1,68 @@
+package watcher
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceAddresses(t *testing.T) {
+	w := &Watcher{}
+	pending := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", ResourceVersion: "1"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	assigned := pending.DeepCopy()
+	assigned.ResourceVersion = "2"
+	assigned.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}, {Hostname: "web.elb.example.com"}}
+
+	// アドレスの割り当てはServiceが実際に公開された瞬間として通知する
+	if !w.hasSignificantChange(pending, assigned) {
+		t.Fatal("Expected the address assignment to be significant")
+	}
+	event := w.convertToEvent(assigned, "Service", "UPDATED")
+	if got := strings.Join(event.Addresses, ","); got != "203.0.113.10,web.elb.example.com" || event.Status != StatusAddressAssigned {
+		t.Errorf("Addresses = %q, Status = %q", got, event.Status)
+	}
+	changes := DiffEvents(w.convertToEvent(pending, "Service", "UPDATED"), event)
+	if len(changes) != 2 || changes[0].Field != "status" || changes[1].Field != "addresses" || changes[1].Old != "" {
+		t.Errorf("Unexpected changes %+v", changes)
+	}
+
+	// LoadBalancer以外のServiceにはアドレスも状態もない
+	clusterIP := &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}}
+	if event := w.convertToEvent(clusterIP, "Service", "ADDED"); event.Addresses != nil || event.Status != "" {
+		t.Errorf("Expected no addresses for ClusterIP, got %v %q", event.Addresses, event.Status)
+	}
+}
+
+func TestIngressAddresses(t *testing.T) {
+	w := &Watcher{}
+	assigned := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", ResourceVersion: "1"},
+		Status: networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+			Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.20"}},
+		}},
+	}
+	lost := assigned.DeepCopy()
+	lost.ResourceVersion = "2"
+	lost.Status.LoadBalancer.Ingress = nil
+
+	if !w.hasSignificantChange(assigned, lost) {
+		t.Fatal("Expected the address removal to be significant")
+	}
+	if event := w.convertToEvent(lost, "Ingress", "UPDATED"); event.Status != StatusAddressPending || len(event.Addresses) != 0 {
+		t.Errorf("Expected a pending Ingress, got %q %v", event.Status, event.Addresses)
+	}
+
+	// アドレスとルール以外の変更は通知しない
+	annotated := assigned.DeepCopy()
+	annotated.ResourceVersion = "3"
+	annotated.Annotations = map[string]string{"note": "x"}
+	if w.hasSignificantChange(assigned, annotated) {
+		t.Error("Expected an annotation change to be ignored")
+	}
+}
@@ -0,0 +1,49 @@
+package watcher
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterScopedEvents(t *testing.T) {
+	w := &Watcher{}
+
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1", ResourceVersion: "1"},
+		Status:     corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+	}
+	released := pv.DeepCopy()
+	released.ResourceVersion = "2"
+	released.Status.Phase = corev1.VolumeReleased
+	if !w.hasSignificantChange(pv, released) {
+		t.Fatal("Expected the release of the volume to be significant")
+	}
+	event := w.convertToEvent(released, "PersistentVolume", "UPDATED")
+	if event.Status != "Released" || event.Name != "pv-1" || event.Namespace != "" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+
+	// フェーズが変わらない更新は通知しない
+	relabeled := released.DeepCopy()
+	relabeled.ResourceVersion = "3"
+	relabeled.Labels = map[string]string{"team": "platform"}
+	if w.hasSignificantChange(released, relabeled) {
+		t.Error("Expected a label update to be ignored")
+	}
+
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-123", ResourceVersion: "1"},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+	terminating := ns.DeepCopy()
+	terminating.ResourceVersion = "2"
+	terminating.Status.Phase = corev1.NamespaceTerminating
+	if !w.hasSignificantChange(ns, terminating) {
+		t.Fatal("Expected the termination of the namespace to be significant")
+	}
+	if event := w.convertToEvent(terminating, "Namespace", "UPDATED"); event.Status != "Terminating" || event.Name != "pr-123" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,67 @@
+package watcher
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// CronJobInfo represents the schedule and the runs of a CronJob
+type CronJobInfo struct {
+	Schedule           string
+	TimeZone           string // IANA time zone of the schedule; empty for the controller's local time
+	Suspended          bool
+	LastScheduleTime   time.Time // Zero until the first run
+	LastSuccessfulTime time.Time
+	Active             []ActiveJob
+}
+
+// ActiveJob is a running Job of a CronJob
+type ActiveJob struct {
+	Name          string
+	ScheduledTime time.Time // Zero when it cannot be derived from the name
+}
+
+// cronJobInfo extracts the schedule and the runs of a CronJob
+func cronJobInfo(cj *batchv1.CronJob) *CronJobInfo {
+	info := &CronJobInfo{
+		Schedule:  cj.Spec.Schedule,
+		Suspended: cj.Spec.Suspend != nil && *cj.Spec.Suspend,
+	}
+	if cj.Spec.TimeZone != nil {
+		info.TimeZone = *cj.Spec.TimeZone
+	}
+	if t := cj.Status.LastScheduleTime; t != nil {
+		info.LastScheduleTime = t.Time
+	}
+	if t := cj.Status.LastSuccessfulTime; t != nil {
+		info.LastSuccessfulTime = t.Time
+	}
+	for _, ref := range cj.Status.Active {
+		info.Active = append(info.Active, ActiveJob{Name: ref.Name, ScheduledTime: jobScheduledTime(cj.Name, ref.Name)})
+	}
+	return info
+}
+
+// jobScheduledTime derives the scheduled time of a Job from its name, which the
+// CronJob controller suffixes with the scheduled time in Unix minutes
+func jobScheduledTime(cronJob, job string) time.Time {
+	suffix, ok := strings.CutPrefix(job, cronJob+"-")
+	if !ok {
+		return time.Time{}
+	}
+	minutes, err := strconv.ParseInt(suffix, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(minutes*60, 0)
+}
+
+// cronJobChanged reports whether the schedule of the CronJob changed or it was
+// suspended or resumed. Runs are reported by the Jobs themselves.
+func cronJobChanged(oldCronJob, newCronJob *batchv1.CronJob) bool {
+	oldInfo, newInfo := cronJobInfo(oldCronJob), cronJobInfo(newCronJob)
+	return oldInfo.Schedule != newInfo.Schedule || oldInfo.TimeZone != newInfo.TimeZone || oldInfo.Suspended != newInfo.Suspended
+}
@@ -0,0 +1,45 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCronJobEvent(t *testing.T) {
+	suspend, tz := false, "Asia/Tokyo"
+	last := metav1.NewTime(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "prod"},
+		Spec:       batchv1.CronJobSpec{Schedule: "0 * * * *", TimeZone: &tz, Suspend: &suspend},
+		Status: batchv1.CronJobStatus{
+			LastScheduleTime: &last,
+			// ジョブ名の接尾辞はスケジュール時刻（Unix分）
+			Active: []corev1.ObjectReference{{Name: "report-28401120"}, {Name: "manual-run"}},
+		},
+	}
+
+	event := (&Watcher{}).convertToEvent(cj, "CronJob", "UPDATED")
+	info := event.CronJob
+	if info == nil || info.Schedule != "0 * * * *" || info.TimeZone != "Asia/Tokyo" || info.Suspended || !info.LastScheduleTime.Equal(last.Time) {
+		t.Fatalf("Unexpected cron job info %+v", info)
+	}
+	if len(info.Active) != 2 || !info.Active[0].ScheduledTime.Equal(time.Unix(28401120*60, 0)) || !info.Active[1].ScheduledTime.IsZero() {
+		t.Errorf("Unexpected active jobs %+v", info.Active)
+	}
+
+	// 実行のたびの状態の変化では通知せず、一時停止で通知する
+	updated := cj.DeepCopy()
+	updated.Status.Active = nil
+	if cronJobChanged(cj, updated) {
+		t.Error("Expected a change of the runs to be ignored")
+	}
+	suspended := true
+	updated.Spec.Suspend = &suspended
+	if !cronJobChanged(cj, updated) {
+		t.Error("Expected suspending the CronJob to be detected")
+	}
+}
@@ -0,0 +1,98 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldChange represents a change of a single field between two versions of a resource
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// DiffEvents returns the field changes between the previous and current state of a resource
+func DiffEvents(prev, curr *Event) []FieldChange {
+	if prev == nil || curr == nil {
+		return nil
+	}
+
+	var changes []FieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("status", prev.Status, curr.Status)
+	add("reason", prev.Reason, curr.Reason)
+	add("serviceType", prev.ServiceType, curr.ServiceType)
+	add("addresses", strings.Join(prev.Addresses, ", "), strings.Join(curr.Addresses, ", "))
+
+	if prev.Replicas != nil && curr.Replicas != nil {
+		add("replicas", fmt.Sprint(prev.Replicas.Desired), fmt.Sprint(curr.Replicas.Desired))
+		add("readyReplicas", fmt.Sprint(prev.Replicas.Ready), fmt.Sprint(curr.Replicas.Ready))
+	}
+
+	if prev.Scaling != nil && curr.Scaling != nil {
+		add("desiredReplicas", fmt.Sprint(prev.Scaling.DesiredReplicas), fmt.Sprint(curr.Scaling.DesiredReplicas))
+		add("currentReplicas", fmt.Sprint(prev.Scaling.CurrentReplicas), fmt.Sprint(curr.Scaling.CurrentReplicas))
+		add("minReplicas", fmt.Sprint(prev.Scaling.MinReplicas), fmt.Sprint(curr.Scaling.MinReplicas))
+		add("maxReplicas", fmt.Sprint(prev.Scaling.MaxReplicas), fmt.Sprint(curr.Scaling.MaxReplicas))
+	}
+
+	if prev.Job != nil && curr.Job != nil {
+		add("failed", fmt.Sprint(prev.Job.Failed), fmt.Sprint(curr.Job.Failed))
+		add("succeeded", fmt.Sprint(prev.Job.Succeeded), fmt.Sprint(curr.Job.Succeeded))
+	}
+
+	// Compare container images by container name
+	oldImages := make(map[string]string, len(prev.Containers))
+	for _, c := range prev.Containers {
+		oldImages[c.Name] = c.Image
+	}
+	newImages := make(map[string]bool, len(curr.Containers))
+	for _, c := range curr.Containers {
+		newImages[c.Name] = true
+		add("image["+c.Name+"]", oldImages[c.Name], c.Image)
+	}
+	for _, c := range prev.Containers {
+		if !newImages[c.Name] {
+			add("image["+c.Name+"]", c.Image, "")
+		}
+	}
+	changes = append(changes, resourceChanges(prev.Containers, curr.Containers)...)
+
+	return changes
+}
+
+// MergeChanges combines the changes of two consecutive updates into a single list.
+// The oldest value of each field is kept and fields that end up unchanged are dropped.
+func MergeChanges(earlier, later []FieldChange) []FieldChange {
+	if len(earlier) == 0 {
+		return later
+	}
+
+	merged := make([]FieldChange, 0, len(earlier)+len(later))
+	index := make(map[string]int, len(earlier))
+	for _, c := range earlier {
+		index[c.Field] = len(merged)
+		merged = append(merged, c)
+	}
+	for _, c := range later {
+		if i, exists := index[c.Field]; exists {
+			merged[i].New = c.New
+			continue
+		}
+		merged = append(merged, c)
+	}
+
+	result := merged[:0]
+	for _, c := range merged {
+		if c.Old != c.New {
+			result = append(result, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package watcher
+
+import (
+	"testing"
+)
+
+func TestDiffEvents(t *testing.T) {
+	prev := &Event{
+		Status:     "Running",
+		Replicas:   &ReplicaInfo{Desired: 3, Ready: 3},
+		Containers: []ContainerInfo{{Name: "app", Image: "app:v1"}, {Name: "sidecar", Image: "proxy:v1"}},
+	}
+	curr := &Event{
+		Status:     "Running",
+		Replicas:   &ReplicaInfo{Desired: 5, Ready: 3},
+		Containers: []ContainerInfo{{Name: "app", Image: "app:v2"}},
+	}
+
+	changes := DiffEvents(prev, curr)
+
+	expected := []FieldChange{
+		{Field: "replicas", Old: "3", New: "5"},
+		{Field: "image[app]", Old: "app:v1", New: "app:v2"},
+		{Field: "image[sidecar]", Old: "proxy:v1", New: ""},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d: %v", len(expected), len(changes), changes)
+	}
+	for i, c := range expected {
+		if changes[i] != c {
+			t.Errorf("Change %d = %+v, want %+v", i, changes[i], c)
+		}
+	}
+}
+
+func TestDiffEvents_Nil(t *testing.T) {
+	if changes := DiffEvents(nil, &Event{}); changes != nil {
+		t.Errorf("Expected no changes for nil previous event, got %v", changes)
+	}
+}
+
+func TestMergeChanges(t *testing.T) {
+	earlier := []FieldChange{
+		{Field: "replicas", Old: "3", New: "5"},
+		{Field: "status", Old: "Pending", New: "Running"},
+	}
+	later := []FieldChange{
+		{Field: "replicas", Old: "5", New: "3"},
+		{Field: "image[app]", Old: "app:v1", New: "app:v2"},
+	}
+
+	merged := MergeChanges(earlier, later)
+
+	// 元の値に戻ったフィールドは除外される
+	expected := []FieldChange{
+		{Field: "status", Old: "Pending", New: "Running"},
+		{Field: "image[app]", Old: "app:v1", New: "app:v2"},
+	}
+	if len(merged) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d: %v", len(expected), len(merged), merged)
+	}
+	for i, c := range expected {
+		if merged[i] != c {
+			t.Errorf("Change %d = %+v, want %+v", i, merged[i], c)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package watcher
+
+import (
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ScalingInfo represents the state of a HorizontalPodAutoscaler: why it
+// scaled its target to the desired replicas
+type ScalingInfo struct {
+	Target          string // Kind/name of the scaled resource
+	MinReplicas     int32
+	MaxReplicas     int32
+	CurrentReplicas int32
+	DesiredReplicas int32
+	Metrics         []MetricInfo
+	Conditions      []ScalingCondition
+}
+
+// MetricInfo is a metric of a HorizontalPodAutoscaler with its current and target values
+type MetricInfo struct {
+	Name    string // e.g. "cpu", or "requests_per_second on Ingress/web"
+	Current string // e.g. "85%" or "120"; "<unknown>" until the metric is available
+	Target  string
+}
+
+// ScalingCondition is a condition of a HorizontalPodAutoscaler
+type ScalingCondition struct {
+	Type    string
+	Status  string
+	Reason  string
+	Message string
+}
+
+// unknownMetric is shown for metrics without a current value, as in kubectl
+const unknownMetric = "<unknown>"
+
+// hpaScaling extracts the scaling details of a HorizontalPodAutoscaler. The
+// current metrics are matched to the spec by source, since their order is not
+// guaranteed.
+func hpaScaling(hpa *autoscalingv2.HorizontalPodAutoscaler) *ScalingInfo {
+	scaling := &ScalingInfo{
+		Target:          hpa.Spec.ScaleTargetRef.Kind + "/" + hpa.Spec.ScaleTargetRef.Name,
+		MinReplicas:     1,
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+	}
+	if hpa.Spec.MinReplicas != nil {
+		scaling.MinReplicas = *hpa.Spec.MinReplicas
+	}
+
+	current := make(map[string]string, len(hpa.Status.CurrentMetrics))
+	for _, m := range hpa.Status.CurrentMetrics {
+		if name, value, ok := metricStatus(m); ok {
+			current[name] = value
+		}
+	}
+	for _, m := range hpa.Spec.Metrics {
+		name, target, ok := metricSpec(m)
+		if !ok {
+			continue
+		}
+		value, ok := current[name]
+		if !ok {
+			value = unknownMetric
+		}
+		scaling.Metrics = append(scaling.Metrics, MetricInfo{Name: name, Current: value, Target: target})
+	}
+
+	for _, c := range hpa.Status.Conditions {
+		scaling.Conditions = append(scaling.Conditions, ScalingCondition{
+			Type:    string(c.Type),
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+		})
+	}
+	return scaling
+}
+
+// hpaReason returns the condition that explains what the autoscaler did: a
+// limited or inactive autoscaler first, then the result of the last rescale
+func hpaReason(hpa *autoscalingv2.HorizontalPodAutoscaler) (reason, message string) {
+	var able *autoscalingv2.HorizontalPodAutoscalerCondition
+	for i, c := range hpa.Status.Conditions {
+		switch {
+		case c.Type == autoscalingv2.ScalingActive && c.Status == corev1.ConditionFalse,
+			c.Type == autoscalingv2.ScalingLimited && c.Status == corev1.ConditionTrue:
+			return c.Reason, c.Message
+		case c.Type == autoscalingv2.AbleToScale:
+			able = &hpa.Status.Conditions[i]
+		}
+	}
+	if able != nil {
+		return able.Reason, able.Message
+	}
+	return "", ""
+}
+
+// hpaChanged reports whether the autoscaler scaled, was reconfigured or
+// changed conditions. Metric values alone change constantly and are ignored.
+func hpaChanged(oldHPA, newHPA *autoscalingv2.HorizontalPodAutoscaler) bool {
+	oldScaling, newScaling := hpaScaling(oldHPA), hpaScaling(newHPA)
+	if oldScaling.DesiredReplicas != newScaling.DesiredReplicas || oldScaling.CurrentReplicas != newScaling.CurrentReplicas ||
+		oldScaling.MinReplicas != newScaling.MinReplicas || oldScaling.MaxReplicas != newScaling.MaxReplicas {
+		return true
+	}
+	if len(oldScaling.Conditions) != len(newScaling.Conditions) {
+		return true
+	}
+	for i, c := range oldScaling.Conditions {
+		n := newScaling.Conditions[i]
+		if c.Type != n.Type || c.Status != n.Status || c.Reason != n.Reason {
+			return true
+		}
+	}
+	return false
+}
+
+// metricSpec returns the name and target value of a metric of the spec
+func metricSpec(m autoscalingv2.MetricSpec) (name, target string, ok bool) {
+	switch {
+	case m.Resource != nil:
+		return string(m.Resource.Name), metricTarget(m.Resource.Target), true
+	case m.ContainerResource != nil:
+		return containerMetricName(m.ContainerResource.Name, m.ContainerResource.Container), metricTarget(m.ContainerResource.Target), true
+	case m.Pods != nil:
+		return m.Pods.Metric.Name, metricTarget(m.Pods.Target), true
+	case m.Object != nil:
+		return objectMetricName(m.Object.Metric.Name, m.Object.DescribedObject), metricTarget(m.Object.Target), true
+	case m.External != nil:
+		return m.External.Metric.Name, metricTarget(m.External.Target), true
+	}
+	return "", "", false
+}
+
+// metricStatus returns the name and current value of a metric of the status
+func metricStatus(m autoscalingv2.MetricStatus) (name, current string, ok bool) {
+	switch {
+	case m.Resource != nil:
+		return string(m.Resource.Name), metricValue(m.Resource.Current), true
+	case m.ContainerResource != nil:
+		return containerMetricName(m.ContainerResource.Name, m.ContainerResource.Container), metricValue(m.ContainerResource.Current), true
+	case m.Pods != nil:
+		return m.Pods.Metric.Name, metricValue(m.Pods.Current), true
+	case m.Object != nil:
+		return objectMetricName(m.Object.Metric.Name, m.Object.DescribedObject), metricValue(m.Object.Current), true
+	case m.External != nil:
+		return m.External.Metric.Name, metricValue(m.External.Current), true
+	}
+	return "", "", false
+}
+
+func containerMetricName(name corev1.ResourceName, container string) string {
+	return fmt.Sprintf("%s (container %s)", name, container)
+}
+
+func objectMetricName(name string, object autoscalingv2.CrossVersionObjectReference) string {
+	return fmt.Sprintf("%s on %s/%s", name, object.Kind, object.Name)
+}
+
+func metricTarget(t autoscalingv2.MetricTarget) string {
+	return formatMetric(t.AverageUtilization, t.AverageValue, t.Value)
+}
+
+func metricValue(v autoscalingv2.MetricValueStatus) string {
+	return formatMetric(v.AverageUtilization, v.AverageValue, v.Value)
+}
+
+// formatMetric renders a utilization as a percentage of the requests, or a
+// quantity per Pod or in total
+func formatMetric(utilization *int32, average, value *resource.Quantity) string {
+	switch {
+	case utilization != nil:
+		return fmt.Sprintf("%d%%", *utilization)
+	case average != nil:
+		return average.String()
+	case value != nil:
+		return value.String()
+	}
+	return unknownMetric
+}
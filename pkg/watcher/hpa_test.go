@@ -0,0 +1,107 @@
+package watcher
+
+import (
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func newTestHPA() *autoscalingv2.HorizontalPodAutoscaler {
+	minReplicas, targetCPU, currentCPU := int32(2), int32(70), int32(85)
+	rps := resource.MustParse("120")
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			Metrics: []autoscalingv2.MetricSpec{
+				{Type: autoscalingv2.ResourceMetricSourceType, Resource: &autoscalingv2.ResourceMetricSource{
+					Name: corev1.ResourceCPU, Target: autoscalingv2.MetricTarget{AverageUtilization: &targetCPU}}},
+				{Type: autoscalingv2.PodsMetricSourceType, Pods: &autoscalingv2.PodsMetricSource{
+					Metric: autoscalingv2.MetricIdentifier{Name: "requests_per_second"}, Target: autoscalingv2.MetricTarget{AverageValue: resource.NewQuantity(100, resource.DecimalSI)}}},
+				{Type: autoscalingv2.ExternalMetricSourceType, External: &autoscalingv2.ExternalMetricSource{
+					Metric: autoscalingv2.MetricIdentifier{Name: "queue_depth"}, Target: autoscalingv2.MetricTarget{Value: resource.NewQuantity(30, resource.DecimalSI)}}},
+			},
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 3,
+			DesiredReplicas: 5,
+			// 現在値はspecと異なる順序で報告されることがある
+			CurrentMetrics: []autoscalingv2.MetricStatus{
+				{Type: autoscalingv2.PodsMetricSourceType, Pods: &autoscalingv2.PodsMetricStatus{
+					Metric: autoscalingv2.MetricIdentifier{Name: "requests_per_second"}, Current: autoscalingv2.MetricValueStatus{AverageValue: &rps}}},
+				{Type: autoscalingv2.ResourceMetricSourceType, Resource: &autoscalingv2.ResourceMetricStatus{
+					Name: corev1.ResourceCPU, Current: autoscalingv2.MetricValueStatus{AverageUtilization: &currentCPU}}},
+			},
+			Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+				{Type: autoscalingv2.AbleToScale, Status: corev1.ConditionTrue, Reason: "SucceededRescale", Message: "the HPA controller was able to update the target scale to 5"},
+				{Type: autoscalingv2.ScalingActive, Status: corev1.ConditionTrue, Reason: "ValidMetricFound"},
+				{Type: autoscalingv2.ScalingLimited, Status: corev1.ConditionFalse, Reason: "DesiredWithinRange"},
+			},
+		},
+	}
+}
+
+func TestHPAScaling(t *testing.T) {
+	event := (&Watcher{}).convertToEvent(newTestHPA(), "HorizontalPodAutoscaler", "UPDATED")
+	s := event.Scaling
+	if s == nil || s.Target != "Deployment/web" || s.MinReplicas != 2 || s.MaxReplicas != 10 || s.CurrentReplicas != 3 || s.DesiredReplicas != 5 {
+		t.Fatalf("Unexpected scaling %+v", s)
+	}
+
+	want := []MetricInfo{
+		{Name: "cpu", Current: "85%", Target: "70%"},
+		{Name: "requests_per_second", Current: "120", Target: "100"},
+		// まだ値のないメトリクスは<unknown>
+		{Name: "queue_depth", Current: "<unknown>", Target: "30"},
+	}
+	if len(s.Metrics) != len(want) {
+		t.Fatalf("Expected %d metrics, got %+v", len(want), s.Metrics)
+	}
+	for i := range want {
+		if s.Metrics[i] != want[i] {
+			t.Errorf("Metric %d = %+v, want %+v", i, s.Metrics[i], want[i])
+		}
+	}
+	if len(s.Conditions) != 3 {
+		t.Errorf("Expected 3 conditions, got %+v", s.Conditions)
+	}
+
+	if event.Reason != "SucceededRescale" {
+		t.Errorf("Expected the reason of the rescale, got %q", event.Reason)
+	}
+}
+
+func TestHPAReason_Limited(t *testing.T) {
+	hpa := newTestHPA()
+	// 上限に達している場合はその理由を優先する
+	hpa.Status.Conditions[2] = autoscalingv2.HorizontalPodAutoscalerCondition{
+		Type: autoscalingv2.ScalingLimited, Status: corev1.ConditionTrue, Reason: "TooManyReplicas", Message: "the desired replica count is more than the maximum replica count"}
+	if reason, message := hpaReason(hpa); reason != "TooManyReplicas" || message == "" {
+		t.Errorf("hpaReason() = %q, %q", reason, message)
+	}
+}
+
+func TestHPAChanged(t *testing.T) {
+	oldHPA, newHPA := newTestHPA(), newTestHPA()
+	// メトリクスの値の変化だけでは通知しない
+	cpu := int32(90)
+	newHPA.Status.CurrentMetrics[1].Resource.Current.AverageUtilization = &cpu
+	if hpaChanged(oldHPA, newHPA) {
+		t.Error("Expected a change of metric values alone to be ignored")
+	}
+
+	newHPA.Status.DesiredReplicas = 6
+	if !hpaChanged(oldHPA, newHPA) {
+		t.Error("Expected a change of the desired replicas to be detected")
+	}
+
+	newHPA = newTestHPA()
+	newHPA.Status.Conditions[1].Status = corev1.ConditionFalse
+	newHPA.Status.Conditions[1].Reason = "FailedGetResourceMetric"
+	if !hpaChanged(oldHPA, newHPA) {
+		t.Error("Expected a change of conditions to be detected")
+	}
+}
@@ -0,0 +1,123 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// JobInfo represents the progress of a Job
+type JobInfo struct {
+	Completions  int32 // Successful Pods required
+	BackoffLimit int32 // Retries before the Job is marked failed
+	Active       int32
+	Succeeded    int32
+	Failed       int32
+	FailedPods   []FailedPod // Attached by the pipeline to failed Jobs
+}
+
+// FailedPod is a failed Pod of a Job with why it failed
+type FailedPod struct {
+	Name   string
+	Reason string // e.g. "Error (exit code 1)", "OOMKilled (exit code 137)" or "DeadlineExceeded"
+}
+
+// jobInfo extracts the progress of a Job
+func jobInfo(job *batchv1.Job) *JobInfo {
+	info := &JobInfo{
+		Completions:  1,
+		BackoffLimit: 6,
+		Active:       job.Status.Active,
+		Succeeded:    job.Status.Succeeded,
+		Failed:       job.Status.Failed,
+	}
+	if job.Spec.Completions != nil {
+		info.Completions = *job.Spec.Completions
+	}
+	if job.Spec.BackoffLimit != nil {
+		info.BackoffLimit = *job.Spec.BackoffLimit
+	}
+	return info
+}
+
+// jobCondition returns the terminal or suspended condition of a Job, if any
+func jobCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for i, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobFailed, batchv1.JobComplete, batchv1.JobSuspended:
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// jobStatus returns the state of a Job: the type of its terminal or suspended
+// condition, or Running
+func jobStatus(job *batchv1.Job) string {
+	if c := jobCondition(job); c != nil {
+		return string(c.Type)
+	}
+	return "Running"
+}
+
+// jobChanged reports whether the Job finished, was suspended or resumed, or a
+// Pod of it failed
+func jobChanged(oldJob, newJob *batchv1.Job) bool {
+	return jobStatus(oldJob) != jobStatus(newJob) || oldJob.Status.Failed != newJob.Status.Failed
+}
+
+// FailedPods returns the failed Pods of a Job, most recent first, at most
+// limit of them. Requires the list permission on pods.
+func (w *Watcher) FailedPods(ctx context.Context, namespace, job string, limit int) ([]FailedPod, error) {
+	list, err := w.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{"job-name": job}.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of job %s/%s: %w", namespace, job, err)
+	}
+	return failedPods(list.Items, limit), nil
+}
+
+// failedPods returns the failed Pods among the Pods of a Job, most recent first
+func failedPods(pods []corev1.Pod, limit int) []FailedPod {
+	var failed []corev1.Pod
+	for _, p := range pods {
+		if p.Status.Phase == corev1.PodFailed {
+			failed = append(failed, p)
+		}
+	}
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[j].CreationTimestamp.Before(&failed[i].CreationTimestamp)
+	})
+	if limit > 0 && len(failed) > limit {
+		failed = failed[:limit]
+	}
+
+	result := make([]FailedPod, 0, len(failed))
+	for _, p := range failed {
+		result = append(result, FailedPod{Name: p.Name, Reason: podTermination(&p)})
+	}
+	return result
+}
+
+// podTermination describes why a failed Pod terminated: the first container
+// that exited with an error, or the reason of the Pod, e.g. when it was evicted
+func podTermination(pod *corev1.Pod) string {
+	for _, s := range pod.Status.ContainerStatuses {
+		if t := s.State.Terminated; t != nil && t.ExitCode != 0 {
+			return fmt.Sprintf("%s (exit code %d)", t.Reason, t.ExitCode)
+		}
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	return string(corev1.PodFailed)
+}
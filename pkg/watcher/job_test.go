@@ -0,0 +1,66 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestJobEvent(t *testing.T) {
+	backoffLimit := int32(3)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "prod"},
+		Spec:       batchv1.JobSpec{BackoffLimit: &backoffLimit},
+		Status: batchv1.JobStatus{
+			Failed: 4,
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailureTarget, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+			},
+		},
+	}
+
+	event := (&Watcher{}).convertToEvent(job, "Job", "UPDATED")
+	if event.Status != "Failed" || event.Reason != "BackoffLimitExceeded" || event.Message != "Job has reached the specified backoff limit" {
+		t.Errorf("Unexpected status %q, reason %q or message %q", event.Status, event.Reason, event.Message)
+	}
+	if j := event.Job; j == nil || j.BackoffLimit != 3 || j.Failed != 4 || j.Completions != 1 {
+		t.Errorf("Unexpected job info %+v", event.Job)
+	}
+
+	// 失敗したPodの数が変わらず、終了もしていなければ通知しない
+	running := job.DeepCopy()
+	running.Status.Conditions = nil
+	if !jobChanged(running, job) {
+		t.Error("Expected the failure of the Job to be detected")
+	}
+	if jobChanged(running, running.DeepCopy()) {
+		t.Error("Expected no change to be detected")
+	}
+}
+
+func TestFailedPods(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, age time.Duration, phase corev1.PodPhase) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	exited := pod("migrate-b", time.Minute, corev1.PodFailed)
+	exited.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+	}}
+	deadline := pod("migrate-a", 2*time.Minute, corev1.PodFailed)
+	deadline.Status.Reason = "DeadlineExceeded"
+
+	// 新しい順に並び、成功したPodは含まない
+	got := failedPods([]corev1.Pod{deadline, pod("migrate-c", 0, corev1.PodSucceeded), exited, pod("migrate-old", time.Hour, corev1.PodFailed)}, 2)
+	want := []FailedPod{{Name: "migrate-b", Reason: "Error (exit code 1)"}, {Name: "migrate-a", Reason: "DeadlineExceeded"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("failedPods() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+package watcher
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// lastAppliedAnnotation holds the previous configuration applied with kubectl,
+// which duplicates the manifest and may contain Secret data
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// redacted replaces the values of Secret data in manifests
+const redacted = "<redacted>"
+
+// Manifest returns the cached object as YAML, sanitized for notifications:
+// managed fields and the last applied configuration are removed, and the
+// values of Secrets are redacted. It fails unless the kind is watched and the
+// object is in the cache, e.g. after it was deleted.
+func (w *Watcher) Manifest(kind, namespace, name string) (string, error) {
+	w.mu.Lock()
+	state := w.informers[ScopeKey(kind, namespace)]
+	w.mu.Unlock()
+	if state == nil {
+		if namespace != "" {
+			return "", fmt.Errorf("%s is not watched in %s", kind, namespace)
+		}
+		return "", fmt.Errorf("%s is not watched", kind)
+	}
+
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+	obj, exists, err := state.informer.GetStore().GetByKey(key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("%s %s not found in the cache", kind, key)
+	}
+	object, ok := obj.(runtime.Object)
+	if !ok {
+		return "", fmt.Errorf("unexpected object type %T", obj)
+	}
+	return manifestYAML(object)
+}
+
+// manifestYAML renders a sanitized copy of the object as YAML
+func manifestYAML(obj runtime.Object) (string, error) {
+	content, err := manifestContent(obj)
+	if err != nil {
+		return "", err
+	}
+	return encodeManifest(content)
+}
+
+// manifestContent returns the sanitized unstructured content of the object
+func manifestContent(obj runtime.Object) (map[string]interface{}, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	// Objects from informers carry no type information
+	if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil && len(gvks) > 0 {
+		content["apiVersion"], content["kind"] = gvks[0].GroupVersion().String(), gvks[0].Kind
+	}
+	sanitizeManifest(content)
+	return content, nil
+}
+
+// encodeManifest renders unstructured content as YAML
+func encodeManifest(content map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(orderedManifest(content)); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sanitizeManifest removes noise and secrets from the unstructured content of an object
+func sanitizeManifest(content map[string]interface{}) {
+	if meta, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(meta, "managedFields")
+		if annotations, ok := meta["annotations"].(map[string]interface{}); ok {
+			delete(annotations, lastAppliedAnnotation)
+			if len(annotations) == 0 {
+				delete(meta, "annotations")
+			}
+		}
+	}
+	if status, ok := content["status"].(map[string]interface{}); ok && len(status) == 0 {
+		delete(content, "status")
+	}
+	if content["kind"] == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			if data, ok := content[field].(map[string]interface{}); ok {
+				for key := range data {
+					data[key] = redacted
+				}
+			}
+		}
+	}
+}
+
+// manifestOrder is the order of the top-level fields, as in kubectl's output
+var manifestOrder = []string{"apiVersion", "kind", "metadata", "spec", "data", "stringData", "type", "status"}
+
+// orderedManifest returns the content as a YAML mapping with the top-level
+// fields in the usual order; nested fields are sorted by key
+func orderedManifest(content map[string]interface{}) *yaml.Node {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string) {
+		value := &yaml.Node{}
+		if err := value.Encode(content[key]); err != nil {
+			return
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+		delete(content, key)
+	}
+	for _, key := range manifestOrder {
+		if _, ok := content[key]; ok {
+			add(key)
+		}
+	}
+	// Fields of other kinds, e.g. rules of a Role
+	var rest yaml.Node
+	if err := rest.Encode(content); err == nil {
+		root.Content = append(root.Content, rest.Content...)
+	}
+	return root
+}
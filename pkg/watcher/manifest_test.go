@@ -0,0 +1,67 @@
+package watcher
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestManifestYAML(t *testing.T) {
+	replicas := int32(3)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			Annotations: map[string]string{
+				lastAppliedAnnotation: `{"kind":"Deployment"}`,
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply}},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+
+	got, err := manifestYAML(deployment)
+	if err != nil {
+		t.Fatalf("manifestYAML() error = %v", err)
+	}
+	// apiVersionとkindを補い、kubectlと同じ順序で出力する
+	if !strings.HasPrefix(got, "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n") {
+		t.Errorf("Unexpected manifest header:\n%s", got)
+	}
+	if !strings.Contains(got, "  replicas: 3\n") {
+		t.Errorf("Expected the spec in the manifest:\n%s", got)
+	}
+	for _, removed := range []string{"managedFields", lastAppliedAnnotation, "annotations"} {
+		if strings.Contains(got, removed) {
+			t.Errorf("Expected %s to be removed:\n%s", removed, got)
+		}
+	}
+}
+
+func TestManifestYAML_RedactsSecrets(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+		StringData: map[string]string{"user": "admin"},
+		Type:       corev1.SecretTypeOpaque,
+	}
+
+	got, err := manifestYAML(secret)
+	if err != nil {
+		t.Fatalf("manifestYAML() error = %v", err)
+	}
+	// キーは残し、値だけを伏せる
+	for _, want := range []string{"password: <redacted>", "user: <redacted>", "type: Opaque"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the manifest:\n%s", want, got)
+		}
+	}
+	for _, secretValue := range []string{"hunter2", "aHVudGVyMg==", "admin"} {
+		if strings.Contains(got, secretValue) {
+			t.Errorf("Expected %q to be redacted:\n%s", secretValue, got)
+		}
+	}
+}
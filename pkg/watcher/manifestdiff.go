@@ -0,0 +1,257 @@
+package watcher
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// maxDiffCells bounds the table used to match the changed lines of two
+// manifests; larger changes are shown as the removal of all old lines and the
+// addition of all new ones
+const maxDiffCells = 1000000
+
+// diffTruncated marks the end of a truncated diff
+const diffTruncated = "... truncated"
+
+// manifestDiff returns the unified diff attached to update events of the kind,
+// or an empty string when manifestDiff is disabled for the kind or nothing
+// significant changed
+func (w *Watcher) manifestDiff(kind string, oldObj, newObj interface{}) string {
+	if w.config == nil || !w.config.ManifestDiff.Enabled || !slices.Contains(w.config.ManifestDiff.Kinds, kind) {
+		return ""
+	}
+	oldObject, ok := oldObj.(runtime.Object)
+	if !ok {
+		return ""
+	}
+	newObject, ok := newObj.(runtime.Object)
+	if !ok {
+		return ""
+	}
+
+	cfg := w.config.ManifestDiff
+	diff, err := diffManifests(oldObject, newObject, cfg.ContextLines)
+	if err != nil {
+		slog.Debug("Failed to diff manifests", "kind", kind, "error", err)
+		return ""
+	}
+	return truncateDiff(diff, cfg.MaxBytes)
+}
+
+// diffManifests returns the unified diff of the significant sections of the
+// sanitized manifests
+func diffManifests(oldObj, newObj runtime.Object, contextLines int) (string, error) {
+	oldYAML, err := significantManifest(oldObj)
+	if err != nil {
+		return "", err
+	}
+	newYAML, err := significantManifest(newObj)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(oldYAML, newYAML, contextLines), nil
+}
+
+// significantManifest renders the sections of the sanitized manifest that are
+// changed by users: the labels and annotations, and all top-level fields but
+// the status, such as the spec of workloads and the data of ConfigMaps
+func significantManifest(obj runtime.Object) (string, error) {
+	content, err := manifestContent(obj)
+	if err != nil {
+		return "", err
+	}
+	significant := make(map[string]interface{})
+	if meta, ok := content["metadata"].(map[string]interface{}); ok {
+		kept := make(map[string]interface{})
+		for _, field := range []string{"labels", "annotations"} {
+			if value, ok := meta[field]; ok {
+				kept[field] = value
+			}
+		}
+		if len(kept) > 0 {
+			significant["metadata"] = kept
+		}
+	}
+	for key, value := range content {
+		switch key {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		significant[key] = value
+	}
+	return encodeManifest(significant)
+}
+
+// diffLine is a line of a diff: unchanged (' '), removed ('-') or added ('+')
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the hunks of the unified diff between two texts, with
+// contextLines unchanged lines around the changes, or an empty string when
+// they are equal
+func unifiedDiff(oldText, newText string, contextLines int) string {
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+
+	var changed []int
+	for i, l := range lines {
+		if l.op != ' ' {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for start := 0; start < len(changed); {
+		// Changes separated by at most twice the context form one hunk
+		end := start
+		for end+1 < len(changed) && changed[end+1]-changed[end] <= 2*contextLines+1 {
+			end++
+		}
+		from := max(changed[start]-contextLines, 0)
+		to := min(changed[end]+contextLines+1, len(lines))
+		writeHunk(&b, lines, from, to)
+		start = end + 1
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writeHunk writes lines[from:to] as a hunk with its header
+func writeHunk(b *strings.Builder, lines []diffLine, from, to int) {
+	oldStart, newStart := 1, 1
+	for _, l := range lines[:from] {
+		if l.op != '+' {
+			oldStart++
+		}
+		if l.op != '-' {
+			newStart++
+		}
+	}
+	var oldCount, newCount int
+	for _, l := range lines[from:to] {
+		if l.op != '+' {
+			oldCount++
+		}
+		if l.op != '-' {
+			newCount++
+		}
+	}
+	// Empty ranges are numbered by the line before them
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, l := range lines[from:to] {
+		b.WriteByte(l.op)
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+}
+
+// splitLines splits a text into lines without the trailing newline
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines matches the lines of a and b with a longest common subsequence.
+// The common prefix and suffix are matched first, so that the table only
+// covers the changed region.
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		lines = append(lines, diffLine{' ', l})
+	}
+	lines = append(lines, diffChanged(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, l := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', l})
+	}
+	return lines
+}
+
+// diffChanged matches the lines of the changed region
+func diffChanged(a, b []string) []diffLine {
+	var lines []diffLine
+	if len(a)*len(b) > maxDiffCells {
+		for _, l := range a {
+			lines = append(lines, diffLine{'-', l})
+		}
+		for _, l := range b {
+			lines = append(lines, diffLine{'+', l})
+		}
+		return lines
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
+
+// truncateDiff keeps the first maxBytes bytes of the diff, up to a line boundary
+func truncateDiff(diff string, maxBytes int) string {
+	if len(diff) <= maxBytes {
+		return diff
+	}
+	diff = diff[:max(maxBytes-len(diffTruncated)-1, 0)]
+	if i := strings.LastIndexByte(diff, '\n'); i > 0 {
+		diff = diff[:i]
+	}
+	return strings.ToValidUTF8(diff, "") + "\n" + diffTruncated
+}
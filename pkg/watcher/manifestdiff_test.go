@@ -0,0 +1,94 @@
+package watcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kqns91/kube-watcher/pkg/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\n"
+
+	// 離れた変更は別のハンクになる
+	want := "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n@@ -9,1 +9,2 @@\n i\n+j"
+	if got := unifiedDiff(oldText, newText, 1); got != want {
+		t.Errorf("unifiedDiff() = %q, want %q", got, want)
+	}
+	// 近い変更は1つのハンクにまとめる
+	want = "@@ -1,9 +1,10 @@\n a\n-b\n+B\n c\n d\n e\n f\n g\n h\n i\n+j"
+	if got := unifiedDiff(oldText, newText, 4); got != want {
+		t.Errorf("unifiedDiff() = %q, want %q", got, want)
+	}
+	if got := unifiedDiff(oldText, oldText, 3); got != "" {
+		t.Errorf("Expected no diff for equal texts, got %q", got)
+	}
+}
+
+func TestWatcher_ManifestDiff(t *testing.T) {
+	w := &Watcher{config: &config.Config{ManifestDiff: config.ManifestDiffConfig{
+		Enabled: true, Kinds: []string{"ConfigMap", "Secret"}, MaxBytes: 2000, ContextLines: 1,
+	}}}
+	oldCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string]string{"LOG_LEVEL": "info", "TIMEOUT": "30s"},
+	}
+	newCM := oldCM.DeepCopy()
+	newCM.ResourceVersion = "2"
+	newCM.Data["LOG_LEVEL"] = "debug"
+
+	want := "@@ -1,3 +1,3 @@\n data:\n-  LOG_LEVEL: info\n+  LOG_LEVEL: debug\n   TIMEOUT: 30s"
+	if got := w.manifestDiff("ConfigMap", oldCM, newCM); got != want {
+		t.Errorf("manifestDiff() = %q, want %q", got, want)
+	}
+	if got := w.manifestDiff("Deployment", &appsv1.Deployment{}, &appsv1.Deployment{}); got != "" {
+		t.Errorf("Expected no diff for a kind not configured, got %q", got)
+	}
+
+	// Secretの値は伏せたまま、キーの変更だけを示す
+	oldSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	newSecret := oldSecret.DeepCopy()
+	newSecret.Data["user"] = []byte("admin")
+	got := w.manifestDiff("Secret", oldSecret, newSecret)
+	if !strings.Contains(got, "+  user: <redacted>") || strings.Contains(got, "admin") {
+		t.Errorf("Unexpected secret diff %q", got)
+	}
+}
+
+func TestManifestDiff_IgnoresStatus(t *testing.T) {
+	replicas := int32(3)
+	oldDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", ResourceVersion: "1", Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	newDeployment := oldDeployment.DeepCopy()
+	newDeployment.ResourceVersion = "2"
+	newDeployment.Status.ReadyReplicas = 3
+
+	// ステータスとメタデータの変化は差分に含めない
+	got, err := diffManifests(oldDeployment, newDeployment, 3)
+	if err != nil {
+		t.Fatalf("diffManifests() error = %v", err)
+	}
+	if got != "" {
+		t.Errorf("Expected no diff for a status change, got %q", got)
+	}
+}
+
+func TestTruncateDiff(t *testing.T) {
+	diff := "@@ -1,4 +1,4 @@\n-a: 1\n+a: 2\n b: 3\n c: 4\n d: 5"
+	if got := truncateDiff(diff, 100); got != diff {
+		t.Errorf("truncateDiff() = %q, want the diff unchanged", got)
+	}
+	want := "@@ -1,4 +1,4 @@\n-a: 1\n" + diffTruncated
+	if got := truncateDiff(diff, 36); got != want {
+		t.Errorf("truncateDiff() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,24 @@
+package watcher
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Statuses of Nodes
+const (
+	StatusCordoned    = "Cordoned"    // New Pods are not scheduled to the Node
+	StatusSchedulable = "Schedulable" // The Node accepts new Pods
+)
+
+// nodeStatus returns whether the Node is cordoned
+func nodeStatus(node *corev1.Node) string {
+	if node.Spec.Unschedulable {
+		return StatusCordoned
+	}
+	return StatusSchedulable
+}
+
+// nodeChanged reports whether the Node was cordoned or uncordoned
+func nodeChanged(oldNode, newNode *corev1.Node) bool {
+	return oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable
+}
@@ -0,0 +1,37 @@
+package watcher
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeEvent(t *testing.T) {
+	w := &Watcher{}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", ResourceVersion: "1"}}
+	cordoned := node.DeepCopy()
+	cordoned.ResourceVersion = "2"
+	cordoned.Spec.Unschedulable = true
+
+	if !w.hasSignificantChange(node, cordoned) {
+		t.Fatal("Expected the cordon to be significant")
+	}
+	event := w.convertToEvent(cordoned, "Node", "UPDATED")
+	if event.Status != StatusCordoned || event.Name != "node-1" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+
+	// cordon以外の変更（ステータスの更新など）は通知しない
+	heartbeat := cordoned.DeepCopy()
+	heartbeat.ResourceVersion = "3"
+	heartbeat.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	if w.hasSignificantChange(cordoned, heartbeat) {
+		t.Error("Expected a status update to be ignored")
+	}
+
+	pod := &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node-1"}}
+	if event := w.convertToEvent(pod, "Pod", "DELETED"); event.NodeName != "node-1" {
+		t.Errorf("NodeName = %q", event.NodeName)
+	}
+}
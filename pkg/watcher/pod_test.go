@@ -0,0 +1,36 @@
+package watcher
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodFailureReason(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "sidecar", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: ReasonCrashLoopBackOff}}},
+			{Name: "app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		}},
+	}
+	if got := podFailureReason(pod); got != ReasonCrashLoopBackOff {
+		t.Errorf("podFailureReason() = %q, want %q", got, ReasonCrashLoopBackOff)
+	}
+
+	// OOMKilledで再起動を繰り返す場合はOOMKilledを理由とする
+	pod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{Reason: ReasonOOMKilled}
+	if got := podFailureReason(pod); got != ReasonOOMKilled {
+		t.Errorf("podFailureReason() = %q, want %q", got, ReasonOOMKilled)
+	}
+
+	event := (&Watcher{}).convertToEvent(pod, "Pod", "UPDATED")
+	if event.Reason != ReasonOOMKilled || event.Containers[0].Reason != "" || event.Containers[1].Reason != ReasonOOMKilled {
+		t.Errorf("Unexpected reasons in event %+v", event)
+	}
+
+	pod.Status.ContainerStatuses = nil
+	if got := podFailureReason(pod); got != "" {
+		t.Errorf("Expected no failure for a healthy Pod, got %q", got)
+	}
+}
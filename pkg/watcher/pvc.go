@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// VolumeInfo represents the state of a PersistentVolumeClaim
+type VolumeInfo struct {
+	StorageClass string
+	Requested    string // Requested size, e.g. "10Gi"
+	Capacity     string // Provisioned size; empty until bound
+	VolumeName   string
+	ResizeError  string // Why the last resize failed; empty if it did not
+}
+
+// pvcInfo extracts the state of a PersistentVolumeClaim
+func pvcInfo(pvc *corev1.PersistentVolumeClaim) *VolumeInfo {
+	info := &VolumeInfo{
+		VolumeName:  pvc.Spec.VolumeName,
+		ResizeError: pvcResizeError(pvc),
+	}
+	if pvc.Spec.StorageClassName != nil {
+		info.StorageClass = *pvc.Spec.StorageClassName
+	}
+	if q, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		info.Requested = q.String()
+	}
+	if q, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		info.Capacity = q.String()
+	}
+	return info
+}
+
+// pvcResizeError describes why the resize of a PersistentVolumeClaim failed:
+// the message of the resize error condition, or the infeasible resize status
+func pvcResizeError(pvc *corev1.PersistentVolumeClaim) string {
+	for _, c := range pvc.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		if c.Type == corev1.PersistentVolumeClaimControllerResizeError || c.Type == corev1.PersistentVolumeClaimNodeResizeError {
+			if c.Message != "" {
+				return c.Message
+			}
+			return string(c.Type)
+		}
+	}
+	switch status := pvc.Status.AllocatedResourceStatuses[corev1.ResourceStorage]; status {
+	case corev1.PersistentVolumeClaimControllerResizeInfeasible, corev1.PersistentVolumeClaimNodeResizeInfeasible:
+		return string(status)
+	}
+	return ""
+}
+
+// pvcChanged reports whether the claim was bound or lost, was resized, or a
+// resize was requested or failed
+func pvcChanged(oldPVC, newPVC *corev1.PersistentVolumeClaim) bool {
+	if oldPVC.Status.Phase != newPVC.Status.Phase {
+		return true
+	}
+	oldInfo, newInfo := pvcInfo(oldPVC), pvcInfo(newPVC)
+	return oldInfo.Requested != newInfo.Requested || oldInfo.Capacity != newInfo.Capacity || oldInfo.ResizeError != newInfo.ResizeError
+}
@@ -0,0 +1,49 @@
+package watcher
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPVCEvent(t *testing.T) {
+	class := "fast"
+	pvc := &corev1.PersistentVolumeClaim{
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &class,
+			Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("20Gi"),
+			}},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase:    corev1.ClaimBound,
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+		},
+	}
+
+	event := (&Watcher{}).convertToEvent(pvc, "PersistentVolumeClaim", "UPDATED")
+	if v := event.Volume; v == nil || v.StorageClass != "fast" || v.Requested != "20Gi" || v.Capacity != "10Gi" || v.ResizeError != "" {
+		t.Fatalf("Unexpected volume info %+v", event.Volume)
+	}
+
+	// 拡張の失敗は条件のメッセージか、実行不可能なステータスで示される
+	failed := pvc.DeepCopy()
+	failed.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+		Type: corev1.PersistentVolumeClaimControllerResizeError, Status: corev1.ConditionTrue, Message: "quota exceeded",
+	}}
+	if got := pvcResizeError(failed); got != "quota exceeded" {
+		t.Errorf("pvcResizeError() = %q", got)
+	}
+	if !pvcChanged(pvc, failed) {
+		t.Error("Expected the resize failure to be detected")
+	}
+
+	infeasible := pvc.DeepCopy()
+	infeasible.Status.AllocatedResourceStatuses = map[corev1.ResourceName]corev1.ClaimResourceStatus{
+		corev1.ResourceStorage: corev1.PersistentVolumeClaimNodeResizeInfeasible,
+	}
+	if got := pvcResizeError(infeasible); got != "NodeResizeInfeasible" {
+		t.Errorf("pvcResizeError() = %q", got)
+	}
+}
@@ -0,0 +1,65 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// NotifiedReason is the reason of the Kubernetes Events recorded for notifications
+const NotifiedReason = "KubeWatcherNotified"
+
+// EventRecorder records a Kubernetes Event on the involved object when a
+// notification about it was sent, so that kubectl describe shows where the
+// change was reported. Events are written asynchronously, and repeated ones
+// are aggregated and rate limited per object by client-go.
+type EventRecorder struct {
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+}
+
+// NewEventRecorder creates an EventRecorder using the in-cluster configuration or the kubeconfig
+func NewEventRecorder() (*EventRecorder, error) {
+	k8sConfig, err := RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+	}
+	return newEventRecorder(clientset), nil
+}
+
+func newEventRecorder(client kubernetes.Interface) *EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	// The sink creates each Event in the namespace of its involved object
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	return &EventRecorder{
+		broadcaster: broadcaster,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "kube-watcher"}),
+	}
+}
+
+// Notified records that the event was sent to the destinations. Events without
+// an object reference, such as those injected by embedders, are skipped.
+func (r *EventRecorder) Notified(event *Event, destinations []string) {
+	if r == nil || event.Ref == nil || len(destinations) == 0 {
+		return
+	}
+	r.recorder.Eventf(event.Ref, corev1.EventTypeNormal, NotifiedReason,
+		"%s event notified to %s", event.EventType, strings.Join(destinations, ", "))
+}
+
+// Stop stops recording. Events not yet written are discarded.
+func (r *EventRecorder) Stop() {
+	if r == nil {
+		return
+	}
+	r.broadcaster.Shutdown()
+}
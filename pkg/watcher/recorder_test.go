@@ -0,0 +1,73 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEventRecorder_Notified(t *testing.T) {
+	client := fake.NewClientset()
+	r := newEventRecorder(client)
+	defer r.Stop()
+
+	pod := objectRef(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod", UID: "uid-1"}})
+	r.Notified(&Event{Kind: "Pod", Namespace: "prod", Name: "api", EventType: "UPDATED", Ref: pod}, []string{"slack", "webhook"})
+	// オブジェクトのないイベントや通知先がない場合は記録しない
+	r.Notified(&Event{Kind: "Pod", Namespace: "prod", Name: "injected", EventType: "UPDATED"}, []string{"slack"})
+	r.Notified(&Event{Kind: "Pod", Namespace: "prod", Name: "api", EventType: "DELETED", Ref: pod}, nil)
+
+	// イベントは非同期に書き込まれる
+	var events *corev1.EventList
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var err error
+		events, err = client.CoreV1().Events("prod").List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(events.Items) > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(events.Items) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events.Items))
+	}
+	e := events.Items[0]
+	if e.Reason != NotifiedReason || e.Type != corev1.EventTypeNormal {
+		t.Errorf("Unexpected reason %q or type %q", e.Reason, e.Type)
+	}
+	if e.InvolvedObject.Kind != "Pod" || e.InvolvedObject.Name != "api" || e.InvolvedObject.UID != "uid-1" {
+		t.Errorf("Unexpected involved object %+v", e.InvolvedObject)
+	}
+	if e.Message != "UPDATED event notified to slack, webhook" {
+		t.Errorf("Unexpected message %q", e.Message)
+	}
+	if e.Source.Component != "kube-watcher" {
+		t.Errorf("Unexpected source %q", e.Source.Component)
+	}
+}
+
+func TestEventRecorder_Nil(t *testing.T) {
+	// 無効な場合のnilのレコーダーは何もしない
+	var r *EventRecorder
+	r.Notified(&Event{Ref: &corev1.ObjectReference{}}, []string{"slack"})
+	r.Stop()
+}
+
+func TestObjectRef(t *testing.T) {
+	ref := objectRef(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod", UID: "uid-1", ResourceVersion: "42"}})
+	if ref == nil {
+		t.Fatal("Expected a reference")
+	}
+	// 型情報のないオブジェクトでもスキームから種類が補完される
+	if ref.Kind != "Pod" || ref.APIVersion != "v1" || ref.Name != "api" || ref.Namespace != "prod" || ref.UID != "uid-1" || ref.ResourceVersion != "42" {
+		t.Errorf("Unexpected reference %+v", ref)
+	}
+}
@@ -0,0 +1,96 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// RelatedEvent is a Warning Kubernetes Event of the resource of an event,
+// aggregated by reason as in kubectl describe
+type RelatedEvent struct {
+	Reason   string
+	Message  string // Message of the latest occurrence
+	Count    int32  // Occurrences of the reason
+	LastSeen time.Time
+}
+
+// RelatedEvents returns the Warning Events recorded on the object since the
+// given time, aggregated by reason and most recent first, at most limit of
+// them. Requires the list permission on events.
+func (w *Watcher) RelatedEvents(ctx context.Context, ref *corev1.ObjectReference, since time.Time, limit int) ([]RelatedEvent, error) {
+	selector := fields.Set{
+		"involvedObject.kind": ref.Kind,
+		"involvedObject.name": ref.Name,
+		"type":                corev1.EventTypeWarning,
+	}
+	list, err := w.clientset.CoreV1().Events(ref.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events of %s %s/%s: %w", ref.Kind, ref.Namespace, ref.Name, err)
+	}
+	return aggregateEvents(list.Items, ref, since, limit), nil
+}
+
+// aggregateEvents aggregates the Warning Events of the object by reason. The
+// object is matched again, since fake clients ignore field selectors, and by
+// UID so that the events of a deleted object of the same name are skipped.
+func aggregateEvents(events []corev1.Event, ref *corev1.ObjectReference, since time.Time, limit int) []RelatedEvent {
+	byReason := make(map[string]*RelatedEvent)
+	for _, e := range events {
+		involved := e.InvolvedObject
+		if e.Type != corev1.EventTypeWarning || involved.Kind != ref.Kind || involved.Name != ref.Name ||
+			(ref.UID != "" && involved.UID != "" && involved.UID != ref.UID) {
+			continue
+		}
+		lastSeen, count := eventOccurrences(e)
+		if lastSeen.Before(since) {
+			continue
+		}
+		r, ok := byReason[e.Reason]
+		if !ok {
+			r = &RelatedEvent{Reason: e.Reason}
+			byReason[e.Reason] = r
+		}
+		r.Count += count
+		if !lastSeen.Before(r.LastSeen) {
+			r.LastSeen = lastSeen
+			r.Message = e.Message
+		}
+	}
+
+	related := make([]RelatedEvent, 0, len(byReason))
+	for _, r := range byReason {
+		related = append(related, *r)
+	}
+	sort.Slice(related, func(i, j int) bool {
+		if !related[i].LastSeen.Equal(related[j].LastSeen) {
+			return related[i].LastSeen.After(related[j].LastSeen)
+		}
+		return related[i].Reason < related[j].Reason
+	})
+	if limit > 0 && len(related) > limit {
+		related = related[:limit]
+	}
+	return related
+}
+
+// eventOccurrences returns when an Event was last seen and how many times,
+// for both the legacy fields and the series of events.k8s.io Events
+func eventOccurrences(e corev1.Event) (time.Time, int32) {
+	if e.Series != nil {
+		return e.Series.LastObservedTime.Time, max(e.Series.Count, 1)
+	}
+	lastSeen := e.LastTimestamp.Time
+	if lastSeen.IsZero() {
+		lastSeen = e.EventTime.Time
+	}
+	if lastSeen.IsZero() {
+		lastSeen = e.CreationTimestamp.Time
+	}
+	return lastSeen, max(e.Count, 1)
+}
@@ -0,0 +1,55 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAggregateEvents(t *testing.T) {
+	now := time.Now()
+	ref := &corev1.ObjectReference{Kind: "Pod", Namespace: "prod", Name: "web", UID: "uid-2"}
+	warning := func(reason, message string, count int32, ago time.Duration) corev1.Event {
+		return corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web", UID: "uid-2"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        message,
+			Count:          count,
+			LastTimestamp:  metav1.NewTime(now.Add(-ago)),
+		}
+	}
+	oldPod := warning("BackOff", "from the deleted Pod", 9, time.Second)
+	oldPod.InvolvedObject.UID = "uid-1"
+	normal := warning("Pulled", "image pulled", 1, time.Second)
+	normal.Type = corev1.EventTypeNormal
+
+	related := aggregateEvents([]corev1.Event{
+		warning("BackOff", "Back-off restarting failed container", 3, 2*time.Minute),
+		warning("BackOff", "Back-off restarting failed container app", 2, time.Minute),
+		warning("FailedMount", "secret not found", 1, 5*time.Minute),
+		warning("Unhealthy", "Liveness probe failed", 1, 2*time.Hour),
+		oldPod,
+		normal,
+	}, ref, now.Add(-time.Hour), 5)
+
+	// 理由ごとに集約され、新しい順に並ぶ。古いもの、別のPodのもの、Normalは含まない
+	if len(related) != 2 {
+		t.Fatalf("Expected 2 reasons, got %+v", related)
+	}
+	if related[0].Reason != "BackOff" || related[0].Count != 5 || related[0].Message != "Back-off restarting failed container app" {
+		t.Errorf("Unexpected first reason %+v", related[0])
+	}
+	if related[1].Reason != "FailedMount" {
+		t.Errorf("Unexpected second reason %+v", related[1])
+	}
+
+	if related := aggregateEvents([]corev1.Event{
+		warning("BackOff", "a", 1, time.Minute),
+		warning("FailedMount", "b", 1, 2*time.Minute),
+	}, ref, time.Time{}, 1); len(related) != 1 || related[0].Reason != "BackOff" {
+		t.Errorf("Expected only the latest reason, got %+v", related)
+	}
+}
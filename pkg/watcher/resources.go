@@ -0,0 +1,82 @@
+package watcher
+
+import (
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// templateContainers returns the containers of a Pod template with their
+// images and resources
+func templateContainers(spec corev1.PodSpec) []ContainerInfo {
+	containers := make([]ContainerInfo, 0, len(spec.Containers))
+	for _, container := range spec.Containers {
+		containers = append(containers, ContainerInfo{
+			Name:      container.Name,
+			Image:     container.Image,
+			Resources: containerResources(container.Resources),
+		})
+	}
+	return containers
+}
+
+// containerResources returns the requests and limits of a container keyed
+// like "requests.cpu", or nil when it has none
+func containerResources(r corev1.ResourceRequirements) map[string]string {
+	if len(r.Requests) == 0 && len(r.Limits) == 0 {
+		return nil
+	}
+	resources := make(map[string]string, len(r.Requests)+len(r.Limits))
+	for name, q := range r.Requests {
+		resources["requests."+string(name)] = q.String()
+	}
+	for name, q := range r.Limits {
+		resources["limits."+string(name)] = q.String()
+	}
+	return resources
+}
+
+// resourcesChanged reports whether the requests or limits of a container of
+// the Pod templates differ
+func resourcesChanged(oldSpec, newSpec corev1.PodSpec) bool {
+	oldResources := make(map[string]map[string]string, len(oldSpec.Containers))
+	for _, c := range oldSpec.Containers {
+		oldResources[c.Name] = containerResources(c.Resources)
+	}
+	for _, c := range newSpec.Containers {
+		if old, ok := oldResources[c.Name]; ok && !maps.Equal(old, containerResources(c.Resources)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceChanges returns the changes of the requests and limits of the
+// containers present in both versions, e.g. "limits.memory[app]"
+func resourceChanges(prev, curr []ContainerInfo) []FieldChange {
+	oldResources := make(map[string]map[string]string, len(prev))
+	for _, c := range prev {
+		oldResources[c.Name] = c.Resources
+	}
+	var changes []FieldChange
+	for _, c := range curr {
+		old, ok := oldResources[c.Name]
+		if !ok {
+			continue
+		}
+		keys := slices.Sorted(maps.Keys(c.Resources))
+		for key := range old {
+			if _, exists := c.Resources[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if old[key] != c.Resources[key] {
+				changes = append(changes, FieldChange{Field: key + "[" + c.Name + "]", Old: old[key], New: c.Resources[key]})
+			}
+		}
+	}
+	return changes
+}
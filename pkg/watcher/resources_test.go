@@ -0,0 +1,62 @@
+package watcher
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestStatefulSet(cpu, memory string) *appsv1.StatefulSet {
+	replicas := int32(3)
+	limits := corev1.ResourceList{}
+	if memory != "" {
+		limits[corev1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod", ResourceVersion: cpu + memory},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "postgres",
+				Image: "postgres:16",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+					Limits:   limits,
+				},
+			}}}},
+		},
+	}
+}
+
+func TestResourceChanges(t *testing.T) {
+	w := &Watcher{}
+	oldSet := newTestStatefulSet("500m", "1Gi")
+	newSet := newTestStatefulSet("1", "")
+
+	// 黙って行われたリソースの変更も通知する
+	if !w.hasSignificantChange(oldSet, newSet) {
+		t.Fatal("Expected the resource change to be significant")
+	}
+	unchanged := newTestStatefulSet("500m", "1Gi")
+	unchanged.ResourceVersion = "2"
+	if w.hasSignificantChange(oldSet, unchanged) {
+		t.Error("Expected equal resources not to be significant")
+	}
+
+	changes := DiffEvents(w.convertToEvent(oldSet, "StatefulSet", "UPDATED"), w.convertToEvent(newSet, "StatefulSet", "UPDATED"))
+	expected := []FieldChange{
+		{Field: "limits.memory[postgres]", Old: "1Gi", New: ""},
+		{Field: "requests.cpu[postgres]", Old: "500m", New: "1"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %v", len(expected), changes)
+	}
+	for i, c := range expected {
+		if changes[i] != c {
+			t.Errorf("Change %d = %+v, want %+v", i, changes[i], c)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package watcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// ReasonRollback is the reason of Deployment updates that restore the Pod
+// template of an earlier revision, as done by kubectl rollout undo
+const ReasonRollback = "RollbackPerformed"
+
+// revisionAnnotation is set by the Deployment controller to the revision of the current template
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// maxTrackedRevisions is the number of revisions remembered per Deployment
+const maxTrackedRevisions = 10
+
+// RollbackInfo describes the rollback of a Deployment
+type RollbackInfo struct {
+	FromRevision int64
+	ToRevision   int64    // The revision whose template was restored
+	FromImages   []string // Container images before the rollback, like "app=app:v3"
+	ToImages     []string
+}
+
+// templateRevision is the images of the Pod template of a revision
+type templateRevision struct {
+	revision int64
+	images   string
+}
+
+// rollbackTracker remembers the images of the revisions of each Deployment,
+// so that a template update restoring an earlier revision is recognized as a rollback
+type rollbackTracker struct {
+	mu        sync.Mutex
+	revisions map[string][]templateRevision // Namespace/name -> observed revisions, oldest first
+}
+
+// deploymentRevision returns the revision of the Deployment, or 0 when it has none
+func deploymentRevision(d *appsv1.Deployment) int64 {
+	revision, err := strconv.ParseInt(d.Annotations[revisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+// templateImages returns the container images of the Deployment's Pod template
+func templateImages(d *appsv1.Deployment) []string {
+	images := make([]string, 0, len(d.Spec.Template.Spec.Containers))
+	for _, c := range d.Spec.Template.Spec.Containers {
+		images = append(images, c.Name+"="+c.Image)
+	}
+	return images
+}
+
+// observe records the images of the Deployment's current revision. The
+// revision is only recorded once the controller has observed the template,
+// since until then the annotation still names the previous revision.
+func (t *rollbackTracker) observe(d *appsv1.Deployment) {
+	revision := deploymentRevision(d)
+	if revision == 0 || d.Status.ObservedGeneration < d.Generation {
+		return
+	}
+	key := d.Namespace + "/" + d.Name
+	images := strings.Join(templateImages(d), ",")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.revisions == nil {
+		t.revisions = make(map[string][]templateRevision)
+	}
+	revisions := t.revisions[key]
+	for _, r := range revisions {
+		if r.revision == revision {
+			return
+		}
+	}
+	revisions = append(revisions, templateRevision{revision: revision, images: images})
+	if len(revisions) > maxTrackedRevisions {
+		revisions = revisions[len(revisions)-maxTrackedRevisions:]
+	}
+	t.revisions[key] = revisions
+}
+
+// forget drops the revisions of a deleted Deployment
+func (t *rollbackTracker) forget(d *appsv1.Deployment) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.revisions, d.Namespace+"/"+d.Name)
+}
+
+// detect returns the rollback performed by the update, or nil: either the
+// revision decreased, or the template now has the images of an earlier revision
+func (t *rollbackTracker) detect(oldDeployment, newDeployment *appsv1.Deployment) *RollbackInfo {
+	oldRevision, newRevision := deploymentRevision(oldDeployment), deploymentRevision(newDeployment)
+	oldImages, newImages := templateImages(oldDeployment), templateImages(newDeployment)
+	rollback := &RollbackInfo{FromRevision: oldRevision, FromImages: oldImages, ToImages: newImages}
+	if oldRevision > 0 && newRevision > 0 && newRevision < oldRevision {
+		rollback.ToRevision = newRevision
+		return rollback
+	}
+
+	current := strings.Join(newImages, ",")
+	if current == strings.Join(oldImages, ",") {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	revisions := t.revisions[newDeployment.Namespace+"/"+newDeployment.Name]
+	for i := len(revisions) - 1; i >= 0; i-- {
+		r := revisions[i]
+		if r.revision < oldRevision && r.images == current {
+			rollback.ToRevision = r.revision
+			return rollback
+		}
+	}
+	return nil
+}
+
+// rollbackMessage describes a rollback, e.g. "Rolled back from revision 5 to
+// revision 3 (app=app:v3 → app=app:v2)"
+func rollbackMessage(r *RollbackInfo) string {
+	return fmt.Sprintf("Rolled back from revision %d to revision %d (%s → %s)",
+		r.FromRevision, r.ToRevision, strings.Join(r.FromImages, ", "), strings.Join(r.ToImages, ", "))
+}
@@ -0,0 +1,68 @@
+package watcher
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newTestDeployment returns a Deployment of the revision running the image,
+// as observed by the controller
+func newTestDeployment(revision, image string, generation int64) *appsv1.Deployment {
+	replicas := int32(3)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "prod", Generation: generation, ResourceVersion: revision + image,
+			Annotations: map[string]string{revisionAnnotation: revision},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}}},
+		},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: generation},
+	}
+}
+
+func TestRollbackDetection(t *testing.T) {
+	var events []*Event
+	w := &Watcher{handler: func(e *Event) { events = append(events, e) }}
+	handler := w.createEventHandler("Deployment", &informerState{}).(cache.ResourceEventHandlerFuncs)
+
+	v1 := newTestDeployment("1", "app:v1", 1)
+	handler.AddFunc(v1)
+	v2 := newTestDeployment("2", "app:v2", 2)
+	handler.UpdateFunc(v1, v2)
+	if events[len(events)-1].Rollback != nil {
+		t.Fatal("Expected a rollout not to be a rollback")
+	}
+
+	// rollout undoはテンプレートを戻し、リビジョンはコントローラが後から更新する
+	undone := newTestDeployment("2", "app:v1", 3)
+	undone.Status.ObservedGeneration = 2
+	handler.UpdateFunc(v2, undone)
+	e := events[len(events)-1]
+	if e.Rollback == nil || e.Rollback.FromRevision != 2 || e.Rollback.ToRevision != 1 || e.Reason != ReasonRollback {
+		t.Fatalf("Expected a rollback from revision 2 to 1, got %+v", e)
+	}
+	if want := "Rolled back from revision 2 to revision 1 (app=app:v2 → app=app:v1)"; e.Message != want {
+		t.Errorf("Message = %q, want %q", e.Message, want)
+	}
+
+	// コントローラによるリビジョンの更新は再度ロールバックとして通知しない
+	count := len(events)
+	observed := newTestDeployment("3", "app:v1", 3)
+	handler.UpdateFunc(undone, observed)
+	if len(events) != count {
+		t.Errorf("Expected the revision bump not to be notified, got %+v", events[len(events)-1])
+	}
+
+	// リビジョンが減った場合もロールバックとみなす
+	decreased := newTestDeployment("2", "app:v1", 3)
+	handler.UpdateFunc(observed, decreased)
+	if e := events[len(events)-1]; e.Rollback == nil || e.Rollback.FromRevision != 3 || e.Rollback.ToRevision != 2 {
+		t.Errorf("Expected a rollback from revision 3 to 2, got %+v", e.Rollback)
+	}
+}
@@ -0,0 +1,141 @@
+package watcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Fingerprint identifies the state of a cached object across restarts
+type Fingerprint struct {
+	Hash            string // Changes when the object is changed by users, but not with its status
+	ResourceVersion uint64 // 0 unless the resource version is a number
+}
+
+// ScopeKey identifies the informer of a kind in a namespace, "" for
+// cluster-scoped kinds, as Kind/namespace. It is the prefix of the
+// Kind/namespace/name keys of the objects in its cache.
+func ScopeKey(kind, namespace string) string {
+	return kind + "/" + namespace
+}
+
+// Progress is how far the informer of a kind has followed its resources. Any
+// object with a higher resource version was changed after the progress.
+type Progress struct {
+	ResourceVersion uint64    `json:"resourceVersion"`     // Highest resource version notified, including the initial list
+	LastEvent       time.Time `json:"lastEvent,omitempty"` // Last add, update or delete notification, including resyncs
+}
+
+// parseResourceVersion returns the resource version as a number, or 0. The API
+// server declares resource versions opaque, but they are etcd revisions,
+// increasing across all objects, so they are comparable in practice.
+func parseResourceVersion(resourceVersion string) uint64 {
+	version, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// Synced reports whether the informers of all watched kinds have loaded their
+// initial list into the cache. It is false until Start has registered them.
+func (w *Watcher) Synced() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.started.IsZero() {
+		return false
+	}
+	for _, s := range w.informers {
+		if !s.informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Progress returns the progress of the informer of every watched kind and
+// namespace, keyed by ScopeKey
+func (w *Watcher) Progress() map[string]Progress {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	progress := make(map[string]Progress, len(w.informers))
+	for key, s := range w.informers {
+		p := Progress{ResourceVersion: s.resourceVersion.Load()}
+		if ns := s.lastEvent.Load(); ns != 0 {
+			p.LastEvent = time.Unix(0, ns)
+		}
+		progress[key] = p
+	}
+	return progress
+}
+
+// states returns the informers of the watched kinds and namespaces
+func (w *Watcher) states() []*informerState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	states := make([]*informerState, 0, len(w.informers))
+	for _, s := range w.informers {
+		states = append(states, s)
+	}
+	return states
+}
+
+// Keys returns the Kind/namespace/name of every cached object; the namespace
+// is empty for cluster-scoped kinds. It is cheaper than Fingerprints.
+func (w *Watcher) Keys() []string {
+	var keys []string
+	for _, s := range w.states() {
+		for _, key := range s.informer.GetStore().ListKeys() {
+			if !strings.Contains(key, "/") {
+				key = "/" + key
+			}
+			keys = append(keys, s.kind+"/"+key)
+		}
+	}
+	return keys
+}
+
+// Fingerprints returns the fingerprint of every cached object, keyed by
+// Kind/namespace/name. The hash covers the labels, annotations and spec or
+// data of the object, not its status; Secrets are hashed by their resource
+// version, so that their data is not hashed.
+func (w *Watcher) Fingerprints() map[string]Fingerprint {
+	fingerprints := make(map[string]Fingerprint)
+	for _, s := range w.states() {
+		kind := s.kind
+		for _, obj := range s.informer.GetStore().List() {
+			object, ok := obj.(runtime.Object)
+			if !ok {
+				continue
+			}
+			accessor, err := meta.Accessor(object)
+			if err != nil {
+				continue
+			}
+			fingerprints[kind+"/"+accessor.GetNamespace()+"/"+accessor.GetName()] = Fingerprint{
+				Hash:            fingerprint(kind, object, accessor.GetResourceVersion()),
+				ResourceVersion: parseResourceVersion(accessor.GetResourceVersion()),
+			}
+		}
+	}
+	return fingerprints
+}
+
+// fingerprint hashes the significant manifest of the object, falling back to
+// its resource version
+func fingerprint(kind string, obj runtime.Object, resourceVersion string) string {
+	if kind == "Secret" {
+		return resourceVersion
+	}
+	manifest, err := significantManifest(obj)
+	if err != nil {
+		return resourceVersion
+	}
+	sum := sha256.Sum256([]byte(manifest))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package watcher
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFingerprint(t *testing.T) {
+	replicas := int32(2)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", ResourceVersion: "1"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	base := fingerprint("Deployment", d, d.ResourceVersion)
+
+	// ステータスだけの変更ではフィンガープリントは変わらない
+	status := d.DeepCopy()
+	status.ResourceVersion = "2"
+	status.Status.ReadyReplicas = 2
+	if got := fingerprint("Deployment", status, status.ResourceVersion); got != base {
+		t.Error("Expected a status update to keep the fingerprint")
+	}
+
+	scaled := d.DeepCopy()
+	scaled.ResourceVersion = "3"
+	replicas = 3
+	scaled.Spec.Replicas = &replicas
+	if got := fingerprint("Deployment", scaled, scaled.ResourceVersion); got == base {
+		t.Error("Expected a spec update to change the fingerprint")
+	}
+
+	// Secretはデータをハッシュせずリソースバージョンを使う
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "default", ResourceVersion: "7"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	if got := fingerprint("Secret", secret, secret.ResourceVersion); got != "7" {
+		t.Errorf("Expected the resource version as the fingerprint of a Secret, got %q", got)
+	}
+}
+
+func TestInformerState_Advance(t *testing.T) {
+	s := &informerState{}
+	for _, rv := range []string{"12", "30", "25", "not-a-number"} {
+		s.advance(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", ResourceVersion: rv}})
+	}
+	// 通知されたリソースバージョンの最大値だけを保持する
+	if got := s.resourceVersion.Load(); got != 30 {
+		t.Errorf("Expected the highest resource version 30, got %d", got)
+	}
+}
@@ -0,0 +1,110 @@
+package watcher
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerStatus is the state of the informer of a kind in a namespace
+type InformerStatus struct {
+	Kind      string
+	Namespace string    // "" for cluster-scoped kinds
+	Synced    bool      // The initial list has been loaded into the cache
+	Objects   int       // Objects in the cache
+	Started   time.Time // When the informer was started
+	LastEvent time.Time // Last add, update or delete notification, including resyncs; zero if none
+}
+
+// Name identifies the informer in alerts: the kind, followed by the namespace
+// for namespaced kinds, e.g. "Pod in prod"
+func (s InformerStatus) Name() string {
+	if s.Namespace == "" {
+		return s.Kind
+	}
+	return s.Kind + " in " + s.Namespace
+}
+
+// informerState tracks the activity of the informer of a kind in a namespace
+type informerState struct {
+	informer        cache.SharedIndexInformer
+	kind, namespace string
+	lastEvent       atomic.Int64  // Unix nanoseconds
+	resourceVersion atomic.Uint64 // Highest resource version notified
+}
+
+// touch records that the informer delivered a notification
+func (s *informerState) touch() {
+	s.lastEvent.Store(time.Now().UnixNano())
+}
+
+// advance raises the highest resource version notified to that of obj
+func (s *informerState) advance(obj interface{}) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	version := parseResourceVersion(accessor.GetResourceVersion())
+	for {
+		current := s.resourceVersion.Load()
+		if version <= current || s.resourceVersion.CompareAndSwap(current, version) {
+			return
+		}
+	}
+}
+
+// Informers returns the state of the informers, ordered by kind and
+// namespace. It is empty until Start has registered them.
+func (w *Watcher) Informers() []InformerStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	statuses := make([]InformerStatus, 0, len(w.informers))
+	for _, s := range w.informers {
+		status := InformerStatus{
+			Kind:      s.kind,
+			Namespace: s.namespace,
+			Synced:    s.informer.HasSynced(),
+			Objects:   len(s.informer.GetStore().ListKeys()),
+			Started:   w.started,
+		}
+		if ns := s.lastEvent.Load(); ns != 0 {
+			status.LastEvent = time.Unix(0, ns)
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Kind != statuses[j].Kind {
+			return statuses[i].Kind < statuses[j].Kind
+		}
+		return statuses[i].Namespace < statuses[j].Namespace
+	})
+	return statuses
+}
+
+// Cached returns the objects of a kind in the caches of its informers as
+// events without an event type, ordered by namespace and name, e.g. to check
+// their state periodically. It is empty unless the kind is watched.
+func (w *Watcher) Cached(kind string) []*Event {
+	var events []*Event
+	for _, state := range w.states() {
+		if state.kind != kind {
+			continue
+		}
+		for _, obj := range state.informer.GetStore().List() {
+			if event := w.convertToEvent(obj, kind, ""); event != nil {
+				events = append(events, event)
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Namespace != events[j].Namespace {
+			return events[i].Namespace < events[j].Namespace
+		}
+		return events[i].Name < events[j].Name
+	})
+	return events
+}
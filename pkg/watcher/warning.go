@@ -0,0 +1,25 @@
+package watcher
+
+// EventTypeWarning is the event type of the warnings synthesized by
+// kube-watcher, e.g. for CronJobs that missed their schedule. Unlike the other
+// event types, it does not correspond to a change of the resource.
+const EventTypeWarning = "WARNING"
+
+// Reasons of the warnings about CronJobs
+const (
+	ReasonMissedSchedule = "MissedSchedule" // No Job was started for a scheduled run
+	ReasonLongRunningJob = "LongRunningJob" // A Job has been running for longer than allowed
+)
+
+// Reasons of the warnings about PersistentVolumeClaims
+const (
+	ReasonClaimPending = "ClaimPending" // The claim has not been bound for longer than allowed
+	ReasonResizeFailed = "ResizeFailed" // Expanding the volume failed
+)
+
+// Reasons of the notifications about drained Nodes, which consolidate the
+// cordon of a Node and the Pod evictions from it
+const (
+	ReasonNodeCordoned = "NodeCordoned" // The Node was cordoned without Pods being evicted
+	ReasonNodeDrained  = "NodeDrained"  // Pods were evicted from the Node
+)
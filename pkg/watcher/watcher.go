@@ -4,24 +4,42 @@ package watcher
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/kqns91/kube-watcher/pkg/config"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/reference"
 )
 
 // ContainerInfo represents container information
 type ContainerInfo struct {
-	Name  string
-	Image string
+	Name      string
+	Image     string
+	Reason    string            // Why the container is failing (CrashLoopBackOff or OOMKilled); Pods only
+	Resources map[string]string // Requests and limits keyed like "requests.cpu"; workloads only
+	Restarts  int32             // Restart count of the container; Pods only
+}
+
+// ContainerLogs is the tail of the log of a failing container
+type ContainerLogs struct {
+	Container string
+	Previous  bool // Read from the previous, terminated instance of the container
+	Text      string
 }
 
 // ReplicaInfo represents replica information
@@ -31,15 +49,43 @@ type ReplicaInfo struct {
 	Current int32
 }
 
+// OwnerRef identifies the controller of a resource, e.g. the ReplicaSet of a Pod
+type OwnerRef struct {
+	Kind string
+	Name string
+}
+
+// Severity is the internal severity of an event and its counterparts in the
+// paging integrations
+type Severity struct {
+	Level        string // "critical" | "warning" | "info"
+	PagerDuty    string // PagerDuty Events API v2 severity
+	Opsgenie     string // Opsgenie alert priority, e.g. P1
+	Alertmanager string // Value of the severity label of Alertmanager alerts
+}
+
 // Event represents a Kubernetes resource event
 type Event struct {
+	ID        string // Assigned by the pipeline to notified events, e.g. to acknowledge them
+	Cluster   string // Name of the cluster the event originates from
 	Kind      string
 	Namespace string
 	Name      string
 	EventType string
 	Timestamp time.Time
-	Object    runtime.Object
+	CreatedAt time.Time               // Creation time of the resource
+	Ref       *corev1.ObjectReference // The involved object; the object itself is not retained
 	Labels    map[string]string
+	Owner     *OwnerRef      // Controller of the resource; nil when it has none
+	NodeName  string         // Node the Pod is scheduled to; Pods only
+	Logs      *ContainerLogs // Attached by the pipeline to failing Pods when podLogs is enabled
+	Warnings  []RelatedEvent // Attached by the pipeline when relatedEvents is enabled
+	Manifest  string         // YAML of the object; attached by the pipeline when manifests is enabled
+	Severity  *Severity      // Assigned by the pipeline when severity is enabled
+
+	// ManifestDiff is the unified diff of the old and new manifest of an
+	// UPDATED event when manifestDiff is enabled for the kind
+	ManifestDiff string
 
 	// Additional information
 	Reason      string
@@ -48,6 +94,38 @@ type Event struct {
 	Containers  []ContainerInfo
 	Replicas    *ReplicaInfo
 	ServiceType string
+	Addresses   []string      // External IPs and hostnames; LoadBalancer Services and Ingresses only
+	Scaling     *ScalingInfo  // HorizontalPodAutoscalers only
+	Job         *JobInfo      // Jobs only
+	CronJob     *CronJobInfo  // CronJobs only
+	Volume      *VolumeInfo   // PersistentVolumeClaims only
+	Rollback    *RollbackInfo // Deployments only, when the update restored an earlier revision
+
+	// Changes lists the field changes of an UPDATED event
+	Changes []FieldChange
+}
+
+// Workload returns the top-level workload of the resource: the Deployment of
+// the Pods of its ReplicaSets, the controller of other resources, or the
+// resource itself when it has no controller
+func (e *Event) Workload() (kind, name string) {
+	if e.Owner == nil {
+		return e.Kind, e.Name
+	}
+	if e.Kind == "Pod" && e.Owner.Kind == "ReplicaSet" {
+		// The ReplicaSets of a Deployment are named after it with the pod template hash
+		if i := strings.LastIndex(e.Owner.Name, "-"); i > 0 {
+			return "Deployment", e.Owner.Name[:i]
+		}
+	}
+	return e.Owner.Kind, e.Owner.Name
+}
+
+// LogAttrs returns the log attributes identifying the event followed by extra
+// key-value pairs, so that all log lines about events use the same fields
+func (e *Event) LogAttrs(extra ...any) []any {
+	attrs := []any{"kind", e.Kind, "namespace", e.Namespace, "name", e.Name, "eventType", e.EventType}
+	return append(attrs, extra...)
 }
 
 // EventHandler is a function that handles resource events
@@ -55,15 +133,29 @@ type EventHandler func(event *Event)
 
 // Watcher watches Kubernetes resources and triggers events
 type Watcher struct {
-	clientset *kubernetes.Clientset
-	config    *config.Config
-	handler   EventHandler
-	stopCh    chan struct{}
+	clientset    *kubernetes.Clientset
+	config       *config.Config
+	handler      EventHandler
+	errorHandler WatchErrorHandler
+	stopCh       chan struct{}
+	rollbacks    rollbackTracker
+
+	mu        sync.Mutex                // Protects the fields below
+	informers map[string]*informerState // ScopeKey of the kind and namespace -> informer
+	started   time.Time
 }
 
-// NewWatcher creates a new Watcher instance
-func NewWatcher(cfg *config.Config, handler EventHandler) (*Watcher, error) {
-	// Try in-cluster config first, fall back to kubeconfig
+// WatchErrorHandler is called when an informer's list or watch fails.
+// The informer keeps retrying with backoff.
+type WatchErrorHandler func(kind string, err error)
+
+// SetWatchErrorHandler sets the handler for list and watch failures. It must be called before Start.
+func (w *Watcher) SetWatchErrorHandler(handler WatchErrorHandler) {
+	w.errorHandler = handler
+}
+
+// RESTConfig returns the in-cluster configuration, or the kubeconfig when not running in a cluster
+func RESTConfig() (*rest.Config, error) {
 	k8sConfig, err := rest.InClusterConfig()
 	if err != nil {
 		// Try loading from kubeconfig
@@ -75,6 +167,27 @@ func NewWatcher(cfg *config.Config, handler EventHandler) (*Watcher, error) {
 			return nil, fmt.Errorf("failed to create kubernetes config: %w", err)
 		}
 	}
+	return k8sConfig, nil
+}
+
+// CurrentNamespace returns the namespace kube-watcher runs in: the service account's
+// namespace in a cluster, or the kubeconfig context's namespace
+func CurrentNamespace() string {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil || namespace == "" {
+		return "default"
+	}
+	return namespace
+}
+
+// NewWatcher creates a new Watcher instance
+func NewWatcher(cfg *config.Config, handler EventHandler) (*Watcher, error) {
+	k8sConfig, err := RESTConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	clientset, err := kubernetes.NewForConfig(k8sConfig)
 	if err != nil {
@@ -91,86 +204,163 @@ func NewWatcher(cfg *config.Config, handler EventHandler) (*Watcher, error) {
 
 // Start begins watching configured resources
 func (w *Watcher) Start(ctx context.Context) error {
-	factory := informers.NewSharedInformerFactoryWithOptions(
-		w.clientset,
-		time.Second*30,
-		informers.WithNamespace(w.config.Namespace),
-	)
-
-	// Register informers for each configured resource
+	// The informers of a namespace share a factory; cluster-scoped kinds are
+	// watched by a factory without a namespace
+	factories := make(map[string]informers.SharedInformerFactory)
 	for _, resource := range w.config.Resources {
-		if err := w.registerInformer(factory, resource.Kind); err != nil {
+		namespace := w.config.WatchNamespace(resource)
+		factory, ok := factories[namespace]
+		if !ok {
+			factory = informers.NewSharedInformerFactoryWithOptions(
+				w.clientset,
+				time.Second*30,
+				informers.WithNamespace(namespace),
+			)
+			factories[namespace] = factory
+		}
+		if err := w.registerInformer(factory, resource.Kind, namespace); err != nil {
 			return fmt.Errorf("failed to register informer for %s: %w", resource.Kind, err)
 		}
 	}
 
 	// Start all informers
-	factory.Start(w.stopCh)
+	w.mu.Lock()
+	w.started = time.Now()
+	w.mu.Unlock()
+	for _, factory := range factories {
+		factory.Start(w.stopCh)
+	}
 
 	// Wait for cache sync
-	factory.WaitForCacheSync(w.stopCh)
+	for _, factory := range factories {
+		factory.WaitForCacheSync(w.stopCh)
+	}
 
 	// Block until context is cancelled
 	<-ctx.Done()
 	close(w.stopCh)
 
+	// Wait for the informers to stop, including event handlers that are still running
+	for _, factory := range factories {
+		factory.Shutdown()
+	}
+
 	return nil
 }
 
-// registerInformer registers an informer for a specific resource kind
-func (w *Watcher) registerInformer(factory informers.SharedInformerFactory, kind string) error {
+// registerInformer registers an informer for a specific resource kind in the
+// namespace of the factory, "" for cluster-scoped kinds
+func (w *Watcher) registerInformer(factory informers.SharedInformerFactory, kind, namespace string) error {
+	var informer cache.SharedIndexInformer
 	switch kind {
 	case "Pod":
-		informer := factory.Core().V1().Pods().Informer()
-		informer.AddEventHandler(w.createEventHandler("Pod"))
+		informer = factory.Core().V1().Pods().Informer()
 	case "Deployment":
-		informer := factory.Apps().V1().Deployments().Informer()
-		informer.AddEventHandler(w.createEventHandler("Deployment"))
+		informer = factory.Apps().V1().Deployments().Informer()
 	case "Service":
-		informer := factory.Core().V1().Services().Informer()
-		informer.AddEventHandler(w.createEventHandler("Service"))
+		informer = factory.Core().V1().Services().Informer()
+	case "Ingress":
+		informer = factory.Networking().V1().Ingresses().Informer()
 	case "ConfigMap":
-		informer := factory.Core().V1().ConfigMaps().Informer()
-		informer.AddEventHandler(w.createEventHandler("ConfigMap"))
+		informer = factory.Core().V1().ConfigMaps().Informer()
 	case "Secret":
-		informer := factory.Core().V1().Secrets().Informer()
-		informer.AddEventHandler(w.createEventHandler("Secret"))
+		informer = factory.Core().V1().Secrets().Informer()
 	case "ReplicaSet":
-		informer := factory.Apps().V1().ReplicaSets().Informer()
-		informer.AddEventHandler(w.createEventHandler("ReplicaSet"))
+		informer = factory.Apps().V1().ReplicaSets().Informer()
 	case "StatefulSet":
-		informer := factory.Apps().V1().StatefulSets().Informer()
-		informer.AddEventHandler(w.createEventHandler("StatefulSet"))
+		informer = factory.Apps().V1().StatefulSets().Informer()
 	case "DaemonSet":
-		informer := factory.Apps().V1().DaemonSets().Informer()
-		informer.AddEventHandler(w.createEventHandler("DaemonSet"))
+		informer = factory.Apps().V1().DaemonSets().Informer()
+	case "HorizontalPodAutoscaler":
+		informer = factory.Autoscaling().V2().HorizontalPodAutoscalers().Informer()
+	case "Job":
+		informer = factory.Batch().V1().Jobs().Informer()
+	case "CronJob":
+		informer = factory.Batch().V1().CronJobs().Informer()
+	case "PersistentVolumeClaim":
+		informer = factory.Core().V1().PersistentVolumeClaims().Informer()
+	case "Node":
+		informer = factory.Core().V1().Nodes().Informer()
+	case "PersistentVolume":
+		informer = factory.Core().V1().PersistentVolumes().Informer()
+	case "Namespace":
+		informer = factory.Core().V1().Namespaces().Informer()
 	default:
 		return fmt.Errorf("unsupported resource kind: %s", kind)
 	}
 
+	state := &informerState{informer: informer, kind: kind, namespace: namespace}
+	if _, err := informer.AddEventHandler(w.createEventHandler(kind, state)); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	if w.informers == nil {
+		w.informers = make(map[string]*informerState)
+	}
+	w.informers[ScopeKey(kind, namespace)] = state
+	w.mu.Unlock()
+	if w.errorHandler != nil {
+		err := informer.SetWatchErrorHandlerWithContext(func(ctx context.Context, r *cache.Reflector, err error) {
+			cache.DefaultWatchErrorHandler(ctx, r, err)
+			w.errorHandler(kind, err)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// createEventHandler creates a ResourceEventHandler for a specific resource kind
-func (w *Watcher) createEventHandler(kind string) cache.ResourceEventHandler {
+// createEventHandler creates a ResourceEventHandler for a specific resource kind,
+// recording every notification in state
+func (w *Watcher) createEventHandler(kind string, state *informerState) cache.ResourceEventHandler {
 	return cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
+			state.touch()
+			state.advance(obj)
+			if d, ok := obj.(*appsv1.Deployment); ok {
+				w.rollbacks.observe(d)
+			}
 			event := w.convertToEvent(obj, kind, "ADDED")
 			if event != nil {
 				w.handler(event)
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Resyncs count as activity, even though they are not notified
+			state.touch()
+			state.advance(newObj)
+			// Rollbacks are detected before the revision of the new template is recorded
+			var rollback *RollbackInfo
+			if d, ok := newObj.(*appsv1.Deployment); ok {
+				if old, ok := oldObj.(*appsv1.Deployment); ok {
+					rollback = w.rollbacks.detect(old, d)
+				}
+				w.rollbacks.observe(d)
+			}
 			// Skip if there's no meaningful change
-			if !w.hasSignificantChange(oldObj, newObj) {
+			if rollback == nil && !w.hasSignificantChange(oldObj, newObj) {
 				return
 			}
 			event := w.convertToEvent(newObj, kind, "UPDATED")
 			if event != nil {
+				event.Changes = DiffEvents(w.convertToEvent(oldObj, kind, "UPDATED"), event)
+				event.ManifestDiff = w.manifestDiff(kind, oldObj, newObj)
+				if rollback != nil {
+					event.Rollback = rollback
+					event.Reason = ReasonRollback
+					event.Message = rollbackMessage(rollback)
+				}
 				w.handler(event)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
+			state.touch()
+			state.advance(obj)
+			if d, ok := obj.(*appsv1.Deployment); ok {
+				w.rollbacks.forget(d)
+			}
 			event := w.convertToEvent(obj, kind, "DELETED")
 			if event != nil {
 				w.handler(event)
@@ -196,10 +386,13 @@ func (w *Watcher) hasSignificantChange(oldObj, newObj interface{}) bool {
 	switch oldTyped := oldObj.(type) {
 	case *corev1.Pod:
 		newTyped := newObj.(*corev1.Pod)
-		// Only notify on status phase changes or container image changes
+		// Only notify on status phase changes, container failures or container image changes
 		if oldTyped.Status.Phase != newTyped.Status.Phase {
 			return true
 		}
+		if podFailureReason(oldTyped) != podFailureReason(newTyped) {
+			return true
+		}
 		// Check if any container image changed
 		if len(oldTyped.Spec.Containers) != len(newTyped.Spec.Containers) {
 			return true
@@ -230,7 +423,8 @@ func (w *Watcher) hasSignificantChange(oldObj, newObj interface{}) bool {
 				return true
 			}
 		}
-		return false
+		// Notify on container resource changes, a frequent cause of capacity incidents
+		return resourcesChanged(oldTyped.Spec.Template.Spec, newTyped.Spec.Template.Spec)
 
 	case *corev1.Service:
 		newTyped := newObj.(*corev1.Service)
@@ -242,7 +436,12 @@ func (w *Watcher) hasSignificantChange(oldObj, newObj interface{}) bool {
 		if len(oldTyped.Spec.Ports) != len(newTyped.Spec.Ports) {
 			return true
 		}
-		return false
+		// Notify when a load balancer address is assigned or removed, the moment the Service goes live
+		return !slices.Equal(serviceAddresses(oldTyped), serviceAddresses(newTyped))
+
+	case *networkingv1.Ingress:
+		// Notify when an address is assigned or removed, or the rules change
+		return ingressChanged(oldTyped, newObj.(*networkingv1.Ingress))
 
 	case *appsv1.ReplicaSet:
 		newTyped := newObj.(*appsv1.ReplicaSet)
@@ -264,10 +463,42 @@ func (w *Watcher) hasSignificantChange(oldObj, newObj interface{}) bool {
 		if oldTyped.Status.ReadyReplicas != newTyped.Status.ReadyReplicas {
 			return true
 		}
-		return false
+		return resourcesChanged(oldTyped.Spec.Template.Spec, newTyped.Spec.Template.Spec)
+
+	case *appsv1.DaemonSet:
+		// Notify on container resource changes only
+		return resourcesChanged(oldTyped.Spec.Template.Spec, newObj.(*appsv1.DaemonSet).Spec.Template.Spec)
+
+	case *autoscalingv2.HorizontalPodAutoscaler:
+		// Notify when the autoscaler scales, is reconfigured or changes conditions
+		return hpaChanged(oldTyped, newObj.(*autoscalingv2.HorizontalPodAutoscaler))
+
+	case *batchv1.Job:
+		// Notify when the Job finishes, is suspended or resumed, or a Pod of it fails
+		return jobChanged(oldTyped, newObj.(*batchv1.Job))
+
+	case *batchv1.CronJob:
+		// Notify when the schedule changes or the CronJob is suspended or resumed
+		return cronJobChanged(oldTyped, newObj.(*batchv1.CronJob))
+
+	case *corev1.PersistentVolumeClaim:
+		// Notify when the claim is bound or lost, resized, or a resize fails
+		return pvcChanged(oldTyped, newObj.(*corev1.PersistentVolumeClaim))
+
+	case *corev1.Node:
+		// Notify when the Node is cordoned or uncordoned
+		return nodeChanged(oldTyped, newObj.(*corev1.Node))
+
+	case *corev1.PersistentVolume:
+		// Notify when the volume is bound, released or failed
+		return oldTyped.Status.Phase != newObj.(*corev1.PersistentVolume).Status.Phase
+
+	case *corev1.Namespace:
+		// Notify when the namespace starts terminating
+		return oldTyped.Status.Phase != newObj.(*corev1.Namespace).Status.Phase
 
 	default:
-		// For ConfigMap, Secret, and DaemonSet, compare ResourceVersion only
+		// For ConfigMap and Secret, compare ResourceVersion only
 		// This reduces noise significantly
 		return false
 	}
@@ -281,7 +512,7 @@ func (w *Watcher) convertToEvent(obj interface{}, kind, eventType string) *Event
 		Kind:      kind,
 		EventType: eventType,
 		Timestamp: time.Now(),
-		Object:    obj.(runtime.Object),
+		Ref:       objectRef(obj),
 	}
 
 	// Extract metadata and additional information based on object type
@@ -292,11 +523,22 @@ func (w *Watcher) convertToEvent(obj interface{}, kind, eventType string) *Event
 		event.Status = string(o.Status.Phase)
 		event.Reason = o.Status.Reason
 		event.Message = o.Status.Message
+		event.NodeName = o.Spec.NodeName
+		if event.Reason == "" {
+			event.Reason = podFailureReason(o)
+		}
+		failures := containerFailures(o)
+		restarts := make(map[string]int32, len(o.Status.ContainerStatuses))
+		for _, s := range o.Status.ContainerStatuses {
+			restarts[s.Name] = s.RestartCount
+		}
 		// Extract container information
 		for _, container := range o.Spec.Containers {
 			event.Containers = append(event.Containers, ContainerInfo{
-				Name:  container.Name,
-				Image: container.Image,
+				Name:     container.Name,
+				Image:    container.Image,
+				Reason:   failures[container.Name],
+				Restarts: restarts[container.Name],
 			})
 		}
 
@@ -309,12 +551,7 @@ func (w *Watcher) convertToEvent(obj interface{}, kind, eventType string) *Event
 			Current: o.Status.Replicas,
 		}
 		// Extract container information from template
-		for _, container := range o.Spec.Template.Spec.Containers {
-			event.Containers = append(event.Containers, ContainerInfo{
-				Name:  container.Name,
-				Image: container.Image,
-			})
-		}
+		event.Containers = templateContainers(o.Spec.Template.Spec)
 		// Check deployment status
 		for _, cond := range o.Status.Conditions {
 			if cond.Type == appsv1.DeploymentProgressing {
@@ -329,6 +566,16 @@ func (w *Watcher) convertToEvent(obj interface{}, kind, eventType string) *Event
 		meta = o
 		labels = o.Labels
 		event.ServiceType = string(o.Spec.Type)
+		if o.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			event.Addresses = serviceAddresses(o)
+			event.Status = addressStatus(event.Addresses)
+		}
+
+	case *networkingv1.Ingress:
+		meta = o
+		labels = o.Labels
+		event.Addresses = ingressAddresses(o)
+		event.Status = addressStatus(event.Addresses)
 
 	case *corev1.ConfigMap:
 		meta = o
@@ -355,10 +602,72 @@ func (w *Watcher) convertToEvent(obj interface{}, kind, eventType string) *Event
 			Ready:   o.Status.ReadyReplicas,
 			Current: o.Status.Replicas,
 		}
+		event.Containers = templateContainers(o.Spec.Template.Spec)
 
 	case *appsv1.DaemonSet:
 		meta = o
 		labels = o.Labels
+		event.Containers = templateContainers(o.Spec.Template.Spec)
+
+	case *autoscalingv2.HorizontalPodAutoscaler:
+		meta = o
+		labels = o.Labels
+		event.Scaling = hpaScaling(o)
+		event.Reason, event.Message = hpaReason(o)
+
+	case *batchv1.Job:
+		meta = o
+		labels = o.Labels
+		event.Job = jobInfo(o)
+		event.Status = jobStatus(o)
+		if c := jobCondition(o); c != nil {
+			event.Reason = c.Reason
+			event.Message = c.Message
+		}
+		for _, container := range o.Spec.Template.Spec.Containers {
+			event.Containers = append(event.Containers, ContainerInfo{
+				Name:  container.Name,
+				Image: container.Image,
+			})
+		}
+
+	case *batchv1.CronJob:
+		meta = o
+		labels = o.Labels
+		event.CronJob = cronJobInfo(o)
+		event.Status = "Scheduled"
+		if event.CronJob.Suspended {
+			event.Status = "Suspended"
+		}
+		for _, container := range o.Spec.JobTemplate.Spec.Template.Spec.Containers {
+			event.Containers = append(event.Containers, ContainerInfo{
+				Name:  container.Name,
+				Image: container.Image,
+			})
+		}
+
+	case *corev1.PersistentVolumeClaim:
+		meta = o
+		labels = o.Labels
+		event.Volume = pvcInfo(o)
+		event.Status = string(o.Status.Phase)
+
+	case *corev1.Node:
+		meta = o
+		labels = o.Labels
+		event.Status = nodeStatus(o)
+
+	case *corev1.PersistentVolume:
+		meta = o
+		labels = o.Labels
+		event.Status = string(o.Status.Phase)
+		event.Reason = o.Status.Reason
+		event.Message = o.Status.Message
+
+	case *corev1.Namespace:
+		meta = o
+		labels = o.Labels
+		event.Status = string(o.Status.Phase)
 
 	default:
 		return nil
@@ -366,12 +675,100 @@ func (w *Watcher) convertToEvent(obj interface{}, kind, eventType string) *Event
 
 	event.Namespace = meta.GetNamespace()
 	event.Name = meta.GetName()
+	event.CreatedAt = meta.GetCreationTimestamp().Time
 	event.Labels = labels
+	if ref := metav1.GetControllerOf(meta); ref != nil {
+		event.Owner = &OwnerRef{Kind: ref.Kind, Name: ref.Name}
+	}
 
 	return event
 }
 
+// objectRef returns a reference to a Kubernetes object. Events keep the
+// reference rather than the object, so that events held in batches or stories
+// do not pin the full objects in memory.
+func objectRef(obj interface{}) *corev1.ObjectReference {
+	o, ok := obj.(runtime.Object)
+	if !ok {
+		return nil
+	}
+	ref, err := reference.GetReference(scheme.Scheme, o)
+	if err != nil {
+		return nil
+	}
+	return ref
+}
+
+// DetectClusterName returns the cluster name of the current kubeconfig context.
+// It returns an empty string when running in-cluster or when no context is configured.
+func DetectClusterName() string {
+	if _, err := rest.InClusterConfig(); err == nil {
+		return ""
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rawConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return ""
+	}
+
+	if ctx, ok := rawConfig.Contexts[rawConfig.CurrentContext]; ok && ctx.Cluster != "" {
+		return ctx.Cluster
+	}
+	return rawConfig.CurrentContext
+}
+
 // Stop stops the watcher
 func (w *Watcher) Stop() {
 	close(w.stopCh)
 }
+
+// Container failure reasons reported for Pods
+const (
+	ReasonCrashLoopBackOff = "CrashLoopBackOff"
+	ReasonOOMKilled        = "OOMKilled"
+)
+
+// containerFailures returns the failure reason of each failing container of
+// the Pod. OOMKilled takes precedence, as it explains a crash loop.
+func containerFailures(pod *corev1.Pod) map[string]string {
+	failures := make(map[string]string)
+	for _, s := range pod.Status.ContainerStatuses {
+		switch {
+		case s.State.Terminated != nil && s.State.Terminated.Reason == ReasonOOMKilled,
+			s.LastTerminationState.Terminated != nil && s.LastTerminationState.Terminated.Reason == ReasonOOMKilled:
+			failures[s.Name] = ReasonOOMKilled
+		case s.State.Waiting != nil && s.State.Waiting.Reason == ReasonCrashLoopBackOff:
+			failures[s.Name] = ReasonCrashLoopBackOff
+		}
+	}
+	return failures
+}
+
+// podFailureReason returns the failure reason of the first failing container
+// of the Pod, in spec order, or ""
+func podFailureReason(pod *corev1.Pod) string {
+	failures := containerFailures(pod)
+	for _, c := range pod.Spec.Containers {
+		if reason := failures[c.Name]; reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// ContainerLogs returns the last tailLines lines, at most limitBytes, of the log
+// of a container. With previous, the log of the previous instance is read, e.g.
+// of a container in a crash loop. Requires the get permission on pods/log.
+func (w *Watcher) ContainerLogs(ctx context.Context, namespace, pod, container string, tailLines, limitBytes int64, previous bool) (string, error) {
+	data, err := w.clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container:  container,
+		TailLines:  &tailLines,
+		LimitBytes: &limitBytes,
+		Previous:   previous,
+	}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of %s/%s[%s]: %w", namespace, pod, container, err)
+	}
+	return string(data), nil
+}